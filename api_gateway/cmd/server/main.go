@@ -13,31 +13,46 @@ import (
 
 
 func main() {
+	// load the jwt signing keys, fail fast if they are missing or too short
+	jwtKeys, err := config.LoadJWTKeys()
+	if err != nil {
+		log.Fatalf("failed to load the jwt signing keys with error: %s", err.Error())
+	}
+	// load the number of requests that each client can have in flight at once
+	maxInFlightPerClient, err := config.LoadMaxInFlightPerClient()
+	if err != nil {
+		log.Fatalf("failed to load the concurrency limit with error: %s", err.Error())
+	}
+	// load the users that can call admin only routes
+	adminUserIds, err := config.LoadAdminUserIds()
+	if err != nil {
+		log.Fatalf("failed to load the admin user ids with error: %s", err.Error())
+	}
+	// load the tls configuration of the backend services, nil keeps a service on plaintext
+	userServiceTLS, err := config.LoadServiceTLSConfig("USER_SERVICE")
+	if err != nil {
+		log.Fatalf("failed to load the user service tls configuration with error: %s", err.Error())
+	}
+	documentServiceTLS, err := config.LoadServiceTLSConfig("DOCUMENT_SERVICE")
+	if err != nil {
+		log.Fatalf("failed to load the document service tls configuration with error: %s", err.Error())
+	}
 	// create a client that can be used to access the user service
-	userServiceClient, err := usClient.NewUserServiceClient(config.UserServiceAddr)
+	userServiceClient, err := usClient.NewUserServiceClientWithTLS(config.UserServiceAddr, userServiceTLS)
 	if err != nil {
 		log.Fatalf("failed to create a user service client with error: %s", err.Error())
 	}
 	// create a client that can be used to access the document service
-	documentServiceClient, err := dsClient.NewDocumentServiceClient(config.DocumentServiceAddr)
+	documentServiceClient, err := dsClient.NewDocumentServiceClientWithTLS(config.DocumentServiceAddr, documentServiceTLS)
 	if err != nil {
 		log.Fatalf("failed to create a document service client with error: %s", err.Error())
 	}
 	// create an instance of the struct which implements the server.ServerInterface
-	service := server.NewService(userServiceClient, documentServiceClient)
-	// create a request validation middleware
-	validationMiddleware := server.RequestValidationMiddleware()
-	// create an instance of the handler 
-	h := server.HandlerWithOptions(
-		&service, server.StdHTTPServerOptions{
-			BaseURL: "todo",
-			Middlewares: []server.MiddlewareFunc{
-				server.AuthMiddleware,
-				validationMiddleware, 
-			},
-			ErrorHandlerFunc: server.ErrorHandlerFunc,
-		},
-	)
+	service := server.NewService(userServiceClient, documentServiceClient, jwtKeys, adminUserIds)
+	// create an instance of the handler with the auth and request validation middlewares
+	h := server.NewHandler(&service)
+	// limit the requests that each client has in flight before any other middleware runs
+	h = server.NewConcurrencyLimiter(maxInFlightPerClient).Middleware(h)
 	// create a net/http server from this handler
 	s := &http.Server{
 		Handler: h,
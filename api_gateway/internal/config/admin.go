@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/api_gateway/internal/util"
+)
+
+// load the ids of the users that can call admin routes from ADMIN_USER_IDS, separated by
+// commas. No user is an admin when it is not set. The gateway should fail to start if this
+// returns an error
+func LoadAdminUserIds() ([]uuid.UUID, error) {
+	value := util.GetEnvWithDefault("ADMIN_USER_IDS", "")
+	var adminUserIds []uuid.UUID
+	for _, id := range strings.Split(value, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		parsedId, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse admin user id: %s in ADMIN_USER_IDS: %w", id, err)
+		}
+		adminUserIds = append(adminUserIds, parsedId)
+	}
+	return adminUserIds, nil
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLoadAdminUserIds_Unit(t *testing.T) {
+	t.Setenv("ADMIN_USER_IDS", "")
+	adminUserIds, err := LoadAdminUserIds()
+	if err != nil || len(adminUserIds) != 0 {
+		t.Errorf("want no admins, got: %v with error: %v", adminUserIds, err)
+	}
+	first, second := uuid.New(), uuid.New()
+	t.Setenv("ADMIN_USER_IDS", first.String()+", "+second.String()+",")
+	adminUserIds, err = LoadAdminUserIds()
+	if err != nil || len(adminUserIds) != 2 || adminUserIds[0] != first || adminUserIds[1] != second {
+		t.Errorf("want admins: %v, got: %v with error: %v", []uuid.UUID{ first, second }, adminUserIds, err)
+	}
+	t.Setenv("ADMIN_USER_IDS", first.String()+",not-a-uuid")
+	if _, err := LoadAdminUserIds(); err == nil {
+		t.Errorf("expected an error for a malformed admin user id")
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/townsag/reed/api_gateway/internal/util"
+)
+
+// the number of requests that a single client can have in flight at once when
+// MAX_IN_FLIGHT_PER_CLIENT is not set
+const DefaultMaxInFlightPerClient = 32
+
+// load the in flight budget of each client from MAX_IN_FLIGHT_PER_CLIENT, the gateway should
+// fail to start if this returns an error
+func LoadMaxInFlightPerClient() (int, error) {
+	value := util.GetEnvWithDefault("MAX_IN_FLIGHT_PER_CLIENT", strconv.Itoa(DefaultMaxInFlightPerClient))
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse MAX_IN_FLIGHT_PER_CLIENT: %w", err)
+	}
+	if limit < 1 {
+		return 0, fmt.Errorf("MAX_IN_FLIGHT_PER_CLIENT must be at least 1, got: %d", limit)
+	}
+	return limit, nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestLoadMaxInFlightPerClient_Unit(t *testing.T) {
+	t.Setenv("MAX_IN_FLIGHT_PER_CLIENT", "")
+	limit, err := LoadMaxInFlightPerClient()
+	if err != nil || limit != DefaultMaxInFlightPerClient {
+		t.Errorf("want the default limit: %d, got: %d with error: %v", DefaultMaxInFlightPerClient, limit, err)
+	}
+	t.Setenv("MAX_IN_FLIGHT_PER_CLIENT", "4")
+	limit, err = LoadMaxInFlightPerClient()
+	if err != nil || limit != 4 {
+		t.Errorf("want limit: 4, got: %d with error: %v", limit, err)
+	}
+	for _, value := range []string{ "0", "-1", "many" } {
+		t.Setenv("MAX_IN_FLIGHT_PER_CLIENT", value)
+		if _, err := LoadMaxInFlightPerClient(); err == nil {
+			t.Errorf("expected an error for MAX_IN_FLIGHT_PER_CLIENT: %s", value)
+		}
+	}
+}
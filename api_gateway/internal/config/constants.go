@@ -14,6 +14,12 @@ var DocumentServiceAddr string = util.GetEnvWithDefault(
 
 const TIMEOUT_MILLISECONDS = 500 * time.Millisecond
 
-var JWTSecretKey string = util.GetEnvWithDefault(
-	"JWT_SIGNING_KEY", "asdf",
-)
\ No newline at end of file
+// reassigning the documents of a user moves them in batches until none are left, which takes
+// far longer than a single request for a user that owns many documents. This matches the
+// default max handler timeout of the document service
+const REASSIGN_DOCUMENTS_TIMEOUT = 30 * time.Second
+
+// the page size used by paginated routes when the client does not send a limit, and the largest
+// page size that a client can ask for. These match the defaults of the document service
+const DefaultPageLimit int32 = 10
+const MaxPageLimit int32 = 100
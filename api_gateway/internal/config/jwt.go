@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// HS256 keys should be at least as long as the output of the hash function
+const MinJWTKeyLength = 32
+
+type JWTKeys struct {
+	// new tokens are signed with the current key
+	Current []byte
+	// tokens signed with a previous key still verify, this allows the signing key to be
+	// rotated without invalidating tokens that are still live
+	Previous [][]byte
+}
+
+// the keys that a token can be verified with, the current key comes first
+func (k *JWTKeys) VerificationKeys() [][]byte {
+	return append([][]byte{k.Current}, k.Previous...)
+}
+
+// read a value from the environment variable or from the file at the path in the
+// environment variable with a _FILE suffix. Files are how secret managers usually
+// mount secrets into a container
+func readSecret(key string) (string, error) {
+	if value := os.Getenv(key); value != "" {
+		return value, nil
+	}
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", nil
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// load the jwt signing keys:
+// - the current key is read from JWT_SIGNING_KEY or JWT_SIGNING_KEY_FILE
+// - the previous keys are read from JWT_PREVIOUS_SIGNING_KEYS or JWT_PREVIOUS_SIGNING_KEYS_FILE,
+//   separated by commas or new lines
+// the gateway should fail to start if this returns an error
+func LoadJWTKeys() (*JWTKeys, error) {
+	current, err := readSecret("JWT_SIGNING_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if len(current) < MinJWTKeyLength {
+		return nil, fmt.Errorf(
+			"the jwt signing key must be at least %d bytes, got %d bytes", MinJWTKeyLength, len(current),
+		)
+	}
+	keys := &JWTKeys{ Current: []byte(current) }
+	previous, err := readSecret("JWT_PREVIOUS_SIGNING_KEYS")
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range strings.FieldsFunc(previous, func(r rune) bool { return r == ',' || r == '\n' }) {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if len(key) < MinJWTKeyLength {
+			return nil, fmt.Errorf(
+				"previous jwt signing keys must be at least %d bytes, got %d bytes", MinJWTKeyLength, len(key),
+			)
+		}
+		keys.Previous = append(keys.Previous, []byte(key))
+	}
+	return keys, nil
+}
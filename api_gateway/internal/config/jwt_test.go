@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadJWTKeys_FromEnv_Unit(t *testing.T) {
+	current := strings.Repeat("c", MinJWTKeyLength)
+	previous := strings.Repeat("p", MinJWTKeyLength)
+	t.Setenv("JWT_SIGNING_KEY", current)
+	t.Setenv("JWT_PREVIOUS_SIGNING_KEYS", previous+","+strings.Repeat("q", MinJWTKeyLength))
+	keys, err := LoadJWTKeys()
+	if err != nil {
+		t.Fatalf("failed to load jwt keys with error: %v", err)
+	}
+	if string(keys.Current) != current {
+		t.Errorf("want current key: %s, got: %s", current, keys.Current)
+	}
+	if len(keys.Previous) != 2 || string(keys.Previous[0]) != previous {
+		t.Errorf("want two previous keys starting with: %s, got: %s", previous, keys.Previous)
+	}
+	if len(keys.VerificationKeys()) != 3 {
+		t.Errorf("want 3 verification keys, got: %d", len(keys.VerificationKeys()))
+	}
+}
+
+func TestLoadJWTKeys_FromFile_Unit(t *testing.T) {
+	current := strings.Repeat("f", MinJWTKeyLength)
+	path := filepath.Join(t.TempDir(), "jwt-signing-key")
+	if err := os.WriteFile(path, []byte(current+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write key file with error: %v", err)
+	}
+	t.Setenv("JWT_SIGNING_KEY", "")
+	t.Setenv("JWT_SIGNING_KEY_FILE", path)
+	keys, err := LoadJWTKeys()
+	if err != nil {
+		t.Fatalf("failed to load jwt keys with error: %v", err)
+	}
+	if string(keys.Current) != current {
+		t.Errorf("want current key: %s, got: %s", current, keys.Current)
+	}
+}
+
+func TestLoadJWTKeys_MissingOrShort_Unit(t *testing.T) {
+	t.Setenv("JWT_SIGNING_KEY", "")
+	t.Setenv("JWT_SIGNING_KEY_FILE", "")
+	if _, err := LoadJWTKeys(); err == nil {
+		t.Errorf("expected an error when the jwt signing key is missing")
+	}
+	t.Setenv("JWT_SIGNING_KEY", "asdf")
+	if _, err := LoadJWTKeys(); err == nil {
+		t.Errorf("expected an error when the jwt signing key is too short")
+	}
+}
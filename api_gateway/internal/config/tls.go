@@ -0,0 +1,28 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strconv"
+
+	"github.com/townsag/reed/api_gateway/internal/util"
+	"github.com/townsag/reed/user_service/pkg/middleware"
+)
+
+// read the tls configuration that the gateway uses to reach a backend service, prefix is the
+// prefix of the environment variables of the service, for example USER_SERVICE. The connection
+// is plaintext and nil is returned unless <prefix>_TLS is true or <prefix>_TLS_CA_FILE is set.
+// <prefix>_TLS_CA_FILE is the certificate that the server certificate is verified against, the
+// system roots are used when it is not set, and <prefix>_TLS_SERVER_NAME is the name that the
+// server certificate must be issued for. The gateway should fail to start if this returns an error
+func LoadServiceTLSConfig(prefix string) (*tls.Config, error) {
+	enabled, err := strconv.ParseBool(util.GetEnvWithDefault(prefix+"_TLS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s_TLS: %w", prefix, err)
+	}
+	caFile := util.GetEnvWithDefault(prefix+"_TLS_CA_FILE", "")
+	if !enabled && caFile == "" {
+		return nil, nil
+	}
+	return middleware.LoadClientTLSConfig(caFile, util.GetEnvWithDefault(prefix+"_TLS_SERVER_NAME", ""))
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadServiceTLSConfig_Unit(t *testing.T) {
+	t.Setenv("USER_SERVICE_TLS", "")
+	t.Setenv("USER_SERVICE_TLS_CA_FILE", "")
+	t.Setenv("USER_SERVICE_TLS_SERVER_NAME", "user-service")
+	tlsConfig, err := LoadServiceTLSConfig("USER_SERVICE")
+	if err != nil || tlsConfig != nil {
+		t.Errorf("want plaintext when tls is not enabled, got: %v with error: %v", tlsConfig, err)
+	}
+	// the system roots verify the server when no ca file is set
+	t.Setenv("USER_SERVICE_TLS", "true")
+	tlsConfig, err = LoadServiceTLSConfig("USER_SERVICE")
+	if err != nil || tlsConfig == nil || tlsConfig.RootCAs != nil || tlsConfig.ServerName != "user-service" {
+		t.Errorf("want tls with the system roots and the server name, got: %v with error: %v", tlsConfig, err)
+	}
+	t.Setenv("USER_SERVICE_TLS", "sometimes")
+	if _, err = LoadServiceTLSConfig("USER_SERVICE"); err == nil {
+		t.Errorf("expected an error for USER_SERVICE_TLS: sometimes")
+	}
+	// setting a ca file enables tls, a ca file that can not be read fails the gateway
+	t.Setenv("USER_SERVICE_TLS", "")
+	t.Setenv("USER_SERVICE_TLS_CA_FILE", filepath.Join(t.TempDir(), "missing.pem"))
+	if _, err = LoadServiceTLSConfig("USER_SERVICE"); err == nil {
+		t.Errorf("expected an error for a missing USER_SERVICE_TLS_CA_FILE")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/oapi-codegen/runtime"
@@ -38,38 +39,112 @@ const (
 	PrincipalTypeUser  PrincipalType = "user"
 )
 
-// CreatedAt Timestamp measured in milliseconds since Unix epoch (January 1, 1970, 00:00:00 UTC)
-type CreatedAt = int64
+// Defines values for PublicAccess.
+const (
+	PublicAccessEditor PublicAccess = "editor"
+	PublicAccessNone   PublicAccess = "none"
+	PublicAccessViewer PublicAccess = "viewer"
+)
+
+// AccessedDocument defines model for AccessedDocument.
+type AccessedDocument struct {
+	// AccessedAt the last time the caller opened the document
+	AccessedAt time.Time       `json:"accessedAt"`
+	Document   Document        `json:"document"`
+	Permission PermissionLevel `json:"permission"`
+}
+
+// CollaboratorPermissionLevel the permission levels that can be granted to a collaborator, ownership cannot be granted by sharing
+type CollaboratorPermissionLevel = PermissionLevel
+
+// Collection defines model for Collection.
+type Collection struct {
+	CollectionId openapi_types.UUID `json:"collectionId"`
+
+	// CreatedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
+	CreatedAt CreatedAt          `json:"createdAt"`
+	Name      string             `json:"name"`
+	OwnerId   openapi_types.UUID `json:"ownerId"`
+}
+
+// CreatedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
+type CreatedAt = time.Time
 
 // Document defines model for Document.
 type Document struct {
-	// CreatedAt Timestamp measured in milliseconds since Unix epoch (January 1, 1970, 00:00:00 UTC)
+	// ArchivedAt when the document was archived, clients that sync documents treat an archived document as deleted
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+
+	// CollaboratorCount the number of principals the document is shared with, not counting the owner. Only present when getting one document with includeCollaboratorCount
+	CollaboratorCount *int64 `json:"collaboratorCount,omitempty"`
+
+	// CreatedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
 	CreatedAt           CreatedAt          `json:"createdAt"`
 	DocumentDescription *string            `json:"documentDescription,omitempty"`
 	DocumentId          openapi_types.UUID `json:"documentId"`
 	DocumentName        *string            `json:"documentName,omitempty"`
 
-	// LastModifiedAt Timestamp measured in milliseconds since Unix epoch (January 1, 1970, 00:00:00 UTC)
+	// LastModifiedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
 	LastModifiedAt LastModifiedAt `json:"lastModifiedAt"`
+
+	// PermissionCreatedAt when the caller was granted their permission on the document, only present when listing documents
+	PermissionCreatedAt *time.Time `json:"permissionCreatedAt,omitempty"`
+
+	// PermissionLastModifiedAt when the caller's permission on the document was last changed, only present when listing documents
+	PermissionLastModifiedAt *time.Time       `json:"permissionLastModifiedAt,omitempty"`
+	PublicAccess             *PermissionLevel `json:"publicAccess,omitempty"`
+}
+
+// DocumentChange an update of the name or description of a document, a field that was not changed by the update has the same old and new value
+type DocumentChange struct {
+	// ActorId the principal that made the update
+	ActorId        openapi_types.UUID `json:"actorId"`
+	ChangeId       openapi_types.UUID `json:"changeId"`
+	ChangedAt      time.Time          `json:"changedAt"`
+	NewDescription *string            `json:"newDescription,omitempty"`
+	NewName        *string            `json:"newName,omitempty"`
+	OldDescription *string            `json:"oldDescription,omitempty"`
+	OldName        *string            `json:"oldName,omitempty"`
+}
+
+// DocumentTombstone defines model for DocumentTombstone.
+type DocumentTombstone struct {
+	DeletedAt  time.Time          `json:"deletedAt"`
+	DocumentId openapi_types.UUID `json:"documentId"`
 }
 
 // Error defines model for Error.
 type Error struct {
-	Message *string `json:"message,omitempty"`
+	// Fields maps each invalid request field to the reason it failed validation, or the field whose value is already taken to the reason of the conflict
+	Fields  *map[string]string `json:"fields,omitempty"`
+	Message *string            `json:"message,omitempty"`
 }
 
-// LastModifiedAt Timestamp measured in milliseconds since Unix epoch (January 1, 1970, 00:00:00 UTC)
-type LastModifiedAt = int64
+// LastModifiedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
+type LastModifiedAt = time.Time
+
+// PendingShare defines model for PendingShare.
+type PendingShare struct {
+	Document        Document        `json:"document"`
+	PermissionLevel PermissionLevel `json:"permissionLevel"`
+	SharedAt        time.Time       `json:"sharedAt"`
+
+	// SharedBy the user that offered the share
+	SharedBy openapi_types.UUID `json:"sharedBy"`
+}
 
 // Permission defines model for Permission.
 type Permission struct {
-	// CreatedAt Timestamp measured in milliseconds since Unix epoch (January 1, 1970, 00:00:00 UTC)
+	// CreatedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
 	CreatedAt  CreatedAt          `json:"createdAt"`
 	CreatedBy  openapi_types.UUID `json:"createdBy"`
 	DocumentId openapi_types.UUID `json:"documentId"`
 
-	// LastModifiedAt Timestamp measured in milliseconds since Unix epoch (January 1, 1970, 00:00:00 UTC)
-	LastModifiedAt  LastModifiedAt  `json:"lastModifiedAt"`
+	// LastModifiedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
+	LastModifiedAt LastModifiedAt `json:"lastModifiedAt"`
+
+	// Pending true while the principal has not accepted the share, a pending share grants no access
+	Pending         *bool           `json:"pending,omitempty"`
 	PermissionLevel PermissionLevel `json:"permissionLevel"`
 	Principal       Principal       `json:"principal"`
 }
@@ -86,70 +161,273 @@ type Principal struct {
 // PrincipalType defines model for PrincipalType.
 type PrincipalType string
 
+// PublicAccess the permission level granted to holders of a public link of a document, none disables the public link
+type PublicAccess string
+
+// SharedDocument defines model for SharedDocument.
+type SharedDocument struct {
+	// CollaboratorCount the number of principals the document is shared with, not counting the owner
+	CollaboratorCount int64    `json:"collaboratorCount"`
+	Document          Document `json:"document"`
+}
+
 // User defines model for User.
 type User struct {
-	Email        string             `json:"email"`
-	MaxDocuments int32              `json:"maxDocuments"`
-	UserId       openapi_types.UUID `json:"userId"`
-	UserName     string             `json:"userName"`
+	// CreatedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
+	CreatedAt CreatedAt `json:"createdAt"`
+	Email     string    `json:"email"`
+	IsActive  bool      `json:"isActive"`
+
+	// LastModifiedAt RFC3339 timestamp in UTC, includes fractional seconds when they are non zero
+	LastModifiedAt LastModifiedAt     `json:"lastModifiedAt"`
+	MaxDocuments   int32              `json:"maxDocuments"`
+	UserId         openapi_types.UUID `json:"userId"`
+	UserName       string             `json:"userName"`
 }
 
+// UserShareResult defines model for UserShareResult.
+type UserShareResult struct {
+	// Created true if the user did not have a permission on the document before and false if their existing permission was updated
+	Created bool               `json:"created"`
+	UserId  openapi_types.UUID `json:"userId"`
+}
+
+// CollectionId defines model for CollectionId.
+type CollectionId = openapi_types.UUID
+
 // DocumentId defines model for DocumentId.
 type DocumentId = openapi_types.UUID
 
+// GuestId defines model for GuestId.
+type GuestId = openapi_types.UUID
+
 // PrincipalId defines model for PrincipalId.
 type PrincipalId = openapi_types.UUID
 
 // UserId defines model for UserId.
 type UserId = openapi_types.UUID
 
+// AvailabilityResponse only the fields that were checked are set
+type AvailabilityResponse struct {
+	EmailAvailable    *bool `json:"emailAvailable,omitempty"`
+	UserNameAvailable *bool `json:"userNameAvailable,omitempty"`
+}
+
 // BadRequest defines model for BadRequest.
 type BadRequest = Error
 
+// CollectionResponse defines model for CollectionResponse.
+type CollectionResponse = Collection
+
+// CreateGuestsResponse defines model for CreateGuestsResponse.
+type CreateGuestsResponse struct {
+	GuestIds []openapi_types.UUID `json:"guestIds"`
+}
+
+// CurrentPrincipalResponse defines model for CurrentPrincipalResponse.
+type CurrentPrincipalResponse struct {
+	// ExpiresAt when the token expires
+	ExpiresAt     *time.Time         `json:"expiresAt,omitempty"`
+	PrincipalId   openapi_types.UUID `json:"principalId"`
+	PrincipalType PrincipalType      `json:"principalType"`
+	User          *User              `json:"user,omitempty"`
+
+	// UserName only present for user type tokens
+	UserName *string `json:"userName,omitempty"`
+}
+
+// EnsureOwnerResponse defines model for EnsureOwnerResponse.
+type EnsureOwnerResponse struct {
+	// Repaired true when the document had no owner and the fallback owner was made its owner
+	Repaired bool `json:"repaired"`
+}
+
+// GetDocumentCountsResponse archived documents are not counted
+type GetDocumentCountsResponse struct {
+	Editor int64 `json:"editor"`
+	Owner  int64 `json:"owner"`
+	Viewer int64 `json:"viewer"`
+}
+
+// GetDocumentHistoryResponse defines model for GetDocumentHistoryResponse.
+type GetDocumentHistoryResponse struct {
+	Changes []DocumentChange `json:"changes"`
+	Cursor  *string          `json:"cursor,omitempty"`
+
+	// HasMore false once there are no more changes after this page
+	HasMore bool `json:"hasMore"`
+
+	// Limit the page size that was used for this page after defaults and clamping
+	Limit int32 `json:"limit"`
+}
+
 // GetDocumentResponse defines model for GetDocumentResponse.
 type GetDocumentResponse struct {
 	Cursor    *string    `json:"cursor,omitempty"`
 	Documents []Document `json:"documents"`
+
+	// HasMore false once there are no more documents after this page
+	HasMore bool `json:"hasMore"`
+
+	// Limit the page size that was used for this page after defaults and clamping
+	Limit int32 `json:"limit"`
 }
 
 // GetPermissionOfPrincipalResponse defines model for GetPermissionOfPrincipalResponse.
 type GetPermissionOfPrincipalResponse = Permission
 
+// GetSharingSummaryResponse defines model for GetSharingSummaryResponse.
+type GetSharingSummaryResponse struct {
+	// CollaboratorCount the number of principals the document is shared with, not counting the owner
+	CollaboratorCount int64 `json:"collaboratorCount"`
+
+	// Collaborators the most recently modified collaborator permissions
+	Collaborators []Permission `json:"collaborators"`
+	Owner         Permission   `json:"owner"`
+}
+
+// ListPendingSharesResponse defines model for ListPendingSharesResponse.
+type ListPendingSharesResponse struct {
+	PendingShares []PendingShare `json:"pendingShares"`
+}
+
 // ListPermissionsOnDocumentResponse defines model for ListPermissionsOnDocumentResponse.
 type ListPermissionsOnDocumentResponse struct {
-	Cursor      *string       `json:"cursor,omitempty"`
+	Cursor *string `json:"cursor,omitempty"`
+
+	// HasMore false once there are no more permissions after this page
+	HasMore bool `json:"hasMore"`
+
+	// Limit the page size that was used for this page after defaults and clamping
+	Limit       int32         `json:"limit"`
 	Permissions []*Permission `json:"permissions"`
 }
 
+// ListRecentlyAccessedResponse defines model for ListRecentlyAccessedResponse.
+type ListRecentlyAccessedResponse struct {
+	Cursor    *string            `json:"cursor,omitempty"`
+	Documents []AccessedDocument `json:"documents"`
+
+	// HasMore false once there are no more documents after this page
+	HasMore bool `json:"hasMore"`
+
+	// Limit the page size that was used for this page after defaults and clamping
+	Limit int32 `json:"limit"`
+}
+
+// ListSharedDocumentsResponse defines model for ListSharedDocumentsResponse.
+type ListSharedDocumentsResponse struct {
+	Cursor *string `json:"cursor,omitempty"`
+
+	// HasMore false once there are no more documents after this page
+	HasMore bool `json:"hasMore"`
+
+	// Limit the page size that was used for this page after defaults and clamping
+	Limit           int32            `json:"limit"`
+	SharedDocuments []SharedDocument `json:"sharedDocuments"`
+}
+
 // LoginResponse defines model for LoginResponse.
 type LoginResponse struct {
 	ExpiresIn int32  `json:"expiresIn"`
 	Token     string `json:"token"`
 }
 
+// NotFound defines model for NotFound.
+type NotFound = Error
+
 // PostDocumentResponse defines model for PostDocumentResponse.
 type PostDocumentResponse struct {
 	DocumentId openapi_types.UUID `json:"documentId"`
 }
 
+// PostUserResponse defines model for PostUserResponse.
+type PostUserResponse struct {
+	User   User               `json:"user"`
+	UserId openapi_types.UUID `json:"userId"`
+}
+
+// ReassignDocumentsResponse defines model for ReassignDocumentsResponse.
+type ReassignDocumentsResponse struct {
+	// MovedCount the number of documents that now belong to the successor
+	MovedCount int64 `json:"movedCount"`
+}
+
+// RotateGuestLinkResponse defines model for RotateGuestLinkResponse.
+type RotateGuestLinkResponse struct {
+	// GuestToken a bearer token for the guest that expires after 30 days, the tokens issued before the rotation no longer work
+	GuestToken string `json:"guestToken"`
+}
+
+// SetPublicAccessResponse defines model for SetPublicAccessResponse.
+type SetPublicAccessResponse struct {
+	// PublicAccess the permission level granted to holders of a public link of a document, none disables the public link
+	PublicAccess PublicAccess `json:"publicAccess"`
+
+	// PublicLinkToken a bearer token that grants the public access level on the document, only present when the public link is enabled
+	PublicLinkToken *string `json:"publicLinkToken,omitempty"`
+}
+
 // ShareDocumentResponse defines model for ShareDocumentResponse.
 type ShareDocumentResponse struct {
-	GuestId          *openapi_types.UUID `json:"guestId,omitempty"`
+	// Created when sharing with a user, true if the user did not have a permission on the document before and false if their existing permission was updated
+	Created *bool               `json:"created,omitempty"`
+	GuestId *openapi_types.UUID `json:"guestId,omitempty"`
+
+	// GuestToken a bearer token for the guest, only present when a guest was created. The token expires after 30 days and stops working earlier once the link of the guest is rotated
+	GuestToken *string `json:"guestToken,omitempty"`
+
+	// Pending true when the user was offered a pending share that they have to accept
+	Pending          *bool               `json:"pending,omitempty"`
 	UserIdSharedWith *openapi_types.UUID `json:"userIdSharedWith,omitempty"`
 }
 
+// ShareDocumentUsersResponse defines model for ShareDocumentUsersResponse.
+type ShareDocumentUsersResponse struct {
+	// Results one result for each share in the order of the request
+	Results []UserShareResult `json:"results"`
+}
+
 // Unauthenticated defines model for Unauthenticated.
 type Unauthenticated = Error
 
 // Unauthorized defines model for Unauthorized.
 type Unauthorized = Error
 
+// GetAdminDocumentsParams defines parameters for GetAdminDocuments.
+type GetAdminDocumentsParams struct {
+	// Cursor the cursor returned by the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit the number of documents to retrieve in a page, defaults to 10. Values over 100 are clamped to 100, the page size that was used is returned in the response
+	Limit *int32 `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// OwnerId only documents owned by this user
+	OwnerId *openapi_types.UUID `form:"ownerId,omitempty" json:"ownerId,omitempty"`
+
+	// CreatedBefore only documents created before this time
+	CreatedBefore *time.Time `form:"createdBefore,omitempty" json:"createdBefore,omitempty"`
+
+	// CreatedAfter only documents created at or after this time
+	CreatedAfter *time.Time `form:"createdAfter,omitempty" json:"createdAfter,omitempty"`
+}
+
+// PostAdminDocumentsDocumentIdEnsureOwnerJSONBody defines parameters for PostAdminDocumentsDocumentIdEnsureOwner.
+type PostAdminDocumentsDocumentIdEnsureOwnerJSONBody struct {
+	FallbackOwnerId openapi_types.UUID `json:"fallbackOwnerId"`
+}
+
 // PostAuthLoginJSONBody defines parameters for PostAuthLogin.
 type PostAuthLoginJSONBody struct {
 	Password string `json:"password"`
 	UserName string `json:"userName"`
 }
 
+// PostCollectionJSONBody defines parameters for PostCollection.
+type PostCollectionJSONBody struct {
+	Name string `json:"name"`
+}
+
 // DeleteDocumentJSONBody defines parameters for DeleteDocument.
 type DeleteDocumentJSONBody struct {
 	DocumentIds []openapi_types.UUID `json:"documentIds"`
@@ -160,54 +438,171 @@ type GetDocumentParams struct {
 	// Cursor a cursor can optionally be supplied for pagination
 	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
 
-	// Limit the number of documents to retrieve in a page
+	// Limit the number of documents to retrieve in a page, defaults to 10. Values over 100 are clamped to 100, the page size that was used is returned in the response
 	Limit           *int32           `form:"limit,omitempty" json:"limit,omitempty"`
 	PermissionLevel *PermissionLevel `form:"permissionLevel,omitempty" json:"permissionLevel,omitempty"`
+
+	// IncludePermissionTimestamps include when the caller was granted each document and when that grant was last changed
+	IncludePermissionTimestamps *bool `form:"includePermissionTimestamps,omitempty" json:"includePermissionTimestamps,omitempty"`
+
+	// FavoritesOnly only list the documents that the caller starred
+	FavoritesOnly *bool `form:"favoritesOnly,omitempty" json:"favoritesOnly,omitempty"`
+
+	// IncludeArchived also list archived documents, they are listed with their archived at time set
+	IncludeArchived *bool `form:"includeArchived,omitempty" json:"includeArchived,omitempty"`
+
+	// CollectionId only list the documents in this collection, the caller must own the collection
+	CollectionId *openapi_types.UUID `form:"collectionId,omitempty" json:"collectionId,omitempty"`
 }
 
 // PostDocumentJSONBody defines parameters for PostDocument.
 type PostDocumentJSONBody struct {
-	DocumentDescription *string            `json:"documentDescription,omitempty"`
-	DocumentName        *string            `json:"documentName,omitempty"`
-	UserId              openapi_types.UUID `json:"userId"`
+	DocumentDescription *string `json:"documentDescription,omitempty"`
+
+	// DocumentId an id generated by an offline-first client for the new document, the server generates one when it is omitted
+	DocumentId   *openapi_types.UUID `json:"documentId,omitempty"`
+	DocumentName *string             `json:"documentName,omitempty"`
+	UserId       openapi_types.UUID  `json:"userId"`
+}
+
+// GetDocumentRecentParams defines parameters for GetDocumentRecent.
+type GetDocumentRecentParams struct {
+	// Cursor the cursor returned by the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit the number of documents to retrieve in a page, defaults to 10. Values over 100 are clamped to 100, the page size that was used is returned in the response
+	Limit *int32 `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetDocumentSharedParams defines parameters for GetDocumentShared.
+type GetDocumentSharedParams struct {
+	// Cursor the cursor returned by the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit the number of documents to retrieve in a page, defaults to 10. Values over 100 are clamped to 100, the page size that was used is returned in the response
+	Limit *int32 `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetDocumentSyncParams defines parameters for GetDocumentSync.
+type GetDocumentSyncParams struct {
+	// Since only documents modified after this time are returned, ignored when a cursor is supplied
+	Since time.Time `form:"since" json:"since"`
+
+	// Cursor the cursor returned by the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit the number of documents to retrieve in a page, defaults to 10. Values over 100 are clamped to 100, the page size that was used is returned in the response
+	Limit *int32 `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetDocumentDocumentIdParams defines parameters for GetDocumentDocumentId.
+type GetDocumentDocumentIdParams struct {
+	// IncludeTombstone respond with the tombstone of a recently deleted document instead of not found, so that syncing clients can tell it apart from a document that never existed. Only callers that held a permission on the document when it was deleted get the tombstone. Defaults to false
+	IncludeTombstone *bool `form:"includeTombstone,omitempty" json:"includeTombstone,omitempty"`
+
+	// IncludeCollaboratorCount include the number of principals the document is shared with, so that detail views do not need to get the sharing summary. Defaults to false
+	IncludeCollaboratorCount *bool `form:"includeCollaboratorCount,omitempty" json:"includeCollaboratorCount,omitempty"`
 }
 
 // PutDocumentDocumentIdJSONBody defines parameters for PutDocumentDocumentId.
 type PutDocumentDocumentIdJSONBody struct {
+	// ClearDescription remove the description of the document, cannot be combined with documentDescription
+	ClearDescription *bool `json:"clearDescription,omitempty"`
+
+	// ClearName remove the name of the document, cannot be combined with documentName
+	ClearName           *bool   `json:"clearName,omitempty"`
 	DocumentDescription *string `json:"documentDescription,omitempty"`
 	DocumentName        *string `json:"documentName,omitempty"`
 }
 
+// PostDocumentDocumentIdGuestsJSONBody defines parameters for PostDocumentDocumentIdGuests.
+type PostDocumentDocumentIdGuestsJSONBody struct {
+	// Count the number of guests to create, the document service rejects counts over its configured cap
+	Count int32 `json:"count"`
+
+	// PermissionLevel the permission levels that can be granted to a collaborator, ownership cannot be granted by sharing
+	PermissionLevel *CollaboratorPermissionLevel `json:"permissionLevel,omitempty"`
+}
+
+// GetDocumentDocumentIdHistoryParams defines parameters for GetDocumentDocumentIdHistory.
+type GetDocumentDocumentIdHistoryParams struct {
+	// Cursor the cursor returned by the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit the number of changes to retrieve in a page, defaults to 10. Values over 100 are clamped to 100, the page size that was used is returned in the response
+	Limit *int32 `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
 // GetDocumentDocumentIdPermissionParams defines parameters for GetDocumentDocumentIdPermission.
 type GetDocumentDocumentIdPermissionParams struct {
 	// Cursor a cursor can optionally be supplied for pagination
 	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
 
-	// Limit the number of documents to retrieve in a page
+	// Limit the number of documents to retrieve in a page, defaults to 10. Values over 100 are clamped to 100, the page size that was used is returned in the response
 	Limit *int32 `form:"limit,omitempty" json:"limit,omitempty"`
 
 	// PermissionFilter specify how the retrieved users can be filtered by permission level
 	PermissionFilter *[]PermissionLevel `form:"permissionFilter,omitempty" json:"permissionFilter,omitempty"`
+
+	// ExcludeSelf leave the permission of the calling user out of the listing, other principals at the owner level are still listed. Defaults to false
+	ExcludeSelf *bool `form:"excludeSelf,omitempty" json:"excludeSelf,omitempty"`
 }
 
 // PostDocumentDocumentIdPermissionJSONBody defines parameters for PostDocumentDocumentIdPermission.
 type PostDocumentDocumentIdPermissionJSONBody struct {
-	PermissionLevel PermissionLevel     `json:"permissionLevel"`
-	UserIdToShare   *openapi_types.UUID `json:"userIdToShare,omitempty"`
+	// EmailToShare share with the user that has this email instead of by user id, cannot be combined with userIdToShare
+	EmailToShare *openapi_types.Email `json:"emailToShare,omitempty"`
+
+	// Invite offer the user a pending share that grants no access until the user accepts it instead of sharing directly. Only applies when sharing with a user, the user must not already have a permission or a pending share on the document
+	Invite *bool `json:"invite,omitempty"`
+
+	// PermissionLevel the permission levels that can be granted to a collaborator, ownership cannot be granted by sharing
+	PermissionLevel *CollaboratorPermissionLevel `json:"permissionLevel,omitempty"`
+	UserIdToShare   *openapi_types.UUID          `json:"userIdToShare,omitempty"`
 }
 
 // PutDocumentDocumentIdPermissionPrincipalPrincipalIdJSONBody defines parameters for PutDocumentDocumentIdPermissionPrincipalPrincipalId.
 type PutDocumentDocumentIdPermissionPrincipalPrincipalIdJSONBody struct {
-	PermissionLevel PermissionLevel `json:"permissionLevel"`
-	PrincipalType   PrincipalType   `json:"principalType"`
+	// PermissionLevel the permission levels that can be granted to a collaborator, ownership cannot be granted by sharing
+	PermissionLevel CollaboratorPermissionLevel `json:"permissionLevel"`
+	PrincipalType   PrincipalType               `json:"principalType"`
+}
+
+// PostDocumentDocumentIdPermissionUsersJSONBody defines parameters for PostDocumentDocumentIdPermissionUsers.
+type PostDocumentDocumentIdPermissionUsersJSONBody struct {
+	Shares []struct {
+		// PermissionLevel the permission levels that can be granted to a collaborator, ownership cannot be granted by sharing
+		PermissionLevel CollaboratorPermissionLevel `json:"permissionLevel"`
+		UserId          openapi_types.UUID          `json:"userId"`
+	} `json:"shares"`
+}
+
+// PutDocumentDocumentIdPublicAccessJSONBody defines parameters for PutDocumentDocumentIdPublicAccess.
+type PutDocumentDocumentIdPublicAccessJSONBody struct {
+	// PublicAccess the permission level granted to holders of a public link of a document, none disables the public link
+	PublicAccess PublicAccess `json:"publicAccess"`
+}
+
+// GetUserParams defines parameters for GetUser.
+type GetUserParams struct {
+	Email openapi_types.Email `form:"email" json:"email"`
 }
 
 // PostUserJSONBody defines parameters for PostUser.
 type PostUserJSONBody struct {
-	MaxDocuments *int32              `json:"maxDocuments,omitempty"`
-	Password     string              `json:"password"`
-	UserEmail    openapi_types.Email `json:"userEmail"`
-	UserName     string              `json:"userName"`
+	MaxDocuments *int32 `json:"maxDocuments,omitempty"`
+	Password     string `json:"password"`
+
+	// Plan the plan of the new user, used to resolve the default document quota when maxDocuments is not provided
+	Plan      *string             `json:"plan,omitempty"`
+	UserEmail openapi_types.Email `json:"userEmail"`
+	UserName  string              `json:"userName"`
+}
+
+// GetUserAvailabilityParams defines parameters for GetUserAvailability.
+type GetUserAvailabilityParams struct {
+	UserName *string              `form:"userName,omitempty" json:"userName,omitempty"`
+	Email    *openapi_types.Email `form:"email,omitempty" json:"email,omitempty"`
 }
 
 // PutUserUserIdJSONBody defines parameters for PutUserUserId.
@@ -216,9 +611,20 @@ type PutUserUserIdJSONBody struct {
 	OldPassword string `json:"oldPassword"`
 }
 
+// PostUserUserIdReassignDocumentsJSONBody defines parameters for PostUserUserIdReassignDocuments.
+type PostUserUserIdReassignDocumentsJSONBody struct {
+	SuccessorId openapi_types.UUID `json:"successorId"`
+}
+
+// PostAdminDocumentsDocumentIdEnsureOwnerJSONRequestBody defines body for PostAdminDocumentsDocumentIdEnsureOwner for application/json ContentType.
+type PostAdminDocumentsDocumentIdEnsureOwnerJSONRequestBody PostAdminDocumentsDocumentIdEnsureOwnerJSONBody
+
 // PostAuthLoginJSONRequestBody defines body for PostAuthLogin for application/json ContentType.
 type PostAuthLoginJSONRequestBody PostAuthLoginJSONBody
 
+// PostCollectionJSONRequestBody defines body for PostCollection for application/json ContentType.
+type PostCollectionJSONRequestBody PostCollectionJSONBody
+
 // DeleteDocumentJSONRequestBody defines body for DeleteDocument for application/json ContentType.
 type DeleteDocumentJSONRequestBody DeleteDocumentJSONBody
 
@@ -228,23 +634,53 @@ type PostDocumentJSONRequestBody PostDocumentJSONBody
 // PutDocumentDocumentIdJSONRequestBody defines body for PutDocumentDocumentId for application/json ContentType.
 type PutDocumentDocumentIdJSONRequestBody PutDocumentDocumentIdJSONBody
 
+// PostDocumentDocumentIdGuestsJSONRequestBody defines body for PostDocumentDocumentIdGuests for application/json ContentType.
+type PostDocumentDocumentIdGuestsJSONRequestBody PostDocumentDocumentIdGuestsJSONBody
+
 // PostDocumentDocumentIdPermissionJSONRequestBody defines body for PostDocumentDocumentIdPermission for application/json ContentType.
 type PostDocumentDocumentIdPermissionJSONRequestBody PostDocumentDocumentIdPermissionJSONBody
 
 // PutDocumentDocumentIdPermissionPrincipalPrincipalIdJSONRequestBody defines body for PutDocumentDocumentIdPermissionPrincipalPrincipalId for application/json ContentType.
 type PutDocumentDocumentIdPermissionPrincipalPrincipalIdJSONRequestBody PutDocumentDocumentIdPermissionPrincipalPrincipalIdJSONBody
 
+// PostDocumentDocumentIdPermissionUsersJSONRequestBody defines body for PostDocumentDocumentIdPermissionUsers for application/json ContentType.
+type PostDocumentDocumentIdPermissionUsersJSONRequestBody PostDocumentDocumentIdPermissionUsersJSONBody
+
+// PutDocumentDocumentIdPublicAccessJSONRequestBody defines body for PutDocumentDocumentIdPublicAccess for application/json ContentType.
+type PutDocumentDocumentIdPublicAccessJSONRequestBody PutDocumentDocumentIdPublicAccessJSONBody
+
 // PostUserJSONRequestBody defines body for PostUser for application/json ContentType.
 type PostUserJSONRequestBody PostUserJSONBody
 
 // PutUserUserIdJSONRequestBody defines body for PutUserUserId for application/json ContentType.
 type PutUserUserIdJSONRequestBody PutUserUserIdJSONBody
 
+// PostUserUserIdReassignDocumentsJSONRequestBody defines body for PostUserUserIdReassignDocuments for application/json ContentType.
+type PostUserUserIdReassignDocumentsJSONRequestBody PostUserUserIdReassignDocumentsJSONBody
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// get every document regardless of who can see it, newest first. This is only meant to be called by admins, for example for support and cleanup
+	// (GET /admin/documents)
+	GetAdminDocuments(w http.ResponseWriter, r *http.Request, params GetAdminDocumentsParams)
+	// make the fallback owner the owner of the document if the document has no owner, for example after a failed migration. This is only meant to be called by admins
+	// (POST /admin/documents/{documentId}/ensure-owner)
+	PostAdminDocumentsDocumentIdEnsureOwner(w http.ResponseWriter, r *http.Request, documentId DocumentId)
 	// get a token
 	// (POST /auth/login)
 	PostAuthLogin(w http.ResponseWriter, r *http.Request)
+	// get the principal that the caller is authenticated as
+	// (GET /auth/me)
+	GetAuthMe(w http.ResponseWriter, r *http.Request)
+	// create a collection owned by the caller to organize their documents, a collection does not grant access to the documents in it
+	// (POST /collection)
+	PostCollection(w http.ResponseWriter, r *http.Request)
+	// remove a document from a collection that the caller owns, removing a document that is not in the collection is a no-op
+	// (DELETE /collection/{collectionId}/document/{documentId})
+	DeleteCollectionCollectionIdDocumentDocumentId(w http.ResponseWriter, r *http.Request, collectionId CollectionId, documentId DocumentId)
+	// add a document that the caller has a permission on to a collection that the caller owns, adding a document that is in the collection is a no-op
+	// (PUT /collection/{collectionId}/document/{documentId})
+	PutCollectionCollectionIdDocumentDocumentId(w http.ResponseWriter, r *http.Request, collectionId CollectionId, documentId DocumentId)
 	// batch delete endpoint for deleting lists of documents
 	// (DELETE /document)
 	DeleteDocument(w http.ResponseWriter, r *http.Request)
@@ -254,15 +690,42 @@ type ServerInterface interface {
 	// create a new document for a user
 	// (POST /document)
 	PostDocument(w http.ResponseWriter, r *http.Request)
+	// get the number of active documents that the caller holds each permission level on
+	// (GET /document/counts)
+	GetDocumentCounts(w http.ResponseWriter, r *http.Request)
+	// get the shares offered to the caller that they have not accepted yet, newest first. A pending share grants no access to the document until it is accepted
+	// (GET /document/pending)
+	GetDocumentPending(w http.ResponseWriter, r *http.Request)
+	// get the documents the caller opened, most recently opened first
+	// (GET /document/recent)
+	GetDocumentRecent(w http.ResponseWriter, r *http.Request, params GetDocumentRecentParams)
+	// get the documents owned by the caller that are shared with at least one collaborator, newest first
+	// (GET /document/shared)
+	GetDocumentShared(w http.ResponseWriter, r *http.Request, params GetDocumentSharedParams)
+	// incremental sync, get the documents of the caller that changed after a point in time, oldest change first
+	// (GET /document/sync)
+	GetDocumentSync(w http.ResponseWriter, r *http.Request, params GetDocumentSyncParams)
 	// delete a document
 	// (DELETE /document/{documentId})
 	DeleteDocumentDocumentId(w http.ResponseWriter, r *http.Request, documentId DocumentId)
 	// get one document
 	// (GET /document/{documentId})
-	GetDocumentDocumentId(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	GetDocumentDocumentId(w http.ResponseWriter, r *http.Request, documentId DocumentId, params GetDocumentDocumentIdParams)
 	// update one document
 	// (PUT /document/{documentId})
 	PutDocumentDocumentId(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// restore an archived document, only the owner can restore it. Restoring a document that is not archived is a no-op
+	// (DELETE /document/{documentId}/archive)
+	DeleteDocumentDocumentIdArchive(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// archive a document, only the owner can archive it. An archived document can be read but not changed or shared until it is restored, archiving an archived document is a no-op
+	// (PUT /document/{documentId}/archive)
+	PutDocumentDocumentIdArchive(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// create several guests on a document at once, for example one share link per reviewer. This is only meant to be called by users that have owner permissions on that document
+	// (POST /document/{documentId}/guests)
+	PostDocumentDocumentIdGuests(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// get the changes to the name and description of a document, newest first. Any principal with a permission on the document can read its history
+	// (GET /document/{documentId}/history)
+	GetDocumentDocumentIdHistory(w http.ResponseWriter, r *http.Request, documentId DocumentId, params GetDocumentDocumentIdHistoryParams)
 	// get all the users that have permission on a document, this is only meant to be called by users that have owner permissions on that document
 	// (GET /document/{documentId}/permission)
 	GetDocumentDocumentIdPermission(w http.ResponseWriter, r *http.Request, documentId DocumentId, params GetDocumentDocumentIdPermissionParams)
@@ -278,9 +741,39 @@ type ServerInterface interface {
 	// update the permission level of a user or a guest on a document
 	// (PUT /document/{documentId}/permission/principal/{principalId})
 	PutDocumentDocumentIdPermissionPrincipalPrincipalId(w http.ResponseWriter, r *http.Request, documentId DocumentId, principalId PrincipalId)
+	// remove the permission of the caller on a document, the owner of a document cannot leave it
+	// (DELETE /document/{documentId}/permission/self)
+	DeleteDocumentDocumentIdPermissionSelf(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// accept the pending share of the caller on a document, the share grants access from then on
+	// (POST /document/{documentId}/permission/self/accept)
+	PostDocumentDocumentIdPermissionSelfAccept(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// share a document with several users at once, for example from a share dialog. The caller must hold at least each granted level, and the batch is rejected as a whole if any share is not allowed
+	// (POST /document/{documentId}/permission/users)
+	PostDocumentDocumentIdPermissionUsers(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// enable or disable the public link of a document, this is only meant to be called by users that have owner permissions on that document
+	// (PUT /document/{documentId}/public-access)
+	PutDocumentDocumentIdPublicAccess(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// get the owner of a document, a preview of its collaborators, and the number of collaborators
+	// (GET /document/{documentId}/sharing-summary)
+	GetDocumentDocumentIdSharingSummary(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// unstar a document, unstarring a document that is not starred is a no-op
+	// (DELETE /document/{documentId}/star)
+	DeleteDocumentDocumentIdStar(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// star a document that the caller has a permission on, starring a starred document is a no-op
+	// (PUT /document/{documentId}/star)
+	PutDocumentDocumentIdStar(w http.ResponseWriter, r *http.Request, documentId DocumentId)
+	// invalidate the tokens that were issued to a guest without deleting the guest, for example after its share link leaked. The guest keeps its permission and a new token is returned. This is only meant to be called by users that have owner permissions on the document of the guest
+	// (POST /guest/{guestId}/rotate)
+	PostGuestGuestIdRotate(w http.ResponseWriter, r *http.Request, guestId GuestId)
+	// look up a user by email, this is only meant to be called by admins
+	// (GET /user)
+	GetUser(w http.ResponseWriter, r *http.Request, params GetUserParams)
 	// create a user
 	// (POST /user)
 	PostUser(w http.ResponseWriter, r *http.Request)
+	// check whether a user could be created with the user name and the email before signing up, at least one of them is required. Only callers with a user token can check availability because it reveals whether an email is registered
+	// (GET /user/availability)
+	GetUserAvailability(w http.ResponseWriter, r *http.Request, params GetUserAvailabilityParams)
 	// deactivate a user
 	// (DELETE /user/{userId})
 	DeleteUserUserId(w http.ResponseWriter, r *http.Request, userId UserId)
@@ -290,6 +783,9 @@ type ServerInterface interface {
 	// update a user including the users password
 	// (PUT /user/{userId})
 	PutUserUserId(w http.ResponseWriter, r *http.Request, userId UserId)
+	// make the successor the owner of every document owned by the user, the user loses their permission on those documents. This is only meant to be called by admins, for example when the user leaves
+	// (POST /user/{userId}/reassign-documents)
+	PostUserUserIdReassignDocuments(w http.ResponseWriter, r *http.Request, userId UserId)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -299,13 +795,880 @@ type ServerInterfaceWrapper struct {
 	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
 }
 
-type MiddlewareFunc func(http.Handler) http.Handler
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// GetAdminDocuments operation middleware
+func (siw *ServerInterfaceWrapper) GetAdminDocuments(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAdminDocumentsParams
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "ownerId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "ownerId", r.URL.Query(), &params.OwnerId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "ownerId", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "createdBefore" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "createdBefore", r.URL.Query(), &params.CreatedBefore)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "createdBefore", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "createdAfter" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "createdAfter", r.URL.Query(), &params.CreatedAfter)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "createdAfter", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAdminDocuments(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostAdminDocumentsDocumentIdEnsureOwner operation middleware
+func (siw *ServerInterfaceWrapper) PostAdminDocumentsDocumentIdEnsureOwner(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostAdminDocumentsDocumentIdEnsureOwner(w, r, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostAuthLogin operation middleware
+func (siw *ServerInterfaceWrapper) PostAuthLogin(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostAuthLogin(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetAuthMe operation middleware
+func (siw *ServerInterfaceWrapper) GetAuthMe(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAuthMe(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostCollection operation middleware
+func (siw *ServerInterfaceWrapper) PostCollection(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostCollection(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteCollectionCollectionIdDocumentDocumentId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteCollectionCollectionIdDocumentDocumentId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "collectionId" -------------
+	var collectionId CollectionId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "collectionId", r.PathValue("collectionId"), &collectionId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "collectionId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteCollectionCollectionIdDocumentDocumentId(w, r, collectionId, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PutCollectionCollectionIdDocumentDocumentId operation middleware
+func (siw *ServerInterfaceWrapper) PutCollectionCollectionIdDocumentDocumentId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "collectionId" -------------
+	var collectionId CollectionId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "collectionId", r.PathValue("collectionId"), &collectionId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "collectionId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutCollectionCollectionIdDocumentDocumentId(w, r, collectionId, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteDocument operation middleware
+func (siw *ServerInterfaceWrapper) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteDocument(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocument operation middleware
+func (siw *ServerInterfaceWrapper) GetDocument(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDocumentParams
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "permissionLevel" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "permissionLevel", r.URL.Query(), &params.PermissionLevel)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "permissionLevel", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "includePermissionTimestamps" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "includePermissionTimestamps", r.URL.Query(), &params.IncludePermissionTimestamps)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "includePermissionTimestamps", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "favoritesOnly" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "favoritesOnly", r.URL.Query(), &params.FavoritesOnly)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "favoritesOnly", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "includeArchived" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "includeArchived", r.URL.Query(), &params.IncludeArchived)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "includeArchived", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "collectionId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "collectionId", r.URL.Query(), &params.CollectionId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "collectionId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocument(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostDocument operation middleware
+func (siw *ServerInterfaceWrapper) PostDocument(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostDocument(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentCounts operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentCounts(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentCounts(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentPending operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentPending(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentPending(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentRecent operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentRecent(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDocumentRecentParams
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentRecent(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentShared operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentShared(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDocumentSharedParams
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentShared(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentSync operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentSync(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDocumentSyncParams
+
+	// ------------- Required query parameter "since" -------------
+
+	if paramValue := r.URL.Query().Get("since"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "since"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "since", r.URL.Query(), &params.Since)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "since", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentSync(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteDocumentDocumentId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteDocumentDocumentId(w, r, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentDocumentId operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentDocumentId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDocumentDocumentIdParams
+
+	// ------------- Optional query parameter "includeTombstone" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "includeTombstone", r.URL.Query(), &params.IncludeTombstone)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "includeTombstone", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "includeCollaboratorCount" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "includeCollaboratorCount", r.URL.Query(), &params.IncludeCollaboratorCount)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "includeCollaboratorCount", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentDocumentId(w, r, documentId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PutDocumentDocumentId operation middleware
+func (siw *ServerInterfaceWrapper) PutDocumentDocumentId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutDocumentDocumentId(w, r, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteDocumentDocumentIdArchive operation middleware
+func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentIdArchive(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteDocumentDocumentIdArchive(w, r, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PutDocumentDocumentIdArchive operation middleware
+func (siw *ServerInterfaceWrapper) PutDocumentDocumentIdArchive(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutDocumentDocumentIdArchive(w, r, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostDocumentDocumentIdGuests operation middleware
+func (siw *ServerInterfaceWrapper) PostDocumentDocumentIdGuests(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostDocumentDocumentIdGuests(w, r, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentDocumentIdHistory operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdHistory(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDocumentDocumentIdHistoryParams
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentDocumentIdHistory(w, r, documentId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentDocumentIdPermission operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdPermission(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDocumentDocumentIdPermissionParams
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "permissionFilter" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "permissionFilter", r.URL.Query(), &params.PermissionFilter)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "permissionFilter", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "excludeSelf" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "excludeSelf", r.URL.Query(), &params.ExcludeSelf)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "excludeSelf", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentDocumentIdPermission(w, r, documentId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostDocumentDocumentIdPermission operation middleware
+func (siw *ServerInterfaceWrapper) PostDocumentDocumentIdPermission(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
 
-// PostAuthLogin operation middleware
-func (siw *ServerInterfaceWrapper) PostAuthLogin(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PostAuthLogin(w, r)
+		siw.Handler.PostDocumentDocumentIdPermission(w, r, documentId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -315,8 +1678,28 @@ func (siw *ServerInterfaceWrapper) PostAuthLogin(w http.ResponseWriter, r *http.
 	handler.ServeHTTP(w, r)
 }
 
-// DeleteDocument operation middleware
-func (siw *ServerInterfaceWrapper) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+// DeleteDocumentDocumentIdPermissionPrincipalPrincipalId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentIdPermissionPrincipalPrincipalId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "principalId" -------------
+	var principalId PrincipalId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "principalId", r.PathValue("principalId"), &principalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "principalId", Err: err})
+		return
+	}
 
 	ctx := r.Context()
 
@@ -325,7 +1708,7 @@ func (siw *ServerInterfaceWrapper) DeleteDocument(w http.ResponseWriter, r *http
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.DeleteDocument(w, r)
+		siw.Handler.DeleteDocumentDocumentIdPermissionPrincipalPrincipalId(w, r, documentId, principalId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -335,46 +1718,37 @@ func (siw *ServerInterfaceWrapper) DeleteDocument(w http.ResponseWriter, r *http
 	handler.ServeHTTP(w, r)
 }
 
-// GetDocument operation middleware
-func (siw *ServerInterfaceWrapper) GetDocument(w http.ResponseWriter, r *http.Request) {
+// GetDocumentDocumentIdPermissionPrincipalPrincipalId operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdPermissionPrincipalPrincipalId(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
-	ctx := r.Context()
-
-	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
-
-	r = r.WithContext(ctx)
-
-	// Parameter object where we will unmarshal all parameters from the context
-	var params GetDocumentParams
-
-	// ------------- Optional query parameter "cursor" -------------
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
 
-	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
 		return
 	}
 
-	// ------------- Optional query parameter "limit" -------------
+	// ------------- Path parameter "principalId" -------------
+	var principalId PrincipalId
 
-	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	err = runtime.BindStyledParameterWithOptions("simple", "principalId", r.PathValue("principalId"), &principalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "principalId", Err: err})
 		return
 	}
 
-	// ------------- Optional query parameter "permissionLevel" -------------
+	ctx := r.Context()
 
-	err = runtime.BindQueryParameter("form", true, false, "permissionLevel", r.URL.Query(), &params.PermissionLevel)
-	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "permissionLevel", Err: err})
-		return
-	}
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetDocument(w, r, params)
+		siw.Handler.GetDocumentDocumentIdPermissionPrincipalPrincipalId(w, r, documentId, principalId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -384,8 +1758,28 @@ func (siw *ServerInterfaceWrapper) GetDocument(w http.ResponseWriter, r *http.Re
 	handler.ServeHTTP(w, r)
 }
 
-// PostDocument operation middleware
-func (siw *ServerInterfaceWrapper) PostDocument(w http.ResponseWriter, r *http.Request) {
+// PutDocumentDocumentIdPermissionPrincipalPrincipalId operation middleware
+func (siw *ServerInterfaceWrapper) PutDocumentDocumentIdPermissionPrincipalPrincipalId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "principalId" -------------
+	var principalId PrincipalId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "principalId", r.PathValue("principalId"), &principalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "principalId", Err: err})
+		return
+	}
 
 	ctx := r.Context()
 
@@ -394,7 +1788,7 @@ func (siw *ServerInterfaceWrapper) PostDocument(w http.ResponseWriter, r *http.R
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PostDocument(w, r)
+		siw.Handler.PutDocumentDocumentIdPermissionPrincipalPrincipalId(w, r, documentId, principalId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -404,8 +1798,8 @@ func (siw *ServerInterfaceWrapper) PostDocument(w http.ResponseWriter, r *http.R
 	handler.ServeHTTP(w, r)
 }
 
-// DeleteDocumentDocumentId operation middleware
-func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentId(w http.ResponseWriter, r *http.Request) {
+// DeleteDocumentDocumentIdPermissionSelf operation middleware
+func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentIdPermissionSelf(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -425,7 +1819,7 @@ func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentId(w http.ResponseWrite
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.DeleteDocumentDocumentId(w, r, documentId)
+		siw.Handler.DeleteDocumentDocumentIdPermissionSelf(w, r, documentId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -435,8 +1829,8 @@ func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentId(w http.ResponseWrite
 	handler.ServeHTTP(w, r)
 }
 
-// GetDocumentDocumentId operation middleware
-func (siw *ServerInterfaceWrapper) GetDocumentDocumentId(w http.ResponseWriter, r *http.Request) {
+// PostDocumentDocumentIdPermissionSelfAccept operation middleware
+func (siw *ServerInterfaceWrapper) PostDocumentDocumentIdPermissionSelfAccept(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -456,7 +1850,7 @@ func (siw *ServerInterfaceWrapper) GetDocumentDocumentId(w http.ResponseWriter,
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetDocumentDocumentId(w, r, documentId)
+		siw.Handler.PostDocumentDocumentIdPermissionSelfAccept(w, r, documentId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -466,8 +1860,8 @@ func (siw *ServerInterfaceWrapper) GetDocumentDocumentId(w http.ResponseWriter,
 	handler.ServeHTTP(w, r)
 }
 
-// PutDocumentDocumentId operation middleware
-func (siw *ServerInterfaceWrapper) PutDocumentDocumentId(w http.ResponseWriter, r *http.Request) {
+// PostDocumentDocumentIdPermissionUsers operation middleware
+func (siw *ServerInterfaceWrapper) PostDocumentDocumentIdPermissionUsers(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -487,7 +1881,7 @@ func (siw *ServerInterfaceWrapper) PutDocumentDocumentId(w http.ResponseWriter,
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PutDocumentDocumentId(w, r, documentId)
+		siw.Handler.PostDocumentDocumentIdPermissionUsers(w, r, documentId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -497,8 +1891,8 @@ func (siw *ServerInterfaceWrapper) PutDocumentDocumentId(w http.ResponseWriter,
 	handler.ServeHTTP(w, r)
 }
 
-// GetDocumentDocumentIdPermission operation middleware
-func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdPermission(w http.ResponseWriter, r *http.Request) {
+// PutDocumentDocumentIdPublicAccess operation middleware
+func (siw *ServerInterfaceWrapper) PutDocumentDocumentIdPublicAccess(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -517,35 +1911,39 @@ func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdPermission(w http.Respon
 
 	r = r.WithContext(ctx)
 
-	// Parameter object where we will unmarshal all parameters from the context
-	var params GetDocumentDocumentIdPermissionParams
-
-	// ------------- Optional query parameter "cursor" -------------
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutDocumentDocumentIdPublicAccess(w, r, documentId)
+	}))
 
-	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
-	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
-		return
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
 	}
 
-	// ------------- Optional query parameter "limit" -------------
+	handler.ServeHTTP(w, r)
+}
 
-	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
-	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
-		return
-	}
+// GetDocumentDocumentIdSharingSummary operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdSharingSummary(w http.ResponseWriter, r *http.Request) {
 
-	// ------------- Optional query parameter "permissionFilter" -------------
+	var err error
 
-	err = runtime.BindQueryParameter("form", true, false, "permissionFilter", r.URL.Query(), &params.PermissionFilter)
+	// ------------- Path parameter "documentId" -------------
+	var documentId DocumentId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "permissionFilter", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
 		return
 	}
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetDocumentDocumentIdPermission(w, r, documentId, params)
+		siw.Handler.GetDocumentDocumentIdSharingSummary(w, r, documentId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -555,8 +1953,8 @@ func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdPermission(w http.Respon
 	handler.ServeHTTP(w, r)
 }
 
-// PostDocumentDocumentIdPermission operation middleware
-func (siw *ServerInterfaceWrapper) PostDocumentDocumentIdPermission(w http.ResponseWriter, r *http.Request) {
+// DeleteDocumentDocumentIdStar operation middleware
+func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentIdStar(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -576,7 +1974,7 @@ func (siw *ServerInterfaceWrapper) PostDocumentDocumentIdPermission(w http.Respo
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PostDocumentDocumentIdPermission(w, r, documentId)
+		siw.Handler.DeleteDocumentDocumentIdStar(w, r, documentId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -586,8 +1984,8 @@ func (siw *ServerInterfaceWrapper) PostDocumentDocumentIdPermission(w http.Respo
 	handler.ServeHTTP(w, r)
 }
 
-// DeleteDocumentDocumentIdPermissionPrincipalPrincipalId operation middleware
-func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentIdPermissionPrincipalPrincipalId(w http.ResponseWriter, r *http.Request) {
+// PutDocumentDocumentIdStar operation middleware
+func (siw *ServerInterfaceWrapper) PutDocumentDocumentIdStar(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -600,15 +1998,6 @@ func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentIdPermissionPrincipalPr
 		return
 	}
 
-	// ------------- Path parameter "principalId" -------------
-	var principalId PrincipalId
-
-	err = runtime.BindStyledParameterWithOptions("simple", "principalId", r.PathValue("principalId"), &principalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
-	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "principalId", Err: err})
-		return
-	}
-
 	ctx := r.Context()
 
 	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
@@ -616,7 +2005,7 @@ func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentIdPermissionPrincipalPr
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.DeleteDocumentDocumentIdPermissionPrincipalPrincipalId(w, r, documentId, principalId)
+		siw.Handler.PutDocumentDocumentIdStar(w, r, documentId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -626,26 +2015,17 @@ func (siw *ServerInterfaceWrapper) DeleteDocumentDocumentIdPermissionPrincipalPr
 	handler.ServeHTTP(w, r)
 }
 
-// GetDocumentDocumentIdPermissionPrincipalPrincipalId operation middleware
-func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdPermissionPrincipalPrincipalId(w http.ResponseWriter, r *http.Request) {
+// PostGuestGuestIdRotate operation middleware
+func (siw *ServerInterfaceWrapper) PostGuestGuestIdRotate(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
-	// ------------- Path parameter "documentId" -------------
-	var documentId DocumentId
-
-	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
-	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
-		return
-	}
-
-	// ------------- Path parameter "principalId" -------------
-	var principalId PrincipalId
+	// ------------- Path parameter "guestId" -------------
+	var guestId GuestId
 
-	err = runtime.BindStyledParameterWithOptions("simple", "principalId", r.PathValue("principalId"), &principalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "guestId", r.PathValue("guestId"), &guestId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "principalId", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "guestId", Err: err})
 		return
 	}
 
@@ -656,7 +2036,7 @@ func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdPermissionPrincipalPrinc
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetDocumentDocumentIdPermissionPrincipalPrincipalId(w, r, documentId, principalId)
+		siw.Handler.PostGuestGuestIdRotate(w, r, guestId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -666,29 +2046,49 @@ func (siw *ServerInterfaceWrapper) GetDocumentDocumentIdPermissionPrincipalPrinc
 	handler.ServeHTTP(w, r)
 }
 
-// PutDocumentDocumentIdPermissionPrincipalPrincipalId operation middleware
-func (siw *ServerInterfaceWrapper) PutDocumentDocumentIdPermissionPrincipalPrincipalId(w http.ResponseWriter, r *http.Request) {
+// GetUser operation middleware
+func (siw *ServerInterfaceWrapper) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
-	// ------------- Path parameter "documentId" -------------
-	var documentId DocumentId
+	ctx := r.Context()
 
-	err = runtime.BindStyledParameterWithOptions("simple", "documentId", r.PathValue("documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
-	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetUserParams
+
+	// ------------- Required query parameter "email" -------------
+
+	if paramValue := r.URL.Query().Get("email"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "email"})
 		return
 	}
 
-	// ------------- Path parameter "principalId" -------------
-	var principalId PrincipalId
-
-	err = runtime.BindStyledParameterWithOptions("simple", "principalId", r.PathValue("principalId"), &principalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindQueryParameter("form", true, true, "email", r.URL.Query(), &params.Email)
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "principalId", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "email", Err: err})
 		return
 	}
 
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetUser(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostUser operation middleware
+func (siw *ServerInterfaceWrapper) PostUser(w http.ResponseWriter, r *http.Request) {
+
 	ctx := r.Context()
 
 	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
@@ -696,7 +2096,7 @@ func (siw *ServerInterfaceWrapper) PutDocumentDocumentIdPermissionPrincipalPrinc
 	r = r.WithContext(ctx)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PutDocumentDocumentIdPermissionPrincipalPrincipalId(w, r, documentId, principalId)
+		siw.Handler.PostUser(w, r)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -706,8 +2106,10 @@ func (siw *ServerInterfaceWrapper) PutDocumentDocumentIdPermissionPrincipalPrinc
 	handler.ServeHTTP(w, r)
 }
 
-// PostUser operation middleware
-func (siw *ServerInterfaceWrapper) PostUser(w http.ResponseWriter, r *http.Request) {
+// GetUserAvailability operation middleware
+func (siw *ServerInterfaceWrapper) GetUserAvailability(w http.ResponseWriter, r *http.Request) {
+
+	var err error
 
 	ctx := r.Context()
 
@@ -715,8 +2117,27 @@ func (siw *ServerInterfaceWrapper) PostUser(w http.ResponseWriter, r *http.Reque
 
 	r = r.WithContext(ctx)
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetUserAvailabilityParams
+
+	// ------------- Optional query parameter "userName" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "userName", r.URL.Query(), &params.UserName)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "userName", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "email" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "email", r.URL.Query(), &params.Email)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "email", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PostUser(w, r)
+		siw.Handler.GetUserAvailability(w, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -819,6 +2240,37 @@ func (siw *ServerInterfaceWrapper) PutUserUserId(w http.ResponseWriter, r *http.
 	handler.ServeHTTP(w, r)
 }
 
+// PostUserUserIdReassignDocuments operation middleware
+func (siw *ServerInterfaceWrapper) PostUserUserIdReassignDocuments(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "userId" -------------
+	var userId UserId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "userId", r.PathValue("userId"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "userId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostUserUserIdReassignDocuments(w, r, userId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -939,22 +2391,48 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	m.HandleFunc("GET "+options.BaseURL+"/admin/documents", wrapper.GetAdminDocuments)
+	m.HandleFunc("POST "+options.BaseURL+"/admin/documents/{documentId}/ensure-owner", wrapper.PostAdminDocumentsDocumentIdEnsureOwner)
 	m.HandleFunc("POST "+options.BaseURL+"/auth/login", wrapper.PostAuthLogin)
+	m.HandleFunc("GET "+options.BaseURL+"/auth/me", wrapper.GetAuthMe)
+	m.HandleFunc("POST "+options.BaseURL+"/collection", wrapper.PostCollection)
+	m.HandleFunc("DELETE "+options.BaseURL+"/collection/{collectionId}/document/{documentId}", wrapper.DeleteCollectionCollectionIdDocumentDocumentId)
+	m.HandleFunc("PUT "+options.BaseURL+"/collection/{collectionId}/document/{documentId}", wrapper.PutCollectionCollectionIdDocumentDocumentId)
 	m.HandleFunc("DELETE "+options.BaseURL+"/document", wrapper.DeleteDocument)
 	m.HandleFunc("GET "+options.BaseURL+"/document", wrapper.GetDocument)
 	m.HandleFunc("POST "+options.BaseURL+"/document", wrapper.PostDocument)
+	m.HandleFunc("GET "+options.BaseURL+"/document/counts", wrapper.GetDocumentCounts)
+	m.HandleFunc("GET "+options.BaseURL+"/document/pending", wrapper.GetDocumentPending)
+	m.HandleFunc("GET "+options.BaseURL+"/document/recent", wrapper.GetDocumentRecent)
+	m.HandleFunc("GET "+options.BaseURL+"/document/shared", wrapper.GetDocumentShared)
+	m.HandleFunc("GET "+options.BaseURL+"/document/sync", wrapper.GetDocumentSync)
 	m.HandleFunc("DELETE "+options.BaseURL+"/document/{documentId}", wrapper.DeleteDocumentDocumentId)
 	m.HandleFunc("GET "+options.BaseURL+"/document/{documentId}", wrapper.GetDocumentDocumentId)
 	m.HandleFunc("PUT "+options.BaseURL+"/document/{documentId}", wrapper.PutDocumentDocumentId)
+	m.HandleFunc("DELETE "+options.BaseURL+"/document/{documentId}/archive", wrapper.DeleteDocumentDocumentIdArchive)
+	m.HandleFunc("PUT "+options.BaseURL+"/document/{documentId}/archive", wrapper.PutDocumentDocumentIdArchive)
+	m.HandleFunc("POST "+options.BaseURL+"/document/{documentId}/guests", wrapper.PostDocumentDocumentIdGuests)
+	m.HandleFunc("GET "+options.BaseURL+"/document/{documentId}/history", wrapper.GetDocumentDocumentIdHistory)
 	m.HandleFunc("GET "+options.BaseURL+"/document/{documentId}/permission", wrapper.GetDocumentDocumentIdPermission)
 	m.HandleFunc("POST "+options.BaseURL+"/document/{documentId}/permission", wrapper.PostDocumentDocumentIdPermission)
 	m.HandleFunc("DELETE "+options.BaseURL+"/document/{documentId}/permission/principal/{principalId}", wrapper.DeleteDocumentDocumentIdPermissionPrincipalPrincipalId)
 	m.HandleFunc("GET "+options.BaseURL+"/document/{documentId}/permission/principal/{principalId}", wrapper.GetDocumentDocumentIdPermissionPrincipalPrincipalId)
 	m.HandleFunc("PUT "+options.BaseURL+"/document/{documentId}/permission/principal/{principalId}", wrapper.PutDocumentDocumentIdPermissionPrincipalPrincipalId)
+	m.HandleFunc("DELETE "+options.BaseURL+"/document/{documentId}/permission/self", wrapper.DeleteDocumentDocumentIdPermissionSelf)
+	m.HandleFunc("POST "+options.BaseURL+"/document/{documentId}/permission/self/accept", wrapper.PostDocumentDocumentIdPermissionSelfAccept)
+	m.HandleFunc("POST "+options.BaseURL+"/document/{documentId}/permission/users", wrapper.PostDocumentDocumentIdPermissionUsers)
+	m.HandleFunc("PUT "+options.BaseURL+"/document/{documentId}/public-access", wrapper.PutDocumentDocumentIdPublicAccess)
+	m.HandleFunc("GET "+options.BaseURL+"/document/{documentId}/sharing-summary", wrapper.GetDocumentDocumentIdSharingSummary)
+	m.HandleFunc("DELETE "+options.BaseURL+"/document/{documentId}/star", wrapper.DeleteDocumentDocumentIdStar)
+	m.HandleFunc("PUT "+options.BaseURL+"/document/{documentId}/star", wrapper.PutDocumentDocumentIdStar)
+	m.HandleFunc("POST "+options.BaseURL+"/guest/{guestId}/rotate", wrapper.PostGuestGuestIdRotate)
+	m.HandleFunc("GET "+options.BaseURL+"/user", wrapper.GetUser)
 	m.HandleFunc("POST "+options.BaseURL+"/user", wrapper.PostUser)
+	m.HandleFunc("GET "+options.BaseURL+"/user/availability", wrapper.GetUserAvailability)
 	m.HandleFunc("DELETE "+options.BaseURL+"/user/{userId}", wrapper.DeleteUserUserId)
 	m.HandleFunc("GET "+options.BaseURL+"/user/{userId}", wrapper.GetUserUserId)
 	m.HandleFunc("PUT "+options.BaseURL+"/user/{userId}", wrapper.PutUserUserId)
+	m.HandleFunc("POST "+options.BaseURL+"/user/{userId}/reassign-documents", wrapper.PostUserUserIdReassignDocuments)
 
 	return m
 }
@@ -962,45 +2440,116 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xaS3PjuBH+KygkhyRFW5Kt9czqNo/djRNnRrVjVw4uHyCyJWKWBDgAKFlx6b+nAPAB",
-	"UpQIWcrWaipTc7BIPLobH7q/7uYLDnmacQZMSTx5wRkRJAUFwvz6yMM8BaZuI/0LnkmaJYAneHR1DeMf",
-	"bt5cwNsfZxejq+j6gox/uLkYX93cjMajN+PhcIgDTBme4IyoGAeYkVTPjOoVAyzgW04FRHiiRA4BlmEM",
-	"KdFbzblIicITnOdUj1TrTM+WSlC2wJtNgKeCspBmJDmdbJmz5HHCPUgQp5Mrt6sdI9JGT5YZZxLMwb4n",
-	"0a/wLQep9K+QMwXM/EmyLKEhUZSzwVfJmX5Wb/NnAXM8wX8a1KAZ2Ldy8JMQXNitIpChoJleBE/0Xqjc",
-	"bBPgX0CVsPq1EOkgGTLBMxCKWkXCXEgu9F8tlYMKamYcVZDKPhVKufTsYjkiBFlja7/S+I/O0k/VSD77",
-	"CqHqMsDnfxZ6T0GkVErK2ed5hd9XGWGfFvUuu4W5o9KRRn5mv8+RZPWW3ofiqtM6lgA/Xyz4RfHs8elv",
-	"DdWbR+Zu7X9od3xB2QlsAs8ZFSBvWeOyUqaur+rbSpmCBQijJv8NWIcJW0rZYYGzvI9qX/IwBCnneYKM",
-	"fnrDKZenvJZRI3D0O8yuy3UbHXBQX2Ii4CgFUsqmjg6joKXSQjswL32CwmMbmaJ/UxX7GcFT1QdGchUD",
-	"U1oXiDyUrILQC05BSrIwV6hehHKGzOVgC8QFomxJEhrpvY50/e+ae1SnXGnBBf3P61VQMZVI2xpRiRhX",
-	"iCQJX0GEFEcZCG1xZMaQUBX+40iFPnGF3tlNzJEVE/R6HwTo43hnVGjOuqcpSEXSDKVAZC4gQlRbPEmo",
-	"hJCzSCJJWQjogdFnBBkPY/SXfxCWE7FGowCNfnwzDNBwODH/0cP9h7+aS1+YZPRmeDV+e3011P+Chnu5",
-	"GXe6lyrQbTtvV4t9JqrVdULtR1ftPSHZ8xqVwz8ZHtSxXkKk+heP6Jz6iHzXHL3H7wSOHbZ22XZKAbaA",
-	"2bJmBdWX/use4Lstbf7gMHLC7YmAVMx6vz4IH55wOg4tLnu5gyUk/sTFDtcrlKSvd241cIvHVG+CJmLb",
-	"0rnGPBjP021VgeWpFmBJYQVCAyeiius/+IqBcJapLT519W3iI2vmb72HV42/N288zWcG7zShtVtjbKcx",
-	"2luXptChBweWGXTqr/PADjKYEpp0+rOUPH90sxYPpphXqaYXK9nhSlsWqjLOakpQSN2SsYOgBVhCmAuq",
-	"1l/0aViVZ0AECE0H6l8/l/J+XWlUmrPTK9m3tQKxUpmNxZTNeYdbNBE+o0hmEKII5pSBRCoGpM0k5iQE",
-	"NAO1AmDmqR66IApWZI0Ii8yzMKHA1CW6jwG9m96iX4r31C6U5bOEhgiYEuuMU6bQnAvzZkkE5blEMxL+",
-	"BixCKQ0FlyCWNAR5iW4V4iKMQSpBFEjDgEAqqRlKmieKZgk05xiRMsGXNNI/UMhjkHTpKlPubYXWS+VS",
-	"n5CiylQaXAX+fn8/rYxD5wWtwgFegrCOGw8vR5dDjQ+eASMZxRN8fTm8vNZ3g6jYnN9Ak7VBYrIFjWdu",
-	"Cwca1WZBjUCTQ+gjtkmFRRRI9Z5H6yNSiYxIueLCQDwlz3fAFhpFN+NAU/by59sevDszr68aM68Dj8tQ",
-	"3IFKlq7EpF1juRoOd/mpatygmWBuAjz2meWUb8yUUf+UdtbgXlQ8eXwKsMzTlIg1nuAFKERQmVwqspDa",
-	"Dub2Pul5g8jhjxEkoGAbDR/N84ppngoOdcxrlhB6vZ9XQUev6pN06utXzkE00l6CKPdSrghTElnbbJfu",
-	"tqEy3vZrnzj6UNjo98SFnnftO6/I3wyYKvjMiArjQncELKo9pnmms8yEaifI56iupNVAc8LLJtBg3AaX",
-	"U0Q017IuWj+2zUiQLUihkDDEzVOSJGs0AyRzDTyIjGwZWVBWekdThf2Wg1jXZdiiruXmkFuO46UDJyxP",
-	"ZyAaymqfLUAJCsaxI6J3hx37JjSlCndWe3fRAi1I11LbHPHQkmJBZTdPr3F2XaXf84K28YxJgtz7X1x+",
-	"ghZ0CczWI2JiiYN9VJsdcbYT6LuD6v/Mifrm6zsTcG/22U0wTxZGO8uX5wUtm6Ehghis6tiiPRNBRaLR",
-	"hRs3HA9e6ji28Y/NH5s9ub64ZKuQZ2TZIhKRyqqvjjX7LDU8WQOnbkPtKgGfmcfkDPpt3wriXRvWQwbO",
-	"SehYlOVdrjPfdXCv86F9HYITedWNJ//MiLAcqkFGu4mo4iiMCTMc4xVU9OxQl2eRdqYewNvpPwdZo7rp",
-	"7xqcquj/qWknNW0KYisTaxTzlQFuuXtkwp40xpkBmtNEgYAIzdYuoUoKIgvPWcIjKD+N2M9+fzZrNQQ/",
-	"sBddlXSbaWWApVqbIow2BO6gyR73ob85f76k2R6pcVIxWUKTGjshOrCNOyoRZ8kapUBspWsGKCRJYmHQ",
-	"XswUop0l9WT7usMJTN0PAo6OP73cfYd/OFGN7OjOhCXk99z0rA/n820BumsofZHHA9Hdff4zZfq7wI+A",
-	"qhiEhriMiTZ4M8avqIoRYQieqTTlFJNz6jiiV9YPbBZhGhP2pa0Z+9wEn4A4qJomgxenm/KqnKPeveqz",
-	"TFsf4n2/GUl5cAvbFWi5LuLjt15DTPws7VfR2f9R2/lFKkOtnYs51xe11Mv7VF4fTYLe0e6hHZb8eCDg",
-	"jxGOTtnt3ap59nV8fULV6bxQV97SwqDhuBaJpcMghXP3w6P26XnZi95JVR5slek0GOhtZaeU0TRPTR69",
-	"3dZudP3623w/lS31apuyXb2/K1ivPDqgDVjv2NMS7MfR6AgTH1J89fy28BxZVKs4qlHsYn7wYu3kQU70",
-	"1If6Y/vvkHaQUNHlXrPtJhT7rHO64qdxQt9L4XOPlQ8jCIXd90X71vGcwoczWE0dP7zlSnkS7Xnf8p/u",
-	"4KCxtF8X6DsqSxZhnLIwyaMyu7PFjKw20ZZDa36x0fyo6vFJY0OCWJaIykVSfDwlJ4MByeilfXupQKrB",
-	"coQ3T5v/BgAA//9yXjMr/jUAAA==",
+	"H4sIAAAAAAAC/+xde2/cOJL/KoTugAMO8ivOZnf8nyfz2MHOJkGS2QNuJjjQUnU31xKpISl3egN/9wOL",
+	"pETq1eqHM3Y2/9ktkSKLVcV6/Fj8lGSirAQHrlVy9SmpqKQlaJD430tRFJBpJvhPufkfPtKyKiC5Si6e",
+	"XcLzP7348wn85Zubk4tn+eUJff6nFyfPn714cfH84s/Pz8/PkzRhPLlKKqpXSZpwWpqWWdhnmkj4vWYS",
+	"8uRKyxrSRGUrKKn52ELIkurkKqlrZt7Um8q0V1oyvkzu79PkO5HVJXB9vMHlbY+HDe3HGtQRx7V03R02",
+	"qDeS8YxVtDjewKqgy8MG94sCebxx1ba3Q4Z0bxqrSnAFKAzXd5QV9IYVTG/eugfm90xwDVybP2lVFSyj",
+	"hrnP/qkEN7+1H8xBZZJV5mlylQhebIheAVkwKHJF9IpqsgYJJFtBdgs5oRKIAp2kSSVFBVIzOxAoKSvc",
+	"aAocghv6jRAFUJ7cpzj/V7SEydfum0mLm39Cpu2k42G+/pvp7luav4XfDRPuNOP/lLBIrpL/OGuVzJl9",
+	"qs6+l1LIoS9+S3PiP3afBjpoL6JPDaHtenzmLyVQDSjOaq8BxGvnBBn/ZhpKNYMTmx+olHST3N+HXP1r",
+	"2+WH2cv5spYSuG70wREmBh8rJkFd6z6nr1fAkdO1uAVO3JtJ2s47pxpONCthaPJVrLW2Eqt5/z0+mWaB",
+	"N9HLTnC2NTKqKhSyEeGuJCjgmiyEJOZVYoZqiaAGVWC4qrFijec0f6G/56qW8HrNQR5hjSVU1I6vO1+j",
+	"W0mzzn4XJSuaEy6IMN8nlOdW39GiuKHZrft5TRUpaQ6EaWV/StIhTRUSpxnIfEr8CNqbCy9FzfcU5rhn",
+	"KrMVu4O8mbBCnc2FJpn5BuR93Z0zLWTEx4zrF8/bOTOuYWn5y1Jj3rt3DNYzX+4Q09PcDa3pai/a/pUp",
+	"LeTmCMyWrShfQqwop4SyWV1s19eaaZLVUlna93TGiqq/CzkgyAtaKCCCZ2B4V4JbYFIK3KhxiIQutJHu",
+	"FVOkoksY4N80KVjJBnSjEQjThij2L3A2AFVGXeSoN5pO3UdyWNC6MIzGc5IVtKzMDNJo0S+fbV90T912",
+	"6n6Iey37MdZ7fHUa8dqZGYbYYL+1DkT86a12S8AD1/sNyJIpxQR/vTjMepjclJuvTA7m3YoaDnlXlyU9",
+	"jsoRRUFvhKRaSNwkhleQ1+UNSCIWpNmWVbzvMUXUikrIyZrpVdruCIwv8U2vc2co9nBQanhApVCaSMiA",
+	"62JDSpGzBYOchC1J1dBUJek8IQqXoS9GzeY0eyWHt514funAIszn0J+Z0m+A54YrDP2PYbFXYX+zFVA4",
+	"iq0WfPyJXafbrOtr/nnU8X4KNGDAJ6FC0yQUmfnrPiozafLxZClO3G+/fvjvCeGIpXV/lW045K1TDNdZ",
+	"BkpB/tj2aj+ur3v2A+zZhgFQrzTkVY9ROTytlUoTFZN0Nq/HS7F1Y+h+5iBOEEvGjxfo+Yl3fc0RUmG8",
+	"Y4BVOlO1r6VB93Om9q5G5bGoC4LzMx98JfQPoub5w0cqXwlN7KdMcF2oY7pDeZTb2B4+H1IdP+0SIDHj",
+	"N4GtI4x91xjaPnNsAvzmjx2m+RaoUmzJj6kOS3EH+SyHodVzqJ64WJMbKITxCgQ6BsoytJjlHHRIEgxj",
+	"B3oI7ePbPzN+e6wQ93sv9fE3KbkBKo2CN8+dVgaCTSxJnPw7FX15TnK6UWkbPlaEKVVDTm5gYXYO80Ca",
+	"OTDBzXZiiGmiikLebuWhYKTzCfYO9Jv6pmCZtVuO4WEE3W21L8N3jY2K/5uVm0dxpPFSUsuCQGx7QrFD",
+	"UsAdFETEUdyURNHsJsrrmhaM3xqHF7hJM20X3Gi2O5DdbIUH6deS8TcB3S+6odkMMz35SP5C2WADOvSE",
+	"Yjw/JRj2Zgskh/mF5CxHZ39F74DQwOfpEtWzrzE9rGVku2GSwEemMFAQtEY7psqphnzQIlq26eat+ZF9",
+	"5XOID6h9hONz9Dsl77upnliYcc5Ki0qhlJqZApUFA9lYh5apxKL9uOEwFHMYnJPzoLclJnCNzFjFYgES",
+	"clwibInr64xGvYKNXUEtUDIqPUh0uwVZk+5/mF7N28T24XezWaqjJHCUMXiH8lVA7ENcc6DZylGEWcoJ",
+	"mdsdzPwjXXZ2ZhzJjB6n8xa/sNXq9aOcrxx+4bTWK+DaEALmWH4NxuBTUoJSxtO4SoJOjNCh8PElEZIw",
+	"fkcLhnbegTbjdfyNZt7NLIRk/9p/CujjIJszhZqIFoVYQ25YuQJp2NP6QdTlvdNjGMHX9iO4kq4BIia6",
+	"nn2PIal743rEciqo0kSz0mqFjBaF4cIKOOSROp2dTM6DocxNILR6eH7052ezk44a5knUaRqSoc/0FgLh",
+	"Q6LdTwx7wc1Ldkt35mZGObkBu/1blqBRkDi1cWm1YpV517BP8PrNxm+CSZoAr0szI5crbLKHH7o0j6Ne",
+	"PQKF+I7hgHyIP9u6t7k96Hrr+r5sXrz3mKGBYAaSYx/vpANy8/24T4XjHFzvcBbx6r794eXl5eU3KBNK",
+	"07IyCvqX9y9TwnhW1DkospBWtmlBFGSC56rZADcu6MLJv0CKJG11SPLs/NnlycWzk4vL9xcvrs7Pr87P",
+	"Ty+eXRr01V+++d/Z8jUh6i5ZPokQacwjs0n7FinJCtY6TWrDs9CRMsQilJNeNp5QRXIowNoM88b/mVNA",
+	"p+R1z6ZagsaXBA/pYcxOt8Qve2Ocl0jaSzb8CL4LaTAR950pqP71V2OiZzT/310ma/uQf47fjpT2hDQ1",
+	"fOd2FsN1jXZEY3zcgh8yhwtnuYex25kgp1Yx9iY+Oeb/UlNehpkQ7qE2358fedC7OK4zt0bUki2r9hhh",
+	"SF92oB99r4Y758kbsEYLG7MueM08osHiUovLbMPIKMCWimYrRHfCdrqiVvYV9lrk6OBwWJM7WtTQgwDR",
+	"TAu3qQzs3F6d2A8jMKr9VJJulyw7xrn7pZ3QtY7enlx0DuttuoDDelSuRZFvay6KfKT5IIgFOcYTNZzS",
+	"FKu8F+WN0oLDQOTVbhm70ORIwdo0+PbQ4K0N3hswMir+RfOc2a3/TfRGf8AR45W0Utbnc36O9/C8DAjn",
+	"9lElOGGaLCgrICf4LjolKXGRAttgvRIKLPubjZAWEmi+IZpiCCrqzUlkJviiYJn+jScDE2/8m0/bHeo0",
+	"2aZBH7sNFWEHRhMD+3kwjdOwk672Ca9dZMK2+HYzrOcsHtaoOB+KMb9imyTdU4KS/kSDYQRzGJKtN5GT",
+	"NxgZ3NFycq0sBWYbRDMV9+Hm0XTAjBVA4v1o5fZAGxALFyzthdFcgJkLF1geDJ8dzpTN4GajvMdh1kka",
+	"a+I+J7XrubOBMuC0jznQHlj1YUgxhPPt5BA+I0j+IKR6MAv/aU8KzOS5+PTw/Dv25vbARxjrWIkiB6ms",
+	"oRcmLzqWH0fHiymTzlDdTEcQ+jDvJWlvAQcjIKbNyR2VnJZmvX6NpvLKdhT+9A/fafjj9+4DPjo8EVd7",
+	"lEjG3XeucUU/ByVoz5QdS53jqatBY4qp60yzu5GTWIdq6pJ+jHAmMyAXs3Pq8RGWHRLur2wYy9KkM8aA",
+	"IDsrym6qYGztRvatx5CKOxjQ4Kc4kP5IEwVZLZnevDP8YkliM3Ymr9D+94P/9D/XhvLIXThOfNqOZaV1",
+	"ZYP6jC9En6rvMVVQMaIqyAxginGnEw27yQXNgNyAXoOLSZhXl1TDmm6a8z42eGfTgtdvfiI/uucsUq7A",
+	"tdxUgrnzUubJHZVM1IqYw0LAc1KyTAoF8o5loE7JT5oIma1AaUk1KO+zKKPry7rQrCogboNDqqS4Y7n5",
+	"h2RiBYrdhZPx37aDNl3VCs1bptHEDyfw1/fv3zTEYQuXnzFbAkhrSCbnpxen5+jTVsBpxZKr5PL0/PQy",
+	"SfG8Kq7fGc1Lxs8i4OQSkPcN51Mf/jaQ92vzaihq4XntX4dUvIXqEQm6lrwNXVQS7pC4DmSHR2h/r0Fu",
+	"gpPa2DQJM0Q9Fp4NdxFmCJIBUtvII11C2kLwtCAX56fkH8ZlVETcgSQX5+foaiEyz27hF+fnFgoyBvNj",
+	"qp2py1z6M7y/8ZFpWiDd4Mlgr2JLxllpdv2LIRDO4OG/dupi3dDd5eZGBtJmCXY4N73l406ZtGgZI3XW",
+	"Yxtccmfj4tvDA5lw+maPxrh9MoR6bh/StXl59xF96BzifnZ+PrYFN++dDR1suk+T53PaBielscnF9ibd",
+	"7DW2u5zbzuWLcXOwJ2GSK6M/CNyBbIlPJCypzAtQaAGvVwLTgQqAMGP0wtrGe6RCVc2UkSVcvhKo1YQ3",
+	"LuaMzIxKS6WoMF0EBP9WdVUJqR2mFiivK7MsdIlmb6u6PpgBd1Xf2afWB7s/Azy8etIcNOnouiHitK+c",
+	"BcUhDBdUQg2oVAN6jHVq2yw4O+vqCIDS34p8cwAAw599fb1vYrHbwTBGIi56cL+PDAydHH70MmAaPd/e",
+	"qEEIx0JT0lsYOqHcuDk+WNl6R4vueWfVnHeORcPqOuojpyVbWi7cQdamxKjWq7MCAdBXn6ZYvdYri5M+",
+	"FkNXVKm1kMjJJf34M/ClsUNfPMdN0//7ly2eR9Dy8lnU8nKO2ey8kWYsDyYUMYb+c4pDYPonV79+6Op6",
+	"Sjx+3rOIWeqQO0qYtCtrvfo7JPvQZLSWxEGiv78UG3IMZNKCLK9xacIvEqqGCZfF0JRRsQogLMeSK96X",
+	"DGP8hqJxsU00sI8Hk4WBuixPy0ayhqWDQNmZhLZ6wy5aECGXlFtvA5hsDdo0bp0LsDFyjDp6ULXLdzWN",
+	"jGPCdMBxXW3e9nj2KQQS3TeGUmQntTnLPnd+h7+3SxUW9PKf/S6ueRXxwfN+SOD13x7/Oh+mQSSYkxRB",
+	"TJgspCjjpe7qFLE2xjC2NPGioC2+6cCYziUN+jG6iHBxIsas5HRHszdc4eQ+3fp+10yuh3Rcrb+y0E4s",
+	"RPO8xwMBtxg7sReSFHM4zGT4h/lrD94yyiYMyk9rke/a6Ptx9rhWhx2zHFbY6xwEeWzQ+0JsQQxwjbnM",
+	"Fki4be8cYPhXgrx0NHpae+QN1dnKzZ0Az9sALf5mWLFgSqso2Deqycasz4CzJuOZ1EczTeRCVBagUWyM",
+	"s2TCDgVzB30rumTcB2O/RjYPiWwOdTsAstixrEsDAewS2QFwyBQwE4FKLcaX5/5tf7Kth3ocIY/7WDus",
+	"9x4QpKI5uaVMrjA7NFScbDDsaQSjY/l1dbrSVMrR8S3onZBMgzIY4QNHRAsl7Ij6RcvSFtZk3nC5Xmfr",
+	"Nq9TdxDDVqScoOe1a/FANETmZyrY6NKQpGWttNkrO5vhmCKIcfrz4+7/xnFlWhRDbE3Jkt0Bt8lXD4m1",
+	"P0WGzridO+pfP5jlsRuovQcqZjlZAjejdXE6A2dcFIzDCQbSvQXhk4oGE9ziTMwvJjMJsulFIegf1RlD",
+	"o06UTGvIUcMfjqk/MDX9YKGEwdIJT8JD+Obhi0zQziEQWyXAWTuB4dqgbBG0oMaCHSEPImNSn5Lc5iGc",
+	"Ie5nMj0dV9hMDtSRnTqdf7Dai+0/igCXia3doM0cqLoHShN8DrkDiOY2ejvE8F4EH6+Z9ggIjtiz9ri2",
+	"FiGJO4e1I3DqBnpJzestINVuoI7UXLPCKWLf8W+zls7W4puzcrY411cgx2Nwdz7sKz6jFdaennHXNew6",
+	"h67TTq1J+6uVsDmCYbGkcwTDIly/CsaTFoyxynNPXS4G00ToBEkI8dLGaS6AKo12fXzUPtycZonOhmez",
+	"BMe8t0VsOrCspmJsB5GF0/HclhK25AJnZiu/OLljqjFHR9hPMZ7BvKs4dgKXfRX+Ryv8Tz/SwXgmwYzf",
+	"HAHc8CwlA2pg0VMA/oSwx/vYgLlZK1ZCak4HQxOXnC/7u6V7v+CsXLRELi1Bwyow+yUeIkpNak47sDZC",
+	"SrQ/SGyPETWWkR1c6KFzpYHm5jUujOtd8zwlSrRFLYxr4itdmAyHhgLdD1pR2eSg47wfNyBP6/GbyltY",
+	"TMKyo/NIV1Dk06cffLRp3VbLaFi9mdwp+S5QZRjD/Y1Ph4HbE9YDGnYi7uuak/3OJ3l65qApK4g5nKVI",
+	"LpDkHKyaDZ1LdActR+0xx6FKHBNzHVGlR4kfBYem7tMnKNlp8vzi+NRomXBb5tewf1d4XbWMtlRGl7WR",
+	"/UAP2Ith8ZYjITtmIjVGtP9+YfOtNRMLoLITRe8qTATTIK3jahtxMZW23lQmyhvGvQU9EKoPawQEB69w",
+	"LMPXMQWDsKU/dv266Xbks7vmEsZP282CKpi9QHUnMIJZ0MJZGvuhFp6cQeCLvGyXvVHr6szlO/exslzi",
+	"88s0tiQobU9J9jPITk+2OHljvfgGTJ+St/j3BDCu6fLoeLi9teYXvZqO3oROr6F/zazh9cDC+4qC0hi2",
+	"N7WOiiQJ6U2zMJDu2CJPXW/IFENd74Rei2UYKwioBzwy1OcWe0Hk0RLU2Zwz+8vmuKvN7qXxnuBOvRIJ",
+	"Zj9R9ti+Cz4w/J8v2LI2C5TRKkl3iwvsXL9jqohlv3zieBHzY0DXh+70/MIRqS4BrIzDSAvPO4KHGplq",
+	"rMAcn1wS3IU0bbGMCiQxwTVYg5x1eKlWrTNK77x2CW8D8mDXgT27ZZRpiV/ZawfnxEdbmXV3FT76HIO/",
+	"ZvBrkHE0yNi9dvILF2YfwQg4o/FtKM+n6hp2cuJ8ExyUcrXtJ4JF1rCjOW4gq0Z+jm6ojUt6XIx5vrC3",
+	"muQryviR10+w5TQ2ZCXWbgR24rnbS5zRuWCFRlTIzaYHsbEXCRUiB59smgYy/4B9RZPY8bq3pihZ9+oy",
+	"pTeF+cEQJelPtgDqIhOh2LWJBWMfm2kTUWv/u6vYmhKhVyBbAVbERQHsHouUsClJzYqCFC5KPD/MCR8x",
+	"3vUOisWukc2LucijqesLny44tmv1xDqVRjDQz2lCpZ/PJYo07r5uERf4Uh+N+l68c8UisQiV/xdnGLlS",
+	"0eOeR2Ut2yab05altAhmpgi2D7M3bkEIy8fjht0xNhrRV8zqFxHjd0wPDBChb+3YBi8s6SHZrMPdNkLw",
+	"mkIPvJ2HT3/kTEKmi41LH+F6gKt3Onzrje8Xsfb2kgkLPR2otdUfcsemGAmrHtO7TDs8s+89LUdwPYev",
+	"Mfr38D3pqBYkwHAna6+Y6OQokf14W4/N7ojSBj5sXNEAm+1VQfjQYu4P8yrb4Z41e+zZp6De5V7J+Pbr",
+	"Tc2EN1EJzS85Ve8XzsUfOnsYnbOB7WPzz6P0PJ9z+g70pwlti21PGviEc1dlf7Ni+xn1cNF2C+LP4ICj",
+	"VME56mZ1zNq8vaOa2+rzztnnjqeRhtJ3g3V8xSJQHv7WuXm8OUu/K+PiHKTMnZP0tfpBlG4fdmpBxmuX",
+	"xjW2aBRw4gJBtJiGehi/ZjaHnFlL+rNmlmIOu/bXEc7nsyfCNJa0jmkif2Eb30THeZwHhGg5M6X4sNUe",
+	"mgGd7z9oxfHex6PtUkinuNTGQ25jBxdC7g5nqGB0ST/+ZCfjy2P5f+dcra6SD5/F0Yvv7/zCtb8Vx+6x",
+	"VZ94tLGswUyjQ7ja9jmjhVjaqtHh0f4VXrDkj1Xg+UpfGAIthbSpO21rl2BE26ws1nkj1FQ+LbDEN+Ub",
+	"9634qsx91AXWsD6hzc0EnwmjEl2IcDRjdu/rqHe/6fkYgjZyH/fTcsTs7dl4J5m9eaJ3xXYnj/fQYeN5",
+	"SBsXrTlpJrJLSu6dbfzOtd3THY97eeJ++IAJjLfrWMSFeWCRO+3Gq1qFFyAGwhc+t82sNJX7uFLvTLtH",
+	"6EDFPio304uWx/40hW7E548K3PhYaf1HmCvxes6p1ZeSYMX94u4GXMQAxtknd2///Zm923530+FH28EW",
+	"HwPfcq++tV/aR9vaptjPz4zf/rsYtO5SRB+bwkrLbl9dA5qPqvbXadvAlLF4Tb6+KZJn2uGjoargRqUH",
+	"CLsC6C3k1vK13d0CVApfC9jQaH2b9cABhdCNY+LygiyMc8SX7u7/cSO5dtcujZkCxh3qo3AGAQguXTrj",
+	"6PBIZvVBD13hRJ7ugasDZKIQ4pbUlQ/K3mxsnnyWXdorZ2/DHNM1wBzPHMfF2Xqn1hbkc1j0frrKfVVQ",
+	"PnJVXUGbmKiRYptbRxQWgr2UKJpzU4jXaQXx91poahP04Uy8ueFuOMrDzHpcBOx7f5fZdlxCVKZ/h+Lj",
+	"TbvwiwfX6L+YV0zMMMvXQmJj167626/NDw7d0t7Ry7CCX9pe5tscNgPTsW2sVxYXbo+8i6JguQ2vmP7/",
+	"z/ePff/Gx8AAnbJjXgn4DeSM3lFW0BtW4J0H07vJdfjurJ0l4M/ouOMUg0/vUYfvSdNME87xqZbcX0F2",
+	"axQXoj3c3pGJushxh3C3Q8VorAbU3LKru8xKsSVHMEiVxoVWLMOW1iSyCqVzNj7AMznzKaOc2OGFfEdu",
+	"IKO1AsK0OfUABmPZDJ+30iNhyRSiUaMCYT2e/mSD2zNwI6bpLz4S/kUiQrCE3qQqSCdlfow6X827YWTq",
+	"OJV3czkd3acCDp3lOcqFKLB+E5hdPTtFFPnE845xEr6cRl3/0YCIP/zwtFOKttKBd12tr1i1JNuq4M4k",
+	"UGUU9El0r+X+nDbpGNgX37pPhvdjHilzWmNuQeyVzwwbP1gWpDf3J2ohNHewNVSL4+KdSw2jQmwddHIh",
+	"lL2rlsnekSahgjpOe9932FSpt98zGJUht7ZzeVd8Y++vHwx72zrQVihqWbibedXV2Rmt2Kl9eqpB6bO7",
+	"CxN8//8BAKZd6LG3swAA",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -2,27 +2,33 @@ package server
 
 import (
 	"net/http"
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-
 	"github.com/townsag/reed/api_gateway/internal/config"
 )
 
 
 // TODO: at some point may want to factor authentication code out into its own package
 
-// the differentiation between a user type claims and a guest type claims is implicit for now
-// one can tell that they have a user type token if the token has a UserName
-// this may not be the best way to do this but I will look into other approaches when I understand
-// the encoding json package a bit better
+// the type of principal that a token was issued to is stored explicitly in the PrincipalType
+// claim. Tokens issued before the claim existed do not have it, for those tokens the type is
+// inferred from the UserName, a user type token has a UserName and a guest type token does not.
+// Public link tokens are guest type tokens that also carry the id of the document that they
+// were issued for in the PublicLinkDocumentId claim and the version of the public link of the
+// document at the time that they were issued in the PublicLinkVersion claim. User type tokens carry the token version
+// of the user at the time that they were issued, the token is revoked once the user service
+// bumps the version. Guest tokens carry the token version of the guest in the same way
 type CustomClaims struct {
 	UserName string `json:"userName"`
+	PrincipalType PrincipalType `json:"principalType,omitempty"`
+	PublicLinkDocumentId string `json:"publicLinkDocumentId,omitempty"`
+	PublicLinkVersion *int32 `json:"publicLinkVersion,omitempty"`
+	TokenVersion int32 `json:"tokenVersion,omitempty"`
 	jwt.RegisteredClaims
 	// ^this is called struct embedding, it adds all the fields from the jwt registered claims
     // struct to the custom claims struct. They can be accessed as if they were elements of 
@@ -38,12 +44,76 @@ func (c CustomClaims) ParsePrincipalId() (uuid.UUID, error) {
 }
 
 func (c CustomClaims) GetTokenType() PrincipalType {
+	switch c.PrincipalType {
+	case PrincipalTypeUser, PrincipalTypeGuest:
+		return c.PrincipalType
+	}
+	// fall back to the implicit differentiation for tokens without the explicit claim
 	if c.UserName != "" {
 		return PrincipalTypeUser
 	}
 	return PrincipalTypeGuest
 }
 
+// how long a public link token is valid for, holders of the public link have to ask the owner
+// of the document for a new link after this
+const PublicLinkTokenTTL = 7 * 24 * time.Hour
+
+// the version of the public link that the token was issued for, nil when the token is not a
+// public link token of the document. Public link tokens issued before the version claim existed
+// do not have one and are not honoured
+func (c CustomClaims) GetPublicLinkVersion(documentId uuid.UUID) *int32 {
+	if c.PublicLinkDocumentId == "" || c.PublicLinkDocumentId != documentId.String() {
+		return nil
+	}
+	return c.PublicLinkVersion
+}
+
+// public link tokens expire after PublicLinkTokenTTL. The document service stops honouring
+// them before that as soon as the public link of the document is disabled, disabling the link
+// bumps its version past the version in the token. Each token gets a fresh subject so that
+// holders of the public link can be told apart
+func signPublicLinkToken(documentId uuid.UUID, publicLinkVersion int32, keys *config.JWTKeys) (string, error) {
+	now := time.Now()
+	return signToken(
+		CustomClaims{
+			PrincipalType: PrincipalTypeGuest,
+			PublicLinkDocumentId: documentId.String(),
+			PublicLinkVersion: &publicLinkVersion,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer: "reed",
+				Subject: uuid.NewString(),
+				IssuedAt: jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(PublicLinkTokenTTL)),
+			},
+		},
+		keys,
+	)
+}
+
+// how long a guest token is valid for, the owner of the document issues the guest a new token
+// by rotating the link of the guest after this
+const GuestTokenTTL = 30 * 24 * time.Hour
+
+// guest tokens expire after GuestTokenTTL. They are revoked before that by rotating the link of
+// the guest which bumps the token version of the guest past the version in the token
+func signGuestToken(guestId uuid.UUID, tokenVersion int32, keys *config.JWTKeys) (string, error) {
+	now := time.Now()
+	return signToken(
+		CustomClaims{
+			PrincipalType: PrincipalTypeGuest,
+			TokenVersion: tokenVersion,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer: "reed",
+				Subject: guestId.String(),
+				IssuedAt: jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(GuestTokenTTL)),
+			},
+		},
+		keys,
+	)
+}
+
 var SubjectNotFoundError error = fmt.Errorf("Subject not found in JWT claims")
 
 // get a token
@@ -57,16 +127,16 @@ func (s *Service) PostAuthLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// use the users service client to validate the credentials
-	userId, isValid, err := s.userServiceClient.ValidatePassword(
+	userId, tokenVersion, isValid, err := s.userServiceClient.ValidatePassword(
 		r.Context(), reqBody.UserName, reqBody.Password,
 	)
 	if err != nil {
-		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+		if GrpcToHttpStatus(err) == http.StatusNotFound {
 			// if the user is missing, send a 400 error
 			SendError(w, http.StatusNotFound, fmt.Sprintf("no user found with username: %v", reqBody.UserName))
 			return
 		} else {
-			SendError(w, GrpcToHttpStatus(err), err.Error())
+			SendGrpcError(w, r, err)
 			return
 		}
 	}
@@ -77,10 +147,11 @@ func (s *Service) PostAuthLogin(w http.ResponseWriter, r *http.Request) {
 	}
 	// if the credentials are valid, construct a token that includes the username and a generic scope
 	// us the golang-jwt library to make a token, maybe put this part in a package 
-	token := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
+	signedToken, err := signToken(
 		CustomClaims{
 			UserName: reqBody.UserName,
+			PrincipalType: PrincipalTypeUser,
+			TokenVersion: tokenVersion,
 			RegisteredClaims: jwt.RegisteredClaims{
 				Issuer: "reed",
 				Subject: userId.String(),
@@ -88,10 +159,10 @@ func (s *Service) PostAuthLogin(w http.ResponseWriter, r *http.Request) {
 				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 60)),
 			},
 		},
+		s.jwtKeys,
 	)
-	signedToken, err := token.SignedString([]byte(config.JWTSecretKey))
 	if err != nil {
-		SendError(w, http.StatusInternalServerError, err.Error())
+		SendInternalError(w, r, err)
 		return
 	}
 	// return a 200 response with the validated token
@@ -101,4 +172,41 @@ func (s *Service) PostAuthLogin(w http.ResponseWriter, r *http.Request) {
 			Token: signedToken,
 		},
 	)
-}
\ No newline at end of file
+}
+
+// get the principal that the caller is authenticated as, so that clients do not have to decode
+// the token themselves
+// (GET /auth/me)
+func (s *Service) GetAuthMe(w http.ResponseWriter, r *http.Request) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	response := CurrentPrincipalResponse{
+		PrincipalId: principalId,
+		PrincipalType: claims.GetTokenType(),
+	}
+	// every token is issued with an expiry, a token without one is rejected by the auth middleware
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = &claims.ExpiresAt.Time
+	}
+	// guests do not have a profile in the user service
+	if response.PrincipalType == PrincipalTypeUser {
+		response.UserName = &claims.UserName
+		ctx, cancel := context.WithTimeout(r.Context(), config.TIMEOUT_MILLISECONDS)
+		defer cancel()
+		serviceReply, err := s.userServiceClient.GetUser(ctx, principalId)
+		if err != nil {
+			SendGrpcError(w, r, err)
+			return
+		}
+		response.User = protoToNetUser(principalId, serviceReply.User)
+	}
+	SendJsonResponse(w, http.StatusOK, &response)
+}
@@ -7,7 +7,9 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+
 	"github.com/townsag/reed/api_gateway/internal/config"
+	"github.com/townsag/reed/user_service/pkg/middleware"
 )
 
 type contextKey string
@@ -16,6 +18,7 @@ const (
 )
 
 var ErrorClaimsNotFound error = fmt.Errorf("no claims found in this request context")
+var ErrorMalformedClaims error = fmt.Errorf("poorly formatted jwt claims")
 
 func GetClaims(ctx context.Context) (*CustomClaims, error) {
 	claims := ctx.Value(claimsKey)
@@ -29,6 +32,39 @@ func GetClaims(ctx context.Context) (*CustomClaims, error) {
 	return customClaims, nil
 }
 
+// verify the token with the current key or any of the previous keys so that tokens issued
+// before the signing key was rotated are still valid until they expire. A token without an
+// expiry is rejected, guest tokens issued before they had an expiry would otherwise never expire
+func parseToken(tokenString string, keys *config.JWTKeys) (*CustomClaims, error) {
+	verificationKeys := jwt.VerificationKeySet{}
+	for _, key := range keys.VerificationKeys() {
+		verificationKeys.Keys = append(verificationKeys.Keys, key)
+	}
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&CustomClaims{},
+		func (token *jwt.Token) (any, error) {
+			return verificationKeys, nil
+		},
+		// tokens are signed with the HS256 method when they are issued by the login handler
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	customClaims, ok := token.Claims.(*CustomClaims)
+	if !ok {
+		return nil, ErrorMalformedClaims
+	}
+	return customClaims, nil
+}
+
+// new tokens are always signed with the current key
+func signToken(claims CustomClaims, keys *config.JWTKeys) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(keys.Current)
+}
+
 /*
 Some notes:
 - based on the implementation of parse with claims and the below stack overflow thread
@@ -38,12 +74,27 @@ Some notes:
 - also look at this jwt documentation example
 	- https://pkg.go.dev/github.com/golang-jwt/jwt/v5#example-ParseWithClaims-CustomClaimsType
 */
-func AuthMiddleware(next http.Handler) http.Handler {
+func NewAuthMiddleware(
+	keys *config.JWTKeys, tokenVersions *TokenVersionCache, guestTokenVersions *TokenVersionCache,
+) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return authMiddleware(next, keys, tokenVersions, guestTokenVersions)
+	}
+}
+
+// the token version of user type tokens is checked against the current version of the user
+// when a token version cache is given, without one only the signature and expiry are checked.
+// Guest tokens are checked the same way against the current version of the guest, public link
+// tokens do not belong to a guest and are not versioned
+func authMiddleware(
+	next http.Handler, keys *config.JWTKeys, tokenVersions *TokenVersionCache, guestTokenVersions *TokenVersionCache,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// check if the path is /auth/login
-		if r.URL.Path == "/auth/login" {
+		// check if the route is exempt from auth, for example /auth/login
+		if isAuthExempt(r) {
 			// if so, then continue without validating that there is a token
 			next.ServeHTTP(w, r)
+			return
 		}
 		// read the token from the Authentication header
 		headerValue := r.Header.Get("Authentication")
@@ -57,27 +108,61 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			SendError(w, http.StatusUnauthorized, "poorly formatted header value for Authentication header")
 			return
 		}
-		// validate the token body
-		// attempt to validate the token body first as a user type token then as a guest type token
-		token, err := jwt.ParseWithClaims(
-			tokenString, 
-			&CustomClaims{}, 
-			func (token *jwt.Token) (any, error) {
-				return []byte(config.JWTSecretKey), nil
-			},
-			jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}),
-		)
+		// validate the token body, an expired token or one with a bad signature is unauthenticated
+		// so that the client knows to log in again. Forbidden is only for valid tokens that lack
+		// permission
+		customClaims, err := parseToken(tokenString, keys)
 		if err != nil {
-			SendError(w, http.StatusForbidden, err.Error())
+			SendError(w, http.StatusUnauthorized, err.Error())
 			return
 		}
-		customClaims, ok := token.Claims.(*CustomClaims)
-		if !ok {
-			SendError(w, http.StatusForbidden, "poorly formatted jwt claims")
-			return
+		// reject user type tokens that were issued before the user was deactivated or changed
+		// their password
+		if tokenVersions != nil && customClaims.GetTokenType() == PrincipalTypeUser {
+			userId, err := customClaims.ParsePrincipalId()
+			if err != nil {
+				SendError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			version, isActive, err := tokenVersions.Current(r.Context(), userId)
+			if err != nil {
+				if GrpcToHttpStatus(err) == http.StatusNotFound {
+					SendError(w, http.StatusUnauthorized, "the user that this token was issued to no longer exists")
+					return
+				}
+				SendGrpcError(w, r, err)
+				return
+			}
+			if !isActive || customClaims.TokenVersion != version {
+				SendError(w, http.StatusUnauthorized, "this token has been revoked, log in again to get a new token")
+				return
+			}
+		}
+		// reject guest tokens that were issued before the link of the guest was rotated
+		if guestTokenVersions != nil && customClaims.GetTokenType() == PrincipalTypeGuest && customClaims.PublicLinkDocumentId == "" {
+			guestId, err := customClaims.ParsePrincipalId()
+			if err != nil {
+				SendError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			version, _, err := guestTokenVersions.Current(r.Context(), guestId)
+			if err != nil {
+				if GrpcToHttpStatus(err) == http.StatusNotFound {
+					SendError(w, http.StatusUnauthorized, "the guest that this token was issued to no longer exists")
+					return
+				}
+				SendGrpcError(w, r, err)
+				return
+			}
+			if customClaims.TokenVersion != version {
+				SendError(w, http.StatusUnauthorized, "the link of this guest has been rotated, ask the owner of the document for the new link")
+				return
+			}
 		}
-		// add the custom claims to the request context
+		// add the custom claims to the request context, the principal id is also sent to the
+		// backend services so that their logs show who made the request
 		ctx := context.WithValue(r.Context(), claimsKey, customClaims)
+		ctx = middleware.WithPrincipalId(ctx, customClaims.Subject)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
\ No newline at end of file
@@ -0,0 +1,342 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/api_gateway/internal/config"
+)
+
+var testJWTKeys = &config.JWTKeys{
+	Current: []byte("test-current-key-that-is-at-least-32-bytes"),
+}
+
+// sign the claims and parse them back the same way the auth middleware does
+func roundTripClaims(t *testing.T, claims CustomClaims) *CustomClaims {
+	secret := []byte("test-secret")
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		t.Fatalf("failed to sign token with error: %v", err)
+	}
+	token, err := jwt.ParseWithClaims(
+		signed,
+		&CustomClaims{},
+		func (token *jwt.Token) (any, error) {
+			return secret, nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+	)
+	if err != nil {
+		t.Fatalf("failed to parse token with error: %v", err)
+	}
+	parsed, ok := token.Claims.(*CustomClaims)
+	if !ok {
+		t.Fatalf("want claims of type *CustomClaims, got: %T", token.Claims)
+	}
+	return parsed
+}
+
+func testRegisteredClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer: "reed",
+		Subject: uuid.NewString(),
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
+
+func TestGetTokenType_UserClaim_Unit(t *testing.T) {
+	claims := roundTripClaims(t, CustomClaims{
+		UserName: "testUser",
+		PrincipalType: PrincipalTypeUser,
+		RegisteredClaims: testRegisteredClaims(),
+	})
+	if claims.GetTokenType() != PrincipalTypeUser {
+		t.Errorf("want token type: %v, got: %v", PrincipalTypeUser, claims.GetTokenType())
+	}
+}
+
+func TestGetTokenType_GuestClaim_Unit(t *testing.T) {
+	// the explicit claim takes precedence over the presence of a user name
+	claims := roundTripClaims(t, CustomClaims{
+		UserName: "guestName",
+		PrincipalType: PrincipalTypeGuest,
+		RegisteredClaims: testRegisteredClaims(),
+	})
+	if claims.GetTokenType() != PrincipalTypeGuest {
+		t.Errorf("want token type: %v, got: %v", PrincipalTypeGuest, claims.GetTokenType())
+	}
+}
+
+func TestGetTokenType_LegacyClaims_Unit(t *testing.T) {
+	// tokens issued before the explicit claim existed are still typed by their user name
+	userClaims := roundTripClaims(t, CustomClaims{
+		UserName: "testUser",
+		RegisteredClaims: testRegisteredClaims(),
+	})
+	if userClaims.GetTokenType() != PrincipalTypeUser {
+		t.Errorf("want legacy token type: %v, got: %v", PrincipalTypeUser, userClaims.GetTokenType())
+	}
+	guestClaims := roundTripClaims(t, CustomClaims{
+		RegisteredClaims: testRegisteredClaims(),
+	})
+	if guestClaims.GetTokenType() != PrincipalTypeGuest {
+		t.Errorf("want legacy token type: %v, got: %v", PrincipalTypeGuest, guestClaims.GetTokenType())
+	}
+}
+
+func TestParseToken_RotatedKey_Unit(t *testing.T) {
+	previousKeys := &config.JWTKeys{
+		Current: []byte("test-previous-key-that-is-at-least-32-bytes"),
+	}
+	rotatedKeys := &config.JWTKeys{
+		Current: []byte("test-current-key-that-is-at-least-32-bytes"),
+		Previous: [][]byte{previousKeys.Current},
+	}
+	claims := CustomClaims{
+		UserName: "testUser",
+		PrincipalType: PrincipalTypeUser,
+		RegisteredClaims: testRegisteredClaims(),
+	}
+	// a token signed before the rotation still verifies after the rotation
+	oldToken, err := signToken(claims, previousKeys)
+	if err != nil {
+		t.Fatalf("failed to sign token with error: %v", err)
+	}
+	if _, err := parseToken(oldToken, rotatedKeys); err != nil {
+		t.Errorf("expected a token signed with the previous key to verify, got error: %v", err)
+	}
+	// new tokens are signed with the current key, so they verify without the previous keys
+	newToken, err := signToken(claims, rotatedKeys)
+	if err != nil {
+		t.Fatalf("failed to sign token with error: %v", err)
+	}
+	if _, err := parseToken(newToken, &config.JWTKeys{ Current: rotatedKeys.Current }); err != nil {
+		t.Errorf("expected a new token to be signed with the current key, got error: %v", err)
+	}
+	// once the previous key is retired, tokens signed with it no longer verify
+	if _, err := parseToken(oldToken, &config.JWTKeys{ Current: rotatedKeys.Current }); err == nil {
+		t.Errorf("expected a token signed with a retired key to fail verification")
+	}
+}
+
+func TestSignPublicLinkToken_Unit(t *testing.T) {
+	documentId := uuid.New()
+	signed, err := signPublicLinkToken(documentId, 3, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign public link token with error: %v", err)
+	}
+	claims, err := parseToken(signed, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to parse public link token with error: %v", err)
+	}
+	if claims.GetTokenType() != PrincipalTypeGuest {
+		t.Errorf("want token type: %v, got: %v", PrincipalTypeGuest, claims.GetTokenType())
+	}
+	if version := claims.GetPublicLinkVersion(documentId); version == nil || *version != 3 {
+		t.Errorf("want public link version: 3 of document: %v, got: %v", documentId, version)
+	}
+	if claims.GetPublicLinkVersion(uuid.New()) != nil {
+		t.Errorf("expected the token not to be a public link of another document")
+	}
+	// public link tokens expire even while the public link stays enabled
+	if claims.ExpiresAt == nil {
+		t.Fatalf("expected the public link token to have an expiry")
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl <= 0 || ttl > PublicLinkTokenTTL {
+		t.Errorf("want expiry within: %v, got: %v", PublicLinkTokenTTL, ttl)
+	}
+}
+
+func TestSignGuestToken_Expires_Unit(t *testing.T) {
+	signed, err := signGuestToken(uuid.New(), 2, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign guest token with error: %v", err)
+	}
+	claims, err := parseToken(signed, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to parse guest token with error: %v", err)
+	}
+	if claims.ExpiresAt == nil {
+		t.Fatalf("expected the guest token to have an expiry")
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl <= 0 || ttl > GuestTokenTTL {
+		t.Errorf("want expiry within: %v, got: %v", GuestTokenTTL, ttl)
+	}
+}
+
+func TestParseToken_NoExpiry_Unit(t *testing.T) {
+	// guest tokens issued before they had an expiry are rejected
+	signed, err := signToken(CustomClaims{
+		PrincipalType: PrincipalTypeGuest,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: "reed",
+			Subject: uuid.NewString(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign token with error: %v", err)
+	}
+	if _, err = parseToken(signed, testJWTKeys); err == nil {
+		t.Errorf("expected an error for a token without an expiry")
+	}
+}
+
+func TestGetPublicLinkVersion_UnversionedToken_Unit(t *testing.T) {
+	// public link tokens issued before the version claim existed are not honoured
+	documentId := uuid.New()
+	claims := CustomClaims{
+		PrincipalType: PrincipalTypeGuest,
+		PublicLinkDocumentId: documentId.String(),
+	}
+	if version := claims.GetPublicLinkVersion(documentId); version != nil {
+		t.Errorf("want no public link version for an unversioned token, got: %v", *version)
+	}
+}
+
+func serveAuthMeRequest(t *testing.T, service *Service, token string) map[string]any {
+	r := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	r.Header.Set("Authentication", "Bearer "+token)
+	w := httptest.NewRecorder()
+	NewHandler(service).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal response with error: %v", err)
+	}
+	return decoded
+}
+
+func TestGetAuthMe_UserToken_Unit(t *testing.T) {
+	userId := uuid.New()
+	service := newFakeBackendService(
+		t,
+		&fakeUserServer{ users: map[string]uuid.UUID{ "user@example.com": userId } },
+		&fakeDocumentServer{},
+	)
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	token, err := signToken(CustomClaims{
+		UserName: "testUser",
+		PrincipalType: PrincipalTypeUser,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: "reed",
+			Subject: userId.String(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign token with error: %v", err)
+	}
+	decoded := serveAuthMeRequest(t, service, token)
+	if decoded["principalId"] != userId.String() {
+		t.Errorf("want principalId: %v, got: %v", userId, decoded["principalId"])
+	}
+	if decoded["principalType"] != string(PrincipalTypeUser) {
+		t.Errorf("want principalType: %v, got: %v", PrincipalTypeUser, decoded["principalType"])
+	}
+	if decoded["userName"] != "testUser" {
+		t.Errorf("want userName: testUser, got: %v", decoded["userName"])
+	}
+	if decoded["expiresAt"] != expiresAt.UTC().Format(time.RFC3339) {
+		t.Errorf("want expiresAt: %v, got: %v", expiresAt.UTC().Format(time.RFC3339), decoded["expiresAt"])
+	}
+	// the profile is looked up in the user service
+	user, ok := decoded["user"].(map[string]any)
+	if !ok {
+		t.Fatalf("want the user profile in the response, got: %v", decoded["user"])
+	}
+	if user["email"] != "user@example.com" {
+		t.Errorf("want email: user@example.com, got: %v", user["email"])
+	}
+}
+
+func TestGetAuthMe_GuestToken_Unit(t *testing.T) {
+	token, err := signPublicLinkToken(uuid.New(), 0, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign public link token with error: %v", err)
+	}
+	claims, err := parseToken(token, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to parse public link token with error: %v", err)
+	}
+	// the service clients are nil, guests are answered from the claims alone
+	service := NewService(nil, nil, testJWTKeys, nil)
+	decoded := serveAuthMeRequest(t, &service, token)
+	if decoded["principalId"] != claims.Subject {
+		t.Errorf("want principalId: %v, got: %v", claims.Subject, decoded["principalId"])
+	}
+	if decoded["principalType"] != string(PrincipalTypeGuest) {
+		t.Errorf("want principalType: %v, got: %v", PrincipalTypeGuest, decoded["principalType"])
+	}
+	if _, ok := decoded["expiresAt"]; !ok {
+		t.Errorf("want expiresAt for a public link token")
+	}
+	// guests have no user name or profile
+	for _, field := range []string{ "userName", "user" } {
+		if _, ok := decoded[field]; ok {
+			t.Errorf("want no %s for a guest token, got: %v", field, decoded[field])
+		}
+	}
+}
+
+// the login path resolves the user name to the subject of the token in one call to the user
+// service
+func TestPostAuthLogin_Valid_Unit(t *testing.T) {
+	userId := uuid.New()
+	users := &fakeUserServer{
+		users: map[string]uuid.UUID{ "someone": userId },
+		passwords: map[string]string{ "someone": "asdfasdf" },
+	}
+	service := newFakeBackendService(t, users, &fakeDocumentServer{})
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/auth/login", `{"userName": "someone", "password": "asdfasdf"}`, "",
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response LoginResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	claims, err := parseToken(response.Token, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to parse the issued token with error: %v", err)
+	}
+	if claims.Subject != userId.String() {
+		t.Errorf("want subject: %s, got: %s", userId, claims.Subject)
+	}
+}
+
+func TestPostAuthLogin_InvalidPassword_Unit(t *testing.T) {
+	users := &fakeUserServer{
+		users: map[string]uuid.UUID{ "someone": uuid.New() },
+		passwords: map[string]string{ "someone": "asdfasdf" },
+	}
+	service := newFakeBackendService(t, users, &fakeDocumentServer{})
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/auth/login", `{"userName": "someone", "password": "qwerqwer"}`, "",
+	)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusUnauthorized, w.Code, w.Body.String())
+	}
+}
+
+func TestPostAuthLogin_UnknownUserName_Unit(t *testing.T) {
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/auth/login", `{"userName": "nobody", "password": "asdfasdf"}`, "",
+	)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+}
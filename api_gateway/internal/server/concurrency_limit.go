@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+)
+
+// limits the number of requests that each client can have in flight at once. Each client key
+// has a counting semaphore, clients without requests in flight are removed from the map so
+// that the map does not grow with every client that has ever connected
+type ConcurrencyLimiter struct {
+	limit int
+	mu sync.Mutex
+	inFlight map[string]int
+}
+
+func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		limit: limit,
+		inFlight: make(map[string]int),
+	}
+}
+
+// returns false without taking a slot when the client has no budget left
+func (l *ConcurrencyLimiter) acquire(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight[key] >= l.limit {
+		return false
+	}
+	l.inFlight[key]++
+	return true
+}
+
+func (l *ConcurrencyLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight[key]--
+	if l.inFlight[key] <= 0 {
+		delete(l.inFlight, key)
+	}
+}
+
+// clients are told apart by the ip address of the connection. The X-Forwarded-For header is
+// not used because any client can set it to get a fresh budget
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// the limiter should wrap the whole handler instead of being installed with the route
+// middlewares, so that requests for unknown routes are also counted
+func (l *ConcurrencyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := clientKey(r)
+		if !l.acquire(key) {
+			SendError(w, http.StatusTooManyRequests, "too many requests in flight from this client")
+			return
+		}
+		// release in a defer so that the slot is freed even if the handler panics
+		defer l.release(key)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func serveFromClient(handler http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/document", nil)
+	r.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// the handler holds every request until unblock is closed, entered receives once for each
+// request that reaches the handler
+func newBlockingHandler() (handler http.Handler, entered chan struct{}, unblock chan struct{}) {
+	entered = make(chan struct{})
+	unblock = make(chan struct{})
+	handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return handler, entered, unblock
+}
+
+func TestConcurrencyLimiter_ExceedBudget_Unit(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2)
+	blocking, entered, unblock := newBlockingHandler()
+	handler := limiter.Middleware(blocking)
+	// fill the budget of the client with requests that stay in flight
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveFromClient(handler, "10.0.0.1:1234")
+		}()
+		<-entered
+	}
+	// the next request from the same ip is rejected even from another port
+	w := serveFromClient(handler, "10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("want status: %d, got: %d", http.StatusTooManyRequests, w.Code)
+	}
+	// other clients have their own budget
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serveFromClient(handler, "10.0.0.2:1234")
+	}()
+	<-entered
+	close(unblock)
+	wg.Wait()
+}
+
+func TestConcurrencyLimiter_CompletedRequestsFreeCapacity_Unit(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	// each request finishes before the next one starts, so the budget of one is enough
+	for range 3 {
+		w := serveFromClient(handler, "10.0.0.1:1234")
+		if w.Code != http.StatusNoContent {
+			t.Errorf("want status: %d, got: %d", http.StatusNoContent, w.Code)
+		}
+	}
+	if len(limiter.inFlight) != 0 {
+		t.Errorf("want no clients with requests in flight, got: %v", limiter.inFlight)
+	}
+}
+
+func TestConcurrencyLimiter_PanicFreesCapacity_Unit(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1)
+	panicking := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected the panic of the handler to propagate")
+			}
+		}()
+		serveFromClient(panicking, "10.0.0.1:1234")
+	}()
+	// the slot taken by the request that panicked is released
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	w := serveFromClient(handler, "10.0.0.1:1234")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("want status: %d after the panic, got: %d", http.StatusNoContent, w.Code)
+	}
+}
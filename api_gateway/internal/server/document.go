@@ -9,6 +9,7 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/google/uuid"
+	"github.com/townsag/reed/api_gateway/internal/config"
 	pb "github.com/townsag/reed/document_service/api/v1"
 )
 
@@ -41,7 +42,10 @@ func netToProtoPermissionLevel(permissionLevel PermissionLevel) (pb.PermissionLe
 	case Viewer:
 		return pb.PermissionLevel_PERMISSION_VIEWER, nil
 	}
-	return -1, fmt.Errorf("failed to map the permission level to a valid proto type")
+	// never -1, which is not a value of the proto enum and could be sent on the wire
+	return pb.PermissionLevel_PERMISSION_UNSPECIFIED, fmt.Errorf(
+		"failed to map the permission level: %q to a valid proto type", permissionLevel,
+	)
 }
 
 func netToProtoPermissionFilter(permissionFilter []PermissionLevel) ([]pb.PermissionLevel, error) {
@@ -84,6 +88,8 @@ func protoToNetPermissionLevel(permissionLevel pb.PermissionLevel) (PermissionLe
 		return Editor, nil
 	case pb.PermissionLevel_PERMISSION_VIEWER:
 		return Viewer, nil
+	case pb.PermissionLevel_PERMISSION_UNSPECIFIED:
+		return "", fmt.Errorf("the document service returned an unspecified permission level")
 	default:
 		return "", fmt.Errorf(
 			"failed to map the permission level: %v to a valid net permission type",
@@ -106,6 +112,19 @@ func protoToNetCursor(cursor *pb.Cursor) (string, error) {
 	return cursorString, nil
 }
 
+// resolve the limit query parameter of a paginated route to the page size that is sent to the
+// document service. A missing limit uses the default and a limit over the max is clamped to the
+// max, a limit under one is rejected
+func resolvePageLimit(limit *int32) (int32, error) {
+	if limit == nil {
+		return config.DefaultPageLimit, nil
+	}
+	if *limit < 1 {
+		return 0, fmt.Errorf("limit must be at least 1, got: %d", *limit)
+	}
+	return min(*limit, config.MaxPageLimit), nil
+}
+
 func protoToNetDocument(document *pb.Document) (*Document, error) {
 	// parse the document id
 	documentId, err := uuid.Parse(document.DocumentId)
@@ -114,15 +133,60 @@ func protoToNetDocument(document *pb.Document) (*Document, error) {
 			"failed to parse the returned document id with error: %w", err,
 		)
 	}
-	return &Document{
-		CreatedAt: document.CreatedAt.Seconds,
+	netDocument := &Document{
+		CreatedAt: document.CreatedAt.AsTime(),
 		DocumentDescription: document.Description,
 		DocumentId: documentId,
 		DocumentName: document.DocumentName,
-		LastModifiedAt: document.LastModifiedAt.Seconds,
+		LastModifiedAt: document.LastModifiedAt.AsTime(),
+	}
+	if document.PublicAccess != nil {
+		publicAccess, err := protoToNetPermissionLevel(document.GetPublicAccess())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the public access of the document: %w", err)
+		}
+		netDocument.PublicAccess = &publicAccess
+	}
+	if document.ArchivedAt != nil {
+		archivedAt := document.ArchivedAt.AsTime()
+		netDocument.ArchivedAt = &archivedAt
+	}
+	return netDocument, nil
+}
+
+func protoToNetDocumentChange(change *pb.GetDocumentHistoryReply_DocumentChange) (*DocumentChange, error) {
+	changeId, err := uuid.Parse(change.ChangeId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the returned change id with error: %w", err)
+	}
+	actorId, err := uuid.Parse(change.ActorId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the actor id of the change with error: %w", err)
+	}
+	return &DocumentChange{
+		ChangeId: changeId,
+		ActorId: actorId,
+		OldName: change.OldName,
+		NewName: change.NewName,
+		OldDescription: change.OldDescription,
+		NewDescription: change.NewDescription,
+		ChangedAt: change.ChangedAt.AsTime(),
 	}, nil
 }
 
+// public access of none disables the public link, it is mapped to a nil proto permission level
+func netToProtoPublicAccess(publicAccess PublicAccess) (*pb.PermissionLevel, error) {
+	switch publicAccess {
+	case PublicAccessNone:
+		return nil, nil
+	case PublicAccessViewer:
+		return pb.PermissionLevel_PERMISSION_VIEWER.Enum(), nil
+	case PublicAccessEditor:
+		return pb.PermissionLevel_PERMISSION_EDITOR.Enum(), nil
+	}
+	return nil, fmt.Errorf("failed to map the public access to a valid proto type")
+}
+
 func protoToNetPrincipalType(principalType pb.Principal_PrincipalType) (PrincipalType, error) {
 	switch principalType {
 	case pb.Principal_USER:
@@ -186,14 +250,19 @@ func protoToNetPermission(permission *pb.Permission) (*Permission, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse permission: %w", err)
 	}
-	return &Permission{
-		CreatedAt: permission.CreatedAt.Seconds,
+	netPermission := &Permission{
+		CreatedAt: permission.CreatedAt.AsTime(),
 		CreatedBy: createdBy,
 		DocumentId: documentId,
-		LastModifiedAt: permission.LastModifiedAt.Seconds,
+		LastModifiedAt: permission.LastModifiedAt.AsTime(),
 		PermissionLevel: permissionLevel,
 		Principal: *principal,
-	}, nil
+	}
+	// the field is left out for permissions that have been accepted
+	if permission.Pending {
+		netPermission.Pending = &permission.Pending
+	}
+	return netPermission, nil
 }
 
 func protoToNetPermissions(permissions []*pb.Permission) ([]*Permission, error) {
@@ -230,7 +299,7 @@ func (s *Service) DeleteDocument(w http.ResponseWriter, r *http.Request) {
 	// it has the correct permissions
 	principalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	// coarse grain authorization check: only users should be able to delete document
@@ -246,12 +315,42 @@ func (s *Service) DeleteDocument(w http.ResponseWriter, r *http.Request) {
 	// if the principal id is a guest id, the document service will reject it
 	err = s.documentServiceClient.DeleteDocuments(r.Context(), reqBody.DocumentIds, principalId)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// get the number of documents the caller holds each permission level on in one request, for
+// badges in the ui
+// (GET /document/counts)
+func (s *Service) GetDocumentCounts(w http.ResponseWriter, r *http.Request) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	reply, err := s.documentServiceClient.CountDocumentsByPrincipalGrouped(
+		r.Context(),
+		principalId,		// target principal id
+		principalId,		// calling principal id
+	)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	SendJsonResponse(w, http.StatusOK, GetDocumentCountsResponse{
+		Owner: reply.OwnerCount,
+		Editor: reply.EditorCount,
+		Viewer: reply.ViewerCount,
+	})
+}
+
 // get all the documents that a given user has owner permissions on
 // (GET /document)
 func (s *Service) GetDocument(w http.ResponseWriter, r *http.Request, params GetDocumentParams) {
@@ -264,7 +363,7 @@ func (s *Service) GetDocument(w http.ResponseWriter, r *http.Request, params Get
 	// parse the principle id from the JWT claims 
 	principalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	// cursor, limit, and permission level are query params in the params struct
@@ -292,19 +391,27 @@ func (s *Service) GetDocument(w http.ResponseWriter, r *http.Request, params Get
 			permissionLevel = parsedPermissionLevel
 		}
 	}
-	// if the limit is not present, we pass nil for the limit and let the document service define 
-	// the default value
+	// resolve the limit here instead of leaving it to the document service so that the page
+	// size that was used can be returned to the client
+	limit, err := resolvePageLimit(params.Limit)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	// call the document service client 
 	reply, err := s.documentServiceClient.ListDocumentsByPrincipal(
 		r.Context(),
 		principalId,		// target principal id 
 		principalId,		// calling principal id
 		[]pb.PermissionLevel{permissionLevel},
+		params.FavoritesOnly != nil && *params.FavoritesOnly,
+		params.CollectionId,
+		params.IncludeArchived != nil && *params.IncludeArchived,
 		cursor,
-		params.Limit,
+		&limit,
 	)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
 	// format the document service response into the http response
@@ -322,11 +429,537 @@ func (s *Service) GetDocument(w http.ResponseWriter, r *http.Request, params Get
 			SendError(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
+		if params.IncludePermissionTimestamps != nil && *params.IncludePermissionTimestamps {
+			permissionCreatedAt := documentPermission.PermissionCreatedAt.AsTime()
+			permissionLastModifiedAt := documentPermission.PermissionLastModifiedAt.AsTime()
+			document.PermissionCreatedAt = &permissionCreatedAt
+			document.PermissionLastModifiedAt = &permissionLastModifiedAt
+		}
 		documents[i] = *document
 	}
 	response := &GetDocumentResponse{
 		Cursor: &respCursor,
 		Documents: documents,
+		HasMore: reply.HasMore,
+		Limit: limit,
+	}
+	SendJsonResponse(w, http.StatusOK, response)
+}
+
+// star a document for the caller
+// (PUT /document/{documentId}/star)
+func (s *Service) PutDocumentDocumentIdStar(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	err = s.documentServiceClient.StarDocument(r.Context(), documentId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unstar a document for the caller
+// (DELETE /document/{documentId}/star)
+func (s *Service) DeleteDocumentDocumentIdStar(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	err = s.documentServiceClient.UnstarDocument(r.Context(), documentId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// archive a document owned by the caller
+// (PUT /document/{documentId}/archive)
+func (s *Service) PutDocumentDocumentIdArchive(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	err = s.documentServiceClient.ArchiveDocument(r.Context(), documentId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// restore an archived document owned by the caller
+// (DELETE /document/{documentId}/archive)
+func (s *Service) DeleteDocumentDocumentIdArchive(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	err = s.documentServiceClient.RestoreDocument(r.Context(), documentId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// create a collection owned by the caller
+// (POST /collection)
+func (s *Service) PostCollection(w http.ResponseWriter, r *http.Request) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	var request PostCollectionJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, fmt.Sprintf(
+			"failed to parse the request body with error: %v", err.Error(),
+		))
+		return
+	}
+	collection, err := s.documentServiceClient.CreateCollection(r.Context(), request.Name, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	collectionId, err := uuid.Parse(collection.CollectionId)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	ownerId, err := uuid.Parse(collection.OwnerId)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	SendJsonResponse(w, http.StatusOK, &CollectionResponse{
+		CollectionId: collectionId,
+		OwnerId: ownerId,
+		Name: collection.Name,
+		CreatedAt: collection.CreatedAt.AsTime(),
+	})
+}
+
+// add a document to a collection of the caller
+// (PUT /collection/{collectionId}/document/{documentId})
+func (s *Service) PutCollectionCollectionIdDocumentDocumentId(
+	w http.ResponseWriter, r *http.Request, collectionId CollectionId, documentId DocumentId,
+) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	err = s.documentServiceClient.AddToCollection(r.Context(), collectionId, documentId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// remove a document from a collection of the caller
+// (DELETE /collection/{collectionId}/document/{documentId})
+func (s *Service) DeleteCollectionCollectionIdDocumentDocumentId(
+	w http.ResponseWriter, r *http.Request, collectionId CollectionId, documentId DocumentId,
+) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	err = s.documentServiceClient.RemoveFromCollection(r.Context(), collectionId, documentId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// get the documents of the caller that changed after a point in time
+// (GET /document/sync)
+func (s *Service) GetDocumentSync(w http.ResponseWriter, r *http.Request, params GetDocumentSyncParams) {
+	// read the JWT claims from the request context
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// the cursor of the previous page takes precedence over the since time
+	var cursor *pb.Cursor = nil
+	if params.Cursor != nil {
+		cursor, err = netToProtoCursor(*params.Cursor)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "failed to parse the provided cursor")
+			return
+		}
+	}
+	limit, err := resolvePageLimit(params.Limit)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reply, err := s.documentServiceClient.ListDocumentsModifiedSince(
+		r.Context(),
+		principalId,		// target principal id
+		principalId,		// calling principal id
+		params.Since,
+		cursor,
+		&limit,
+	)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	respCursor, err := protoToNetCursor(reply.Cursor)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	// archived documents are included with their archived at time set as tombstones
+	var documents []Document = make([]Document, len(reply.DocumentPermissions))
+	for i, documentPermission := range reply.DocumentPermissions {
+		document, err := protoToNetDocument(documentPermission.Document)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		documents[i] = *document
+	}
+	response := &GetDocumentResponse{
+		Cursor: &respCursor,
+		Documents: documents,
+		HasMore: reply.HasMore,
+		Limit: limit,
+	}
+	SendJsonResponse(w, http.StatusOK, response)
+}
+
+// get the documents owned by the caller that are shared with at least one collaborator
+// (GET /document/shared)
+// get the shares offered to the caller that they have not accepted yet
+// (GET /document/pending)
+func (s *Service) GetDocumentPending(w http.ResponseWriter, r *http.Request) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	// shares are only offered to users
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "must have a user type token to list pending shares")
+		return
+	}
+	userId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	reply, err := s.documentServiceClient.ListPendingShares(r.Context(), userId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	pendingShares := make([]PendingShare, len(reply.PendingShares))
+	for i, pendingShare := range reply.PendingShares {
+		document, err := protoToNetDocument(pendingShare.Document)
+		if err != nil {
+			sendSanitizedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		permissionLevel, err := protoToNetPermissionLevel(pendingShare.PermissionLevel)
+		if err != nil {
+			sendSanitizedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		sharedBy, err := uuid.Parse(pendingShare.SharedBy)
+		if err != nil {
+			sendSanitizedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		pendingShares[i] = PendingShare{
+			Document: *document,
+			PermissionLevel: permissionLevel,
+			SharedBy: sharedBy,
+			SharedAt: pendingShare.SharedAt.AsTime(),
+		}
+	}
+	SendJsonResponse(w, http.StatusOK, &ListPendingSharesResponse{ PendingShares: pendingShares })
+}
+
+func (s *Service) GetDocumentShared(w http.ResponseWriter, r *http.Request, params GetDocumentSharedParams) {
+	// read the JWT claims from the request context
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	// guests cannot own documents so they have no documents that they have shared
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "must have a user type token to list shared documents")
+		return
+	}
+	userId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	var cursor *pb.Cursor = nil
+	if params.Cursor != nil {
+		cursor, err = netToProtoCursor(*params.Cursor)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "failed to parse the provided cursor")
+			return
+		}
+	}
+	limit, err := resolvePageLimit(params.Limit)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reply, err := s.documentServiceClient.ListSharedDocumentsByOwner(
+		r.Context(),
+		userId,		// owner id
+		userId,		// calling principal id
+		cursor,
+		&limit,
+	)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	respCursor, err := protoToNetCursor(reply.Cursor)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	sharedDocuments := make([]SharedDocument, len(reply.SharedDocuments))
+	for i, sharedDocument := range reply.SharedDocuments {
+		document, err := protoToNetDocument(sharedDocument.Document)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		sharedDocuments[i] = SharedDocument{
+			Document: *document,
+			CollaboratorCount: sharedDocument.CollaboratorCount,
+		}
+	}
+	response := &ListSharedDocumentsResponse{
+		Cursor: &respCursor,
+		SharedDocuments: sharedDocuments,
+		HasMore: reply.HasMore,
+		Limit: limit,
+	}
+	SendJsonResponse(w, http.StatusOK, response)
+}
+
+// get every document regardless of who can see it, this is an admin only route that is meant
+// for support and cleanup
+// (GET /admin/documents)
+func (s *Service) GetAdminDocuments(w http.ResponseWriter, r *http.Request, params GetAdminDocumentsParams) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if claims.GetTokenType() != PrincipalTypeUser || !s.isAdmin(principalId) {
+		SendError(w, http.StatusForbidden, "must be an admin to list all documents")
+		return
+	}
+	var cursor *pb.Cursor = nil
+	if params.Cursor != nil {
+		cursor, err = netToProtoCursor(*params.Cursor)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "failed to parse the provided cursor")
+			return
+		}
+	}
+	limit, err := resolvePageLimit(params.Limit)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reply, err := s.documentServiceClient.ListAllDocuments(
+		r.Context(),
+		principalId,
+		cursor,
+		&limit,
+		params.OwnerId,
+		params.CreatedBefore,
+		params.CreatedAfter,
+	)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	respCursor, err := protoToNetCursor(reply.Cursor)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	documents := make([]Document, len(reply.Documents))
+	for i, pbDocument := range reply.Documents {
+		document, err := protoToNetDocument(pbDocument)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		documents[i] = *document
+	}
+	SendJsonResponse(w, http.StatusOK, &GetDocumentResponse{
+		Cursor: &respCursor,
+		Documents: documents,
+		HasMore: reply.HasMore,
+		Limit: limit,
+	})
+}
+
+// make the fallback owner the owner of a document that lost its owner, this is an admin only
+// route because the document service leaves authorizing the repair to the gateway
+// (POST /admin/documents/{documentId}/ensure-owner)
+func (s *Service) PostAdminDocumentsDocumentIdEnsureOwner(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if claims.GetTokenType() != PrincipalTypeUser || !s.isAdmin(principalId) {
+		SendError(w, http.StatusForbidden, "must be an admin to repair the owner of a document")
+		return
+	}
+	var reqBody PostAdminDocumentsDocumentIdEnsureOwnerJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		SendError(w, http.StatusBadRequest, fmt.Sprintf("error decoding request body: %s", err.Error()))
+		return
+	}
+	repaired, err := s.documentServiceClient.EnsureDocumentHasOwner(r.Context(), documentId, reqBody.FallbackOwnerId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	SendJsonResponse(w, http.StatusOK, &EnsureOwnerResponse{
+		Repaired: repaired,
+	})
+}
+
+func (s *Service) GetDocumentRecent(w http.ResponseWriter, r *http.Request, params GetDocumentRecentParams) {
+	// read the JWT claims from the request context
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	var cursor *pb.Cursor = nil
+	if params.Cursor != nil {
+		cursor, err = netToProtoCursor(*params.Cursor)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "failed to parse the provided cursor")
+			return
+		}
+	}
+	limit, err := resolvePageLimit(params.Limit)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reply, err := s.documentServiceClient.ListRecentlyAccessed(r.Context(), principalId, cursor, &limit)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	respCursor, err := protoToNetCursor(reply.Cursor)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	accessedDocuments := make([]AccessedDocument, len(reply.AccessedDocuments))
+	for i, accessedDocument := range reply.AccessedDocuments {
+		document, err := protoToNetDocument(accessedDocument.Document)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		permission, err := protoToNetPermissionLevel(accessedDocument.Permission)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		accessedDocuments[i] = AccessedDocument{
+			Document: *document,
+			Permission: permission,
+			AccessedAt: accessedDocument.AccessedAt.AsTime(),
+		}
+	}
+	response := &ListRecentlyAccessedResponse{
+		Cursor: &respCursor,
+		Documents: accessedDocuments,
+		HasMore: reply.HasMore,
+		Limit: limit,
 	}
 	SendJsonResponse(w, http.StatusOK, response)
 }
@@ -352,7 +985,7 @@ func (s *Service) PostDocument(w http.ResponseWriter, r *http.Request) {
 	// coarse grain authorization
 	userId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	// parse the request body
@@ -371,10 +1004,11 @@ func (s *Service) PostDocument(w http.ResponseWriter, r *http.Request) {
 		userId,
 		request.DocumentName,
 		request.DocumentDescription,
+		request.DocumentId,
 	)
 	// if the call fails, proxy the error back to the client
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
 	SendJsonResponse(
@@ -396,7 +1030,7 @@ func (s *Service) DeleteDocumentDocumentId(w http.ResponseWriter, r *http.Reques
 	}
 	principalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	// coarse grain authorization, check if the type of the token is user type 
@@ -410,7 +1044,7 @@ func (s *Service) DeleteDocumentDocumentId(w http.ResponseWriter, r *http.Reques
 		r.Context(), documentId, principalId,
 	)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -418,7 +1052,9 @@ func (s *Service) DeleteDocumentDocumentId(w http.ResponseWriter, r *http.Reques
 
 // get one document
 // (GET /document/{documentId})
-func (s *Service) GetDocumentDocumentId(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+func (s *Service) GetDocumentDocumentId(
+	w http.ResponseWriter, r *http.Request, documentId DocumentId, params GetDocumentDocumentIdParams,
+) {
 	// document Id is a query parameter that has been parsed out of the request path
 	// parse the userId from the custom claims
 	claims, err := GetClaims(r.Context())
@@ -428,13 +1064,32 @@ func (s *Service) GetDocumentDocumentId(w http.ResponseWriter, r *http.Request,
 	}
 	principalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
-	// call the document service with the document id and the user id
-	result, err := s.documentServiceClient.GetDocument(r.Context(), principalId, documentId)
+	// call the document service with the document id and the user id, the document service
+	// decides whether a public link token grants access to the document
+	includeTombstone := params.IncludeTombstone != nil && *params.IncludeTombstone
+	includeCollaboratorCount := params.IncludeCollaboratorCount != nil && *params.IncludeCollaboratorCount
+	result, err := s.documentServiceClient.GetDocument(
+		r.Context(), documentId, principalId, claims.GetPublicLinkVersion(documentId),
+		includeTombstone, includeCollaboratorCount,
+	)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
+		return
+	}
+	// a recently deleted document is reported as gone along with when it was deleted
+	if tombstone := result.GetTombstone(); tombstone != nil {
+		deletedDocumentId, err := uuid.Parse(tombstone.DocumentId)
+		if err != nil {
+			sendSanitizedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		SendJsonResponse(w, http.StatusGone, &DocumentTombstone{
+			DocumentId: deletedDocumentId,
+			DeletedAt: tombstone.DeletedAt.AsTime(),
+		})
 		return
 	}
 	// format the document service response such that it can be sent as an http response body
@@ -446,6 +1101,7 @@ func (s *Service) GetDocumentDocumentId(w http.ResponseWriter, r *http.Request,
 		)
 		return
 	}
+	document.CollaboratorCount = result.CollaboratorCount
 	SendJsonResponse(w, http.StatusOK, document)
 }
 
@@ -456,11 +1112,13 @@ func (s *Service) PutDocumentDocumentId(w http.ResponseWriter, r *http.Request,
 	claims, err := GetClaims(r.Context())
 	if err != nil {
 		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
 	}
 	// parse the principal id from the token
 	principalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
 	}
 	// parse the request body
 	var body PutDocumentDocumentIdJSONRequestBody
@@ -471,11 +1129,124 @@ func (s *Service) PutDocumentDocumentId(w http.ResponseWriter, r *http.Request,
 	// call the document service using the document service client
 	err = s.documentServiceClient.UpdateDocument(
 		r.Context(), documentId, principalId, body.DocumentName, body.DocumentDescription,
+		body.ClearName != nil && *body.ClearName,
+		body.ClearDescription != nil && *body.ClearDescription,
 	)
 	// proxy any error back to the client
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
+// get the changes to the name and description of a document
+// (GET /document/{documentId}/history)
+func (s *Service) GetDocumentDocumentIdHistory(
+	w http.ResponseWriter,
+	r *http.Request,
+	documentId DocumentId,
+	params GetDocumentDocumentIdHistoryParams,
+) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	callingPrincipalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	var cursor *pb.Cursor = nil
+	if params.Cursor != nil {
+		cursor, err = netToProtoCursor(*params.Cursor)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "failed to parse the provided cursor")
+			return
+		}
+	}
+	limit, err := resolvePageLimit(params.Limit)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// the document service checks that the caller has a permission on the document
+	reply, err := s.documentServiceClient.GetDocumentHistory(
+		r.Context(), documentId, callingPrincipalId, cursor, &limit,
+	)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	respCursor, err := protoToNetCursor(reply.Cursor)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	changes := make([]DocumentChange, len(reply.Changes))
+	for i, change := range reply.Changes {
+		netChange, err := protoToNetDocumentChange(change)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		changes[i] = *netChange
+	}
+	SendJsonResponse(w, http.StatusOK, &GetDocumentHistoryResponse{
+		Changes: changes,
+		Cursor: &respCursor,
+		HasMore: reply.HasMore,
+		Limit: limit,
+	})
+}
+
+// enable or disable the public link of a document
+// (PUT /document/{documentId}/public-access)
+func (s *Service) PutDocumentDocumentIdPublicAccess(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+	// parse the claims and the principal id from the JWT
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// coarse grain check, only users can be the owner of a document
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "must have a user token to change the public access of a document")
+		return
+	}
+	// parse the request body
+	var body PutDocumentDocumentIdPublicAccessJSONRequestBody
+	if err = json.NewDecoder(r.Body).Decode(&body); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	publicAccess, err := netToProtoPublicAccess(body.PublicAccess)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// the document service checks that the caller is the owner of the document
+	publicLinkVersion, err := s.documentServiceClient.SetPublicAccess(r.Context(), documentId, principalId, publicAccess)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	response := SetPublicAccessResponse{
+		PublicAccess: body.PublicAccess,
+	}
+	// issue a public link token when the public link is enabled
+	if publicAccess != nil {
+		token, err := signPublicLinkToken(documentId, publicLinkVersion, s.jwtKeys)
+		if err != nil {
+			SendInternalError(w, r, err)
+			return
+		}
+		response.PublicLinkToken = &token
+	}
+	SendJsonResponse(w, http.StatusOK, response)
+}
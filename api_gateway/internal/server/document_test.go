@@ -0,0 +1,432 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/townsag/reed/document_service/api/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/townsag/reed/api_gateway/internal/config"
+)
+
+// a timestamp with a non zero fractional second
+var nanosTimestamp = time.Date(2023, time.December, 13, 16, 0, 0, 123456789, time.UTC)
+
+func TestProtoToNetDocument_NanosRoundTrip_Unit(t *testing.T) {
+	document, err := protoToNetDocument(&pb.Document{
+		DocumentId: uuid.NewString(),
+		CreatedAt: timestamppb.New(nanosTimestamp),
+		LastModifiedAt: timestamppb.New(nanosTimestamp.Add(time.Nanosecond)),
+	})
+	if err != nil {
+		t.Fatalf("failed to convert proto document with error: %v", err)
+	}
+	// serialize and deserialize the document the same way a client would see it
+	body, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("failed to marshal document with error: %v", err)
+	}
+	var decoded Document
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal document with error: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(nanosTimestamp) {
+		t.Errorf("want created at: %v, got: %v", nanosTimestamp, decoded.CreatedAt)
+	}
+	if !decoded.LastModifiedAt.Equal(nanosTimestamp.Add(time.Nanosecond)) {
+		t.Errorf("want last modified at: %v, got: %v", nanosTimestamp.Add(time.Nanosecond), decoded.LastModifiedAt)
+	}
+}
+
+func TestProtoToNetPermission_NanosRoundTrip_Unit(t *testing.T) {
+	permission, err := protoToNetPermission(&pb.Permission{
+		Recipient: &pb.Principal{
+			PrincipalId: uuid.NewString(),
+			PrincipalType: pb.Principal_USER,
+		},
+		DocumentId: uuid.NewString(),
+		PermissionLevel: pb.PermissionLevel_PERMISSION_EDITOR,
+		CreatedBy: uuid.NewString(),
+		CreatedAt: timestamppb.New(nanosTimestamp),
+		LastModifiedAt: timestamppb.New(nanosTimestamp),
+	})
+	if err != nil {
+		t.Fatalf("failed to convert proto permission with error: %v", err)
+	}
+	body, err := json.Marshal(permission)
+	if err != nil {
+		t.Fatalf("failed to marshal permission with error: %v", err)
+	}
+	var decoded Permission
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal permission with error: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(nanosTimestamp) {
+		t.Errorf("want created at: %v, got: %v", nanosTimestamp, decoded.CreatedAt)
+	}
+	if !decoded.LastModifiedAt.Equal(nanosTimestamp) {
+		t.Errorf("want last modified at: %v, got: %v", nanosTimestamp, decoded.LastModifiedAt)
+	}
+}
+
+func TestResolvePageLimit_Unit(t *testing.T) {
+	tests := []struct {
+		name string
+		limit *int32
+		want int32
+	}{
+		{ "missing", nil, config.DefaultPageLimit },
+		{ "valid", proto.Int32(5), 5 },
+		{ "max", proto.Int32(config.MaxPageLimit), config.MaxPageLimit },
+		{ "over max", proto.Int32(config.MaxPageLimit + 1), config.MaxPageLimit },
+	}
+	for _, test := range tests {
+		got, err := resolvePageLimit(test.limit)
+		if err != nil {
+			t.Errorf("failed to resolve %s limit with error: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("want page size: %d for %s limit, got: %d", test.want, test.name, got)
+		}
+	}
+	for _, limit := range []int32{ 0, -1 } {
+		if _, err := resolvePageLimit(&limit); err == nil {
+			t.Errorf("want an error for limit: %d", limit)
+		}
+	}
+}
+
+func TestGetDocument_InvalidLimit_Unit(t *testing.T) {
+	for _, limit := range []string{ "0", "-1" } {
+		w := serveTestRequest(t, http.MethodGet, "/document?limit="+limit, "", signTestToken(t))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("want status: %d for limit: %s, got: %d with body: %s", http.StatusBadRequest, limit, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetDocument_Limit_Unit(t *testing.T) {
+	tests := []struct {
+		query string
+		want int32
+	}{
+		{ "", config.DefaultPageLimit },
+		{ "?limit=5", 5 },
+		{ "?limit=100000", config.MaxPageLimit },
+	}
+	for _, test := range tests {
+		documents := &fakeDocumentServer{}
+		service := newFakeBackendService(t, &fakeUserServer{}, documents)
+		r := httptest.NewRequest(http.MethodGet, "/document"+test.query, nil)
+		r.Header.Set("Authentication", "Bearer "+signTestToken(t))
+		w := httptest.NewRecorder()
+		NewHandler(service).ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("want status: %d for query: %q, got: %d with body: %s", http.StatusOK, test.query, w.Code, w.Body.String())
+		}
+		// the page size that was sent to the document service is returned to the client
+		var response GetDocumentResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to unmarshal response with error: %v", err)
+		}
+		if response.Limit != test.want {
+			t.Errorf("want limit: %d in the response for query: %q, got: %d", test.want, test.query, response.Limit)
+		}
+		pageSizes := documents.listedPageSizes()
+		if len(pageSizes) != 1 || pageSizes[0] != test.want {
+			t.Errorf("want page size: %d sent to the document service for query: %q, got: %v", test.want, test.query, pageSizes)
+		}
+	}
+}
+
+func TestGetDocument_FavoritesOnly_Unit(t *testing.T) {
+	tests := []struct {
+		query string
+		want bool
+	}{
+		{ "", false },
+		{ "?favoritesOnly=false", false },
+		{ "?favoritesOnly=true", true },
+	}
+	for _, test := range tests {
+		documents := &fakeDocumentServer{}
+		service := newFakeBackendService(t, &fakeUserServer{}, documents)
+		r := httptest.NewRequest(http.MethodGet, "/document"+test.query, nil)
+		r.Header.Set("Authentication", "Bearer "+signTestToken(t))
+		w := httptest.NewRecorder()
+		NewHandler(service).ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("want status: %d for query: %q, got: %d with body: %s", http.StatusOK, test.query, w.Code, w.Body.String())
+		}
+		favoritesOnly := documents.listedFavoritesOnly()
+		if len(favoritesOnly) != 1 || favoritesOnly[0] != test.want {
+			t.Errorf("want favorites only: %v sent to the document service for query: %q, got: %v", test.want, test.query, favoritesOnly)
+		}
+	}
+}
+
+func TestGetDocument_IncludeArchived_Unit(t *testing.T) {
+	tests := []struct {
+		query string
+		want bool
+	}{
+		{ "", false },
+		{ "?includeArchived=true", true },
+		{ "?includeArchived=true&favoritesOnly=true", true },
+	}
+	for _, test := range tests {
+		documents := &fakeDocumentServer{}
+		service := newFakeBackendService(t, &fakeUserServer{}, documents)
+		r := httptest.NewRequest(http.MethodGet, "/document"+test.query, nil)
+		r.Header.Set("Authentication", "Bearer "+signTestToken(t))
+		w := httptest.NewRecorder()
+		NewHandler(service).ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("want status: %d for query: %q, got: %d with body: %s", http.StatusOK, test.query, w.Code, w.Body.String())
+		}
+		includeArchived := documents.listedIncludeArchived()
+		if len(includeArchived) != 1 || includeArchived[0] != test.want {
+			t.Errorf("want include archived: %v sent to the document service for query: %q, got: %v", test.want, test.query, includeArchived)
+		}
+	}
+}
+
+func TestGetDocument_CollectionId_Unit(t *testing.T) {
+	collectionId := uuid.NewString()
+	tests := []struct {
+		query string
+		want string
+	}{
+		{ "", "" },
+		{ "?collectionId=" + collectionId, collectionId },
+		{ "?collectionId=" + collectionId + "&favoritesOnly=true", collectionId },
+	}
+	for _, test := range tests {
+		documents := &fakeDocumentServer{}
+		service := newFakeBackendService(t, &fakeUserServer{}, documents)
+		r := httptest.NewRequest(http.MethodGet, "/document"+test.query, nil)
+		r.Header.Set("Authentication", "Bearer "+signTestToken(t))
+		w := httptest.NewRecorder()
+		NewHandler(service).ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("want status: %d for query: %q, got: %d with body: %s", http.StatusOK, test.query, w.Code, w.Body.String())
+		}
+		collectionIds := documents.listedCollections()
+		if len(collectionIds) != 1 || collectionIds[0] != test.want {
+			t.Errorf("want collection id: %q sent to the document service for query: %q, got: %v", test.want, test.query, collectionIds)
+		}
+	}
+	// a collection id that is not a uuid is rejected before the document service is called
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	r := httptest.NewRequest(http.MethodGet, "/document?collectionId=not-a-uuid", nil)
+	r.Header.Set("Authentication", "Bearer "+signTestToken(t))
+	w := httptest.NewRecorder()
+	NewHandler(service).ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest || len(documents.listedCollections()) != 0 {
+		t.Errorf("want status: %d without listing for an invalid collection id, got: %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestPostCollection_Unit(t *testing.T) {
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	userId := uuid.New()
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/collection", `{"name": "reading list"}`, signVersionedTestToken(t, userId, 0),
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response CollectionResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	if response.OwnerId != userId || response.Name != "reading list" || response.CollectionId == uuid.Nil {
+		t.Errorf("want a collection named reading list owned by: %s, got: %+v", userId, response)
+	}
+	// the name of a collection must not be empty
+	w = serveVersionedRequest(
+		t, service, http.MethodPost, "/collection", `{"name": ""}`, signVersionedTestToken(t, userId, 0),
+	)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d for an empty name, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestCollectionMembership_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	collectionId, documentId := uuid.NewString(), uuid.NewString()
+	path := "/collection/" + collectionId + "/document/" + documentId
+	for _, method := range []string{ http.MethodPut, http.MethodDelete } {
+		w := serveVersionedRequest(t, service, method, path, "", signVersionedTestToken(t, uuid.New(), 0))
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("want status: %d for %s, got: %d with body: %s", http.StatusNoContent, method, w.Code, w.Body.String())
+		}
+	}
+	want := []string{ "add " + collectionId + " " + documentId, "remove " + collectionId + " " + documentId }
+	if got := documents.changedCollections(); !slices.Equal(got, want) {
+		t.Errorf("want collection changes: %v, got: %v", want, got)
+	}
+}
+
+func TestArchiveDocument_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	userId, documentId := uuid.New(), uuid.NewString()
+	for _, method := range []string{ http.MethodPut, http.MethodDelete } {
+		w := serveVersionedRequest(
+			t, service, method, "/document/"+documentId+"/archive", "", signVersionedTestToken(t, userId, 0),
+		)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("want status: %d for %s, got: %d with body: %s", http.StatusNoContent, method, w.Code, w.Body.String())
+		}
+	}
+	// the caller is sent to the document service so that it can check ownership
+	want := []string{ "archive " + documentId + " " + userId.String(), "restore " + documentId + " " + userId.String() }
+	if got := documents.changedArchives(); !slices.Equal(got, want) {
+		t.Errorf("want archive changes: %v, got: %v", want, got)
+	}
+}
+
+func TestGetAdminDocuments_NotAdmin_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	w := serveVersionedRequest(t, service, http.MethodGet, "/admin/documents", "", signVersionedTestToken(t, uuid.New(), 0))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	if len(documents.listedAll()) != 0 {
+		t.Errorf("want no request to list all documents, got: %d", len(documents.listedAll()))
+	}
+}
+
+func TestPostAdminEnsureOwner_NotAdmin_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	body := `{"fallbackOwnerId":"` + uuid.NewString() + `"}`
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/admin/documents/"+uuid.NewString()+"/ensure-owner", body,
+		signVersionedTestToken(t, uuid.New(), 0),
+	)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	if len(documents.repairedOwners()) != 0 {
+		t.Errorf("want no request to repair the owner, got: %v", documents.repairedOwners())
+	}
+}
+
+func TestPostAdminEnsureOwner_Admin_Unit(t *testing.T) {
+	adminId := uuid.New()
+	documentId := uuid.NewString()
+	fallbackOwnerId := uuid.NewString()
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	service.adminUserIds = map[uuid.UUID]struct{}{ adminId: {} }
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/admin/documents/"+documentId+"/ensure-owner",
+		`{"fallbackOwnerId":"`+fallbackOwnerId+`"}`, signVersionedTestToken(t, adminId, 0),
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	want := []string{ documentId + " " + fallbackOwnerId + " " + adminId.String() }
+	if got := documents.repairedOwners(); !slices.Equal(got, want) {
+		t.Errorf("want owner repairs: %v, got: %v", want, got)
+	}
+	var response EnsureOwnerResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response with error: %v", err)
+	}
+	if !response.Repaired {
+		t.Errorf("want the repair to be reported, got: %+v", response)
+	}
+}
+
+func TestGetAdminDocuments_Filters_Unit(t *testing.T) {
+	adminId := uuid.New()
+	ownerId := uuid.New()
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	service.adminUserIds = map[uuid.UUID]struct{}{ adminId: {} }
+	createdBefore := nanosTimestamp.Format(time.RFC3339Nano)
+	w := serveVersionedRequest(
+		t, service, http.MethodGet,
+		"/admin/documents?limit=5&ownerId="+ownerId.String()+"&createdBefore="+createdBefore, "",
+		signVersionedTestToken(t, adminId, 0),
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	requests := documents.listedAll()
+	if len(requests) != 1 {
+		t.Fatalf("want one request to list all documents, got: %d", len(requests))
+	}
+	req := requests[0]
+	if req.GetPageSize() != 5 || req.GetOwnerId() != ownerId.String() || req.CreatedAfter != nil ||
+		!req.GetCreatedBefore().AsTime().Equal(nanosTimestamp) {
+		t.Errorf("want the limit and filters to be sent to the document service, got: %v", req)
+	}
+	var response GetDocumentResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response with error: %v", err)
+	}
+	if len(response.Documents) != 1 || response.Limit != 5 {
+		t.Errorf("want one document and limit: 5 in the response, got: %+v", response)
+	}
+}
+
+func TestNetToProtoPermissionLevel_Unknown_Unit(t *testing.T) {
+	level, err := netToProtoPermissionLevel(PermissionLevel("admin"))
+	if err == nil {
+		t.Errorf("want an error for an unknown permission level, got: %v", level)
+	}
+	// an unknown level is never sent as a value that is not in the proto enum
+	if level != pb.PermissionLevel_PERMISSION_UNSPECIFIED {
+		t.Errorf("want: %v for an unknown permission level, got: %v", pb.PermissionLevel_PERMISSION_UNSPECIFIED, level)
+	}
+}
+
+func TestProtoToNetPermissionLevel_Unspecified_Unit(t *testing.T) {
+	level, err := protoToNetPermissionLevel(pb.PermissionLevel_PERMISSION_UNSPECIFIED)
+	if err == nil {
+		t.Errorf("want an error for an unspecified permission level, got: %q", level)
+	}
+}
+
+// when the documents of the fake document server were deleted
+var tombstoneTime = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func TestGetDocument_Tombstone_Unit(t *testing.T) {
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	documentId := uuid.New()
+	token := signVersionedTestToken(t, uuid.New(), 0)
+	path := "/document/" + documentId.String()
+	// without the tombstone a deleted document cannot be told apart from one that never existed
+	w := serveVersionedRequest(t, service, http.MethodGet, path, "", token)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want status: %d without includeTombstone, got: %d", http.StatusNotFound, w.Code)
+	}
+	w = serveVersionedRequest(t, service, http.MethodGet, path+"?includeTombstone=true", "", token)
+	if w.Code != http.StatusGone {
+		t.Fatalf("want status: %d with includeTombstone, got: %d with body: %s", http.StatusGone, w.Code, w.Body.String())
+	}
+	var tombstone DocumentTombstone
+	if err := json.Unmarshal(w.Body.Bytes(), &tombstone); err != nil {
+		t.Fatalf("failed to unmarshal tombstone with error: %v", err)
+	}
+	if tombstone.DocumentId != documentId || !tombstone.DeletedAt.Equal(tombstoneTime) {
+		t.Errorf(
+			"want tombstone of document: %s deleted at: %v, got: %s deleted at: %v",
+			documentId, tombstoneTime, tombstone.DocumentId, tombstone.DeletedAt,
+		)
+	}
+}
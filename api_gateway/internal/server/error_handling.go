@@ -1,15 +1,37 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 )
 
-// create a middleware function that intercepts domain errors raised from the service 
-// layer and translates them to appropriate
-// consider refactoring this function to use the factory pattern instead so that 
-// we can use closures to make the error handler more configurable
+// the path parameters that the spec declares as uuids, a value that fails to bind to one of
+// these is reported as a malformed uuid instead of with the error of the binding library
+var uuidPathParams = map[string]bool{
+	"documentId": true,
+	"principalId": true,
+	"userId": true,
+}
+
+// handles the errors that the generated code encounters when parsing the query parameters and
+// path parameters of a request. These errors are raised before the middlewares and the handler
+// run, so a malformed parameter never reaches a downstream service. The response names the
+// parameter that could not be parsed so that the client does not have to guess which one it was
 func ErrorHandlerFunc(w http.ResponseWriter, r *http.Request, err error) {
-	http.Error(w, err.Error(), http.StatusBadRequest)
+	var formatErr *InvalidParamFormatError
+	if !errors.As(err, &formatErr) {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reason := "has an invalid format"
+	if uuidPathParams[formatErr.ParamName] {
+		reason = "must be a uuid"
+	}
+	message := fmt.Sprintf("invalid %s: %s", formatErr.ParamName, reason)
+	fields := map[string]string{ formatErr.ParamName: reason }
+	SendJsonResponse(w, http.StatusBadRequest, Error{
+		Message: &message,
+		Fields: &fields,
+	})
 }
-// my understanding is that this function is used to handle errors that the generated
-// code encounters when parsing request query parameters and path parameters
\ No newline at end of file
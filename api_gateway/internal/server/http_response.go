@@ -3,9 +3,15 @@ package server
 import (
 	"net/http"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	documentService "github.com/townsag/reed/document_service/pkg/client"
+	"github.com/townsag/reed/user_service/pkg/middleware"
 )
 
 func SendError(w http.ResponseWriter, code int, message string) {
@@ -18,6 +24,73 @@ func SendError(w http.ResponseWriter, code int, message string) {
 }
 // Decide that each method should implement it's own version of serializing the successful
 
+// send an error returned by a gRPC client, if the downstream service attached field
+// violations to the status they are included in the response body so that the client
+// can see every invalid field at once. Only the message of 4xx errors is passed through to the
+// client, the message of a 5xx error can hold internal details like database errors so it is
+// logged with the request id and replaced with a generic message
+func SendGrpcError(w http.ResponseWriter, r *http.Request, err error) {
+	code, responseError := GrpcErrorResponse(err)
+	if code >= http.StatusInternalServerError {
+		sendSanitizedError(w, r, code, err)
+		return
+	}
+	SendJsonResponse(w, code, responseError)
+}
+
+// the http status and the response body for an error returned by a gRPC client. This is the one
+// place that the status codes and the error details of the downstream services are translated,
+// handlers should send the error with SendGrpcError instead of calling this directly. The body
+// holds the unsanitized message, SendGrpcError replaces it for 5xx errors
+func GrpcErrorResponse(err error) (code int, responseError Error) {
+	code = GrpcToHttpStatus(err)
+	message := err.Error()
+	if st, ok := status.FromError(err); ok {
+		// drop the "rpc error: code = ... desc =" prefix that the client adds
+		message = st.Message()
+	}
+	responseError.Message = &message
+	if fields := grpcFieldViolations(err); len(fields) > 0 {
+		responseError.Fields = &fields
+	}
+	return code, responseError
+}
+
+// send a 500 for an error raised in the gateway itself, the error is logged instead of sent
+// to the client
+func SendInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	sendSanitizedError(w, r, http.StatusInternalServerError, err)
+}
+
+func sendSanitizedError(w http.ResponseWriter, r *http.Request, code int, err error) {
+	requestId := GetRequestId(r.Context())
+	slog.ErrorContext(
+		r.Context(), "request failed with an internal error",
+		"requestId", requestId, "principalId", middleware.GetPrincipalId(r.Context()),
+		"method", r.Method, "path", r.URL.Path, "status", code, "error", err,
+	)
+	SendError(w, code, fmt.Sprintf("%s, request id: %s", http.StatusText(code), requestId))
+}
+
+// collect the field violations from any BadRequest details attached to a gRPC status
+func grpcFieldViolations(err error) map[string]string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	fields := make(map[string]string)
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, violation := range badRequest.GetFieldViolations() {
+			fields[violation.GetField()] = violation.GetDescription()
+		}
+	}
+	return fields
+}
+
 func SendJsonResponse(w http.ResponseWriter, code int, responseBody interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -37,11 +110,27 @@ func GrpcToHttpStatus(err error) int {
         return http.StatusNotFound
     case codes.AlreadyExists:
         return http.StatusConflict
+    case codes.Aborted:
+        // the user service uses aborted for transactions that conflicted with a concurrent
+        // request, the client can retry these
+        return http.StatusConflict
     case codes.PermissionDenied:
         return http.StatusForbidden
+    case codes.FailedPrecondition:
+        // the document service marks the failed precondition of a mutation on an archived
+        // document with the archived reason, the other failed preconditions are bad requests
+        if documentService.IsDocumentArchived(err) {
+            return http.StatusGone
+        }
+        return http.StatusBadRequest
     case codes.Unauthenticated:
         return http.StatusUnauthorized
     case codes.ResourceExhausted:
+        // a quota of the document does not reset by waiting, so it is forbidden instead of a
+        // rate limit that the client can retry
+        if documentService.IsQuotaExceeded(err) {
+            return http.StatusForbidden
+        }
         return http.StatusTooManyRequests
     case codes.Unimplemented:
         return http.StatusNotImplemented
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	documentService "github.com/townsag/reed/document_service/pkg/client"
+)
+
+// send the log records of the test to a buffer instead of stderr
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// serve a reassign request as an admin to a document service that fails with the given error
+func serveFailingReassignRequest(t *testing.T, reassignErr error) (int, string, string) {
+	adminId := uuid.New()
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{ reassignErr: reassignErr })
+	service.adminUserIds = map[uuid.UUID]struct{}{ adminId: {} }
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/user/"+uuid.NewString()+"/reassign-documents",
+		`{"successorId": "`+uuid.NewString()+`"}`, signVersionedTestToken(t, adminId, 0),
+	)
+	var response Error
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	if response.Message == nil {
+		t.Fatalf("want an error message in the response")
+	}
+	return w.Code, *response.Message, w.Header().Get(RequestIdHeader)
+}
+
+func TestSendGrpcError_InternalIsSanitized_Unit(t *testing.T) {
+	logs := captureLogs(t)
+	// the document service sends the message of a RepoImpl error with an internal status
+	detail := "failed to acquire connection: dial tcp 10.0.0.5:5432: connect: connection refused"
+	code, message, requestId := serveFailingReassignRequest(t, status.Error(codes.Internal, detail))
+	if code != http.StatusInternalServerError {
+		t.Errorf("want status: %d, got: %d", http.StatusInternalServerError, code)
+	}
+	if strings.Contains(message, detail) {
+		t.Errorf("want a generic message, got: %s", message)
+	}
+	if requestId == "" || !strings.Contains(message, requestId) {
+		t.Errorf("want the message to include the request id: %s, got: %s", requestId, message)
+	}
+	var record map[string]any
+	if err := json.NewDecoder(logs).Decode(&record); err != nil {
+		t.Fatalf("failed to decode log record with error: %v", err)
+	}
+	if record["requestId"] != requestId || !strings.Contains(record["error"].(string), detail) {
+		t.Errorf("want a log record with request id: %s and the error detail, got: %v", requestId, record)
+	}
+}
+
+func TestSendGrpcError_ClientErrorPassedThrough_Unit(t *testing.T) {
+	logs := captureLogs(t)
+	code, message, _ := serveFailingReassignRequest(t, status.Error(codes.InvalidArgument, "the successor must be a different user"))
+	if code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d", http.StatusBadRequest, code)
+	}
+	if message != "the successor must be a different user" {
+		t.Errorf("want the message of the downstream service, got: %s", message)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("want no log records for a client error, got: %s", logs.String())
+	}
+}
+
+func TestSendGrpcError_ConflictingField_Unit(t *testing.T) {
+	// the user service attaches the field of a duplicate user to the already exists status
+	st, err := status.New(codes.AlreadyExists, "unique conflict").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{ Field: "email", Description: "is already in use" },
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to attach details to status with error: %v", err)
+	}
+	w := httptest.NewRecorder()
+	SendGrpcError(w, httptest.NewRequest(http.MethodPost, "/user", nil), st.Err())
+	if w.Code != http.StatusConflict {
+		t.Errorf("want status: %d, got: %d", http.StatusConflict, w.Code)
+	}
+	var response Error
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	if response.Fields == nil || (*response.Fields)["email"] != "is already in use" {
+		t.Errorf("want the conflicting email field in the response, got: %v", response.Fields)
+	}
+}
+
+
+func TestGrpcToHttpStatus_EachCode_Unit(t *testing.T) {
+	want := map[codes.Code]int{
+		codes.InvalidArgument: http.StatusBadRequest,
+		codes.NotFound: http.StatusNotFound,
+		codes.AlreadyExists: http.StatusConflict,
+		codes.Aborted: http.StatusConflict,
+		codes.PermissionDenied: http.StatusForbidden,
+		codes.FailedPrecondition: http.StatusBadRequest,
+		codes.Unauthenticated: http.StatusUnauthorized,
+		codes.ResourceExhausted: http.StatusTooManyRequests,
+		codes.Unimplemented: http.StatusNotImplemented,
+		codes.Unavailable: http.StatusServiceUnavailable,
+		codes.DeadlineExceeded: http.StatusGatewayTimeout,
+		codes.Internal: http.StatusInternalServerError,
+		codes.Unknown: http.StatusInternalServerError,
+		codes.DataLoss: http.StatusInternalServerError,
+	}
+	for code, httpStatus := range want {
+		if got := GrpcToHttpStatus(status.Error(code, "message")); got != httpStatus {
+			t.Errorf("want gRPC code: %v to map to: %d, got: %d", code, httpStatus, got)
+		}
+	}
+	// an error that did not come from a gRPC call is an internal error of the gateway
+	if got := GrpcToHttpStatus(errors.New("not a status")); got != http.StatusInternalServerError {
+		t.Errorf("want a non status error to map to: %d, got: %d", http.StatusInternalServerError, got)
+	}
+}
+
+func TestGrpcToHttpStatus_ArchivedDocument_Unit(t *testing.T) {
+	// only the failed precondition with the archived reason is gone
+	st, err := status.New(codes.FailedPrecondition, "the document is archived").WithDetails(&errdetails.ErrorInfo{
+		Reason: documentService.ReasonDocumentArchived,
+		Domain: "document_service",
+	})
+	if err != nil {
+		t.Fatalf("failed to attach details to status with error: %v", err)
+	}
+	if got := GrpcToHttpStatus(st.Err()); got != http.StatusGone {
+		t.Errorf("want an archived document to map to: %d, got: %d", http.StatusGone, got)
+	}
+	st, err = status.New(codes.FailedPrecondition, "some other precondition").WithDetails(&errdetails.ErrorInfo{
+		Reason: "SOME_OTHER_REASON",
+	})
+	if err != nil {
+		t.Fatalf("failed to attach details to status with error: %v", err)
+	}
+	if got := GrpcToHttpStatus(st.Err()); got != http.StatusBadRequest {
+		t.Errorf("want another failed precondition to map to: %d, got: %d", http.StatusBadRequest, got)
+	}
+}
+
+func TestGrpcToHttpStatus_QuotaExceeded_Unit(t *testing.T) {
+	st, err := status.New(codes.ResourceExhausted, "the document has too many guests").WithDetails(&errdetails.ErrorInfo{
+		Reason: documentService.ReasonQuotaExceeded,
+		Domain: "document_service",
+	})
+	if err != nil {
+		t.Fatalf("failed to attach details to status with error: %v", err)
+	}
+	if got := GrpcToHttpStatus(st.Err()); got != http.StatusForbidden {
+		t.Errorf("want an exceeded quota to map to: %d, got: %d", http.StatusForbidden, got)
+	}
+	// a rate limit without the quota reason can be retried
+	if got := GrpcToHttpStatus(status.Error(codes.ResourceExhausted, "rate limit exceeded")); got != http.StatusTooManyRequests {
+		t.Errorf("want a rate limit to map to: %d, got: %d", http.StatusTooManyRequests, got)
+	}
+}
+
+func TestGrpcErrorResponse_FieldViolations_Unit(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid user").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{ Field: "userName", Description: "must not be empty" },
+			{ Field: "email", Description: "is not a valid email" },
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to attach details to status with error: %v", err)
+	}
+	code, response := GrpcErrorResponse(st.Err())
+	if code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d", http.StatusBadRequest, code)
+	}
+	if response.Message == nil || *response.Message != "invalid user" {
+		t.Errorf("want the message of the status without the client prefix, got: %v", response.Message)
+	}
+	want := map[string]string{ "userName": "must not be empty", "email": "is not a valid email" }
+	if response.Fields == nil || !maps.Equal(*response.Fields, want) {
+		t.Errorf("want fields: %v, got: %v", want, response.Fields)
+	}
+	// a status without details has no fields
+	if _, response = GrpcErrorResponse(status.Error(codes.NotFound, "missing")); response.Fields != nil {
+		t.Errorf("want no fields for a status without details, got: %v", *response.Fields)
+	}
+}
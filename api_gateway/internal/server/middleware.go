@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/townsag/reed/api_gateway/internal/config"
+)
+
+// routes that can be reached without a token, keyed by method and path. The handlers for these
+// routes issue tokens instead of acting on behalf of an authenticated principal. These routes
+// are still validated against the openapi spec
+var authExemptRoutes = map[string]bool{
+	http.MethodPost + " /auth/login": true,
+}
+
+func isAuthExempt(r *http.Request) bool {
+	return authExemptRoutes[r.Method+" "+r.URL.Path]
+}
+
+// the generated handler wraps the operation handler with each middleware in turn, which means
+// that the last middleware in the list is the outermost one and runs first. Chain accepts the
+// middlewares in the order that they should run and returns them in the order expected by the
+// generated handler
+func Chain(middlewares ...MiddlewareFunc) []MiddlewareFunc {
+	chain := make([]MiddlewareFunc, len(middlewares))
+	for i, middleware := range middlewares {
+		chain[len(middlewares)-1-i] = middleware
+	}
+	return chain
+}
+
+// the middlewares that are installed on every route, in the order that they run:
+//  0. recovery: turns a panic in any of the later steps into a logged 500 so that the client
+//     is not left with a dropped connection
+//  1. request id: gives the request an id that is logged with any internal error and sent
+//     back in the X-Request-Id header
+//  2. auth: rejects requests without a valid token and adds the claims of the token to the
+//     request context. User and guest type tokens are also rejected once they have been
+//     revoked. Routes in authExemptRoutes skip this step
+//  3. request validation: validates the request against the openapi spec. This runs after auth
+//     so that unauthenticated callers are rejected before we look at the request, and so that
+//     the security requirements of the spec can be checked against the claims set by auth
+func DefaultMiddlewares(
+	jwtKeys *config.JWTKeys, tokenVersions *TokenVersionCache, guestTokenVersions *TokenVersionCache,
+) []MiddlewareFunc {
+	return Chain(
+		RecoveryMiddleware(),
+		RequestIdMiddleware(),
+		NewAuthMiddleware(jwtKeys, tokenVersions, guestTokenVersions),
+		RequestValidationMiddleware(),
+	)
+}
+
+// create the http handler for the api gateway with the default middleware chain
+func NewHandler(service *Service) http.Handler {
+	return HandlerWithOptions(
+		service, StdHTTPServerOptions{
+			Middlewares: DefaultMiddlewares(service.jwtKeys, service.tokenVersions, service.guestTokenVersions),
+			ErrorHandlerFunc: ErrorHandlerFunc,
+		},
+	)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// the service clients are nil, so these tests only send requests that are rejected by the
+// middleware chain before they reach a handler
+func serveTestRequest(t *testing.T, method string, path string, body string, token string) *httptest.ResponseRecorder {
+	service := NewService(nil, nil, testJWTKeys, nil)
+	handler := NewHandler(&service)
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		r.Header.Set("Authentication", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func signTestToken(t *testing.T) string {
+	signed, err := signToken(CustomClaims{
+		UserName: "testUser",
+		PrincipalType: PrincipalTypeUser,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: "reed",
+			Subject: uuid.NewString(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign token with error: %v", err)
+	}
+	return signed
+}
+
+func TestChain_RunsInOrder_Unit(t *testing.T) {
+	var order []string
+	record := func(name string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	// wrap the handler the same way the generated handler does
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	})
+	for _, middleware := range Chain(record("first"), record("second")) {
+		handler = middleware(handler)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	want := []string{"first", "second", "handler"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("want order: %v, got: %v", want, order)
+	}
+}
+
+func TestMiddleware_ProtectedRouteWithoutToken_Unit(t *testing.T) {
+	// the body is invalid, auth runs first so the request is rejected as unauthenticated
+	// instead of as a bad request
+	w := serveTestRequest(t, http.MethodPost, "/document", `{}`, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusUnauthorized, w.Code, w.Body.String())
+	}
+}
+
+func TestMiddleware_ProtectedRouteWithToken_Unit(t *testing.T) {
+	// with a valid token the request reaches validation, which rejects the missing userId
+	w := serveTestRequest(t, http.MethodPost, "/document", `{}`, signTestToken(t))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestMiddleware_LoginWithoutToken_Unit(t *testing.T) {
+	// the login route is reachable without a token but the body is still validated
+	w := serveTestRequest(t, http.MethodPost, "/auth/login", `{"userName": "a", "password": "short"}`, "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
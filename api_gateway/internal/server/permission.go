@@ -1,12 +1,15 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
 	pb "github.com/townsag/reed/document_service/api/v1"
+
+	"github.com/townsag/reed/api_gateway/internal/config"
 )
 
 // get all the users that have permission on a document, this is only meant to be called by
@@ -33,7 +36,7 @@ func (s *Service) GetDocumentDocumentIdPermission(
 	// parse out the calling userId
 	userId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	// parse out the permissions filter
@@ -56,12 +59,22 @@ func (s *Service) GetDocumentDocumentIdPermission(
 			return
 		}
 	}
+	limit, err := resolvePageLimit(params.Limit)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// optionally leave the calling user out of the listing
+	var excludedPrincipalId *uuid.UUID
+	if params.ExcludeSelf != nil && *params.ExcludeSelf {
+		excludedPrincipalId = &userId
+	}
 	// call the document service with the document id and the calling users user id
 	result, err := s.documentServiceClient.ListPermissionsOnDocument(
-		r.Context(), documentId, userId, permissionFilter, cursor, params.Limit,
+		r.Context(), documentId, userId, permissionFilter, cursor, &limit, excludedPrincipalId,
 	)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
 	// reformat the response and send it to the client
@@ -86,6 +99,8 @@ func (s *Service) GetDocumentDocumentIdPermission(
 		&ListPermissionsOnDocumentResponse{
 			Cursor: &responseCursor,
 			Permissions: permissions,
+			HasMore: result.HasMore,
+			Limit: limit,
 		},
 	)
 }
@@ -113,52 +128,127 @@ func (s *Service) PostDocumentDocumentIdPermission(
 	// perform a coarse grain check of the provided token, if it is a guest token we can reject it
 	principalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	if claims.GetTokenType() != PrincipalTypeUser {
 		SendError(w, http.StatusForbidden, "must have a user token to create permissions on a document")
 		return
 	}
-	// parse the request body
+	// parse the request body, the request validation middleware has already rejected bodies
+	// that grant the owner level or that identify the user both by id and by email
 	var reqBody PostDocumentDocumentIdPermissionJSONBody
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		SendError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	// validate that the permission level is not owner
-	if reqBody.PermissionLevel == Owner {
-		SendError(w, http.StatusBadRequest, "cannot create permissions at owner level")
-		return
+	// the permission level is optional, when it is missing the document service decides the
+	// default permission level of a guest
+	var permissionLevel *pb.PermissionLevel
+	if reqBody.PermissionLevel != nil {
+		// parse the permission level
+		parsedPermissionLevel, err := netToProtoPermissionLevel(*reqBody.PermissionLevel)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "unable to map the given permission level to a valid permission level")
+			return
+		}
+		permissionLevel = &parsedPermissionLevel
 	}
-	// parse the permission level
-	permissionLevel, err := netToProtoPermissionLevel(reqBody.PermissionLevel)
-	if err != nil {
-		SendError(w, http.StatusBadRequest, "unable to map the given permission level to a valid permission level")
+	// a user can be identified either by id or by email, an email is resolved to the id of the
+	// user that it belongs to before the permission is created
+	userIdToShare := reqBody.UserIdToShare
+	if reqBody.EmailToShare != nil {
+		if permissionLevel == nil {
+			SendError(w, http.StatusBadRequest, "permissionLevel is required when sharing with a user")
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), config.TIMEOUT_MILLISECONDS)
+		defer cancel()
+		// the caller has to be able to grant the level before the email is resolved, otherwise any
+		// user could learn which emails belong to a user by sharing a document they can not share
+		callerPermission, err := s.documentServiceClient.GetPermissionsOfPrincipalOnDocument(
+			ctx, documentId, principalId, principalId, nil,
+		)
+		if err != nil {
+			if code := GrpcToHttpStatus(err); code == http.StatusNotFound || code == http.StatusForbidden {
+				SendError(w, http.StatusForbidden, "must hold a permission on the document to share it")
+				return
+			}
+			SendGrpcError(w, r, err)
+			return
+		}
+		// the levels are numbered from viewer up to owner, like the permission hierarchy of the
+		// document service a caller can not grant a level above their own
+		if callerPermission.Permission.GetPending() || callerPermission.Permission.GetPermissionLevel() < *permissionLevel {
+			SendError(w, http.StatusForbidden, "cannot grant a permission level higher than your own")
+			return
+		}
+		reply, err := s.userServiceClient.GetUserByEmail(ctx, string(*reqBody.EmailToShare))
+		if err != nil {
+			if GrpcToHttpStatus(err) == http.StatusNotFound {
+				SendError(w, http.StatusNotFound, fmt.Sprintf("no user found with email: %s", *reqBody.EmailToShare))
+				return
+			}
+			SendGrpcError(w, r, err)
+			return
+		}
+		resolvedUserId, err := uuid.Parse(reply.User.UserId)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to parse user id returned from backend service")
+			return
+		}
+		userIdToShare = &resolvedUserId
+	}
+	invite := reqBody.Invite != nil && *reqBody.Invite
+	if invite && userIdToShare == nil {
+		SendError(w, http.StatusBadRequest, "only a user can be invited, set userIdToShare or emailToShare")
 		return
 	}
 	// determine if this is a request to create a guest or a request to create a permission of a user
-	if reqBody.UserIdToShare != nil {
-		// this is a request to create a permission on a user
-		err := s.documentServiceClient.UpsertPermissionUser(
-			r.Context(), *reqBody.UserIdToShare, principalId, documentId, permissionLevel,
+	if userIdToShare != nil {
+		// this is a request to create a permission on a user, there is no default level
+		// for sharing with a user
+		if permissionLevel == nil {
+			SendError(w, http.StatusBadRequest, "permissionLevel is required when sharing with a user")
+			return
+		}
+		if invite {
+			// the user is offered a pending share that grants no access until they accept it
+			err = s.documentServiceClient.InviteUser(
+				r.Context(), *userIdToShare, principalId, documentId, *permissionLevel,
+			)
+			if err != nil {
+				SendGrpcError(w, r, err)
+				return
+			}
+			pending := true
+			SendJsonResponse(w, http.StatusOK, &ShareDocumentResponse{
+				UserIdSharedWith: userIdToShare,
+				Pending: &pending,
+			})
+			return
+		}
+		reply, err := s.documentServiceClient.UpsertPermissionUser(
+			r.Context(), *userIdToShare, principalId, documentId, *permissionLevel,
 		)
 		if err != nil {
-			SendError(w, GrpcToHttpStatus(err), err.Error())
+			SendGrpcError(w, r, err)
 			return
 		}
-		// send a response with the user id that the document was shared with
+		// send a response with the user id that the document was shared with and whether
+		// the share created a new permission or updated an existing one
 		SendJsonResponse(w, http.StatusOK, &ShareDocumentResponse{
-			UserIdSharedWith: reqBody.UserIdToShare,
+			UserIdSharedWith: userIdToShare,
+			Created: &reply.Created,
 		})
 		return
 	} else {
 		// this is a request to create a guest
 		result, err := s.documentServiceClient.CreateGuest(
-			r.Context(), documentId, principalId, permissionLevel,
+			r.Context(), documentId, principalId, permissionLevel, nil,
 		)
 		if err != nil {
-			SendError(w, GrpcToHttpStatus(err), err.Error())
+			SendGrpcError(w, r, err)
 			return
 		}
 		guestId, err := uuid.Parse(result.GuestId)
@@ -166,14 +256,155 @@ func (s *Service) PostDocumentDocumentIdPermission(
 			SendError(w, http.StatusInternalServerError, "Internal Server Error")
 			return
 		}
-		// send a response with the created guest id
+		// a new guest starts at the first token version
+		guestToken, err := signGuestToken(guestId, 0, s.jwtKeys)
+		if err != nil {
+			SendInternalError(w, r, err)
+			return
+		}
+		// send a response with the created guest id and a token for the guest
 		SendJsonResponse(w, http.StatusOK, &ShareDocumentResponse{
 			GuestId: &guestId,
+			GuestToken: &guestToken,
 		})
 		return
 	}
 }
 
+// create several guests on a document at once
+// (POST /document/{documentId}/guests)
+func (s *Service) PostDocumentDocumentIdGuests(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// coarse grain check, only users can be the owner of a document
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "must have a user token to create guests on a document")
+		return
+	}
+	var reqBody PostDocumentDocumentIdGuestsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// the document service decides the default permission level of a guest
+	var permissionLevel *pb.PermissionLevel
+	if reqBody.PermissionLevel != nil {
+		parsedPermissionLevel, err := netToProtoPermissionLevel(*reqBody.PermissionLevel)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "unable to map the given permission level to a valid permission level")
+			return
+		}
+		permissionLevel = &parsedPermissionLevel
+	}
+	// the document service checks that the caller is the owner of the document and that the
+	// count is under its cap
+	guestIds, err := s.documentServiceClient.CreateGuests(r.Context(), documentId, principalId, reqBody.Count, permissionLevel)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	SendJsonResponse(w, http.StatusOK, &CreateGuestsResponse{
+		GuestIds: guestIds,
+	})
+}
+
+// share a document with a batch of users
+// (POST /document/{documentId}/permission/users)
+func (s *Service) PostDocumentDocumentIdPermissionUsers(w http.ResponseWriter, r *http.Request, documentId DocumentId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal Server error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "must have a user token to create permissions on a document")
+		return
+	}
+	// the request validation middleware has already rejected empty batches, batches over the
+	// cap of the document service, and shares that grant the owner level
+	var reqBody PostDocumentDocumentIdPermissionUsersJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	shares := make([]*pb.UserShare, len(reqBody.Shares))
+	for i, share := range reqBody.Shares {
+		permissionLevel, err := netToProtoPermissionLevel(share.PermissionLevel)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "unable to map the given permission level to a valid permission level")
+			return
+		}
+		shares[i] = &pb.UserShare{ UserId: share.UserId.String(), PermissionLevel: permissionLevel }
+	}
+	reply, err := s.documentServiceClient.UpsertPermissionsUser(r.Context(), principalId, documentId, shares)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	results := make([]UserShareResult, len(reply.Results))
+	for i, result := range reply.Results {
+		userId, err := uuid.Parse(result.UserId)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to parse user id returned from backend service")
+			return
+		}
+		results[i] = UserShareResult{ UserId: userId, Created: result.Created }
+	}
+	SendJsonResponse(w, http.StatusOK, &ShareDocumentUsersResponse{
+		Results: results,
+	})
+}
+
+// invalidate the tokens of a guest and issue a new one
+// (POST /guest/{guestId}/rotate)
+func (s *Service) PostGuestGuestIdRotate(w http.ResponseWriter, r *http.Request, guestId GuestId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// coarse grain check, only users can be the owner of a document
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "must have a user token to rotate the link of a guest")
+		return
+	}
+	// the document service checks that the caller is the owner of the document of the guest
+	tokenVersion, err := s.documentServiceClient.RotateGuestLink(r.Context(), guestId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	// the tokens issued before the rotation are rejected by this gateway right away, other
+	// gateway instances reject them once their cached version expires
+	s.guestTokenVersions.Invalidate(guestId)
+	guestToken, err := signGuestToken(guestId, tokenVersion, s.jwtKeys)
+	if err != nil {
+		SendInternalError(w, r, err)
+		return
+	}
+	SendJsonResponse(w, http.StatusOK, &RotateGuestLinkResponse{
+		GuestToken: guestToken,
+	})
+}
+
 // delete a user or guests permissions on a document
 // (DELETE /document/{documentId}/permission/principal/{principalId})
 func (s *Service) DeleteDocumentDocumentIdPermissionPrincipalPrincipalId(
@@ -190,7 +421,7 @@ func (s *Service) DeleteDocumentDocumentIdPermissionPrincipalPrincipalId(
 	}
 	callingPrincipalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	// perform a coarse grain authorization check, only user type tokens should be able to
@@ -204,7 +435,68 @@ func (s *Service) DeleteDocumentDocumentIdPermissionPrincipalPrincipalId(
 		r.Context(), principalId, documentId, callingPrincipalId,
 	)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// a collaborator removes their own permission on a document
+// (DELETE /document/{documentId}/permission/self)
+func (s *Service) DeleteDocumentDocumentIdPermissionSelf(
+	w http.ResponseWriter,
+	r *http.Request,
+	documentId DocumentId,
+) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	callingPrincipalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// guests only exist for one document, a guest link is removed by the owner of the document
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "only users type tokens can leave a document")
+		return
+	}
+	// the document service rejects owners that try to leave
+	err = s.documentServiceClient.LeaveDocument(r.Context(), documentId, callingPrincipalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// accept the pending share of the caller on a document
+// (POST /document/{documentId}/permission/self/accept)
+func (s *Service) PostDocumentDocumentIdPermissionSelfAccept(
+	w http.ResponseWriter,
+	r *http.Request,
+	documentId DocumentId,
+) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	callingPrincipalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// shares are only offered to users
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "only users type tokens can accept a share")
+		return
+	}
+	err = s.documentServiceClient.AcceptPendingShare(r.Context(), documentId, callingPrincipalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -226,7 +518,7 @@ func (s *Service) GetDocumentDocumentIdPermissionPrincipalPrincipalId(
 	}
 	callingPrincipalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusForbidden, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	// coarse grain check, guests cannot get the permission of a principal on a document
@@ -237,10 +529,10 @@ func (s *Service) GetDocumentDocumentIdPermissionPrincipalPrincipalId(
 	}
 	// call the document service to get the permission of the principal on this document
 	result, err := s.documentServiceClient.GetPermissionsOfPrincipalOnDocument(
-		r.Context(), documentId, principalId, callingPrincipalId,
+		r.Context(), documentId, principalId, callingPrincipalId, claims.GetPublicLinkVersion(documentId),
 	)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
 	// reformat the returned permission so that it can be sent over http instead of gRPC
@@ -270,7 +562,7 @@ func (s *Service) PutDocumentDocumentIdPermissionPrincipalPrincipalId(
 	}
 	callingPrincipalId, err := claims.ParsePrincipalId()
 	if err != nil {
-		SendError(w, http.StatusBadRequest, err.Error())
+		SendError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	// coarse grain check: only users can have the permission level of owner, hence only users can
@@ -299,11 +591,11 @@ func (s *Service) PutDocumentDocumentIdPermissionPrincipalPrincipalId(
 	// if this is a user principal type then call the document service upsert permission
 	// user rpc, if this is a guest principal type then call the update permission guest rpc
 	if reqBody.PrincipalType == PrincipalTypeUser {
-		err = s.documentServiceClient.UpsertPermissionUser(
+		_, err = s.documentServiceClient.UpsertPermissionUser(
 			r.Context(), principalId, callingPrincipalId, documentId, permissionLevel,
 		)
 		if err != nil {
-			SendError(w, GrpcToHttpStatus(err), err.Error())
+			SendGrpcError(w, r, err)
 			return
 		} else {
 			w.WriteHeader(http.StatusNoContent)
@@ -314,7 +606,7 @@ func (s *Service) PutDocumentDocumentIdPermissionPrincipalPrincipalId(
 			r.Context(), principalId, callingPrincipalId, permissionLevel,
 		)
 		if err != nil {
-			SendError(w, GrpcToHttpStatus(err), err.Error())
+			SendGrpcError(w, r, err)
 			return
 		} else {
 			w.WriteHeader(http.StatusNoContent)
@@ -331,4 +623,48 @@ CHECKPOINT:
 	- look into wether I should be using a pointer or value receiver for the route handler 
 	  functions
 
-*/
\ No newline at end of file
+*/
+// get the owner of a document, a preview of its collaborators, and the number of collaborators
+// (GET /document/{documentId}/sharing-summary)
+func (s *Service) GetDocumentDocumentIdSharingSummary(
+	w http.ResponseWriter,
+	r *http.Request,
+	documentId DocumentId,
+) {
+	// parse the claims and the calling principal id from the JWT
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	callingPrincipalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// the document service checks that the caller has a permission on the document
+	result, err := s.documentServiceClient.GetDocumentSharingSummary(r.Context(), documentId, callingPrincipalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	owner, err := protoToNetPermission(result.Owner)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	collaborators := make([]Permission, len(result.Collaborators))
+	for i, collaborator := range result.Collaborators {
+		permission, err := protoToNetPermission(collaborator)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		collaborators[i] = *permission
+	}
+	SendJsonResponse(w, http.StatusOK, GetSharingSummaryResponse{
+		Owner: *owner,
+		Collaborators: collaborators,
+		CollaboratorCount: result.CollaboratorCount,
+	})
+}
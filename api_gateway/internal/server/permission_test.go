@@ -0,0 +1,777 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	documentPb "github.com/townsag/reed/document_service/api/v1"
+	documentService "github.com/townsag/reed/document_service/pkg/client"
+	userPb "github.com/townsag/reed/user_service/api"
+	userService "github.com/townsag/reed/user_service/pkg/client"
+)
+
+// resolves the emails in users to user ids and back, every other email is not found. Every
+// other user id is an active user without an email so that tests can sign tokens for random
+// users. Changing the password or deactivating a user bumps their token version. The user
+// names in passwords can log in with their password, every other user name is not found
+type fakeUserServer struct {
+	userPb.UnimplementedUserServiceServer
+	users map[string]uuid.UUID
+	mu sync.Mutex
+	tokenVersions map[string]int32
+	deactivated map[string]bool
+	passwords map[string]string
+}
+
+// the user id of a user name is looked up in users, like the real user service an invalid
+// password is not an error
+func (f *fakeUserServer) ValidatePassword(
+	ctx context.Context, req *userPb.ValidatePasswordRequest,
+) (*userPb.ValidatePasswordReply, error) {
+	password, ok := f.passwords[req.UserName]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no user found")
+	}
+	if password != req.UserPassword {
+		nilId := uuid.Nil.String()
+		return &userPb.ValidatePasswordReply{ UserId: &nilId, IsValid: false }, nil
+	}
+	userId := f.users[req.UserName].String()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &userPb.ValidatePasswordReply{
+		UserId: &userId,
+		IsValid: true,
+		TokenVersion: f.tokenVersions[userId],
+	}, nil
+}
+
+func (f *fakeUserServer) bumpTokenVersion(userId string) {
+	if f.tokenVersions == nil {
+		f.tokenVersions = make(map[string]int32)
+	}
+	f.tokenVersions[userId]++
+}
+
+func (f *fakeUserServer) GetUserByEmail(
+	ctx context.Context, req *userPb.GetUserByEmailRequest,
+) (*userPb.UserReply, error) {
+	userId, ok := f.users[req.UserEmail]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no user found")
+	}
+	return &userPb.UserReply{
+		User: &userPb.User{ UserId: userId.String(), Email: req.UserEmail },
+	}, nil
+}
+
+// a user name or email is taken when it is a key of users
+func (f *fakeUserServer) CheckAvailability(
+	ctx context.Context, req *userPb.CheckAvailabilityRequest,
+) (*userPb.CheckAvailabilityReply, error) {
+	reply := &userPb.CheckAvailabilityReply{}
+	if req.UserName != nil {
+		_, taken := f.users[*req.UserName]
+		available := !taken
+		reply.UserNameAvailable = &available
+	}
+	if req.Email != nil {
+		_, taken := f.users[*req.Email]
+		available := !taken
+		reply.EmailAvailable = &available
+	}
+	return reply, nil
+}
+
+func (f *fakeUserServer) GetUser(
+	ctx context.Context, req *userPb.GetUserRequest,
+) (*userPb.UserReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user := &userPb.User{
+		UserId: req.UserId,
+		IsActive: !f.deactivated[req.UserId],
+		TokenVersion: f.tokenVersions[req.UserId],
+	}
+	for email, userId := range f.users {
+		if userId.String() == req.UserId {
+			user.Email = email
+		}
+	}
+	return &userPb.UserReply{ User: user }, nil
+}
+
+func (f *fakeUserServer) ChangeUserPassword(
+	ctx context.Context, req *userPb.ChangeUserPasswordRequest,
+) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bumpTokenVersion(req.UserId)
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeUserServer) DeactivateUser(
+	ctx context.Context, req *userPb.DeactivateUserRequest,
+) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.deactivated == nil {
+		f.deactivated = make(map[string]bool)
+	}
+	f.deactivated[req.UserId] = true
+	f.bumpTokenVersion(req.UserId)
+	return &emptypb.Empty{}, nil
+}
+
+// records the user id of every permission that is upserted, the page size of every page of
+// documents that is listed, the owners whose documents are reassigned, and the documents that
+// are left. Reassigning fails with reassignErr when it is set and records the deadline of the
+// request in reassignDeadline. Every guest exists and starts at
+// token version 0, rotating the link of a guest bumps its version
+type fakeDocumentServer struct {
+	documentPb.UnimplementedDocumentServiceServer
+	mu sync.Mutex
+	upsertedUserIds []string
+	pageSizes []int32
+	favoritesOnly []bool
+	includeArchived []bool
+	reassignedOwnerIds []string
+	reassignErr error
+	reassignDeadline time.Time
+	leftDocumentIds []string
+	// the excluded principal of each list permissions request, empty when none was sent
+	excludedPrincipalIds []string
+	listAllRequests []*documentPb.ListAllDocumentsRequest
+	guestTokenVersions map[string]int32
+	// the collection id of each list documents request, empty when none was sent
+	listedCollectionIds []string
+	// the collection and document of each membership change, prefixed with add or remove
+	collectionChanges []string
+	// the document and caller of each archive change, prefixed with archive or restore
+	archiveChanges []string
+	// the document, fallback owner and caller of each owner repair
+	ownerRepairs []string
+	// the level of each principal on every document, a principal that is not a key has no
+	// permission on any document
+	permissionLevels map[string]documentPb.PermissionLevel
+}
+
+func (f *fakeDocumentServer) GetPermissionsOfPrincipalOnDocument(
+	ctx context.Context, req *documentPb.GetPermissionsRequest,
+) (*documentPb.GetPermissionsReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	level, ok := f.permissionLevels[req.PrincipalId]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "no permission on the document")
+	}
+	return &documentPb.GetPermissionsReply{
+		Permission: &documentPb.Permission{
+			Recipient: &documentPb.Principal{ PrincipalId: req.PrincipalId },
+			DocumentId: req.DocumentId,
+			PermissionLevel: level,
+		},
+	}, nil
+}
+
+func (f *fakeDocumentServer) EnsureDocumentHasOwner(
+	ctx context.Context, req *documentPb.EnsureDocumentHasOwnerRequest,
+) (*documentPb.EnsureDocumentHasOwnerReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ownerRepairs = append(
+		f.ownerRepairs, req.DocumentId+" "+req.FallbackOwnerId+" "+req.GetClientContext().GetPrincipalId(),
+	)
+	return &documentPb.EnsureDocumentHasOwnerReply{ Repaired: true }, nil
+}
+
+func (f *fakeDocumentServer) repairedOwners() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ownerRepairs
+}
+
+func (f *fakeDocumentServer) RotateGuestLink(
+	ctx context.Context, req *documentPb.RotateGuestLinkRequest,
+) (*documentPb.RotateGuestLinkReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.guestTokenVersions == nil {
+		f.guestTokenVersions = make(map[string]int32)
+	}
+	f.guestTokenVersions[req.GuestId]++
+	return &documentPb.RotateGuestLinkReply{ TokenVersion: f.guestTokenVersions[req.GuestId] }, nil
+}
+
+func (f *fakeDocumentServer) GetGuestTokenVersion(
+	ctx context.Context, req *documentPb.GetGuestTokenVersionRequest,
+) (*documentPb.GetGuestTokenVersionReply, error) {
+	// like the document service, only the guest can read its token version
+	if req.GetClientContext().GetPrincipalId() != req.GuestId {
+		return nil, status.Error(codes.PermissionDenied, "only the guest can read its token version")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &documentPb.GetGuestTokenVersionReply{ TokenVersion: f.guestTokenVersions[req.GuestId] }, nil
+}
+
+func (f *fakeDocumentServer) upserted() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.upsertedUserIds
+}
+
+func (f *fakeDocumentServer) UpsertPermissionUser(
+	ctx context.Context, req *documentPb.UpsertPermissionUserRequest,
+) (*documentPb.UpsertPermissionUserReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.upsertedUserIds = append(f.upsertedUserIds, req.UserId)
+	return &documentPb.UpsertPermissionUserReply{ Created: true }, nil
+}
+
+// a user is created the first time they are upserted and updated after that
+func (f *fakeDocumentServer) UpsertPermissionsUser(
+	ctx context.Context, req *documentPb.UpsertPermissionsUserRequest,
+) (*documentPb.UpsertPermissionsUserReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reply := &documentPb.UpsertPermissionsUserReply{}
+	for _, share := range req.Shares {
+		reply.Results = append(reply.Results, &documentPb.UpsertPermissionsUserReply_Result{
+			UserId: share.UserId,
+			Created: !slices.Contains(f.upsertedUserIds, share.UserId),
+		})
+		f.upsertedUserIds = append(f.upsertedUserIds, share.UserId)
+	}
+	return reply, nil
+}
+
+func (f *fakeDocumentServer) listedPageSizes() []int32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pageSizes
+}
+
+func (f *fakeDocumentServer) ListDocumentsByPrincipal(
+	ctx context.Context, req *documentPb.ListDocumentByPrincipalRequest,
+) (*documentPb.ListDocumentsByPrincipalReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pageSizes = append(f.pageSizes, req.GetPageSize())
+	f.favoritesOnly = append(f.favoritesOnly, req.GetFavoritesOnly())
+	f.includeArchived = append(f.includeArchived, req.GetIncludeArchived())
+	f.listedCollectionIds = append(f.listedCollectionIds, req.GetCollectionId())
+	return &documentPb.ListDocumentsByPrincipalReply{}, nil
+}
+
+func (f *fakeDocumentServer) listedCollections() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.listedCollectionIds
+}
+
+// the collection is owned by the calling principal
+func (f *fakeDocumentServer) CreateCollection(
+	ctx context.Context, req *documentPb.CreateCollectionRequest,
+) (*documentPb.Collection, error) {
+	return &documentPb.Collection{
+		CollectionId: uuid.NewString(),
+		OwnerId: req.GetClientContext().GetPrincipalId(),
+		Name: req.Name,
+		CreatedAt: timestamppb.Now(),
+	}, nil
+}
+
+func (f *fakeDocumentServer) AddToCollection(
+	ctx context.Context, req *documentPb.AddToCollectionRequest,
+) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.collectionChanges = append(f.collectionChanges, "add "+req.CollectionId+" "+req.DocumentId)
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeDocumentServer) RemoveFromCollection(
+	ctx context.Context, req *documentPb.RemoveFromCollectionRequest,
+) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.collectionChanges = append(f.collectionChanges, "remove "+req.CollectionId+" "+req.DocumentId)
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeDocumentServer) ArchiveDocument(
+	ctx context.Context, req *documentPb.ArchiveDocumentRequest,
+) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.archiveChanges = append(f.archiveChanges, "archive "+req.DocumentId+" "+req.GetClientContext().GetPrincipalId())
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeDocumentServer) RestoreDocument(
+	ctx context.Context, req *documentPb.RestoreDocumentRequest,
+) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.archiveChanges = append(f.archiveChanges, "restore "+req.DocumentId+" "+req.GetClientContext().GetPrincipalId())
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeDocumentServer) changedArchives() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.archiveChanges
+}
+
+func (f *fakeDocumentServer) changedCollections() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.collectionChanges
+}
+
+func (f *fakeDocumentServer) listedFavoritesOnly() []bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.favoritesOnly
+}
+
+func (f *fakeDocumentServer) listedIncludeArchived() []bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.includeArchived
+}
+
+// creates the requested number of guests without recording them
+func (f *fakeDocumentServer) CreateGuests(
+	ctx context.Context, req *documentPb.CreateGuestsRequest,
+) (*documentPb.CreateGuestsReply, error) {
+	guestIds := make([]string, req.Count)
+	for i := range guestIds {
+		guestIds[i] = uuid.NewString()
+	}
+	return &documentPb.CreateGuestsReply{ GuestIds: guestIds }, nil
+}
+
+func (f *fakeDocumentServer) ListPermissionsOnDocument(
+	ctx context.Context, req *documentPb.ListPermissionsOnDocumentRequest,
+) (*documentPb.ListPermissionsOnDocumentReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.excludedPrincipalIds = append(f.excludedPrincipalIds, req.GetExcludedPrincipalId())
+	return &documentPb.ListPermissionsOnDocumentReply{}, nil
+}
+
+// every document was deleted at the tombstone time, the tombstone is only sent when the
+// request asks for it
+func (f *fakeDocumentServer) GetDocument(
+	ctx context.Context, req *documentPb.GetDocumentRequest,
+) (*documentPb.GetDocumentReply, error) {
+	if !req.GetIncludeTombstone() {
+		return nil, status.Error(codes.NotFound, "document not found")
+	}
+	return &documentPb.GetDocumentReply{
+		Tombstone: &documentPb.DocumentTombstone{
+			DocumentId: req.GetDocumentId(),
+			DeletedAt: timestamppb.New(tombstoneTime),
+		},
+	}, nil
+}
+
+func (f *fakeDocumentServer) LeaveDocument(
+	ctx context.Context, req *documentPb.LeaveDocumentRequest,
+) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.leftDocumentIds = append(f.leftDocumentIds, req.DocumentId)
+	return &emptypb.Empty{}, nil
+}
+
+// every principal holds the same fixed counts
+func (f *fakeDocumentServer) CountDocumentsByPrincipalGrouped(
+	ctx context.Context, req *documentPb.CountDocumentsByPrincipalGroupedRequest,
+) (*documentPb.CountDocumentsByPrincipalGroupedReply, error) {
+	return &documentPb.CountDocumentsByPrincipalGroupedReply{
+		OwnerCount: 2, EditorCount: 3, ViewerCount: 1,
+	}, nil
+}
+
+func (f *fakeDocumentServer) reassigned() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reassignedOwnerIds
+}
+
+func (f *fakeDocumentServer) ReassignOwnedDocuments(
+	ctx context.Context, req *documentPb.ReassignOwnedDocumentsRequest,
+) (*documentPb.ReassignOwnedDocumentsReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reassignDeadline, _ = ctx.Deadline()
+	if f.reassignErr != nil {
+		return nil, f.reassignErr
+	}
+	f.reassignedOwnerIds = append(f.reassignedOwnerIds, req.FromOwnerId)
+	return &documentPb.ReassignOwnedDocumentsReply{ MovedCount: 3 }, nil
+}
+
+func (f *fakeDocumentServer) listedAll() []*documentPb.ListAllDocumentsRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.listAllRequests
+}
+
+// every request lists one document
+func (f *fakeDocumentServer) ListAllDocuments(
+	ctx context.Context, req *documentPb.ListAllDocumentsRequest,
+) (*documentPb.ListAllDocumentsReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listAllRequests = append(f.listAllRequests, req)
+	return &documentPb.ListAllDocumentsReply{
+		Documents: []*documentPb.Document{{
+			DocumentId: uuid.NewString(),
+			CreatedAt: timestamppb.Now(),
+			LastModifiedAt: timestamppb.Now(),
+		}},
+		Cursor: &documentPb.Cursor{},
+	}, nil
+}
+
+// serve the fake backend services on a local port and return a service that calls them
+func newFakeBackendService(t *testing.T, users *fakeUserServer, documents *fakeDocumentServer) *Service {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen with error: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	userPb.RegisterUserServiceServer(grpcServer, users)
+	documentPb.RegisterDocumentServiceServer(grpcServer, documents)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	usClient, err := userService.NewUserServiceClient(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to create user service client with error: %v", err)
+	}
+	t.Cleanup(func() { usClient.Close() })
+	dsClient, err := documentService.NewDocumentServiceClient(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to create document service client with error: %v", err)
+	}
+	t.Cleanup(func() { dsClient.Close() })
+	service := NewService(usClient, dsClient, testJWTKeys, nil)
+	return &service
+}
+
+func serveShareRequest(t *testing.T, service *Service, callerId uuid.UUID, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(
+		http.MethodPost, "/document/"+uuid.NewString()+"/permission", strings.NewReader(body),
+	)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authentication", "Bearer "+signVersionedTestToken(t, callerId, 0))
+	w := httptest.NewRecorder()
+	NewHandler(service).ServeHTTP(w, r)
+	return w
+}
+
+// a document server on which the caller holds the level on every document
+func newSharerDocumentServer(callerId uuid.UUID, level documentPb.PermissionLevel) *fakeDocumentServer {
+	return &fakeDocumentServer{
+		permissionLevels: map[string]documentPb.PermissionLevel{ callerId.String(): level },
+	}
+}
+
+func TestShareByEmail_KnownEmail_Unit(t *testing.T) {
+	userId := uuid.New()
+	callerId := uuid.New()
+	documents := newSharerDocumentServer(callerId, documentPb.PermissionLevel_PERMISSION_OWNER)
+	service := newFakeBackendService(
+		t, &fakeUserServer{ users: map[string]uuid.UUID{ "known@example.com": userId } }, documents,
+	)
+	w := serveShareRequest(t, service, callerId, `{"emailToShare": "known@example.com", "permissionLevel": "viewer"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(documents.upserted()) != 1 || documents.upserted()[0] != userId.String() {
+		t.Errorf("want the permission to be upserted for user: %s, got: %v", userId, documents.upserted())
+	}
+	var response ShareDocumentResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	if response.UserIdSharedWith == nil || *response.UserIdSharedWith != userId {
+		t.Errorf("want userIdSharedWith: %s, got: %v", userId, response.UserIdSharedWith)
+	}
+}
+
+func TestShareByEmail_UnknownEmail_Unit(t *testing.T) {
+	callerId := uuid.New()
+	documents := newSharerDocumentServer(callerId, documentPb.PermissionLevel_PERMISSION_OWNER)
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	w := serveShareRequest(t, service, callerId, `{"emailToShare": "unknown@example.com", "permissionLevel": "viewer"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+	if len(documents.upserted()) != 0 {
+		t.Errorf("want no permissions to be upserted, got: %v", documents.upserted())
+	}
+}
+
+func TestShareByEmail_MalformedEmail_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	w := serveShareRequest(t, service, uuid.New(), `{"emailToShare": "not-an-email", "permissionLevel": "viewer"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if len(documents.upserted()) != 0 {
+		t.Errorf("want no permissions to be upserted, got: %v", documents.upserted())
+	}
+}
+
+func TestShareByEmail_CallerWithoutPermission_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(
+		t, &fakeUserServer{ users: map[string]uuid.UUID{ "known@example.com": uuid.New() } }, documents,
+	)
+	// a known and an unknown email get the same response, so the caller learns nothing about
+	// which emails belong to a user
+	for _, email := range []string{ "known@example.com", "unknown@example.com" } {
+		w := serveShareRequest(t, service, uuid.New(), `{"emailToShare": "`+email+`", "permissionLevel": "viewer"}`)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("want status: %d for email: %s, got: %d with body: %s", http.StatusForbidden, email, w.Code, w.Body.String())
+		}
+	}
+	if len(documents.upserted()) != 0 {
+		t.Errorf("want no permissions to be upserted, got: %v", documents.upserted())
+	}
+}
+
+func TestShareByEmail_LevelAboveCaller_Unit(t *testing.T) {
+	callerId := uuid.New()
+	documents := newSharerDocumentServer(callerId, documentPb.PermissionLevel_PERMISSION_VIEWER)
+	service := newFakeBackendService(
+		t, &fakeUserServer{ users: map[string]uuid.UUID{ "known@example.com": uuid.New() } }, documents,
+	)
+	w := serveShareRequest(t, service, callerId, `{"emailToShare": "known@example.com", "permissionLevel": "editor"}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	if len(documents.upserted()) != 0 {
+		t.Errorf("want no permissions to be upserted, got: %v", documents.upserted())
+	}
+}
+
+func TestShareByEmail_EmailAndUserId_Unit(t *testing.T) {
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	body := `{"emailToShare": "known@example.com", "userIdToShare": "` + uuid.NewString() + `", "permissionLevel": "viewer"}`
+	w := serveShareRequest(t, service, uuid.New(), body)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestCreateGuests_Unit(t *testing.T) {
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/document/"+uuid.NewString()+"/guests",
+		`{"count": 3, "permissionLevel": "editor"}`, signVersionedTestToken(t, uuid.New(), 0),
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response CreateGuestsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	if len(response.GuestIds) != 3 {
+		t.Errorf("want 3 guest ids, got: %v", response.GuestIds)
+	}
+}
+
+func TestShareWithUsers_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	existingId, newId := uuid.New(), uuid.New()
+	path := "/document/" + uuid.NewString() + "/permission/users"
+	body := `{"shares": [{"userId": "` + existingId.String() + `", "permissionLevel": "viewer"}]}`
+	w := serveVersionedRequest(t, service, http.MethodPost, path, body, signVersionedTestToken(t, uuid.New(), 0))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	body = `{"shares": [` +
+		`{"userId": "` + newId.String() + `", "permissionLevel": "viewer"},` +
+		`{"userId": "` + existingId.String() + `", "permissionLevel": "editor"}]}`
+	w = serveVersionedRequest(t, service, http.MethodPost, path, body, signVersionedTestToken(t, uuid.New(), 0))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response ShareDocumentUsersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	// the results are in the order of the shares, the new user is created and the existing user is updated
+	want := []UserShareResult{ { UserId: newId, Created: true }, { UserId: existingId, Created: false } }
+	if !slices.Equal(response.Results, want) {
+		t.Errorf("want results: %+v, got: %+v", want, response.Results)
+	}
+}
+
+func TestShareWithUsers_Invalid_Unit(t *testing.T) {
+	// the batch is validated against the spec before the document service is called
+	path := "/document/" + uuid.NewString() + "/permission/users"
+	for _, body := range []string{
+		`{"shares": []}`,
+		`{"shares": [{"userId": "` + uuid.NewString() + `", "permissionLevel": "owner"}]}`,
+		`{"shares": [{"userId": "` + uuid.NewString() + `"}]}`,
+	} {
+		w := serveTestRequest(t, http.MethodPost, path, body, signTestToken(t))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("want status: %d for body: %s, got: %d with body: %s", http.StatusBadRequest, body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCreateGuests_ZeroCount_Unit(t *testing.T) {
+	// the count is validated against the spec before the document service is called
+	w := serveTestRequest(t, http.MethodPost, "/document/"+uuid.NewString()+"/guests", `{"count": 0}`, signTestToken(t))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestGetDocumentCounts_Unit(t *testing.T) {
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	w := serveVersionedRequest(
+		t, service, http.MethodGet, "/document/counts", "", signVersionedTestToken(t, uuid.New(), 0),
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response GetDocumentCountsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	want := GetDocumentCountsResponse{ Owner: 2, Editor: 3, Viewer: 1 }
+	if response != want {
+		t.Errorf("want counts: %+v, got: %+v", want, response)
+	}
+}
+
+func TestLeaveDocument_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	documentId := uuid.NewString()
+	w := serveVersionedRequest(
+		t, service, http.MethodDelete, "/document/"+documentId+"/permission/self", "",
+		signVersionedTestToken(t, uuid.New(), 0),
+	)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	documents.mu.Lock()
+	defer documents.mu.Unlock()
+	if len(documents.leftDocumentIds) != 1 || documents.leftDocumentIds[0] != documentId {
+		t.Errorf("want document: %s to be left, got: %v", documentId, documents.leftDocumentIds)
+	}
+}
+
+func TestLeaveDocument_MalformedDocumentId_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	w := serveVersionedRequest(
+		t, service, http.MethodDelete, "/document/not-a-uuid/permission/self", "",
+		signVersionedTestToken(t, uuid.New(), 0),
+	)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "documentId") {
+		t.Errorf("want the error to name the document id, got: %s", w.Body.String())
+	}
+	documents.mu.Lock()
+	defer documents.mu.Unlock()
+	if len(documents.leftDocumentIds) != 0 {
+		t.Errorf("want no call to the document service, got: %v", documents.leftDocumentIds)
+	}
+}
+
+func TestListPermissions_ExcludeSelf_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	userId := uuid.New()
+	path := "/document/" + uuid.NewString() + "/permission"
+	for _, query := range []string{ "", "?excludeSelf=false", "?excludeSelf=true" } {
+		w := serveVersionedRequest(
+			t, service, http.MethodGet, path+query, "", signVersionedTestToken(t, userId, 0),
+		)
+		if w.Code != http.StatusOK {
+			t.Fatalf("want status: %d for query: %q, got: %d with body: %s", http.StatusOK, query, w.Code, w.Body.String())
+		}
+	}
+	documents.mu.Lock()
+	defer documents.mu.Unlock()
+	want := []string{ "", "", userId.String() }
+	if !slices.Equal(documents.excludedPrincipalIds, want) {
+		t.Errorf("want excluded principals: %v, got: %v", want, documents.excludedPrincipalIds)
+	}
+}
+
+// sign a guest type token for the subject, guest tokens skip the token version check so a
+// malformed subject reaches the handler
+func signGuestTestToken(t *testing.T, subject string) string {
+	signed, err := signToken(CustomClaims{
+		PrincipalType: PrincipalTypeGuest,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: "reed",
+			Subject: subject,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign token with error: %v", err)
+	}
+	return signed
+}
+
+func TestGetPermissionOfPrincipal_UnauthenticatedVsForbidden_Unit(t *testing.T) {
+	guestId := uuid.New()
+	path := "/document/" + uuid.NewString() + "/permission/principal/" + uuid.NewString()
+	tests := []struct{
+		name string
+		token string
+		want int
+	}{
+		// the token cannot be verified, the client should log in again
+		{ "bad signature", signGuestTestToken(t, guestId.String()) + "x", http.StatusUnauthorized },
+		// the token is signed but does not identify a principal
+		{ "malformed principal id", signGuestTestToken(t, "not-a-uuid"), http.StatusUnauthorized },
+		// the token is valid but guests cannot read the permissions of other principals
+		{ "guest reading another principal", signGuestTestToken(t, guestId.String()), http.StatusForbidden },
+	}
+	for _, test := range tests {
+		// the service clients are nil, every case is rejected before the document service
+		w := serveTestRequest(t, http.MethodGet, path, "", test.token)
+		if w.Code != test.want {
+			t.Errorf("want status: %d for %s, got: %d with body: %s", test.want, test.name, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// records whether the response has been started, once the status line has been sent a panic
+// can no longer be turned into a 500
+type responseStartedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *responseStartedWriter) WriteHeader(code int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseStartedWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+// lets http.ResponseController reach the flusher and deadlines of the wrapped writer
+func (w *responseStartedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recover from a panic in any later middleware or handler, log it with its stack and respond
+// with a 500 instead of dropping the connection. This runs before the request id middleware so
+// that a panic there is also recovered, the request id is read back from the response header
+// that the request id middleware sets
+func RecoveryMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseStartedWriter{ ResponseWriter: w }
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// net/http uses this panic to abort a response on purpose, it is not a bug
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				requestId := w.Header().Get(RequestIdHeader)
+				slog.ErrorContext(
+					r.Context(), "recovered from a panic while handling a request",
+					"requestId", requestId, "method", r.Method, "path", r.URL.Path,
+					"panic", fmt.Sprint(recovered), "stack", string(debug.Stack()),
+				)
+				if rw.started {
+					// part of the response has been sent, abort it so that the client does not
+					// mistake it for a complete response
+					panic(http.ErrAbortHandler)
+				}
+				SendError(w, http.StatusInternalServerError, fmt.Sprintf(
+					"%s, request id: %s", http.StatusText(http.StatusInternalServerError), requestId,
+				))
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// wrap the handler with the middlewares the same way the generated handler does
+func wrapWithMiddlewares(handler http.Handler, middlewares []MiddlewareFunc) http.Handler {
+	for _, middleware := range middlewares {
+		handler = middleware(handler)
+	}
+	return handler
+}
+
+func TestRecoveryMiddleware_HandlerPanics_Unit(t *testing.T) {
+	logs := captureLogs(t)
+	handler := wrapWithMiddlewares(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var documentIds map[string]string
+			documentIds["first"] = "panics"
+		}),
+		Chain(RecoveryMiddleware(), RequestIdMiddleware()),
+	)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/document", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+	requestId := w.Header().Get(RequestIdHeader)
+	if requestId == "" || !strings.Contains(w.Body.String(), requestId) {
+		t.Errorf("want the request id: %q in the response body, got: %s", requestId, w.Body.String())
+	}
+	logged := logs.String()
+	for _, want := range []string{ requestId, "assignment to entry in nil map", "runtime/debug.Stack" } {
+		if !strings.Contains(logged, want) {
+			t.Errorf("want the log to contain: %q, got: %s", want, logged)
+		}
+	}
+}
+
+func TestRecoveryMiddleware_DefaultChain_Unit(t *testing.T) {
+	captureLogs(t)
+	// the service has no document service client, so listing documents panics in the handler
+	service := NewService(nil, nil, testJWTKeys, nil)
+	r := httptest.NewRequest(http.MethodGet, "/document", nil)
+	r.Header.Set("Authentication", "Bearer "+signTestToken(t))
+	w := httptest.NewRecorder()
+	NewHandler(&service).ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+}
+
+func TestRecoveryMiddleware_ResponseStarted_Unit(t *testing.T) {
+	captureLogs(t)
+	handler := wrapWithMiddlewares(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"documents": [`))
+			panic("failed halfway through the response")
+		}),
+		Chain(RecoveryMiddleware()),
+	)
+	w := httptest.NewRecorder()
+	defer func() {
+		// the partial response is aborted instead of being followed by a 500
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("want the response to be aborted, got panic: %v", recovered)
+		}
+		if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Internal Server Error") {
+			t.Errorf("want the partial response to be left alone, got: %d with body: %s", w.Code, w.Body.String())
+		}
+	}()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/document", nil))
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// the response header that carries the id of the request, clients can quote it when they
+// report an error so that the error can be found in the gateway logs
+const RequestIdHeader = "X-Request-Id"
+
+type requestIdContextKey struct{}
+
+// give every request a fresh id. The id is not read from the request because any client can
+// set the header, a reused id would make the logs of two requests look like one
+func RequestIdMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestId := uuid.NewString()
+			w.Header().Set(RequestIdHeader, requestId)
+			ctx := context.WithValue(r.Context(), requestIdContextKey{}, requestId)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// get the id of the request, this is empty when the request id middleware has not run
+func GetRequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdContextKey{}).(string)
+	return requestId
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// overrides the handlers of the routes under test to record that they were reached instead of
+// calling the backend services, every other handler is promoted from the embedded service
+type recordingServer struct {
+	*Service
+	reached bool
+}
+
+func (s *recordingServer) record(w http.ResponseWriter) {
+	s.reached = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *recordingServer) PostDocumentDocumentIdPermission(
+	w http.ResponseWriter, r *http.Request, documentId DocumentId,
+) {
+	s.record(w)
+}
+
+func (s *recordingServer) PutDocumentDocumentIdPermissionPrincipalPrincipalId(
+	w http.ResponseWriter, r *http.Request, documentId DocumentId, principalId PrincipalId,
+) {
+	s.record(w)
+}
+
+func (s *recordingServer) PutDocumentDocumentIdPublicAccess(
+	w http.ResponseWriter, r *http.Request, documentId DocumentId,
+) {
+	s.record(w)
+}
+
+func (s *recordingServer) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	s.record(w)
+}
+
+// serve the request through the default middleware chain and report whether the handler of the
+// route was reached
+func serveRecordedRequest(t *testing.T, method string, path string, body string) (*httptest.ResponseRecorder, bool) {
+	service := NewService(nil, nil, testJWTKeys, nil)
+	recorder := &recordingServer{ Service: &service }
+	handler := HandlerWithOptions(recorder, StdHTTPServerOptions{
+		Middlewares: DefaultMiddlewares(testJWTKeys, service.tokenVersions, service.guestTokenVersions),
+		ErrorHandlerFunc: ErrorHandlerFunc,
+	})
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authentication", "Bearer "+signTestToken(t))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w, recorder.reached
+}
+
+func TestRequestValidation_MalformedRequests_Unit(t *testing.T) {
+	permissionPath := "/document/" + uuid.NewString() + "/permission"
+	principalPath := permissionPath + "/principal/" + uuid.NewString()
+	publicAccessPath := "/document/" + uuid.NewString() + "/public-access"
+	userId := uuid.NewString()
+	tests := []struct {
+		name string
+		method string
+		path string
+		body string
+	}{
+		{ "share at owner level", http.MethodPost, permissionPath, `{"userIdToShare": "` + userId + `", "permissionLevel": "owner"}` },
+		{ "share at unknown level", http.MethodPost, permissionPath, `{"userIdToShare": "` + userId + `", "permissionLevel": "admin"}` },
+		{ "share with id and email", http.MethodPost, permissionPath, `{"userIdToShare": "` + userId + `", "emailToShare": "user@example.com", "permissionLevel": "viewer"}` },
+		{ "share with malformed id", http.MethodPost, permissionPath, `{"userIdToShare": "not-a-uuid", "permissionLevel": "viewer"}` },
+		{ "share with malformed json", http.MethodPost, permissionPath, `{"userIdToShare": ` },
+		{ "update to owner level", http.MethodPut, principalPath, `{"permissionLevel": "owner", "principalType": "user"}` },
+		{ "update without principal type", http.MethodPut, principalPath, `{"permissionLevel": "editor"}` },
+		{ "update with unknown principal type", http.MethodPut, principalPath, `{"permissionLevel": "editor", "principalType": "admin"}` },
+		{ "public access at owner level", http.MethodPut, publicAccessPath, `{"publicAccess": "owner"}` },
+		{ "delete malformed document ids", http.MethodDelete, "/document", `{"documentIds": ["not-a-uuid"]}` },
+		{ "delete without document ids", http.MethodDelete, "/document", `{}` },
+		{ "look up malformed email", http.MethodGet, "/user?email=not-an-email", `` },
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, reached := serveRecordedRequest(t, test.method, test.path, test.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+			}
+			if reached {
+				t.Errorf("want the request to be rejected before it reaches the handler")
+			}
+		})
+	}
+}
+
+func TestRequestValidation_ValidBodies_Unit(t *testing.T) {
+	permissionPath := "/document/" + uuid.NewString() + "/permission"
+	principalPath := permissionPath + "/principal/" + uuid.NewString()
+	tests := []struct {
+		name string
+		method string
+		path string
+		body string
+	}{
+		{ "share with a user", http.MethodPost, permissionPath, `{"userIdToShare": "` + uuid.NewString() + `", "permissionLevel": "editor"}` },
+		{ "share by email", http.MethodPost, permissionPath, `{"emailToShare": "user@example.com", "permissionLevel": "viewer"}` },
+		{ "create a guest", http.MethodPost, permissionPath, `{}` },
+		{ "update a guest", http.MethodPut, principalPath, `{"permissionLevel": "viewer", "principalType": "guest"}` },
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, reached := serveRecordedRequest(t, test.method, test.path, test.body)
+			if !reached {
+				t.Errorf("want the request to reach the handler, got status: %d with body: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequestValidation_MalformedPathIds_Unit(t *testing.T) {
+	documentId := uuid.NewString()
+	tests := []struct {
+		name string
+		method string
+		path string
+		body string
+		param string
+	}{
+		{ "malformed document id", http.MethodPost, "/document/not-a-uuid/permission", `{}`, "documentId" },
+		{
+			"malformed principal id", http.MethodPut, "/document/" + documentId + "/permission/principal/not-a-uuid",
+			`{"permissionLevel": "viewer", "principalType": "guest"}`, "principalId",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, reached := serveRecordedRequest(t, test.method, test.path, test.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+			}
+			if reached {
+				t.Errorf("want the request to be rejected before it reaches the handler")
+			}
+			var response Error
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode the error response with error: %v", err)
+			}
+			if response.Message == nil || !strings.Contains(*response.Message, test.param) {
+				t.Errorf("want the message to name the parameter: %s, got: %v", test.param, response.Message)
+			}
+			if response.Fields == nil || (*response.Fields)[test.param] == "" {
+				t.Errorf("want a field violation for the parameter: %s, got: %v", test.param, response.Fields)
+			}
+		})
+	}
+}
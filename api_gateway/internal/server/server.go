@@ -1,6 +1,9 @@
 package server
 
 import (
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/api_gateway/internal/config"
 	userService "github.com/townsag/reed/user_service/pkg/client"
 	documentService "github.com/townsag/reed/document_service/pkg/client"
 )
@@ -14,6 +17,16 @@ var _ ServerInterface = (*Service)(nil)
 type Service struct {
 	userServiceClient *userService.UserServiceClient
 	documentServiceClient *documentService.DocumentServiceClient
+	// the keys used to sign and verify jwts
+	jwtKeys *config.JWTKeys
+	// the current token versions of recently seen users, this is nil when there is no user
+	// service client to read them from
+	tokenVersions *TokenVersionCache
+	// the current token versions of recently seen guests, this is nil when there is no document
+	// service client to read them from
+	guestTokenVersions *TokenVersionCache
+	// the users that can call admin only routes
+	adminUserIds map[uuid.UUID]struct{}
 	// probably also add a client for accessing some external state like a cache or a 
 	// way to record request counts 
 }
@@ -21,12 +34,29 @@ type Service struct {
 func NewService(
 	usClient *userService.UserServiceClient,
 	dsClient *documentService.DocumentServiceClient,
+	jwtKeys *config.JWTKeys,
+	adminUserIds []uuid.UUID,
 ) Service {
-	return Service{
+	service := Service{
 		userServiceClient: usClient,
 		documentServiceClient: dsClient,
+		jwtKeys: jwtKeys,
+		adminUserIds: make(map[uuid.UUID]struct{}, len(adminUserIds)),
+	}
+	for _, adminUserId := range adminUserIds {
+		service.adminUserIds[adminUserId] = struct{}{}
+	}
+	if usClient != nil {
+		service.tokenVersions = NewTokenVersionCache(usClient, TokenVersionTTL)
+	}
+	if dsClient != nil {
+		service.guestTokenVersions = NewGuestTokenVersionCache(dsClient, TokenVersionTTL)
 	}
+	return service
 }
 
-
-
+// whether the user is allowed to call admin only routes
+func (s *Service) isAdmin(userId uuid.UUID) bool {
+	_, ok := s.adminUserIds[userId]
+	return ok
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/api_gateway/internal/config"
+	documentService "github.com/townsag/reed/document_service/pkg/client"
+	userService "github.com/townsag/reed/user_service/pkg/client"
+)
+
+// how long the token version of a user is cached for, a token that was revoked on another
+// gateway instance keeps working for at most this long
+const TokenVersionTTL = 30 * time.Second
+
+// once the cache holds this many users the expired entries are swept out before another one
+// is added, this keeps the cache from growing with every user that has ever made a request
+const maxTokenVersionEntries = 10000
+
+type tokenVersionEntry struct {
+	version int32
+	isActive bool
+	fetchedAt time.Time
+}
+
+// TokenVersionCache holds the current token version of recently seen principals so that the
+// auth middleware does not have to call a backend service on every request. The version of a
+// user is bumped by the user service when the user is deactivated or changes their password,
+// the version of a guest is bumped by the document service when the link of the guest is
+// rotated
+type TokenVersionCache struct {
+	// reads the current token version of the principal and whether the principal is active
+	fetch func(ctx context.Context, principalId uuid.UUID) (int32, bool, error)
+	ttl time.Duration
+	mu sync.Mutex
+	entries map[uuid.UUID]tokenVersionEntry
+}
+
+func NewTokenVersionCache(client *userService.UserServiceClient, ttl time.Duration) *TokenVersionCache {
+	return &TokenVersionCache{
+		fetch: func(ctx context.Context, userId uuid.UUID) (int32, bool, error) {
+			serviceReply, err := client.GetUser(ctx, userId)
+			if err != nil {
+				return 0, false, err
+			}
+			return serviceReply.User.TokenVersion, serviceReply.User.IsActive, nil
+		},
+		ttl: ttl,
+		entries: make(map[uuid.UUID]tokenVersionEntry),
+	}
+}
+
+// guests are always active, a guest that was deleted is not found
+func NewGuestTokenVersionCache(client *documentService.DocumentServiceClient, ttl time.Duration) *TokenVersionCache {
+	return &TokenVersionCache{
+		fetch: func(ctx context.Context, guestId uuid.UUID) (int32, bool, error) {
+			tokenVersion, err := client.GetGuestTokenVersion(ctx, guestId)
+			if err != nil {
+				return 0, false, err
+			}
+			return tokenVersion, true, nil
+		},
+		ttl: ttl,
+		entries: make(map[uuid.UUID]tokenVersionEntry),
+	}
+}
+
+// get the current token version of the principal and whether the principal is active, the
+// backend service is only called when the cached entry is missing or older than the ttl
+func (c *TokenVersionCache) Current(ctx context.Context, principalId uuid.UUID) (int32, bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[principalId]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < c.ttl {
+		return entry.version, entry.isActive, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.TIMEOUT_MILLISECONDS)
+	defer cancel()
+	version, isActive, err := c.fetch(ctx, principalId)
+	if err != nil {
+		return 0, false, err
+	}
+	entry = tokenVersionEntry{
+		version: version,
+		isActive: isActive,
+		fetchedAt: time.Now(),
+	}
+	c.mu.Lock()
+	if len(c.entries) >= maxTokenVersionEntries {
+		for cachedId, cached := range c.entries {
+			if time.Since(cached.fetchedAt) >= c.ttl {
+				delete(c.entries, cachedId)
+			}
+		}
+	}
+	c.entries[principalId] = entry
+	c.mu.Unlock()
+	return entry.version, entry.isActive, nil
+}
+
+// drop the cached version of the principal so that the next request reads it from the backend
+// service, this is called after this gateway changes the version of the principal
+func (c *TokenVersionCache) Invalidate(principalId uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, principalId)
+}
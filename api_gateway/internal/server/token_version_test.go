@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func signVersionedTestToken(t *testing.T, userId uuid.UUID, tokenVersion int32) string {
+	signed, err := signToken(CustomClaims{
+		UserName: "testUser",
+		PrincipalType: PrincipalTypeUser,
+		TokenVersion: tokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: "reed",
+			Subject: userId.String(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign token with error: %v", err)
+	}
+	return signed
+}
+
+func serveVersionedRequest(
+	t *testing.T, service *Service, method string, path string, body string, token string,
+) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authentication", "Bearer "+token)
+	w := httptest.NewRecorder()
+	NewHandler(service).ServeHTTP(w, r)
+	return w
+}
+
+func TestTokenVersion_ChangePasswordRevokesToken_Unit(t *testing.T) {
+	userId := uuid.New()
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	token := signVersionedTestToken(t, userId, 0)
+	w := serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d before the password change, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	w = serveVersionedRequest(
+		t, service, http.MethodPut, "/user/"+userId.String(),
+		`{"oldPassword": "asdfasdf", "newPassword": "qwerqwer"}`, token,
+	)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("want status: %d for the password change, got: %d with body: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	// the token that was issued before the password change is revoked
+	w = serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", token)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("want status: %d after the password change, got: %d with body: %s", http.StatusUnauthorized, w.Code, w.Body.String())
+	}
+	// a token issued after the password change carries the new version
+	w = serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", signVersionedTestToken(t, userId, 1))
+	if w.Code != http.StatusOK {
+		t.Errorf("want status: %d for a token with the new version, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+func TestTokenVersion_DeactivateRevokesToken_Unit(t *testing.T) {
+	userId := uuid.New()
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	token := signVersionedTestToken(t, userId, 0)
+	w := serveVersionedRequest(t, service, http.MethodDelete, "/user/"+userId.String(), "", token)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("want status: %d for the deactivation, got: %d with body: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	// neither the old token nor a token with the current version is honoured for a deactivated user
+	for _, tokenVersion := range []int32{ 0, 1 } {
+		w = serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", signVersionedTestToken(t, userId, tokenVersion))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf(
+				"want status: %d for a token with version %d, got: %d with body: %s",
+				http.StatusUnauthorized, tokenVersion, w.Code, w.Body.String(),
+			)
+		}
+	}
+}
+
+func TestTokenVersion_CachedWithinTTL_Unit(t *testing.T) {
+	userId := uuid.New()
+	users := &fakeUserServer{}
+	service := newFakeBackendService(t, users, &fakeDocumentServer{})
+	token := signVersionedTestToken(t, userId, 0)
+	w := serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	// a version bump that this gateway did not make is only seen once the cached version expires
+	users.mu.Lock()
+	users.bumpTokenVersion(userId.String())
+	users.mu.Unlock()
+	w = serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", token)
+	if w.Code != http.StatusOK {
+		t.Errorf("want status: %d while the version is cached, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	service.tokenVersions.ttl = 0
+	w = serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", token)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("want status: %d once the cached version expires, got: %d with body: %s", http.StatusUnauthorized, w.Code, w.Body.String())
+	}
+}
+
+func TestGuestTokenVersion_RotateRevokesToken_Unit(t *testing.T) {
+	guestId := uuid.New()
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	token, err := signGuestToken(guestId, 0, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign guest token with error: %v", err)
+	}
+	w := serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d before the rotation, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	w = serveVersionedRequest(
+		t, service, http.MethodPost, "/guest/"+guestId.String()+"/rotate", "", signVersionedTestToken(t, uuid.New(), 0),
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d for the rotation, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var rotated RotateGuestLinkResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &rotated); err != nil {
+		t.Fatalf("failed to unmarshal response with error: %v", err)
+	}
+	// the token that was issued before the rotation is revoked
+	w = serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", token)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("want status: %d after the rotation, got: %d with body: %s", http.StatusUnauthorized, w.Code, w.Body.String())
+	}
+	// the token issued by the rotation carries the new version
+	w = serveVersionedRequest(t, service, http.MethodGet, "/auth/me", "", rotated.GuestToken)
+	if w.Code != http.StatusOK {
+		t.Errorf("want status: %d for the rotated token, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+func TestGuestTokenVersion_GuestCannotRotate_Unit(t *testing.T) {
+	guestId := uuid.New()
+	service := newFakeBackendService(t, &fakeUserServer{}, &fakeDocumentServer{})
+	token, err := signGuestToken(guestId, 0, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign guest token with error: %v", err)
+	}
+	w := serveVersionedRequest(t, service, http.MethodPost, "/guest/"+guestId.String()+"/rotate", "", token)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/google/uuid"
+
 	"github.com/townsag/reed/api_gateway/internal/config"
+	userPb "github.com/townsag/reed/user_service/api"
 )
 
 // Create a User
@@ -36,15 +39,22 @@ func (s *Service) PostUser(w http.ResponseWriter, r *http.Request) {
 		string(reqBody.UserEmail),
 		reqBody.Password,
 		reqBody.MaxDocuments,
+		reqBody.Plan,
 	)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
+		return
+	}
+	// return the created user that is returned by the gRPC client
+	userId, err := uuid.Parse(serviceReply.UserId)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to parse user id returned from backend service")
 		return
 	}
-	// return the userId that is returned by the gRPC client
-	// only the UserId field of the create user reply struct is exported so we 
-	// can directly encode the service reply
-	SendJsonResponse(w, http.StatusCreated, serviceReply)
+	SendJsonResponse(w, http.StatusCreated, &PostUserResponse{
+		UserId: userId,
+		User: *protoToNetUser(userId, serviceReply.User),
+	})
 }
 
 // deactivate a user
@@ -58,9 +68,11 @@ func (s *Service) DeleteUserUserId(w http.ResponseWriter, r *http.Request, userI
 	defer cancel()
 	err := s.userServiceClient.DeactivateUser(ctx, userId)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
+	// deactivating the user revokes their tokens, stop honouring them on this gateway right away
+	s.tokenVersions.Invalidate(userId)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -73,22 +85,135 @@ func (s *Service) GetUserUserId(w http.ResponseWriter, r *http.Request, userId U
 	defer cancel()
 	serviceReply, err := s.userServiceClient.GetUser(ctx, userId)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
 	// ignore the returned user id, we don't have to parse it because it 
 	// will be the same as the calling user id 
 	// format the response into a user struct
-	response := &User{
-		Email: serviceReply.User.Email,
-		MaxDocuments: serviceReply.User.MaxDocuments,
-		UserId: userId,
-		UserName: serviceReply.User.UserName,
-	}
+	response := protoToNetUser(userId, serviceReply.User)
 	// return the User object to the client
 	SendJsonResponse(w, http.StatusOK, response)
 }
 
+// look up a user by email, this is an admin only route. Letting any user look up emails would
+// let them enumerate the registered users and read their profiles, users that only know
+// someone by email share with them through emailToShare instead
+// (GET /user)
+func (s *Service) GetUser(w http.ResponseWriter, r *http.Request, params GetUserParams) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// guests are scoped to a single document, they should not be able to look up users either
+	if claims.GetTokenType() != PrincipalTypeUser || !s.isAdmin(principalId) {
+		SendError(w, http.StatusForbidden, "must be an admin to look up users")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.TIMEOUT_MILLISECONDS)
+	defer cancel()
+	serviceReply, err := s.userServiceClient.GetUserByEmail(ctx, string(params.Email))
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	userId, err := uuid.Parse(serviceReply.User.UserId)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to parse user id returned from backend service")
+		return
+	}
+	SendJsonResponse(w, http.StatusOK, protoToNetUser(userId, serviceReply.User))
+}
+
+// check whether a user could be created with the user name and the email, this lets a signup
+// form validate the fields before it is submitted
+// (GET /user/availability)
+func (s *Service) GetUserAvailability(w http.ResponseWriter, r *http.Request, params GetUserAvailabilityParams) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	// availability reveals whether an email is registered, so guests cannot check it like they
+	// cannot look up users
+	if claims.GetTokenType() != PrincipalTypeUser {
+		SendError(w, http.StatusForbidden, "must have a user type token to check availability")
+		return
+	}
+	if params.UserName == nil && params.Email == nil {
+		SendError(w, http.StatusBadRequest, "at least one of userName or email is required")
+		return
+	}
+	var email *string
+	if params.Email != nil {
+		email = (*string)(params.Email)
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.TIMEOUT_MILLISECONDS)
+	defer cancel()
+	serviceReply, err := s.userServiceClient.CheckAvailability(ctx, params.UserName, email)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	SendJsonResponse(w, http.StatusOK, &AvailabilityResponse{
+		UserNameAvailable: serviceReply.UserNameAvailable,
+		EmailAvailable: serviceReply.EmailAvailable,
+	})
+}
+
+// make the successor the owner of every document owned by the user, this is an admin only
+// route that is meant for when a user leaves
+// (POST /user/{userId}/reassign-documents)
+func (s *Service) PostUserUserIdReassignDocuments(w http.ResponseWriter, r *http.Request, userId UserId) {
+	claims, err := GetClaims(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	principalId, err := claims.ParsePrincipalId()
+	if err != nil {
+		SendError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if claims.GetTokenType() != PrincipalTypeUser || !s.isAdmin(principalId) {
+		SendError(w, http.StatusForbidden, "must be an admin to reassign the documents of a user")
+		return
+	}
+	var reqBody PostUserUserIdReassignDocumentsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		SendError(w, http.StatusBadRequest, fmt.Sprintf("error decoding request body: %s", err.Error()))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), config.REASSIGN_DOCUMENTS_TIMEOUT)
+	defer cancel()
+	movedCount, err := s.documentServiceClient.ReassignOwnedDocuments(ctx, userId, reqBody.SuccessorId, principalId)
+	if err != nil {
+		SendGrpcError(w, r, err)
+		return
+	}
+	SendJsonResponse(w, http.StatusOK, &ReassignDocumentsResponse{
+		MovedCount: movedCount,
+	})
+}
+
+func protoToNetUser(userId UserId, user *userPb.User) *User {
+	return &User{
+		Email: user.Email,
+		MaxDocuments: user.MaxDocuments,
+		UserId: userId,
+		UserName: user.UserName,
+		IsActive: user.IsActive,
+		CreatedAt: user.CreatedAt.AsTime(),
+		LastModifiedAt: user.LastModifiedAt.AsTime(),
+	}
+}
+
 // update a user including the users password
 func (s *Service) PutUserUserId(w http.ResponseWriter, r *http.Request, userId UserId) {
 	var reqBody PutUserUserIdJSONRequestBody
@@ -102,8 +227,10 @@ func (s *Service) PutUserUserId(w http.ResponseWriter, r *http.Request, userId U
 	defer cancel()
 	err = s.userServiceClient.ChangeUserPassword(ctx, userId, reqBody.OldPassword, reqBody.NewPassword)
 	if err != nil {
-		SendError(w, GrpcToHttpStatus(err), err.Error())
+		SendGrpcError(w, r, err)
 		return
 	}
+	// changing the password revokes the tokens of the user, including the one that made this request
+	s.tokenVersions.Invalidate(userId)
 	w.WriteHeader(http.StatusNoContent)
 }
\ No newline at end of file
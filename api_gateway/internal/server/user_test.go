@@ -0,0 +1,206 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/api_gateway/internal/config"
+	userPb "github.com/townsag/reed/user_service/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestProtoToNetUser_Fields_Unit(t *testing.T) {
+	createdAt := time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC)
+	lastModifiedAt := createdAt.Add(time.Hour)
+	for _, isActive := range []bool{true, false} {
+		userId := uuid.New()
+		user := protoToNetUser(userId, &userPb.User{
+			UserId: userId.String(),
+			UserName: "testUser",
+			Email: "test@example.com",
+			MaxDocuments: 10,
+			IsActive: isActive,
+			CreatedAt: timestamppb.New(createdAt),
+			LastModifiedAt: timestamppb.New(lastModifiedAt),
+		})
+		// serialize the user the same way it is sent to the client
+		body, err := json.Marshal(user)
+		if err != nil {
+			t.Fatalf("failed to marshal user with error: %v", err)
+		}
+		var decoded map[string]any
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			t.Fatalf("failed to unmarshal user with error: %v", err)
+		}
+		if decoded["isActive"] != isActive {
+			t.Errorf("want isActive: %v, got: %v", isActive, decoded["isActive"])
+		}
+		if decoded["createdAt"] != createdAt.Format(time.RFC3339Nano) {
+			t.Errorf("want createdAt: %v, got: %v", createdAt.Format(time.RFC3339Nano), decoded["createdAt"])
+		}
+		if decoded["lastModifiedAt"] != lastModifiedAt.Format(time.RFC3339Nano) {
+			t.Errorf("want lastModifiedAt: %v, got: %v", lastModifiedAt.Format(time.RFC3339Nano), decoded["lastModifiedAt"])
+		}
+	}
+}
+
+func TestGetUserByEmail_GuestToken_Unit(t *testing.T) {
+	token, err := signPublicLinkToken(uuid.New(), 0, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign public link token with error: %v", err)
+	}
+	// guests are rejected before the user service is called
+	w := serveTestRequest(t, http.MethodGet, "/user?email=test@example.com", "", token)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+}
+
+func TestGetUserByEmail_NotAdmin_Unit(t *testing.T) {
+	users := &fakeUserServer{
+		users: map[string]uuid.UUID{ "test@example.com": uuid.New() },
+	}
+	service := newFakeBackendService(t, users, &fakeDocumentServer{})
+	// users that are not admins cannot enumerate other users by email
+	w := serveVersionedRequest(
+		t, service, http.MethodGet, "/user?email=test@example.com", "", signVersionedTestToken(t, uuid.New(), 0),
+	)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+}
+
+func TestGetUserByEmail_Admin_Unit(t *testing.T) {
+	adminId := uuid.New()
+	userId := uuid.New()
+	users := &fakeUserServer{
+		users: map[string]uuid.UUID{ "test@example.com": userId },
+	}
+	service := newFakeBackendService(t, users, &fakeDocumentServer{})
+	service.adminUserIds = map[uuid.UUID]struct{}{ adminId: {} }
+	w := serveVersionedRequest(
+		t, service, http.MethodGet, "/user?email=test@example.com", "", signVersionedTestToken(t, adminId, 0),
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal user with error: %v", err)
+	}
+	if decoded["userId"] != userId.String() {
+		t.Errorf("want userId: %v, got: %v", userId, decoded["userId"])
+	}
+}
+
+func TestGetUserByEmail_MissingEmail_Unit(t *testing.T) {
+	w := serveTestRequest(t, http.MethodGet, "/user", "", signTestToken(t))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestReassignDocuments_NotAdmin_Unit(t *testing.T) {
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	body := `{"successorId": "` + uuid.NewString() + `"}`
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/user/"+uuid.NewString()+"/reassign-documents", body,
+		signVersionedTestToken(t, uuid.New(), 0),
+	)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	if len(documents.reassigned()) != 0 {
+		t.Errorf("want no documents to be reassigned, got: %v", documents.reassigned())
+	}
+}
+
+func TestReassignDocuments_Admin_Unit(t *testing.T) {
+	adminId := uuid.New()
+	departingId := uuid.New()
+	documents := &fakeDocumentServer{}
+	service := newFakeBackendService(t, &fakeUserServer{}, documents)
+	service.adminUserIds = map[uuid.UUID]struct{}{ adminId: {} }
+	body := `{"successorId": "` + uuid.NewString() + `"}`
+	w := serveVersionedRequest(
+		t, service, http.MethodPost, "/user/"+departingId.String()+"/reassign-documents", body,
+		signVersionedTestToken(t, adminId, 0),
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(documents.reassigned()) != 1 || documents.reassigned()[0] != departingId.String() {
+		t.Errorf("want the documents of user: %s to be reassigned, got: %v", departingId, documents.reassigned())
+	}
+	var response ReassignDocumentsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response with error: %v", err)
+	}
+	if response.MovedCount != 3 {
+		t.Errorf("want movedCount: 3, got: %d", response.MovedCount)
+	}
+	// reassigning runs in batches, it gets longer than the timeout of a single request
+	documents.mu.Lock()
+	remaining := time.Until(documents.reassignDeadline)
+	documents.mu.Unlock()
+	if remaining <= config.TIMEOUT_MILLISECONDS {
+		t.Errorf("want a deadline further out than: %v, got: %v", config.TIMEOUT_MILLISECONDS, remaining)
+	}
+}
+
+func TestGetUserAvailability_Unit(t *testing.T) {
+	users := &fakeUserServer{
+		users: map[string]uuid.UUID{ "takenUser": uuid.New(), "taken@example.com": uuid.New() },
+	}
+	service := newFakeBackendService(t, users, &fakeDocumentServer{})
+	token := signVersionedTestToken(t, uuid.New(), 0)
+	w := serveVersionedRequest(
+		t, service, http.MethodGet, "/user/availability?userName=takenUser&email=free@example.com", "", token,
+	)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response AvailabilityResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode the response with error: %v", err)
+	}
+	if response.UserNameAvailable == nil || *response.UserNameAvailable {
+		t.Errorf("want the user name to be taken, got: %v", response.UserNameAvailable)
+	}
+	if response.EmailAvailable == nil || !*response.EmailAvailable {
+		t.Errorf("want the email to be available, got: %v", response.EmailAvailable)
+	}
+	// a field that was not checked is left out of the response
+	w = serveVersionedRequest(t, service, http.MethodGet, "/user/availability?email=taken@example.com", "", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d with body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	response = AvailabilityResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode the response with error: %v", err)
+	}
+	if response.UserNameAvailable != nil || response.EmailAvailable == nil || *response.EmailAvailable {
+		t.Errorf("want only a taken email in the response, got: %+v", response)
+	}
+}
+
+func TestGetUserAvailability_InvalidRequests_Unit(t *testing.T) {
+	// neither field is set
+	w := serveTestRequest(t, http.MethodGet, "/user/availability", "", signTestToken(t))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	// guests are rejected before the user service is called
+	token, err := signPublicLinkToken(uuid.New(), 0, testJWTKeys)
+	if err != nil {
+		t.Fatalf("failed to sign public link token with error: %v", err)
+	}
+	w = serveTestRequest(t, http.MethodGet, "/user/availability?userName=someone", "", token)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status: %d, got: %d with body: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+}
@@ -23,13 +23,13 @@ func main() {
 	documentName := "test document"
 	documentDescription := "a document for testing tracing"
 	documentId, err := client.CreateDocument(
-		ctx, ownerId, &documentName, &documentDescription,
+		ctx, ownerId, &documentName, &documentDescription, nil,
 	)
 	if err != nil {
 		log.Fatalf("failed to create document: %v", err)
 	}
 	// create some permissions on that document
-	err = client.UpsertPermissionUser(
+	_, err = client.UpsertPermissionUser(
 		ctx, sharedId, ownerId, documentId, api.PermissionLevel_PERMISSION_VIEWER,
 	)
 	if err != nil {
@@ -37,7 +37,7 @@ func main() {
 	}
 	// get the document
 	document, err := client.GetDocument(
-		ctx, documentId, ownerId,
+		ctx, documentId, ownerId, nil, false, false,
 	)
 	if err != nil {
 		log.Fatalf("failed to get the document: %v", err)
@@ -45,7 +45,7 @@ func main() {
 	fmt.Printf("created document: %+v\n", document)
 	// get the permissions on the document
 	permission, err := client.GetPermissionsOfPrincipalOnDocument(
-		ctx, documentId, sharedId,ownerId,
+		ctx, documentId, sharedId, ownerId, nil,
 	)
 	if err != nil {
 		log.Fatalf("failed to get the permission of the principal: %v", err)
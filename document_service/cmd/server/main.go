@@ -5,23 +5,34 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"net"
+	"syscall"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	pb "github.com/townsag/reed/document_service/api/v1"
 	"github.com/townsag/reed/document_service/internal/config"
 	"github.com/townsag/reed/document_service/internal/repository"
 	"github.com/townsag/reed/document_service/internal/server"
 	"github.com/townsag/reed/document_service/internal/service"
+	"github.com/townsag/reed/document_service/internal/worker"
 
 	"github.com/townsag/reed/user_service/pkg/middleware"
 )
 
 func main() {
 	// initialize the otel sdk
-	otelShutdown, err := config.SetupOTelSDK(context.Background())
+	telemetryCfg, err := middleware.GetTelemetryConfig("document-service")
+	if err != nil {
+		slog.Error("failed to get the telemetry configuration", "error", err)
+		os.Exit(1)
+	}
+	otelShutdown, err := middleware.SetupOTelSDK(context.Background(), telemetryCfg)
 	if err != nil {
 		slog.Error("failed to bootstrap the otel sdk: ", "error", err)
 		os.Exit(1)
@@ -40,10 +51,70 @@ func main() {
 		os.Exit(1)
 	}
 	defer pool.Close()
+	// the list methods read from a replica when one is configured
+	replicaCfg, err := config.GetReadReplicaConfiguration()
+	if err != nil {
+		slog.Error("failed to get read replica connection configuration", "error", err)
+		os.Exit(1)
+	}
+	var readPool *pgxpool.Pool
+	if replicaCfg != nil {
+		replicaCfg.AfterConnect = config.RegisterTypes
+		readPool, err = config.CreateDBConnectionPool(context.Background(), replicaCfg)
+		if err != nil {
+			slog.Error("failed to create read replica connection pool", "error", err)
+			os.Exit(1)
+		}
+		defer readPool.Close()
+	}
+	acquireTimeout, queryTimeout, err := config.GetRepositoryTimeouts()
+	if err != nil {
+		slog.Error("failed to get database timeout configuration", "error", err)
+		os.Exit(1)
+	}
 	// create a document repo object
-	documentRepo := repository.NewDocumentRepository(pool)
+	documentRepo := repository.NewDocumentRepositoryWithReadPool(pool, readPool, acquireTimeout, queryTimeout)
+	maxPermissionWatchers, err := config.GetMaxPermissionWatchers(repository.DefaultMaxPermissionWatchers)
+	if err != nil {
+		slog.Error("failed to get the permission watchers configuration", "error", err)
+		os.Exit(1)
+	}
+	documentRepo.SetMaxPermissionWatchers(maxPermissionWatchers)
+	// record the latency of each repository method
+	instrumentedRepo, err := repository.NewInstrumentedDocumentRepository(documentRepo, otel.GetMeterProvider())
+	if err != nil {
+		slog.Error("failed to instrument the document repository", "error", err)
+		os.Exit(1)
+	}
+	maxGuestBatchSize, err := config.GetMaxGuestBatchSize(service.DefaultMaxGuestBatchSize)
+	if err != nil {
+		slog.Error("failed to get the guest batch size configuration", "error", err)
+		os.Exit(1)
+	}
+	maxGuestsPerDocument, err := config.GetMaxGuestsPerDocument(service.DefaultMaxGuestsPerDocument)
+	if err != nil {
+		slog.Error("failed to get the guests per document configuration", "error", err)
+		os.Exit(1)
+	}
+	defaultGuestPermissions := service.DefaultGuestPermissions()
+	defaultGuestPermissionNames := make([]string, 0, len(defaultGuestPermissions))
+	for _, level := range defaultGuestPermissions {
+		defaultGuestPermissionNames = append(defaultGuestPermissionNames, level.String())
+	}
+	guestPermissionNames, err := config.GetGuestPermissionNames(defaultGuestPermissionNames)
+	if err != nil {
+		slog.Error("failed to get the guest permissions configuration", "error", err)
+		os.Exit(1)
+	}
+	guestPermissions, err := service.ParseGuestPermissions(guestPermissionNames)
+	if err != nil {
+		slog.Error("failed to parse the guest permissions configuration", "error", err)
+		os.Exit(1)
+	}
 	// create a document service object
-	documentService := service.NewDocumentService(documentRepo)
+	documentService := service.NewDocumentServiceWithGuestPolicy(
+		instrumentedRepo, maxGuestBatchSize, maxGuestsPerDocument, guestPermissions,
+	)
 	// create a document server object
 	documentServer := server.NewDocumentServiceImpl(documentService)
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", 50051))
@@ -51,14 +122,89 @@ func main() {
 		slog.Error("failed to listen", "error", err)
 		os.Exit(1)
 	}
-	s := grpc.NewServer(
-		grpc.UnaryInterceptor(middleware.LoggingInterceptor()),
+	rateLimiter, err := config.GetRateLimiter()
+	if err != nil {
+		slog.Error("failed to get the rate limit configuration", "error", err)
+		os.Exit(1)
+	}
+	// recover from panics in the handlers and in the interceptors after this one
+	recoveryInterceptor, err := middleware.RecoveryInterceptor(otel.GetMeterProvider())
+	if err != nil {
+		slog.Error("failed to create the recovery interceptor", "error", err)
+		os.Exit(1)
+	}
+	recoveryStreamInterceptor, err := middleware.RecoveryStreamInterceptor(otel.GetMeterProvider())
+	if err != nil {
+		slog.Error("failed to create the recovery stream interceptor", "error", err)
+		os.Exit(1)
+	}
+	maxHandlerTimeout, err := config.GetMaxHandlerTimeout()
+	if err != nil {
+		slog.Error("failed to get the max handler timeout", "error", err)
+		os.Exit(1)
+	}
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor,
+			grpc.UnaryServerInterceptor(middleware.PrincipalIdInterceptor()),
+			grpc.UnaryServerInterceptor(rateLimiter.Interceptor()),
+			grpc.UnaryServerInterceptor(middleware.LoggingInterceptor()),
+			// innermost so that the logging interceptor sees the DeadlineExceeded of a handler that timed out
+			grpc.UnaryServerInterceptor(middleware.TimeoutInterceptor(maxHandlerTimeout)),
+		),
+		// the streams are long lived watches, so they are not bounded by the handler timeout
+		grpc.ChainStreamInterceptor(
+			recoveryStreamInterceptor,
+			middleware.PrincipalIdStreamInterceptor(),
+			rateLimiter.StreamInterceptor(),
+			middleware.LoggingStreamInterceptor(),
+		),
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
+	}
+	// serve over tls when a certificate is configured, plaintext otherwise
+	tlsConfig, err := config.GetServerTLSConfig()
+	if err != nil {
+		slog.Error("failed to get the tls configuration", "error", err)
+		os.Exit(1)
+	}
+	if tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s := grpc.NewServer(serverOptions...)
 	pb.RegisterDocumentServiceServer(s, documentServer)
+	// background workers are registered with the manager before it is started, they are
+	// cancelled when the server shuts down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	workerManager := worker.NewManager(worker.DefaultShutdownTimeout)
+	downgradeInterval, downgradeBatchSize, err := config.GetDowngradeJobConfig(
+		worker.DefaultDowngradeInterval, service.DefaultDowngradeBatchSize,
+	)
+	if err != nil {
+		slog.Error("failed to get the downgrade job configuration", "error", err)
+		os.Exit(1)
+	}
+	workerManager.Register(worker.NewDowngradeWorker(documentService, downgradeInterval, downgradeBatchSize))
+	tombstonePruneInterval, err := config.GetTombstonePruneInterval(worker.DefaultTombstonePruneInterval)
+	if err != nil {
+		slog.Error("failed to get the tombstone prune interval", "error", err)
+		os.Exit(1)
+	}
+	workerManager.Register(worker.NewTombstoneWorker(documentService, tombstonePruneInterval))
+	workerManager.Start(ctx)
+	go func() {
+		<-ctx.Done()
+		slog.Info("shutting down the server")
+		documentServer.StopWatches()
+		s.GracefulStop()
+	}()
 	slog.Info(fmt.Sprintf("server listening at %v", lis.Addr()))
 	if err := s.Serve(lis); err != nil {
 		slog.Error("failed to serve", "error", err)
 		os.Exit(1)
 	}
+	if err := workerManager.Shutdown(); err != nil {
+		slog.Error("failed to stop the background workers", "error", err)
+	}
+	documentService.StopAccessWrites()
 }
\ No newline at end of file
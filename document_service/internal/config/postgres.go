@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"context"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -43,6 +44,123 @@ func GetConfiguration() (*pgxpool.Config, error) {
 	return cfg, nil	
 }
 
+// read the connection string of an optional read replica from POSTGRES_REPLICA_DSN, returns a
+// nil config when no replica is configured so that the caller falls back to the primary
+func GetReadReplicaConfiguration() (*pgxpool.Config, error) {
+	dsn := GetEnvWithDefault("POSTGRES_REPLICA_DSN", "")
+	if dsn == "" {
+		return nil, nil
+	}
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse POSTGRES_REPLICA_DSN: %w", err)
+	}
+	cfg.ConnConfig.Tracer = otelpgx.NewTracer()
+	return cfg, nil
+}
+
+// read the amount of time to wait for a connection from the pool and the amount of time that
+// the queries of one repository method can take, values use the time.ParseDuration format
+func GetRepositoryTimeouts() (acquireTimeout time.Duration, queryTimeout time.Duration, err error) {
+	acquireTimeout, err = time.ParseDuration(GetEnvWithDefault("POOL_ACQUIRE_TIMEOUT", "5s"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse POOL_ACQUIRE_TIMEOUT: %w", err)
+	}
+	queryTimeout, err = time.ParseDuration(GetEnvWithDefault("QUERY_TIMEOUT", "10s"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse QUERY_TIMEOUT: %w", err)
+	}
+	return acquireTimeout, queryTimeout, nil
+}
+
+// read the most guests that can be created in one call to CreateGuests from
+// MAX_GUEST_BATCH_SIZE
+func GetMaxGuestBatchSize(defaultValue int32) (int32, error) {
+	value, err := strconv.ParseInt(GetEnvWithDefault("MAX_GUEST_BATCH_SIZE", strconv.Itoa(int(defaultValue))), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse MAX_GUEST_BATCH_SIZE: %w", err)
+	}
+	if value < 1 {
+		return 0, fmt.Errorf("MAX_GUEST_BATCH_SIZE must be at least 1, got: %d", value)
+	}
+	return int32(value), nil
+}
+
+// read the most guests that a document can have at once from MAX_GUESTS_PER_DOCUMENT
+func GetMaxGuestsPerDocument(defaultValue int32) (int32, error) {
+	value, err := strconv.ParseInt(GetEnvWithDefault("MAX_GUESTS_PER_DOCUMENT", strconv.Itoa(int(defaultValue))), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse MAX_GUESTS_PER_DOCUMENT: %w", err)
+	}
+	if value < 1 {
+		return 0, fmt.Errorf("MAX_GUESTS_PER_DOCUMENT must be at least 1, got: %d", value)
+	}
+	return int32(value), nil
+}
+
+// read the most permission watchers that can be open at once from MAX_PERMISSION_WATCHERS
+func GetMaxPermissionWatchers(defaultValue int) (int, error) {
+	value, err := strconv.Atoi(GetEnvWithDefault("MAX_PERMISSION_WATCHERS", strconv.Itoa(defaultValue)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse MAX_PERMISSION_WATCHERS: %w", err)
+	}
+	if value < 1 {
+		return 0, fmt.Errorf("MAX_PERMISSION_WATCHERS must be at least 1, got: %d", value)
+	}
+	return value, nil
+}
+
+// read the names of the permission levels that guests can hold from the comma separated
+// GUEST_PERMISSIONS, for example "viewer" to only allow read only share links
+func GetGuestPermissionNames(defaultValue []string) ([]string, error) {
+	value := GetEnvWithDefault("GUEST_PERMISSIONS", strings.Join(defaultValue, ","))
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("GUEST_PERMISSIONS must name at least one permission level, got: %q", value)
+	}
+	return names, nil
+}
+
+// read how often the downgrade job runs from DOWNGRADE_INTERVAL, in the time.ParseDuration
+// format, and the number of downgrades applied per transaction from DOWNGRADE_BATCH_SIZE
+func GetDowngradeJobConfig(
+	defaultInterval time.Duration, defaultBatchSize int32,
+) (interval time.Duration, batchSize int32, err error) {
+	interval, err = time.ParseDuration(GetEnvWithDefault("DOWNGRADE_INTERVAL", defaultInterval.String()))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse DOWNGRADE_INTERVAL: %w", err)
+	}
+	if interval <= 0 {
+		return 0, 0, fmt.Errorf("DOWNGRADE_INTERVAL must be positive, got: %v", interval)
+	}
+	value, err := strconv.ParseInt(GetEnvWithDefault("DOWNGRADE_BATCH_SIZE", strconv.Itoa(int(defaultBatchSize))), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse DOWNGRADE_BATCH_SIZE: %w", err)
+	}
+	if value < 1 {
+		return 0, 0, fmt.Errorf("DOWNGRADE_BATCH_SIZE must be at least 1, got: %d", value)
+	}
+	return interval, int32(value), nil
+}
+
+// read how often the tombstone prune job runs from TOMBSTONE_PRUNE_INTERVAL, in the
+// time.ParseDuration format
+func GetTombstonePruneInterval(defaultInterval time.Duration) (interval time.Duration, err error) {
+	interval, err = time.ParseDuration(GetEnvWithDefault("TOMBSTONE_PRUNE_INTERVAL", defaultInterval.String()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse TOMBSTONE_PRUNE_INTERVAL: %w", err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("TOMBSTONE_PRUNE_INTERVAL must be positive, got: %v", interval)
+	}
+	return interval, nil
+}
+
 func CreateDBConnectionPool(ctx context.Context, config *pgxpool.Config) (*pgxpool.Pool, error) {
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/townsag/reed/user_service/pkg/middleware"
+)
+
+// read the tls configuration of the gRPC server. TLS_CERT_FILE and TLS_KEY_FILE are the paths of
+// the certificate and key of the server, the server is plaintext when neither is set and nil is
+// returned. TLS_MIN_VERSION is 1.2 or 1.3 and TLS_CIPHER_SUITES is a comma separated list of the
+// cipher suites allowed for tls 1.2 connections
+func GetServerTLSConfig() (*tls.Config, error) {
+	certFile := GetEnvWithDefault("TLS_CERT_FILE", "")
+	keyFile := GetEnvWithDefault("TLS_KEY_FILE", "")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	minVersion, err := middleware.ParseTLSVersion(GetEnvWithDefault("TLS_MIN_VERSION", "1.3"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS_MIN_VERSION: %w", err)
+	}
+	cipherSuites, err := middleware.ParseCipherSuites(GetEnvWithDefault("TLS_CIPHER_SUITES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS_CIPHER_SUITES: %w", err)
+	}
+	return middleware.LoadServerTLSConfig(certFile, keyFile, minVersion, cipherSuites)
+}
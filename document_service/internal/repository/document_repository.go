@@ -1,9 +1,15 @@
 package repository
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -21,8 +27,21 @@ import (
 type DocumentRepository struct {
 	queries *sqlc.Queries
 	pool *pgxpool.Pool
+	// an optional pool of connections to a read replica that the list and count methods read
+	// from. Nil when no replica is configured, in which case every method uses pool
+	readPool *pgxpool.Pool
+	// the maximum amount of time to wait for a connection from the pool
+	acquireTimeout time.Duration
+	// the maximum amount of time that the queries of one repository method can take, this
+	// includes the time spent waiting for a connection
+	queryTimeout time.Duration
+	// the watchers of the permission changes share one listening connection
+	permissions *permissionBroker
 }
 
+const DefaultAcquireTimeout time.Duration = 5 * time.Second
+const DefaultQueryTimeout time.Duration = 10 * time.Second
+
 // validate at compile time that the repository.DocumentRepository struct conforms to the 
 // service.DocumentRepository interface
 var _ service.DocumentRepository = (*DocumentRepository)(nil)
@@ -34,10 +53,104 @@ var _ service.DocumentRepository = (*DocumentRepository)(nil)
 
 // define a factory method for that struct
 func NewDocumentRepository(pool *pgxpool.Pool) *DocumentRepository {
+	return NewDocumentRepositoryWithTimeouts(pool, DefaultAcquireTimeout, DefaultQueryTimeout)
+}
+
+func NewDocumentRepositoryWithTimeouts(
+	pool *pgxpool.Pool,
+	acquireTimeout time.Duration,
+	queryTimeout time.Duration,
+) *DocumentRepository {
+	return NewDocumentRepositoryWithReadPool(pool, nil, acquireTimeout, queryTimeout)
+}
+
+// readPool can be nil, in which case every method uses pool
+func NewDocumentRepositoryWithReadPool(
+	pool *pgxpool.Pool,
+	readPool *pgxpool.Pool,
+	acquireTimeout time.Duration,
+	queryTimeout time.Duration,
+) *DocumentRepository {
 	return &DocumentRepository{
 		queries: sqlc.New(pool),
 		pool: pool,
+		readPool: readPool,
+		acquireTimeout: acquireTimeout,
+		queryTimeout: queryTimeout,
+		permissions: &permissionBroker{
+			pool: pool,
+			maxWatchers: DefaultMaxPermissionWatchers,
+			watchers: map[uuid.UUID]map[*permissionWatcher]struct{}{},
+		},
+	}
+}
+
+// derive a context that is bounded by the query timeout and acquire a connection from the pool
+// waiting at most the acquire timeout. Without this, a request can block on an exhausted pool
+// for as long as the calling context allows, which is forever for calls without a deadline.
+// The caller must call the returned release function once it is done with the connection
+func (dr *DocumentRepository) acquire(
+	ctx context.Context,
+) (context.Context, *pgxpool.Conn, func(), error) {
+	return dr.acquireFrom(ctx, dr.pool)
+}
+
+// acquire a connection from the read replica when one is configured and from the primary
+// otherwise. A replica can lag behind the primary, so only the list and count methods use it.
+// Reads that grant access, like GetPermissionLevel or the public access of GetDocument, must
+// use acquire so that a revoked permission is never honoured because of replication lag
+func (dr *DocumentRepository) acquireRead(
+	ctx context.Context,
+) (context.Context, *pgxpool.Conn, func(), error) {
+	if dr.readPool == nil {
+		return dr.acquireFrom(ctx, dr.pool)
+	}
+	return dr.acquireFrom(ctx, dr.readPool)
+}
+
+func (dr *DocumentRepository) acquireFrom(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+) (context.Context, *pgxpool.Conn, func(), error) {
+	queryCtx, cancel := context.WithTimeout(ctx, dr.queryTimeout)
+	acquireCtx, cancelAcquire := context.WithTimeout(queryCtx, dr.acquireTimeout)
+	defer cancelAcquire()
+	conn, err := pool.Acquire(acquireCtx)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to acquire a database connection within %v", dr.acquireTimeout),
+			err,
+		)
+	}
+	release := func() {
+		conn.Release()
+		cancel()
+	}
+	return queryCtx, conn, release, nil
+}
+
+// log an unexpected failure of the database at error level and wrap it in a repo impl error.
+// The service layer only sees the domain error so this is the last place where the underlying
+// error and the ids of the document and principal involved are available together. attrs are
+// slog key value pairs. Logging with the context attaches the trace id of the request
+func repoImpl(ctx context.Context, msg string, err error, attrs ...any) *service.RepoImplError {
+	slog.ErrorContext(ctx, msg, append(attrs, "error", err)...)
+	return service.RepoImpl(msg, err)
+}
+
+// the service clamps the page size before calling the repository, check it again here for
+// callers that use the repository directly. A page size below one returns an empty page that
+// reports more rows, which loops a caller that pages until hasMore is false, and a page size
+// above the max would pass an unbounded limit to the database
+func checkPageSize(pageSize int32) (int32, error) {
+	if pageSize < 1 {
+		return 0, service.InvalidInput(
+			fmt.Sprintf("page size must be at least 1, got: %d", pageSize), nil,
+		)
 	}
+	return min(pageSize, service.MaxPageSize), nil
 }
 
 func repositoryToServiceDocument(repoDocument *sqlc.Document) (*service.Document, error) {
@@ -58,9 +171,64 @@ func repositoryToServiceDocument(repoDocument *sqlc.Document) (*service.Document
 		description := repoDocument.Description.String
 		serviceDocument.Description = &description
 	}
+	if repoDocument.ArchivedAt.Valid {
+		archivedAt := repoDocument.ArchivedAt.Time
+		serviceDocument.ArchivedAt = &archivedAt
+	}
+	if repoDocument.PublicAccess.Valid {
+		publicAccess, err := repoToServicePermissionLevel(repoDocument.PublicAccess.PermissionLevel)
+		if err != nil {
+			return nil, err
+		}
+		serviceDocument.PublicAccess = &publicAccess
+	}
+	serviceDocument.PublicLinkVersion = repoDocument.PublicLinkVersion
 	return serviceDocument, nil
 }
 
+func repositoryToServiceCollection(repoCollection sqlc.Collection) *service.Collection {
+	return &service.Collection{
+		ID: repoCollection.ID.Bytes,
+		OwnerID: repoCollection.OwnerID.Bytes,
+		Name: repoCollection.Name,
+		CreatedAt: repoCollection.CreatedAt.Time,
+	}
+}
+
+func repositoryToServiceDocumentChange(repoChange sqlc.DocumentHistory) service.DocumentChange {
+	change := service.DocumentChange{
+		ID: repoChange.ID.Bytes,
+		DocumentID: repoChange.DocumentID.Bytes,
+		ActorID: repoChange.ActorID.Bytes,
+		ChangedAt: repoChange.ChangedAt.Time,
+	}
+	if repoChange.OldName.Valid {
+		change.OldName = &repoChange.OldName.String
+	}
+	if repoChange.NewName.Valid {
+		change.NewName = &repoChange.NewName.String
+	}
+	if repoChange.OldDescription.Valid {
+		change.OldDescription = &repoChange.OldDescription.String
+	}
+	if repoChange.NewDescription.Valid {
+		change.NewDescription = &repoChange.NewDescription.String
+	}
+	return change
+}
+
+// mutations on an archived document are rejected with a gone error until the document
+// is restored
+func checkDocumentActive(repoDocument sqlc.Document) error {
+	if repoDocument.ArchivedAt.Valid {
+		return service.Gone(
+			fmt.Sprintf("the document with id: %s has been archived", repoDocument.ID.String()),
+			nil,
+		)
+	}
+	return nil
+}
+
 func serviceToRepoPermissionLevel(
 	permissionService service.PermissionLevel,
 ) (sqlc.PermissionLevel, error) {
@@ -72,7 +240,7 @@ func serviceToRepoPermissionLevel(
 	case service.Owner:
 		return sqlc.PermissionLevelOwner, nil
 	default:
-		return "", fmt.Errorf("failed to match any of the valid permissions")
+		return "", fmt.Errorf("failed to match any of the valid permissions for permission: %v", permissionService)
 	}
 }
 
@@ -92,6 +260,7 @@ func repoToServicePermissionLevel(
 }
 
 func repoToServicePermission(
+	ctx context.Context,
 	permissionRepo sqlc.Permission,
 ) (service.Permission, error) {
 	errorSuffix := fmt.Sprintf(
@@ -99,35 +268,79 @@ func repoToServicePermission(
 		permissionRepo.RecipientID.String(), 
 		permissionRepo.DocumentID.String(),
 	)
+	logAttrs := []any{
+		"documentId", permissionRepo.DocumentID.String(),
+		"principalId", permissionRepo.RecipientID.String(),
+	}
 	permissionLevel, err := repoToServicePermissionLevel(permissionRepo.PermissionLevel)
 	if err != nil {
-		return service.Permission{}, service.RepoImpl("failed to parse permission level" + errorSuffix, err)
+		return service.Permission{}, repoImpl(
+			ctx,
+			"failed to parse permission level" + errorSuffix,
+			err,
+			logAttrs...,
+		)
 	}
 	serviceRecipientType, err := repoToServiceRecipientType(permissionRepo.RecipientType)
 	if err != nil {
-		return service.Permission{}, service.RepoImpl("failed to parse recipient type" + errorSuffix, err)
+		return service.Permission{}, repoImpl(
+			ctx,
+			"failed to parse recipient type" + errorSuffix,
+			err,
+			logAttrs...,
+		)
 	}
 	recipientId, err := uuid.FromBytes(permissionRepo.RecipientID.Bytes[:])
 	if err != nil {
-		return service.Permission{}, service.RepoImpl("failed to parse the recipient id" + errorSuffix, err)
+		return service.Permission{}, repoImpl(
+			ctx,
+			"failed to parse the recipient id" + errorSuffix,
+			err,
+			logAttrs...,
+		)
 	}
 	documentId, err := uuid.FromBytes(permissionRepo.DocumentID.Bytes[:])
 	if err != nil {
-		return service.Permission{}, service.RepoImpl("failed to parse the document id" + errorSuffix, err)
+		return service.Permission{}, repoImpl(
+			ctx,
+			"failed to parse the document id" + errorSuffix,
+			err,
+			logAttrs...,
+		)
 	}
 	creatorId, err := uuid.FromBytes(permissionRepo.CreatedBy.Bytes[:])
 	if err != nil {
-		return service.Permission{}, service.RepoImpl("failed to parse created by id" + errorSuffix, err)
+		return service.Permission{}, repoImpl(
+			ctx,
+			"failed to parse created by id" + errorSuffix,
+			err,
+			logAttrs...,
+		)
 	}
-	return service.Permission{
+	permission := service.Permission{
 		RecipientID: recipientId,
 		RecipientType: serviceRecipientType,
 		DocumentID: documentId,
 		PermissionLevel: permissionLevel,
 		CreatedBy: creatorId,
 		CreatedAt: permissionRepo.CreatedAt.Time,
-		LastModifiedAt: permissionRepo.CreatedAt.Time,
-	}, nil
+		LastModifiedAt: permissionRepo.LastModifiedAt.Time,
+		Pending: permissionRepo.Pending,
+	}
+	if permissionRepo.DowngradeTo.Valid {
+		downgradeTo, err := repoToServicePermissionLevel(permissionRepo.DowngradeTo.PermissionLevel)
+		if err != nil {
+			return service.Permission{}, repoImpl(
+				ctx,
+				"failed to parse downgrade level" + errorSuffix,
+				err,
+				logAttrs...,
+			)
+		}
+		permission.DowngradeTo = &downgradeTo
+		permission.DowngradeAt = &permissionRepo.DowngradeAt.Time
+	}
+	return permission, nil
 }
 
 func repoToServiceRecipientType(
@@ -145,6 +358,23 @@ func repoToServiceRecipientType(
 
 var conflictErrorCode string = "23505"
 
+// the partial unique index that allows at most one owner permission on each document
+const singleOwnerIndex = "idx_permissions_single_owner"
+
+// report whether the error is a write that would have given a document a second owner
+func isOwnerConflict(err error) bool {
+	var pgError *pgconn.PgError
+	return errors.As(err, &pgError) && pgError.Code == conflictErrorCode &&
+		pgError.ConstraintName == singleOwnerIndex
+}
+
+func ownerConflict(documentId uuid.UUID, err error) *service.UniqueConflictError {
+	return service.UniqueConflict(
+		fmt.Sprintf("document: %s already has an owner", documentId.String()),
+		err,
+	)
+}
+
 // define methods on that struct that implement the document repository interface 
 // defined in the service package. Inside those methods return domain errors defined
 // in the service package
@@ -154,16 +384,30 @@ func (dr *DocumentRepository) CreateDocument(
 	userId uuid.UUID, 
 	documentName *string,
 	documentDescription *string,
+	clientDocumentId *uuid.UUID,
 ) (documentId uuid.UUID, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer release()
 	// start a transaction
-	tx, err := dr.pool.Begin(ctx)
+	tx, err := conn.Begin(ctx)
 	if err != nil {
-		return uuid.Nil, service.RepoImpl("failed to begin a database transaction", err)
+		return uuid.Nil, repoImpl(
+			ctx,
+			"failed to begin a database transaction",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
 	}
 	defer tx.Rollback(ctx)
 	txQueries := dr.queries.WithTx(tx)
-	// generate a uuid for the document
+	// generate a uuid for the document unless the client supplied one
 	documentId = uuid.New()
+	if clientDocumentId != nil {
+		documentId = *clientDocumentId
+	}
 	// create a record in the documents table for the new document
 	params := sqlc.CreateDocumentParams{
 		ID: pgtype.UUID{ Bytes: documentId, Valid: true },
@@ -182,7 +426,19 @@ func (dr *DocumentRepository) CreateDocument(
 	}
 	err = txQueries.CreateDocument(ctx, params)
 	if err != nil {
-		return uuid.Nil, service.RepoImpl("unable to create a new document", err)
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == conflictErrorCode {
+			return uuid.Nil, service.UniqueConflict(
+				fmt.Sprintf("a document with id: %s already exists", documentId.String()),
+				err,
+			)
+		}
+		return uuid.Nil, repoImpl(
+			ctx,
+			"unable to create a new document",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
 	}
 	// create a record in the permissions table designating the user_id
 	// as the owner of that document
@@ -192,16 +448,23 @@ func (dr *DocumentRepository) CreateDocument(
 		PermissionLevel: sqlc.PermissionLevelOwner,
 		CreatedBy: pgtype.UUID{ Bytes: userId, Valid: true },
 	}
-	err = txQueries.UpsertPermissionUser(ctx, paramsPermission)
+	_, err = txQueries.UpsertPermissionUser(ctx, paramsPermission)
 	if err != nil {
-		return uuid.Nil, service.RepoImpl("unable to create permissions on new document for user", err)
+		return uuid.Nil, repoImpl(
+			ctx,
+			"unable to create permissions on new document for user",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
 	}
 	// return the generated document id
 	err = tx.Commit(ctx)
 	if err != nil {
-		return uuid.Nil, service.RepoImpl(
+		return uuid.Nil, repoImpl(
+			ctx,
 			"error encountered when creating document",
 			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
 		)
 	}
 	return documentId, nil
@@ -211,7 +474,13 @@ func (dr *DocumentRepository) GetDocument(
 	ctx context.Context,
 	documentId uuid.UUID,
 ) (document *service.Document, err error) {
-	repoDocument, err := dr.queries.GetDocument(
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	repoDocument, err := queries.GetDocument(
 		ctx,
 		pgtype.UUID{ Bytes: documentId, Valid: true },
 	)
@@ -222,31 +491,108 @@ func (dr *DocumentRepository) GetDocument(
 				err,
 			)
 		} else {
-			return nil, service.RepoImpl(
+			return nil, repoImpl(
+				ctx,
 				fmt.Sprintf("error when trying to retrieve document with id: %s", documentId.String()),
 				err,
+				"documentId", documentId.String(),
 			)
 		}
 	}
 
 	document, err = repositoryToServiceDocument(&repoDocument)
 	if err != nil {
-		return nil, service.RepoImpl("failed to parse the returned document", err)
+		return nil, repoImpl(
+			ctx,
+			"failed to parse the returned document",
+			err,
+			"documentId", documentId.String(),
+		)
 	}
 	return document, nil
 }
 
+// only a principal that held a permission on the document when it was deleted finds its tombstone
+func (dr *DocumentRepository) GetDocumentTombstone(
+	ctx context.Context,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+	deletedAfter time.Time,
+) (tombstone *service.DocumentTombstone, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	repoTombstone, err := queries.GetDocumentTombstone(
+		ctx,
+		sqlc.GetDocumentTombstoneParams{
+			DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+			DeletedAfter: pgtype.Timestamptz{ Time: deletedAfter, Valid: true },
+			PrincipalID: pgtype.UUID{ Bytes: principalId, Valid: true },
+		},
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.NotFound(
+				fmt.Sprintf("no tombstone found for document with id %s", documentId.String()),
+				err,
+			)
+		}
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf("error when trying to retrieve tombstone of document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	return &service.DocumentTombstone{
+		DocumentID: repoTombstone.DocumentID.Bytes,
+		DeletedAt: repoTombstone.DeletedAt.Time,
+	}, nil
+}
+
+func (dr *DocumentRepository) PruneDocumentTombstones(
+	ctx context.Context,
+	deletedBefore time.Time,
+) (pruned int64, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	pruned, err = sqlc.New(conn).PruneDocumentTombstones(
+		ctx, pgtype.Timestamptz{ Time: deletedBefore, Valid: true },
+	)
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to prune document tombstones", err)
+	}
+	return pruned, nil
+}
+
+// the row of the document is locked before the update so that the change appended to the
+// history of the document holds the values that the update replaced. A cleared name or
+// description is set to null
 func (dr *DocumentRepository) UpdateDocument(
 	ctx context.Context,
 	documentId uuid.UUID,
+	actorId uuid.UUID,
 	documentName *string,
 	documentDescription *string,
+	clearName bool,
+	clearDescription bool,
 ) error {
-	if documentName == nil && documentDescription == nil {
-		return service.InvalidInput("at least of of name or description must be non nil", nil)
+	if documentName == nil && documentDescription == nil && !clearName && !clearDescription {
+		return service.InvalidInput("at least of of name or description must be non nil or cleared", nil)
+	}
+	if (documentName != nil && clearName) || (documentDescription != nil && clearDescription) {
+		return service.InvalidInput("a field cannot be both set and cleared", nil)
 	}
 	params := sqlc.UpdateDocumentParams{
 		ID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		ClearName: clearName,
+		ClearDescription: clearDescription,
 	}
 	if documentName != nil {
 		params.Name = pgtype.Text{ String: *documentName, Valid: true }
@@ -254,175 +600,1266 @@ func (dr *DocumentRepository) UpdateDocument(
 	if documentDescription != nil {
 		params.Description = pgtype.Text{ String: *documentDescription, Valid: true }
 	}
-	countRows, err := dr.queries.UpdateDocument(ctx, params)
+	ctx, conn, release, err := dr.acquire(ctx)
 	if err != nil {
-		return service.RepoImpl(
-			fmt.Sprintf("error encountered when trying to update document with id: %v", documentId.String()),
+		return err
+	}
+	defer release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to begin a database transaction",
 			err,
+			"documentId", documentId.String(), "principalId", actorId.String(),
 		)
 	}
-	if countRows < 1 {
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	repoDocument, err := txQueries.GetDocumentForUpdate(ctx, params.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return service.NotFound(
 			fmt.Sprintf("unable to update the document with id: %v", documentId.String()),
 			nil,
 		)
 	}
-	return nil
-}
-
-// this function encapsulates the logic for deleting a document and the relevant permissions
-// and guests associated with that document. This function has been pulled out of the delete
-// document logic so that the logic for deleting one document can be shared between the delete
-// document function and the delete documents function.
-// the calling code is responsible for committing the transaction 
-func deleteDocumentHelper(
-	ctx context.Context,
-	txQueries *sqlc.Queries,
-	documentId uuid.UUID,
-) (err error) {
-	// delete any rows in the permissions table that reference that document
-	// this should use the index on the permissions table using the document column
-	_, err = txQueries.DeletePermissionByDocument(
-		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
-	)
 	if err != nil {
-		return service.RepoImpl(
-			fmt.Sprintf("failed to delete document with id %s", documentId.String()),
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("error encountered when trying to read document with id: %v", documentId.String()),
 			err,
+			"documentId", documentId.String(),
 		)
 	}
-	// delete any guests from the guests table that are linked to that document
-	_, err = txQueries.DeleteGuestsByDocument(
-		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
-	)
+	if err = checkDocumentActive(repoDocument); err != nil {
+		return err
+	}
+	_, err = txQueries.UpdateDocument(ctx, params)
 	if err != nil {
-		return service.RepoImpl(
-			fmt.Sprintf("failed to delete guests with document id: %s", documentId.String()),
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("error encountered when trying to update document with id: %v", documentId.String()),
 			err,
+			"documentId", documentId.String(),
 		)
 	}
-	// delete the row from the documents table
-	count, err := txQueries.DeleteDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	// a field that is not part of the update keeps its old value, the same as the coalesce in
+	// the update query
+	history := sqlc.InsertDocumentHistoryParams{
+		ID: pgtype.UUID{ Bytes: uuid.New(), Valid: true },
+		DocumentID: params.ID,
+		ActorID: pgtype.UUID{ Bytes: actorId, Valid: true },
+		OldName: repoDocument.Name,
+		NewName: repoDocument.Name,
+		OldDescription: repoDocument.Description,
+		NewDescription: repoDocument.Description,
+	}
+	if params.Name.Valid || clearName {
+		history.NewName = params.Name
+	}
+	if params.Description.Valid || clearDescription {
+		history.NewDescription = params.Description
+	}
+	err = txQueries.InsertDocumentHistory(ctx, history)
 	if err != nil {
-		return service.RepoImpl(
-			fmt.Sprintf("failed to delete document with id: %s", documentId.String()),
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to record the history of document with id: %v", documentId.String()),
 			err,
+			"documentId", documentId.String(), "principalId", actorId.String(),
 		)
 	}
-	if count < 1 {
-		return service.NotFound(
-			fmt.Sprintf("no document found with id: %s", documentId.String()),
-			nil,
+	err = tx.Commit(ctx)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to commit transaction",
+			err,
+			"documentId", documentId.String(), "principalId", actorId.String(),
 		)
 	}
-	return err
+	return nil
 }
 
-// what does it mean for a document to be deleted: only support hard deletion
-// - delete the document in the documents table and all permissions on the document
-//	 in the permissions table
-// - publish an event notifying other services that the document has been deleted
-// decided to use hard deletion because it is simpler to implement and understand 
-// by users
-// decided not to use cascading deletes because of hidden potential for mistakes
-func (dr *DocumentRepository) DeleteDocument(
+// updates to a document do not match archived documents, read the document to tell the
+// difference between a missing document and an archived one after an update matched no rows
+func missingOrArchived(ctx context.Context, queries *sqlc.Queries, documentId uuid.UUID) error {
+	repoDocument, err := queries.GetDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("error encountered when trying to read document with id: %v", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	if err == nil {
+		if err = checkDocumentActive(repoDocument); err != nil {
+			return err
+		}
+	}
+	return service.NotFound(
+		fmt.Sprintf("unable to update the document with id: %v", documentId.String()),
+		nil,
+	)
+}
+
+// a nil public access disables the public link of the document and bumps its version, the
+// version of the public link after the change is returned
+func (dr *DocumentRepository) SetPublicAccess(
 	ctx context.Context,
 	documentId uuid.UUID,
-) error {
-	// start a transaction
-	tx, err := dr.pool.Begin(ctx)
-	if err != nil {
-		return service.RepoImpl("failed to begin a database transaction", err)
+	publicAccess *service.PermissionLevel,
+) (publicLinkVersion int32, err error) {
+	params := sqlc.SetPublicAccessParams{
+		ID: pgtype.UUID{ Bytes: documentId, Valid: true },
 	}
-	defer tx.Rollback(ctx)
-	txQueries := dr.queries.WithTx(tx)
-	err = deleteDocumentHelper(ctx, txQueries, documentId)
+	if publicAccess != nil {
+		repoPublicAccess, err := serviceToRepoPermissionLevel(*publicAccess)
+		if err != nil {
+			return 0, service.InvalidInput(
+				fmt.Sprintf("invalid input for public access: %v", *publicAccess),
+				err,
+			)
+		}
+		params.PublicAccess = sqlc.NullPermissionLevel{ PermissionLevel: repoPublicAccess, Valid: true }
+	}
+	ctx, conn, release, err := dr.acquire(ctx)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	publicLinkVersion, err = queries.SetPublicAccess(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, missingOrArchived(ctx, queries, documentId)
 	}
-	err = tx.Commit(ctx)
 	if err != nil {
-		return service.RepoImpl(
-			"failed to commit transaction",
+		return 0, repoImpl(
+			ctx,
+			fmt.Sprintf("error encountered when setting the public access of document with id: %v", documentId.String()),
 			err,
+			"documentId", documentId.String(),
 		)
 	}
-	return nil
+	return publicLinkVersion, nil
 }
 
-func (dr *DocumentRepository) DeleteDocuments(
+// bump the last modified at time of an active document without changing its content, no
+// history is recorded because neither the name nor the description changes
+func (dr *DocumentRepository) TouchDocument(
 	ctx context.Context,
-	documentIds uuid.UUIDs,
-	userId uuid.UUID,
-) (err error) {
-	// TODO: refactor this to verify that the given user has permission to delete this document
-	// also the given user must be a user and not a guest
-	if len(documentIds) < 1 {
-		return service.InvalidInput("expected at least one documentId", nil)
-	}
-	// TODO: refactor this to use job ids and support job status for batch delete
-	// start a transaction, this will be a long running transaction
-	tx, err := dr.pool.Begin(ctx)
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
 	if err != nil {
-		return service.RepoImpl("failed to create a database transaction", err)
-	}
-	defer tx.Rollback(ctx)
-	txQueries := dr.queries.WithTx(tx)
-	// design decision, don't support partial success or partial failures
-	// either all the documents are deleted or none of them are
-	for _, documentId := range documentIds {
-		err = deleteDocumentHelper(ctx, txQueries, documentId)
-		if err != nil {
-			return err
-		}
+		return err
 	}
-	err = tx.Commit(ctx)
+	defer release()
+	queries := sqlc.New(conn)
+	countRows, err := queries.TouchDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
 	if err != nil {
-		return service.RepoImpl("failed to commit transaction", err)
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("error encountered when touching document with id: %v", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
 	}
-	return err
+	if countRows < 1 {
+		return missingOrArchived(ctx, queries, documentId)
+	}
+	return nil
 }
 
-func parseDocumentPermission(
-	document sqlc.Document,
-	permissionLevel sqlc.PermissionLevel,
-) (*service.DocumentPermission, error) {
-	permissionLevelService, err := repoToServicePermissionLevel(permissionLevel)
+func (dr *DocumentRepository) RecordDocumentAccess(
+	ctx context.Context,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
 	if err != nil {
-		// TODO: log the error
-		return nil, service.RepoImpl(
-			fmt.Sprintf(
-				"failed to parse permission for documentId: %s", 
-				document.ID.String(), 
-			),
-			err,
-		)
+		return err
 	}
-	serviceDocument, err := repositoryToServiceDocument(&document)
+	defer release()
+	err = sqlc.New(conn).UpsertDocumentAccess(ctx, sqlc.UpsertDocumentAccessParams{
+		PrincipalID: pgtype.UUID{ Bytes: principalId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+	})
 	if err != nil {
-		return nil, service.RepoImpl(
-			fmt.Sprintf(
-				"failed to parse document with documentId: %s", document.ID.String(),
-			),
+		return repoImpl(
+			ctx,
+			"failed to record the access of the principal to the document",
 			err,
+			"documentId", documentId.String(), "principalId", principalId.String(),
 		)
 	}
-	return &service.DocumentPermission{
-		Document: *serviceDocument,
-		Permission: permissionLevelService,
-	}, nil
+	return nil
 }
 
-func (dr *DocumentRepository) readDocuments(
+func (dr *DocumentRepository) StarDocument(
 	ctx context.Context,
-	principalId uuid.UUID, 
-	repoPermissionList []sqlc.PermissionLevel,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	err = sqlc.New(conn).UpsertFavorite(ctx, sqlc.UpsertFavoriteParams{
+		PrincipalID: pgtype.UUID{ Bytes: principalId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+	})
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to star the document for the principal",
+			err,
+			"documentId", documentId.String(), "principalId", principalId.String(),
+		)
+	}
+	return nil
+}
+
+// unstarring a document that the principal has not starred is a no-op
+func (dr *DocumentRepository) UnstarDocument(
+	ctx context.Context,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	_, err = sqlc.New(conn).DeleteFavorite(ctx, sqlc.DeleteFavoriteParams{
+		PrincipalID: pgtype.UUID{ Bytes: principalId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+	})
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to unstar the document for the principal",
+			err,
+			"documentId", documentId.String(), "principalId", principalId.String(),
+		)
+	}
+	return nil
+}
+
+func (dr *DocumentRepository) CreateCollection(
+	ctx context.Context,
+	ownerId uuid.UUID,
+	name string,
+) (collection *service.Collection, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	repoCollection, err := sqlc.New(conn).CreateCollection(ctx, sqlc.CreateCollectionParams{
+		ID: pgtype.UUID{ Bytes: uuid.New(), Valid: true },
+		OwnerID: pgtype.UUID{ Bytes: ownerId, Valid: true },
+		Name: name,
+	})
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			"failed to create the collection",
+			err,
+			"ownerId", ownerId.String(),
+		)
+	}
+	return repositoryToServiceCollection(repoCollection), nil
+}
+
+func (dr *DocumentRepository) GetCollection(
+	ctx context.Context,
+	collectionId uuid.UUID,
+) (collection *service.Collection, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	repoCollection, err := sqlc.New(conn).GetCollection(ctx, pgtype.UUID{ Bytes: collectionId, Valid: true })
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.NotFound(
+				fmt.Sprintf("no collection found with id: %s", collectionId.String()),
+				err,
+			)
+		}
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to read the collection with id: %s", collectionId.String()),
+			err,
+			"collectionId", collectionId.String(),
+		)
+	}
+	return repositoryToServiceCollection(repoCollection), nil
+}
+
+// adding a document that is already in the collection is a no-op
+func (dr *DocumentRepository) AddToCollection(
+	ctx context.Context,
+	collectionId uuid.UUID,
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	err = sqlc.New(conn).InsertDocumentCollection(ctx, sqlc.InsertDocumentCollectionParams{
+		CollectionID: pgtype.UUID{ Bytes: collectionId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+	})
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to add the document to the collection",
+			err,
+			"documentId", documentId.String(), "collectionId", collectionId.String(),
+		)
+	}
+	return nil
+}
+
+// removing a document that is not in the collection is a no-op
+func (dr *DocumentRepository) RemoveFromCollection(
+	ctx context.Context,
+	collectionId uuid.UUID,
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	_, err = sqlc.New(conn).DeleteDocumentCollection(ctx, sqlc.DeleteDocumentCollectionParams{
+		CollectionID: pgtype.UUID{ Bytes: collectionId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+	})
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to remove the document from the collection",
+			err,
+			"documentId", documentId.String(), "collectionId", collectionId.String(),
+		)
+	}
+	return nil
+}
+
+// archiving a document is a soft delete, the permissions and guests of the document are
+// kept so that it can be restored. Archiving an archived document is a no-op
+func (dr *DocumentRepository) ArchiveDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	count, err := queries.ArchiveDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("error encountered when trying to archive document with id: %v", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	if count < 1 {
+		return service.NotFound(
+			fmt.Sprintf("no document found with id: %s", documentId.String()),
+			nil,
+		)
+	}
+	return nil
+}
+
+func (dr *DocumentRepository) RestoreDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	count, err := queries.RestoreDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("error encountered when trying to restore document with id: %v", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	if count < 1 {
+		return service.NotFound(
+			fmt.Sprintf("no document found with id: %s", documentId.String()),
+			nil,
+		)
+	}
+	return nil
+}
+
+// this function encapsulates the logic for deleting a document and the relevant permissions,
+// history and guests associated with that document. This function has been pulled out of the delete
+// document logic so that the logic for deleting one document can be shared between the delete
+// document function and the delete documents function.
+// the calling code is responsible for committing the transaction 
+func deleteDocumentHelper(
+	ctx context.Context,
+	txQueries *sqlc.Queries,
+	documentId uuid.UUID,
+) (err error) {
+	// lock the document before reading the rows that reference it. A permission or guest that
+	// is inserted concurrently either commits before the lock is granted and is deleted below,
+	// or waits for the lock and fails its foreign key check once the document is gone
+	_, err = txQueries.GetDocumentForUpdate(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return service.NotFound(
+				fmt.Sprintf("no document found with id: %s", documentId.String()),
+				err,
+			)
+		}
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to lock document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// leave a tombstone so that syncing clients can tell that the document was deleted, the
+	// tombstone records who held a permission so it is written before the permissions are deleted
+	err = txQueries.UpsertDocumentTombstone(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to create a tombstone for document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// delete any rows in the permissions table that reference that document
+	// this should use the index on the permissions table using the document column
+	_, err = txQueries.DeletePermissionByDocument(
+		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
+	)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to delete document with id %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// delete the history of the document
+	_, err = txQueries.DeleteDocumentHistoryByDocument(
+		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
+	)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to delete the history of document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// delete the permission audit of the document
+	_, err = txQueries.DeletePermissionAuditByDocument(
+		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
+	)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to delete the permission audit of document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// delete the accesses of principals to the document
+	_, err = txQueries.DeleteAccessLogByDocument(
+		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
+	)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to delete the accesses of document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// delete the favorites of principals on the document
+	_, err = txQueries.DeleteFavoritesByDocument(
+		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
+	)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to delete the favorites of document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// delete the memberships of the document in collections
+	_, err = txQueries.DeleteDocumentCollectionsByDocument(
+		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
+	)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to delete the collection memberships of document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// delete any guests from the guests table that are linked to that document
+	_, err = txQueries.DeleteGuestsByDocument(
+		ctx, pgtype.UUID{ Bytes: documentId, Valid: true },
+	)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to delete guests with document id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	// delete the row from the documents table
+	count, err := txQueries.DeleteDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to delete document with id: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	if count < 1 {
+		return service.NotFound(
+			fmt.Sprintf("no document found with id: %s", documentId.String()),
+			nil,
+		)
+	}
+	return nil
+}
+
+// what does it mean for a document to be deleted: only support hard deletion
+// - delete the document in the documents table and all permissions on the document
+//	 in the permissions table
+// - publish an event notifying other services that the document has been deleted
+// decided to use hard deletion because it is simpler to implement and understand 
+// by users
+// decided not to use cascading deletes because of hidden potential for mistakes
+func (dr *DocumentRepository) DeleteDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+) error {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	// read committed so that the rows that reference the document are read after the document
+	// lock, see deleteDocumentHelper. A repeatable read snapshot is taken before the lock is
+	// granted and would miss a permission that was committed while waiting for it
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.ReadCommitted })
+	if err != nil {
+		return repoImpl(ctx, "failed to begin a database transaction", err, "documentId", documentId.String())
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	err = deleteDocumentHelper(ctx, txQueries, documentId)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to commit transaction",
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	return nil
+}
+
+func (dr *DocumentRepository) DeleteDocuments(
+	ctx context.Context,
+	documentIds uuid.UUIDs,
+	userId uuid.UUID,
+) (err error) {
+	// the service layer verifies that the given user owns every document before calling this
+	if len(documentIds) < 1 {
+		return service.InvalidInput("expected at least one documentId", nil)
+	}
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	// TODO: refactor this to use job ids and support job status for batch delete
+	// start a transaction, this will be a long running transaction. Read committed for the same
+	// reason as DeleteDocument
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.ReadCommitted })
+	if err != nil {
+		return repoImpl(ctx, "failed to create a database transaction", err, "principalId", userId.String())
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	// design decision, don't support partial success or partial failures
+	// either all the documents are deleted or none of them are. The documents are locked in
+	// order so that two batches that share documents cannot deadlock
+	documentIds = slices.Clone(documentIds)
+	slices.SortFunc(documentIds, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	for _, documentId := range documentIds {
+		err = deleteDocumentHelper(ctx, txQueries, documentId)
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return repoImpl(ctx, "failed to commit transaction", err, "principalId", userId.String())
+	}
+	return err
+}
+
+// move every document owned by the from owner to the to owner, batchSize documents per
+// transaction. Each batch acquires its own connection so that a principal that owns many
+// documents does not hold one transaction open for the whole move. A batch that fails leaves
+// the batches that were committed before it in place, calling this again moves the rest
+func (dr *DocumentRepository) ReassignOwnedDocuments(
+	ctx context.Context,
+	fromOwnerId uuid.UUID,
+	toOwnerId uuid.UUID,
+	batchSize int32,
+) (moved int64, err error) {
+	if batchSize < 1 {
+		return 0, service.InvalidInput(fmt.Sprintf("batch size must be at least 1, got: %d", batchSize), nil)
+	}
+	for {
+		batchMoved, err := dr.reassignOwnedDocumentsBatch(ctx, fromOwnerId, toOwnerId, batchSize)
+		moved += batchMoved
+		if err != nil {
+			return moved, err
+		}
+		if batchMoved < int64(batchSize) {
+			return moved, nil
+		}
+	}
+}
+
+func (dr *DocumentRepository) reassignOwnedDocumentsBatch(
+	ctx context.Context,
+	fromOwnerId uuid.UUID,
+	toOwnerId uuid.UUID,
+	batchSize int32,
+) (int64, error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to begin a database transaction", err, "principalId", fromOwnerId.String())
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	fromOwner := pgtype.UUID{ Bytes: fromOwnerId, Valid: true }
+	documentIds, err := txQueries.ListOwnedDocumentIdsForUpdate(ctx, sqlc.ListOwnedDocumentIdsForUpdateParams{
+		RecipientID: fromOwner,
+		Limit: batchSize,
+	})
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to list the documents owned by the principal", err, "principalId", fromOwnerId.String())
+	}
+	if len(documentIds) == 0 {
+		return 0, nil
+	}
+	// remove the old owner before granting the new one because a document can only have one
+	// owner at a time, the transaction keeps the documents of the batch from being seen
+	// without an owner
+	_, err = txQueries.DeletePermissionsOfPrincipal(ctx, sqlc.DeletePermissionsOfPrincipalParams{
+		RecipientID: fromOwner,
+		DocumentIds: documentIds,
+	})
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to remove the permissions of the old owner", err, "principalId", fromOwnerId.String())
+	}
+	_, err = txQueries.UpsertOwnerPermissions(ctx, sqlc.UpsertOwnerPermissionsParams{
+		OwnerID: pgtype.UUID{ Bytes: toOwnerId, Valid: true },
+		DocumentIds: documentIds,
+		CreatedBy: fromOwner,
+	})
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to grant owner permissions to the new owner", err, "principalId", toOwnerId.String())
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to commit transaction", err, "principalId", fromOwnerId.String())
+	}
+	return int64(len(documentIds)), nil
+}
+
+func (dr *DocumentRepository) EnsureDocumentHasOwner(
+	ctx context.Context,
+	documentId uuid.UUID,
+	fallbackOwnerId uuid.UUID,
+) (repaired bool, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return false, repoImpl(ctx, "failed to begin a database transaction", err, "documentId", documentId.String())
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	repoDocumentId := pgtype.UUID{ Bytes: documentId, Valid: true }
+	// lock the document so that two repairs of the same document cannot both see it without
+	// an owner
+	_, err = txQueries.GetDocumentForUpdate(ctx, repoDocumentId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, service.NotFound(
+			fmt.Sprintf("unable to find the document with id: %v", documentId.String()),
+			nil,
+		)
+	}
+	if err != nil {
+		return false, repoImpl(ctx, "failed to read the document", err, "documentId", documentId.String())
+	}
+	ownerCount, err := txQueries.CountPermissionsOnDocument(ctx, sqlc.CountPermissionsOnDocumentParams{
+		DocumentID: repoDocumentId,
+		PermissionsList: []sqlc.PermissionLevel{ sqlc.PermissionLevelOwner },
+	})
+	if err != nil {
+		return false, repoImpl(ctx, "failed to count the owners of the document", err, "documentId", documentId.String())
+	}
+	if ownerCount > 0 {
+		return false, nil
+	}
+	fallbackOwner := pgtype.UUID{ Bytes: fallbackOwnerId, Valid: true }
+	_, err = txQueries.UpsertOwnerPermissions(ctx, sqlc.UpsertOwnerPermissionsParams{
+		OwnerID: fallbackOwner,
+		DocumentIds: []pgtype.UUID{ repoDocumentId },
+		CreatedBy: fallbackOwner,
+	})
+	if err != nil {
+		return false, repoImpl(
+			ctx, "failed to grant the owner permission to the fallback owner", err,
+			"documentId", documentId.String(), "principalId", fallbackOwnerId.String(),
+		)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, repoImpl(ctx, "failed to commit transaction", err, "documentId", documentId.String())
+	}
+	return true, nil
+}
+
+func (dr *DocumentRepository) SchedulePermissionDowngrade(
+	ctx context.Context,
+	documentId uuid.UUID,
+	recipientId uuid.UUID,
+	downgradeTo service.PermissionLevel,
+	downgradeAt time.Time,
+) (err error) {
+	downgradeToRepo, err := serviceToRepoPermissionLevel(downgradeTo)
+	if err != nil {
+		return service.InvalidInput(
+			fmt.Sprintf("invalid input received for downgrade level: %v", downgradeTo),
+			err,
+		)
+	}
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	count, err := sqlc.New(conn).SchedulePermissionDowngrade(ctx, sqlc.SchedulePermissionDowngradeParams{
+		RecipientID: pgtype.UUID{ Bytes: recipientId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		DowngradeTo: sqlc.NullPermissionLevel{ PermissionLevel: downgradeToRepo, Valid: true },
+		DowngradeAt: pgtype.Timestamptz{ Time: downgradeAt, Valid: true },
+	})
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf(
+				"failed to schedule a downgrade of the permission of %s on document %s",
+				recipientId.String(), documentId.String(),
+			),
+			err,
+			"documentId", documentId.String(), "principalId", recipientId.String(),
+		)
+	}
+	if count < 1 {
+		return service.NotFound(
+			fmt.Sprintf(
+				"no permission of recipient: %s on document: %s can be downgraded to: %v",
+				recipientId.String(), documentId.String(), downgradeTo,
+			),
+			nil,
+		)
+	}
+	return nil
+}
+
+func (dr *DocumentRepository) ApplyDueDowngrades(
+	ctx context.Context,
+	now time.Time,
+	batchSize int32,
+) (applied int64, err error) {
+	if batchSize < 1 {
+		return 0, service.InvalidInput(fmt.Sprintf("batch size must be at least 1, got: %d", batchSize), nil)
+	}
+	for {
+		batchApplied, err := dr.applyDueDowngradesBatch(ctx, now, batchSize)
+		applied += batchApplied
+		if err != nil {
+			return applied, err
+		}
+		if batchApplied < int64(batchSize) {
+			return applied, nil
+		}
+	}
+}
+
+func (dr *DocumentRepository) applyDueDowngradesBatch(
+	ctx context.Context,
+	now time.Time,
+	batchSize int32,
+) (int64, error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to begin a database transaction", err)
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	dueDowngrades, err := txQueries.ListDueDowngradesForUpdate(ctx, sqlc.ListDueDowngradesForUpdateParams{
+		Now: pgtype.Timestamptz{ Time: now, Valid: true },
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to list the due permission downgrades", err)
+	}
+	if len(dueDowngrades) == 0 {
+		return 0, nil
+	}
+	for _, due := range dueDowngrades {
+		logAttrs := []any{ "documentId", due.DocumentID.String(), "principalId", due.RecipientID.String() }
+		_, err = txQueries.ApplyPermissionDowngrade(ctx, sqlc.ApplyPermissionDowngradeParams{
+			RecipientID: due.RecipientID,
+			DocumentID: due.DocumentID,
+		})
+		if err != nil {
+			return 0, repoImpl(ctx, "failed to apply a permission downgrade", err, logAttrs...)
+		}
+		err = txQueries.InsertPermissionAudit(ctx, sqlc.InsertPermissionAuditParams{
+			ID: pgtype.UUID{ Bytes: uuid.New(), Valid: true },
+			DocumentID: due.DocumentID,
+			RecipientID: due.RecipientID,
+			OldPermissionLevel: due.PermissionLevel,
+			NewPermissionLevel: due.DowngradeTo.PermissionLevel,
+			Reason: service.ScheduledDowngradeReason,
+		})
+		if err != nil {
+			return 0, repoImpl(ctx, "failed to record a permission downgrade in the audit", err, logAttrs...)
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, repoImpl(ctx, "failed to commit transaction", err)
+	}
+	return int64(len(dueDowngrades)), nil
+}
+
+func (dr *DocumentRepository) ListPermissionAudit(
+	ctx context.Context,
+	documentId uuid.UUID,
+	pageSize int32,
+) (entries []service.PermissionAuditEntry, err error) {
+	pageSize, err = checkPageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	rows, err := sqlc.New(conn).ListPermissionAuditByDocument(ctx, sqlc.ListPermissionAuditByDocumentParams{
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		Limit: pageSize,
+	})
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to retrieve the permission audit of document: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	entries = make([]service.PermissionAuditEntry, 0, len(rows))
+	for _, row := range rows {
+		oldLevel, err := repoToServicePermissionLevel(row.OldPermissionLevel)
+		if err != nil {
+			return nil, repoImpl(ctx, "failed to parse the old permission level of an audit entry", err, "documentId", documentId.String())
+		}
+		newLevel, err := repoToServicePermissionLevel(row.NewPermissionLevel)
+		if err != nil {
+			return nil, repoImpl(ctx, "failed to parse the new permission level of an audit entry", err, "documentId", documentId.String())
+		}
+		entries = append(entries, service.PermissionAuditEntry{
+			ID: row.ID.Bytes,
+			DocumentID: row.DocumentID.Bytes,
+			RecipientID: row.RecipientID.Bytes,
+			OldPermissionLevel: oldLevel,
+			NewPermissionLevel: newLevel,
+			Reason: row.Reason,
+			ChangedAt: row.ChangedAt.Time,
+		})
+	}
+	return entries, nil
+}
+
+// the channel that the notify_permission_change trigger publishes the changes to the permissions
+// of every document on, the document of a change is part of the payload
+const permissionChannel = "document_permissions"
+
+// how long stopping the listener waits for its connection to close
+const unlistenTimeout = 5 * time.Second
+
+const DefaultMaxPermissionWatchers int = 1024
+
+// how many changes a watcher buffers, a watcher that falls further behind than this is dropped
+// so that one slow caller does not hold up the changes of every other watcher
+const permissionWatcherBuffer = 64
+
+var errWatcherFellBehind = errors.New("the watcher fell behind the permission changes")
+
+// the payload that the notify_permission_change trigger publishes
+type permissionNotification struct {
+	DocumentID uuid.UUID `json:"document_id"`
+	Change string `json:"change"`
+	RecipientID uuid.UUID `json:"recipient_id"`
+	RecipientType sqlc.RecipientType `json:"recipient_type"`
+	PermissionLevel sqlc.PermissionLevel `json:"permission_level"`
+	Pending bool `json:"pending"`
+}
+
+// fans the permission changes out to the open watchers. Every watcher of the repository shares
+// one connection that listens on the permission channel, so the watchers do not take
+// connections away from the other methods of the repository. The connection is taken from the
+// pool when the first watcher opens and closed when the last watcher closes
+type permissionBroker struct {
+	pool *pgxpool.Pool
+	mu sync.Mutex
+	maxWatchers int
+	watchers map[uuid.UUID]map[*permissionWatcher]struct{}
+	watcherCount int
+	// stops the listener, nil when no listener is running
+	stopListener context.CancelFunc
+}
+
+// a subscription to the permission changes of one document
+type permissionWatcher struct {
+	broker *permissionBroker
+	documentId uuid.UUID
+	events chan permissionNotification
+	// closed by the broker when it drops the watcher, err holds the reason
+	dropped chan struct{}
+	err error
+}
+
+var _ service.PermissionWatcher = (*permissionWatcher)(nil)
+
+// limit how many watchers can be open at once, watching beyond the limit fails with a resource
+// exhausted error until another watcher is closed
+func (dr *DocumentRepository) SetMaxPermissionWatchers(maxWatchers int) {
+	dr.permissions.mu.Lock()
+	defer dr.permissions.mu.Unlock()
+	dr.permissions.maxWatchers = maxWatchers
+}
+
+func (dr *DocumentRepository) WatchDocumentPermissions(
+	ctx context.Context,
+	documentId uuid.UUID,
+) (watcher service.PermissionWatcher, err error) {
+	b := dr.permissions
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.watcherCount >= b.maxWatchers {
+		return nil, service.ResourceExhausted(
+			fmt.Sprintf("too many permission watchers are open, the limit is %d", b.maxWatchers), nil,
+		)
+	}
+	// the listener is started before the watcher is returned, so a change made after the watcher
+	// is returned is never missed
+	if b.stopListener == nil {
+		if err = b.startListener(ctx, dr.acquireTimeout); err != nil {
+			return nil, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to listen for the permission changes of document: %s", documentId.String()),
+				err,
+				"documentId", documentId.String(),
+			)
+		}
+	}
+	w := &permissionWatcher{
+		broker: b,
+		documentId: documentId,
+		events: make(chan permissionNotification, permissionWatcherBuffer),
+		dropped: make(chan struct{}),
+	}
+	if b.watchers[documentId] == nil {
+		b.watchers[documentId] = map[*permissionWatcher]struct{}{}
+	}
+	b.watchers[documentId][w] = struct{}{}
+	b.watcherCount++
+	return w, nil
+}
+
+// take a connection out of the pool for good and listen on the permission channel with it. The
+// connection is held for as long as the listener runs, so it is not bounded by the query timeout
+// like the connections of the other methods, only acquiring it is bounded. Called with mu held
+func (b *permissionBroker) startListener(ctx context.Context, acquireTimeout time.Duration) error {
+	acquireCtx, cancel := context.WithTimeout(ctx, acquireTimeout)
+	defer cancel()
+	pooled, err := b.pool.Acquire(acquireCtx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire a database connection within %v: %w", acquireTimeout, err)
+	}
+	// a hijacked connection no longer counts towards the size of the pool, and it is closed
+	// instead of being returned so that no other caller receives a listening connection
+	conn := pooled.Hijack()
+	if _, err = conn.Exec(acquireCtx, "LISTEN "+pgx.Identifier{ permissionChannel }.Sanitize()); err != nil {
+		closeListenerConn(conn)
+		return err
+	}
+	listenCtx, stop := context.WithCancel(context.Background())
+	b.stopListener = stop
+	go b.listen(listenCtx, conn)
+	return nil
+}
+
+func closeListenerConn(conn *pgx.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), unlistenTimeout)
+	defer cancel()
+	conn.Close(ctx)
+}
+
+// deliver each change to the watchers of its document until the listener is stopped or the
+// connection fails
+func (b *permissionBroker) listen(ctx context.Context, conn *pgx.Conn) {
+	defer closeListenerConn(conn)
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			b.dropAll(ctx, err)
+			return
+		}
+		var payload permissionNotification
+		if err = json.Unmarshal([]byte(notification.Payload), &payload); err != nil {
+			slog.WarnContext(ctx, "failed to parse a permission change notification", "error", err)
+			continue
+		}
+		b.deliver(payload)
+	}
+}
+
+func (b *permissionBroker) deliver(payload permissionNotification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for w := range b.watchers[payload.DocumentID] {
+		select {
+		case w.events <- payload:
+		default:
+			b.drop(w, errWatcherFellBehind)
+		}
+	}
+}
+
+// the connection of the listener failed, every watcher is dropped so that its caller can watch
+// again, which starts a new listener. Nothing is dropped when the listener was stopped because
+// the last watcher closed
+func (b *permissionBroker) dropAll(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// stopListener is called with mu held, so a listener that is still running is the current one
+	if ctx.Err() != nil {
+		return
+	}
+	slog.ErrorContext(ctx, "stopped listening for permission changes", "error", err)
+	for _, watchers := range b.watchers {
+		for w := range watchers {
+			b.drop(w, err)
+		}
+	}
+	b.stopListener()
+	b.stopListener = nil
+}
+
+// remove the watcher and stop the listener once no watchers are left. Called with mu held
+func (b *permissionBroker) remove(w *permissionWatcher) bool {
+	watchers := b.watchers[w.documentId]
+	if _, ok := watchers[w]; !ok {
+		return false
+	}
+	delete(watchers, w)
+	if len(watchers) == 0 {
+		delete(b.watchers, w.documentId)
+	}
+	b.watcherCount--
+	if b.watcherCount == 0 && b.stopListener != nil {
+		b.stopListener()
+		b.stopListener = nil
+	}
+	return true
+}
+
+// called with mu held
+func (b *permissionBroker) drop(w *permissionWatcher, err error) {
+	if b.remove(w) {
+		w.err = err
+		close(w.dropped)
+	}
+}
+
+func (w *permissionWatcher) Next(ctx context.Context) (event service.PermissionEvent, err error) {
+	var payload permissionNotification
+	select {
+	case payload = <-w.events:
+	case <-ctx.Done():
+		// the caller stopped watching, this is not a failure of the database
+		return event, ctx.Err()
+	case <-w.dropped:
+		// the changes that were delivered before the watcher was dropped are still returned
+		select {
+		case payload = <-w.events:
+		default:
+			return event, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to wait for the permission changes of document: %s", w.documentId.String()),
+				w.err,
+				"documentId", w.documentId.String(),
+			)
+		}
+	}
+	event = service.PermissionEvent{
+		DocumentID: w.documentId,
+		RecipientID: payload.RecipientID,
+		Pending: payload.Pending,
+	}
+	switch payload.Change {
+	case "added":
+		event.Change = service.PermissionAdded
+	case "updated":
+		event.Change = service.PermissionUpdated
+	case "removed":
+		event.Change = service.PermissionRemoved
+	default:
+		return event, repoImpl(
+			ctx,
+			"failed to parse a permission change notification",
+			fmt.Errorf("unknown change: %s", payload.Change),
+			"documentId", w.documentId.String(),
+		)
+	}
+	event.RecipientType, err = repoToServiceRecipientType(payload.RecipientType)
+	if err != nil {
+		return event, repoImpl(ctx, "failed to parse the recipient type of a permission change", err, "documentId", w.documentId.String())
+	}
+	event.PermissionLevel, err = repoToServicePermissionLevel(payload.PermissionLevel)
+	if err != nil {
+		return event, repoImpl(ctx, "failed to parse the permission level of a permission change", err, "documentId", w.documentId.String())
+	}
+	return event, nil
+}
+
+func (w *permissionWatcher) Close() {
+	w.broker.mu.Lock()
+	defer w.broker.mu.Unlock()
+	w.broker.remove(w)
+}
+
+func parseDocumentPermission(
+	ctx context.Context,
+	document sqlc.Document,
+	permissionLevel sqlc.PermissionLevel,
+	permissionCreatedAt pgtype.Timestamptz,
+	permissionLastModifiedAt pgtype.Timestamptz,
+) (*service.DocumentPermission, error) {
+	permissionLevelService, err := repoToServicePermissionLevel(permissionLevel)
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf(
+				"failed to parse permission for documentId: %s", 
+				document.ID.String(), 
+			),
+			err,
+			"documentId", document.ID.String(),
+		)
+	}
+	serviceDocument, err := repositoryToServiceDocument(&document)
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf(
+				"failed to parse document with documentId: %s", document.ID.String(),
+			),
+			err,
+			"documentId", document.ID.String(),
+		)
+	}
+	return &service.DocumentPermission{
+		Document: *serviceDocument,
+		Permission: permissionLevelService,
+		PermissionCreatedAt: permissionCreatedAt.Time,
+		PermissionLastModifiedAt: permissionLastModifiedAt.Time,
+	}, nil
+}
+
+func readDocuments(
+	ctx context.Context,
+	queries *sqlc.Queries,
+	principalId uuid.UUID, 
+	repoPermissionList []sqlc.PermissionLevel,
+	favoritesOnly bool,
+	collectionId *uuid.UUID,
+	includeArchived bool,
 	cursor *service.Cursor,
 	pageSize int32,
 ) (
 	documentPermissionList []service.DocumentPermission,
 	err error,
 ) {
+	// a null collection id matches every document
+	repoCollectionId := pgtype.UUID{ Valid: collectionId != nil }
+	if collectionId != nil {
+		repoCollectionId.Bytes = *collectionId
+	}
 	switch cursor.SortField {
 	case service.CreatedAt:
 		params := sqlc.ListDocumentsByCreatedAtParams{
@@ -431,13 +1868,24 @@ func (dr *DocumentRepository) readDocuments(
 			ID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
 			Limit: pageSize,
 			PermissionsList: repoPermissionList,
+			FavoritesOnly: favoritesOnly,
+			CollectionID: repoCollectionId,
+			IncludeArchived: includeArchived,
 		}
-		rows, err := dr.queries.ListDocumentsByCreatedAt(ctx, params)
+		rows, err := queries.ListDocumentsByCreatedAt(ctx, params)
 		if err != nil {
-			return nil, service.RepoImpl("failed to retrieve document by principal", err)
+			return nil, repoImpl(
+				ctx,
+				"failed to retrieve document by principal",
+				err,
+				"principalId", principalId.String(),
+			)
 		}
 		for _, row := range rows {
-			documentPermission, err := parseDocumentPermission(row.Document, row.PermissionLevel)
+			documentPermission, err := parseDocumentPermission(
+				ctx,
+				row.Document, row.PermissionLevel, row.PermissionCreatedAt, row.PermissionLastModifiedAt,
+			)
 			if err != nil {
 				return nil, err
 			} else {
@@ -451,19 +1899,36 @@ func (dr *DocumentRepository) readDocuments(
 			ID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
 			Limit: pageSize,
 			PermissionsList: repoPermissionList,
+			FavoritesOnly: favoritesOnly,
+			CollectionID: repoCollectionId,
+			IncludeArchived: includeArchived,
 		}
-		rows, err := dr.queries.ListDocumentsByLastModifiedAt(ctx, params)
+		rows, err := queries.ListDocumentsByLastModifiedAt(ctx, params)
 		if err != nil {
-			return nil, service.RepoImpl("failed to retrieve document by principal", err)
+			return nil, repoImpl(
+				ctx,
+				"failed to retrieve document by principal",
+				err,
+				"principalId", principalId.String(),
+			)
 		}
 		for _, row := range rows {
-			documentPermission, err := parseDocumentPermission(row.Document, row.PermissionLevel)
+			documentPermission, err := parseDocumentPermission(
+				ctx,
+				row.Document, row.PermissionLevel, row.PermissionCreatedAt, row.PermissionLastModifiedAt,
+			)
 			if err != nil {
 				return nil, err
 			} else {
 				documentPermissionList = append(documentPermissionList, *documentPermission)
 			}
 		}
+	default:
+		// an unknown sort field would otherwise read no rows, which looks like the end of the
+		// traversal instead of an error
+		return nil, service.InvalidInput(
+			fmt.Sprintf("cursor sort field: %v does not map to any valid sort field", cursor.SortField), nil,
+		)
 	}
 	return documentPermissionList, nil
 }
@@ -473,6 +1938,9 @@ What does this function do:
 - parse the user input:
 	- cursor
 	- list of permissions
+	- whether to only read the favorites of the principal
+	- the collection to read the documents of, if any
+	- whether to include archived documents
 - read from the database based on the contents of the cursor
 - parse the returned values into a new format
 - construct a new cursor
@@ -482,52 +1950,643 @@ func (dr *DocumentRepository) ListDocumentsByPrincipal(
 	ctx context.Context,
 	principalId uuid.UUID, 
 	permissions []service.PermissionLevel,
+	favoritesOnly bool,
+	collectionId *uuid.UUID,
+	includeArchived bool,
 	cursor *service.Cursor,
 	pageSize int32,
-) (documentPermissions []service.DocumentPermission, cursorResp *service.Cursor, err error) {
+) (documentPermissions []service.DocumentPermission, cursorResp *service.Cursor, hasMore bool, err error) {
 	// determine the query parameters by parsing the cursor object
 	// assume that a default cursor will be constructed on the client side
 	// and we don't need to support the null cursor case
 	if cursor == nil {
 		// can return nil here for documents because nil is the zero value
 		// for the slice type. Slice operations can be made on nil
-		return nil, nil, service.ErrNilPointer
+		return nil, nil, false, service.ErrNilPointer
 	}
 	if len(permissions) < 1 {
-		return nil, nil, service.InvalidInput("expected at least one permission", nil)
+		return nil, nil, false, service.InvalidInput("expected at least one permission", nil)
 	}
 	repoPermissionsList := make([]sqlc.PermissionLevel, 0)
 	for _, permissionLevel := range permissions {
 		repoPermissionLevel, err := serviceToRepoPermissionLevel(permissionLevel)
 		if err != nil {
-			return nil, nil, service.InvalidInput(
+			return nil, nil, false, service.InvalidInput(
 				fmt.Sprintf("input permission: %v does not map to any valid permissions", permissionLevel), nil,
 			)
 		}
-		repoPermissionsList = append(repoPermissionsList, repoPermissionLevel)
+		repoPermissionsList = append(repoPermissionsList, repoPermissionLevel)
+	}
+	// readDocuments also rejects an unknown sort field, checking it here fails the request
+	// before a connection is acquired
+	if cursor.SortField != service.CreatedAt && cursor.SortField != service.LastModifiedAt {
+		return nil, nil, false, service.InvalidInput(
+			fmt.Sprintf("cursor sort field: %v does not map to any valid sort field", cursor.SortField), nil,
+		)
+	}
+	pageSize, err = checkPageSize(pageSize)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	cursorResp = &service.Cursor{
+		SortField: cursor.SortField,
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer release()
+	// read from the database, read one more row than the page size so that we can tell if
+	// there are more documents after this page without a second query
+	documentPermissions, err = readDocuments(
+		ctx, sqlc.New(conn), principalId, repoPermissionsList, favoritesOnly, collectionId, includeArchived, cursor, pageSize + 1,
+	)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if int32(len(documentPermissions)) > pageSize {
+		hasMore = true
+		documentPermissions = documentPermissions[:pageSize]
+	}
+	// populate the new cursor
+	if len(documentPermissions) > 0 {
+		if cursorResp.SortField == service.CreatedAt {
+			cursorResp.LastSeenTime = documentPermissions[len(documentPermissions) - 1].Document.CreatedAt
+		} else {
+			cursorResp.LastSeenTime = documentPermissions[len(documentPermissions) - 1].Document.LastModifiedAt
+		}
+		cursorResp.LastSeenID = documentPermissions[len(documentPermissions) - 1].Document.ID
+	} else {
+		cursorResp.LastSeenTime = cursor.LastSeenTime
+		cursorResp.LastSeenID = cursor.LastSeenID
+	}
+
+	return documentPermissions, cursorResp, hasMore, nil
+}
+
+// documents are read in ascending order of last modified at, the cursor holds the last
+// modified at time and id of the last document of the previous page
+func (dr *DocumentRepository) ListDocumentsModifiedSince(
+	ctx context.Context,
+	principalId uuid.UUID,
+	cursor *service.Cursor,
+	pageSize int32,
+) (documentPermissions []service.DocumentPermission, cursorResp *service.Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		return nil, nil, false, service.ErrNilPointer
+	}
+	pageSize, err = checkPageSize(pageSize)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer release()
+	// read one more row than the page size so that we can tell if there are more documents
+	// after this page without a second query
+	rows, err := sqlc.New(conn).ListDocumentsModifiedAfter(ctx, sqlc.ListDocumentsModifiedAfterParams{
+		RecipientID: pgtype.UUID{ Bytes: principalId, Valid: true },
+		LastModifiedAt: pgtype.Timestamptz{ Time: cursor.LastSeenTime, Valid: true },
+		ID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
+		Limit: pageSize + 1,
+	})
+	if err != nil {
+		return nil, nil, false, repoImpl(
+			ctx,
+			"failed to retrieve documents modified since cursor",
+			err,
+			"principalId", principalId.String(),
+		)
+	}
+	for _, row := range rows {
+		documentPermission, err := parseDocumentPermission(
+			ctx,
+			row.Document, row.PermissionLevel, row.PermissionCreatedAt, row.PermissionLastModifiedAt,
+		)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		documentPermissions = append(documentPermissions, *documentPermission)
+	}
+	if int32(len(documentPermissions)) > pageSize {
+		hasMore = true
+		documentPermissions = documentPermissions[:pageSize]
+	}
+	// populate the new cursor, an empty page keeps the cursor that was passed in so that the
+	// client can poll with it again later
+	cursorResp = &service.Cursor{
+		SortField: service.LastModifiedAt,
+		LastSeenTime: cursor.LastSeenTime,
+		LastSeenID: cursor.LastSeenID,
+	}
+	if len(documentPermissions) > 0 {
+		cursorResp.LastSeenTime = documentPermissions[len(documentPermissions) - 1].Document.LastModifiedAt
+		cursorResp.LastSeenID = documentPermissions[len(documentPermissions) - 1].Document.ID
+	}
+	return documentPermissions, cursorResp, hasMore, nil
+}
+
+// documents are read in reverse chronological order of creation, the cursor holds the created
+// at time and id of the last document of the previous page
+func (dr *DocumentRepository) ListSharedDocumentsByOwner(
+	ctx context.Context,
+	ownerId uuid.UUID,
+	cursor *service.Cursor,
+	pageSize int32,
+) (sharedDocuments []service.SharedDocument, cursorResp *service.Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		return nil, nil, false, service.ErrNilPointer
+	}
+	if cursor.SortField != service.CreatedAt {
+		return nil, nil, false, service.InvalidInput(
+			fmt.Sprintf("cursor sort field: %v is not supported for shared documents", cursor.SortField), nil,
+		)
+	}
+	pageSize, err = checkPageSize(pageSize)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer release()
+	// read one more row than the page size so that we can tell if there are more documents
+	// after this page without a second query
+	rows, err := sqlc.New(conn).ListSharedDocumentsByOwner(ctx, sqlc.ListSharedDocumentsByOwnerParams{
+		RecipientID: pgtype.UUID{ Bytes: ownerId, Valid: true },
+		CreatedAt: pgtype.Timestamptz{ Time: cursor.LastSeenTime, Valid: true },
+		ID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
+		Limit: pageSize + 1,
+	})
+	if err != nil {
+		return nil, nil, false, repoImpl(
+			ctx,
+			"failed to retrieve shared documents by owner",
+			err,
+			"principalId", ownerId.String(),
+		)
+	}
+	for _, row := range rows {
+		document, err := repositoryToServiceDocument(&row.Document)
+		if err != nil {
+			return nil, nil, false, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to parse document with documentId: %s", row.Document.ID.String()),
+				err,
+				"documentId", row.Document.ID.String(),
+				"principalId", ownerId.String(),
+			)
+		}
+		sharedDocuments = append(sharedDocuments, service.SharedDocument{
+			Document: *document,
+			CollaboratorCount: row.CollaboratorCount,
+		})
+	}
+	if int32(len(sharedDocuments)) > pageSize {
+		hasMore = true
+		sharedDocuments = sharedDocuments[:pageSize]
+	}
+	// populate the new cursor, an empty page keeps the cursor that was passed in
+	cursorResp = &service.Cursor{
+		SortField: service.CreatedAt,
+		LastSeenTime: cursor.LastSeenTime,
+		LastSeenID: cursor.LastSeenID,
+	}
+	if len(sharedDocuments) > 0 {
+		cursorResp.LastSeenTime = sharedDocuments[len(sharedDocuments) - 1].Document.CreatedAt
+		cursorResp.LastSeenID = sharedDocuments[len(sharedDocuments) - 1].Document.ID
+	}
+	return sharedDocuments, cursorResp, hasMore, nil
+}
+
+// documents are read in reverse chronological order of creation, the cursor holds the created
+// at time and id of the last document of the previous page
+func (dr *DocumentRepository) ListAllDocuments(
+	ctx context.Context,
+	cursor *service.Cursor,
+	pageSize int32,
+	filters service.DocumentFilters,
+) (documents []service.Document, cursorResp *service.Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		return nil, nil, false, service.ErrNilPointer
+	}
+	if cursor.SortField != service.CreatedAt {
+		return nil, nil, false, service.InvalidInput(
+			fmt.Sprintf("cursor sort field: %v is not supported for all documents", cursor.SortField), nil,
+		)
+	}
+	pageSize, err = checkPageSize(pageSize)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	params := sqlc.ListAllDocumentsParams{
+		CreatedAt: pgtype.Timestamptz{ Time: cursor.LastSeenTime, Valid: true },
+		ID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
+		// read one more row than the page size so that we can tell if there are more documents
+		// after this page without a second query
+		Limit: pageSize + 1,
+	}
+	if filters.OwnerID != nil {
+		params.OwnerID = pgtype.UUID{ Bytes: *filters.OwnerID, Valid: true }
+	}
+	if filters.CreatedBefore != nil {
+		params.CreatedBefore = pgtype.Timestamptz{ Time: *filters.CreatedBefore, Valid: true }
+	}
+	if filters.CreatedAfter != nil {
+		params.CreatedAfter = pgtype.Timestamptz{ Time: *filters.CreatedAfter, Valid: true }
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer release()
+	rows, err := sqlc.New(conn).ListAllDocuments(ctx, params)
+	if err != nil {
+		return nil, nil, false, repoImpl(ctx, "failed to retrieve all documents", err)
+	}
+	for _, row := range rows {
+		document, err := repositoryToServiceDocument(&row)
+		if err != nil {
+			return nil, nil, false, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to parse document with documentId: %s", row.ID.String()),
+				err,
+				"documentId", row.ID.String(),
+			)
+		}
+		documents = append(documents, *document)
+	}
+	if int32(len(documents)) > pageSize {
+		hasMore = true
+		documents = documents[:pageSize]
+	}
+	// populate the new cursor, an empty page keeps the cursor that was passed in
+	cursorResp = &service.Cursor{
+		SortField: service.CreatedAt,
+		LastSeenTime: cursor.LastSeenTime,
+		LastSeenID: cursor.LastSeenID,
+	}
+	if len(documents) > 0 {
+		cursorResp.LastSeenTime = documents[len(documents) - 1].CreatedAt
+		cursorResp.LastSeenID = documents[len(documents) - 1].ID
+	}
+	return documents, cursorResp, hasMore, nil
+}
+
+// the principals that read the document since the given time, most recent read first. The
+// viewers are read from the primary because the window is short, a replica that lags behind
+// the access writes would leave out the reads that the list is meant to show
+func (dr *DocumentRepository) GetDocumentViewers(
+	ctx context.Context,
+	documentId uuid.UUID,
+	since time.Time,
+	limit int32,
+) (viewers []service.DocumentViewer, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	rows, err := sqlc.New(conn).ListDocumentViewers(ctx, sqlc.ListDocumentViewersParams{
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		AccessedAt: pgtype.Timestamptz{ Time: since, Valid: true },
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			"failed to retrieve the viewers of the document",
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	viewers = make([]service.DocumentViewer, 0, len(rows))
+	for _, row := range rows {
+		recipientType, err := repoToServiceRecipientType(row.RecipientType)
+		if err != nil {
+			return nil, repoImpl(ctx, "failed to parse the recipient type of a viewer", err, "documentId", documentId.String())
+		}
+		permissionLevel, err := repoToServicePermissionLevel(row.PermissionLevel)
+		if err != nil {
+			return nil, repoImpl(ctx, "failed to parse the permission level of a viewer", err, "documentId", documentId.String())
+		}
+		viewers = append(viewers, service.DocumentViewer{
+			PrincipalID: uuid.UUID(row.PrincipalID.Bytes),
+			RecipientType: recipientType,
+			Permission: permissionLevel,
+			ViewedAt: row.AccessedAt.Time,
+		})
+	}
+	return viewers, nil
+}
+
+// documents are read most recently accessed first, the cursor holds the accessed at time and id
+// of the last document of the previous page
+func (dr *DocumentRepository) ListRecentlyAccessed(
+	ctx context.Context,
+	principalId uuid.UUID,
+	cursor *service.Cursor,
+	pageSize int32,
+) (accessedDocuments []service.AccessedDocument, cursorResp *service.Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		return nil, nil, false, service.ErrNilPointer
+	}
+	if cursor.SortField != service.AccessedAt {
+		return nil, nil, false, service.InvalidInput(
+			fmt.Sprintf("cursor sort field: %v is not supported for recently accessed documents", cursor.SortField), nil,
+		)
+	}
+	pageSize, err = checkPageSize(pageSize)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer release()
+	// read one more row than the page size so that we can tell if there are more documents
+	// after this page without a second query
+	rows, err := sqlc.New(conn).ListRecentlyAccessedDocuments(ctx, sqlc.ListRecentlyAccessedDocumentsParams{
+		PrincipalID: pgtype.UUID{ Bytes: principalId, Valid: true },
+		AccessedAt: pgtype.Timestamptz{ Time: cursor.LastSeenTime, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
+		Limit: pageSize + 1,
+	})
+	if err != nil {
+		return nil, nil, false, repoImpl(
+			ctx,
+			"failed to retrieve recently accessed documents",
+			err,
+			"principalId", principalId.String(),
+		)
+	}
+	for _, row := range rows {
+		documentPermission, err := parseDocumentPermission(
+			ctx, row.Document, row.PermissionLevel, pgtype.Timestamptz{}, pgtype.Timestamptz{},
+		)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		accessedDocuments = append(accessedDocuments, service.AccessedDocument{
+			Document: documentPermission.Document,
+			Permission: documentPermission.Permission,
+			AccessedAt: row.AccessedAt.Time,
+		})
+	}
+	if int32(len(accessedDocuments)) > pageSize {
+		hasMore = true
+		accessedDocuments = accessedDocuments[:pageSize]
+	}
+	// populate the new cursor, an empty page keeps the cursor that was passed in
+	cursorResp = &service.Cursor{
+		SortField: service.AccessedAt,
+		LastSeenTime: cursor.LastSeenTime,
+		LastSeenID: cursor.LastSeenID,
+	}
+	if len(accessedDocuments) > 0 {
+		cursorResp.LastSeenTime = accessedDocuments[len(accessedDocuments) - 1].AccessedAt
+		cursorResp.LastSeenID = accessedDocuments[len(accessedDocuments) - 1].Document.ID
+	}
+	return accessedDocuments, cursorResp, hasMore, nil
+}
+
+// changes are read in reverse chronological order, the cursor holds the time and id of the
+// last change of the previous page
+func (dr *DocumentRepository) ListDocumentHistory(
+	ctx context.Context,
+	documentId uuid.UUID,
+	cursor *service.Cursor,
+	pageSize int32,
+) (changes []service.DocumentChange, cursorResp *service.Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		return nil, nil, false, service.ErrNilPointer
+	}
+	if cursor.SortField != service.CreatedAt {
+		return nil, nil, false, service.InvalidInput(
+			fmt.Sprintf("cursor sort field: %v is not supported for document history", cursor.SortField), nil,
+		)
+	}
+	pageSize, err = checkPageSize(pageSize)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer release()
+	// read one more row than the page size so that we can tell if there are more changes
+	// after this page without a second query
+	rows, err := sqlc.New(conn).ListDocumentHistory(ctx, sqlc.ListDocumentHistoryParams{
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		ChangedAt: pgtype.Timestamptz{ Time: cursor.LastSeenTime, Valid: true },
+		ID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
+		Limit: pageSize + 1,
+	})
+	if err != nil {
+		return nil, nil, false, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to retrieve the history of document: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	for _, row := range rows {
+		changes = append(changes, repositoryToServiceDocumentChange(row))
+	}
+	if int32(len(changes)) > pageSize {
+		hasMore = true
+		changes = changes[:pageSize]
+	}
+	// populate the new cursor, an empty page keeps the cursor that was passed in
+	cursorResp = &service.Cursor{
+		SortField: service.CreatedAt,
+		LastSeenTime: cursor.LastSeenTime,
+		LastSeenID: cursor.LastSeenID,
+	}
+	if len(changes) > 0 {
+		cursorResp.LastSeenTime = changes[len(changes) - 1].ChangedAt
+		cursorResp.LastSeenID = changes[len(changes) - 1].ID
+	}
+	return changes, cursorResp, hasMore, nil
+}
+
+// found is false when the principal has no permission on the document, this is not an error
+// because authorization checks expect principals without a permission
+func (dr *DocumentRepository) GetPermissionLevel(
+	ctx context.Context,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+) (level service.PermissionLevel, found bool, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer release()
+	repoLevel, err := sqlc.New(conn).GetPermissionLevel(ctx, sqlc.GetPermissionLevelParams{
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		RecipientID: pgtype.UUID{ Bytes: principalId, Valid: true },
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, repoImpl(
+			ctx,
+			fmt.Sprintf(
+				"failed to get permission level for principal: %s on document: %s",
+				principalId.String(), documentId.String(),
+			),
+			err,
+			"documentId", documentId.String(), "principalId", principalId.String(),
+		)
+	}
+	level, err = repoToServicePermissionLevel(repoLevel)
+	if err != nil {
+		return 0, false, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to parse permission level: %v", repoLevel),
+			err,
+			"documentId", documentId.String(), "principalId", principalId.String(),
+		)
+	}
+	return level, true, nil
+}
+
+// the levels of the principal on a batch of documents are read with one query, documents that
+// the principal has no permission on are missing from the map
+// the ownership of the whole batch is read in one query so that checking a batch costs one
+// round trip instead of one per document
+func (dr *DocumentRepository) GetOwnedDocumentIds(
+	ctx context.Context,
+	ownerId uuid.UUID,
+	documentIds uuid.UUIDs,
+) (owned uuid.UUIDs, err error) {
+	repoDocumentIds := make([]pgtype.UUID, len(documentIds))
+	for i, documentId := range documentIds {
+		repoDocumentIds[i] = pgtype.UUID{ Bytes: documentId, Valid: true }
+	}
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	rows, err := sqlc.New(conn).GetOwnedDocumentIds(
+		ctx,
+		sqlc.GetOwnedDocumentIdsParams{
+			RecipientID: pgtype.UUID{ Bytes: ownerId, Valid: true },
+			DocumentIds: repoDocumentIds,
+		},
+	)
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to get the documents owned by principal: %s", ownerId.String()),
+			err,
+			"principalId", ownerId.String(),
+		)
+	}
+	owned = make(uuid.UUIDs, len(rows))
+	for i, row := range rows {
+		owned[i] = row.Bytes
+	}
+	return owned, nil
+}
+
+func (dr *DocumentRepository) GetPermissionLevelsForPrincipalOnDocuments(
+	ctx context.Context,
+	principalId uuid.UUID,
+	documentIds uuid.UUIDs,
+) (levels map[uuid.UUID]service.PermissionLevel, err error) {
+	repoDocumentIds := make([]pgtype.UUID, len(documentIds))
+	for i, documentId := range documentIds {
+		repoDocumentIds[i] = pgtype.UUID{ Bytes: documentId, Valid: true }
+	}
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	rows, err := sqlc.New(conn).GetPermissionLevelsForPrincipalOnDocuments(
+		ctx,
+		sqlc.GetPermissionLevelsForPrincipalOnDocumentsParams{
+			RecipientID: pgtype.UUID{ Bytes: principalId, Valid: true },
+			DocumentIds: repoDocumentIds,
+		},
+	)
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to get permission levels for principal: %s", principalId.String()),
+			err,
+			"principalId", principalId.String(),
+		)
+	}
+	levels = make(map[uuid.UUID]service.PermissionLevel, len(rows))
+	for _, row := range rows {
+		level, err := repoToServicePermissionLevel(row.PermissionLevel)
+		if err != nil {
+			return nil, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to parse permission level: %v", row.PermissionLevel),
+				err,
+				"principalId", principalId.String(),
+			)
+		}
+		levels[uuid.UUID(row.DocumentID.Bytes)] = level
 	}
-	cursorResp = &service.Cursor{
-		SortField: cursor.SortField,
+	return levels, nil
+}
+
+// the levels of a batch of principals on the document are read with one query, principals that
+// have no permission on the document are missing from the map
+func (dr *DocumentRepository) GetPermissionsForPrincipals(
+	ctx context.Context,
+	documentId uuid.UUID,
+	principalIds uuid.UUIDs,
+) (levels map[uuid.UUID]service.PermissionLevel, err error) {
+	repoPrincipalIds := make([]pgtype.UUID, len(principalIds))
+	for i, principalId := range principalIds {
+		repoPrincipalIds[i] = pgtype.UUID{ Bytes: principalId, Valid: true }
 	}
-	// read from the database
-	documentPermissions, err = dr.readDocuments(ctx, principalId, repoPermissionsList, cursor, pageSize)
+	ctx, conn, release, err := dr.acquire(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	// populate the new cursor
-	if len(documentPermissions) > 0 {
-		if cursorResp.SortField == service.CreatedAt {
-			cursorResp.LastSeenTime = documentPermissions[len(documentPermissions) - 1].Document.CreatedAt
-		} else {
-			cursorResp.LastSeenTime = documentPermissions[len(documentPermissions) - 1].Document.LastModifiedAt
+	defer release()
+	rows, err := sqlc.New(conn).GetPermissionLevelsOfPrincipalsOnDocument(
+		ctx,
+		sqlc.GetPermissionLevelsOfPrincipalsOnDocumentParams{
+			DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+			RecipientIds: repoPrincipalIds,
+		},
+	)
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to get permission levels of principals on document: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	levels = make(map[uuid.UUID]service.PermissionLevel, len(rows))
+	for _, row := range rows {
+		level, err := repoToServicePermissionLevel(row.PermissionLevel)
+		if err != nil {
+			return nil, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to parse permission level: %v", row.PermissionLevel),
+				err,
+				"documentId", documentId.String(),
+			)
 		}
-		cursorResp.LastSeenID = documentPermissions[len(documentPermissions) - 1].Document.ID
-	} else {
-		cursorResp.LastSeenTime = cursor.LastSeenTime
-		cursorResp.LastSeenID = cursor.LastSeenID
+		levels[uuid.UUID(row.RecipientID.Bytes)] = level
 	}
-
-	return documentPermissions, cursorResp, nil
+	return levels, nil
 }
 
 func (dr *DocumentRepository) GetPermissionOfPrincipalOnDocument(
@@ -541,7 +2600,13 @@ func (dr *DocumentRepository) GetPermissionOfPrincipalOnDocument(
 		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
 		RecipientID: pgtype.UUID{ Bytes: principalId, Valid: true },
 	}
-	row, err := dr.queries.GetPermissionOfPrincipalOnDocument(
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return service.Permission{}, err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	row, err := queries.GetPermissionOfPrincipalOnDocument(
 		ctx,
 		params,
 	)
@@ -557,17 +2622,19 @@ func (dr *DocumentRepository) GetPermissionOfPrincipalOnDocument(
 				err,
 			)
 		} else {
-			return service.Permission{}, service.RepoImpl(
+			return service.Permission{}, repoImpl(
+				ctx,
 				fmt.Sprintf(
 					"failed to get permission for principal: %s on document: %s",
 					principalId.String(),
 					documentId.String(),
 				),
 				err,
+				"documentId", documentId.String(), "principalId", principalId.String(),
 			)
 		}
 	}
-	return repoToServicePermission(row)
+	return repoToServicePermission(ctx, row)
 }
 
 func readPermissions(
@@ -577,7 +2644,13 @@ func readPermissions(
 	permissionFilter []sqlc.PermissionLevel,
 	cursor *service.Cursor,
 	maxPermissions int32,
+	excludedRecipientId *uuid.UUID,
 ) (repoPermissions []sqlc.Permission, err error) {
+	// the invalid uuid is sent as null, which excludes no permissions
+	var repoExcludedRecipientId pgtype.UUID
+	if excludedRecipientId != nil {
+		repoExcludedRecipientId = pgtype.UUID{ Bytes: *excludedRecipientId, Valid: true }
+	}
 	switch cursor.SortField {
 	case service.CreatedAt:
 		params := sqlc.ListPermissionOnDocumentCreatedAtParams{
@@ -586,10 +2659,16 @@ func readPermissions(
 			RecipientID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
 			Limit: maxPermissions,
 			PermissionsList: permissionFilter,
+			ExcludedRecipientID: repoExcludedRecipientId,
 		}
 		repoPermissions, err = txQueries.ListPermissionOnDocumentCreatedAt(ctx, params)
 		if err != nil {
-			return nil, service.RepoImpl(fmt.Sprintf("failed to retrieve permissions on document %s", documentId.String()), err)
+			return nil, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to retrieve permissions on document %s", documentId.String()),
+				err,
+				"documentId", documentId.String(),
+			)
 		}
 	case service.LastModifiedAt:
 		params := sqlc.ListPermissionOnDocumentLastModifiedAtParams{
@@ -598,44 +2677,118 @@ func readPermissions(
 			RecipientID: pgtype.UUID{ Bytes: cursor.LastSeenID, Valid: true },
 			Limit: maxPermissions,
 			PermissionsList: permissionFilter,
+			ExcludedRecipientID: repoExcludedRecipientId,
 		}
 		repoPermissions, err = txQueries.ListPermissionOnDocumentLastModifiedAt(ctx, params)
 		if err != nil {
-			return nil, service.RepoImpl(fmt.Sprintf("failed to retrieve permissions on document %s", documentId.String()), err)
+			return nil, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to retrieve permissions on document %s", documentId.String()),
+				err,
+				"documentId", documentId.String(),
+			)
 		}
+	default:
+		// an unknown sort field would otherwise read no rows, which looks like the end of the
+		// traversal instead of an error
+		return nil, service.InvalidInput(
+			fmt.Sprintf("cursor sort field: %v does not map to any valid sort field", cursor.SortField), nil,
+		)
 	}
 	return repoPermissions, nil
 }
 
+// set the label of each guest in the page of permissions, the labels are read with one query
+// for the whole page instead of joining the guests table into the pagination queries
+func readGuestLabels(
+	ctx context.Context,
+	txQueries *sqlc.Queries,
+	documentId uuid.UUID,
+	permissions []service.Permission,
+) error {
+	var guestIds []pgtype.UUID
+	for _, permission := range permissions {
+		if permission.RecipientType == service.Guest {
+			guestIds = append(guestIds, pgtype.UUID{ Bytes: permission.RecipientID, Valid: true })
+		}
+	}
+	if len(guestIds) == 0 {
+		return nil
+	}
+	rows, err := txQueries.ListGuestLabels(ctx, guestIds)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			fmt.Sprintf("failed to read the guest labels on document: %s", documentId.String()),
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	labels := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		labels[uuid.UUID(row.ID.Bytes)] = row.Label.String
+	}
+	for i := range permissions {
+		if label, ok := labels[permissions[i].RecipientID]; ok {
+			permissions[i].Label = &label
+		}
+	}
+	return nil
+}
+
+// a non nil excludedRecipientId leaves the permission of that principal out of the pages, for
+// example so that the caller is not listed among the principals they shared the document with
 func (dr *DocumentRepository) ListPermissionsOnDocument(
 	ctx context.Context,
 	documentId uuid.UUID,
 	permissionFilter []service.PermissionLevel,
 	cursor *service.Cursor,
 	pageSize int32,
-) (permissions []service.Permission, respCursor *service.Cursor, err error) {
+	excludedRecipientId *uuid.UUID,
+) (permissions []service.Permission, respCursor *service.Cursor, hasMore bool, err error) {
 	// check for an empty permissionFilter list
 	if len(permissionFilter) < 1 {
-		return nil, nil, service.InvalidInput("permission filter list is empty, need at least one valid permission", nil)
+		return nil, nil, false, service.InvalidInput("permission filter list is empty, need at least one valid permission", nil)
 	}
 	// parse the permission filters
 	repoPermissionFilter := make([]sqlc.PermissionLevel, len(permissionFilter))
 	for i, pl := range permissionFilter {
 		rpl, err := serviceToRepoPermissionLevel(pl)
 		if err != nil {
-			return nil, nil, service.InvalidInput("failed to parse permission filter", err)
+			return nil, nil, false, service.InvalidInput("failed to parse permission filter", err)
 		}
 		repoPermissionFilter[i] = rpl
 	}
 	// check for a nil cursor
 	if cursor == nil {
-		return nil, nil, service.ErrNilPointer
+		return nil, nil, false, service.ErrNilPointer
 	}
+	// readPermissions also rejects an unknown sort field, checking it here fails the request
+	// before a connection is acquired
+	if cursor.SortField != service.CreatedAt && cursor.SortField != service.LastModifiedAt {
+		return nil, nil, false, service.InvalidInput(
+			fmt.Sprintf("cursor sort field: %v does not map to any valid sort field", cursor.SortField), nil,
+		)
+	}
+	pageSize, err = checkPageSize(pageSize)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer release()
 	// create a transaction at the repeatable read level, this grantees that this transaction will not see
 	// the effects of another transaction that may be concurrently deleting the document.
-	tx, err := dr.pool.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.RepeatableRead })
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.RepeatableRead })
 	if err != nil {
-		return nil, nil, service.RepoImpl("failed to begin a database transaction", err)
+		return nil, nil, false, repoImpl(
+			ctx,
+			"failed to begin a database transaction",
+			err,
+			"documentId", documentId.String(),
+		)
 	}
 	defer tx.Rollback(ctx)
 	txQueries := dr.queries.WithTx(tx)
@@ -643,40 +2796,53 @@ func (dr *DocumentRepository) ListPermissionsOnDocument(
 	_, err = txQueries.GetDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil, service.NotFound(
+			return nil, nil, false, service.NotFound(
 				fmt.Sprintf("no document found with id %s", documentId.String()),
 				err,
 			)
 		} else {
-			return nil, nil, service.RepoImpl(
+			return nil, nil, false, repoImpl(
+				ctx,
 				fmt.Sprintf("error when trying to list permissions on document with id: %s", documentId.String()),
 				err,
+				"documentId", documentId.String(),
 			)
 		}
 	}
-	// get the recipient permission rows from the database
+	// get the recipient permission rows from the database, read one more row than the page size
+	// so that we can tell if there are more permissions after this page
 	repoPermissions, err := readPermissions(
-		ctx, txQueries, documentId, repoPermissionFilter, cursor, pageSize,
+		ctx, txQueries, documentId, repoPermissionFilter, cursor, pageSize + 1, excludedRecipientId,
 	)
 	// return errors if necessary
 	if err != nil {
-		return nil, nil, service.RepoImpl(
+		return nil, nil, false, repoImpl(
+			ctx,
 			fmt.Sprintf("failed to read permissions on document: %s", documentId.String()),
 			err,
-		)	
+			"documentId", documentId.String(),
+		)
+	}
+	if int32(len(repoPermissions)) > pageSize {
+		hasMore = true
+		repoPermissions = repoPermissions[:pageSize]
 	}
 	// reformat them from repo to service format
 	permissions = make([]service.Permission, len(repoPermissions))
 	for i, elem := range repoPermissions {
-		servicePermission, err := repoToServicePermission(elem)
+		servicePermission, err := repoToServicePermission(ctx, elem)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, false, err
 		}
 		permissions[i] = servicePermission
 	}
+	if err = readGuestLabels(ctx, txQueries, documentId, permissions); err != nil {
+		return nil, nil, false, err
+	}
 	// construct a return cursor
 	// if we retrieved previously unseen permissions, then update the cursor with the new permission 
-	// information, else, we update it with the previously seen cursor information
+	// information, else, we update it with the previously seen cursor information. Echoing the
+	// request cursor keeps it stable once the traversal is exhausted, hasMore is false in that case
 	respCursor = &service.Cursor{ SortField: cursor.SortField }
 	if len(permissions) > 0 {
 		respCursor.LastSeenID = permissions[len(permissions) - 1].RecipientID
@@ -690,70 +2856,129 @@ func (dr *DocumentRepository) ListPermissionsOnDocument(
 		respCursor.LastSeenID = cursor.LastSeenID
 		respCursor.LastSeenTime = cursor.LastSeenTime
 	}
-	return permissions, respCursor, nil
+	return permissions, respCursor, hasMore, nil
 }
 
-func (dr *DocumentRepository) CreateGuest(
-	ctx context.Context, 
-	creatorId uuid.UUID,
+// count the principals that hold one of the permission levels in the filter on the document
+func (dr *DocumentRepository) CountPermissionsOnDocument(
+	ctx context.Context,
 	documentId uuid.UUID,
-	permissionLevel service.PermissionLevel,
-) (guestId uuid.UUID, err error) {
-	// generate a new uuid for the guest
-	guestId = uuid.New()
-	repoPermission, err := serviceToRepoPermissionLevel(permissionLevel)
+	permissionFilter []service.PermissionLevel,
+) (count int64, err error) {
+	if len(permissionFilter) < 1 {
+		return 0, service.InvalidInput("permission filter list is empty, need at least one valid permission", nil)
+	}
+	repoPermissionFilter := make([]sqlc.PermissionLevel, len(permissionFilter))
+	for i, pl := range permissionFilter {
+		rpl, err := serviceToRepoPermissionLevel(pl)
+		if err != nil {
+			return 0, service.InvalidInput("failed to parse permission filter", err)
+		}
+		repoPermissionFilter[i] = rpl
+	}
+	ctx, conn, release, err := dr.acquireRead(ctx)
 	if err != nil {
-		return uuid.Nil, service.InvalidInput(
-			fmt.Sprintf("invalid input for permission: %v", permissionLevel),
+		return 0, err
+	}
+	defer release()
+	count, err = sqlc.New(conn).CountPermissionsOnDocument(ctx, sqlc.CountPermissionsOnDocumentParams{
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		PermissionsList: repoPermissionFilter,
+	})
+	if err != nil {
+		return 0, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to count permissions on document: %s", documentId.String()),
 			err,
+			"documentId", documentId.String(),
 		)
 	}
-	/*
-	- explicitly check if the document exists at the beginning of the transaction
-		- if the document does not exist, return a not found error
-		- this is preferable to parsing the foreign key missing error that we would get
-		  for inserting a guest to an invalid document because we know the error explicitly
-		  instead of guessing at the foreign key that is missing
-	*/
-	// get a transaction
-	tx, err := dr.pool.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.RepeatableRead })
+	return count, nil
+}
+
+// count the active documents of the principal at each permission level with one grouped query,
+// levels that the principal holds on no documents are missing from the map
+func (dr *DocumentRepository) CountDocumentsByPrincipalGrouped(
+	ctx context.Context,
+	principalId uuid.UUID,
+) (counts map[service.PermissionLevel]int64, err error) {
+	ctx, conn, release, err := dr.acquireRead(ctx)
 	if err != nil {
-		return uuid.Nil, service.RepoImpl("failed to create a transaction when creating a guest", err)
+		return nil, err
 	}
-	defer tx.Rollback(ctx)
-	txQueries := dr.queries.WithTx(tx)
-	// query the documents table to see if the document exists
-	_, err = txQueries.GetDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	defer release()
+	rows, err := sqlc.New(conn).CountDocumentsByPrincipalGrouped(ctx, pgtype.UUID{ Bytes: principalId, Valid: true })
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return uuid.Nil, service.NotFound(
-				fmt.Sprintf("the document with id: %v was not found", documentId.String()),
+		return nil, repoImpl(
+			ctx,
+			fmt.Sprintf("failed to count documents of principal: %s", principalId.String()),
+			err,
+			"principalId", principalId.String(),
+		)
+	}
+	counts = make(map[service.PermissionLevel]int64, len(rows))
+	for _, row := range rows {
+		level, err := repoToServicePermissionLevel(row.PermissionLevel)
+		if err != nil {
+			return nil, repoImpl(
+				ctx,
+				fmt.Sprintf("failed to parse permission level: %v", row.PermissionLevel),
 				err,
+				"principalId", principalId.String(),
 			)
-		} else {
-			return uuid.Nil, service.RepoImpl("failed to validate document id with database error", err)
 		}
+		counts[level] = row.DocumentCount
 	}
+	return counts, nil
+}
+
+// add a guest to the guests table and its permission to the permissions table, the caller
+// owns the transaction that txQueries belongs to. copiedFrom is the guest that this guest is a
+// copy of, it is not valid for a guest that is created directly
+func insertGuest(
+	ctx context.Context,
+	txQueries *sqlc.Queries,
+	creatorId uuid.UUID,
+	documentId uuid.UUID,
+	guestId uuid.UUID,
+	repoPermission sqlc.PermissionLevel,
+	label *string,
+	copiedFrom pgtype.UUID,
+) (err error) {
 	// add a new guest to the guests table
 	params := sqlc.CreateGuestParams{
 		ID: pgtype.UUID{ Bytes: guestId, Valid: true },
 		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
 		CreatedBy: pgtype.UUID{ Bytes: creatorId, Valid: true },
+		CopiedFrom: copiedFrom,
+	}
+	if label != nil {
+		params.Label = pgtype.Text{ String: *label, Valid: true }
 	}
 	err = txQueries.CreateGuest(ctx, params)
 	if err != nil {
 		var pgError *pgconn.PgError
 		if errors.As(err, &pgError) {
 			if pgError.Code == conflictErrorCode {
-				return uuid.Nil, service.UniqueConflict(
+				return service.UniqueConflict(
 					fmt.Sprintf("unique conflict encountered when creating guest with id: %s", guestId.String()),
 					err,
 				)
 			} else {
-				return uuid.Nil, service.RepoImpl("encountered a postgres error when trying to create a user", err)
+				return repoImpl(
+					ctx,
+					"encountered a postgres error when trying to create a user",
+					err,
+					"documentId", documentId.String(), "principalId", guestId.String(),
+				)
 			}
 		} else {
-			return uuid.Nil, service.RepoImpl("encountered an unexpected error when creating a user", err)
+			return repoImpl(
+				ctx,
+				"encountered an unexpected error when creating a user",
+				err,
+				"documentId", documentId.String(), "principalId", guestId.String(),
+			)
 		}
 	}
 	// add a new permission record to the permissions table associated with that guest
@@ -768,7 +2993,7 @@ func (dr *DocumentRepository) CreateGuest(
 		var pgError *pgconn.PgError
 		if errors.As(err, &pgError) {
 			if pgError.Code == conflictErrorCode {
-				return uuid.Nil, service.UniqueConflict(
+				return service.UniqueConflict(
 					fmt.Sprintf(
 						"unique conflict encountered when creating permission on document: %s, for guest with id: %s",
 						documentId.String(),
@@ -776,19 +3001,313 @@ func (dr *DocumentRepository) CreateGuest(
 					),
 					err,
 				)
-			} else {
-				return uuid.Nil, service.RepoImpl("encountered a postgres error when trying to create a permission", err)
+			} else {
+				return repoImpl(
+					ctx,
+					"encountered a postgres error when trying to create a permission",
+					err,
+					"documentId", documentId.String(), "principalId", guestId.String(),
+				)
+			}
+		} else {
+			return repoImpl(
+				ctx,
+				"encountered an unexpected error when creating a permission",
+				err,
+				"documentId", documentId.String(), "principalId", guestId.String(),
+			)
+		}
+	}
+	return nil
+}
+
+// lock the document so that concurrent guest creation on it is serialized, then check that it
+// is active and that adding count guests keeps it within maxGuests. The caller must use a read
+// committed transaction, a repeatable read snapshot is taken before the lock is granted and
+// would not see the guests created by the transaction that held the lock
+func lockDocumentForGuests(
+	ctx context.Context,
+	txQueries *sqlc.Queries,
+	documentId uuid.UUID,
+	count int32,
+	maxGuests int32,
+) (err error) {
+	repoDocumentId := pgtype.UUID{ Bytes: documentId, Valid: true }
+	repoDocument, err := txQueries.GetDocumentForUpdate(ctx, repoDocumentId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return service.NotFound(
+				fmt.Sprintf("the document with id: %v was not found", documentId.String()),
+				err,
+			)
+		}
+		return repoImpl(
+			ctx,
+			"failed to validate document id with database error",
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	if err = checkDocumentActive(repoDocument); err != nil {
+		return err
+	}
+	existing, err := txQueries.CountGuestPermissionsOnDocument(ctx, repoDocumentId)
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to count the guests on document with database error",
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	if existing + int64(count) > int64(maxGuests) {
+		return service.QuotaExceeded(
+			fmt.Sprintf(
+				"document: %s has %d of at most %d guests, cannot create %d more",
+				documentId.String(), existing, maxGuests, count,
+			),
+			nil,
+		)
+	}
+	return nil
+}
+
+func (dr *DocumentRepository) CreateGuest(
+	ctx context.Context, 
+	creatorId uuid.UUID,
+	documentId uuid.UUID,
+	permissionLevel service.PermissionLevel,
+	label *string,
+	maxGuests int32,
+) (guestId uuid.UUID, err error) {
+	// generate a new uuid for the guest
+	guestId = uuid.New()
+	repoPermission, err := serviceToRepoPermissionLevel(permissionLevel)
+	if err != nil {
+		return uuid.Nil, service.InvalidInput(
+			fmt.Sprintf("invalid input for permission: %v", permissionLevel),
+			err,
+		)
+	}
+	/*
+	- explicitly check if the document exists at the beginning of the transaction
+		- if the document does not exist, return a not found error
+		- this is preferable to parsing the foreign key missing error that we would get
+		  for inserting a guest to an invalid document because we know the error explicitly
+		  instead of guessing at the foreign key that is missing
+	*/
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer release()
+	// get a transaction, read committed so that the guest count is read after the document lock
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.ReadCommitted })
+	if err != nil {
+		return uuid.Nil, repoImpl(
+			ctx,
+			"failed to create a transaction when creating a guest",
+			err,
+			"documentId", documentId.String(), "principalId", guestId.String(),
+		)
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	if err = lockDocumentForGuests(ctx, txQueries, documentId, 1, maxGuests); err != nil {
+		return uuid.Nil, err
+	}
+	if err = insertGuest(ctx, txQueries, creatorId, documentId, guestId, repoPermission, label, pgtype.UUID{}); err != nil {
+		return uuid.Nil, err
+	}
+	// commit the transaction
+	err = tx.Commit(ctx)
+	if err != nil {
+		return uuid.Nil, repoImpl(
+			ctx,
+			"failed to commit transaction",
+			err,
+			"documentId", documentId.String(), "principalId", guestId.String(),
+		)
+	}
+	return guestId, nil
+}
+
+// create count guests and their permissions in one transaction, either every guest is created
+// or none are
+func (dr *DocumentRepository) CreateGuests(
+	ctx context.Context,
+	creatorId uuid.UUID,
+	documentId uuid.UUID,
+	count int32,
+	permissionLevel service.PermissionLevel,
+	maxGuests int32,
+) (guestIds []uuid.UUID, err error) {
+	if count < 1 {
+		return nil, service.InvalidInput(fmt.Sprintf("count must be at least 1, got: %d", count), nil)
+	}
+	repoPermission, err := serviceToRepoPermissionLevel(permissionLevel)
+	if err != nil {
+		return nil, service.InvalidInput(
+			fmt.Sprintf("invalid input for permission: %v", permissionLevel),
+			err,
+		)
+	}
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// read committed so that the guest count is read after the document lock, see
+	// lockDocumentForGuests
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.ReadCommitted })
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			"failed to create a transaction when creating guests",
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	if err = lockDocumentForGuests(ctx, txQueries, documentId, count, maxGuests); err != nil {
+		return nil, err
+	}
+	guestIds = make([]uuid.UUID, count)
+	for i := range guestIds {
+		guestIds[i] = uuid.New()
+		err = insertGuest(ctx, txQueries, creatorId, documentId, guestIds[i], repoPermission, nil, pgtype.UUID{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return nil, repoImpl(
+			ctx,
+			"failed to commit transaction",
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	return guestIds, nil
+}
+
+// copy the permissions of the source document onto the target document in one transaction. A
+// guest belongs to one document, so each guest of the source is copied as a new guest of the
+// target with the same level and label. Users that already have a permission or a pending
+// share on the target and guests that were already copied onto the target are skipped, a
+// scheduled downgrade is copied with its permission
+func (dr *DocumentRepository) CopyPermissions(
+	ctx context.Context,
+	creatorId uuid.UUID,
+	sourceDocumentId uuid.UUID,
+	targetDocumentId uuid.UUID,
+	maxGuests int32,
+) (copied int, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	// read committed so that the guest count is read after the document lock, see
+	// lockDocumentForGuests
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.ReadCommitted })
+	if err != nil {
+		return 0, repoImpl(
+			ctx,
+			"failed to create a transaction when copying permissions",
+			err,
+			"sourceDocumentId", sourceDocumentId.String(), "targetDocumentId", targetDocumentId.String(),
+		)
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	rows, err := txQueries.ListCopyablePermissionsOnDocument(ctx, sqlc.ListCopyablePermissionsOnDocumentParams{
+		DocumentID: pgtype.UUID{ Bytes: sourceDocumentId, Valid: true },
+		TargetDocumentID: pgtype.UUID{ Bytes: targetDocumentId, Valid: true },
+	})
+	if err != nil {
+		return 0, repoImpl(
+			ctx,
+			"failed to read the permissions of the source document",
+			err,
+			"sourceDocumentId", sourceDocumentId.String(),
+		)
+	}
+	var guestCount int32
+	for _, row := range rows {
+		if row.RecipientType == sqlc.RecipientTypeGuest {
+			guestCount++
+		}
+	}
+	// the copied guests count towards the guest limit of the target
+	if err = lockDocumentForGuests(ctx, txQueries, targetDocumentId, guestCount, maxGuests); err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		// a copied guest is a new guest on the target that remembers the guest it was copied
+		// from, so that copying again skips it
+		recipientId := row.RecipientID
+		if row.RecipientType == sqlc.RecipientTypeGuest {
+			var label *string
+			if row.Label.Valid {
+				label = &row.Label.String
+			}
+			recipientId = pgtype.UUID{ Bytes: uuid.New(), Valid: true }
+			err = insertGuest(
+				ctx, txQueries, creatorId, targetDocumentId, recipientId.Bytes, row.PermissionLevel, label, row.RecipientID,
+			)
+			if err != nil {
+				return 0, err
+			}
+		} else {
+			inserted, err := txQueries.InsertPermissionUserIfAbsent(ctx, sqlc.InsertPermissionUserIfAbsentParams{
+				RecipientID: row.RecipientID,
+				DocumentID: pgtype.UUID{ Bytes: targetDocumentId, Valid: true },
+				PermissionLevel: row.PermissionLevel,
+				CreatedBy: pgtype.UUID{ Bytes: creatorId, Valid: true },
+			})
+			if err != nil {
+				return 0, repoImpl(
+					ctx,
+					"failed to copy the permission of a user",
+					err,
+					"targetDocumentId", targetDocumentId.String(), "principalId", uuid.UUID(row.RecipientID.Bytes).String(),
+				)
+			}
+			// a user that already has a permission on the target keeps it and its schedule
+			if inserted == 0 {
+				continue
+			}
+		}
+		copied++
+		// a temporary permission stays temporary on the copy
+		if row.DowngradeTo.Valid {
+			_, err = txQueries.SchedulePermissionDowngrade(ctx, sqlc.SchedulePermissionDowngradeParams{
+				RecipientID: recipientId,
+				DocumentID: pgtype.UUID{ Bytes: targetDocumentId, Valid: true },
+				DowngradeTo: row.DowngradeTo,
+				DowngradeAt: row.DowngradeAt,
+			})
+			if err != nil {
+				return 0, repoImpl(
+					ctx,
+					"failed to copy the scheduled downgrade of a permission",
+					err,
+					"targetDocumentId", targetDocumentId.String(), "principalId", uuid.UUID(recipientId.Bytes).String(),
+				)
 			}
-		} else {
-			return uuid.Nil, service.RepoImpl("encountered an unexpected error when creating a permission", err)
 		}
 	}
-	// commit the transaction
-	err = tx.Commit(ctx)
-	if err != nil {
-		return uuid.Nil, service.RepoImpl("failed to commit transaction", err)
+	if err = tx.Commit(ctx); err != nil {
+		return 0, repoImpl(
+			ctx,
+			"failed to commit transaction",
+			err,
+			"sourceDocumentId", sourceDocumentId.String(), "targetDocumentId", targetDocumentId.String(),
+		)
 	}
-	return guestId, nil
+	return copied, nil
 }
 
 func (dr *DocumentRepository) UpsertPermissionUser(
@@ -796,11 +3315,11 @@ func (dr *DocumentRepository) UpsertPermissionUser(
 	userId uuid.UUID, 
 	documentId uuid.UUID, 
 	permissionLevel service.PermissionLevel,
-) (err error) {
+) (created bool, err error) {
 	repoPermission, err := serviceToRepoPermissionLevel(permissionLevel)
 	if err != nil {
-		return service.InvalidInput(
-			fmt.Sprintf("invalid input for permission: %d", permissionLevel),
+		return false, service.InvalidInput(
+			fmt.Sprintf("invalid input for permission: %v", permissionLevel),
 			err,
 		)
 	}
@@ -816,37 +3335,326 @@ func (dr *DocumentRepository) UpsertPermissionUser(
 		- check if the document exists
 		- if not, return a not found error
 	*/
-	tx, err := dr.pool.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.RepeatableRead })
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.RepeatableRead })
 	if err != nil {
-		return service.RepoImpl("failed to create a transaction when creating a guest", err)
+		return false, repoImpl(
+			ctx,
+			"failed to create a transaction when creating a guest",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
 	}
 	defer tx.Rollback(ctx)
 	txQueries := dr.queries.WithTx(tx)
 	// query the documents table to see if the document exists
-	_, err = txQueries.GetDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	repoDocument, err := txQueries.GetDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return service.NotFound(
+			return false, service.NotFound(
 				fmt.Sprintf("the permission on document %v cannot be updated because it is not found", documentId.String()),
 				err,
 			)
 		} else {
-			return service.RepoImpl("failed to validate that this document exists", err)
+			return false, repoImpl(
+				ctx,
+				"failed to validate that this document exists",
+				err,
+				"documentId", documentId.String(), "principalId", userId.String(),
+			)
 		}
 	}
+	if err = checkDocumentActive(repoDocument); err != nil {
+		return false, err
+	}
 	params := sqlc.UpsertPermissionUserParams{
 		RecipientID: pgtype.UUID{ Bytes: userId, Valid: true },
 		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
 		PermissionLevel: repoPermission,
 		CreatedBy: pgtype.UUID{ Bytes: userId, Valid: true },
 	}
-	err = txQueries.UpsertPermissionUser(ctx, params)
+	created, err = txQueries.UpsertPermissionUser(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// the user already has this permission level, the upsert skipped the update so that
+		// last modified at is unchanged
+		return false, nil
+	}
+	if isOwnerConflict(err) {
+		return false, ownerConflict(documentId, err)
+	}
+	if err != nil {
+		return false, repoImpl(
+			ctx,
+			"failed to update user permission",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, repoImpl(
+			ctx,
+			"failed to commit transaction",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
+	}
+	return created, nil
+}
+
+// upsert the permissions of a batch of users in one statement, the document is checked to be
+// active in the same transaction. The recipients whose permission was created or changed are
+// in the map, created is false for a recipient whose existing permission was updated.
+// Recipients that already held the level they were granted are not in the map
+func (dr *DocumentRepository) UpsertPermissionsUser(
+	ctx context.Context,
+	creatorId uuid.UUID,
+	documentId uuid.UUID,
+	shares []service.UserShare,
+) (created map[uuid.UUID]bool, err error) {
+	recipientIds := make([]pgtype.UUID, len(shares))
+	permissionLevels := make([]string, len(shares))
+	for i, share := range shares {
+		repoPermission, err := serviceToRepoPermissionLevel(share.PermissionLevel)
+		if err != nil {
+			return nil, service.InvalidInput(
+				fmt.Sprintf("invalid input for permission: %v", share.PermissionLevel),
+				err,
+			)
+		}
+		recipientIds[i] = pgtype.UUID{ Bytes: share.UserID, Valid: true }
+		permissionLevels[i] = string(repoPermission)
+	}
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.RepeatableRead })
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			"failed to create a transaction when upserting user permissions",
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	repoDocument, err := txQueries.GetDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.NotFound(
+				fmt.Sprintf("the permissions on document %v cannot be updated because it is not found", documentId.String()),
+				err,
+			)
+		}
+		return nil, repoImpl(
+			ctx,
+			"failed to validate that this document exists",
+			err,
+			"documentId", documentId.String(),
+		)
+	}
+	if err = checkDocumentActive(repoDocument); err != nil {
+		return nil, err
+	}
+	rows, err := txQueries.UpsertPermissionsUser(ctx, sqlc.UpsertPermissionsUserParams{
+		RecipientIds: recipientIds,
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		PermissionLevels: permissionLevels,
+		CreatedBy: pgtype.UUID{ Bytes: creatorId, Valid: true },
+	})
+	if isOwnerConflict(err) {
+		return nil, ownerConflict(documentId, err)
+	}
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			"failed to upsert user permissions",
+			err,
+			"documentId", documentId.String(), "principalId", creatorId.String(),
+		)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return nil, repoImpl(
+			ctx,
+			"failed to commit transaction",
+			err,
+			"documentId", documentId.String(), "principalId", creatorId.String(),
+		)
+	}
+	created = make(map[uuid.UUID]bool, len(rows))
+	for _, row := range rows {
+		created[uuid.UUID(row.RecipientID.Bytes)] = row.Inserted
+	}
+	return created, nil
+}
+
+// offer the user a permission on the document that grants no access until the user accepts it
+func (dr *DocumentRepository) InvitePermissionUser(
+	ctx context.Context,
+	creatorId uuid.UUID,
+	userId uuid.UUID,
+	documentId uuid.UUID,
+	permissionLevel service.PermissionLevel,
+) (err error) {
+	repoPermission, err := serviceToRepoPermissionLevel(permissionLevel)
+	if err != nil {
+		return service.InvalidInput(
+			fmt.Sprintf("invalid input for permission: %v", permissionLevel),
+			err,
+		)
+	}
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{ IsoLevel: pgx.RepeatableRead })
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to create a transaction when inviting a user",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
+	}
+	defer tx.Rollback(ctx)
+	txQueries := dr.queries.WithTx(tx)
+	repoDocument, err := txQueries.GetDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return service.NotFound(
+				fmt.Sprintf("the user cannot be invited to document %v because it is not found", documentId.String()),
+				err,
+			)
+		}
+		return repoImpl(
+			ctx,
+			"failed to validate that this document exists",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
+	}
+	if err = checkDocumentActive(repoDocument); err != nil {
+		return err
+	}
+	count, err := txQueries.InsertPendingPermissionUser(ctx, sqlc.InsertPendingPermissionUserParams{
+		RecipientID: pgtype.UUID{ Bytes: userId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+		PermissionLevel: repoPermission,
+		CreatedBy: pgtype.UUID{ Bytes: creatorId, Valid: true },
+	})
+	if isOwnerConflict(err) {
+		return ownerConflict(documentId, err)
+	}
 	if err != nil {
-		return service.RepoImpl("failed to update user permission", err)
+		return repoImpl(
+			ctx,
+			"failed to create a pending share",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
+	}
+	if count < 1 {
+		return service.UniqueConflict(
+			fmt.Sprintf(
+				"user: %s already has a permission or a pending share on document: %s",
+				userId.String(), documentId.String(),
+			),
+			nil,
+		)
 	}
 	err = tx.Commit(ctx)
 	if err != nil {
-		return service.RepoImpl("failed to commit transaction", err)
+		return repoImpl(
+			ctx,
+			"failed to commit transaction",
+			err,
+			"documentId", documentId.String(), "principalId", userId.String(),
+		)
+	}
+	return nil
+}
+
+func (dr *DocumentRepository) ListPendingShares(
+	ctx context.Context,
+	principalId uuid.UUID,
+	limit int32,
+) (pendingShares []service.PendingShare, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	rows, err := queries.ListPendingSharesByPrincipal(ctx, sqlc.ListPendingSharesByPrincipalParams{
+		RecipientID: pgtype.UUID{ Bytes: principalId, Valid: true },
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, repoImpl(
+			ctx,
+			"failed to list the pending shares of principal",
+			err,
+			"principalId", principalId.String(),
+		)
+	}
+	pendingShares = make([]service.PendingShare, 0, len(rows))
+	for _, row := range rows {
+		documentPermission, err := parseDocumentPermission(
+			ctx,
+			row.Document, row.PermissionLevel, row.PermissionCreatedAt, row.PermissionLastModifiedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		pendingShares = append(pendingShares, service.PendingShare{
+			Document: documentPermission.Document,
+			Permission: documentPermission.Permission,
+			SharedBy: row.CreatedBy.Bytes,
+			SharedAt: row.PermissionCreatedAt.Time,
+		})
+	}
+	return pendingShares, nil
+}
+
+func (dr *DocumentRepository) AcceptPendingShare(
+	ctx context.Context,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+) (err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	count, err := queries.AcceptPendingShare(ctx, sqlc.AcceptPendingShareParams{
+		RecipientID: pgtype.UUID{ Bytes: principalId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+	})
+	if err != nil {
+		return repoImpl(
+			ctx,
+			"failed to accept the pending share",
+			err,
+			"documentId", documentId.String(), "principalId", principalId.String(),
+		)
+	}
+	if count < 1 {
+		return service.NotFound(
+			fmt.Sprintf(
+				"no pending share on document: %s found for principal: %s",
+				documentId.String(), principalId.String(),
+			),
+			nil,
+		)
 	}
 	return nil
 }
@@ -859,7 +3667,7 @@ func (dr *DocumentRepository) UpdatePermissionGuest(
 	permissionRepo, err := serviceToRepoPermissionLevel(permissionLevel)
 	if err != nil {
 		return service.InvalidInput(
-			fmt.Sprintf("invalid input received for permission: %d", permissionLevel),
+			fmt.Sprintf("invalid input received for permission: %v", permissionLevel),
 			err,
 		)
 	}
@@ -867,7 +3675,13 @@ func (dr *DocumentRepository) UpdatePermissionGuest(
 	// being deleted while we are making the update is a not found error. This will already happen
 	// because deleting the guest will delete its record from the 
 	// read the guest record from the guests table to find the document id
-	guest, err := dr.queries.SelectGuest(ctx, pgtype.UUID{ Bytes: guestId, Valid: true })
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	guest, err := queries.SelectGuest(ctx, pgtype.UUID{ Bytes: guestId, Valid: true })
 	if err != nil {
 		// check the error type, return not found error for no rows returned 
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -879,8 +3693,22 @@ func (dr *DocumentRepository) UpdatePermissionGuest(
 			)
 		} else {
 			// or repo implementation error otherwise
-			return service.RepoImpl("failed to read guest information", err)
+			return repoImpl(ctx, "failed to read guest information", err, "principalId", guestId.String())
+		}
+	}
+	// the permissions of guests on an archived document cannot be changed
+	repoDocument, err := queries.GetDocument(ctx, guest.DocumentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return service.NotFound(
+				fmt.Sprintf("the document of guest: %v was not found", guestId.String()),
+				err,
+			)
 		}
+		return repoImpl(ctx, "failed to read the document of the guest", err, "principalId", guestId.String())
+	}
+	if err = checkDocumentActive(repoDocument); err != nil {
+		return err
 	}
 	// then update the permission associated with this guest
 	// reading the documentId here keeps the interface cleaner than it would be if the calling
@@ -890,9 +3718,12 @@ func (dr *DocumentRepository) UpdatePermissionGuest(
 		DocumentID: guest.DocumentID,
 		PermissionLevel: permissionRepo,
 	}
-	count, err := dr.queries.UpdatePermissionGuest(ctx, params)
+	count, err := queries.UpdatePermissionGuest(ctx, params)
+	if isOwnerConflict(err) {
+		return ownerConflict(guest.DocumentID.Bytes, err)
+	}
 	if err != nil {
-		return service.RepoImpl("failed to update guest permissions", err)
+		return repoImpl(ctx, "failed to update guest permissions", err, "principalId", guestId.String())
 	}
 	if count < 1 {
 		return service.NotFound(
@@ -907,6 +3738,109 @@ func (dr *DocumentRepository) UpdatePermissionGuest(
 	return nil
 }
 
+// a nil label clears the label of the guest. The guest must belong to the document
+func (dr *DocumentRepository) UpdateGuestLabel(
+	ctx context.Context,
+	documentId uuid.UUID,
+	guestId uuid.UUID,
+	label *string,
+) (err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	// the guests of an archived document cannot be changed
+	repoDocument, err := queries.GetDocument(ctx, pgtype.UUID{ Bytes: documentId, Valid: true })
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return service.NotFound(
+				fmt.Sprintf("no document found with id %s", documentId.String()),
+				err,
+			)
+		}
+		return repoImpl(
+			ctx, "failed to read the document of the guest", err,
+			"documentId", documentId.String(), "principalId", guestId.String(),
+		)
+	}
+	if err = checkDocumentActive(repoDocument); err != nil {
+		return err
+	}
+	params := sqlc.UpdateGuestLabelParams{
+		ID: pgtype.UUID{ Bytes: guestId, Valid: true },
+		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
+	}
+	if label != nil {
+		params.Label = pgtype.Text{ String: *label, Valid: true }
+	}
+	count, err := queries.UpdateGuestLabel(ctx, params)
+	if err != nil {
+		return repoImpl(
+			ctx, "failed to update the label of the guest", err,
+			"documentId", documentId.String(), "principalId", guestId.String(),
+		)
+	}
+	if count < 1 {
+		return service.NotFound(
+			fmt.Sprintf(
+				"unable to find guest: %s on document: %s",
+				guestId.String(),
+				documentId.String(),
+			),
+			nil,
+		)
+	}
+	return nil
+}
+
+// the token version is read from the primary so that a token is rejected as soon as the link
+// of its guest was rotated
+func (dr *DocumentRepository) GetGuest(
+	ctx context.Context,
+	guestId uuid.UUID,
+) (documentId uuid.UUID, tokenVersion int32, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	defer release()
+	guest, err := sqlc.New(conn).SelectGuest(ctx, pgtype.UUID{ Bytes: guestId, Valid: true })
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, 0, service.NotFound(
+				fmt.Sprintf("unable to find a guest with guestId: %v", guestId.String()),
+				err,
+			)
+		}
+		return uuid.Nil, 0, repoImpl(ctx, "failed to read guest information", err, "principalId", guestId.String())
+	}
+	return guest.DocumentID.Bytes, guest.TokenVersion, nil
+}
+
+func (dr *DocumentRepository) RotateGuestTokenVersion(
+	ctx context.Context,
+	guestId uuid.UUID,
+) (tokenVersion int32, err error) {
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	tokenVersion, err = sqlc.New(conn).RotateGuestTokenVersion(ctx, pgtype.UUID{ Bytes: guestId, Valid: true })
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, service.NotFound(
+				fmt.Sprintf("unable to find a guest with guestId: %v", guestId.String()),
+				err,
+			)
+		}
+		return 0, repoImpl(ctx, "failed to rotate the token version of the guest", err, "principalId", guestId.String())
+	}
+	return tokenVersion, nil
+}
+
 func (dr *DocumentRepository) DeletePermissionsPrincipal(
 	ctx context.Context,
 	recipientId uuid.UUID,
@@ -921,15 +3855,23 @@ func (dr *DocumentRepository) DeletePermissionsPrincipal(
 		RecipientID: pgtype.UUID{ Bytes: recipientId, Valid: true },
 		DocumentID: pgtype.UUID{ Bytes: documentId, Valid: true },
 	}
-	count, err := dr.queries.DeletePermissionPrincipal(ctx, params)
+	ctx, conn, release, err := dr.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	count, err := queries.DeletePermissionPrincipal(ctx, params)
 	if err != nil {
-		return service.RepoImpl(
+		return repoImpl(
+			ctx,
 			fmt.Sprintf(
 				"error encountered when deleting permissions of %s on document %s",
 				recipientId.String(),
 				documentId.String(),
 			),
 			err,
+			"documentId", documentId.String(), "principalId", recipientId.String(),
 		)
 	}
 	if count < 1 {
@@ -0,0 +1,278 @@
+package document_repository_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/repository"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise soft deletion of documents:
+- archived documents can still be read but cannot be mutated
+- mutations on an archived document return a gone error instead of a not found error
+- restoring an archived document allows it to be mutated again
+- archived documents are only listed by principal when they are requested
+*/
+
+// create a document and archive it, returns the id of the document and the id of its owner
+func createArchivedDocument(t *testing.T, documentService *service.DocumentService) (uuid.UUID, uuid.UUID) {
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	err = documentService.ArchiveDocument(t.Context(), ownerId, documentId)
+	if err != nil {
+		t.Fatalf("failed to archive document with error: %v", err)
+	}
+	return documentId, ownerId
+}
+
+func TestArchiveDocument_GetDocument_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId := createArchivedDocument(t, documentService)
+	document, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get archived document with error: %v", err)
+	}
+	if document.ArchivedAt == nil {
+		t.Errorf("expected the archived at time of an archived document to be set")
+	}
+	// archiving an archived document keeps the original archived at time
+	err = documentService.ArchiveDocument(t.Context(), ownerId, documentId)
+	if err != nil {
+		t.Fatalf("failed to archive an archived document with error: %v", err)
+	}
+	rearchived, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get archived document with error: %v", err)
+	}
+	if rearchived.ArchivedAt == nil || !rearchived.ArchivedAt.Equal(*document.ArchivedAt) {
+		t.Errorf("want archived at: %v, got: %v", document.ArchivedAt, rearchived.ArchivedAt)
+	}
+}
+
+func TestArchiveDocument_NotFound_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	// the caller has no permission on a document that does not exist
+	err := documentService.ArchiveDocument(t.Context(), uuid.New(), uuid.New())
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want permission denied error when archiving a missing document, got: %v", err)
+	}
+}
+
+func TestArchiveDocument_NotOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	var permissionDenied *service.PermissionDeniedError
+	// only the owner can archive the document
+	err := documentService.ArchiveDocument(t.Context(), editorId, documentId)
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want permission denied error when an editor archives the document, got: %v", err)
+	}
+	document, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	if document.ArchivedAt != nil {
+		t.Errorf("expected the document not to be archived by an editor")
+	}
+	// only the owner can restore the document
+	if err = documentService.ArchiveDocument(t.Context(), ownerId, documentId); err != nil {
+		t.Fatalf("failed to archive document with error: %v", err)
+	}
+	err = documentService.RestoreDocument(t.Context(), editorId, documentId)
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want permission denied error when an editor restores the document, got: %v", err)
+	}
+}
+
+func TestArchiveDocument_UpdateDocument_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId := createArchivedDocument(t, documentService)
+	name := "archived"
+	err := documentService.UpdateDocument(t.Context(), documentId, ownerId, &name, nil, false, false)
+	var goneErr *service.GoneError
+	if !errors.As(err, &goneErr) {
+		t.Fatalf("want gone error when updating an archived document, got: %v", err)
+	}
+	// updating a document that never existed is still a not found error
+	err = documentService.UpdateDocument(t.Context(), uuid.New(), ownerId, &name, nil, false, false)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want not found error when updating a missing document, got: %v", err)
+	}
+}
+
+func TestArchiveDocument_UpsertPermissionUser_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId := createArchivedDocument(t, documentService)
+	recipientId := uuid.New()
+	_, err := documentService.UpsertPermissionUser(t.Context(), ownerId, recipientId, documentId, service.Viewer)
+	var goneErr *service.GoneError
+	if !errors.As(err, &goneErr) {
+		t.Fatalf("want gone error when sharing an archived document, got: %v", err)
+	}
+	// verify that no permission was created for the recipient
+	_, err = documentService.GetPermissionOfPrincipalOnDocument(t.Context(), recipientId, documentId, recipientId, nil)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want not found error for the recipient, got: %v", err)
+	}
+}
+
+func TestArchiveDocument_CreateGuest_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId := createArchivedDocument(t, documentService)
+	_, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	var goneErr *service.GoneError
+	if !errors.As(err, &goneErr) {
+		t.Errorf("want gone error when creating a guest on an archived document, got: %v", err)
+	}
+}
+
+func TestArchiveDocument_UpdatePermissionGuest_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	err = documentService.ArchiveDocument(t.Context(), ownerId, documentId)
+	if err != nil {
+		t.Fatalf("failed to archive document with error: %v", err)
+	}
+	err = documentRepo.UpdatePermissionGuest(t.Context(), guestId, service.Editor)
+	var goneErr *service.GoneError
+	if !errors.As(err, &goneErr) {
+		t.Errorf("want gone error when updating a guest on an archived document, got: %v", err)
+	}
+}
+
+func TestRestoreDocument_UpdateDocument_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId := createArchivedDocument(t, documentService)
+	err := documentService.RestoreDocument(t.Context(), ownerId, documentId)
+	if err != nil {
+		t.Fatalf("failed to restore document with error: %v", err)
+	}
+	// the restored document can be updated and shared again
+	name := "restored"
+	err = documentService.UpdateDocument(t.Context(), documentId, ownerId, &name, nil, false, false)
+	if err != nil {
+		t.Fatalf("failed to update restored document with error: %v", err)
+	}
+	document, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get restored document with error: %v", err)
+	}
+	if document.ArchivedAt != nil {
+		t.Errorf("expected the archived at time of a restored document to be nil, got: %v", document.ArchivedAt)
+	}
+	if document.Name == nil || *document.Name != name {
+		t.Errorf("want document name: %s, got: %v", name, document.Name)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, uuid.New(), documentId, service.Viewer)
+	if err != nil {
+		t.Errorf("failed to share restored document with error: %v", err)
+	}
+}
+
+// page through the documents of the principal from the given cursor, archived documents are
+// listed when includeArchived is set
+func listDocumentsWithArchived(
+	t *testing.T,
+	documentRepo *repository.DocumentRepository,
+	principalId uuid.UUID,
+	includeArchived bool,
+	cursor *service.Cursor,
+	pageSize int32,
+) []service.Document {
+	var documents []service.Document
+	for range 100 {
+		documentPermissions, respCursor, hasMore, err := documentRepo.ListDocumentsByPrincipal(
+			t.Context(), principalId, allPermissions, false, nil, includeArchived, cursor, pageSize,
+		)
+		if err != nil {
+			t.Fatalf("failed to list documents by principal with error: %v", err)
+		}
+		for _, documentPermission := range documentPermissions {
+			documents = append(documents, documentPermission.Document)
+		}
+		if !hasMore {
+			return documents
+		}
+		cursor = respCursor
+	}
+	t.Fatalf("the traversal did not end after 100 pages")
+	return nil
+}
+
+func TestListDocumentsByPrincipal_IncludeArchived_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 7)
+	// archived and active documents are interleaved so that pages mix the two
+	archived := map[int]bool{ 1: true, 2: true, 5: true }
+	for i := range archived {
+		if err := documentService.ArchiveDocument(t.Context(), ownerId, documentIds[i]); err != nil {
+			t.Fatalf("failed to archive document with error: %v", err)
+		}
+	}
+	var all, active []uuid.UUID
+	for i := len(documentIds) - 1; i >= 0; i-- {
+		all = append(all, documentIds[i])
+		if !archived[i] {
+			active = append(active, documentIds[i])
+		}
+	}
+	// archived documents are never listed unless they are requested
+	documents := listDocumentsWithArchived(t, documentRepo, ownerId, false, service.NewBeginningCursor(service.CreatedAt), 2)
+	verifyTraversal(t, active, documents)
+	documents = listDocumentsWithArchived(t, documentRepo, ownerId, true, service.NewBeginningCursor(service.CreatedAt), 2)
+	verifyTraversal(t, all, documents)
+	for _, document := range documents {
+		if document.ArchivedAt != nil && !archived[slices.Index(documentIds, document.ID)] {
+			t.Errorf("expected active document: %s not to be marked as archived", document.ID)
+		}
+	}
+}
+
+func TestListDocumentsByPrincipal_IncludeArchivedLastModifiedAt_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 5)
+	// archiving a document modifies it, so the archived document is listed first
+	if err := documentService.ArchiveDocument(t.Context(), ownerId, documentIds[1]); err != nil {
+		t.Fatalf("failed to archive document with error: %v", err)
+	}
+	active := []uuid.UUID{ documentIds[4], documentIds[3], documentIds[2], documentIds[0] }
+	documents := listDocumentsWithArchived(
+		t, documentRepo, ownerId, false, service.NewBeginningCursor(service.LastModifiedAt), 3,
+	)
+	verifyTraversal(t, active, documents)
+	documents = listDocumentsWithArchived(
+		t, documentRepo, ownerId, true, service.NewBeginningCursor(service.LastModifiedAt), 3,
+	)
+	verifyTraversal(t, append([]uuid.UUID{ documentIds[1] }, active...), documents)
+	// a restored document is listed without the flag
+	if err := documentService.RestoreDocument(t.Context(), ownerId, documentIds[1]); err != nil {
+		t.Fatalf("failed to restore document with error: %v", err)
+	}
+	documents = listDocumentsWithArchived(
+		t, documentRepo, ownerId, false, service.NewBeginningCursor(service.LastModifiedAt), 3,
+	)
+	verifyTraversal(t, append([]uuid.UUID{ documentIds[1] }, active...), documents)
+}
@@ -0,0 +1,165 @@
+package document_repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestPermissionLevel_Permits_Unit(t *testing.T) {
+	want := map[service.PermissionLevel][]service.Action{
+		service.Viewer: { service.View },
+		service.Editor: { service.View, service.Edit },
+		service.Owner: { service.View, service.Edit, service.Manage, service.Delete },
+	}
+	for level, permitted := range want {
+		for _, action := range service.AllActions {
+			wantAllowed := false
+			for _, p := range permitted {
+				wantAllowed = wantAllowed || p == action
+			}
+			if got := level.Permits(action); got != wantAllowed {
+				t.Errorf("want level: %v permits action: %v to be %t, got: %t", level, action, wantAllowed, got)
+			}
+		}
+	}
+	if service.Owner.Permits(service.Action(42)) {
+		t.Errorf("want an unknown action to never be permitted")
+	}
+}
+
+func TestCheckAccess_UnknownAction_Unit(t *testing.T) {
+	// the action is rejected before the repository is called
+	documentService := service.NewDocumentService(nil)
+	_, err := documentService.CheckAccess(t.Context(), uuid.New(), uuid.New(), nil, service.Action(42))
+	var invalidErr *service.InvalidInputError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error for an unknown action, got: %v", err)
+	}
+}
+
+// answers CheckAccess without a database from a fixed document and permission level
+type checkAccessRepository struct {
+	stubDocumentRepository
+	document service.Document
+	level service.PermissionLevel
+	found bool
+}
+
+func (r checkAccessRepository) GetDocument(ctx context.Context, documentId uuid.UUID) (*service.Document, error) {
+	document := r.document
+	return &document, nil
+}
+
+func (r checkAccessRepository) GetPermissionLevel(
+	ctx context.Context, documentId uuid.UUID, principalId uuid.UUID,
+) (service.PermissionLevel, bool, error) {
+	return r.level, r.found, nil
+}
+
+func TestCheckAccess_ArchivedAndPublicLink_Unit(t *testing.T) {
+	archivedAt := time.Now()
+	editorAccess := service.Editor
+	var linkVersion int32 = 2
+	var oldLinkVersion int32 = 1
+	tests := []struct {
+		name string
+		repo checkAccessRepository
+		publicLinkVersion *int32
+		action service.Action
+		want bool
+	}{
+		{
+			"owner views archived",
+			checkAccessRepository{ document: service.Document{ ArchivedAt: &archivedAt }, level: service.Owner, found: true },
+			nil, service.View, true,
+		},
+		{
+			"owner edits archived",
+			checkAccessRepository{ document: service.Document{ ArchivedAt: &archivedAt }, level: service.Owner, found: true },
+			nil, service.Edit, false,
+		},
+		{
+			"owner manages archived",
+			checkAccessRepository{ document: service.Document{ ArchivedAt: &archivedAt }, level: service.Owner, found: true },
+			nil, service.Manage, false,
+		},
+		{
+			"owner deletes archived",
+			checkAccessRepository{ document: service.Document{ ArchivedAt: &archivedAt }, level: service.Owner, found: true },
+			nil, service.Delete, true,
+		},
+		{
+			"stranger edits through public link",
+			checkAccessRepository{ document: service.Document{ PublicAccess: &editorAccess, PublicLinkVersion: linkVersion } },
+			&linkVersion, service.Edit, true,
+		},
+		{
+			"stranger manages through public link",
+			checkAccessRepository{ document: service.Document{ PublicAccess: &editorAccess, PublicLinkVersion: linkVersion } },
+			&linkVersion, service.Manage, false,
+		},
+		{
+			"viewer edits through public link",
+			checkAccessRepository{ document: service.Document{ PublicAccess: &editorAccess, PublicLinkVersion: linkVersion }, level: service.Viewer, found: true },
+			&linkVersion, service.Edit, true,
+		},
+		{
+			"stranger views through old public link",
+			checkAccessRepository{ document: service.Document{ PublicAccess: &editorAccess, PublicLinkVersion: linkVersion } },
+			&oldLinkVersion, service.View, false,
+		},
+		{
+			"stranger views through disabled public link",
+			checkAccessRepository{ document: service.Document{ PublicLinkVersion: linkVersion } },
+			&linkVersion, service.View, false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			documentService := service.NewDocumentService(test.repo)
+			allowed, err := documentService.CheckAccess(
+				t.Context(), uuid.New(), uuid.New(), test.publicLinkVersion, test.action,
+			)
+			if err != nil {
+				t.Fatalf("failed to check access with error: %v", err)
+			}
+			if allowed != test.want {
+				t.Errorf("want allowed: %t, got: %t", test.want, allowed)
+			}
+		})
+	}
+}
+
+func TestCheckAccess_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	strangerId := uuid.New()
+	tests := []struct {
+		name string
+		principalId uuid.UUID
+		action service.Action
+		want bool
+	}{
+		{ "owner deletes", ownerId, service.Delete, true },
+		{ "editor edits", editorId, service.Edit, true },
+		{ "editor manages", editorId, service.Manage, false },
+		{ "stranger views", strangerId, service.View, false },
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			allowed, err := documentService.CheckAccess(t.Context(), documentId, test.principalId, nil, test.action)
+			if err != nil {
+				t.Fatalf("failed to check access with error: %v", err)
+			}
+			if allowed != test.want {
+				t.Errorf("want allowed: %t, got: %t", test.want, allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestCreateDocument_ClientDocumentId_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	clientDocumentId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, &clientDocumentId)
+	if err != nil {
+		t.Fatalf("failed to create document with a client supplied id with error: %v", err)
+	}
+	if documentId != clientDocumentId {
+		t.Errorf("want the client supplied document id: %s, got: %s", clientDocumentId, documentId)
+	}
+	document, err := documentService.GetDocument(t.Context(), ownerId, clientDocumentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document by the client supplied id with error: %v", err)
+	}
+	if document.ID != clientDocumentId {
+		t.Errorf("want document with id: %s, got: %s", clientDocumentId, document.ID)
+	}
+}
+
+func TestCreateDocument_DuplicateClientDocumentId_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	clientDocumentId := uuid.New()
+	firstName := "first document"
+	_, err := documentService.CreateDocument(t.Context(), ownerId, &firstName, nil, &clientDocumentId)
+	if err != nil {
+		t.Fatalf("failed to create document with a client supplied id with error: %v", err)
+	}
+	// a second client that generated the same id is rejected, even when it is a different user
+	secondName := "second document"
+	_, err = documentService.CreateDocument(t.Context(), uuid.New(), &secondName, nil, &clientDocumentId)
+	var conflictErr *service.UniqueConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Errorf("want a unique conflict error when reusing a document id, got: %v", err)
+	}
+	// the first document is unchanged
+	document, err := documentService.GetDocument(t.Context(), ownerId, clientDocumentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	if document.Name == nil || *document.Name != firstName {
+		t.Errorf("want document name: %s, got: %v", firstName, document.Name)
+	}
+}
+
+func TestCreateDocument_NilClientDocumentId_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	nilId := uuid.Nil
+	_, err := documentService.CreateDocument(t.Context(), uuid.New(), nil, nil, &nilId)
+	var invalidErr *service.InvalidInputError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want an invalid input error for the nil uuid, got: %v", err)
+	}
+}
+
+func TestCreateDocument_ServerGeneratedId_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	firstId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	secondId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	if firstId == uuid.Nil || firstId == secondId {
+		t.Errorf("want distinct server generated ids, got: %s and %s", firstId, secondId)
+	}
+}
@@ -0,0 +1,39 @@
+package document_repository_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func checkCollaboratorCount(t *testing.T, documentService *service.DocumentService, documentId uuid.UUID, want int64) {
+	count, err := documentService.CountCollaborators(t.Context(), documentId)
+	if err != nil {
+		t.Fatalf("failed to count the collaborators with error: %v", err)
+	}
+	if count != want {
+		t.Errorf("want collaborator count: %d, got: %d", want, count)
+	}
+}
+
+func TestCountCollaborators_SharingChanges_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	// the owner is not a collaborator
+	checkCollaboratorCount(t, documentService, documentId, 1)
+	viewerId := uuid.New()
+	if _, err := documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, documentId, service.Viewer); err != nil {
+		t.Fatalf("failed to share the document with error: %v", err)
+	}
+	checkCollaboratorCount(t, documentService, documentId, 2)
+	// changing the level of a collaborator does not change the count
+	if _, err := documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, documentId, service.Editor); err != nil {
+		t.Fatalf("failed to update the permission with error: %v", err)
+	}
+	checkCollaboratorCount(t, documentService, documentId, 2)
+	if err := documentService.DeletePermissionPrincipal(t.Context(), editorId, documentId); err != nil {
+		t.Fatalf("failed to remove the editor with error: %v", err)
+	}
+	checkCollaboratorCount(t, documentService, documentId, 1)
+}
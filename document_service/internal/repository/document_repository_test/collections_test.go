@@ -0,0 +1,217 @@
+package document_repository_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise collections:
+- documents are added to and removed from a collection, both are idempotent
+- the collection filter of ListDocumentsByPrincipal lists only the documents in the collection
+- a collection does not grant access and only its owner can change or list it
+- deleting a document removes it from its collections
+*/
+
+// page through the documents in the collection as the principal, newest document first
+func listCollection(
+	t *testing.T,
+	documentService *service.DocumentService,
+	principalId uuid.UUID,
+	collectionId uuid.UUID,
+	pageSize int32,
+) []service.Document {
+	var documents []service.Document
+	cursor := service.NewBeginningCursor(service.CreatedAt)
+	for range 100 {
+		documentPermissions, respCursor, hasMore, err := documentService.ListDocumentsByPrincipal(
+			t.Context(), principalId, allPermissions, false, &collectionId, false, cursor, pageSize,
+		)
+		if err != nil {
+			t.Fatalf("failed to list the documents in the collection with error: %v", err)
+		}
+		for _, documentPermission := range documentPermissions {
+			documents = append(documents, documentPermission.Document)
+		}
+		if !hasMore {
+			return documents
+		}
+		cursor = respCursor
+	}
+	t.Fatalf("the traversal did not end after 100 pages")
+	return nil
+}
+
+func createCollection(t *testing.T, documentService *service.DocumentService, ownerId uuid.UUID) uuid.UUID {
+	t.Helper()
+	collection, err := documentService.CreateCollection(t.Context(), ownerId, "reading list")
+	if err != nil {
+		t.Fatalf("failed to create collection with error: %v", err)
+	}
+	return collection.ID
+}
+
+func TestCreateCollection_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	collection, err := documentService.CreateCollection(t.Context(), ownerId, "reading list")
+	if err != nil {
+		t.Fatalf("failed to create collection with error: %v", err)
+	}
+	if collection.OwnerID != ownerId || collection.Name != "reading list" || collection.CreatedAt.IsZero() {
+		t.Errorf("want a collection named reading list owned by: %s, got: %+v", ownerId, collection)
+	}
+	// a new collection is empty
+	verifyTraversal(t, nil, listCollection(t, documentService, ownerId, collection.ID, 10))
+}
+
+func TestCreateCollection_InvalidName_Unit(t *testing.T) {
+	// the name is checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	for _, name := range []string{ "", "   ", strings.Repeat("a", service.MaxCollectionNameLength + 1) } {
+		_, err := documentService.CreateCollection(t.Context(), uuid.New(), name)
+		var serviceError *service.InvalidInputError
+		if !errors.As(err, &serviceError) {
+			t.Errorf("want: a service InvalidInputError for a collection named: %q, got: %v", name, err)
+		}
+	}
+}
+
+func TestAddToCollection_AddRemove_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 5)
+	collectionId := createCollection(t, documentService, ownerId)
+	for _, i := range []int{ 0, 2, 3 } {
+		if err := documentService.AddToCollection(t.Context(), ownerId, collectionId, documentIds[i]); err != nil {
+			t.Fatalf("failed to add document to collection with error: %v", err)
+		}
+	}
+	// adding a document that is in the collection is a no-op
+	if err := documentService.AddToCollection(t.Context(), ownerId, collectionId, documentIds[2]); err != nil {
+		t.Fatalf("failed to add a document that is in the collection with error: %v", err)
+	}
+	// the documents in the collection are read newest first, a page at a time
+	want := []uuid.UUID{ documentIds[3], documentIds[2], documentIds[0] }
+	verifyTraversal(t, want, listCollection(t, documentService, ownerId, collectionId, 2))
+	if err := documentService.RemoveFromCollection(t.Context(), ownerId, collectionId, documentIds[2]); err != nil {
+		t.Fatalf("failed to remove document from collection with error: %v", err)
+	}
+	verifyTraversal(t, []uuid.UUID{ documentIds[3], documentIds[0] }, listCollection(t, documentService, ownerId, collectionId, 10))
+	// removing a document that is not in the collection is a no-op
+	if err := documentService.RemoveFromCollection(t.Context(), ownerId, collectionId, documentIds[2]); err != nil {
+		t.Errorf("failed to remove a document that is not in the collection with error: %v", err)
+	}
+	// the documents that are not in the collection are still listed without the filter
+	documents := traverseDocuments(t, documentRepo, ownerId, service.NewBeginningCursor(service.CreatedAt), 10)
+	if len(documents) != len(documentIds) {
+		t.Errorf("want %d documents without the collection filter, got: %d", len(documentIds), len(documents))
+	}
+}
+
+func TestAddToCollection_SeparateCollections_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 3)
+	firstCollectionId := createCollection(t, documentService, ownerId)
+	secondCollectionId := createCollection(t, documentService, ownerId)
+	// a document can be in more than one collection
+	for _, membership := range []struct{ collectionId uuid.UUID; documentId uuid.UUID }{
+		{ firstCollectionId, documentIds[0] },
+		{ firstCollectionId, documentIds[1] },
+		{ secondCollectionId, documentIds[1] },
+		{ secondCollectionId, documentIds[2] },
+	} {
+		if err := documentService.AddToCollection(t.Context(), ownerId, membership.collectionId, membership.documentId); err != nil {
+			t.Fatalf("failed to add document to collection with error: %v", err)
+		}
+	}
+	verifyTraversal(t, []uuid.UUID{ documentIds[1], documentIds[0] }, listCollection(t, documentService, ownerId, firstCollectionId, 10))
+	verifyTraversal(t, []uuid.UUID{ documentIds[2], documentIds[1] }, listCollection(t, documentService, ownerId, secondCollectionId, 10))
+	// moving a document between collections leaves the other collection unchanged
+	if err := documentService.RemoveFromCollection(t.Context(), ownerId, secondCollectionId, documentIds[1]); err != nil {
+		t.Fatalf("failed to remove document from collection with error: %v", err)
+	}
+	verifyTraversal(t, []uuid.UUID{ documentIds[1], documentIds[0] }, listCollection(t, documentService, ownerId, firstCollectionId, 10))
+	verifyTraversal(t, []uuid.UUID{ documentIds[2] }, listCollection(t, documentService, ownerId, secondCollectionId, 10))
+}
+
+func TestAddToCollection_NoAccess_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	var permissionDenied *service.PermissionDeniedError
+	// a principal cannot add a document they have no permission on to their collection
+	strangerId := uuid.New()
+	strangerCollectionId := createCollection(t, documentService, strangerId)
+	err := documentService.AddToCollection(t.Context(), strangerId, strangerCollectionId, documentId)
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when adding a document without a permission, got: %v", err)
+	}
+	// a principal cannot change or list the collection of another principal
+	ownerCollectionId := createCollection(t, documentService, ownerId)
+	err = documentService.AddToCollection(t.Context(), editorId, ownerCollectionId, documentId)
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when adding to the collection of another principal, got: %v", err)
+	}
+	if err = documentService.AddToCollection(t.Context(), ownerId, ownerCollectionId, documentId); err != nil {
+		t.Fatalf("failed to add document to collection with error: %v", err)
+	}
+	err = documentService.RemoveFromCollection(t.Context(), editorId, ownerCollectionId, documentId)
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when removing from the collection of another principal, got: %v", err)
+	}
+	_, _, _, err = documentService.ListDocumentsByPrincipal(
+		t.Context(), editorId, allPermissions, false, &ownerCollectionId, false, nil, 10,
+	)
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when listing the collection of another principal, got: %v", err)
+	}
+	// an unknown collection is not found
+	var notFound *service.NotFoundError
+	err = documentService.AddToCollection(t.Context(), ownerId, uuid.New(), documentId)
+	if !errors.As(err, &notFound) {
+		t.Errorf("want a not found error when adding to an unknown collection, got: %v", err)
+	}
+}
+
+func TestListDocumentsByPrincipal_CollectionGrantsNoAccess_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	collectionId := createCollection(t, documentService, editorId)
+	if err := documentService.AddToCollection(t.Context(), editorId, collectionId, documentId); err != nil {
+		t.Fatalf("failed to add document to collection with error: %v", err)
+	}
+	verifyTraversal(t, []uuid.UUID{ documentId }, listCollection(t, documentService, editorId, collectionId, 10))
+	// a document in the collection that the principal lost access to is not listed
+	if err := documentService.LeaveDocument(t.Context(), editorId, documentId); err != nil {
+		t.Fatalf("failed to remove the editor from the document with error: %v", err)
+	}
+	verifyTraversal(t, nil, listCollection(t, documentService, editorId, collectionId, 10))
+	// the owner of the collection can still remove the document after losing access to it
+	if err := documentService.RemoveFromCollection(t.Context(), editorId, collectionId, documentId); err != nil {
+		t.Errorf("failed to remove a document the principal lost access to with error: %v", err)
+	}
+}
+
+func TestDeleteDocument_InCollection_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	collectionId := createCollection(t, documentService, ownerId)
+	if err := documentService.AddToCollection(t.Context(), ownerId, collectionId, documentId); err != nil {
+		t.Fatalf("failed to add document to collection with error: %v", err)
+	}
+	// the memberships of the document are deleted with it
+	if err := documentRepo.DeleteDocument(t.Context(), documentId); err != nil {
+		t.Fatalf("failed to delete a document in a collection with error: %v", err)
+	}
+	verifyTraversal(t, nil, listCollection(t, documentService, ownerId, collectionId, 10))
+}
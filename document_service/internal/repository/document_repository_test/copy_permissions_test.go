@@ -0,0 +1,222 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise copying the sharing of one document onto another:
+- the users and guests of the source are copied, the owner and pending shares are not
+- guests are copied as new guests of the target with the same level and label
+- users that already have a permission on the target keep it
+- scheduled downgrades are copied, and copying again does not add the guests a second time
+- the caller must own both documents
+*/
+
+// read every permission on the document keyed by recipient
+func readPermissions(
+	t *testing.T, documentService *service.DocumentService, documentId uuid.UUID,
+) map[uuid.UUID]service.Permission {
+	permissions, _, _, err := documentService.ListPermissionsOnDocument(
+		t.Context(), documentId, nil, nil, service.MaxPageSize, nil,
+	)
+	if err != nil {
+		t.Fatalf("failed to list the permissions on document with error: %v", err)
+	}
+	byRecipient := make(map[uuid.UUID]service.Permission)
+	for _, permission := range permissions {
+		byRecipient[permission.RecipientID] = permission
+	}
+	return byRecipient
+}
+
+func TestCopyPermissions_UsersAndGuests_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	sourceId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	viewerId, inviteeId := uuid.New(), uuid.New()
+	if _, err := documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, sourceId, service.Viewer); err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	if err := documentService.InviteUser(t.Context(), ownerId, inviteeId, sourceId, service.Editor); err != nil {
+		t.Fatalf("failed to invite user with error: %v", err)
+	}
+	label := "design review"
+	editorLevel := service.Editor
+	labeledGuestId, err := documentService.CreateGuest(t.Context(), ownerId, sourceId, &editorLevel, &label)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, sourceId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	// the viewer of the source already edits the target and keeps the higher level
+	targetId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	if _, err = documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, targetId, service.Editor); err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	copied, err := documentService.CopyPermissions(t.Context(), sourceId, targetId, ownerId)
+	if err != nil {
+		t.Fatalf("failed to copy permissions with error: %v", err)
+	}
+	// the editor and both guests are copied, the viewer is skipped
+	if copied != 3 {
+		t.Errorf("want 3 permissions copied, got: %d", copied)
+	}
+	permissions := readPermissions(t, documentService, targetId)
+	wantUsers := map[uuid.UUID]service.PermissionLevel{
+		ownerId: service.Owner,
+		editorId: service.Editor,
+		viewerId: service.Editor,
+	}
+	for userId, wantLevel := range wantUsers {
+		if permission, ok := permissions[userId]; !ok || permission.PermissionLevel != wantLevel {
+			t.Errorf("want level: %v for user: %s on the target, got: %v with present: %v", wantLevel, userId, permission.PermissionLevel, ok)
+		}
+	}
+	if _, ok := permissions[inviteeId]; ok {
+		t.Errorf("want the pending share of user: %s not to be copied", inviteeId)
+	}
+	// the guests of the target are new guests with the levels and labels of the source guests
+	var guests []service.Permission
+	for _, permission := range permissions {
+		if permission.RecipientType == service.Guest {
+			guests = append(guests, permission)
+		}
+	}
+	if len(guests) != 2 {
+		t.Fatalf("want 2 guests on the target, got: %d", len(guests))
+	}
+	var labeled, unlabeled int
+	for _, guest := range guests {
+		if guest.RecipientID == labeledGuestId || guest.RecipientID == guestId {
+			t.Errorf("want a new guest on the target, got the source guest: %s", guest.RecipientID)
+		}
+		switch {
+		case guest.Label != nil && *guest.Label == label && guest.PermissionLevel == service.Editor:
+			labeled++
+		case guest.Label == nil && guest.PermissionLevel == service.DefaultGuestPermissionLevel:
+			unlabeled++
+		}
+	}
+	if labeled != 1 || unlabeled != 1 {
+		t.Errorf("want one labeled editor guest and one unlabeled guest on the target, got: %v", guests)
+	}
+	// the source is unchanged
+	if sourcePermissions := readPermissions(t, documentService, sourceId); len(sourcePermissions) != 6 {
+		t.Errorf("want 6 permissions on the source, got: %d", len(sourcePermissions))
+	}
+}
+
+func TestCopyPermissions_DowngradesAndRepeatedCopies_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	sourceId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	editorLevel := service.Editor
+	label := "design review"
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, sourceId, &editorLevel, &label)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	downgradeAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Microsecond)
+	for _, recipientId := range []uuid.UUID{ editorId, guestId } {
+		err = documentService.SchedulePermissionDowngrade(
+			t.Context(), ownerId, sourceId, recipientId, service.Viewer, downgradeAt,
+		)
+		if err != nil {
+			t.Fatalf("failed to schedule downgrade with error: %v", err)
+		}
+	}
+	targetId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	copied, err := documentService.CopyPermissions(t.Context(), sourceId, targetId, ownerId)
+	if err != nil || copied != 2 {
+		t.Fatalf("want 2 permissions copied, got: %d with error: %v", copied, err)
+	}
+	// copying again adds nothing, the editor already has a permission and the guest was copied
+	copied, err = documentService.CopyPermissions(t.Context(), sourceId, targetId, ownerId)
+	if err != nil || copied != 0 {
+		t.Fatalf("want no permissions copied the second time, got: %d with error: %v", copied, err)
+	}
+	permissions := readPermissions(t, documentService, targetId)
+	var guests int
+	for recipientId, permission := range permissions {
+		if permission.RecipientType == service.Guest {
+			guests++
+		} else if recipientId != editorId {
+			continue
+		}
+		// the temporary editors of the source are temporary editors of the target
+		if permission.DowngradeTo == nil || *permission.DowngradeTo != service.Viewer ||
+			permission.DowngradeAt == nil || !permission.DowngradeAt.Equal(downgradeAt) {
+			t.Errorf("want the downgrade of recipient: %s copied, got: %v at %v", recipientId, permission.DowngradeTo, permission.DowngradeAt)
+		}
+	}
+	if guests != 1 {
+		t.Errorf("want one guest on the target after copying twice, got: %d", guests)
+	}
+}
+
+func TestCopyPermissions_NotOwnerOfTarget_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	sourceId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	// the owner of the source only edits the target
+	targetId, targetOwnerId, _ := createDocumentWithEditor(t, documentService)
+	if _, err := documentService.UpsertPermissionUser(t.Context(), targetOwnerId, ownerId, targetId, service.Editor); err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	_, err := documentService.CopyPermissions(t.Context(), sourceId, targetId, ownerId)
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Fatalf("want a permission denied error when the caller does not own the target, got: %v", err)
+	}
+	if permissions := readPermissions(t, documentService, targetId); len(permissions) != 3 {
+		t.Errorf("want the 3 permissions of the target to be unchanged, got: %d", len(permissions))
+	}
+}
+
+func TestCopyPermissions_GuestLimit_Integration(t *testing.T) {
+	documentService := service.NewDocumentServiceWithGuestLimits(
+		createTestingDocumentRepo(t), service.DefaultMaxGuestBatchSize, 2,
+	)
+	sourceId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	if _, err := documentService.CreateGuests(t.Context(), ownerId, sourceId, 2, nil); err != nil {
+		t.Fatalf("failed to create guests with error: %v", err)
+	}
+	targetId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	if _, err = documentService.CreateGuest(t.Context(), ownerId, targetId, nil, nil); err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	// the copied guests would take the target over the limit, so nothing is copied
+	_, err = documentService.CopyPermissions(t.Context(), sourceId, targetId, ownerId)
+	var quotaExceeded *service.QuotaExceededError
+	if !errors.As(err, &quotaExceeded) {
+		t.Fatalf("want a quota exceeded error when copying guests over the limit, got: %v", err)
+	}
+	if _, ok := readPermissions(t, documentService, targetId)[editorId]; ok {
+		t.Errorf("want no permissions copied when the copy fails")
+	}
+}
+
+func TestCopyPermissions_SameDocument_Unit(t *testing.T) {
+	// the documents are checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	documentId := uuid.New()
+	_, err := documentService.CopyPermissions(t.Context(), documentId, documentId, uuid.New())
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError when copying permissions onto the same document, got: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestCountDocumentsByPrincipalGrouped_Levels_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	principalId := uuid.New()
+	otherOwnerId := uuid.New()
+	// the principal owns two documents, edits three, and views one
+	createDocuments(t, documentRepo, principalId, 2)
+	sharedIds := createDocuments(t, documentRepo, otherOwnerId, 4)
+	for i, documentId := range sharedIds {
+		level := service.Editor
+		if i == len(sharedIds) - 1 {
+			level = service.Viewer
+		}
+		_, err := documentService.UpsertPermissionUser(t.Context(), otherOwnerId, principalId, documentId, level)
+		if err != nil {
+			t.Fatalf("failed to share document with error: %v", err)
+		}
+	}
+	// an archived document is not counted
+	archivedIds := createDocuments(t, documentRepo, principalId, 1)
+	if err := documentRepo.ArchiveDocument(t.Context(), archivedIds[0]); err != nil {
+		t.Fatalf("failed to archive document with error: %v", err)
+	}
+	counts, err := documentService.CountDocumentsByPrincipalGrouped(t.Context(), principalId, principalId)
+	if err != nil {
+		t.Fatalf("failed to count documents with error: %v", err)
+	}
+	want := map[service.PermissionLevel]int64{
+		service.Owner: 2,
+		service.Editor: 3,
+		service.Viewer: 1,
+	}
+	for level, wantCount := range want {
+		if counts[level] != wantCount {
+			t.Errorf("want %d documents at level: %v, got: %d", wantCount, level, counts[level])
+		}
+	}
+}
+
+func TestCountDocumentsByPrincipalGrouped_NoDocuments_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	principalId := uuid.New()
+	counts, err := documentService.CountDocumentsByPrincipalGrouped(t.Context(), principalId, principalId)
+	if err != nil {
+		t.Fatalf("failed to count documents with error: %v", err)
+	}
+	// every level is present with a zero count
+	for _, level := range service.AllPermissions {
+		count, ok := counts[level]
+		if !ok || count != 0 {
+			t.Errorf("want a zero count at level: %v, got: %d with present: %v", level, count, ok)
+		}
+	}
+}
+
+func TestCountDocumentsByPrincipalGrouped_OtherPrincipal_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	principalId := uuid.New()
+	createDocuments(t, documentRepo, principalId, 1)
+	// a principal cannot learn how many documents another principal can see
+	_, err := documentService.CountDocumentsByPrincipalGrouped(t.Context(), uuid.New(), principalId)
+	var permissionDeniedErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionDeniedErr) {
+		t.Errorf("want permission denied error when counting the documents of another principal, got: %v", err)
+	}
+}
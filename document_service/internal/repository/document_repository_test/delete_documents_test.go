@@ -0,0 +1,127 @@
+package document_repository_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestDeleteDocuments_NotOwned_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	sharedDocumentId, _, editorId := createDocumentWithEditor(t, documentService)
+	// the editor owns one document in the batch and can only edit the other
+	ownedDocumentId, err := documentService.CreateDocument(t.Context(), editorId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	err = documentService.DeleteDocuments(
+		t.Context(), uuid.UUIDs{ ownedDocumentId, sharedDocumentId }, editorId,
+	)
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Fatalf("want permission denied error when deleting a document that is not owned, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), sharedDocumentId.String()) {
+		t.Errorf("want the error to list the document that is not owned: %s, got: %v", sharedDocumentId, err)
+	}
+	if strings.Contains(err.Error(), ownedDocumentId.String()) {
+		t.Errorf("want the error to not list the owned document: %s, got: %v", ownedDocumentId, err)
+	}
+	// neither document is deleted
+	for _, documentId := range []uuid.UUID{ ownedDocumentId, sharedDocumentId } {
+		_, err = documentService.GetDocument(t.Context(), editorId, documentId, nil)
+		if err != nil {
+			t.Errorf("want document: %s to still exist, got error: %v", documentId, err)
+		}
+	}
+}
+
+func TestDeleteDocuments_MissingDocument_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	// a document that does not exist is not owned by the caller
+	missingId := uuid.New()
+	err = documentService.DeleteDocuments(t.Context(), uuid.UUIDs{ documentId, missingId }, ownerId)
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Fatalf("want permission denied error when deleting a missing document, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), missingId.String()) {
+		t.Errorf("want the error to list the missing document: %s, got: %v", missingId, err)
+	}
+}
+
+func TestDeleteDocuments_AllOwned_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentIds := make(uuid.UUIDs, 2)
+	for i := range documentIds {
+		documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create document with error: %v", err)
+		}
+		documentIds[i] = documentId
+	}
+	err := documentService.DeleteDocuments(t.Context(), documentIds, ownerId)
+	if err != nil {
+		t.Fatalf("failed to delete owned documents with error: %v", err)
+	}
+	for _, documentId := range documentIds {
+		_, err = documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+		var permissionDenied *service.PermissionDeniedError
+		if !errors.As(err, &permissionDenied) {
+			t.Errorf("want permission denied error for deleted document: %s, got: %v", documentId, err)
+		}
+	}
+}
+
+func TestGetOwnedDocumentIds_PartiallyOwned_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	sharedDocumentId, _, editorId := createDocumentWithEditor(t, documentService)
+	ownedDocumentIds := make(uuid.UUIDs, 2)
+	for i := range ownedDocumentIds {
+		documentId, err := documentService.CreateDocument(t.Context(), editorId, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create document with error: %v", err)
+		}
+		ownedDocumentIds[i] = documentId
+	}
+	missingId := uuid.New()
+	// the editor owns two of the requested documents, only edits one, and the last is missing
+	requested := uuid.UUIDs{ ownedDocumentIds[0], sharedDocumentId, ownedDocumentIds[1], missingId }
+	owned, err := documentService.GetOwnedDocumentIds(t.Context(), editorId, requested)
+	if err != nil {
+		t.Fatalf("failed to get the owned documents with error: %v", err)
+	}
+	verifyDocumentIdSet(t, ownedDocumentIds, owned)
+	notOwned, err := documentService.GetNotOwnedDocumentIds(t.Context(), editorId, requested)
+	if err != nil {
+		t.Fatalf("failed to get the documents that are not owned with error: %v", err)
+	}
+	// the offending documents are in the order that they were requested
+	verifyDocumentIds(t, []uuid.UUID{ sharedDocumentId, missingId }, notOwned)
+}
+
+// compare two sets of document ids without regard to order
+func verifyDocumentIdSet(t *testing.T, want uuid.UUIDs, got uuid.UUIDs) {
+	t.Helper()
+	wantSet := make(map[uuid.UUID]struct{}, len(want))
+	for _, documentId := range want {
+		wantSet[documentId] = struct{}{}
+	}
+	if len(got) != len(wantSet) {
+		t.Fatalf("want documents: %v, got: %v", want, got)
+	}
+	for _, documentId := range got {
+		if _, ok := wantSet[documentId]; !ok {
+			t.Errorf("want documents: %v, got unexpected document: %s", want, documentId)
+		}
+	}
+}
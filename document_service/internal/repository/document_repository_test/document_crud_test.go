@@ -2,7 +2,9 @@ package document_repository_test
 
 import (
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/townsag/reed/document_service/internal/repository"
@@ -18,7 +20,7 @@ func TestCreateUpdateDocumentIntegration(t *testing.T) {
 	userId := uuid.New()
 	// create a document for that dummy user id
 	dummyName := "dummy document"
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, &dummyName, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, &dummyName, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with err: %v", err)
 	}
@@ -29,7 +31,7 @@ func TestCreateUpdateDocumentIntegration(t *testing.T) {
 	}
 	// update the name of that document
 	updatedName := "updated document"
-	err = documentRepo.UpdateDocument(t.Context(), documentId, &updatedName, nil)
+	err = documentRepo.UpdateDocument(t.Context(), documentId, userId, &updatedName, nil, false, false)
 	if err != nil {
 		t.Fatalf("failed to update the document with error: %v", err)
 	}
@@ -45,7 +47,7 @@ func TestCreateUpdateDocumentIntegration(t *testing.T) {
 	if *documentUpdated.Name != updatedName {
 		t.Errorf("failed to update document name, want: %s, got: %s", updatedName, *document.Name)
 	}
-	if documentUpdated.LastModifiedAt.After(document.LastModifiedAt) {
+	if !documentUpdated.LastModifiedAt.After(document.LastModifiedAt) {
 		t.Errorf(
 			"failed to update document last modified at: want a timestamp different from the previous timestamp: %v, got: %v",
 			document.LastModifiedAt,
@@ -62,7 +64,7 @@ func TestCreateDeleteDocumentIntegration(t *testing.T) {
 	// create a document for that user id
 	name := "dummy name"
 	description := "dummy description"
-	documentId, err := documentRepo.CreateDocument(t.Context(), dummyUserId, &name, &description)
+	documentId, err := documentRepo.CreateDocument(t.Context(), dummyUserId, &name, &description, nil)
 	if err != nil {
 		t.Fatalf("failed to create document with error: %v", err)
 	}
@@ -121,7 +123,7 @@ func TestUpdateDocument_NotFound_Integration(t *testing.T) {
 	// call update document on a document that does not exist
 	name := "howdy partner"
 	err := documentRepository.UpdateDocument(
-		t.Context(), uuid.New(), &name, nil,
+		t.Context(), uuid.New(), uuid.New(), &name, nil, false, false,
 	)
 	if err == nil {
 		t.Fatalf(
@@ -169,7 +171,7 @@ func TestUpdateDocument_NilInputs_Unit(t *testing.T) {
 	documentRepo := &repository.DocumentRepository{}
 	// call update document with nil inputs
 	err := documentRepo.UpdateDocument(
-		t.Context(), uuid.New(), nil, nil,
+		t.Context(), uuid.New(), uuid.New(), nil, nil, false, false,
 	)
 	if err == nil {
 		t.Fatalf("expected an error when calling update document with nil inputs but got nil instead")
@@ -190,13 +192,13 @@ func TestDeleteDocumentsIntegrations(t *testing.T) {
 	// add two documents to the database
 	dummyUser := uuid.New()
 	documentAID, err := documentRepo.CreateDocument(
-		t.Context(), dummyUser, nil, nil,
+		t.Context(), dummyUser, nil, nil, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	documentBID, err := documentRepo.CreateDocument(
-		t.Context(), dummyUser, nil, nil,
+		t.Context(), dummyUser, nil, nil, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
@@ -232,7 +234,7 @@ func TestDeleteDocuments_NotFound_Integration(t *testing.T) {
 	// add a document to the database
 	dummyUserId := uuid.New()
 	documentId, err := documentRepository.CreateDocument(
-		t.Context(), dummyUserId, nil, nil,
+		t.Context(), dummyUserId, nil, nil, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to create a document: %v", err)
@@ -272,4 +274,87 @@ func TestDeleteDocuments_EmptyArray_Unit(t *testing.T) {
 	} else {
 		t.Fatalf("when calling delete documents with an empty list, want: invalid input error, got: nil")
 	}
-}
\ No newline at end of file
+}
+// count the rows of the table that reference the document, reads the table directly so that
+// rows that the repository api cannot reach are counted as well
+func countRowsOfDocument(t *testing.T, table string, documentId uuid.UUID) int {
+	pool, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("failed to create a connection to the postgres container: %v", err)
+	}
+	var count int
+	err = pool.QueryRow(
+		t.Context(), fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE document_id = $1", table), documentId,
+	).Scan(&count)
+	if err != nil {
+		t.Fatalf("failed to count the rows of table: %s with error: %v", table, err)
+	}
+	return count
+}
+
+func TestDeleteDocument_NoPermissionRowsRemain_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	// the document has an owner, an editor, a guest, and a pending share
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	if _, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil); err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	if err := documentService.InviteUser(t.Context(), ownerId, uuid.New(), documentId, service.Viewer); err != nil {
+		t.Fatalf("failed to invite user with error: %v", err)
+	}
+	if count := countRowsOfDocument(t, "permissions", documentId); count != 4 {
+		t.Fatalf("want 4 permission rows before the document is deleted, got: %d", count)
+	}
+	if err := documentRepo.DeleteDocument(t.Context(), documentId); err != nil {
+		t.Fatalf("failed to delete document with error: %v", err)
+	}
+	for _, table := range []string{ "permissions", "guests" } {
+		if count := countRowsOfDocument(t, table, documentId); count != 0 {
+			t.Errorf("want no rows in table: %s after the document is deleted, got: %d", table, count)
+		}
+	}
+}
+
+func TestDeleteDocument_ConcurrentPermissionInsert_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	pool, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("failed to create a connection to the postgres container: %v", err)
+	}
+	ownerId := uuid.New()
+	documentId, err := documentRepo.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	// insert a permission in a transaction that is still open when the delete starts, the insert
+	// holds a lock on the document that the delete waits for
+	tx, err := pool.Begin(t.Context())
+	if err != nil {
+		t.Fatalf("failed to begin transaction with error: %v", err)
+	}
+	defer tx.Rollback(t.Context())
+	_, err = tx.Exec(
+		t.Context(),
+		"INSERT INTO permissions (recipient_id, recipient_type, document_id, permission_level, created_by) VALUES ($1, 'user', $2, 'viewer', $3)",
+		uuid.New(), documentId, ownerId,
+	)
+	if err != nil {
+		t.Fatalf("failed to insert permission with error: %v", err)
+	}
+	deleted := make(chan error, 1)
+	go func() {
+		deleted <- documentRepo.DeleteDocument(t.Context(), documentId)
+	}()
+	// give the delete time to reach the document lock before the insert commits
+	time.Sleep(200 * time.Millisecond)
+	if err = tx.Commit(t.Context()); err != nil {
+		t.Fatalf("failed to commit the permission insert with error: %v", err)
+	}
+	if err = <-deleted; err != nil {
+		t.Fatalf("failed to delete document with a concurrent permission insert with error: %v", err)
+	}
+	if count := countRowsOfDocument(t, "permissions", documentId); count != 0 {
+		t.Errorf("want no permission rows after the document is deleted, got: %d", count)
+	}
+}
@@ -0,0 +1,275 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+// fails the test unless the optional string holds the wanted value
+func verifyOptionalString(t *testing.T, field string, want *string, got *string) {
+	if want == nil || got == nil {
+		if want != got {
+			t.Errorf("want %s: %v, got: %v", field, want, got)
+		}
+		return
+	}
+	if *want != *got {
+		t.Errorf("want %s: %s, got: %s", field, *want, *got)
+	}
+}
+
+func verifyChange(t *testing.T, want service.DocumentChange, got service.DocumentChange) {
+	if got.DocumentID != want.DocumentID {
+		t.Errorf("want document id: %s, got: %s", want.DocumentID, got.DocumentID)
+	}
+	if got.ActorID != want.ActorID {
+		t.Errorf("want actor id: %s, got: %s", want.ActorID, got.ActorID)
+	}
+	verifyOptionalString(t, "old name", want.OldName, got.OldName)
+	verifyOptionalString(t, "new name", want.NewName, got.NewName)
+	verifyOptionalString(t, "old description", want.OldDescription, got.OldDescription)
+	verifyOptionalString(t, "new description", want.NewDescription, got.NewDescription)
+	if got.ID == uuid.Nil || got.ChangedAt.IsZero() {
+		t.Errorf("want the id and changed at time of the change to be populated, got: %v", got)
+	}
+}
+
+func TestGetDocumentHistory_EachUpdateAppendsOneChange_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	firstName := "first name"
+	description := "description"
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, &firstName, &description, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	editorId := uuid.New()
+	_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, editorId, documentId, service.Editor)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	// the owner renames the document and the editor changes its description
+	secondName := "second name"
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, &secondName, nil, false, false); err != nil {
+		t.Fatalf("failed to update document with error: %v", err)
+	}
+	changes, _, _, err := documentService.GetDocumentHistory(t.Context(), documentId, ownerId, nil, service.DefaultPageSize)
+	if err != nil {
+		t.Fatalf("failed to get document history with error: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("want one change after one update, got: %d", len(changes))
+	}
+	rename := service.DocumentChange{
+		DocumentID: documentId,
+		ActorID: ownerId,
+		OldName: &firstName,
+		NewName: &secondName,
+		OldDescription: &description,
+		NewDescription: &description,
+	}
+	verifyChange(t, rename, changes[0])
+	newDescription := "new description"
+	if err = documentService.UpdateDocument(t.Context(), documentId, editorId, nil, &newDescription, false, false); err != nil {
+		t.Fatalf("failed to update document with error: %v", err)
+	}
+	changes, _, hasMore, err := documentService.GetDocumentHistory(t.Context(), documentId, ownerId, nil, service.DefaultPageSize)
+	if err != nil {
+		t.Fatalf("failed to get document history with error: %v", err)
+	}
+	if len(changes) != 2 || hasMore {
+		t.Fatalf("want two changes after two updates, got: %d changes and hasMore: %v", len(changes), hasMore)
+	}
+	// the history is listed newest first
+	verifyChange(t, service.DocumentChange{
+		DocumentID: documentId,
+		ActorID: editorId,
+		OldName: &secondName,
+		NewName: &secondName,
+		OldDescription: &description,
+		NewDescription: &newDescription,
+	}, changes[0])
+	verifyChange(t, rename, changes[1])
+}
+
+func TestGetDocumentHistory_NullFields_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	// a document created without a name or description records null old values
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	name := "named"
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, &name, nil, false, false); err != nil {
+		t.Fatalf("failed to update document with error: %v", err)
+	}
+	changes, _, _, err := documentService.GetDocumentHistory(t.Context(), documentId, ownerId, nil, service.DefaultPageSize)
+	if err != nil {
+		t.Fatalf("failed to get document history with error: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("want one change after one update, got: %d", len(changes))
+	}
+	verifyChange(t, service.DocumentChange{
+		DocumentID: documentId,
+		ActorID: ownerId,
+		NewName: &name,
+	}, changes[0])
+}
+
+func TestGetDocumentHistory_FailedUpdate_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId := createArchivedDocument(t, documentService)
+	// an update that is rejected does not append a change
+	name := "archived"
+	err := documentService.UpdateDocument(t.Context(), documentId, ownerId, &name, nil, false, false)
+	var goneErr *service.GoneError
+	if !errors.As(err, &goneErr) {
+		t.Fatalf("want gone error when updating an archived document, got: %v", err)
+	}
+	changes, _, _, err := documentService.GetDocumentHistory(t.Context(), documentId, ownerId, nil, service.DefaultPageSize)
+	if err != nil {
+		t.Fatalf("failed to get document history with error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("want no changes after a rejected update, got: %v", changes)
+	}
+}
+
+func TestGetDocumentHistory_ViewerUpdate_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	name := "name"
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, &name, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	viewerId := uuid.New()
+	if _, err = documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, documentId, service.Viewer); err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	// neither a viewer nor a principal without a permission can update the document, and the
+	// rejected update does not append a change
+	renamed := "renamed"
+	for _, actorId := range []uuid.UUID{ viewerId, uuid.New() } {
+		err = documentService.UpdateDocument(t.Context(), documentId, actorId, &renamed, nil, false, false)
+		var permissionDenied *service.PermissionDeniedError
+		if !errors.As(err, &permissionDenied) {
+			t.Errorf("want a permission denied error when principal: %s updates the document, got: %v", actorId, err)
+		}
+	}
+	changes, _, _, err := documentService.GetDocumentHistory(t.Context(), documentId, ownerId, nil, service.DefaultPageSize)
+	if err != nil {
+		t.Fatalf("failed to get document history with error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("want no changes after a rejected update, got: %v", changes)
+	}
+	document, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	if document.Name == nil || *document.Name != name {
+		t.Errorf("want the name to be unchanged: %s, got: %v", name, derefOrNil(document.Name))
+	}
+}
+
+func TestGetDocumentHistory_Pagination_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	names := []string{ "a", "b", "c", "d", "e" }
+	for i := range names {
+		if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, &names[i], nil, false, false); err != nil {
+			t.Fatalf("failed to update document with error: %v", err)
+		}
+	}
+	var got []string
+	var cursor *service.Cursor
+	for range 10 {
+		changes, cursorResp, hasMore, err := documentService.GetDocumentHistory(t.Context(), documentId, ownerId, cursor, 2)
+		if err != nil {
+			t.Fatalf("failed to get document history with error: %v", err)
+		}
+		for _, change := range changes {
+			got = append(got, *change.NewName)
+		}
+		if !hasMore {
+			break
+		}
+		cursor = cursorResp
+	}
+	want := []string{ "e", "d", "c", "b", "a" }
+	if len(got) != len(want) {
+		t.Fatalf("want %d changes, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want the change to name: %s at position %d, got: %s", want[i], i, got[i])
+		}
+	}
+}
+
+func TestGetDocumentHistory_Authorization_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	viewerId := uuid.New()
+	_, err := documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	name := "renamed"
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, &name, nil, false, false); err != nil {
+		t.Fatalf("failed to update document with error: %v", err)
+	}
+	// viewers can read the history
+	changes, _, _, err := documentService.GetDocumentHistory(t.Context(), documentId, viewerId, nil, service.DefaultPageSize)
+	if err != nil {
+		t.Fatalf("want a viewer to be able to read the history, got error: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Errorf("want one change, got: %d", len(changes))
+	}
+	// principals without a permission cannot
+	_, _, _, err = documentService.GetDocumentHistory(t.Context(), documentId, uuid.New(), nil, service.DefaultPageSize)
+	var deniedErr *service.PermissionDeniedError
+	if !errors.As(err, &deniedErr) {
+		t.Errorf("want permission denied error for a principal without a permission, got: %v", err)
+	}
+}
+
+func TestGetDocumentHistory_DeleteDocument_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	name := "renamed"
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, &name, nil, false, false); err != nil {
+		t.Fatalf("failed to update document with error: %v", err)
+	}
+	// the history of the document is deleted with the document
+	if err = documentService.DeleteDocument(t.Context(), documentId); err != nil {
+		t.Fatalf("failed to delete a document with history, got error: %v", err)
+	}
+}
+
+func TestGetDocumentHistory_InvalidSortField_Unit(t *testing.T) {
+	// the cursor is rejected before the repository is called
+	documentService := service.NewDocumentService(nil)
+	_, _, _, err := documentService.GetDocumentHistory(
+		t.Context(), uuid.New(), uuid.New(), service.NewBeginningCursor(service.LastModifiedAt), service.DefaultPageSize,
+	)
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for a cursor sorted by last modified at, got: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise listing the principals that recently read a document:
+- reading a document lists the reader as a viewer, most recent read first
+- a read ages out of the list once it is older than the window
+- only collaborators on the document can list its viewers
+*/
+
+func viewerIds(viewers []service.DocumentViewer) []uuid.UUID {
+	ids := make([]uuid.UUID, len(viewers))
+	for i, viewer := range viewers {
+		ids[i] = viewer.PrincipalID
+	}
+	return ids
+}
+
+func TestGetDocumentViewers_RecentReads_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	// the owner has not read the document yet
+	viewers, err := documentService.GetDocumentViewers(t.Context(), documentId, ownerId, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get the viewers of the document with error: %v", err)
+	}
+	verifyDocumentIds(t, nil, viewerIds(viewers))
+	readDocuments(t, documentService, editorId, documentId)
+	readDocuments(t, documentService, ownerId, documentId)
+	viewers, err = documentService.GetDocumentViewers(t.Context(), documentId, editorId, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get the viewers of the document with error: %v", err)
+	}
+	verifyDocumentIds(t, []uuid.UUID{ ownerId, editorId }, viewerIds(viewers))
+	if viewers[1].Permission != service.Editor || viewers[1].RecipientType != service.User {
+		t.Errorf("want the editor to be listed as a user with the editor level, got: %+v", viewers[1])
+	}
+	// reading the document again moves the reader to the front
+	readDocuments(t, documentService, editorId, documentId)
+	viewers, err = documentService.GetDocumentViewers(t.Context(), documentId, editorId, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get the viewers of the document with error: %v", err)
+	}
+	verifyDocumentIds(t, []uuid.UUID{ editorId, ownerId }, viewerIds(viewers))
+}
+
+func TestGetDocumentViewers_AgesOut_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	readDocuments(t, documentService, editorId, documentId)
+	window := time.Minute
+	viewers, err := documentService.GetDocumentViewers(t.Context(), documentId, ownerId, window)
+	if err != nil {
+		t.Fatalf("failed to get the viewers of the document with error: %v", err)
+	}
+	verifyDocumentIds(t, []uuid.UUID{ editorId }, viewerIds(viewers))
+	// the read is older than the window once the clock has moved past the window
+	documentService.Now = func() time.Time { return time.Now().Add(window + time.Second) }
+	viewers, err = documentService.GetDocumentViewers(t.Context(), documentId, ownerId, window)
+	if err != nil {
+		t.Fatalf("failed to get the viewers of the document with error: %v", err)
+	}
+	verifyDocumentIds(t, nil, viewerIds(viewers))
+}
+
+func TestGetDocumentViewers_NotCollaborator_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	readDocuments(t, documentService, editorId, documentId)
+	_, err := documentService.GetDocumentViewers(t.Context(), documentId, uuid.New(), time.Minute)
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when a stranger lists the viewers of a document, got: %v", err)
+	}
+}
+
+func TestGetDocumentViewers_InvalidWindow_Unit(t *testing.T) {
+	// the window is checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	for _, window := range []time.Duration{ 0, -time.Minute, service.MaxDocumentViewersWindow + time.Second } {
+		_, err := documentService.GetDocumentViewers(t.Context(), uuid.New(), uuid.New(), window)
+		var serviceError *service.InvalidInputError
+		if !errors.As(err, &serviceError) {
+			t.Errorf("want: a service InvalidInputError for window: %v, got: %v", window, err)
+		}
+	}
+}
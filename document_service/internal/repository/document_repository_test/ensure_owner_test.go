@@ -0,0 +1,54 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestEnsureDocumentHasOwner_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	// a document that has an owner is left alone
+	repaired, err := documentService.EnsureDocumentHasOwner(t.Context(), documentId, editorId)
+	if err != nil {
+		t.Fatalf("failed to ensure the document has an owner with error: %v", err)
+	}
+	if repaired {
+		t.Errorf("want no repair for a document that has an owner")
+	}
+	if permission := getOwnPermission(t, documentService, documentId, editorId); permission.PermissionLevel != service.Editor {
+		t.Errorf("want the editor to keep permission level: %v, got: %v", service.Editor, permission.PermissionLevel)
+	}
+	// remove the owner through the repository, the service does not allow this
+	if err = documentRepo.DeletePermissionsPrincipal(t.Context(), ownerId, documentId); err != nil {
+		t.Fatalf("failed to delete the owner permission with error: %v", err)
+	}
+	repaired, err = documentService.EnsureDocumentHasOwner(t.Context(), documentId, editorId)
+	if err != nil {
+		t.Fatalf("failed to ensure the document has an owner with error: %v", err)
+	}
+	if !repaired {
+		t.Errorf("want a repair for a document without an owner")
+	}
+	if permission := getOwnPermission(t, documentService, documentId, editorId); permission.PermissionLevel != service.Owner {
+		t.Errorf("want the fallback owner to have permission level: %v, got: %v", service.Owner, permission.PermissionLevel)
+	}
+	// a second call finds the new owner
+	repaired, err = documentService.EnsureDocumentHasOwner(t.Context(), documentId, uuid.New())
+	if err != nil || repaired {
+		t.Errorf("want no repair once the document has an owner, got repaired: %v with error: %v", repaired, err)
+	}
+}
+
+func TestEnsureDocumentHasOwner_NotFound_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	_, err := documentService.EnsureDocumentHasOwner(t.Context(), uuid.New(), uuid.New())
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want not found error for a missing document, got: %v", err)
+	}
+}
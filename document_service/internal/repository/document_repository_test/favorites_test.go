@@ -0,0 +1,134 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/repository"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+// page through the favorites of the principal at the given permission levels, newest document first
+func listFavorites(
+	t *testing.T,
+	documentRepo *repository.DocumentRepository,
+	principalId uuid.UUID,
+	permissions []service.PermissionLevel,
+	pageSize int32,
+) []service.Document {
+	var documents []service.Document
+	cursor := service.NewBeginningCursor(service.CreatedAt)
+	for range 100 {
+		documentPermissions, respCursor, hasMore, err := documentRepo.ListDocumentsByPrincipal(
+			t.Context(), principalId, permissions, true, nil, false, cursor, pageSize,
+		)
+		if err != nil {
+			t.Fatalf("failed to list the favorites of the principal with error: %v", err)
+		}
+		for _, documentPermission := range documentPermissions {
+			documents = append(documents, documentPermission.Document)
+		}
+		if !hasMore {
+			return documents
+		}
+		cursor = respCursor
+	}
+	t.Fatalf("the traversal did not end after 100 pages")
+	return nil
+}
+
+func TestStarDocument_StarUnstar_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	if err := documentService.StarDocument(t.Context(), editorId, documentId); err != nil {
+		t.Fatalf("failed to star document with error: %v", err)
+	}
+	// starring a starred document is a no-op
+	if err := documentService.StarDocument(t.Context(), editorId, documentId); err != nil {
+		t.Fatalf("failed to star a starred document with error: %v", err)
+	}
+	verifyTraversal(t, []uuid.UUID{ documentId }, listFavorites(t, documentRepo, editorId, allPermissions, 10))
+	if err := documentService.UnstarDocument(t.Context(), editorId, documentId); err != nil {
+		t.Fatalf("failed to unstar document with error: %v", err)
+	}
+	verifyTraversal(t, nil, listFavorites(t, documentRepo, editorId, allPermissions, 10))
+	// unstarring a document that is not starred is a no-op
+	if err := documentService.UnstarDocument(t.Context(), editorId, documentId); err != nil {
+		t.Errorf("failed to unstar a document that is not starred with error: %v", err)
+	}
+}
+
+func TestStarDocument_NoPermission_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, _, _ := createDocumentWithEditor(t, documentService)
+	strangerId := uuid.New()
+	err := documentService.StarDocument(t.Context(), strangerId, documentId)
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Fatalf("want a permission denied error when starring a document without a permission, got: %v", err)
+	}
+	verifyTraversal(t, nil, listFavorites(t, documentRepo, strangerId, allPermissions, 10))
+}
+
+func TestListDocumentsByPrincipal_FavoritesOnly_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 7)
+	for _, i := range []int{ 0, 2, 3, 5, 6 } {
+		if err := documentService.StarDocument(t.Context(), ownerId, documentIds[i]); err != nil {
+			t.Fatalf("failed to star document with error: %v", err)
+		}
+	}
+	// the favorites are read newest first, a page at a time
+	want := []uuid.UUID{ documentIds[6], documentIds[5], documentIds[3], documentIds[2], documentIds[0] }
+	verifyTraversal(t, want, listFavorites(t, documentRepo, ownerId, allPermissions, 2))
+	// the documents that are not starred are still listed without the filter
+	documents := traverseDocuments(t, documentRepo, ownerId, service.NewBeginningCursor(service.CreatedAt), 10)
+	if len(documents) != len(documentIds) {
+		t.Errorf("want %d documents without the favorites filter, got: %d", len(documentIds), len(documents))
+	}
+}
+
+func TestListDocumentsByPrincipal_FavoritesPerPrincipal_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	if err := documentService.StarDocument(t.Context(), ownerId, documentId); err != nil {
+		t.Fatalf("failed to star document with error: %v", err)
+	}
+	// the editor has not starred the document
+	verifyTraversal(t, nil, listFavorites(t, documentRepo, editorId, allPermissions, 10))
+	if err := documentService.StarDocument(t.Context(), editorId, documentId); err != nil {
+		t.Fatalf("failed to star document with error: %v", err)
+	}
+	// the favorites filter is intersected with the permission filter
+	verifyTraversal(t, nil, listFavorites(t, documentRepo, editorId, []service.PermissionLevel{ service.Owner }, 10))
+	verifyTraversal(
+		t, []uuid.UUID{ documentId }, listFavorites(t, documentRepo, editorId, []service.PermissionLevel{ service.Editor }, 10),
+	)
+	// a favorite on a document that the principal lost access to is not listed
+	if err := documentService.LeaveDocument(t.Context(), editorId, documentId); err != nil {
+		t.Fatalf("failed to remove the editor from the document with error: %v", err)
+	}
+	verifyTraversal(t, nil, listFavorites(t, documentRepo, editorId, allPermissions, 10))
+}
+
+func TestDeleteDocument_Starred_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	for _, principalId := range []uuid.UUID{ ownerId, editorId } {
+		if err := documentService.StarDocument(t.Context(), principalId, documentId); err != nil {
+			t.Fatalf("failed to star document with error: %v", err)
+		}
+	}
+	// the favorites of the document are deleted with it
+	if err := documentRepo.DeleteDocument(t.Context(), documentId); err != nil {
+		t.Fatalf("failed to delete a starred document with error: %v", err)
+	}
+	verifyTraversal(t, nil, listFavorites(t, documentRepo, ownerId, allPermissions, 10))
+}
@@ -2,6 +2,7 @@ package document_repository_test
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -14,7 +15,7 @@ func TestCreateGuest_InvalidDocument_Integration(t *testing.T) {
 	documentRepo := createTestingDocumentRepo(t)
 	// call create guest on a document that does not exist in the database
 	_, err := documentRepo.CreateGuest(
-		t.Context(), uuid.New(), uuid.New(), service.Editor,
+		t.Context(), uuid.New(), uuid.New(), service.Editor, nil, service.DefaultMaxGuestsPerDocument,
 	)
 	// validate that the error is correct
 	if err == nil {
@@ -25,4 +26,347 @@ func TestCreateGuest_InvalidDocument_Integration(t *testing.T) {
 			t.Errorf("the wrong type of error was returned, want not found error, got: %v", err)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestCreateGuest_DefaultPermissionLevel_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	// guests created without a permission level are viewers
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), ownerId, documentId, guestId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the guest with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Viewer {
+		t.Errorf("want permission level: %v, got: %v", service.Viewer, permission.PermissionLevel)
+	}
+}
+
+func TestCreateGuest_ExplicitEditor_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	editor := service.Editor
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, &editor, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), ownerId, documentId, guestId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the guest with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Editor {
+		t.Errorf("want permission level: %v, got: %v", service.Editor, permission.PermissionLevel)
+	}
+}
+
+func TestCreateGuest_ExplicitOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	owner := service.Owner
+	_, err := documentService.CreateGuest(t.Context(), uuid.New(), uuid.New(), &owner, nil)
+	var invalidErr *service.InvalidInputError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when creating an owner guest, got: %v", err)
+	}
+}
+
+// list every permission on the document and return the labels of the guests by guest id
+func listGuestLabels(t *testing.T, documentService *service.DocumentService, documentId uuid.UUID) map[uuid.UUID]*string {
+	permissions, _, _, err := documentService.ListPermissionsOnDocument(
+		t.Context(), documentId, service.AllPermissions,
+		service.NewBeginningCursor(service.CreatedAt), service.MaxPageSize, nil,
+	)
+	if err != nil {
+		t.Fatalf("failed to list permissions on document with error: %v", err)
+	}
+	labels := make(map[uuid.UUID]*string)
+	for _, permission := range permissions {
+		if permission.RecipientType == service.Guest {
+			labels[permission.RecipientID] = permission.Label
+		} else if permission.Label != nil {
+			t.Errorf("want no label on the permission of user: %s, got: %s", permission.RecipientID, *permission.Label)
+		}
+	}
+	return labels
+}
+
+func TestGuestLabel_CreateAndList_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	label := "design review"
+	labeledId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, &label)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	unlabeledId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	labels := listGuestLabels(t, documentService, documentId)
+	if labels[labeledId] == nil || *labels[labeledId] != label {
+		t.Errorf("want label: %s for guest: %s, got: %v", label, labeledId, labels[labeledId])
+	}
+	if _, ok := labels[unlabeledId]; !ok || labels[unlabeledId] != nil {
+		t.Errorf("want guest: %s to be listed without a label, got: %v", unlabeledId, labels[unlabeledId])
+	}
+}
+
+func TestGuestLabel_Update_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	label := "printed handout"
+	if err = documentService.UpdateGuestLabel(t.Context(), ownerId, documentId, guestId, &label); err != nil {
+		t.Fatalf("failed to update guest label with error: %v", err)
+	}
+	labels := listGuestLabels(t, documentService, documentId)
+	if labels[guestId] == nil || *labels[guestId] != label {
+		t.Errorf("want label: %s for guest: %s, got: %v", label, guestId, labels[guestId])
+	}
+	// a nil label clears the label
+	if err = documentService.UpdateGuestLabel(t.Context(), ownerId, documentId, guestId, nil); err != nil {
+		t.Fatalf("failed to clear guest label with error: %v", err)
+	}
+	labels = listGuestLabels(t, documentService, documentId)
+	if labels[guestId] != nil {
+		t.Errorf("want the label of guest: %s to be cleared, got: %s", guestId, *labels[guestId])
+	}
+}
+
+func TestGuestLabel_NotOwner_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	label := "not allowed"
+	err = documentService.UpdateGuestLabel(t.Context(), editorId, documentId, guestId, &label)
+	var permissionErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionErr) {
+		t.Errorf("want a permission denied error when an editor labels a guest, got: %v", err)
+	}
+}
+
+func TestGuestLabel_OtherDocument_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	otherDocumentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, otherDocumentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	// a guest cannot be labeled through a document that it does not belong to
+	label := "wrong document"
+	err = documentService.UpdateGuestLabel(t.Context(), ownerId, documentId, guestId, &label)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want a not found error when labeling a guest of another document, got: %v", err)
+	}
+}
+
+func TestGuestLabel_TooLong_Unit(t *testing.T) {
+	// the label is rejected before the repository is called
+	documentService := service.NewDocumentService(nil)
+	label := strings.Repeat("a", service.MaxGuestLabelLength + 1)
+	_, err := documentService.CreateGuest(t.Context(), uuid.New(), uuid.New(), nil, &label)
+	var invalidErr *service.InvalidInputError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when creating a guest with a long label, got: %v", err)
+	}
+	err = documentService.UpdateGuestLabel(t.Context(), uuid.New(), uuid.New(), uuid.New(), &label)
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when updating a guest with a long label, got: %v", err)
+	}
+}
+
+func TestCreateGuests_ValidCount_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	editor := service.Editor
+	guestIds, err := documentService.CreateGuests(t.Context(), ownerId, documentId, 3, &editor)
+	if err != nil {
+		t.Fatalf("failed to create guests with error: %v", err)
+	}
+	if len(guestIds) != 3 {
+		t.Fatalf("want 3 guest ids, got: %v", guestIds)
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, guestId := range guestIds {
+		if seen[guestId] {
+			t.Errorf("want independent guests, got guest: %s twice", guestId)
+		}
+		seen[guestId] = true
+		permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), ownerId, documentId, guestId, nil)
+		if err != nil {
+			t.Fatalf("failed to get the permission of the guest with error: %v", err)
+		}
+		if permission.PermissionLevel != service.Editor || permission.RecipientType != service.Guest {
+			t.Errorf("want guest: %s to be an editor guest, got: %+v", guestId, permission)
+		}
+	}
+}
+
+func TestCreateGuests_NotOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	_, err := documentService.CreateGuests(t.Context(), editorId, documentId, 2, nil)
+	var permissionErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionErr) {
+		t.Errorf("want a permission denied error when an editor creates guests, got: %v", err)
+	}
+}
+
+func TestCreateGuests_OverCap_Integration(t *testing.T) {
+	documentService := service.NewDocumentServiceWithMaxGuestBatchSize(createTestingDocumentRepo(t), 5)
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	for _, count := range []int32{ 0, 6 } {
+		_, err := documentService.CreateGuests(t.Context(), ownerId, documentId, count, nil)
+		var invalidErr *service.InvalidInputError
+		if !errors.As(err, &invalidErr) {
+			t.Errorf("want invalid input error when creating %d guests with a cap of 5, got: %v", count, err)
+		}
+	}
+	// no guests were created by the rejected calls
+	if labels := listGuestLabels(t, documentService, documentId); len(labels) != 0 {
+		t.Errorf("want no guests on the document, got: %d", len(labels))
+	}
+}
+
+func TestCreateGuest_MaxGuestsPerDocument_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentServiceWithGuestLimits(documentRepo, service.DefaultMaxGuestBatchSize, 3)
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	// fill the document up to the limit with one single guest and one batch
+	if _, err = documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil); err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	if _, err = documentService.CreateGuests(t.Context(), ownerId, documentId, 2, nil); err != nil {
+		t.Fatalf("failed to create guests with error: %v", err)
+	}
+	var quotaErr *service.QuotaExceededError
+	_, err = documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if !errors.As(err, &quotaErr) {
+		t.Errorf("want quota exceeded error when creating a guest past the limit, got: %v", err)
+	}
+	_, err = documentService.CreateGuests(t.Context(), ownerId, documentId, 1, nil)
+	if !errors.As(err, &quotaErr) {
+		t.Errorf("want quota exceeded error when creating guests past the limit, got: %v", err)
+	}
+	if labels := listGuestLabels(t, documentService, documentId); len(labels) != 3 {
+		t.Errorf("want 3 guests on the document, got: %d", len(labels))
+	}
+}
+
+func TestCreateGuests_BatchOverMaxGuestsPerDocument_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentServiceWithGuestLimits(documentRepo, service.DefaultMaxGuestBatchSize, 3)
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	if _, err = documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil); err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	// a batch that would cross the limit is rejected as a whole
+	_, err = documentService.CreateGuests(t.Context(), ownerId, documentId, 3, nil)
+	var quotaErr *service.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		t.Errorf("want quota exceeded error when a batch crosses the limit, got: %v", err)
+	}
+	if labels := listGuestLabels(t, documentService, documentId); len(labels) != 1 {
+		t.Errorf("want 1 guest on the document, got: %d", len(labels))
+	}
+}
+
+// a policy that only allows read only share links
+func newViewerOnlyGuestService(documentRepo service.DocumentRepository) *service.DocumentService {
+	return service.NewDocumentServiceWithGuestPolicy(
+		documentRepo, service.DefaultMaxGuestBatchSize, service.DefaultMaxGuestsPerDocument,
+		[]service.PermissionLevel{ service.Viewer },
+	)
+}
+
+func TestGuestPolicy_ViewerOnly_RejectsEditor_Unit(t *testing.T) {
+	// the permission level is checked before the repository is called, so no repository is needed
+	documentService := newViewerOnlyGuestService(nil)
+	editor := service.Editor
+	var invalidErr *service.InvalidInputError
+	_, err := documentService.CreateGuest(t.Context(), uuid.New(), uuid.New(), &editor, nil)
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when creating an editor guest, got: %v", err)
+	}
+	_, err = documentService.CreateGuests(t.Context(), uuid.New(), uuid.New(), 2, &editor)
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when creating editor guests, got: %v", err)
+	}
+	err = documentService.UpdatePermissionGuest(t.Context(), uuid.New(), service.Editor)
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when updating a guest to editor, got: %v", err)
+	}
+}
+
+func TestGuestPolicy_ViewerOnly_AllowsViewer_Integration(t *testing.T) {
+	documentService := newViewerOnlyGuestService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	viewer := service.Viewer
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, &viewer, nil)
+	if err != nil {
+		t.Fatalf("failed to create viewer guest with error: %v", err)
+	}
+	if err = documentService.UpdatePermissionGuest(t.Context(), guestId, service.Viewer); err != nil {
+		t.Errorf("failed to update guest to viewer with error: %v", err)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), ownerId, documentId, guestId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the guest with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Viewer {
+		t.Errorf("want permission level: %v, got: %v", service.Viewer, permission.PermissionLevel)
+	}
+}
@@ -0,0 +1,109 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise rotating the link of a guest:
+- rotating bumps the token version of the guest and the guest keeps its permission
+- only the owner of the document of the guest can rotate its link
+- rotating the link of a guest that does not exist is not found
+- only the guest can read its own token version
+*/
+
+func readGuestTokenVersion(t *testing.T, documentService *service.DocumentService, guestId uuid.UUID) int32 {
+	tokenVersion, err := documentService.GetGuestTokenVersion(t.Context(), guestId, guestId)
+	if err != nil {
+		t.Fatalf("failed to get the token version of the guest with error: %v", err)
+	}
+	return tokenVersion
+}
+
+func TestRotateGuestLink_BumpsVersion_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	if tokenVersion := readGuestTokenVersion(t, documentService, guestId); tokenVersion != 0 {
+		t.Errorf("want a new guest to start at token version 0, got: %d", tokenVersion)
+	}
+	for want := int32(1); want <= 2; want++ {
+		tokenVersion, err := documentService.RotateGuestLink(t.Context(), guestId, ownerId)
+		if err != nil {
+			t.Fatalf("failed to rotate the link of the guest with error: %v", err)
+		}
+		if tokenVersion != want {
+			t.Errorf("want token version: %d after rotating, got: %d", want, tokenVersion)
+		}
+		if stored := readGuestTokenVersion(t, documentService, guestId); stored != want {
+			t.Errorf("want the stored token version: %d after rotating, got: %d", want, stored)
+		}
+	}
+	// the guest keeps its permission on the document
+	if permission, ok := readPermissions(t, documentService, documentId)[guestId]; !ok || permission.PermissionLevel != service.DefaultGuestPermissionLevel {
+		t.Errorf("want the guest to keep its permission after rotating, got: %+v with present: %v", permission, ok)
+	}
+}
+
+func TestRotateGuestLink_NotOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	_, err = documentService.RotateGuestLink(t.Context(), guestId, editorId)
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Fatalf("want a permission denied error when an editor rotates the link of a guest, got: %v", err)
+	}
+	if tokenVersion := readGuestTokenVersion(t, documentService, guestId); tokenVersion != 0 {
+		t.Errorf("want the token version to be unchanged, got: %d", tokenVersion)
+	}
+}
+
+func TestRotateGuestLink_UnknownGuest_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	_, err := documentService.RotateGuestLink(t.Context(), uuid.New(), uuid.New())
+	var notFound *service.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("want a not found error when rotating the link of an unknown guest, got: %v", err)
+	}
+	unknownGuestId := uuid.New()
+	_, err = documentService.GetGuestTokenVersion(t.Context(), unknownGuestId, unknownGuestId)
+	if !errors.As(err, &notFound) {
+		t.Errorf("want a not found error when reading the token version of an unknown guest, got: %v", err)
+	}
+}
+
+func TestGetGuestTokenVersion_OtherPrincipal_Unit(t *testing.T) {
+	// the caller is checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	_, err := documentService.GetGuestTokenVersion(t.Context(), uuid.New(), uuid.New())
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when reading the token version of another guest, got: %v", err)
+	}
+	_, err = documentService.GetGuestTokenVersion(t.Context(), uuid.Nil, uuid.New())
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for a nil caller id, got: %v", err)
+	}
+}
+
+func TestRotateGuestLink_NilGuestId_Unit(t *testing.T) {
+	// the guest id is checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	_, err := documentService.RotateGuestLink(t.Context(), uuid.Nil, uuid.New())
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for a nil guest id, got: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package document_repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/townsag/reed/document_service/internal/repository"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+// embedding the interface satisfies it without a database, calling a method that is not
+// overridden panics
+type stubDocumentRepository struct {
+	service.DocumentRepository
+}
+
+func (s stubDocumentRepository) GetDocument(ctx context.Context, documentId uuid.UUID) (*service.Document, error) {
+	return &service.Document{ ID: documentId }, nil
+}
+
+func TestInstrumentedDocumentRepository_RecordsMethod_Unit(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	instrumentedRepo, err := repository.NewInstrumentedDocumentRepository(stubDocumentRepository{}, meterProvider)
+	if err != nil {
+		t.Fatalf("failed to create instrumented repository with error: %v", err)
+	}
+	documentId := uuid.New()
+	document, err := instrumentedRepo.GetDocument(t.Context(), documentId)
+	if err != nil || document.ID != documentId {
+		t.Fatalf("want the document from the wrapped repository, got: %v, %v", document, err)
+	}
+	var resourceMetrics metricdata.ResourceMetrics
+	if err = reader.Collect(t.Context(), &resourceMetrics); err != nil {
+		t.Fatalf("failed to collect metrics with error: %v", err)
+	}
+	for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			if m.Name != repository.MethodDurationMetric {
+				continue
+			}
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				t.Fatalf("want a float64 histogram, got: %T", m.Data)
+			}
+			if len(histogram.DataPoints) != 1 {
+				t.Fatalf("want 1 data point, got: %d", len(histogram.DataPoints))
+			}
+			dataPoint := histogram.DataPoints[0]
+			method, _ := dataPoint.Attributes.Value(attribute.Key("method"))
+			if method.AsString() != "GetDocument" {
+				t.Errorf("want method attribute: GetDocument, got: %v", method.AsString())
+			}
+			if dataPoint.Count != 1 {
+				t.Errorf("want 1 measurement, got: %d", dataPoint.Count)
+			}
+			return
+		}
+	}
+	t.Errorf("no %s metric was recorded", repository.MethodDurationMetric)
+}
@@ -0,0 +1,52 @@
+package document_repository_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestLeaveDocument_Editor_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	editorId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 1)
+	_, err := documentService.UpsertPermissionUser(t.Context(), ownerId, editorId, documentIds[0], service.Editor)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	if err = documentService.LeaveDocument(t.Context(), editorId, documentIds[0]); err != nil {
+		t.Fatalf("failed to leave document with error: %v", err)
+	}
+	owner := service.Owner
+	verifyPermissionLevel(t, documentRepo, documentIds[0], editorId, nil)
+	verifyPermissionLevel(t, documentRepo, documentIds[0], ownerId, &owner)
+	// leaving again finds no permission
+	err = documentService.LeaveDocument(t.Context(), editorId, documentIds[0])
+	var notFound *service.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("want: a service NotFoundError when leaving a document twice, got: %v", err)
+	}
+}
+
+func TestLeaveDocument_Owner_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 1)
+	err := documentService.LeaveDocument(t.Context(), ownerId, documentIds[0])
+	var invalidInput *service.InvalidInputError
+	if !errors.As(err, &invalidInput) {
+		t.Fatalf("want: a service InvalidInputError when the owner leaves, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "owner cannot leave") {
+		t.Errorf("want an error that explains that the owner cannot leave, got: %v", err)
+	}
+	owner := service.Owner
+	verifyPermissionLevel(t, documentRepo, documentIds[0], ownerId, &owner)
+}
@@ -0,0 +1,97 @@
+package document_repository_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+// page through all documents that match the filters and return them in the order listed
+func listAllDocumentPages(
+	t *testing.T, documentService *service.DocumentService, pageSize int32, filters service.DocumentFilters,
+) []service.Document {
+	var documents []service.Document
+	var cursor *service.Cursor
+	for {
+		page, cursorResp, hasMore, err := documentService.ListAllDocuments(t.Context(), cursor, pageSize, filters)
+		if err != nil {
+			t.Fatalf("failed to list all documents with error: %v", err)
+		}
+		if int32(len(page)) > pageSize {
+			t.Fatalf("want at most %d documents in a page, got: %d", pageSize, len(page))
+		}
+		documents = append(documents, page...)
+		if !hasMore {
+			return documents
+		}
+		cursor = cursorResp
+	}
+}
+
+func TestListAllDocuments_Pagination_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 5)
+	otherIds := createDocuments(t, documentRepo, uuid.New(), 2)
+	// the owner filter only lists the documents of the owner, newest first
+	slices.Reverse(documentIds)
+	verifyTraversal(t, documentIds, listAllDocumentPages(t, documentService, 2, service.DocumentFilters{ OwnerID: &ownerId }))
+	// without filters the documents of every owner are listed, other tests also create documents
+	// so only check that ours are listed once and that the order is newest first
+	all := listAllDocumentPages(t, documentService, service.MaxPageSize, service.DocumentFilters{})
+	seen := make(map[uuid.UUID]int)
+	for i, document := range all {
+		seen[document.ID]++
+		if i > 0 && document.CreatedAt.After(all[i - 1].CreatedAt) {
+			t.Errorf("want documents newest first, document: %s was created after the document before it", document.ID)
+		}
+	}
+	for _, documentId := range append(documentIds, otherIds...) {
+		if seen[documentId] != 1 {
+			t.Errorf("want document: %s to be listed once, got: %d", documentId, seen[documentId])
+		}
+	}
+}
+
+func TestListAllDocuments_CreatedBefore_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 3)
+	cutoff, err := documentRepo.GetDocument(t.Context(), documentIds[1])
+	if err != nil {
+		t.Fatalf("failed to get the document with error: %v", err)
+	}
+	// documents created at the cutoff are excluded
+	documents := listAllDocumentPages(t, documentService, 2, service.DocumentFilters{
+		OwnerID: &ownerId,
+		CreatedBefore: &cutoff.CreatedAt,
+	})
+	verifyTraversal(t, []uuid.UUID{ documentIds[0] }, documents)
+	// documents created at the cutoff are included by created after
+	documents = listAllDocumentPages(t, documentService, 2, service.DocumentFilters{
+		OwnerID: &ownerId,
+		CreatedAfter: &cutoff.CreatedAt,
+	})
+	verifyTraversal(t, []uuid.UUID{ documentIds[2], documentIds[1] }, documents)
+}
+
+func TestListAllDocuments_InvalidFilters_Unit(t *testing.T) {
+	// the filters are rejected before the repository is called
+	documentService := service.NewDocumentService(nil)
+	createdBefore := time.Now()
+	createdAfter := createdBefore.Add(time.Hour)
+	_, _, _, err := documentService.ListAllDocuments(t.Context(), nil, service.DefaultPageSize, service.DocumentFilters{
+		CreatedBefore: &createdBefore,
+		CreatedAfter: &createdAfter,
+	})
+	var invalidErr *service.InvalidInputError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when created after is not before created before, got: %v", err)
+	}
+}
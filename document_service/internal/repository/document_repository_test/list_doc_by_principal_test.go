@@ -22,7 +22,7 @@ func TestListDocumentsByPrincipal_OnUser_DeleteDocumentPath_Integration(t *testi
 	// create a dummy user id
 	userId := uuid.New()
 	// create a document for that user
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
@@ -37,7 +37,7 @@ func TestListDocumentsByPrincipal_OnUser_DeleteDocumentPath_Integration(t *testi
 	// then traverse the created at index in descending order
 	cursor := service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now(), LastSeenID: service.MaxDocumentID() }
 	// view that document in the response from ListDocumentsByPrincipal
-	documentPermissions, respCursor, err := documentRepo.ListDocumentsByPrincipal(t.Context(), userId, permissionsFilter, &cursor, 10)
+	documentPermissions, respCursor, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), userId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -71,7 +71,7 @@ func TestListDocumentsByPrincipal_OnUser_DeleteDocumentPath_Integration(t *testi
 		t.Fatalf("failed to delete document with error: %v", err)
 	}
 	// verify that the document cannot be viewed in the result of ListDocumentsByPrincipal
-	documentPermissions, respCursor, err = documentRepo.ListDocumentsByPrincipal(t.Context(), userId, permissionsFilter, &cursor, 10)
+	documentPermissions, respCursor, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), userId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -100,14 +100,14 @@ func TestListDocumentsByPrincipal_OnUser_DeletePermissionPath_Integration(t *tes
 	// create a dummy user id
 	userId := uuid.New()
 	// create a document for that user
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// create a dummy recipient user
 	recipientUserId := uuid.New()
 	// share the document with the recipient user
-	err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentId, service.Editor)
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentId, service.Editor)
 	if err != nil {
 		t.Fatalf("failed to create a permission on a document with error: %v", err)
 	}
@@ -117,7 +117,7 @@ func TestListDocumentsByPrincipal_OnUser_DeletePermissionPath_Integration(t *tes
 	// then traverse the created at index in descending order
 	cursor := service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now(), LastSeenID: service.MaxDocumentID() }
 	// view that document in the response from ListDocumentsByPrincipal for the recipient user
-	documentPermissions, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), recipientUserId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), recipientUserId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -144,7 +144,7 @@ func TestListDocumentsByPrincipal_OnUser_DeletePermissionPath_Integration(t *tes
 		t.Fatalf("failed to delete permission on a document for the recipient user with error: %v", err)
 	}
 	// verify that the document cannot be viewed in the result of ListDocumentsByPrincipal
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), recipientUserId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), recipientUserId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -160,14 +160,14 @@ func TestListDocumentsByPrincipal_OnUser_UpdatePermissionPath_Integration(t *tes
 	// create a dummy user id
 	userId := uuid.New()
 	// create a document for that user
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// create a dummy recipient user
 	recipientUserId := uuid.New()
 	// share the document with the recipient user
-	err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentId, service.Editor)
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentId, service.Editor)
 	if err != nil {
 		t.Fatalf("failed to create a permission on a document with error: %v", err)
 	}
@@ -177,7 +177,7 @@ func TestListDocumentsByPrincipal_OnUser_UpdatePermissionPath_Integration(t *tes
 	// then traverse the created at index in descending order
 	cursor := service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now(), LastSeenID: service.MaxDocumentID() }
 	// view that document in the response from ListDocumentsByPrincipal
-	documentPermissions, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), recipientUserId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), recipientUserId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -199,12 +199,12 @@ func TestListDocumentsByPrincipal_OnUser_UpdatePermissionPath_Integration(t *tes
 		t.Fatalf("failed to retrieve the created document %s, got this list of document permissions: %v",documentId, documentPermissions)
 	}
 	// modify the recipient users permission on the document
-	err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentId, service.Viewer)
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentId, service.Viewer)
 	if err != nil {
 		t.Fatalf("failed to update permission on a document for the recipient user with error: %v", err)
 	}
 	// verify that the document can be viewed in the result of ListDocumentsByPrincipal with the updated permission
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), recipientUserId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), recipientUserId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -233,12 +233,12 @@ func TestListDocumentsByPrincipal_OnGuest_DeleteDocumentPath_Integration(t *test
 	// create a dummy user id
 	userId := uuid.New()
 	// create a document for that user
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// create a guest on that document
-	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor)
+	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor, nil, service.DefaultMaxGuestsPerDocument)
 	if err != nil {
 		t.Fatalf("failed to create a guest with error: %v", guestId)
 	}
@@ -248,7 +248,7 @@ func TestListDocumentsByPrincipal_OnGuest_DeleteDocumentPath_Integration(t *test
 	// then traverse the created at index in descending order
 	cursor := service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now(), LastSeenID: service.MaxDocumentID() }
 	// view that document in the response from ListDocumentsByPrincipal for the recipient user
-	documentPermissions, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -275,7 +275,7 @@ func TestListDocumentsByPrincipal_OnGuest_DeleteDocumentPath_Integration(t *test
 		t.Fatalf("failed to delete the document with error: %v", err)
 	}
 	// verify that the document cannot be viewed in the result of ListDocumentsByPrincipal
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -291,12 +291,12 @@ func TestListDocumentsByPrincipal_OnGuest_DeletePermissionPath_Integration(t *te
 	// create a dummy user id
 	userId := uuid.New()
 	// create a document for that user
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// create a guest on that document
-	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor)
+	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor, nil, service.DefaultMaxGuestsPerDocument)
 	if err != nil {
 		t.Fatalf("failed to create a guest with error: %v", guestId)
 	}
@@ -306,7 +306,7 @@ func TestListDocumentsByPrincipal_OnGuest_DeletePermissionPath_Integration(t *te
 	// then traverse the created at index in descending order
 	cursor := service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now(), LastSeenID: service.MaxDocumentID() }
 	// view that document in the response from ListDocumentsByPrincipal for the recipient user
-	documentPermissions, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -333,7 +333,7 @@ func TestListDocumentsByPrincipal_OnGuest_DeletePermissionPath_Integration(t *te
 		t.Fatalf("failed to delete the guests permission on a document with error: %v", err)
 	}
 	// verify that the document cannot be viewed in the result of ListDocumentsByPrincipal
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -348,12 +348,12 @@ func TestListDocumentsByPrincipal_OnGuest_UpdatePermissionPath_Integration(t *te
 	// create a dummy user id
 	userId := uuid.New()
 	// create a document for that user
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// create a guest on that document
-	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor)
+	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor, nil, service.DefaultMaxGuestsPerDocument)
 	if err != nil {
 		t.Fatalf("failed to create a guest with error: %v", guestId)
 	}
@@ -363,7 +363,7 @@ func TestListDocumentsByPrincipal_OnGuest_UpdatePermissionPath_Integration(t *te
 	// then traverse the created at index in descending order
 	cursor := service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now(), LastSeenID: service.MaxDocumentID() }
 	// view that document in the response from ListDocumentsByPrincipal for the recipient user
-	documentPermissions, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -390,7 +390,7 @@ func TestListDocumentsByPrincipal_OnGuest_UpdatePermissionPath_Integration(t *te
 		t.Fatalf("failed to delete the guests permission on a document with error: %v", err)
 	}
 	// verify that the document cannot be viewed in the result of ListDocumentsByPrincipal
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, &cursor, 10)
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), guestId, permissionsFilter, false, nil, false, &cursor, 10)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
 	}
@@ -435,20 +435,20 @@ func TestListDocumentsByPrincipal_PermissionFiltering_Integration(t *testing.T)
 	// create a dummy recipient user
 	recipientUserId := uuid.New()
 	// create two documents
-	documentIdA, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentIdA, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create document with error: %v", err)
 	}
-	documentIdB, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentIdB, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create document with error: %v", err)
 	}
 	// share the two documents with the recipient user at editor and viewer level
-	err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentIdA, service.Editor)
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentIdA, service.Editor)
 	if err != nil {
 		t.Fatalf("failed to share document with user with error: %v", err)
 	}
-	err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentIdB, service.Viewer)
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientUserId, documentIdB, service.Viewer)
 	if err != nil {
 		t.Fatalf("failed to share document with user with error: %v", err)
 	}
@@ -459,8 +459,8 @@ func TestListDocumentsByPrincipal_PermissionFiltering_Integration(t *testing.T)
 		LastSeenTime: time.Now(),
 		LastSeenID: service.MaxDocumentID(),
 	}
-	documentPermissions, _, err := documentRepo.ListDocumentsByPrincipal(
-		t.Context(), userId, permissions, cursor, 10,
+	documentPermissions, _, _, err := documentRepo.ListDocumentsByPrincipal(
+		t.Context(), userId, permissions, false, nil, false, cursor, 10,
 
 	)
 	if err != nil {
@@ -474,8 +474,8 @@ func TestListDocumentsByPrincipal_PermissionFiltering_Integration(t *testing.T)
 	}
 	// verify that the user can see no documents when filtering on editor permissions
 	permissions = []service.PermissionLevel{service.Editor}
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(
-		t.Context(), userId, permissions, cursor, 10,
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(
+		t.Context(), userId, permissions, false, nil, false, cursor, 10,
 	)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
@@ -485,8 +485,8 @@ func TestListDocumentsByPrincipal_PermissionFiltering_Integration(t *testing.T)
 	}
 	// verify that the recipient user can see no documents when filtering on the owner permission
 	permissions = []service.PermissionLevel{ service.Owner }
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(
-		t.Context(), recipientUserId, permissions, cursor, 10,
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(
+		t.Context(), recipientUserId, permissions, false, nil, false, cursor, 10,
 	)
 	if err != nil {
 		t.Fatalf("failed to read documents by principal with error: %v", err)
@@ -500,8 +500,8 @@ func TestListDocumentsByPrincipal_PermissionFiltering_Integration(t *testing.T)
 	}
 	// verify that the recipient user can see the first document when filtering on the editor permission
 	permissions = []service.PermissionLevel{ service.Editor }
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(
-		t.Context(), recipientUserId, permissions, cursor, 10,
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(
+		t.Context(), recipientUserId, permissions, false, nil, false, cursor, 10,
 	)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
@@ -511,8 +511,8 @@ func TestListDocumentsByPrincipal_PermissionFiltering_Integration(t *testing.T)
 	}
 	// verify that the recipient user can see the second document when filtering on the viewer permission
 	permissions = []service.PermissionLevel{ service.Viewer }
-	documentPermissions, _, err = documentRepo.ListDocumentsByPrincipal(
-		t.Context(), recipientUserId, permissions, cursor, 10,
+	documentPermissions, _, _, err = documentRepo.ListDocumentsByPrincipal(
+		t.Context(), recipientUserId, permissions, false, nil, false, cursor, 10,
 	)
 	if err != nil {
 		t.Fatalf("failed to list documents by principal with error: %v", err)
@@ -527,8 +527,8 @@ func TestListDocumentsByPrincipal_NilCursor_Unit(t *testing.T) {
 	// create a document repository struct with zero value for database connection
 	documentRepo := &repository.DocumentRepository{}
 	// verify that calling list documents by principal with a nil cursor returns an error
-	_, _, err := documentRepo.ListDocumentsByPrincipal(
-		t.Context(), uuid.New(), []service.PermissionLevel{service.Editor }, nil, 10,
+	_, _, _, err := documentRepo.ListDocumentsByPrincipal(
+		t.Context(), uuid.New(), []service.PermissionLevel{service.Editor }, false, nil, false, nil, 10,
 	)
 	if err == nil {
 		t.Errorf("expected an error when calling with bad cursor but instead received nil")
@@ -550,8 +550,8 @@ func TestListDocumentsByPrincipal_EmptyPermissionsFilter_Unit(t *testing.T) {
 		LastSeenTime: time.Now(),
 		LastSeenID: service.MaxDocumentID(),
 	}
-	_, _, err := documentRepo.ListDocumentsByPrincipal(
-		t.Context(), uuid.New(), permissions, cursor, 10,
+	_, _, _, err := documentRepo.ListDocumentsByPrincipal(
+		t.Context(), uuid.New(), permissions, false, nil, false, cursor, 10,
 	)
 	if err == nil {
 		t.Error("expected an error when calling with an empty permissions array but instead received nil")
@@ -574,8 +574,8 @@ func TestListDocumentsByPrincipal_InvalidPermissionsFilter_Unit(t *testing.T) {
 		LastSeenTime: time.Now(),
 		LastSeenID: service.MaxDocumentID(),
 	}
-	_, _, err := documentRepo.ListDocumentsByPrincipal(
-		t.Context(), uuid.New(), permissions, cursor, 10,
+	_, _, _, err := documentRepo.ListDocumentsByPrincipal(
+		t.Context(), uuid.New(), permissions, false, nil, false, cursor, 10,
 	)
 	if err == nil {
 		t.Error("expected an error when calling with an invalid permission but instead received nil")
@@ -585,4 +585,306 @@ func TestListDocumentsByPrincipal_InvalidPermissionsFilter_Unit(t *testing.T) {
 			t.Errorf("want: a service InvalidInputError, got: %v", err)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestListDocumentsByPrincipal_InvalidSortField_Unit(t *testing.T) {
+	// create a document repository struct with zero value for database connection, the sort
+	// field is checked before a connection is acquired. Accessed at is only valid for the
+	// recently accessed documents
+	documentRepo := &repository.DocumentRepository{}
+	for _, sortField := range []service.SortField{ -1, service.AccessedAt, 42 } {
+		cursor := &service.Cursor{
+			SortField: sortField,
+			LastSeenTime: time.Now(),
+			LastSeenID: service.MaxDocumentID(),
+		}
+		_, _, _, err := documentRepo.ListDocumentsByPrincipal(
+			t.Context(), uuid.New(), []service.PermissionLevel{ service.Editor }, false, nil, false, cursor, 10,
+		)
+		var serviceError *service.InvalidInputError
+		if !errors.As(err, &serviceError) {
+			t.Errorf("want: a service InvalidInputError for sort field: %v, got: %v", sortField, err)
+		}
+	}
+}
+
+func TestListDocumentsByPrincipal_InvalidPageSize_Unit(t *testing.T) {
+	// create a document repository struct with zero value for database connection
+	documentRepo := &repository.DocumentRepository{}
+	cursor := service.NewBeginningCursor(service.CreatedAt)
+	_, _, _, err := documentRepo.ListDocumentsByPrincipal(
+		t.Context(), uuid.New(), []service.PermissionLevel{ service.Editor }, false, nil, false, cursor, 0,
+	)
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for a page size of zero, got: %v", err)
+	}
+}
+
+// find the document permission for the given document in the first page of documents listed for
+// the principal, fails the test if the document is not found
+func findDocumentPermission(
+	t *testing.T,
+	documentRepo *repository.DocumentRepository,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+) service.DocumentPermission {
+	permissionsFilter := []service.PermissionLevel{service.Editor, service.Owner, service.Viewer}
+	cursor := service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now(), LastSeenID: service.MaxDocumentID() }
+	documentPermissions, _, _, err := documentRepo.ListDocumentsByPrincipal(t.Context(), principalId, permissionsFilter, false, nil, false, &cursor, 10)
+	if err != nil {
+		t.Fatalf("failed to list documents by principal with error: %v", err)
+	}
+	for _, documentPermission := range documentPermissions {
+		if documentPermission.Document.ID == documentId {
+			return documentPermission
+		}
+	}
+	t.Fatalf("failed to retrieve the document %s, got this list of document permissions: %v", documentId, documentPermissions)
+	return service.DocumentPermission{}
+}
+
+func TestListDocumentsByPrincipal_PermissionTimestamps_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	ownerId := uuid.New()
+	recipientId := uuid.New()
+	documentId, err := documentRepo.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create a document with error: %v", err)
+	}
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share the document with error: %v", err)
+	}
+	// the grant timestamps should be populated from the permission row
+	granted := findDocumentPermission(t, documentRepo, recipientId, documentId)
+	if granted.PermissionCreatedAt.IsZero() || granted.PermissionLastModifiedAt.IsZero() {
+		t.Fatalf(
+			"expected the permission timestamps to be populated, got created at: %v, last modified at: %v",
+			granted.PermissionCreatedAt, granted.PermissionLastModifiedAt,
+		)
+	}
+	// updating the permission should move the last modified timestamp but not the created timestamp
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
+	if err != nil {
+		t.Fatalf("failed to update the permission with error: %v", err)
+	}
+	updated := findDocumentPermission(t, documentRepo, recipientId, documentId)
+	if updated.Permission != service.Editor {
+		t.Errorf("want permission level: %v, got: %v", service.Editor, updated.Permission)
+	}
+	if !updated.PermissionCreatedAt.Equal(granted.PermissionCreatedAt) {
+		t.Errorf(
+			"the permission created at timestamp changed after an update, want: %v, got: %v",
+			granted.PermissionCreatedAt, updated.PermissionCreatedAt,
+		)
+	}
+	if !updated.PermissionLastModifiedAt.After(granted.PermissionLastModifiedAt) {
+		t.Errorf(
+			"expected the permission last modified at timestamp to advance past %v, got: %v",
+			granted.PermissionLastModifiedAt, updated.PermissionLastModifiedAt,
+		)
+	}
+}
+
+func TestListDocumentsByPrincipal_PermissionUnchangedLevel_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	recipientId := uuid.New()
+	documentId, err := documentRepo.CreateDocument(t.Context(), uuid.New(), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create a document with error: %v", err)
+	}
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share the document with error: %v", err)
+	}
+	granted := findDocumentPermission(t, documentRepo, recipientId, documentId)
+	// sharing again at the same level is a no op, the permission is not rewritten
+	created, err := documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share the document again with error: %v", err)
+	}
+	if created {
+		t.Errorf("expected sharing again at the same level to not report a created permission")
+	}
+	unchanged := findDocumentPermission(t, documentRepo, recipientId, documentId)
+	if !unchanged.PermissionLastModifiedAt.Equal(granted.PermissionLastModifiedAt) {
+		t.Errorf(
+			"the permission last modified at timestamp changed after sharing at the same level, want: %v, got: %v",
+			granted.PermissionLastModifiedAt, unchanged.PermissionLastModifiedAt,
+		)
+	}
+	// a real change of level still updates the permission
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
+	if err != nil {
+		t.Fatalf("failed to update the permission with error: %v", err)
+	}
+	updated := findDocumentPermission(t, documentRepo, recipientId, documentId)
+	if !updated.PermissionLastModifiedAt.After(granted.PermissionLastModifiedAt) {
+		t.Errorf(
+			"expected the permission last modified at timestamp to advance past %v, got: %v",
+			granted.PermissionLastModifiedAt, updated.PermissionLastModifiedAt,
+		)
+	}
+}
+
+// ========== ListDocumentsByPrincipal: Cursor pagination ========== //
+var allPermissions = []service.PermissionLevel{ service.Editor, service.Owner, service.Viewer }
+
+// page through the documents of the principal starting from the given cursor until hasMore is
+// false, returns the listed documents in the order that they were returned
+func traverseDocuments(
+	t *testing.T,
+	documentRepo *repository.DocumentRepository,
+	principalId uuid.UUID,
+	cursor *service.Cursor,
+	pageSize int32,
+) []service.Document {
+	var documents []service.Document
+	// bound the number of pages so that a cursor that does not advance fails the test
+	for range 100 {
+		documentPermissions, respCursor, hasMore, err := documentRepo.ListDocumentsByPrincipal(
+			t.Context(), principalId, allPermissions, false, nil, false, cursor, pageSize,
+		)
+		if err != nil {
+			t.Fatalf("failed to list documents by principal with error: %v", err)
+		}
+		if int32(len(documentPermissions)) > pageSize {
+			t.Fatalf("want at most %d documents in a page, got: %d", pageSize, len(documentPermissions))
+		}
+		for _, documentPermission := range documentPermissions {
+			documents = append(documents, documentPermission.Document)
+		}
+		if !hasMore {
+			return documents
+		}
+		cursor = respCursor
+	}
+	t.Fatalf("the traversal did not end after 100 pages")
+	return nil
+}
+
+// create documents owned by the principal, returns their ids in the order that they were created
+func createDocuments(t *testing.T, documentRepo *repository.DocumentRepository, ownerId uuid.UUID, count int) []uuid.UUID {
+	documentIds := make([]uuid.UUID, count)
+	for i := range documentIds {
+		documentId, err := documentRepo.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create a document with error: %v", err)
+		}
+		documentIds[i] = documentId
+	}
+	return documentIds
+}
+
+// fails the test unless the traversal listed exactly the wanted documents in the wanted order
+func verifyTraversal(t *testing.T, want []uuid.UUID, got []service.Document) {
+	seen := make(map[uuid.UUID]bool)
+	for _, document := range got {
+		if seen[document.ID] {
+			t.Errorf("document: %s was listed more than once", document.ID)
+		}
+		seen[document.ID] = true
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d documents in the traversal, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].ID != want[i] {
+			t.Errorf("want document: %s at position %d of the traversal, got: %s", want[i], i, got[i].ID)
+		}
+	}
+}
+
+func TestListDocumentsByPrincipal_LinearTraversalCreatedAt_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	ownerId := uuid.New()
+	// more documents than fit in one page and not a multiple of the page size
+	documentIds := createDocuments(t, documentRepo, ownerId, 7)
+	documents := traverseDocuments(t, documentRepo, ownerId, service.NewBeginningCursor(service.CreatedAt), 3)
+	// documents are listed in reverse chronological order of creation
+	want := make([]uuid.UUID, len(documentIds))
+	for i, documentId := range documentIds {
+		want[len(documentIds) - 1 - i] = documentId
+	}
+	verifyTraversal(t, want, documents)
+	for i := 1; i < len(documents); i++ {
+		if documents[i].CreatedAt.After(documents[i - 1].CreatedAt) {
+			t.Errorf("want documents in descending order of created at, got: %v after %v", documents[i].CreatedAt, documents[i - 1].CreatedAt)
+		}
+	}
+}
+
+func TestListDocumentsByPrincipal_LinearTraversalExactPages_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	ownerId := uuid.New()
+	// when the documents fill the last page exactly, the last page reports no more documents
+	documentIds := createDocuments(t, documentRepo, ownerId, 6)
+	cursor := service.NewBeginningCursor(service.CreatedAt)
+	var documentCount int
+	for page := range 2 {
+		documentPermissions, respCursor, hasMore, err := documentRepo.ListDocumentsByPrincipal(
+			t.Context(), ownerId, allPermissions, false, nil, false, cursor, 3,
+		)
+		if err != nil {
+			t.Fatalf("failed to list documents by principal with error: %v", err)
+		}
+		documentCount += len(documentPermissions)
+		if wantMore := page == 0; hasMore != wantMore {
+			t.Errorf("want hasMore: %v on page %d, got: %v", wantMore, page, hasMore)
+		}
+		cursor = respCursor
+	}
+	if documentCount != len(documentIds) {
+		t.Errorf("want %d documents, got: %d", len(documentIds), documentCount)
+	}
+	// the cursor returned with the last page lists no more documents and is echoed back
+	documentPermissions, respCursor, hasMore, err := documentRepo.ListDocumentsByPrincipal(
+		t.Context(), ownerId, allPermissions, false, nil, false, cursor, 3,
+	)
+	if err != nil {
+		t.Fatalf("failed to list documents by principal with error: %v", err)
+	}
+	if len(documentPermissions) != 0 || hasMore {
+		t.Errorf("want an empty last page without more documents, got: %d documents and hasMore: %v", len(documentPermissions), hasMore)
+	}
+	if *respCursor != *cursor {
+		t.Errorf("want the cursor to be echoed after the last page, want: %v, got: %v", cursor, respCursor)
+	}
+}
+
+func TestListDocumentsByPrincipal_LinearTraversalLastModifiedAt_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 5)
+	// updating the oldest document moves it to the front of the traversal
+	name := "updated"
+	err := documentRepo.UpdateDocument(t.Context(), documentIds[0], ownerId, &name, nil, false, false)
+	if err != nil {
+		t.Fatalf("failed to update document with error: %v", err)
+	}
+	documents := traverseDocuments(t, documentRepo, ownerId, service.NewBeginningCursor(service.LastModifiedAt), 2)
+	want := []uuid.UUID{ documentIds[0], documentIds[4], documentIds[3], documentIds[2], documentIds[1] }
+	verifyTraversal(t, want, documents)
+}
+
+func TestListDocumentsByPrincipal_InsertAfterCursor_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	ownerId := uuid.New()
+	documentIds := createDocuments(t, documentRepo, ownerId, 5)
+	// read the first page and save the cursor
+	documentPermissions, cursor, hasMore, err := documentRepo.ListDocumentsByPrincipal(
+		t.Context(), ownerId, allPermissions, false, nil, false, service.NewBeginningCursor(service.CreatedAt), 2,
+	)
+	if err != nil {
+		t.Fatalf("failed to list documents by principal with error: %v", err)
+	}
+	if len(documentPermissions) != 2 || !hasMore {
+		t.Fatalf("want a full first page with more documents, got: %d documents and hasMore: %v", len(documentPermissions), hasMore)
+	}
+	// documents created after the traversal started are newer than the cursor, so they are
+	// not listed by the rest of the traversal
+	createDocuments(t, documentRepo, ownerId, 3)
+	documents := traverseDocuments(t, documentRepo, ownerId, cursor, 2)
+	verifyTraversal(t, []uuid.UUID{ documentIds[2], documentIds[1], documentIds[0] }, documents)
+}
@@ -0,0 +1,121 @@
+package document_repository_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise incremental sync with ListDocumentsModifiedSince:
+- only documents created, updated, or archived after since are returned
+- documents are returned oldest modification first
+- archived documents are returned as tombstones with ArchivedAt set
+*/
+
+func createDocumentForSync(t *testing.T, documentService *service.DocumentService, ownerId uuid.UUID) uuid.UUID {
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	return documentId
+}
+
+func TestListDocumentsModifiedSince_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	updatedId := createDocumentForSync(t, documentService, ownerId)
+	archivedId := createDocumentForSync(t, documentService, ownerId)
+	untouchedId := createDocumentForSync(t, documentService, ownerId)
+	// use the time of the database instead of the time of the test process as the sync point
+	untouched, err := documentService.GetDocument(t.Context(), ownerId, untouchedId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	since := untouched.LastModifiedAt
+	// make changes after the sync point
+	createdId := createDocumentForSync(t, documentService, ownerId)
+	name := "updated"
+	if err = documentService.UpdateDocument(t.Context(), updatedId, ownerId, &name, nil, false, false); err != nil {
+		t.Fatalf("failed to update document with error: %v", err)
+	}
+	if err = documentService.ArchiveDocument(t.Context(), ownerId, archivedId); err != nil {
+		t.Fatalf("failed to archive document with error: %v", err)
+	}
+	documentPermissions, _, hasMore, err := documentService.ListDocumentsModifiedSince(
+		t.Context(), ownerId, since, nil, service.MaxPageSize,
+	)
+	if err != nil {
+		t.Fatalf("failed to list documents modified since with error: %v", err)
+	}
+	if hasMore {
+		t.Errorf("expected all the modified documents to fit in one page")
+	}
+	want := []uuid.UUID{createdId, updatedId, archivedId}
+	if len(documentPermissions) != len(want) {
+		t.Fatalf("want %d documents modified since: %v, got: %d", len(want), since, len(documentPermissions))
+	}
+	for i, documentId := range want {
+		if documentPermissions[i].Document.ID != documentId {
+			t.Errorf("want document: %v at position: %d, got: %v", documentId, i, documentPermissions[i].Document.ID)
+		}
+	}
+	if documentPermissions[2].Document.ArchivedAt == nil {
+		t.Errorf("expected the archived document to be returned as a tombstone")
+	}
+	if documentPermissions[0].Document.ArchivedAt != nil || documentPermissions[1].Document.ArchivedAt != nil {
+		t.Errorf("expected active documents not to be marked as archived")
+	}
+}
+
+func TestListDocumentsModifiedSince_Pagination_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	firstId := createDocumentForSync(t, documentService, ownerId)
+	first, err := documentService.GetDocument(t.Context(), ownerId, firstId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	want := []uuid.UUID{
+		createDocumentForSync(t, documentService, ownerId),
+		createDocumentForSync(t, documentService, ownerId),
+		createDocumentForSync(t, documentService, ownerId),
+	}
+	// walk the changes one page at a time, the cursor of each page continues the sync
+	var cursor *service.Cursor
+	var got []uuid.UUID
+	for range len(want) + 1 {
+		documentPermissions, cursorResp, hasMore, err := documentService.ListDocumentsModifiedSince(
+			t.Context(), ownerId, first.LastModifiedAt, cursor, 1,
+		)
+		if err != nil {
+			t.Fatalf("failed to list documents modified since with error: %v", err)
+		}
+		for _, documentPermission := range documentPermissions {
+			got = append(got, documentPermission.Document.ID)
+		}
+		cursor = cursorResp
+		if !hasMore {
+			break
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want documents: %v, got: %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want document: %v at position: %d, got: %v", want[i], i, got[i])
+		}
+	}
+	// polling with the final cursor returns nothing until there are new changes
+	documentPermissions, _, _, err := documentService.ListDocumentsModifiedSince(
+		t.Context(), ownerId, first.LastModifiedAt, cursor, 1,
+	)
+	if err != nil {
+		t.Fatalf("failed to list documents modified since with error: %v", err)
+	}
+	if len(documentPermissions) != 0 {
+		t.Errorf("want no documents after the final cursor, got: %d", len(documentPermissions))
+	}
+}
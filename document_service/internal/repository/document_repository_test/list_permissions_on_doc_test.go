@@ -16,12 +16,12 @@ func TestListPermissionsOnDocument_OnUser_DeleteDocumentPath_Integration(t *test
 	userId := uuid.New()
 	recipientId := uuid.New()
 	// create a document
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// share that document with the recipient
-	err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
 	if err != nil {
 		t.Fatalf("failed to share the document with the recipient with error: %v", err)
 	}
@@ -32,8 +32,8 @@ func TestListPermissionsOnDocument_OnUser_DeleteDocumentPath_Integration(t *test
 	}
 	// list the permissions on that document, verify that the user and recipient permissions are missing
 	cursor := service.NewBeginningCursor(service.CreatedAt)
-	_, _, err = documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{service.Editor, service.Owner}, cursor, 10,
+	_, _, _, err = documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{service.Editor, service.Owner}, cursor, 10, nil,
 	)
 	if err == nil {
 		t.Fatalf("successfully listed the permissions on a document that had been deleted. expected a not found error")
@@ -45,22 +45,22 @@ func TestListPermissionsOnDocument_OnUser_DeletePermissionPath_Integration(t *te
 	// create a dummy user
 	userId := uuid.New()
 	// create a document
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// create a dummy recipient user to share that document with
 	recipientId := uuid.New()
 	// share the document with the recipient 
-	err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
 	if err != nil {
 		t.Fatalf("failed to add permission on user with error: %v", err)
 	}
 	// verify that the user and the recipient both have permissions on the document
 	cursor := service.NewBeginningCursor(service.CreatedAt)
 	permissionsFilter := []service.PermissionLevel{service.Editor, service.Owner}
-	permissions, respCursor, err := documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, permissionsFilter, cursor, 10,
+	permissions, respCursor, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, permissionsFilter, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to get permissions on document with error: %v", err)
@@ -104,8 +104,8 @@ func TestListPermissionsOnDocument_OnUser_DeletePermissionPath_Integration(t *te
 		t.Fatalf("failed to delete the recipients permission on the document with error: %v", err)
 	}
 	// verify that now only the user has permissions on the document
-	permissions, _, err = documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, permissionsFilter, cursor, 10,
+	permissions, _, _, err = documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, permissionsFilter, cursor, 10, nil,
 	)
 	if err!= nil { t.Fatalf("failed to list permissions on document with error: %v", err )}
 	for _, permission := range permissions {
@@ -128,21 +128,21 @@ func TestListPermissionsOnDocument_OnUser_UpdatePermissionPath_Integration(t *te
 		recipientIdB = uuid.New()
 	)
 	// create a document
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// share the document with the two recipient users
 	for _, recipientId := range []uuid.UUID{ recipientIdA, recipientIdB } {
-		err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
+		_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
 		if err != nil {
 			t.Fatalf("failed to share document with recipient with error: %v", err)
 		}
 	}
 	// list the permissions on the document to verify that the two users are there
 	cursor := service.NewBeginningCursor(service.LastModifiedAt)
-	permissions, _, err := documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{ service.Editor, service.Owner }, cursor, 10,
+	permissions, _, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{ service.Editor, service.Owner }, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to list permissions on document with error: %v", err)
@@ -164,14 +164,14 @@ func TestListPermissionsOnDocument_OnUser_UpdatePermissionPath_Integration(t *te
 		)
 	}
 	// modify the permission of recipientA, this should change the order in which the permissions are returned
-	err = documentRepo.UpsertPermissionUser(t.Context(), recipientIdA, documentId, service.Viewer)
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientIdA, documentId, service.Viewer)
 	if err != nil {
 		t.Fatalf("failed to update permissions on user with error: %v", err)
 	}
 	// list the permissions on the document again by last modified at to verify that the first
 	cursor = service.NewBeginningCursor(service.LastModifiedAt)
-	permissions, _, err = documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{ service.Editor, service.Viewer, service.Owner }, cursor, 10,
+	permissions, _, _, err = documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{ service.Editor, service.Viewer, service.Owner }, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to list permissions on document with error: %v", err)
@@ -199,19 +199,19 @@ func TestListPermissionsOnDocument_OnGuest_UpdatePermissionPath_Integration(t *t
 	// create a user and two recipient users
 	userId := uuid.New()
 	// create a document
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// share the document with the guest
-	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor)
+	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor, nil, service.DefaultMaxGuestsPerDocument)
 	if err != nil {
 		t.Fatalf("failed to create a guest with error: %v", err)
 	}
 	// list the permissions on the document to verify that the two users are there
 	cursor := service.NewBeginningCursor(service.LastModifiedAt)
-	permissions, _, err := documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{ service.Editor, service.Owner }, cursor, 10,
+	permissions, _, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{ service.Editor, service.Owner }, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to list permissions on document with error: %v", err)
@@ -243,8 +243,8 @@ func TestListPermissionsOnDocument_OnGuest_UpdatePermissionPath_Integration(t *t
 	// to the cursor that was created before it was modified. Using the old cursor would prevent us from seeing the
 	// modified permission
 	cursor = service.NewBeginningCursor(service.LastModifiedAt)
-	permissions, _, err = documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{ service.Viewer, service.Owner }, cursor, 10,
+	permissions, _, _, err = documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{ service.Viewer, service.Owner }, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to list permissions on document with error: %v", err)
@@ -270,19 +270,19 @@ func TestListPermissionsOnDocument_OnGuest_DeletePermissionPath_Integration(t *t
 	// create a user and two recipient users
 	userId := uuid.New()
 	// create a document
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
 	// share the document with the guest
-	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor)
+	guestId, err := documentRepo.CreateGuest(t.Context(), userId, documentId, service.Editor, nil, service.DefaultMaxGuestsPerDocument)
 	if err != nil {
 		t.Fatalf("failed to create a guest with error: %v", err)
 	}
 	// list the permissions on the document to verify that the two users are there
 	cursor := service.NewBeginningCursor(service.LastModifiedAt)
-	permissions, _, err := documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{ service.Editor, service.Owner }, cursor, 10,
+	permissions, _, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{ service.Editor, service.Owner }, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to list permissions on document with error: %v", err)
@@ -310,8 +310,8 @@ func TestListPermissionsOnDocument_OnGuest_DeletePermissionPath_Integration(t *t
 	}
 	// list the permissions on the document again by last modified
 	cursor = service.NewBeginningCursor(service.LastModifiedAt)
-	permissions, _, err = documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{ service.Viewer, service.Owner }, cursor, 10,
+	permissions, _, _, err = documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{ service.Viewer, service.Owner }, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to list permissions on document with error: %v", err)
@@ -333,7 +333,7 @@ func TestListPermissionsOnDocument_PermissionFiltering_Integration(t *testing.T)
 	)
 	// create a document
 	documentId, err := documentRepo.CreateDocument(
-		t.Context(), userId, nil, nil,
+		t.Context(), userId, nil, nil, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to create document with error: %v", err)
@@ -342,7 +342,7 @@ func TestListPermissionsOnDocument_PermissionFiltering_Integration(t *testing.T)
 	for i, recipientId := range []uuid.UUID{ recipientIdA, recipientIdB, recipientIdC } {
 		var pl service.PermissionLevel = service.Editor
 		if i == 2 { pl = service.Viewer }
-		err = documentRepo.UpsertPermissionUser(
+		_, err = documentRepo.UpsertPermissionUser(
 			t.Context(), recipientId, documentId, pl,
 		)
 		if err != nil {
@@ -352,8 +352,8 @@ func TestListPermissionsOnDocument_PermissionFiltering_Integration(t *testing.T)
 	// list the permissions on that document using editor permission level filter
 	// verify that the expected number of permissions are returned
 	cursor := service.NewBeginningCursor(service.CreatedAt)
-	permissions, _, err := documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{service.Editor}, cursor, 10,
+	permissions, _, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{service.Editor}, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to list permissions on document with error: %v", err)
@@ -371,8 +371,8 @@ func TestListPermissionsOnDocument_PermissionFiltering_Integration(t *testing.T)
 	}
 	// list the permissions on the document using viewer permission level filter
 	// verify that the expected number of permissions are returned
-	permissions, _, err = documentRepo.ListPermissionsOnDocument(
-		t.Context(), documentId, []service.PermissionLevel{service.Viewer}, cursor, 10,
+	permissions, _, _, err = documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{service.Viewer}, cursor, 10, nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to list permissions on document with error: %v", err)
@@ -390,12 +390,58 @@ func TestListPermissionsOnDocument_PermissionFiltering_Integration(t *testing.T)
 	}
 }
 
+func TestListPermissionsOnDocument_ExcludeOwner_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	ownerId := uuid.New()
+	editorId := uuid.New()
+	viewerId := uuid.New()
+	documentId, err := documentRepo.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	for recipientId, pl := range map[uuid.UUID]service.PermissionLevel{ editorId: service.Editor, viewerId: service.Viewer } {
+		_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, pl)
+		if err != nil {
+			t.Fatalf("failed to share the document with user with error: %v", err)
+		}
+	}
+	// both sort fields leave the owner out while listing the other collaborators
+	for _, sortField := range []service.SortField{ service.CreatedAt, service.LastModifiedAt } {
+		permissions, _, _, err := documentRepo.ListPermissionsOnDocument(
+			t.Context(), documentId, service.AllPermissions, service.NewBeginningCursor(sortField), 10, &ownerId,
+		)
+		if err != nil {
+			t.Fatalf("failed to list permissions on document with error: %v", err)
+		}
+		listed := make(map[uuid.UUID]bool)
+		for _, permission := range permissions {
+			listed[permission.RecipientID] = true
+		}
+		if len(permissions) != 2 || !listed[editorId] || !listed[viewerId] {
+			t.Errorf("want the editor and the viewer listed with sort field: %v, got: %+v", sortField, permissions)
+		}
+		if listed[ownerId] {
+			t.Errorf("want the excluded owner left out with sort field: %v", sortField)
+		}
+	}
+	// without an exclusion the owner is listed
+	permissions, _, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, service.AllPermissions, service.NewBeginningCursor(service.CreatedAt), 10, nil,
+	)
+	if err != nil {
+		t.Fatalf("failed to list permissions on document with error: %v", err)
+	}
+	if len(permissions) != 3 {
+		t.Errorf("want 3 permissions without an exclusion, got: %d", len(permissions))
+	}
+}
+
 func TestListPermissionsOnDocument_MissingDocument_Integration(t *testing.T) {
 	documentRepo := createTestingDocumentRepo(t)
 	cursor := service.NewBeginningCursor(service.CreatedAt)
 	permissionFilter := []service.PermissionLevel{ service.Editor }
-	_, _, err := documentRepo.ListPermissionsOnDocument(
-		t.Context(), uuid.New(), permissionFilter, cursor, 10,
+	_, _, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), uuid.New(), permissionFilter, cursor, 10, nil,
 	)
 	if err == nil {
 		t.Error("expected an error when calling list permissions on document on a missing document")
@@ -412,8 +458,8 @@ func TestListPermissionsOnDocument_MissingDocument_Integration(t *testing.T) {
 
 func TestListPermissionsOnDocument_NilCursor_Unit(t *testing.T) {
 	documentRepo := &repository.DocumentRepository{}
-	_, _, err := documentRepo.ListPermissionsOnDocument(
-		t.Context(), uuid.New(), []service.PermissionLevel{ service.Editor }, nil, 10,
+	_, _, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), uuid.New(), []service.PermissionLevel{ service.Editor }, nil, 10, nil,
 	)
 	if err == nil {
 		t.Errorf("expected an error when calling list permissions on document with a nil pointer but instead got nil")
@@ -427,8 +473,8 @@ func TestListPermissionsOnDocument_NilCursor_Unit(t *testing.T) {
 func TestListPermissionsOnDocument_EmptyPermissionsList_Unit(t *testing.T) {
 	documentRepo := &repository.DocumentRepository{}
 	cursor := service.NewBeginningCursor(service.CreatedAt)
-	_, _, err := documentRepo.ListPermissionsOnDocument(
-		t.Context(), uuid.New(), []service.PermissionLevel{}, cursor, 10,
+	_, _, _, err := documentRepo.ListPermissionsOnDocument(
+		t.Context(), uuid.New(), []service.PermissionLevel{}, cursor, 10, nil,
 	)
 	if err == nil {
 		t.Error("expected an error when calling list permissions on document with empty permission level filter list but got nil")
@@ -445,9 +491,9 @@ func TestListPermissionsOnDocument_EmptyPermissionsList_Unit(t *testing.T) {
 
 func TestListPermissionsOnDocument_InvalidPermission_Unit(t *testing.T) {
 	documentRepo := &repository.DocumentRepository{}
-	_, _, err := documentRepo.ListPermissionsOnDocument(
+	_, _, _, err := documentRepo.ListPermissionsOnDocument(
 		t.Context(), uuid.New(), []service.PermissionLevel{ -1 }, 
-		service.NewBeginningCursor(service.CreatedAt), 10,
+		service.NewBeginningCursor(service.CreatedAt), 10, nil,
 	)
 	if err == nil {
 		t.Error("expected an error when calling list permissions on document with an invalid permission, got nil")
@@ -457,4 +503,101 @@ func TestListPermissionsOnDocument_InvalidPermission_Unit(t *testing.T) {
 			t.Errorf("expected a invalid input error when calling list permissions on document with an invalid permission, got: %v", err)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestListPermissionsOnDocument_InvalidSortField_Unit(t *testing.T) {
+	documentRepo := &repository.DocumentRepository{}
+	for _, sortField := range []service.SortField{ -1, service.AccessedAt, 42 } {
+		cursor := service.NewBeginningCursor(service.CreatedAt)
+		cursor.SortField = sortField
+		_, _, _, err := documentRepo.ListPermissionsOnDocument(
+			t.Context(), uuid.New(), []service.PermissionLevel{ service.Editor }, cursor, 10, nil,
+		)
+		var target *service.InvalidInputError
+		if !errors.As(err, &target) {
+			t.Errorf("want: a service InvalidInputError for sort field: %v, got: %v", sortField, err)
+		}
+	}
+}
+// ========== ListPermissionsOnDocument: Pagination ========== //
+func TestListPermissionsOnDocument_PageToExhaustion_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	// create a document and share it with enough recipients to fill multiple pages
+	userId := uuid.New()
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create a document with error: %v", err)
+	}
+	var recipientIds []uuid.UUID
+	for range 4 {
+		recipientId := uuid.New()
+		_, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Viewer)
+		if err != nil {
+			t.Fatalf("failed to share the document with a recipient with error: %v", err)
+		}
+		recipientIds = append(recipientIds, recipientId)
+	}
+	// changing the level of the oldest share moves it to the front of the last modified order
+	// while it stays at the back of the created order
+	changedId := recipientIds[0]
+	if _, err = documentRepo.UpsertPermissionUser(t.Context(), changedId, documentId, service.Editor); err != nil {
+		t.Fatalf("failed to change the level of the permission with error: %v", err)
+	}
+	permissionsFilter := []service.PermissionLevel{service.Viewer, service.Editor, service.Owner}
+	for _, sortField := range []service.SortField{ service.CreatedAt, service.LastModifiedAt } {
+		// page through the five permissions two at a time
+		cursor := service.NewBeginningCursor(sortField)
+		seen := make(map[uuid.UUID]bool)
+		wantPages := []struct{
+			size int
+			hasMore bool
+		}{
+			{ size: 2, hasMore: true },
+			{ size: 2, hasMore: true },
+			{ size: 1, hasMore: false },
+		}
+		for i, want := range wantPages {
+			permissions, respCursor, hasMore, err := documentRepo.ListPermissionsOnDocument(
+				t.Context(), documentId, permissionsFilter, cursor, 2, nil,
+			)
+			if err != nil {
+				t.Fatalf("failed to list permissions sorted by %v on page %d with error: %v", sortField, i, err)
+			}
+			if len(permissions) != want.size {
+				t.Errorf("page %d sorted by %v has the wrong number of permissions, want: %d, got: %d", i, sortField, want.size, len(permissions))
+			}
+			if hasMore != want.hasMore {
+				t.Errorf("page %d sorted by %v has the wrong has more flag, want: %t, got: %t", i, sortField, want.hasMore, hasMore)
+			}
+			for _, permission := range permissions {
+				if seen[permission.RecipientID] {
+					t.Errorf("permission of recipient %v was returned on more than one page sorted by %v", permission.RecipientID, sortField)
+				}
+				seen[permission.RecipientID] = true
+				if permission.RecipientID == changedId && !permission.LastModifiedAt.After(permission.CreatedAt) {
+					t.Errorf("want the changed permission to be last modified after it was created, got: %+v", permission)
+				}
+			}
+			cursor = respCursor
+		}
+		if len(seen) != 5 {
+			t.Errorf("want: 5 distinct permissions after paging to exhaustion sorted by %v, got: %d", sortField, len(seen))
+		}
+		// calling again with the terminal cursor returns no permissions and echoes the cursor
+		permissions, respCursor, hasMore, err := documentRepo.ListPermissionsOnDocument(
+			t.Context(), documentId, permissionsFilter, cursor, 2, nil,
+		)
+		if err != nil {
+			t.Fatalf("failed to list permissions with the terminal cursor with error: %v", err)
+		}
+		if len(permissions) != 0 {
+			t.Errorf("want: no permissions after the traversal is exhausted, got: %d", len(permissions))
+		}
+		if hasMore {
+			t.Errorf("want: has more to be false after the traversal is exhausted, got: %t", hasMore)
+		}
+		if respCursor.LastSeenID != cursor.LastSeenID || !respCursor.LastSeenTime.Equal(cursor.LastSeenTime) {
+			t.Errorf("want: the terminal cursor to be echoed, want: %+v, got: %+v", *cursor, *respCursor)
+		}
+	}
+}
@@ -34,7 +34,7 @@ Testing strategy:
 	- [x] ListPermissionsOnDocument flows:
 		- [x] create a document -> share the document with a user -> delete the document -> verify that we get a not found error after listing permissions on a deleted document
 			- [x] verify that the cursor returned for each call to the list permissions by document method are well formed
-			- do we need to verify at the database level that there are no more permissions in that table on that document? Or is it enough that the permissions cannot be reached using the api provided by the document repo package
+			- [x] verify at the database level that there are no more permissions in that table on that document, the api provided by the document repo package cannot reach the rows of a deleted document
 		- [x] create a document -> share the document with a user -> verify that the permissions are present for both using list by document -> delete the permissions on the shared user -> verify that the permissions are missing for the shared user
 		- [x] create a document -> share the document with a user -> verify that the permissions are present for both using list by document -> update the permission on the shared user -> verify that the permissions are updated for the shared user
 		- [x] create a document -> share the document with a guest -> verify that the permissions are present -> update the permissions of the guest -> verify that the permissions are updated using list permissions by doc
@@ -88,14 +88,14 @@ Testing strategy:
 		- [ ] calling update permissions guest with an invalid permission returns an error
 		- [ ] calling update permissions guest with owner permissions returns an error
 - [ ] cursor based pagination implementation tests:
-	- [ ] linearly traverse, created by sorting:
-		- [ ] create a few documents -> share those documents with a user -> verify that the pagination logic works by listing those documents over a multiple pages
-		- [ ] the documents should be ordered by created by in reverse chronological order
-	- [ ] linear traverse, last updated by sorting
-		- [ ] create a few documents -> share those documents with a user -> update one of the documents -> verify that the sorting logic works by listing those documents by last modified
-	- [ ] linear traverse, cursor pagination logic with inserts after cursor
-		- [ ] create a few documents -> share those documents with a user -> list the documents and save cursor -> create a few new documents -> traverse the rest of the shared documents and verify that the new documents are not listed
-	- [ ] the cursor indicates that the last item has been found when necessary
+	- [x] linearly traverse, created by sorting:
+		- [x] create a few documents -> share those documents with a user -> verify that the pagination logic works by listing those documents over a multiple pages
+		- [x] the documents should be ordered by created by in reverse chronological order
+	- [x] linear traverse, last updated by sorting
+		- [x] create a few documents -> share those documents with a user -> update one of the documents -> verify that the sorting logic works by listing those documents by last modified
+	- [x] linear traverse, cursor pagination logic with inserts after cursor
+		- [x] create a few documents -> share those documents with a user -> list the documents and save cursor -> create a few new documents -> traverse the rest of the shared documents and verify that the new documents are not listed
+	- [x] the cursor indicates that the last item has been found when necessary
 	- [ ] if there are no documents associated with a cursor, the returned cursor should be the nil cursor
 - test repo implementation helper functions:
 
@@ -0,0 +1,87 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise the validation of ids in the permission mutations of the service:
+- the nil uuid is rejected as each id with an invalid input error
+- the ids are validated before the repository is called, so the tests run without a database
+*/
+
+func TestPermissionMutations_NilIds_Unit(t *testing.T) {
+	// the ids are checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	id := uuid.New()
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{ "CreateGuest creator id", func() error {
+			_, err := documentService.CreateGuest(t.Context(), uuid.Nil, id, nil, nil)
+			return err
+		} },
+		{ "CreateGuest document id", func() error {
+			_, err := documentService.CreateGuest(t.Context(), id, uuid.Nil, nil, nil)
+			return err
+		} },
+		{ "CreateGuests caller id", func() error {
+			_, err := documentService.CreateGuests(t.Context(), uuid.Nil, id, 1, nil)
+			return err
+		} },
+		{ "CreateGuests document id", func() error {
+			_, err := documentService.CreateGuests(t.Context(), id, uuid.Nil, 1, nil)
+			return err
+		} },
+		{ "UpsertPermissionUser caller id", func() error {
+			_, err := documentService.UpsertPermissionUser(t.Context(), uuid.Nil, uuid.New(), id, service.Viewer)
+			return err
+		} },
+		{ "UpsertPermissionUser user id", func() error {
+			_, err := documentService.UpsertPermissionUser(t.Context(), id, uuid.Nil, uuid.New(), service.Viewer)
+			return err
+		} },
+		{ "UpsertPermissionUser document id", func() error {
+			_, err := documentService.UpsertPermissionUser(t.Context(), id, uuid.New(), uuid.Nil, service.Viewer)
+			return err
+		} },
+		{ "InviteUser caller id", func() error {
+			return documentService.InviteUser(t.Context(), uuid.Nil, uuid.New(), id, service.Viewer)
+		} },
+		{ "InviteUser user id", func() error {
+			return documentService.InviteUser(t.Context(), id, uuid.Nil, uuid.New(), service.Viewer)
+		} },
+		{ "InviteUser document id", func() error {
+			return documentService.InviteUser(t.Context(), id, uuid.New(), uuid.Nil, service.Viewer)
+		} },
+		{ "UpdatePermissionGuest guest id", func() error {
+			return documentService.UpdatePermissionGuest(t.Context(), uuid.Nil, service.Viewer)
+		} },
+		{ "UpdateGuestLabel caller id", func() error {
+			return documentService.UpdateGuestLabel(t.Context(), uuid.Nil, uuid.New(), uuid.New(), nil)
+		} },
+		{ "UpdateGuestLabel document id", func() error {
+			return documentService.UpdateGuestLabel(t.Context(), id, uuid.Nil, uuid.New(), nil)
+		} },
+		{ "UpdateGuestLabel guest id", func() error {
+			return documentService.UpdateGuestLabel(t.Context(), id, uuid.New(), uuid.Nil, nil)
+		} },
+		{ "DeletePermissionPrincipal recipient id", func() error {
+			return documentService.DeletePermissionPrincipal(t.Context(), uuid.Nil, id)
+		} },
+		{ "DeletePermissionPrincipal document id", func() error {
+			return documentService.DeletePermissionPrincipal(t.Context(), id, uuid.Nil)
+		} },
+	}
+	for _, test := range tests {
+		var serviceError *service.InvalidInputError
+		if err := test.call(); !errors.As(err, &serviceError) {
+			t.Errorf("want: a service InvalidInputError for a nil %s, got: %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestPendingShare_Lifecycle_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	userId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	err = documentService.InviteUser(t.Context(), ownerId, userId, documentId, service.Editor)
+	if err != nil {
+		t.Fatalf("failed to invite user with error: %v", err)
+	}
+	// the pending share grants no access
+	var permissionErr *service.PermissionDeniedError
+	_, err = documentService.GetDocument(t.Context(), userId, documentId, nil)
+	if !errors.As(err, &permissionErr) {
+		t.Errorf("want a permission denied error before the share is accepted, got: %v", err)
+	}
+	documents, _, _, err := documentService.ListDocumentsByPrincipal(
+		t.Context(), userId, nil, false, nil, false, service.NewBeginningCursor(service.CreatedAt), service.MaxPageSize,
+	)
+	if err != nil {
+		t.Fatalf("failed to list documents with error: %v", err)
+	}
+	if len(documents) != 0 {
+		t.Errorf("want no documents listed before the share is accepted, got: %d", len(documents))
+	}
+	// the pending share is listed for the user
+	pendingShares, err := documentService.ListPendingShares(t.Context(), userId)
+	if err != nil {
+		t.Fatalf("failed to list pending shares with error: %v", err)
+	}
+	if len(pendingShares) != 1 {
+		t.Fatalf("want one pending share, got: %d", len(pendingShares))
+	}
+	pendingShare := pendingShares[0]
+	if pendingShare.Document.ID != documentId || pendingShare.Permission != service.Editor || pendingShare.SharedBy != ownerId {
+		t.Errorf(
+			"want pending share of document: %s at: %v from: %s, got: %s at: %v from: %s",
+			documentId, service.Editor, ownerId,
+			pendingShare.Document.ID, pendingShare.Permission, pendingShare.SharedBy,
+		)
+	}
+	// the owner sees the pending share among the permissions on the document
+	permissions, _, _, err := documentService.ListPermissionsOnDocument(
+		t.Context(), documentId, nil, service.NewBeginningCursor(service.CreatedAt), service.MaxPageSize, &ownerId,
+	)
+	if err != nil {
+		t.Fatalf("failed to list permissions on document with error: %v", err)
+	}
+	if len(permissions) != 1 || permissions[0].RecipientID != userId || !permissions[0].Pending {
+		t.Errorf("want the pending share of user: %s listed as pending, got: %+v", userId, permissions)
+	}
+
+	err = documentService.AcceptPendingShare(t.Context(), userId, documentId)
+	if err != nil {
+		t.Fatalf("failed to accept pending share with error: %v", err)
+	}
+	// the accepted share grants access
+	_, err = documentService.GetDocument(t.Context(), userId, documentId, nil)
+	if err != nil {
+		t.Errorf("want the document readable after the share is accepted, got error: %v", err)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), userId, documentId, userId, nil)
+	if err != nil {
+		t.Fatalf("failed to get permission with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Editor || permission.Pending {
+		t.Errorf("want an accepted editor permission, got: %+v", permission)
+	}
+	pendingShares, err = documentService.ListPendingShares(t.Context(), userId)
+	if err != nil {
+		t.Fatalf("failed to list pending shares with error: %v", err)
+	}
+	if len(pendingShares) != 0 {
+		t.Errorf("want no pending shares after accepting, got: %d", len(pendingShares))
+	}
+	// there is nothing left to accept
+	var notFoundErr *service.NotFoundError
+	err = documentService.AcceptPendingShare(t.Context(), userId, documentId)
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want a not found error when accepting twice, got: %v", err)
+	}
+}
+
+func TestInviteUser_Conflict_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	invitedId := uuid.New()
+	collaboratorId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	err = documentService.InviteUser(t.Context(), ownerId, invitedId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to invite user with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, collaboratorId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	var conflictErr *service.UniqueConflictError
+	for _, userId := range []uuid.UUID{ invitedId, collaboratorId } {
+		err = documentService.InviteUser(t.Context(), ownerId, userId, documentId, service.Editor)
+		if !errors.As(err, &conflictErr) {
+			t.Errorf("want a unique conflict error when inviting user: %s again, got: %v", userId, err)
+		}
+	}
+	// a user without access to the document cannot invite others
+	var permissionErr *service.PermissionDeniedError
+	err = documentService.InviteUser(t.Context(), invitedId, uuid.New(), documentId, service.Viewer)
+	if !errors.As(err, &permissionErr) {
+		t.Errorf("want a permission denied error when a pending user invites, got: %v", err)
+	}
+}
+
+func TestPendingShare_DirectShareAtSameLevel_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	invitedId := uuid.New()
+	batchInvitedId := uuid.New()
+	for _, userId := range []uuid.UUID{ invitedId, batchInvitedId } {
+		if err = documentService.InviteUser(t.Context(), ownerId, userId, documentId, service.Editor); err != nil {
+			t.Fatalf("failed to invite user with error: %v", err)
+		}
+	}
+	// sharing directly at the level of the pending share turns it into a permission
+	_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, invitedId, documentId, service.Editor)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionsUser(
+		t.Context(), ownerId, documentId,
+		[]service.UserShare{ { UserID: batchInvitedId, PermissionLevel: service.Editor } },
+	)
+	if err != nil {
+		t.Fatalf("failed to share document with a batch with error: %v", err)
+	}
+	for _, userId := range []uuid.UUID{ invitedId, batchInvitedId } {
+		if _, err = documentService.GetDocument(t.Context(), userId, documentId, nil); err != nil {
+			t.Errorf("want the document readable by user: %s after the direct share, got error: %v", userId, err)
+		}
+		permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), userId, documentId, userId, nil)
+		if err != nil {
+			t.Fatalf("failed to get permission with error: %v", err)
+		}
+		if permission.PermissionLevel != service.Editor || permission.Pending {
+			t.Errorf("want an editor permission that is not pending, got: %+v", permission)
+		}
+	}
+}
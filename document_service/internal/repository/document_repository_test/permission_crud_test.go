@@ -2,6 +2,7 @@ package document_repository_test
 
 import (
 	"errors"
+	"slices"
 	"testing"
 
 	"github.com/google/uuid"
@@ -12,7 +13,7 @@ func TestUpsertPermissionUser_DocumentNotFound_Integration(t *testing.T) {
 	// create a document repo struct with access to the testing postgres instance
 	documentRepo := createTestingDocumentRepo(t)
 	// call upsert permission user on a document that does not exist
-	err := documentRepo.UpsertPermissionUser(t.Context(), uuid.New(), uuid.New(), service.Editor)
+	_, err := documentRepo.UpsertPermissionUser(t.Context(), uuid.New(), uuid.New(), service.Editor)
 	// validate that the returned error is a not found error
 	if err == nil {
 		t.Fatalf(
@@ -35,7 +36,7 @@ func TestUpdatePermissionGuest_GuestNotFound_Integration(t *testing.T) {
 	// create a user
 	userId := uuid.New()
 	// create a document with that user
-	_, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	_, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create document with error: %v", err)
 	}
@@ -86,7 +87,7 @@ func TestDeletePermissionPrincipal_NotFound_Integration(t *testing.T) {
 	// create a user
 	userId := uuid.New()
 	// create a document
-	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil)
+	documentId, err := documentRepo.CreateDocument(t.Context(), userId, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create a document with error: %v", err)
 	}
@@ -107,4 +108,117 @@ func TestDeletePermissionPrincipal_NotFound_Integration(t *testing.T) {
 			)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestUpsertPermissionUser_ReportsCreatedThenUpdated_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentId, err := documentRepo.CreateDocument(t.Context(), uuid.New(), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create a document with error: %v", err)
+	}
+	recipientId := uuid.New()
+	// the first share creates a new permission
+	created, err := documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share the document with error: %v", err)
+	}
+	if !created {
+		t.Errorf("expected the first share to report a created permission")
+	}
+	// sharing again at a new level updates the existing permission
+	created, err = documentRepo.UpsertPermissionUser(t.Context(), recipientId, documentId, service.Editor)
+	if err != nil {
+		t.Fatalf("failed to update the permission with error: %v", err)
+	}
+	if created {
+		t.Errorf("expected the second share to report an updated permission")
+	}
+}
+
+func TestUpsertPermissionsUser_ReportsCreatedAndUpdated_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	viewerId, newId := uuid.New(), uuid.New()
+	if _, err := documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, documentId, service.Viewer); err != nil {
+		t.Fatalf("failed to share the document with error: %v", err)
+	}
+	// one batch creates a permission, updates a permission, and re-shares at the current level
+	results, err := documentService.UpsertPermissionsUser(t.Context(), ownerId, documentId, []service.UserShare{
+		{ UserID: newId, PermissionLevel: service.Viewer },
+		{ UserID: viewerId, PermissionLevel: service.Editor },
+		{ UserID: editorId, PermissionLevel: service.Editor },
+	})
+	if err != nil {
+		t.Fatalf("failed to share the document with a batch of users with error: %v", err)
+	}
+	want := []service.UserShare{
+		{ UserID: newId, PermissionLevel: service.Viewer, Created: true },
+		{ UserID: viewerId, PermissionLevel: service.Editor, Created: false },
+		{ UserID: editorId, PermissionLevel: service.Editor, Created: false },
+	}
+	if !slices.Equal(results, want) {
+		t.Errorf("want results: %+v, got: %+v", want, results)
+	}
+	for _, share := range want {
+		permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), share.UserID, documentId, share.UserID, nil)
+		if err != nil {
+			t.Fatalf("failed to get the permission of user: %s with error: %v", share.UserID, err)
+		}
+		if permission.PermissionLevel != share.PermissionLevel {
+			t.Errorf("want user: %s at level: %v, got: %v", share.UserID, share.PermissionLevel, permission.PermissionLevel)
+		}
+	}
+}
+
+func TestUpsertPermissionsUser_RejectsWholeBatch_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	newId := uuid.New()
+	// the editor cannot change the permission of the owner, so no share of the batch is applied
+	_, err := documentService.UpsertPermissionsUser(t.Context(), editorId, documentId, []service.UserShare{
+		{ UserID: newId, PermissionLevel: service.Viewer },
+		{ UserID: ownerId, PermissionLevel: service.Viewer },
+	})
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when the batch changes the owner, got: %v", err)
+	}
+	_, err = documentService.GetPermissionOfPrincipalOnDocument(t.Context(), newId, documentId, newId, nil)
+	var notFound *service.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("want no permission for the user of a rejected batch, got: %v", err)
+	}
+	// a user that appears twice is rejected before the batch is written
+	_, err = documentService.UpsertPermissionsUser(t.Context(), ownerId, documentId, []service.UserShare{
+		{ UserID: newId, PermissionLevel: service.Viewer },
+		{ UserID: newId, PermissionLevel: service.Editor },
+	})
+	var invalidInput *service.InvalidInputError
+	if !errors.As(err, &invalidInput) {
+		t.Errorf("want an invalid input error for a duplicate user, got: %v", err)
+	}
+}
+
+func TestUpsertPermissionUser_SecondOwner_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	ownerId := uuid.New()
+	documentId, err := documentRepo.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	// the service never grants owner, writing it through the repository stands in for a
+	// buggy write that the schema has to catch
+	_, err = documentRepo.UpsertPermissionUser(t.Context(), uuid.New(), documentId, service.Owner)
+	var conflictErr *service.UniqueConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Errorf("want a unique conflict error when creating a second owner, got: %v", err)
+	}
+	count, err := documentRepo.CountPermissionsOnDocument(
+		t.Context(), documentId, []service.PermissionLevel{ service.Owner },
+	)
+	if err != nil {
+		t.Fatalf("failed to count the owners of the document with error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("want the document to keep exactly 1 owner, got: %d", count)
+	}
+}
@@ -0,0 +1,142 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+	"github.com/townsag/reed/document_service/internal/worker"
+)
+
+func getOwnPermission(
+	t *testing.T, documentService *service.DocumentService, documentId uuid.UUID, principalId uuid.UUID,
+) service.Permission {
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), principalId, documentId, principalId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the principal with error: %v", err)
+	}
+	return permission
+}
+
+func TestPermissionDowngrade_FakeClock_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	now := time.Now()
+	downgradeAt := now.Add(time.Hour)
+	err := documentService.SchedulePermissionDowngrade(t.Context(), ownerId, documentId, editorId, service.Viewer, downgradeAt)
+	if err != nil {
+		t.Fatalf("failed to schedule a downgrade with error: %v", err)
+	}
+	permission := getOwnPermission(t, documentService, documentId, editorId)
+	if permission.DowngradeTo == nil || *permission.DowngradeTo != service.Viewer || permission.DowngradeAt == nil {
+		t.Fatalf("want a downgrade to viewer to be scheduled, got: %+v", permission)
+	}
+	downgradeWorker := worker.NewDowngradeWorker(documentService, time.Minute, service.DefaultDowngradeBatchSize)
+	downgradeWorker.Now = func() time.Time { return now }
+	// the downgrade is not due yet
+	if _, err = downgradeWorker.RunOnce(t.Context()); err != nil {
+		t.Fatalf("failed to run the downgrade job with error: %v", err)
+	}
+	if permission = getOwnPermission(t, documentService, documentId, editorId); permission.PermissionLevel != service.Editor {
+		t.Fatalf("want the permission to stay: %v before the downgrade time, got: %v", service.Editor, permission.PermissionLevel)
+	}
+	// move the clock past the downgrade time
+	now = now.Add(2 * time.Hour)
+	applied, err := downgradeWorker.RunOnce(t.Context())
+	if err != nil {
+		t.Fatalf("failed to run the downgrade job with error: %v", err)
+	}
+	if applied < 1 {
+		t.Errorf("want at least one downgrade to be applied, got: %d", applied)
+	}
+	permission = getOwnPermission(t, documentService, documentId, editorId)
+	if permission.PermissionLevel != service.Viewer {
+		t.Errorf("want permission level: %v after the downgrade, got: %v", service.Viewer, permission.PermissionLevel)
+	}
+	if permission.DowngradeTo != nil || permission.DowngradeAt != nil {
+		t.Errorf("want the schedule to be cleared once it is applied, got: %+v", permission)
+	}
+	entries, err := documentService.ListPermissionAudit(t.Context(), documentId, ownerId, service.DefaultPageSize)
+	if err != nil {
+		t.Fatalf("failed to list the permission audit with error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("want one audit entry, got: %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.RecipientID != editorId || entry.OldPermissionLevel != service.Editor ||
+		entry.NewPermissionLevel != service.Viewer || entry.Reason != service.ScheduledDowngradeReason {
+		t.Errorf("want an audit entry for the downgrade of the editor to viewer, got: %+v", entry)
+	}
+}
+
+func TestPermissionDowngrade_PastTime_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	err := documentService.SchedulePermissionDowngrade(
+		t.Context(), ownerId, documentId, editorId, service.Viewer, time.Now().Add(-time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("failed to schedule a downgrade with error: %v", err)
+	}
+	// a batch size of one applies the downgrades one transaction at a time
+	if _, err = documentService.ApplyDueDowngrades(t.Context(), time.Now(), 1); err != nil {
+		t.Fatalf("failed to apply due downgrades with error: %v", err)
+	}
+	if permission := getOwnPermission(t, documentService, documentId, editorId); permission.PermissionLevel != service.Viewer {
+		t.Errorf("want permission level: %v after the downgrade, got: %v", service.Viewer, permission.PermissionLevel)
+	}
+}
+
+func TestPermissionDowngrade_LevelChangeClearsSchedule_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	err := documentService.SchedulePermissionDowngrade(
+		t.Context(), ownerId, documentId, editorId, service.Viewer, time.Now().Add(time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("failed to schedule a downgrade with error: %v", err)
+	}
+	// the owner lowers the permission by hand and raises it again, the schedule is dropped
+	for _, level := range []service.PermissionLevel{ service.Viewer, service.Editor } {
+		if _, err = documentService.UpsertPermissionUser(t.Context(), ownerId, editorId, documentId, level); err != nil {
+			t.Fatalf("failed to update the permission with error: %v", err)
+		}
+	}
+	if _, err = documentService.ApplyDueDowngrades(t.Context(), time.Now().Add(2*time.Hour), service.DefaultDowngradeBatchSize); err != nil {
+		t.Fatalf("failed to apply due downgrades with error: %v", err)
+	}
+	if permission := getOwnPermission(t, documentService, documentId, editorId); permission.PermissionLevel != service.Editor {
+		t.Errorf("want permission level: %v after the schedule was cleared, got: %v", service.Editor, permission.PermissionLevel)
+	}
+}
+
+func TestPermissionDowngrade_Invalid_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	downgradeAt := time.Now().Add(time.Hour)
+	var invalidErr *service.InvalidInputError
+	// a downgrade must lower the level
+	err := documentService.SchedulePermissionDowngrade(t.Context(), ownerId, documentId, editorId, service.Editor, downgradeAt)
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when downgrading to the same level, got: %v", err)
+	}
+	// the owner cannot be downgraded
+	err = documentService.SchedulePermissionDowngrade(t.Context(), ownerId, documentId, ownerId, service.Viewer, downgradeAt)
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when downgrading the owner, got: %v", err)
+	}
+	// only the owner can schedule a downgrade
+	var permissionErr *service.PermissionDeniedError
+	err = documentService.SchedulePermissionDowngrade(t.Context(), editorId, documentId, editorId, service.Viewer, downgradeAt)
+	if !errors.As(err, &permissionErr) {
+		t.Errorf("want permission denied error when an editor schedules a downgrade, got: %v", err)
+	}
+	var notFoundErr *service.NotFoundError
+	err = documentService.SchedulePermissionDowngrade(t.Context(), ownerId, documentId, uuid.New(), service.Viewer, downgradeAt)
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want not found error for a principal without a permission, got: %v", err)
+	}
+}
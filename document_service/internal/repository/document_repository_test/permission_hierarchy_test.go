@@ -0,0 +1,169 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise the permission hierarchy rule that is enforced in the service layer
+on top of the repository:
+- a caller cannot grant a permission level higher than the level they hold on the document
+- owners can grant any level except owner
+*/
+
+// create a document owned by a new user and share it with an editor, returns the document id
+// and the ids of the owner and the editor
+func createDocumentWithEditor(t *testing.T, documentService *service.DocumentService) (uuid.UUID, uuid.UUID, uuid.UUID) {
+	ownerId := uuid.New()
+	editorId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, editorId, documentId, service.Editor)
+	if err != nil {
+		t.Fatalf("failed to share document with editor with error: %v", err)
+	}
+	return documentId, ownerId, editorId
+}
+
+func TestUpsertPermissionUser_EditorGrantsViewer_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	// the editor shares the document with a new user at the viewer level
+	targetId := uuid.New()
+	_, err := documentService.UpsertPermissionUser(t.Context(), editorId, targetId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("expected an editor to be able to grant viewer, got error: %v", err)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), targetId, documentId, targetId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the target user with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Viewer {
+		t.Errorf("want permission level: %v, got: %v", service.Viewer, permission.PermissionLevel)
+	}
+}
+
+func TestUpsertPermissionUser_EditorGrantsEditor_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	// granting the same level that the caller holds is allowed
+	targetId := uuid.New()
+	_, err := documentService.UpsertPermissionUser(t.Context(), editorId, targetId, documentId, service.Editor)
+	if err != nil {
+		t.Fatalf("expected an editor to be able to grant editor, got error: %v", err)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), targetId, documentId, targetId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the target user with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Editor {
+		t.Errorf("want permission level: %v, got: %v", service.Editor, permission.PermissionLevel)
+	}
+}
+
+func TestUpsertPermissionUser_EditorGrantsOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	targetId := uuid.New()
+	_, err := documentService.UpsertPermissionUser(t.Context(), editorId, targetId, documentId, service.Owner)
+	if err == nil {
+		t.Fatal("expected an error when an editor grants owner but got nil")
+	}
+	var invalidErr *service.InvalidInputError
+	var deniedErr *service.PermissionDeniedError
+	if !errors.As(err, &invalidErr) && !errors.As(err, &deniedErr) {
+		t.Errorf("want invalid input or permission denied error, got: %v", err)
+	}
+	// verify that no permission was created for the target
+	_, err = documentService.GetPermissionOfPrincipalOnDocument(t.Context(), targetId, documentId, targetId, nil)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want not found error for the target user, got: %v", err)
+	}
+}
+
+func TestUpsertPermissionUser_ViewerGrantsEditor_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	viewerId := uuid.New()
+	_, err := documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share document with viewer with error: %v", err)
+	}
+	// the viewer cannot grant a level higher than their own
+	_, err = documentService.UpsertPermissionUser(t.Context(), viewerId, uuid.New(), documentId, service.Editor)
+	var deniedErr *service.PermissionDeniedError
+	if !errors.As(err, &deniedErr) {
+		t.Errorf("want permission denied error when a viewer grants editor, got: %v", err)
+	}
+}
+
+func TestUpsertPermissionUser_EditorDowngradesOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	// the editor cannot modify the permission of a principal holding a higher level
+	_, err := documentService.UpsertPermissionUser(t.Context(), editorId, ownerId, documentId, service.Viewer)
+	var deniedErr *service.PermissionDeniedError
+	if !errors.As(err, &deniedErr) {
+		t.Errorf("want permission denied error when an editor downgrades the owner, got: %v", err)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), ownerId, documentId, ownerId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the owner with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Owner {
+		t.Errorf("want permission level: %v, got: %v", service.Owner, permission.PermissionLevel)
+	}
+}
+
+func TestUpsertPermissionUser_CallerWithoutPermission_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, _ := createDocumentWithEditor(t, documentService)
+	// a principal with no permission on the document cannot share it
+	_, err := documentService.UpsertPermissionUser(t.Context(), uuid.New(), uuid.New(), documentId, service.Viewer)
+	var deniedErr *service.PermissionDeniedError
+	if !errors.As(err, &deniedErr) {
+		t.Errorf("want permission denied error for a caller without permission, got: %v", err)
+	}
+}
+
+func TestUpsertPermissionUser_OwnerSharesWithSelf_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	_, err := documentService.UpsertPermissionUser(t.Context(), ownerId, ownerId, documentId, service.Viewer)
+	var invalidErr *service.InvalidInputError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("want invalid input error when the owner shares with themselves, got: %v", err)
+	}
+	// verify that the owner was not downgraded
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), ownerId, documentId, ownerId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the owner with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Owner {
+		t.Errorf("want permission level: %v, got: %v", service.Owner, permission.PermissionLevel)
+	}
+}
+
+func TestUpsertPermissionUser_OwnerSharesWithExistingEditor_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	// sharing with a principal that already has a non owner permission updates their level
+	_, err := documentService.UpsertPermissionUser(t.Context(), ownerId, editorId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("expected the owner to be able to change the level of an editor, got error: %v", err)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(t.Context(), editorId, documentId, editorId, nil)
+	if err != nil {
+		t.Fatalf("failed to get the permission of the editor with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Viewer {
+		t.Errorf("want permission level: %v, got: %v", service.Viewer, permission.PermissionLevel)
+	}
+}
@@ -0,0 +1,114 @@
+package document_repository_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestPermissionLevel_String_Unit(t *testing.T) {
+	want := map[service.PermissionLevel]string{
+		service.Viewer: "viewer",
+		service.Editor: "editor",
+		service.Owner: "owner",
+		service.PermissionLevel(42): "PermissionLevel(42)",
+	}
+	for level, name := range want {
+		if level.String() != name {
+			t.Errorf("want: %s, got: %s", name, level.String())
+		}
+	}
+}
+
+func TestPermissionLevel_JSONRoundTrip_Unit(t *testing.T) {
+	type payload struct {
+		Level service.PermissionLevel `json:"level"`
+		PublicAccess *service.PermissionLevel `json:"publicAccess"`
+	}
+	for _, level := range service.AllPermissions {
+		data, err := json.Marshal(payload{ Level: level, PublicAccess: &level })
+		if err != nil {
+			t.Fatalf("failed to marshal permission level: %v with error: %v", level, err)
+		}
+		want := `{"level":"` + level.String() + `","publicAccess":"` + level.String() + `"}`
+		if string(data) != want {
+			t.Errorf("want: %s, got: %s", want, data)
+		}
+		var decoded payload
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("failed to unmarshal permission level with error: %v", err)
+		}
+		if decoded.Level != level || decoded.PublicAccess == nil || *decoded.PublicAccess != level {
+			t.Errorf("want: %v after the round trip, got: %+v", level, decoded)
+		}
+	}
+}
+
+func TestPermissionLevel_MarshalUnknown_Unit(t *testing.T) {
+	if _, err := json.Marshal(service.PermissionLevel(42)); err == nil {
+		t.Errorf("expected an error when marshaling an unknown permission level")
+	}
+}
+
+func TestParsePermissionLevel_Unit(t *testing.T) {
+	level, err := service.ParsePermissionLevel("Editor")
+	if err != nil || level != service.Editor {
+		t.Errorf("want: %v ignoring case, got: %v with error: %v", service.Editor, level, err)
+	}
+	for _, name := range []string{ "", "admin", "42" } {
+		_, err := service.ParsePermissionLevel(name)
+		var invalidErr *service.InvalidInputError
+		if !errors.As(err, &invalidErr) {
+			t.Errorf("want invalid input error for permission level: %q, got: %v", name, err)
+		}
+	}
+}
+
+func TestParseGuestPermissions_Unit(t *testing.T) {
+	levels, err := service.ParseGuestPermissions([]string{ "viewer" })
+	if err != nil || len(levels) != 1 || levels[0] != service.Viewer {
+		t.Errorf("want: [%v], got: %v with error: %v", service.Viewer, levels, err)
+	}
+	for _, names := range [][]string{ { "viewer", "owner" }, { "admin" } } {
+		_, err := service.ParseGuestPermissions(names)
+		var invalidErr *service.InvalidInputError
+		if !errors.As(err, &invalidErr) {
+			t.Errorf("want invalid input error for guest permissions: %v, got: %v", names, err)
+		}
+	}
+}
+
+func TestDefaultGuestPermissions_Copy_Unit(t *testing.T) {
+	// writing to the returned slice does not change the default for later callers
+	levels := service.DefaultGuestPermissions()
+	levels[0] = service.Owner
+	if slices.Contains(service.DefaultGuestPermissions(), service.Owner) {
+		t.Errorf("want the default guest permissions unchanged, got: %v", service.DefaultGuestPermissions())
+	}
+}
+
+func TestPermissionLevel_UnmarshalInvalid_Unit(t *testing.T) {
+	for _, data := range []string{ `"admin"`, `2`, `null` } {
+		var level service.PermissionLevel
+		err := json.Unmarshal([]byte(data), &level)
+		if err == nil {
+			t.Errorf("expected an error when unmarshaling: %s, got: %v", data, level)
+		}
+	}
+}
+
+func TestPermissionLevel_ErrorMessageUsesName_Unit(t *testing.T) {
+	// the guest permission level is rejected before the repository is called
+	documentService := service.NewDocumentService(nil)
+	owner := service.Owner
+	_, err := documentService.CreateGuest(t.Context(), uuid.New(), uuid.New(), &owner, nil)
+	if err == nil || !strings.Contains(err.Error(), "owner") {
+		t.Errorf("want the error message to name the permission level, got: %v", err)
+	}
+}
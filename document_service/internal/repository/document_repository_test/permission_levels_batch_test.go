@@ -0,0 +1,158 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestGetPermissionLevelsForPrincipalOnDocuments_MixedLevels_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	principalId := uuid.New()
+	otherOwnerId := uuid.New()
+	// the principal owns one document, edits one, views one, and has no access to the last
+	ownedIds := createDocuments(t, documentRepo, principalId, 1)
+	otherIds := createDocuments(t, documentRepo, otherOwnerId, 3)
+	sharedLevels := []service.PermissionLevel{ service.Editor, service.Viewer }
+	for i, level := range sharedLevels {
+		_, err := documentService.UpsertPermissionUser(t.Context(), otherOwnerId, principalId, otherIds[i], level)
+		if err != nil {
+			t.Fatalf("failed to share document with error: %v", err)
+		}
+	}
+	documentIds := uuid.UUIDs{ ownedIds[0], otherIds[0], otherIds[1], otherIds[2] }
+	levels, err := documentService.GetPermissionLevelsForPrincipalOnDocuments(
+		t.Context(), principalId, principalId, documentIds,
+	)
+	if err != nil {
+		t.Fatalf("failed to get permission levels with error: %v", err)
+	}
+	want := map[uuid.UUID]service.PermissionLevel{
+		ownedIds[0]: service.Owner,
+		otherIds[0]: service.Editor,
+		otherIds[1]: service.Viewer,
+	}
+	if len(levels) != len(want) {
+		t.Errorf("want levels on %d documents, got: %v", len(want), levels)
+	}
+	for documentId, wantLevel := range want {
+		if level, ok := levels[documentId]; !ok || level != wantLevel {
+			t.Errorf("want level: %v on document: %s, got: %v with present: %v", wantLevel, documentId, level, ok)
+		}
+	}
+	if _, ok := levels[otherIds[2]]; ok {
+		t.Errorf("want no level on document: %s without access", otherIds[2])
+	}
+}
+
+func TestGetPermissionLevelsForPrincipalOnDocuments_OtherPrincipal_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	callerId := uuid.New()
+	principalId := uuid.New()
+	otherOwnerId := uuid.New()
+	// the principal edits a document owned by the caller and a document owned by someone else
+	callerOwnedIds := createDocuments(t, documentRepo, callerId, 1)
+	otherIds := createDocuments(t, documentRepo, otherOwnerId, 1)
+	_, err := documentService.UpsertPermissionUser(t.Context(), callerId, principalId, callerOwnedIds[0], service.Editor)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), otherOwnerId, principalId, otherIds[0], service.Editor)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	levels, err := documentService.GetPermissionLevelsForPrincipalOnDocuments(
+		t.Context(), callerId, principalId, uuid.UUIDs{ callerOwnedIds[0], otherIds[0] },
+	)
+	if err != nil {
+		t.Fatalf("failed to get permission levels with error: %v", err)
+	}
+	// only the level on the document that the caller owns is returned
+	if len(levels) != 1 || levels[callerOwnedIds[0]] != service.Editor {
+		t.Errorf("want only the editor level on document: %s, got: %v", callerOwnedIds[0], levels)
+	}
+}
+
+func TestGetPermissionLevelsForPrincipalOnDocuments_OverCap_Unit(t *testing.T) {
+	// the batch size is checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	documentIds := make(uuid.UUIDs, service.MaxPermissionLevelBatchSize + 1)
+	for i := range documentIds {
+		documentIds[i] = uuid.New()
+	}
+	principalId := uuid.New()
+	_, err := documentService.GetPermissionLevelsForPrincipalOnDocuments(t.Context(), principalId, principalId, documentIds)
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for too many documents, got: %v", err)
+	}
+}
+
+func TestGetPermissionsForPrincipals_MixedPrincipals_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	viewerId, invitedId, strangerId := uuid.New(), uuid.New(), uuid.New()
+	_, err := documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	// a pending share grants no access until it is accepted
+	if err = documentService.InviteUser(t.Context(), ownerId, invitedId, documentId, service.Editor); err != nil {
+		t.Fatalf("failed to invite user with error: %v", err)
+	}
+	levels, err := documentService.GetPermissionsForPrincipals(
+		t.Context(), documentId, ownerId, uuid.UUIDs{ ownerId, editorId, viewerId, invitedId, strangerId },
+	)
+	if err != nil {
+		t.Fatalf("failed to get permission levels with error: %v", err)
+	}
+	want := map[uuid.UUID]service.PermissionLevel{
+		ownerId: service.Owner,
+		editorId: service.Editor,
+		viewerId: service.Viewer,
+	}
+	if len(levels) != len(want) {
+		t.Errorf("want levels of %d principals, got: %v", len(want), levels)
+	}
+	for principalId, wantLevel := range want {
+		if level, ok := levels[principalId]; !ok || level != wantLevel {
+			t.Errorf("want level: %v for principal: %s, got: %v with present: %v", wantLevel, principalId, level, ok)
+		}
+	}
+	for _, principalId := range []uuid.UUID{ invitedId, strangerId } {
+		if _, ok := levels[principalId]; ok {
+			t.Errorf("want no level for principal: %s without access", principalId)
+		}
+	}
+}
+
+func TestGetPermissionsForPrincipals_NotOwner_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	_, err := documentService.GetPermissionsForPrincipals(t.Context(), documentId, editorId, uuid.UUIDs{ ownerId })
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when an editor reads the levels of principals, got: %v", err)
+	}
+}
+
+func TestGetPermissionsForPrincipals_OverCap_Unit(t *testing.T) {
+	// the batch size is checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	principalIds := make(uuid.UUIDs, service.MaxPermissionLevelBatchSize + 1)
+	for i := range principalIds {
+		principalIds[i] = uuid.New()
+	}
+	_, err := documentService.GetPermissionsForPrincipals(t.Context(), uuid.New(), uuid.New(), principalIds)
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for too many principals, got: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise the public link of a document:
+- principals without a permission can read a document through an enabled public link
+- disabling the public link revokes that access
+- disabling the public link bumps its version so links handed out before are not honoured again
+- only the owner of a document can change its public access
+*/
+
+func setPublicAccess(
+	t *testing.T,
+	documentService *service.DocumentService,
+	ownerId uuid.UUID,
+	documentId uuid.UUID,
+	publicAccess *service.PermissionLevel,
+) (publicLinkVersion *int32) {
+	version, err := documentService.SetPublicAccess(t.Context(), ownerId, documentId, publicAccess)
+	if err != nil {
+		t.Fatalf("failed to set public access with error: %v", err)
+	}
+	return &version
+}
+
+func TestSetPublicAccess_PublicViewerCanRead_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	strangerId := uuid.New()
+	// without a public link the stranger cannot read the document
+	_, err := documentService.GetDocument(t.Context(), strangerId, documentId, nil)
+	var permissionDeniedErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionDeniedErr) {
+		t.Fatalf("want permission denied error before the public link is enabled, got: %v", err)
+	}
+	publicAccess := service.Viewer
+	publicLinkVersion := setPublicAccess(t, documentService, ownerId, documentId, &publicAccess)
+	document, err := documentService.GetDocument(t.Context(), strangerId, documentId, publicLinkVersion)
+	if err != nil {
+		t.Fatalf("failed to get document through the public link with error: %v", err)
+	}
+	if document.PublicAccess == nil || *document.PublicAccess != service.Viewer {
+		t.Errorf("want public access: %v, got: %v", service.Viewer, document.PublicAccess)
+	}
+	permission, err := documentService.GetPermissionOfPrincipalOnDocument(
+		t.Context(), strangerId, documentId, strangerId, publicLinkVersion,
+	)
+	if err != nil {
+		t.Fatalf("failed to get permission through the public link with error: %v", err)
+	}
+	if permission.PermissionLevel != service.Viewer {
+		t.Errorf("want permission level: %v, got: %v", service.Viewer, permission.PermissionLevel)
+	}
+	// the public link is only honoured when the caller presents it
+	_, err = documentService.GetDocument(t.Context(), strangerId, documentId, nil)
+	if !errors.As(err, &permissionDeniedErr) {
+		t.Errorf("want permission denied error without the public link, got: %v", err)
+	}
+}
+
+func TestSetPublicAccess_DisableRevokesAccess_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	strangerId := uuid.New()
+	publicAccess := service.Editor
+	publicLinkVersion := setPublicAccess(t, documentService, ownerId, documentId, &publicAccess)
+	_, err := documentService.GetDocument(t.Context(), strangerId, documentId, publicLinkVersion)
+	if err != nil {
+		t.Fatalf("failed to get document through the public link with error: %v", err)
+	}
+	setPublicAccess(t, documentService, ownerId, documentId, nil)
+	_, err = documentService.GetDocument(t.Context(), strangerId, documentId, publicLinkVersion)
+	var permissionDeniedErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionDeniedErr) {
+		t.Errorf("want permission denied error after the public link is disabled, got: %v", err)
+	}
+	_, err = documentService.GetPermissionOfPrincipalOnDocument(
+		t.Context(), strangerId, documentId, strangerId, publicLinkVersion,
+	)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want not found error after the public link is disabled, got: %v", err)
+	}
+	// principals with an explicit permission keep their access
+	_, err = documentService.GetDocument(t.Context(), editorId, documentId, nil)
+	if err != nil {
+		t.Errorf("failed to get document as the editor with error: %v", err)
+	}
+}
+
+func TestSetPublicAccess_ReenableDoesNotHonourOldLink_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	strangerId := uuid.New()
+	publicAccess := service.Viewer
+	oldVersion := setPublicAccess(t, documentService, ownerId, documentId, &publicAccess)
+	// changing the level of an enabled public link keeps the links handed out so far
+	publicAccess = service.Editor
+	sameVersion := setPublicAccess(t, documentService, ownerId, documentId, &publicAccess)
+	if *sameVersion != *oldVersion {
+		t.Errorf("want public link version: %d after changing the level, got: %d", *oldVersion, *sameVersion)
+	}
+	setPublicAccess(t, documentService, ownerId, documentId, nil)
+	newVersion := setPublicAccess(t, documentService, ownerId, documentId, &publicAccess)
+	if *newVersion == *oldVersion {
+		t.Fatalf("want the public link version to change after disabling, got: %d", *newVersion)
+	}
+	_, err := documentService.GetDocument(t.Context(), strangerId, documentId, oldVersion)
+	var permissionDeniedErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionDeniedErr) {
+		t.Errorf("want permission denied error for a link from before the public link was disabled, got: %v", err)
+	}
+	_, err = documentService.GetDocument(t.Context(), strangerId, documentId, newVersion)
+	if err != nil {
+		t.Errorf("failed to get document through the re-enabled public link with error: %v", err)
+	}
+}
+
+func TestSetPublicAccess_OnlyOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	publicAccess := service.Viewer
+	_, err := documentService.SetPublicAccess(t.Context(), editorId, documentId, &publicAccess)
+	var permissionDeniedErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionDeniedErr) {
+		t.Errorf("want permission denied error when an editor sets public access, got: %v", err)
+	}
+	// the public link cannot grant ownership of the document
+	ownerAccess := service.Owner
+	_, err = documentService.SetPublicAccess(t.Context(), ownerId, documentId, &ownerAccess)
+	var invalidInputErr *service.InvalidInputError
+	if !errors.As(err, &invalidInputErr) {
+		t.Errorf("want invalid input error when granting owner through the public link, got: %v", err)
+	}
+}
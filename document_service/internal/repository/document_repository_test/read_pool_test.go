@@ -0,0 +1,114 @@
+package document_repository_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	configPkg "github.com/townsag/reed/document_service/internal/config"
+	"github.com/townsag/reed/document_service/internal/repository"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+// create a pool of its own against the postgres container, the connections acquired from it
+// can be counted without the acquisitions of other tests
+func createCountingPool(t *testing.T) *pgxpool.Pool {
+	sharedPool, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("failed to create a connection to the postgres container: %v", err)
+	}
+	config, err := pgxpool.ParseConfig(sharedPool.Config().ConnString())
+	if err != nil {
+		t.Fatalf("failed to parse connection string: %v", err)
+	}
+	config.AfterConnect = configPkg.RegisterTypes
+	pool, err := pgxpool.NewWithConfig(t.Context(), config)
+	if err != nil {
+		t.Fatalf("unable to create a connection pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// fails the test unless each pool had the wanted number of connections acquired from it since
+// the counts were taken
+func verifyAcquired(
+	t *testing.T, operation string, primary *pgxpool.Pool, primaryBefore int64, wantPrimary int64,
+	replica *pgxpool.Pool, replicaBefore int64, wantReplica int64,
+) {
+	gotPrimary := primary.Stat().AcquireCount() - primaryBefore
+	gotReplica := replica.Stat().AcquireCount() - replicaBefore
+	if gotPrimary != wantPrimary || gotReplica != wantReplica {
+		t.Errorf(
+			"%s: want %d primary and %d replica acquisitions, got: %d primary and %d replica",
+			operation, wantPrimary, wantReplica, gotPrimary, gotReplica,
+		)
+	}
+}
+
+func TestReadPool_RoutesListsToReplica_Integration(t *testing.T) {
+	primary := createCountingPool(t)
+	// the replica is the same database, only the pool that a method acquires from matters
+	replica := createCountingPool(t)
+	documentRepo := repository.NewDocumentRepositoryWithReadPool(
+		primary, replica, repository.DefaultAcquireTimeout, repository.DefaultQueryTimeout,
+	)
+	ownerId := uuid.New()
+	primaryBefore, replicaBefore := primary.Stat().AcquireCount(), replica.Stat().AcquireCount()
+	documentId, err := documentRepo.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	verifyAcquired(t, "CreateDocument", primary, primaryBefore, 1, replica, replicaBefore, 0)
+
+	primaryBefore, replicaBefore = primary.Stat().AcquireCount(), replica.Stat().AcquireCount()
+	cursor := service.NewBeginningCursor(service.CreatedAt)
+	_, _, _, err = documentRepo.ListPermissionsOnDocument(t.Context(), documentId, service.AllPermissions, cursor, 10, nil)
+	if err != nil {
+		t.Fatalf("failed to list permissions on document with error: %v", err)
+	}
+	_, _, _, err = documentRepo.ListDocumentsByPrincipal(t.Context(), ownerId, service.AllPermissions, false, nil, false, cursor, 10)
+	if err != nil {
+		t.Fatalf("failed to list documents by principal with error: %v", err)
+	}
+	_, err = documentRepo.CountPermissionsOnDocument(t.Context(), documentId, service.AllPermissions)
+	if err != nil {
+		t.Fatalf("failed to count permissions on document with error: %v", err)
+	}
+	verifyAcquired(t, "list methods", primary, primaryBefore, 0, replica, replicaBefore, 3)
+
+	// reads that grant access stay on the primary
+	primaryBefore, replicaBefore = primary.Stat().AcquireCount(), replica.Stat().AcquireCount()
+	_, _, err = documentRepo.GetPermissionLevel(t.Context(), documentId, ownerId)
+	if err != nil {
+		t.Fatalf("failed to get permission level with error: %v", err)
+	}
+	_, err = documentRepo.GetDocument(t.Context(), documentId)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	verifyAcquired(t, "access checks", primary, primaryBefore, 2, replica, replicaBefore, 0)
+}
+
+func TestReadPool_FallsBackToPrimary_Integration(t *testing.T) {
+	primary := createCountingPool(t)
+	documentRepo := repository.NewDocumentRepositoryWithReadPool(
+		primary, nil, repository.DefaultAcquireTimeout, repository.DefaultQueryTimeout,
+	)
+	ownerId := uuid.New()
+	documentId, err := documentRepo.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	before := primary.Stat().AcquireCount()
+	_, _, _, err = documentRepo.ListPermissionsOnDocument(
+		t.Context(), documentId, service.AllPermissions, service.NewBeginningCursor(service.CreatedAt), 10, nil,
+	)
+	if err != nil {
+		t.Fatalf("failed to list permissions on document with error: %v", err)
+	}
+	if got := primary.Stat().AcquireCount() - before; got != 1 {
+		t.Errorf("want the list to acquire from the primary without a replica, got: %d acquisitions", got)
+	}
+}
@@ -0,0 +1,134 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/townsag/reed/document_service/internal/repository"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+// fails the test unless the principal holds the wanted permission level on the document, a nil
+// want means that the principal should not have a permission
+func verifyPermissionLevel(
+	t *testing.T,
+	documentRepo *repository.DocumentRepository,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+	want *service.PermissionLevel,
+) {
+	level, found, err := documentRepo.GetPermissionLevel(t.Context(), documentId, principalId)
+	if err != nil {
+		t.Fatalf("failed to get permission level with error: %v", err)
+	}
+	if want == nil {
+		if found {
+			t.Errorf("want no permission for principal: %s on document: %s, got: %v", principalId, documentId, level)
+		}
+		return
+	}
+	if !found || level != *want {
+		t.Errorf("want permission: %v for principal: %s on document: %s, got: %v with found: %v", *want, principalId, documentId, level, found)
+	}
+}
+
+func TestReassignOwnedDocuments_Collaborators_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentService := service.NewDocumentService(documentRepo)
+	departingId := uuid.New()
+	successorId := uuid.New()
+	editorId := uuid.New()
+	// one document is shared with an editor, one is already shared with the successor, and one
+	// has a guest
+	documentIds := createDocuments(t, documentRepo, departingId, 3)
+	_, err := documentService.UpsertPermissionUser(t.Context(), departingId, editorId, documentIds[0], service.Editor)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), departingId, successorId, documentIds[1], service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	guestId, err := documentService.CreateGuest(t.Context(), departingId, documentIds[2], nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	// a document that the departing user was only invited to is not moved
+	otherOwnerId := uuid.New()
+	otherDocumentId, err := documentRepo.CreateDocument(t.Context(), otherOwnerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), otherOwnerId, departingId, otherDocumentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	moved, err := documentService.ReassignOwnedDocuments(t.Context(), departingId, successorId)
+	if err != nil {
+		t.Fatalf("failed to reassign owned documents with error: %v", err)
+	}
+	if moved != int64(len(documentIds)) {
+		t.Errorf("want %d documents moved, got: %d", len(documentIds), moved)
+	}
+	owner, editor, viewer := service.Owner, service.Editor, service.Viewer
+	for _, documentId := range documentIds {
+		verifyPermissionLevel(t, documentRepo, documentId, successorId, &owner)
+		verifyPermissionLevel(t, documentRepo, documentId, departingId, nil)
+	}
+	// the other collaborators keep their permissions
+	verifyPermissionLevel(t, documentRepo, documentIds[0], editorId, &editor)
+	verifyPermissionLevel(t, documentRepo, documentIds[2], guestId, &viewer)
+	verifyPermissionLevel(t, documentRepo, otherDocumentId, departingId, &viewer)
+	verifyPermissionLevel(t, documentRepo, otherDocumentId, otherOwnerId, &owner)
+}
+
+func TestReassignOwnedDocuments_Batches_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	departingId := uuid.New()
+	successorId := uuid.New()
+	// a batch size that does not divide the number of documents leaves a partial last batch
+	documentIds := createDocuments(t, documentRepo, departingId, 5)
+	moved, err := documentRepo.ReassignOwnedDocuments(t.Context(), departingId, successorId, 2)
+	if err != nil {
+		t.Fatalf("failed to reassign owned documents with error: %v", err)
+	}
+	if moved != int64(len(documentIds)) {
+		t.Errorf("want %d documents moved, got: %d", len(documentIds), moved)
+	}
+	owner := service.Owner
+	for _, documentId := range documentIds {
+		verifyPermissionLevel(t, documentRepo, documentId, successorId, &owner)
+		verifyPermissionLevel(t, documentRepo, documentId, departingId, nil)
+	}
+	// a departing user that owns nothing has nothing to move
+	moved, err = documentRepo.ReassignOwnedDocuments(t.Context(), departingId, successorId, 2)
+	if err != nil {
+		t.Fatalf("failed to reassign owned documents with error: %v", err)
+	}
+	if moved != 0 {
+		t.Errorf("want no documents moved on the second call, got: %d", moved)
+	}
+}
+
+func TestReassignOwnedDocuments_SameOwner_Unit(t *testing.T) {
+	// the request is rejected before the repository is called
+	documentService := service.NewDocumentService(nil)
+	ownerId := uuid.New()
+	_, err := documentService.ReassignOwnedDocuments(t.Context(), ownerId, ownerId)
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError when reassigning documents to the same owner, got: %v", err)
+	}
+}
+
+func TestReassignOwnedDocuments_NonPositiveBatchSize_Unit(t *testing.T) {
+	// the batch size is rejected before a connection is acquired
+	documentRepo := &repository.DocumentRepository{}
+	_, err := documentRepo.ReassignOwnedDocuments(t.Context(), uuid.New(), uuid.New(), 0)
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for a batch size of 0, got: %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package document_repository_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+// read each document as the principal, waiting for each access to be recorded so that the
+// accesses are recorded in the order of the reads
+func readDocuments(t *testing.T, documentService *service.DocumentService, principalId uuid.UUID, documentIds ...uuid.UUID) {
+	for _, documentId := range documentIds {
+		if _, err := documentService.GetDocument(t.Context(), principalId, documentId, nil); err != nil {
+			t.Fatalf("failed to get document with error: %v", err)
+		}
+		documentService.WaitForAccessWrites()
+	}
+}
+
+// page through the recently accessed documents of the principal
+func listRecentlyAccessedIds(
+	t *testing.T, documentService *service.DocumentService, principalId uuid.UUID, pageSize int32,
+) []uuid.UUID {
+	var got []uuid.UUID
+	var cursor *service.Cursor
+	for range 10 {
+		accessedDocuments, cursorResp, hasMore, err := documentService.ListRecentlyAccessed(
+			t.Context(), principalId, cursor, pageSize,
+		)
+		if err != nil {
+			t.Fatalf("failed to list recently accessed documents with error: %v", err)
+		}
+		for _, accessedDocument := range accessedDocuments {
+			got = append(got, accessedDocument.Document.ID)
+		}
+		if !hasMore {
+			break
+		}
+		cursor = cursorResp
+	}
+	return got
+}
+
+func verifyDocumentIds(t *testing.T, want []uuid.UUID, got []uuid.UUID) {
+	if len(got) != len(want) {
+		t.Fatalf("want %d recently accessed documents, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want document: %s at position %d, got: %s", want[i], i, got[i])
+		}
+	}
+}
+
+func TestListRecentlyAccessed_AccessOrder_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentIds := make([]uuid.UUID, 4)
+	for i := range documentIds {
+		documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create document with error: %v", err)
+		}
+		documentIds[i] = documentId
+	}
+	// the last document is never read so it is not in the list, reading the first document
+	// again moves it to the front
+	readDocuments(t, documentService, ownerId, documentIds[0], documentIds[1], documentIds[2], documentIds[0])
+	want := []uuid.UUID{ documentIds[0], documentIds[2], documentIds[1] }
+	verifyDocumentIds(t, want, listRecentlyAccessedIds(t, documentService, ownerId, service.DefaultPageSize))
+	// the same order is read a page at a time
+	verifyDocumentIds(t, want, listRecentlyAccessedIds(t, documentService, ownerId, 2))
+}
+
+func TestListRecentlyAccessed_PerPrincipal_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	readDocuments(t, documentService, editorId, documentId)
+	// the owner has not read the document
+	if got := listRecentlyAccessedIds(t, documentService, ownerId, service.DefaultPageSize); len(got) != 0 {
+		t.Errorf("want no recently accessed documents for the owner, got: %d", len(got))
+	}
+	verifyDocumentIds(
+		t, []uuid.UUID{ documentId }, listRecentlyAccessedIds(t, documentService, editorId, service.DefaultPageSize),
+	)
+}
+
+func TestListRecentlyAccessed_SkipsLostAccessAndArchived_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	archivedId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	readDocuments(t, documentService, editorId, documentId)
+	readDocuments(t, documentService, ownerId, archivedId)
+	// a failed read is not recorded
+	if _, err = documentService.GetDocument(t.Context(), editorId, archivedId, nil); err == nil {
+		t.Fatalf("want an error when reading a document without a permission")
+	}
+	documentService.WaitForAccessWrites()
+	if err = documentService.LeaveDocument(t.Context(), editorId, documentId); err != nil {
+		t.Fatalf("failed to remove the editor from the document with error: %v", err)
+	}
+	if err = documentService.ArchiveDocument(t.Context(), ownerId, archivedId); err != nil {
+		t.Fatalf("failed to archive document with error: %v", err)
+	}
+	if got := listRecentlyAccessedIds(t, documentService, editorId, service.DefaultPageSize); len(got) != 0 {
+		t.Errorf("want no recently accessed documents after losing access, got: %d", len(got))
+	}
+	if got := listRecentlyAccessedIds(t, documentService, ownerId, service.DefaultPageSize); len(got) != 0 {
+		t.Errorf("want no recently accessed documents after archiving, got: %d", len(got))
+	}
+}
+
+func TestListRecentlyAccessed_InvalidSortField_Unit(t *testing.T) {
+	// the cursor is rejected before the repository is called
+	documentService := service.NewDocumentService(nil)
+	_, _, _, err := documentService.ListRecentlyAccessed(
+		t.Context(), uuid.New(), service.NewBeginningCursor(service.CreatedAt), service.DefaultPageSize,
+	)
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for a cursor sorted by created at, got: %v", err)
+	}
+}
+
+// records accesses without a database, each write blocks until the test releases it
+type blockingAccessRepository struct {
+	stubDocumentRepository
+	release chan struct{}
+	recorded *atomic.Int32
+}
+
+func (r blockingAccessRepository) GetPermissionLevel(
+	ctx context.Context, documentId uuid.UUID, principalId uuid.UUID,
+) (service.PermissionLevel, bool, error) {
+	return service.Viewer, true, nil
+}
+
+func (r blockingAccessRepository) RecordDocumentAccess(
+	ctx context.Context, principalId uuid.UUID, documentId uuid.UUID,
+) error {
+	<-r.release
+	r.recorded.Add(1)
+	return nil
+}
+
+func TestRecordAccess_DropsWhenQueueFull_Unit(t *testing.T) {
+	repo := blockingAccessRepository{ release: make(chan struct{}), recorded: &atomic.Int32{} }
+	documentService := service.NewDocumentService(repo)
+	principalId := uuid.New()
+	// the writer is stuck on the first access, so the reads past the size of the queue are
+	// dropped instead of blocking
+	reads := service.AccessQueueSize + 10
+	for range reads {
+		if _, err := documentService.GetDocument(t.Context(), principalId, uuid.New(), nil); err != nil {
+			t.Fatalf("failed to get document with error: %v", err)
+		}
+	}
+	close(repo.release)
+	documentService.StopAccessWrites()
+	if got := int(repo.recorded.Load()); got >= reads || got > service.AccessQueueSize+1 {
+		t.Errorf("want the accesses past the size of the queue dropped, got: %d recorded of %d", got, reads)
+	}
+	// a read after the service stopped does not record its access
+	recorded := repo.recorded.Load()
+	if _, err := documentService.GetDocument(t.Context(), principalId, uuid.New(), nil); err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	documentService.WaitForAccessWrites()
+	if got := repo.recorded.Load(); got != recorded {
+		t.Errorf("want no access recorded after stopping, got: %d, want: %d", got, recorded)
+	}
+}
@@ -0,0 +1,159 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestListSharedDocumentsByOwner_SharedAndUnshared_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	// the owner has one unshared document, one document shared with a user, and one document
+	// shared with a user and a guest
+	unsharedId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	sharedOnceId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, uuid.New(), sharedOnceId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	sharedTwiceId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, uuid.New(), sharedTwiceId, service.Editor)
+	if err != nil {
+		t.Fatalf("failed to share document with error: %v", err)
+	}
+	_, err = documentService.CreateGuest(t.Context(), ownerId, sharedTwiceId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	sharedDocuments, _, hasMore, err := documentService.ListSharedDocumentsByOwner(
+		t.Context(), ownerId, nil, service.DefaultPageSize,
+	)
+	if err != nil {
+		t.Fatalf("failed to list shared documents with error: %v", err)
+	}
+	if hasMore {
+		t.Errorf("want no more shared documents after the first page")
+	}
+	// shared documents are listed newest first with their collaborator counts
+	want := []service.SharedDocument{
+		{ Document: service.Document{ ID: sharedTwiceId }, CollaboratorCount: 2 },
+		{ Document: service.Document{ ID: sharedOnceId }, CollaboratorCount: 1 },
+	}
+	if len(sharedDocuments) != len(want) {
+		t.Fatalf("want %d shared documents, got: %d", len(want), len(sharedDocuments))
+	}
+	for i := range want {
+		if sharedDocuments[i].Document.ID != want[i].Document.ID {
+			t.Errorf("want document: %s at position %d, got: %s", want[i].Document.ID, i, sharedDocuments[i].Document.ID)
+		}
+		if sharedDocuments[i].CollaboratorCount != want[i].CollaboratorCount {
+			t.Errorf(
+				"want collaborator count: %d for document: %s, got: %d",
+				want[i].CollaboratorCount, want[i].Document.ID, sharedDocuments[i].CollaboratorCount,
+			)
+		}
+		if sharedDocuments[i].Document.ID == unsharedId {
+			t.Errorf("want the unshared document: %s to not be listed", unsharedId)
+		}
+	}
+}
+
+func TestListSharedDocumentsByOwner_OnlyOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	// the document is shared with the editor, it is not listed as shared by the editor
+	sharedDocuments, _, _, err := documentService.ListSharedDocumentsByOwner(
+		t.Context(), editorId, nil, service.DefaultPageSize,
+	)
+	if err != nil {
+		t.Fatalf("failed to list shared documents with error: %v", err)
+	}
+	if len(sharedDocuments) != 0 {
+		t.Errorf("want no shared documents for a collaborator, got: %v", sharedDocuments)
+	}
+	// removing the only collaborator removes the document from the shared documents of the owner
+	err = documentService.DeletePermissionPrincipal(t.Context(), editorId, documentId)
+	if err != nil {
+		t.Fatalf("failed to delete permission with error: %v", err)
+	}
+	sharedDocuments, _, _, err = documentService.ListSharedDocumentsByOwner(
+		t.Context(), ownerId, nil, service.DefaultPageSize,
+	)
+	if err != nil {
+		t.Fatalf("failed to list shared documents with error: %v", err)
+	}
+	if len(sharedDocuments) != 0 {
+		t.Errorf("want no shared documents once the collaborator is removed, got: %v", sharedDocuments)
+	}
+}
+
+func TestListSharedDocumentsByOwner_Pagination_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	// interleave shared and unshared documents so that pages have to skip unshared documents
+	var sharedIds []uuid.UUID
+	for i := range 6 {
+		documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create document with error: %v", err)
+		}
+		if i % 2 == 0 {
+			continue
+		}
+		_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, uuid.New(), documentId, service.Viewer)
+		if err != nil {
+			t.Fatalf("failed to share document with error: %v", err)
+		}
+		sharedIds = append(sharedIds, documentId)
+	}
+	var got []uuid.UUID
+	var cursor *service.Cursor
+	for range 10 {
+		sharedDocuments, cursorResp, hasMore, err := documentService.ListSharedDocumentsByOwner(
+			t.Context(), ownerId, cursor, 2,
+		)
+		if err != nil {
+			t.Fatalf("failed to list shared documents with error: %v", err)
+		}
+		for _, sharedDocument := range sharedDocuments {
+			got = append(got, sharedDocument.Document.ID)
+		}
+		if !hasMore {
+			break
+		}
+		cursor = cursorResp
+	}
+	want := []uuid.UUID{ sharedIds[2], sharedIds[1], sharedIds[0] }
+	if len(got) != len(want) {
+		t.Fatalf("want %d shared documents, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want document: %s at position %d, got: %s", want[i], i, got[i])
+		}
+	}
+}
+
+func TestListSharedDocumentsByOwner_InvalidSortField_Unit(t *testing.T) {
+	// the cursor is rejected before the repository is called
+	documentService := service.NewDocumentService(nil)
+	_, _, _, err := documentService.ListSharedDocumentsByOwner(
+		t.Context(), uuid.New(), service.NewBeginningCursor(service.LastModifiedAt), service.DefaultPageSize,
+	)
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for a cursor sorted by last modified at, got: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestGetDocumentSharingSummary_Counts_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	// share the document with more collaborators than fit in the preview
+	collaboratorCount := int(service.SharingSummaryPreviewSize) + 2
+	for range collaboratorCount - 1 {
+		_, err := documentService.UpsertPermissionUser(t.Context(), ownerId, uuid.New(), documentId, service.Viewer)
+		if err != nil {
+			t.Fatalf("failed to share document with error: %v", err)
+		}
+	}
+	// collaborators can read the summary as well as the owner
+	for _, callerId := range []uuid.UUID{ownerId, editorId} {
+		summary, err := documentService.GetDocumentSharingSummary(t.Context(), documentId, callerId)
+		if err != nil {
+			t.Fatalf("failed to get sharing summary with error: %v", err)
+		}
+		if summary.Owner.RecipientID != ownerId || summary.Owner.PermissionLevel != service.Owner {
+			t.Errorf("want owner: %v, got: %v at level: %v", ownerId, summary.Owner.RecipientID, summary.Owner.PermissionLevel)
+		}
+		if summary.CollaboratorCount != int64(collaboratorCount) {
+			t.Errorf("want collaborator count: %d, got: %d", collaboratorCount, summary.CollaboratorCount)
+		}
+		if len(summary.Collaborators) != int(service.SharingSummaryPreviewSize) {
+			t.Errorf("want %d collaborators in the preview, got: %d", service.SharingSummaryPreviewSize, len(summary.Collaborators))
+		}
+		for _, collaborator := range summary.Collaborators {
+			if collaborator.PermissionLevel == service.Owner {
+				t.Errorf("expected the owner not to be listed as a collaborator")
+			}
+		}
+	}
+}
+
+func TestGetDocumentSharingSummary_OnlyOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	summary, err := documentService.GetDocumentSharingSummary(t.Context(), documentId, ownerId)
+	if err != nil {
+		t.Fatalf("failed to get sharing summary with error: %v", err)
+	}
+	if summary.Owner.RecipientID != ownerId {
+		t.Errorf("want owner: %v, got: %v", ownerId, summary.Owner.RecipientID)
+	}
+	if summary.CollaboratorCount != 0 || len(summary.Collaborators) != 0 {
+		t.Errorf(
+			"want no collaborators, got count: %d and preview: %v",
+			summary.CollaboratorCount, summary.Collaborators,
+		)
+	}
+}
+
+func TestGetDocumentSharingSummary_NoAccess_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, _ := createDocumentWithEditor(t, documentService)
+	_, err := documentService.GetDocumentSharingSummary(t.Context(), documentId, uuid.New())
+	var permissionDeniedErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionDeniedErr) {
+		t.Errorf("want permission denied error for a caller without access, got: %v", err)
+	}
+}
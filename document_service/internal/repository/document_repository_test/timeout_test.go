@@ -0,0 +1,79 @@
+package document_repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	configPkg "github.com/townsag/reed/document_service/internal/config"
+	"github.com/townsag/reed/document_service/internal/repository"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+// create a pool against the postgres container that only ever holds one connection so that
+// the pool can be exhausted by holding that connection
+func createSingleConnectionPool(t *testing.T) *pgxpool.Pool {
+	sharedPool, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("failed to create a connection to the postgres container: %v", err)
+	}
+	config, err := pgxpool.ParseConfig(sharedPool.Config().ConnString())
+	if err != nil {
+		t.Fatalf("failed to parse connection string: %v", err)
+	}
+	config.MaxConns = 1
+	config.AfterConnect = configPkg.RegisterTypes
+	pool, err := pgxpool.NewWithConfig(t.Context(), config)
+	if err != nil {
+		t.Fatalf("unable to create a connection pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestAcquireTimeout_ExhaustedPool_Integration(t *testing.T) {
+	pool := createSingleConnectionPool(t)
+	acquireTimeout := 100 * time.Millisecond
+	documentRepo := repository.NewDocumentRepositoryWithTimeouts(pool, acquireTimeout, time.Minute)
+	// hold the only connection in the pool
+	conn, err := pool.Acquire(t.Context())
+	if err != nil {
+		t.Fatalf("failed to acquire the only connection in the pool with error: %v", err)
+	}
+	defer conn.Release()
+	// use a context without a deadline so that only the acquire timeout can end the wait
+	start := time.Now()
+	_, err = documentRepo.GetDocument(context.Background(), uuid.New())
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Fatal("expected an error when the pool is exhausted but got nil")
+	}
+	var repoErr *service.RepoImplError
+	if !errors.As(err, &repoErr) {
+		t.Errorf("want repository implementation error, got: %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want the error to wrap context.DeadlineExceeded, got: %v", err)
+	}
+	if elapsed > 10 * acquireTimeout {
+		t.Errorf("want the acquire to time out after about %v, took: %v", acquireTimeout, elapsed)
+	}
+}
+
+func TestAcquireTimeout_ReleasedConnection_Integration(t *testing.T) {
+	pool := createSingleConnectionPool(t)
+	documentRepo := repository.NewDocumentRepositoryWithTimeouts(pool, 100 * time.Millisecond, time.Minute)
+	// once the connection is released the repository is able to acquire it again
+	documentId, err := documentRepo.CreateDocument(t.Context(), uuid.New(), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create a document with error: %v", err)
+	}
+	_, err = documentRepo.GetDocument(t.Context(), documentId)
+	if err != nil {
+		t.Errorf("failed to get the document after releasing the connection with error: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestGetDocumentOrTombstone_Deleted_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	// the database clock is used for deleted at, allow for a small skew with the test clock
+	before := time.Now().Add(-time.Minute)
+	err = documentService.DeleteDocument(t.Context(), documentId)
+	if err != nil {
+		t.Fatalf("failed to delete document with error: %v", err)
+	}
+	document, tombstone, err := documentService.GetDocumentOrTombstone(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("want a tombstone for a just deleted document, got error: %v", err)
+	}
+	if document != nil {
+		t.Errorf("want no document for a deleted document, got: %+v", document)
+	}
+	if tombstone == nil {
+		t.Fatalf("want a tombstone for a just deleted document, got nil")
+	}
+	if tombstone.DocumentID != documentId {
+		t.Errorf("want tombstone of document: %s, got: %s", documentId, tombstone.DocumentID)
+	}
+	if tombstone.DeletedAt.Before(before) {
+		t.Errorf("want deleted at after: %v, got: %v", before, tombstone.DeletedAt)
+	}
+	// the plain read of the document still reports not found
+	_, err = documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want a not found error from GetDocument on a deleted document, got: %v", err)
+	}
+}
+
+func TestGetDocumentOrTombstone_NeverExisted_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	document, tombstone, err := documentService.GetDocumentOrTombstone(t.Context(), uuid.New(), uuid.New(), nil)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want a not found error for a document that never existed, got: %v", err)
+	}
+	if document != nil || tombstone != nil {
+		t.Errorf("want neither a document nor a tombstone, got: %+v and %+v", document, tombstone)
+	}
+}
+
+func TestGetDocumentOrTombstone_Active_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	document, tombstone, err := documentService.GetDocumentOrTombstone(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	if tombstone != nil || document == nil || document.ID != documentId {
+		t.Errorf("want document: %s without a tombstone, got: %+v and %+v", documentId, document, tombstone)
+	}
+	// a principal without a permission is still denied, the tombstone mode does not bypass it
+	_, _, err = documentService.GetDocumentOrTombstone(t.Context(), uuid.New(), documentId, nil)
+	var permissionErr *service.PermissionDeniedError
+	if !errors.As(err, &permissionErr) {
+		t.Errorf("want a permission denied error for a stranger, got: %v", err)
+	}
+}
+
+func TestGetDocumentOrTombstone_OnlyFormerCollaborators_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	if err := documentService.DeleteDocument(t.Context(), documentId); err != nil {
+		t.Fatalf("failed to delete document with error: %v", err)
+	}
+	// a collaborator that held a permission when the document was deleted sees the tombstone
+	_, tombstone, err := documentService.GetDocumentOrTombstone(t.Context(), editorId, documentId, nil)
+	if err != nil || tombstone == nil {
+		t.Fatalf("want a tombstone for a former editor, got: %+v with error: %v", tombstone, err)
+	}
+	// a principal that never had access cannot learn that the document existed
+	_, tombstone, err = documentService.GetDocumentOrTombstone(t.Context(), uuid.New(), documentId, nil)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) || tombstone != nil {
+		t.Errorf("want a not found error without a tombstone for a stranger, got: %+v with error: %v", tombstone, err)
+	}
+}
+
+func TestPruneDocumentTombstones_PastRetention_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	if err := documentService.DeleteDocument(t.Context(), documentId); err != nil {
+		t.Fatalf("failed to delete document with error: %v", err)
+	}
+	// a tombstone within the retention is kept
+	pruned, err := documentService.PruneDocumentTombstones(t.Context(), time.Now())
+	if err != nil || pruned != 0 {
+		t.Fatalf("want no tombstones pruned within the retention, got: %d with error: %v", pruned, err)
+	}
+	// move the clock past the retention instead of waiting for it
+	pruned, err = documentService.PruneDocumentTombstones(
+		t.Context(), time.Now().Add(service.TombstoneRetention + time.Minute),
+	)
+	if err != nil || pruned != 1 {
+		t.Fatalf("want one tombstone pruned past the retention, got: %d with error: %v", pruned, err)
+	}
+	_, tombstone, err := documentService.GetDocumentOrTombstone(t.Context(), ownerId, documentId, nil)
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) || tombstone != nil {
+		t.Errorf("want a not found error without a tombstone after pruning, got: %+v with error: %v", tombstone, err)
+	}
+}
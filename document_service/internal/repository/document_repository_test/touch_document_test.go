@@ -0,0 +1,82 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+func TestTouchDocument_Editor_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, editorId := createDocumentWithEditor(t, documentService)
+	before, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	err = documentService.TouchDocument(t.Context(), documentId, editorId)
+	if err != nil {
+		t.Fatalf("failed to touch document with error: %v", err)
+	}
+	after, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	if !after.LastModifiedAt.After(before.LastModifiedAt) {
+		t.Errorf("want last modified at to advance past: %v, got: %v", before.LastModifiedAt, after.LastModifiedAt)
+	}
+	// touching a document does not change its content, so no history is recorded
+	changes, _, _, err := documentService.GetDocumentHistory(t.Context(), documentId, ownerId, nil, service.MaxPageSize)
+	if err != nil {
+		t.Fatalf("failed to get document history with error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("want no history after touching a document, got: %d changes", len(changes))
+	}
+}
+
+func TestTouchDocument_Viewer_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	viewerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	_, err = documentService.UpsertPermissionUser(t.Context(), ownerId, viewerId, documentId, service.Viewer)
+	if err != nil {
+		t.Fatalf("failed to share document with viewer with error: %v", err)
+	}
+	before, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	var permissionErr *service.PermissionDeniedError
+	err = documentService.TouchDocument(t.Context(), documentId, viewerId)
+	if !errors.As(err, &permissionErr) {
+		t.Errorf("want a permission denied error when a viewer touches a document, got: %v", err)
+	}
+	// a principal without a permission is denied as well
+	err = documentService.TouchDocument(t.Context(), documentId, uuid.New())
+	if !errors.As(err, &permissionErr) {
+		t.Errorf("want a permission denied error when a stranger touches a document, got: %v", err)
+	}
+	after, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	if !after.LastModifiedAt.Equal(before.LastModifiedAt) {
+		t.Errorf("want last modified at: %v to be unchanged, got: %v", before.LastModifiedAt, after.LastModifiedAt)
+	}
+}
+
+func TestTouchDocument_Archived_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId := createArchivedDocument(t, documentService)
+	err := documentService.TouchDocument(t.Context(), documentId, ownerId)
+	var goneErr *service.GoneError
+	if !errors.As(err, &goneErr) {
+		t.Errorf("want a gone error when touching an archived document, got: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package document_repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise the set, change, clear and leave unchanged semantics of updating a document:
+- a nil name or description keeps its old value
+- a cleared name or description is set back to null and the history records the removal
+- a field cannot be both set and cleared in the same update
+*/
+
+// read the document and check its name and description, a nil want means the field is null
+func verifyNameAndDescription(
+	t *testing.T,
+	documentService *service.DocumentService,
+	ownerId uuid.UUID,
+	documentId uuid.UUID,
+	wantName *string,
+	wantDescription *string,
+) {
+	document, err := documentService.GetDocument(t.Context(), ownerId, documentId, nil)
+	if err != nil {
+		t.Fatalf("failed to get document with error: %v", err)
+	}
+	if !equalOptionalStrings(wantName, document.Name) {
+		t.Errorf("want name: %v, got: %v", derefOrNil(wantName), derefOrNil(document.Name))
+	}
+	if !equalOptionalStrings(wantDescription, document.Description) {
+		t.Errorf("want description: %v, got: %v", derefOrNil(wantDescription), derefOrNil(document.Description))
+	}
+}
+
+func equalOptionalStrings(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func derefOrNil(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+func TestUpdateDocument_SetChangeClearUnchanged_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	verifyNameAndDescription(t, documentService, ownerId, documentId, nil, nil)
+	// set both fields on a document that has neither
+	name, description := "roadmap", "the plan for next quarter"
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, &name, &description, false, false); err != nil {
+		t.Fatalf("failed to set the name and description with error: %v", err)
+	}
+	verifyNameAndDescription(t, documentService, ownerId, documentId, &name, &description)
+	// change the name, the description is left unchanged
+	newName := "roadmap v2"
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, &newName, nil, false, false); err != nil {
+		t.Fatalf("failed to change the name with error: %v", err)
+	}
+	verifyNameAndDescription(t, documentService, ownerId, documentId, &newName, &description)
+	// clear the description, the name is left unchanged
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, nil, nil, false, true); err != nil {
+		t.Fatalf("failed to clear the description with error: %v", err)
+	}
+	verifyNameAndDescription(t, documentService, ownerId, documentId, &newName, nil)
+	// clear the name while setting the description again
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, nil, &description, true, false); err != nil {
+		t.Fatalf("failed to clear the name with error: %v", err)
+	}
+	verifyNameAndDescription(t, documentService, ownerId, documentId, nil, &description)
+}
+
+func TestUpdateDocument_ClearRecordedInHistory_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	name, description := "notes", "meeting notes"
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, &name, &description, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	if err = documentService.UpdateDocument(t.Context(), documentId, ownerId, nil, nil, false, true); err != nil {
+		t.Fatalf("failed to clear the description with error: %v", err)
+	}
+	changes, _, _, err := documentService.GetDocumentHistory(t.Context(), documentId, ownerId, nil, service.DefaultPageSize)
+	if err != nil {
+		t.Fatalf("failed to get the history of the document with error: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("want one change in the history of the document, got: %d", len(changes))
+	}
+	change := changes[0]
+	if !equalOptionalStrings(change.OldDescription, &description) || change.NewDescription != nil {
+		t.Errorf(
+			"want the description to change from: %s to nil, got: %v to %v",
+			description, derefOrNil(change.OldDescription), derefOrNil(change.NewDescription),
+		)
+	}
+	if !equalOptionalStrings(change.OldName, &name) || !equalOptionalStrings(change.NewName, &name) {
+		t.Errorf("want the name to be unchanged, got: %v to %v", derefOrNil(change.OldName), derefOrNil(change.NewName))
+	}
+}
+
+func TestUpdateDocument_SetAndClear_Unit(t *testing.T) {
+	// the inputs are checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	name := "both"
+	for _, update := range []struct{ name *string; description *string; clearName bool; clearDescription bool }{
+		{ name: &name, clearName: true },
+		{ description: &name, clearDescription: true },
+		{},
+	} {
+		err := documentService.UpdateDocument(
+			t.Context(), uuid.New(), uuid.New(), update.name, update.description, update.clearName, update.clearDescription,
+		)
+		var serviceError *service.InvalidInputError
+		if !errors.As(err, &serviceError) {
+			t.Errorf("want: a service InvalidInputError for update: %+v, got: %v", update, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package document_repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/document_service/internal/repository"
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+/*
+These tests exercise watching the permissions of a document:
+- sharing, updating and removing a permission are each streamed to the watcher in order
+- only the owner of the document can watch its permissions
+- waiting for a change stops once the context is done
+- every watcher of a document receives its changes, the watchers share one listening connection
+- watching fails with resource exhausted once the limit of open watchers is reached
+*/
+
+// wait for the next change on the watcher, failing the test when none arrives in time
+func nextPermissionEvent(t *testing.T, watcher service.PermissionWatcher) service.PermissionEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 5 * time.Second)
+	defer cancel()
+	event, err := watcher.Next(ctx)
+	if err != nil {
+		t.Fatalf("failed to receive a permission change with error: %v", err)
+	}
+	return event
+}
+
+func verifyPermissionEvent(
+	t *testing.T,
+	event service.PermissionEvent,
+	change service.PermissionChange,
+	recipientId uuid.UUID,
+	permissionLevel service.PermissionLevel,
+) {
+	t.Helper()
+	if event.Change != change || event.RecipientID != recipientId || event.PermissionLevel != permissionLevel {
+		t.Errorf(
+			"want change: %v for recipient: %s at level: %v, got: %+v",
+			change, recipientId, permissionLevel, event,
+		)
+	}
+}
+
+func TestWatchDocumentPermissions_StreamsChanges_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	ownerId := uuid.New()
+	documentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	watcher, err := documentService.WatchDocumentPermissions(t.Context(), documentId, ownerId)
+	if err != nil {
+		t.Fatalf("failed to watch the permissions of the document with error: %v", err)
+	}
+	defer watcher.Close()
+	// the watcher is listening once it is returned, so the share is not missed
+	userId := uuid.New()
+	if _, err = documentService.UpsertPermissionUser(t.Context(), ownerId, userId, documentId, service.Viewer); err != nil {
+		t.Fatalf("failed to share the document with error: %v", err)
+	}
+	event := nextPermissionEvent(t, watcher)
+	verifyPermissionEvent(t, event, service.PermissionAdded, userId, service.Viewer)
+	if event.DocumentID != documentId || event.RecipientType != service.User || event.Pending {
+		t.Errorf("want an accepted share with a user on document: %s, got: %+v", documentId, event)
+	}
+	if _, err = documentService.UpsertPermissionUser(t.Context(), ownerId, userId, documentId, service.Editor); err != nil {
+		t.Fatalf("failed to update the permission with error: %v", err)
+	}
+	verifyPermissionEvent(t, nextPermissionEvent(t, watcher), service.PermissionUpdated, userId, service.Editor)
+	guestId, err := documentService.CreateGuest(t.Context(), ownerId, documentId, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create guest with error: %v", err)
+	}
+	event = nextPermissionEvent(t, watcher)
+	verifyPermissionEvent(t, event, service.PermissionAdded, guestId, service.DefaultGuestPermissionLevel)
+	if event.RecipientType != service.Guest {
+		t.Errorf("want the guest to be streamed as a guest, got: %+v", event)
+	}
+	// a removal carries the level from before the removal
+	if err = documentService.DeletePermissionPrincipal(t.Context(), userId, documentId); err != nil {
+		t.Fatalf("failed to remove the permission with error: %v", err)
+	}
+	verifyPermissionEvent(t, nextPermissionEvent(t, watcher), service.PermissionRemoved, userId, service.Editor)
+}
+
+func TestWatchDocumentPermissions_OtherDocument_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	otherDocumentId, err := documentService.CreateDocument(t.Context(), ownerId, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create document with error: %v", err)
+	}
+	watcher, err := documentService.WatchDocumentPermissions(t.Context(), documentId, ownerId)
+	if err != nil {
+		t.Fatalf("failed to watch the permissions of the document with error: %v", err)
+	}
+	defer watcher.Close()
+	// a share on another document is not streamed, the watcher stops waiting once the context is done
+	if _, err = documentService.UpsertPermissionUser(t.Context(), ownerId, uuid.New(), otherDocumentId, service.Viewer); err != nil {
+		t.Fatalf("failed to share the other document with error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 500 * time.Millisecond)
+	defer cancel()
+	event, err := watcher.Next(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want no change for a share on another document, got: %+v with error: %v", event, err)
+	}
+}
+
+func TestWatchDocumentPermissions_SeveralWatchers_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	var watchers []service.PermissionWatcher
+	for range 3 {
+		watcher, err := documentService.WatchDocumentPermissions(t.Context(), documentId, ownerId)
+		if err != nil {
+			t.Fatalf("failed to watch the permissions of the document with error: %v", err)
+		}
+		defer watcher.Close()
+		watchers = append(watchers, watcher)
+	}
+	// a closed watcher does not stop the changes from reaching the others
+	watchers[0].Close()
+	userId := uuid.New()
+	if _, err := documentService.UpsertPermissionUser(t.Context(), ownerId, userId, documentId, service.Viewer); err != nil {
+		t.Fatalf("failed to share the document with error: %v", err)
+	}
+	for _, watcher := range watchers[1:] {
+		verifyPermissionEvent(t, nextPermissionEvent(t, watcher), service.PermissionAdded, userId, service.Viewer)
+	}
+}
+
+func TestWatchDocumentPermissions_WatcherLimit_Integration(t *testing.T) {
+	documentRepo := createTestingDocumentRepo(t)
+	documentRepo.SetMaxPermissionWatchers(1)
+	documentService := service.NewDocumentService(documentRepo)
+	documentId, ownerId, _ := createDocumentWithEditor(t, documentService)
+	watcher, err := documentService.WatchDocumentPermissions(t.Context(), documentId, ownerId)
+	if err != nil {
+		t.Fatalf("failed to watch the permissions of the document with error: %v", err)
+	}
+	_, err = documentService.WatchDocumentPermissions(t.Context(), documentId, ownerId)
+	var exhausted *service.ResourceExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Errorf("want a resource exhausted error for a watcher over the limit, got: %v", err)
+	}
+	// closing a watcher makes room for another, which starts a new listener
+	watcher.Close()
+	watcher, err = documentService.WatchDocumentPermissions(t.Context(), documentId, ownerId)
+	if err != nil {
+		t.Fatalf("failed to watch the permissions of the document after closing a watcher with error: %v", err)
+	}
+	defer watcher.Close()
+	userId := uuid.New()
+	if _, err = documentService.UpsertPermissionUser(t.Context(), ownerId, userId, documentId, service.Viewer); err != nil {
+		t.Fatalf("failed to share the document with error: %v", err)
+	}
+	verifyPermissionEvent(t, nextPermissionEvent(t, watcher), service.PermissionAdded, userId, service.Viewer)
+}
+
+func TestWatchDocumentPermissions_NoWatchersAllowed_Unit(t *testing.T) {
+	// the limit is checked before a connection is taken from the pool
+	documentRepo := repository.NewDocumentRepository(nil)
+	documentRepo.SetMaxPermissionWatchers(0)
+	_, err := documentRepo.WatchDocumentPermissions(t.Context(), uuid.New())
+	var exhausted *service.ResourceExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Errorf("want a resource exhausted error when no watchers are allowed, got: %v", err)
+	}
+}
+
+func TestWatchDocumentPermissions_NotOwner_Integration(t *testing.T) {
+	documentService := service.NewDocumentService(createTestingDocumentRepo(t))
+	documentId, _, editorId := createDocumentWithEditor(t, documentService)
+	_, err := documentService.WatchDocumentPermissions(t.Context(), documentId, editorId)
+	var permissionDenied *service.PermissionDeniedError
+	if !errors.As(err, &permissionDenied) {
+		t.Errorf("want a permission denied error when an editor watches the permissions of a document, got: %v", err)
+	}
+}
+
+func TestWatchDocumentPermissions_NilDocumentId_Unit(t *testing.T) {
+	// the document id is checked before the repository is called
+	documentService := service.NewDocumentService(nil)
+	_, err := documentService.WatchDocumentPermissions(t.Context(), uuid.Nil, uuid.New())
+	var serviceError *service.InvalidInputError
+	if !errors.As(err, &serviceError) {
+		t.Errorf("want: a service InvalidInputError for a nil document id, got: %v", err)
+	}
+}
@@ -0,0 +1,430 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/townsag/reed/document_service/internal/service"
+)
+
+const instrumentationName = "github.com/townsag/reed/document_service/internal/repository"
+
+// the name of the histogram that records the latency of each repository method, the method
+// name is recorded in the method attribute
+const MethodDurationMetric = "repository.method.duration"
+
+// InstrumentedDocumentRepository decorates a document repository and records the latency of
+// each method call in a histogram. It implements the same interface as the repository that it
+// wraps so the service layer does not know that it is there
+type InstrumentedDocumentRepository struct {
+	next service.DocumentRepository
+	duration metric.Float64Histogram
+}
+
+func NewInstrumentedDocumentRepository(
+	next service.DocumentRepository,
+	meterProvider metric.MeterProvider,
+) (*InstrumentedDocumentRepository, error) {
+	duration, err := meterProvider.Meter(instrumentationName).Float64Histogram(
+		MethodDurationMetric,
+		metric.WithDescription("the latency of document repository method calls"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository duration histogram: %w", err)
+	}
+	return &InstrumentedDocumentRepository{
+		next: next,
+		duration: duration,
+	}, nil
+}
+
+// called with defer at the start of each method so that the arguments are evaluated when the
+// method is entered and the measurement is recorded when it returns
+func (r *InstrumentedDocumentRepository) record(ctx context.Context, method string, start time.Time) {
+	r.duration.Record(
+		ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(attribute.String("method", method)),
+	)
+}
+
+func (r *InstrumentedDocumentRepository) CreateDocument(
+	ctx context.Context, userId uuid.UUID, documentName *string, documentDescription *string,
+	clientDocumentId *uuid.UUID,
+) (uuid.UUID, error) {
+	defer r.record(ctx, "CreateDocument", time.Now())
+	return r.next.CreateDocument(ctx, userId, documentName, documentDescription, clientDocumentId)
+}
+
+func (r *InstrumentedDocumentRepository) GetDocument(
+	ctx context.Context, documentId uuid.UUID,
+) (*service.Document, error) {
+	defer r.record(ctx, "GetDocument", time.Now())
+	return r.next.GetDocument(ctx, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) GetDocumentTombstone(
+	ctx context.Context, documentId uuid.UUID, principalId uuid.UUID, deletedAfter time.Time,
+) (*service.DocumentTombstone, error) {
+	defer r.record(ctx, "GetDocumentTombstone", time.Now())
+	return r.next.GetDocumentTombstone(ctx, documentId, principalId, deletedAfter)
+}
+
+func (r *InstrumentedDocumentRepository) PruneDocumentTombstones(
+	ctx context.Context, deletedBefore time.Time,
+) (int64, error) {
+	defer r.record(ctx, "PruneDocumentTombstones", time.Now())
+	return r.next.PruneDocumentTombstones(ctx, deletedBefore)
+}
+
+func (r *InstrumentedDocumentRepository) UpdateDocument(
+	ctx context.Context, documentId uuid.UUID, actorId uuid.UUID, documentName *string, documentDescription *string,
+	clearName bool, clearDescription bool,
+) error {
+	defer r.record(ctx, "UpdateDocument", time.Now())
+	return r.next.UpdateDocument(ctx, documentId, actorId, documentName, documentDescription, clearName, clearDescription)
+}
+
+func (r *InstrumentedDocumentRepository) ListDocumentHistory(
+	ctx context.Context, documentId uuid.UUID, cursor *service.Cursor, pageSize int32,
+) ([]service.DocumentChange, *service.Cursor, bool, error) {
+	defer r.record(ctx, "ListDocumentHistory", time.Now())
+	return r.next.ListDocumentHistory(ctx, documentId, cursor, pageSize)
+}
+
+func (r *InstrumentedDocumentRepository) DeleteDocument(ctx context.Context, documentId uuid.UUID) error {
+	defer r.record(ctx, "DeleteDocument", time.Now())
+	return r.next.DeleteDocument(ctx, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) ArchiveDocument(ctx context.Context, documentId uuid.UUID) error {
+	defer r.record(ctx, "ArchiveDocument", time.Now())
+	return r.next.ArchiveDocument(ctx, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) RestoreDocument(ctx context.Context, documentId uuid.UUID) error {
+	defer r.record(ctx, "RestoreDocument", time.Now())
+	return r.next.RestoreDocument(ctx, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) SetPublicAccess(
+	ctx context.Context, documentId uuid.UUID, publicAccess *service.PermissionLevel,
+) (int32, error) {
+	defer r.record(ctx, "SetPublicAccess", time.Now())
+	return r.next.SetPublicAccess(ctx, documentId, publicAccess)
+}
+
+func (r *InstrumentedDocumentRepository) TouchDocument(ctx context.Context, documentId uuid.UUID) error {
+	defer r.record(ctx, "TouchDocument", time.Now())
+	return r.next.TouchDocument(ctx, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) DeleteDocuments(
+	ctx context.Context, documentIds uuid.UUIDs, userId uuid.UUID,
+) error {
+	defer r.record(ctx, "DeleteDocuments", time.Now())
+	return r.next.DeleteDocuments(ctx, documentIds, userId)
+}
+
+func (r *InstrumentedDocumentRepository) ReassignOwnedDocuments(
+	ctx context.Context, fromOwnerId uuid.UUID, toOwnerId uuid.UUID, batchSize int32,
+) (int64, error) {
+	defer r.record(ctx, "ReassignOwnedDocuments", time.Now())
+	return r.next.ReassignOwnedDocuments(ctx, fromOwnerId, toOwnerId, batchSize)
+}
+
+func (r *InstrumentedDocumentRepository) EnsureDocumentHasOwner(
+	ctx context.Context, documentId uuid.UUID, fallbackOwnerId uuid.UUID,
+) (bool, error) {
+	defer r.record(ctx, "EnsureDocumentHasOwner", time.Now())
+	return r.next.EnsureDocumentHasOwner(ctx, documentId, fallbackOwnerId)
+}
+
+func (r *InstrumentedDocumentRepository) SchedulePermissionDowngrade(
+	ctx context.Context,
+	documentId uuid.UUID,
+	recipientId uuid.UUID,
+	downgradeTo service.PermissionLevel,
+	downgradeAt time.Time,
+) error {
+	defer r.record(ctx, "SchedulePermissionDowngrade", time.Now())
+	return r.next.SchedulePermissionDowngrade(ctx, documentId, recipientId, downgradeTo, downgradeAt)
+}
+
+func (r *InstrumentedDocumentRepository) ApplyDueDowngrades(
+	ctx context.Context, now time.Time, batchSize int32,
+) (int64, error) {
+	defer r.record(ctx, "ApplyDueDowngrades", time.Now())
+	return r.next.ApplyDueDowngrades(ctx, now, batchSize)
+}
+
+func (r *InstrumentedDocumentRepository) WatchDocumentPermissions(
+	ctx context.Context, documentId uuid.UUID,
+) (service.PermissionWatcher, error) {
+	defer r.record(ctx, "WatchDocumentPermissions", time.Now())
+	return r.next.WatchDocumentPermissions(ctx, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) ListPermissionAudit(
+	ctx context.Context, documentId uuid.UUID, pageSize int32,
+) ([]service.PermissionAuditEntry, error) {
+	defer r.record(ctx, "ListPermissionAudit", time.Now())
+	return r.next.ListPermissionAudit(ctx, documentId, pageSize)
+}
+
+func (r *InstrumentedDocumentRepository) ListDocumentsByPrincipal(
+	ctx context.Context,
+	principalId uuid.UUID,
+	permissions []service.PermissionLevel,
+	favoritesOnly bool,
+	collectionId *uuid.UUID,
+	includeArchived bool,
+	cursor *service.Cursor,
+	pageSize int32,
+) ([]service.DocumentPermission, *service.Cursor, bool, error) {
+	defer r.record(ctx, "ListDocumentsByPrincipal", time.Now())
+	return r.next.ListDocumentsByPrincipal(
+		ctx, principalId, permissions, favoritesOnly, collectionId, includeArchived, cursor, pageSize,
+	)
+}
+
+func (r *InstrumentedDocumentRepository) ListDocumentsModifiedSince(
+	ctx context.Context, principalId uuid.UUID, cursor *service.Cursor, pageSize int32,
+) ([]service.DocumentPermission, *service.Cursor, bool, error) {
+	defer r.record(ctx, "ListDocumentsModifiedSince", time.Now())
+	return r.next.ListDocumentsModifiedSince(ctx, principalId, cursor, pageSize)
+}
+
+func (r *InstrumentedDocumentRepository) RecordDocumentAccess(
+	ctx context.Context, principalId uuid.UUID, documentId uuid.UUID,
+) error {
+	defer r.record(ctx, "RecordDocumentAccess", time.Now())
+	return r.next.RecordDocumentAccess(ctx, principalId, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) StarDocument(
+	ctx context.Context, principalId uuid.UUID, documentId uuid.UUID,
+) error {
+	defer r.record(ctx, "StarDocument", time.Now())
+	return r.next.StarDocument(ctx, principalId, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) UnstarDocument(
+	ctx context.Context, principalId uuid.UUID, documentId uuid.UUID,
+) error {
+	defer r.record(ctx, "UnstarDocument", time.Now())
+	return r.next.UnstarDocument(ctx, principalId, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) CreateCollection(
+	ctx context.Context, ownerId uuid.UUID, name string,
+) (*service.Collection, error) {
+	defer r.record(ctx, "CreateCollection", time.Now())
+	return r.next.CreateCollection(ctx, ownerId, name)
+}
+
+func (r *InstrumentedDocumentRepository) GetCollection(
+	ctx context.Context, collectionId uuid.UUID,
+) (*service.Collection, error) {
+	defer r.record(ctx, "GetCollection", time.Now())
+	return r.next.GetCollection(ctx, collectionId)
+}
+
+func (r *InstrumentedDocumentRepository) AddToCollection(
+	ctx context.Context, collectionId uuid.UUID, documentId uuid.UUID,
+) error {
+	defer r.record(ctx, "AddToCollection", time.Now())
+	return r.next.AddToCollection(ctx, collectionId, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) RemoveFromCollection(
+	ctx context.Context, collectionId uuid.UUID, documentId uuid.UUID,
+) error {
+	defer r.record(ctx, "RemoveFromCollection", time.Now())
+	return r.next.RemoveFromCollection(ctx, collectionId, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) GetDocumentViewers(
+	ctx context.Context, documentId uuid.UUID, since time.Time, limit int32,
+) ([]service.DocumentViewer, error) {
+	defer r.record(ctx, "GetDocumentViewers", time.Now())
+	return r.next.GetDocumentViewers(ctx, documentId, since, limit)
+}
+
+func (r *InstrumentedDocumentRepository) ListRecentlyAccessed(
+	ctx context.Context, principalId uuid.UUID, cursor *service.Cursor, pageSize int32,
+) ([]service.AccessedDocument, *service.Cursor, bool, error) {
+	defer r.record(ctx, "ListRecentlyAccessed", time.Now())
+	return r.next.ListRecentlyAccessed(ctx, principalId, cursor, pageSize)
+}
+
+func (r *InstrumentedDocumentRepository) ListSharedDocumentsByOwner(
+	ctx context.Context, ownerId uuid.UUID, cursor *service.Cursor, pageSize int32,
+) ([]service.SharedDocument, *service.Cursor, bool, error) {
+	defer r.record(ctx, "ListSharedDocumentsByOwner", time.Now())
+	return r.next.ListSharedDocumentsByOwner(ctx, ownerId, cursor, pageSize)
+}
+
+func (r *InstrumentedDocumentRepository) ListAllDocuments(
+	ctx context.Context, cursor *service.Cursor, pageSize int32, filters service.DocumentFilters,
+) ([]service.Document, *service.Cursor, bool, error) {
+	defer r.record(ctx, "ListAllDocuments", time.Now())
+	return r.next.ListAllDocuments(ctx, cursor, pageSize, filters)
+}
+
+func (r *InstrumentedDocumentRepository) GetOwnedDocumentIds(
+	ctx context.Context, ownerId uuid.UUID, documentIds uuid.UUIDs,
+) (uuid.UUIDs, error) {
+	defer r.record(ctx, "GetOwnedDocumentIds", time.Now())
+	return r.next.GetOwnedDocumentIds(ctx, ownerId, documentIds)
+}
+
+func (r *InstrumentedDocumentRepository) GetPermissionLevelsForPrincipalOnDocuments(
+	ctx context.Context, principalId uuid.UUID, documentIds uuid.UUIDs,
+) (map[uuid.UUID]service.PermissionLevel, error) {
+	defer r.record(ctx, "GetPermissionLevelsForPrincipalOnDocuments", time.Now())
+	return r.next.GetPermissionLevelsForPrincipalOnDocuments(ctx, principalId, documentIds)
+}
+
+func (r *InstrumentedDocumentRepository) GetPermissionsForPrincipals(
+	ctx context.Context, documentId uuid.UUID, principalIds uuid.UUIDs,
+) (map[uuid.UUID]service.PermissionLevel, error) {
+	defer r.record(ctx, "GetPermissionsForPrincipals", time.Now())
+	return r.next.GetPermissionsForPrincipals(ctx, documentId, principalIds)
+}
+
+func (r *InstrumentedDocumentRepository) GetPermissionOfPrincipalOnDocument(
+	ctx context.Context, documentId uuid.UUID, principalId uuid.UUID,
+) (service.Permission, error) {
+	defer r.record(ctx, "GetPermissionOfPrincipalOnDocument", time.Now())
+	return r.next.GetPermissionOfPrincipalOnDocument(ctx, documentId, principalId)
+}
+
+func (r *InstrumentedDocumentRepository) ListPermissionsOnDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	permissions []service.PermissionLevel,
+	cursor *service.Cursor,
+	pageSize int32,
+	excludedRecipientId *uuid.UUID,
+) ([]service.Permission, *service.Cursor, bool, error) {
+	defer r.record(ctx, "ListPermissionsOnDocument", time.Now())
+	return r.next.ListPermissionsOnDocument(ctx, documentId, permissions, cursor, pageSize, excludedRecipientId)
+}
+
+func (r *InstrumentedDocumentRepository) GetPermissionLevel(
+	ctx context.Context, documentId uuid.UUID, principalId uuid.UUID,
+) (service.PermissionLevel, bool, error) {
+	defer r.record(ctx, "GetPermissionLevel", time.Now())
+	return r.next.GetPermissionLevel(ctx, documentId, principalId)
+}
+
+func (r *InstrumentedDocumentRepository) CountDocumentsByPrincipalGrouped(
+	ctx context.Context, principalId uuid.UUID,
+) (map[service.PermissionLevel]int64, error) {
+	defer r.record(ctx, "CountDocumentsByPrincipalGrouped", time.Now())
+	return r.next.CountDocumentsByPrincipalGrouped(ctx, principalId)
+}
+
+func (r *InstrumentedDocumentRepository) CountPermissionsOnDocument(
+	ctx context.Context, documentId uuid.UUID, permissions []service.PermissionLevel,
+) (int64, error) {
+	defer r.record(ctx, "CountPermissionsOnDocument", time.Now())
+	return r.next.CountPermissionsOnDocument(ctx, documentId, permissions)
+}
+
+func (r *InstrumentedDocumentRepository) CreateGuest(
+	ctx context.Context, creatorId uuid.UUID, documentId uuid.UUID, permission service.PermissionLevel, label *string, maxGuests int32,
+) (uuid.UUID, error) {
+	defer r.record(ctx, "CreateGuest", time.Now())
+	return r.next.CreateGuest(ctx, creatorId, documentId, permission, label, maxGuests)
+}
+
+func (r *InstrumentedDocumentRepository) CreateGuests(
+	ctx context.Context, creatorId uuid.UUID, documentId uuid.UUID, count int32, permission service.PermissionLevel, maxGuests int32,
+) ([]uuid.UUID, error) {
+	defer r.record(ctx, "CreateGuests", time.Now())
+	return r.next.CreateGuests(ctx, creatorId, documentId, count, permission, maxGuests)
+}
+
+func (r *InstrumentedDocumentRepository) CopyPermissions(
+	ctx context.Context, creatorId uuid.UUID, sourceDocumentId uuid.UUID, targetDocumentId uuid.UUID, maxGuests int32,
+) (int, error) {
+	defer r.record(ctx, "CopyPermissions", time.Now())
+	return r.next.CopyPermissions(ctx, creatorId, sourceDocumentId, targetDocumentId, maxGuests)
+}
+
+func (r *InstrumentedDocumentRepository) UpsertPermissionUser(
+	ctx context.Context, userId uuid.UUID, documentId uuid.UUID, permission service.PermissionLevel,
+) (bool, error) {
+	defer r.record(ctx, "UpsertPermissionUser", time.Now())
+	return r.next.UpsertPermissionUser(ctx, userId, documentId, permission)
+}
+
+func (r *InstrumentedDocumentRepository) UpsertPermissionsUser(
+	ctx context.Context, creatorId uuid.UUID, documentId uuid.UUID, shares []service.UserShare,
+) (map[uuid.UUID]bool, error) {
+	defer r.record(ctx, "UpsertPermissionsUser", time.Now())
+	return r.next.UpsertPermissionsUser(ctx, creatorId, documentId, shares)
+}
+
+func (r *InstrumentedDocumentRepository) InvitePermissionUser(
+	ctx context.Context, creatorId uuid.UUID, userId uuid.UUID, documentId uuid.UUID, permission service.PermissionLevel,
+) error {
+	defer r.record(ctx, "InvitePermissionUser", time.Now())
+	return r.next.InvitePermissionUser(ctx, creatorId, userId, documentId, permission)
+}
+
+func (r *InstrumentedDocumentRepository) ListPendingShares(
+	ctx context.Context, principalId uuid.UUID, limit int32,
+) ([]service.PendingShare, error) {
+	defer r.record(ctx, "ListPendingShares", time.Now())
+	return r.next.ListPendingShares(ctx, principalId, limit)
+}
+
+func (r *InstrumentedDocumentRepository) AcceptPendingShare(
+	ctx context.Context, principalId uuid.UUID, documentId uuid.UUID,
+) error {
+	defer r.record(ctx, "AcceptPendingShare", time.Now())
+	return r.next.AcceptPendingShare(ctx, principalId, documentId)
+}
+
+func (r *InstrumentedDocumentRepository) UpdatePermissionGuest(
+	ctx context.Context, guestId uuid.UUID, permission service.PermissionLevel,
+) error {
+	defer r.record(ctx, "UpdatePermissionGuest", time.Now())
+	return r.next.UpdatePermissionGuest(ctx, guestId, permission)
+}
+
+func (r *InstrumentedDocumentRepository) UpdateGuestLabel(
+	ctx context.Context, documentId uuid.UUID, guestId uuid.UUID, label *string,
+) error {
+	defer r.record(ctx, "UpdateGuestLabel", time.Now())
+	return r.next.UpdateGuestLabel(ctx, documentId, guestId, label)
+}
+
+func (r *InstrumentedDocumentRepository) GetGuest(
+	ctx context.Context, guestId uuid.UUID,
+) (uuid.UUID, int32, error) {
+	defer r.record(ctx, "GetGuest", time.Now())
+	return r.next.GetGuest(ctx, guestId)
+}
+
+func (r *InstrumentedDocumentRepository) RotateGuestTokenVersion(
+	ctx context.Context, guestId uuid.UUID,
+) (int32, error) {
+	defer r.record(ctx, "RotateGuestTokenVersion", time.Now())
+	return r.next.RotateGuestTokenVersion(ctx, guestId)
+}
+
+func (r *InstrumentedDocumentRepository) DeletePermissionsPrincipal(
+	ctx context.Context, recipientId uuid.UUID, documentId uuid.UUID,
+) error {
+	defer r.record(ctx, "DeletePermissionsPrincipal", time.Now())
+	return r.next.DeletePermissionsPrincipal(ctx, recipientId, documentId)
+}
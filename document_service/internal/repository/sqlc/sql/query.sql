@@ -6,52 +6,341 @@ VALUES ($1, $2, $3);
 SELECT * FROM documents 
 WHERE id = $1;
 
+-- lock the row of the document so that the values read before an update are the values
+-- that the update replaces
+-- name: GetDocumentForUpdate :one
+SELECT * FROM documents
+WHERE id = $1
+FOR UPDATE;
+
+-- archived documents cannot be updated, the calling code is responsible for
+-- distinguishing between a missing document and an archived document. A null name or
+-- description keeps the old value unless it is cleared
 -- name: UpdateDocument :execrows
 UPDATE documents SET
-name = COALESCE($2, name),
-description = COALESCE($3, description)
+name = CASE WHEN @clear_name::boolean THEN NULL ELSE COALESCE($2, name) END,
+description = CASE WHEN @clear_description::boolean THEN NULL ELSE COALESCE($3, description) END,
+last_modified_at = NOW()
+WHERE id = $1
+AND archived_at IS NULL;
+
+-- a null public access disables the public link of the document and bumps its version, which
+-- revokes the public link tokens that were issued before
+-- name: SetPublicAccess :one
+UPDATE documents SET
+public_access = $2,
+public_link_version = CASE
+    WHEN $2::permission_level IS NULL THEN public_link_version + 1
+    ELSE public_link_version
+END,
+last_modified_at = NOW()
+WHERE id = $1
+AND archived_at IS NULL
+RETURNING public_link_version;
+
+-- name: TouchDocument :execrows
+UPDATE documents SET
+last_modified_at = NOW()
+WHERE id = $1
+AND archived_at IS NULL;
+
+-- archiving an archived document keeps the original archived at time and last
+-- modified at time, the right hand side of each assignment reads the old row
+-- name: ArchiveDocument :execrows
+UPDATE documents SET
+archived_at = COALESCE(archived_at, NOW()),
+last_modified_at = CASE WHEN archived_at IS NULL THEN NOW() ELSE last_modified_at END
+WHERE id = $1;
+
+-- name: RestoreDocument :execrows
+UPDATE documents SET
+archived_at = NULL,
+last_modified_at = CASE WHEN archived_at IS NULL THEN last_modified_at ELSE NOW() END
 WHERE id = $1;
 
 -- name: DeleteDocument :execrows
 DELETE FROM documents 
 WHERE id = $1;
 
+-- name: InsertDocumentHistory :exec
+INSERT INTO document_history (id, document_id, actor_id, old_name, new_name, old_description, new_description)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListDocumentHistory :many
+SELECT * FROM document_history
+WHERE document_id = $1
+AND (changed_at < $2 OR (changed_at = $2 AND id < $3))
+ORDER BY changed_at DESC, id DESC
+LIMIT $4;
+
+-- a document created with a client supplied id may be deleted more than once. This reads the
+-- permissions of the document, so it has to run before they are deleted
+-- name: UpsertDocumentTombstone :exec
+INSERT INTO document_tombstones (document_id, principal_ids)
+SELECT @document_id::uuid, COALESCE(array_agg(recipient_id), '{}')::uuid[]
+FROM permissions
+WHERE document_id = @document_id::uuid AND NOT pending
+ON CONFLICT (document_id) DO UPDATE SET deleted_at = NOW(), principal_ids = EXCLUDED.principal_ids;
+
+-- name: GetDocumentTombstone :one
+SELECT * FROM document_tombstones
+WHERE document_id = $1
+AND deleted_at > @deleted_after::timestamptz
+AND @principal_id::uuid = ANY(principal_ids);
+
+-- name: PruneDocumentTombstones :execrows
+DELETE FROM document_tombstones
+WHERE deleted_at <= @deleted_before::timestamptz;
+
+-- name: UpsertDocumentAccess :exec
+INSERT INTO access_log (principal_id, document_id)
+VALUES ($1, $2)
+ON CONFLICT (principal_id, document_id) DO UPDATE SET accessed_at = NOW();
+
+-- name: DeleteAccessLogByDocument :execrows
+DELETE FROM access_log
+WHERE document_id = $1;
+
+-- starring a document that is already starred is a no-op
+-- name: UpsertFavorite :exec
+INSERT INTO favorites (principal_id, document_id)
+VALUES ($1, $2)
+ON CONFLICT (principal_id, document_id) DO NOTHING;
+
+-- name: DeleteFavorite :execrows
+DELETE FROM favorites
+WHERE principal_id = $1 AND document_id = $2;
+
+-- name: DeleteFavoritesByDocument :execrows
+DELETE FROM favorites
+WHERE document_id = $1;
+
+-- name: CreateCollection :one
+INSERT INTO collections (id, owner_id, name)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetCollection :one
+SELECT * FROM collections
+WHERE id = $1;
+
+-- adding a document that is already in the collection is a no-op
+-- name: InsertDocumentCollection :exec
+INSERT INTO document_collection (collection_id, document_id)
+VALUES ($1, $2)
+ON CONFLICT (collection_id, document_id) DO NOTHING;
+
+-- name: DeleteDocumentCollection :execrows
+DELETE FROM document_collection
+WHERE collection_id = $1 AND document_id = $2;
+
+-- name: DeleteDocumentCollectionsByDocument :execrows
+DELETE FROM document_collection
+WHERE document_id = $1;
+
+-- the documents that the principal no longer has a permission on or that are archived are
+-- skipped, their accesses are kept in case the permission is granted again or the document
+-- is restored
+-- name: ListRecentlyAccessedDocuments :many
+SELECT sqlc.embed(documents), permissions.permission_level, access_log.accessed_at
+FROM access_log
+JOIN documents ON documents.id = access_log.document_id
+JOIN permissions ON permissions.document_id = access_log.document_id
+AND permissions.recipient_id = access_log.principal_id
+WHERE (access_log.accessed_at < $2 OR (access_log.accessed_at = $2 AND access_log.document_id < $3))
+AND access_log.principal_id = $1
+AND NOT permissions.pending
+AND documents.archived_at IS NULL
+ORDER BY access_log.accessed_at DESC, access_log.document_id DESC
+LIMIT $4;
+
+-- the principals that read the document since the given time, most recent read first. Only
+-- principals that still hold a permission on the document are listed
+-- name: ListDocumentViewers :many
+SELECT access_log.principal_id, permissions.recipient_type, permissions.permission_level, access_log.accessed_at
+FROM access_log JOIN permissions
+ON permissions.document_id = access_log.document_id
+AND permissions.recipient_id = access_log.principal_id
+WHERE access_log.document_id = $1
+AND access_log.accessed_at >= $2
+AND NOT permissions.pending
+ORDER BY access_log.accessed_at DESC, access_log.principal_id DESC
+LIMIT $3;
+
+-- name: DeleteDocumentHistoryByDocument :execrows
+DELETE FROM document_history
+WHERE document_id = $1;
+
 -- name: DeletePermissionByDocument :execrows
 DELETE FROM permissions
 WHERE document_id = $1;
 
 -- this query uses cursor based pagination to list documents 
+-- archived documents are only listed when include_archived is set, the cursor
+-- ordering is the same either way
 -- name: ListDocumentsByCreatedAt :many
-SELECT sqlc.embed(documents), permissions.permission_level
+SELECT sqlc.embed(documents), permissions.permission_level,
+permissions.created_at AS permission_created_at,
+permissions.last_modified_at AS permission_last_modified_at
 FROM documents JOIN permissions
 ON documents.id = permissions.document_id
 WHERE (documents.created_at < $2 OR (documents.created_at = $2 AND documents.id < $3))
 AND permissions.permission_level = ANY(@permissions_list::permission_level[])
 AND permissions.recipient_id = $1
+AND NOT permissions.pending
+AND (NOT @favorites_only::boolean OR EXISTS (
+    SELECT 1 FROM favorites
+    WHERE favorites.principal_id = $1 AND favorites.document_id = documents.id
+))
+AND (sqlc.narg(collection_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM document_collection
+    WHERE document_collection.collection_id = sqlc.narg(collection_id)
+    AND document_collection.document_id = documents.id
+))
+AND (@include_archived::boolean OR documents.archived_at IS NULL)
 ORDER BY documents.created_at DESC, documents.id DESC
 LIMIT $4;
 
 -- this query is very similar, only it orders by the last modified at field instead
 -- of the created at field
 -- name: ListDocumentsByLastModifiedAt :many
-SELECT sqlc.embed(documents), permissions.permission_level
+SELECT sqlc.embed(documents), permissions.permission_level,
+permissions.created_at AS permission_created_at,
+permissions.last_modified_at AS permission_last_modified_at
 FROM documents JOIN permissions
 ON documents.id = permissions.document_id
 WHERE (documents.last_modified_at < $2 OR (documents.last_modified_at = $2 AND documents.id < $3))
 AND permissions.permission_level = ANY(@permissions_list::permission_level[])
 AND permissions.recipient_id = $1
+AND NOT permissions.pending
+AND (NOT @favorites_only::boolean OR EXISTS (
+    SELECT 1 FROM favorites
+    WHERE favorites.principal_id = $1 AND favorites.document_id = documents.id
+))
+AND (sqlc.narg(collection_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM document_collection
+    WHERE document_collection.collection_id = sqlc.narg(collection_id)
+    AND document_collection.document_id = documents.id
+))
+AND (@include_archived::boolean OR documents.archived_at IS NULL)
 ORDER BY documents.last_modified_at DESC, documents.id DESC
 LIMIT $4;
 
+-- this query walks the documents of a principal forwards in time for incremental sync,
+-- the last modified at index is scanned backwards. Archived documents are included so
+-- that clients can remove them from their cache
+-- name: ListDocumentsModifiedAfter :many
+SELECT sqlc.embed(documents), permissions.permission_level,
+permissions.created_at AS permission_created_at,
+permissions.last_modified_at AS permission_last_modified_at
+FROM documents JOIN permissions
+ON documents.id = permissions.document_id
+WHERE (documents.last_modified_at > $2 OR (documents.last_modified_at = $2 AND documents.id > $3))
+AND permissions.recipient_id = $1
+AND NOT permissions.pending
+ORDER BY documents.last_modified_at ASC, documents.id ASC
+LIMIT $4;
+
+-- this query lists the documents owned by a principal that have at least one collaborator,
+-- the inner join on collaborators drops documents that are not shared. Grouping by the
+-- primary key of documents allows selecting every column of documents
+-- name: ListSharedDocumentsByOwner :many
+SELECT sqlc.embed(documents), COUNT(*) AS collaborator_count
+FROM documents
+JOIN permissions AS owners ON documents.id = owners.document_id
+JOIN permissions AS collaborators ON documents.id = collaborators.document_id
+WHERE (documents.created_at < $2 OR (documents.created_at = $2 AND documents.id < $3))
+AND owners.recipient_id = $1
+AND owners.permission_level = 'owner'
+AND collaborators.permission_level <> 'owner'
+GROUP BY documents.id
+ORDER BY documents.created_at DESC, documents.id DESC
+LIMIT $4;
+
+-- this query lists every document regardless of the principal, it is only meant for admins.
+-- A null filter matches every document. The owner filter uses exists instead of a join so that
+-- documents without an owner are still listed when no owner is given
+-- name: ListAllDocuments :many
+SELECT * FROM documents
+WHERE (created_at < $1 OR (created_at = $1 AND id < $2))
+AND (sqlc.narg(owner_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM permissions
+    WHERE permissions.document_id = documents.id
+    AND permissions.recipient_id = sqlc.narg(owner_id)::uuid
+    AND permissions.permission_level = 'owner'
+))
+AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before)::timestamptz)
+AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after)::timestamptz)
+ORDER BY created_at DESC, id DESC
+LIMIT $3;
+
+-- a pending share is not a permission until it is accepted
 -- name: GetPermissionOfPrincipalOnDocument :one
 SELECT * FROM permissions 
-WHERE document_id = $1 AND recipient_id = $2;
+WHERE document_id = $1 AND recipient_id = $2
+AND NOT pending;
+
+-- authorization checks only need the level, this avoids reading the rest of the row
+-- name: GetPermissionLevel :one
+SELECT permission_level FROM permissions
+WHERE document_id = $1 AND recipient_id = $2
+AND NOT pending;
+
+-- the levels of one principal on a batch of documents, documents that the principal has no
+-- permission on are not returned
+-- name: GetPermissionLevelsForPrincipalOnDocuments :many
+SELECT document_id, permission_level FROM permissions
+WHERE recipient_id = $1
+AND document_id = ANY(@document_ids::uuid[])
+AND NOT pending;
+
+-- the subset of the documents that the principal owns, documents that do not exist or that
+-- the principal does not own are not returned
+-- name: GetOwnedDocumentIds :many
+SELECT document_id FROM permissions
+WHERE recipient_id = $1
+AND document_id = ANY(@document_ids::uuid[])
+AND permission_level = 'owner'
+AND NOT pending;
+
+-- the levels of a batch of principals on one document, principals that have no permission on
+-- the document are not returned
+-- name: GetPermissionLevelsOfPrincipalsOnDocument :many
+SELECT recipient_id, permission_level FROM permissions
+WHERE document_id = $1
+AND recipient_id = ANY(@recipient_ids::uuid[])
+AND NOT pending;
+
+-- the number of active documents that a principal holds each permission level on, levels
+-- that the principal holds on no documents are not returned. Archived documents are not
+-- counted because they are not shown to users
+-- name: CountDocumentsByPrincipalGrouped :many
+SELECT permissions.permission_level, COUNT(*) AS document_count
+FROM permissions JOIN documents
+ON permissions.document_id = documents.id
+WHERE permissions.recipient_id = $1
+AND documents.archived_at IS NULL
+AND NOT permissions.pending
+GROUP BY permissions.permission_level;
 
+-- name: CountPermissionsOnDocument :one
+SELECT COUNT(*) FROM permissions
+WHERE document_id = $1
+AND permission_level = ANY(@permissions_list::permission_level[]);
+
+-- a guest is active for as long as it holds a permission on the document
+-- name: CountGuestPermissionsOnDocument :one
+SELECT COUNT(*) FROM permissions
+WHERE document_id = $1
+AND recipient_type = 'guest';
+
+-- a null excluded recipient id excludes no permissions, every recipient id is distinct from null
 -- name: ListPermissionOnDocumentCreatedAt :many
 SELECT * FROM permissions
 WHERE document_id = $1
 AND (created_at < $2 OR (created_at = $2 AND recipient_id < $3))
 AND permission_level = ANY(@permissions_list::permission_level[])
+AND recipient_id IS DISTINCT FROM sqlc.narg(excluded_recipient_id)::uuid
 ORDER BY created_at DESC, recipient_id DESC
 LIMIT $4;
 -- sql language quirk, Ands are processed with a higher precedence than Ors
@@ -63,20 +352,109 @@ SELECT * FROM permissions
 WHERE document_id = $1
 AND (last_modified_at < $2 OR (last_modified_at = $2 AND recipient_id < $3))
 AND permission_level = ANY(@permissions_list::permission_level[])
+AND recipient_id IS DISTINCT FROM sqlc.narg(excluded_recipient_id)::uuid
 ORDER BY last_modified_at DESC, recipient_id DESC
 LIMIT $4;
 
--- name: UpsertPermissionUser :exec
+-- xmax is zero for a row version that was created by an insert and is set to the id of
+-- the updating transaction when the conflict path updates an existing row. This lets us
+-- report whether the permission was created or updated without a second read
+-- name: UpsertPermissionUser :one
 INSERT INTO permissions (
     recipient_id, recipient_type, document_id, permission_level, created_by
 ) VALUES ($1, 'user', $2, $3, $4)
 ON CONFLICT (recipient_id, document_id)
 DO UPDATE SET 
     last_modified_at = NOW(),
-    permission_level = $3;
+    permission_level = $3,
+    downgrade_to = NULL,
+    downgrade_at = NULL,
+    pending = FALSE
+WHERE permissions.permission_level <> EXCLUDED.permission_level OR permissions.pending
+RETURNING (xmax = 0) AS inserted;
 -- we dont have to check that the recipient id and the document
 -- id match in the where clause of the do update set because they
 -- have to match for there to have been a conflict
+-- re-sharing at the level the user already has skips the update so that last_modified_at
+-- is unchanged, no row is returned in that case. Sharing with a user that has a pending share
+-- at the same level still updates it so that the direct share grants access right away
+
+-- the batch form of UpsertPermissionUser, the recipient ids and permission levels are parallel
+-- arrays and a recipient must not appear twice. Only the recipients whose permission was created
+-- or changed are returned, the levels are cast through text because the enum array type is not
+-- registered with the driver
+-- name: UpsertPermissionsUser :many
+INSERT INTO permissions (
+    recipient_id, recipient_type, document_id, permission_level, created_by
+) SELECT
+    unnest(@recipient_ids::uuid[]),
+    'user',
+    @document_id::uuid,
+    unnest(@permission_levels::text[])::permission_level,
+    @created_by::uuid
+ON CONFLICT (recipient_id, document_id)
+DO UPDATE SET
+    last_modified_at = NOW(),
+    permission_level = EXCLUDED.permission_level,
+    downgrade_to = NULL,
+    downgrade_at = NULL,
+    pending = FALSE
+WHERE permissions.permission_level <> EXCLUDED.permission_level OR permissions.pending
+RETURNING recipient_id, (xmax = 0) AS inserted;
+
+-- a pending share is only created for a user without a permission or pending share on the
+-- document, no row is inserted otherwise
+-- name: InsertPendingPermissionUser :execrows
+INSERT INTO permissions (
+    recipient_id, recipient_type, document_id, permission_level, created_by, pending
+) VALUES ($1, 'user', $2, $3, $4, TRUE)
+ON CONFLICT (recipient_id, document_id) DO NOTHING;
+
+-- a copied permission is only created for a user without a permission or pending share on the
+-- document, the existing permission is kept otherwise
+-- name: InsertPermissionUserIfAbsent :execrows
+INSERT INTO permissions (
+    recipient_id, recipient_type, document_id, permission_level, created_by
+) VALUES ($1, 'user', $2, $3, $4)
+ON CONFLICT (recipient_id, document_id) DO NOTHING;
+
+-- the permissions that are copied from a document onto another document, the owner and pending
+-- shares are not copied. The label of each guest and the scheduled downgrade are read with the
+-- permission, the guests that were already copied onto the target document are left out
+-- name: ListCopyablePermissionsOnDocument :many
+SELECT permissions.recipient_id, permissions.recipient_type, permissions.permission_level,
+permissions.downgrade_to, permissions.downgrade_at, guests.label
+FROM permissions LEFT JOIN guests
+ON guests.id = permissions.recipient_id
+WHERE permissions.document_id = $1
+AND permissions.permission_level <> 'owner'
+AND NOT permissions.pending
+AND NOT EXISTS (
+    SELECT 1 FROM guests AS copies
+    WHERE copies.document_id = @target_document_id
+    AND copies.copied_from = permissions.recipient_id
+);
+
+-- the pending shares offered to a principal on active documents, newest first
+-- name: ListPendingSharesByPrincipal :many
+SELECT sqlc.embed(documents), permissions.permission_level, permissions.created_by,
+permissions.created_at AS permission_created_at,
+permissions.last_modified_at AS permission_last_modified_at
+FROM documents JOIN permissions
+ON documents.id = permissions.document_id
+WHERE permissions.recipient_id = $1
+AND permissions.pending
+AND documents.archived_at IS NULL
+ORDER BY permissions.created_at DESC, documents.id DESC
+LIMIT $2;
+
+-- name: AcceptPendingShare :execrows
+UPDATE permissions SET
+pending = FALSE,
+last_modified_at = NOW()
+WHERE recipient_id = $1
+AND document_id = $2
+AND pending;
 
 -- name: InsertPermissionGuest :exec
 INSERT INTO permissions (
@@ -90,6 +468,8 @@ WHERE id = $1;
 -- name: UpdatePermissionGuest :execrows
 UPDATE permissions SET
 permission_level = $3,
+downgrade_to = NULL,
+downgrade_at = NULL,
 last_modified_at = NOW()
 WHERE recipient_id = $1
 AND document_id = $2
@@ -100,8 +480,29 @@ AND recipient_type = 'guest';
 -- table, package these two operations using a transaction
 -- name: CreateGuest :exec
 INSERT INTO guests (
-    id, document_id, created_by
-) VALUES ($1, $2, $3);
+    id, document_id, created_by, label, copied_from
+) VALUES ($1, $2, $3, $4, $5);
+
+-- the document id is matched so that a guest cannot be relabeled through another document
+-- name: UpdateGuestLabel :execrows
+UPDATE guests SET
+label = $3,
+last_modified_at = NOW()
+WHERE id = $1
+AND document_id = $2;
+
+-- name: RotateGuestTokenVersion :one
+UPDATE guests SET
+token_version = token_version + 1,
+last_modified_at = NOW()
+WHERE id = $1
+RETURNING token_version;
+
+-- the labels of the guests in a page of permissions, guests without a label are skipped
+-- name: ListGuestLabels :many
+SELECT id, label FROM guests
+WHERE id = ANY(@guest_ids::uuid[])
+AND label IS NOT NULL;
 
 -- name: DeletePermissionPrincipal :execrows
 DELETE FROM permissions 
@@ -110,4 +511,78 @@ AND document_id = $2;
 
 -- name: DeleteGuestsByDocument :execrows
 DELETE FROM guests
-WHERE document_id = $1;
\ No newline at end of file
+WHERE document_id = $1;
+-- the documents owned by a principal are moved to a new owner in batches, locking the owner
+-- permissions of a batch keeps them from changing while the batch is moved
+-- name: ListOwnedDocumentIdsForUpdate :many
+SELECT document_id FROM permissions
+WHERE recipient_id = $1
+AND permission_level = 'owner'
+LIMIT $2
+FOR UPDATE;
+
+-- the new owner may already have a permission on some of the documents, in which case the
+-- permission is raised to owner
+-- name: UpsertOwnerPermissions :execrows
+INSERT INTO permissions (
+    recipient_id, recipient_type, document_id, permission_level, created_by
+) SELECT @owner_id::uuid, 'user', unnest(@document_ids::uuid[]), 'owner', @created_by::uuid
+ON CONFLICT (recipient_id, document_id)
+DO UPDATE SET
+    last_modified_at = NOW(),
+    permission_level = 'owner',
+    pending = FALSE,
+    downgrade_to = NULL,
+    downgrade_at = NULL;
+
+-- name: DeletePermissionsOfPrincipal :execrows
+DELETE FROM permissions
+WHERE recipient_id = $1
+AND document_id = ANY(@document_ids::uuid[]);
+
+-- only an accepted permission above the level it is lowered to can be downgraded, owners are
+-- never downgraded because a document must keep its owner
+-- name: SchedulePermissionDowngrade :execrows
+UPDATE permissions SET
+downgrade_to = @downgrade_to,
+downgrade_at = @downgrade_at,
+last_modified_at = NOW()
+WHERE recipient_id = $1
+AND document_id = $2
+AND NOT pending
+AND permission_level <> 'owner'
+AND permission_level > @downgrade_to;
+
+-- the downgrades that are due, locked so that two instances of the job do not apply the same
+-- downgrade. Rows locked by another instance are skipped instead of waited on
+-- name: ListDueDowngradesForUpdate :many
+SELECT recipient_id, document_id, permission_level, downgrade_to FROM permissions
+WHERE downgrade_at <= @now::timestamptz
+ORDER BY downgrade_at
+LIMIT @batch_size
+FOR UPDATE SKIP LOCKED;
+
+-- name: ApplyPermissionDowngrade :execrows
+UPDATE permissions SET
+permission_level = downgrade_to,
+downgrade_to = NULL,
+downgrade_at = NULL,
+last_modified_at = NOW()
+WHERE recipient_id = $1
+AND document_id = $2
+AND downgrade_to IS NOT NULL;
+
+-- name: InsertPermissionAudit :exec
+INSERT INTO permission_audit (
+    id, document_id, recipient_id, old_permission_level, new_permission_level, reason
+) VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListPermissionAuditByDocument :many
+SELECT * FROM permission_audit
+WHERE document_id = $1
+ORDER BY changed_at DESC, id DESC
+LIMIT $2;
+
+-- name: DeletePermissionAuditByDocument :execrows
+DELETE FROM permission_audit
+WHERE document_id = $1;
@@ -1,10 +1,22 @@
+CREATE TYPE permission_level AS ENUM ('viewer', 'editor', 'owner');
+CREATE TYPE recipient_type AS ENUM ('user', 'guest');
+
 -- partition the documents table on the document id
 CREATE TABLE documents (
     id UUID PRIMARY KEY,
     name TEXT,
     description TEXT,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    last_modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    last_modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- archived documents are soft deleted, they keep their permissions and guests so that
+    -- they can be restored. archived_at is null while the document is active
+    archived_at TIMESTAMPTZ,
+    -- anyone holding the public link to the document gets this permission level, null
+    -- when the public link is disabled. This is never owner
+    public_access permission_level,
+    -- public link tokens carry the version they were issued at, the version is bumped each
+    -- time the public link is disabled so that enabling it again does not revive old tokens
+    public_link_version INTEGER NOT NULL DEFAULT 0
 );
 
 -- the sort order makes a small difference if they are both in the same direction
@@ -31,16 +43,26 @@ CREATE TABLE guests (
     -- field at the application level
     -- this may also make queries for all the links generated for a document easier
     document_id UUID NOT NULL REFERENCES documents(id),
-    description TEXT,
+    -- an optional label chosen by the owner so that the guest links of a document can
+    -- be told apart, for example "shared with the design review"
+    label TEXT,
     -- created by holds the user id that created this guest link
     -- only the creator of the link can modify it
     created_by UUID NOT NULL,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    last_modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    last_modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- guest tokens carry the token version of the guest at the time that they were issued,
+    -- rotating the link of the guest bumps the version so that the earlier tokens stop working
+    -- while the guest keeps its permission and history
+    token_version INTEGER NOT NULL DEFAULT 0,
+    -- the guest on another document that this guest was copied from when the permissions of
+    -- that document were copied, null for a guest that was created directly
+    copied_from UUID
 );
 
-CREATE TYPE permission_level AS ENUM ('viewer', 'editor', 'owner');
-CREATE TYPE recipient_type AS ENUM ('user', 'guest');
+-- copying the permissions of a document again does not add the guests that were already copied
+CREATE UNIQUE INDEX idx_guests_copied_from ON guests(document_id, copied_from)
+WHERE copied_from IS NOT NULL;
 
 -- partition the permissions table on the document_id
 -- this ensures that all the permissions on a document are on the same machine
@@ -53,12 +75,162 @@ CREATE TABLE permissions (
     created_by UUID NOT NULL,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     last_modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    PRIMARY KEY (recipient_id, document_id)
+    -- a pending share has been offered to the recipient but not yet accepted, it grants no
+    -- access to the document until the recipient accepts it
+    pending BOOLEAN NOT NULL DEFAULT FALSE,
+    -- a scheduled downgrade lowers the permission to downgrade_to once downgrade_at has passed,
+    -- for example an editor share that becomes a viewer share after a review. Both are null
+    -- when no downgrade is scheduled, changing the permission level clears the schedule
+    downgrade_to permission_level,
+    downgrade_at TIMESTAMPTZ,
+    PRIMARY KEY (recipient_id, document_id),
+    CHECK ((downgrade_to IS NULL) = (downgrade_at IS NULL))
 );
 
 -- this will be useful when we want to find all the editors/viewers on a document
 CREATE INDEX idx_permissions_document ON permissions(document_id);
 
+-- a document has exactly one owner, the application keeps it that way but the index stops a
+-- buggy write from giving a document a second owner
+CREATE UNIQUE INDEX idx_permissions_single_owner ON permissions(document_id)
+WHERE permission_level = 'owner';
+
+-- the downgrade job looks up the downgrades that are due, most permissions have none
+CREATE INDEX idx_permissions_downgrade_at ON permissions(downgrade_at)
+WHERE downgrade_at IS NOT NULL;
+
+-- every change to a permission is published on the document_permissions channel so that the
+-- permissions of a document can be watched without polling. The changes of every document share
+-- one channel so that the repository can watch all of them on a single connection, the document
+-- is part of the payload. The notification is only delivered once the transaction that made the
+-- change commits. A removal carries the level from before
+-- the removal, an addition or update carries the level from after the change
+CREATE FUNCTION notify_permission_change() RETURNS TRIGGER AS $$
+DECLARE
+    changed permissions;
+    change TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+        change := 'removed';
+    ELSIF TG_OP = 'INSERT' THEN
+        changed := NEW;
+        change := 'added';
+    ELSE
+        changed := NEW;
+        change := 'updated';
+    END IF;
+    PERFORM pg_notify(
+        'document_permissions',
+        json_build_object(
+            'document_id', changed.document_id,
+            'change', change,
+            'recipient_id', changed.recipient_id,
+            'recipient_type', changed.recipient_type,
+            'permission_level', changed.permission_level,
+            'pending', changed.pending
+        )::TEXT
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_permissions_notify
+AFTER INSERT OR UPDATE OR DELETE ON permissions
+FOR EACH ROW EXECUTE FUNCTION notify_permission_change();
+
+-- every update of the name or description of a document appends a row in the same
+-- transaction as the update. Each row holds the name and description from before and after
+-- the update, a field that was not changed by the update has the same old and new value
+CREATE TABLE document_history (
+    id UUID PRIMARY KEY,
+    document_id UUID NOT NULL REFERENCES documents(id),
+    -- the principal that made the update
+    actor_id UUID NOT NULL,
+    old_name TEXT,
+    new_name TEXT,
+    old_description TEXT,
+    new_description TEXT,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- the history of a document is read in reverse chronological order
+CREATE INDEX idx_document_history_document ON document_history(document_id, changed_at DESC, id DESC);
+
+-- changes to permissions that were not made by a principal, for example the downgrades applied
+-- by the downgrade job, are recorded here so that the owner can tell why a level changed
+CREATE TABLE permission_audit (
+    id UUID PRIMARY KEY,
+    document_id UUID NOT NULL REFERENCES documents(id),
+    recipient_id UUID NOT NULL,
+    old_permission_level permission_level NOT NULL,
+    new_permission_level permission_level NOT NULL,
+    -- why the permission changed, for example "scheduled downgrade"
+    reason TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_permission_audit_document ON permission_audit(document_id, changed_at DESC, id DESC);
+
+-- deleting a document leaves a tombstone behind so that clients syncing their documents can tell
+-- a recently deleted document apart from one that never existed. There is no foreign key to the
+-- documents table because the row of the document is gone. The principals that held a permission
+-- on the document when it was deleted are kept so that only they can see the tombstone, the
+-- tombstones older than the retention are pruned by a background job
+CREATE TABLE document_tombstones (
+    document_id UUID PRIMARY KEY,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    principal_ids UUID[] NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_document_tombstones_deleted_at ON document_tombstones(deleted_at);
+
+-- the most recent time that each principal read each document, only the latest read is kept
+-- so the table grows with the number of documents a principal has opened and not with the
+-- number of reads
+CREATE TABLE access_log (
+    principal_id UUID NOT NULL,
+    document_id UUID NOT NULL REFERENCES documents(id),
+    accessed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (principal_id, document_id)
+);
+
+-- the documents a principal opened are read most recent first
+CREATE INDEX idx_access_log_principal ON access_log(principal_id, accessed_at DESC, document_id DESC);
+
+-- the documents that each principal starred, a favorite does not grant access to the document.
+-- Favorites are listed through the permissions of the principal so a favorite on a document that
+-- the principal lost access to is kept but not listed
+CREATE TABLE favorites (
+    principal_id UUID NOT NULL,
+    document_id UUID NOT NULL REFERENCES documents(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (principal_id, document_id)
+);
+
+-- collections let a principal organize their documents like folders. A collection belongs to the
+-- principal that created it and does not grant access to the documents in it, the documents of a
+-- collection are listed through the permissions of the principal like favorites
+CREATE TABLE collections (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_collections_owner ON collections(owner_id);
+
+-- a document can be in any number of collections
+CREATE TABLE document_collection (
+    collection_id UUID NOT NULL REFERENCES collections(id),
+    document_id UUID NOT NULL REFERENCES documents(id),
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, document_id)
+);
+
+-- deleting a document deletes its memberships
+CREATE INDEX idx_document_collection_document ON document_collection(document_id);
+
 -- using the composite primary key of recipient_id and document_id means that we
 -- will have a index on those two fields. 
 -- TODO: Create an index on just the document_id
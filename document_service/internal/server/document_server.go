@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -14,21 +17,35 @@ import (
 
 	pb "github.com/townsag/reed/document_service/api/v1"
 	"github.com/townsag/reed/document_service/internal/service"
+	"github.com/townsag/reed/document_service/pkg/client"
 )
 
 type DocumentServiceServerImpl struct {
 	pb.UnimplementedDocumentServiceServer
 	documentService *service.DocumentService
+	// done once the server is shutting down, the watch streams end when it is done
+	shutdown context.Context
+	stopWatches context.CancelFunc
 }
 
 var _ pb.DocumentServiceServer = (*DocumentServiceServerImpl)(nil)
 
 func NewDocumentServiceImpl(documentService *service.DocumentService) *DocumentServiceServerImpl {
+	shutdown, stopWatches := context.WithCancel(context.Background())
 	return &DocumentServiceServerImpl{
 		documentService: documentService,
+		shutdown: shutdown,
+		stopWatches: stopWatches,
 	}
 }
 
+// end the open watch streams and every watch stream that is opened after this. A watch stream
+// only ends when the caller cancels it, so without this a graceful stop of the grpc server
+// waits forever for the connected watchers. Call this before the graceful stop
+func (s *DocumentServiceServerImpl) StopWatches() {
+	s.stopWatches()
+}
+
 /*
 ## What is this layer for?
 - the server layer if for:
@@ -59,6 +76,10 @@ func serviceToGRPCError(err error) error {
 	var notFound *service.NotFoundError
 	var uniqueError *service.UniqueConflictError
 	var invalidError *service.InvalidInputError
+	var permissionDenied *service.PermissionDeniedError
+	var gone *service.GoneError
+	var quotaExceeded *service.QuotaExceededError
+	var exhausted *service.ResourceExhaustedError
 
 	switch {
 	case err == nil:
@@ -69,12 +90,36 @@ func serviceToGRPCError(err error) error {
 		return status.Error(codes.AlreadyExists, err.Error())
 	case errors.As(err, &invalidError):
 		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &permissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &gone):
+		// the reason tells an archived document apart from the other failed preconditions
+		return errorWithReason(codes.FailedPrecondition, err, client.ReasonDocumentArchived)
+	case errors.As(err, &quotaExceeded):
+		// the reason tells a quota that will not reset by waiting apart from a rate limit
+		return errorWithReason(codes.ResourceExhausted, err, client.ReasonQuotaExceeded)
+	case errors.As(err, &exhausted):
+		// no reason, like a rate limit the caller can retry once other callers are done
+		return status.Error(codes.ResourceExhausted, err.Error())
 	// the repo implementation error falls into the default case of internal server error
 	default:
 		return status.Error(codes.Internal, "internal server error encountered")
 	}
 }
 
+// attach an error info detail with the reason to the status, the status is returned without
+// the detail if it cannot be attached
+func errorWithReason(code codes.Code, err error, reason string) error {
+	st, detailErr := status.New(code, err.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: "document_service",
+	})
+	if detailErr != nil {
+		return status.Error(code, err.Error())
+	}
+	return st.Err()
+}
+
 func pbToServicePermissionLevel(permissionLevel pb.PermissionLevel) (service.PermissionLevel, error) {
 	switch permissionLevel {
 	case pb.PermissionLevel_PERMISSION_VIEWER:
@@ -83,11 +128,38 @@ func pbToServicePermissionLevel(permissionLevel pb.PermissionLevel) (service.Per
 		return service.Editor, nil
 	case pb.PermissionLevel_PERMISSION_OWNER:
 		return service.Owner, nil
+	case pb.PermissionLevel_PERMISSION_UNSPECIFIED:
+		return -1, fmt.Errorf("the permission level is unspecified")
 	default:
 		return -1, fmt.Errorf("failed to match any valid service permission levels for permission: %v", permissionLevel)
 	}
 }
 
+// the version of the public link that the caller presented, nil when they did not present one
+func publicLinkVersion(clientContext *pb.ClientContext) *int32 {
+	if clientContext == nil {
+		return nil
+	}
+	return clientContext.PublicLinkVersion
+}
+
+func pbToServiceAction(action pb.Action) (service.Action, error) {
+	switch action {
+	case pb.Action_ACTION_VIEW:
+		return service.View, nil
+	case pb.Action_ACTION_EDIT:
+		return service.Edit, nil
+	case pb.Action_ACTION_MANAGE:
+		return service.Manage, nil
+	case pb.Action_ACTION_DELETE:
+		return service.Delete, nil
+	case pb.Action_ACTION_UNSPECIFIED:
+		return -1, fmt.Errorf("the action is unspecified")
+	default:
+		return -1, fmt.Errorf("failed to match any valid service actions for action: %v", action)
+	}
+}
+
 func pbToServicePermissionLevelList(
 	permissions []pb.PermissionLevel,
 ) ([]service.PermissionLevel, error) {
@@ -116,18 +188,31 @@ func serviceToPbPermissionLevel(
 	case service.Owner:
 		return  pb.PermissionLevel_PERMISSION_OWNER, nil
 	default:
-		return -1, fmt.Errorf("failed to map a valid pb permission level to: %v", permissionLevel)
+		return pb.PermissionLevel_PERMISSION_UNSPECIFIED, fmt.Errorf(
+			"failed to map a valid pb permission level to: %v", permissionLevel,
+		)
 	}
 }
 
-func serviceToPbDocument(document service.Document) (*pb.Document) {
-	return &pb.Document{
+func serviceToPbDocument(document service.Document) (*pb.Document, error) {
+	pbDocument := &pb.Document{
 		DocumentId: document.ID.String(),
 		DocumentName: document.Name,
 		Description: document.Description,
 		CreatedAt: timestamppb.New(document.CreatedAt),
 		LastModifiedAt: timestamppb.New(document.LastModifiedAt),
 	}
+	if document.PublicAccess != nil {
+		publicAccess, err := serviceToPbPermissionLevel(*document.PublicAccess)
+		if err != nil {
+			return nil, err
+		}
+		pbDocument.PublicAccess = &publicAccess
+	}
+	if document.ArchivedAt != nil {
+		pbDocument.ArchivedAt = timestamppb.New(*document.ArchivedAt)
+	}
+	return pbDocument, nil
 }
 
 func serviceToPbDocumentPermissionList(
@@ -135,9 +220,12 @@ func serviceToPbDocumentPermissionList(
 ) ([]*pb.ListDocumentsByPrincipalReply_DocumentPermission, error) {
 	result := make([]*pb.ListDocumentsByPrincipalReply_DocumentPermission, len(documentPermissions))
 	for i, elem := range documentPermissions {
-		// serialize the service permission to a pb permission
-		document := serviceToPbDocument(elem.Document)
 		// serialize the service document to a pb document
+		document, err := serviceToPbDocument(elem.Document)
+		if err != nil {
+			return nil, err
+		}
+		// serialize the service permission to a pb permission
 		permissionLevel, err := serviceToPbPermissionLevel(elem.Permission)
 		if err != nil {
 			return nil, err
@@ -146,11 +234,79 @@ func serviceToPbDocumentPermissionList(
 		result[i] = &pb.ListDocumentsByPrincipalReply_DocumentPermission{
 			Document: document,
 			PermissionLevel: permissionLevel,
+			PermissionCreatedAt: timestamppb.New(elem.PermissionCreatedAt),
+			PermissionLastModifiedAt: timestamppb.New(elem.PermissionLastModifiedAt),
+		}
+	}
+	return result, nil
+}
+
+func serviceToPbAccessedDocumentList(
+	accessedDocuments []service.AccessedDocument,
+) ([]*pb.ListRecentlyAccessedReply_AccessedDocument, error) {
+	result := make([]*pb.ListRecentlyAccessedReply_AccessedDocument, len(accessedDocuments))
+	for i, elem := range accessedDocuments {
+		document, err := serviceToPbDocument(elem.Document)
+		if err != nil {
+			return nil, err
+		}
+		permission, err := serviceToPbPermissionLevel(elem.Permission)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = &pb.ListRecentlyAccessedReply_AccessedDocument{
+			Document: document,
+			Permission: permission,
+			AccessedAt: timestamppb.New(elem.AccessedAt),
+		}
+	}
+	return result, nil
+}
+
+func serviceToPbSharedDocumentList(
+	sharedDocuments []service.SharedDocument,
+) ([]*pb.ListSharedDocumentsByOwnerReply_SharedDocument, error) {
+	result := make([]*pb.ListSharedDocumentsByOwnerReply_SharedDocument, len(sharedDocuments))
+	for i, elem := range sharedDocuments {
+		document, err := serviceToPbDocument(elem.Document)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = &pb.ListSharedDocumentsByOwnerReply_SharedDocument{
+			Document: document,
+			CollaboratorCount: elem.CollaboratorCount,
 		}
 	}
 	return result, nil
 }
 
+func serviceToPbDocumentChangeList(
+	changes []service.DocumentChange,
+) []*pb.GetDocumentHistoryReply_DocumentChange {
+	result := make([]*pb.GetDocumentHistoryReply_DocumentChange, len(changes))
+	for i, elem := range changes {
+		result[i] = &pb.GetDocumentHistoryReply_DocumentChange{
+			ChangeId: elem.ID.String(),
+			ActorId: elem.ActorID.String(),
+			OldName: elem.OldName,
+			NewName: elem.NewName,
+			OldDescription: elem.OldDescription,
+			NewDescription: elem.NewDescription,
+			ChangedAt: timestamppb.New(elem.ChangedAt),
+		}
+	}
+	return result
+}
+
+func serviceToPbCollection(collection service.Collection) *pb.Collection {
+	return &pb.Collection{
+		CollectionId: collection.ID.String(),
+		OwnerId: collection.OwnerID.String(),
+		Name: collection.Name,
+		CreatedAt: timestamppb.New(collection.CreatedAt),
+	}
+}
+
 func pbToServiceSortField(
 	sortField pb.Cursor_SortField,
 ) (service.SortField, error) {
@@ -159,6 +315,8 @@ func pbToServiceSortField(
 		return service.CreatedAt, nil
 	case pb.Cursor_SORT_FIELD_LAST_MODIFIED_AT:
 		return service.LastModifiedAt, nil
+	case pb.Cursor_SORT_FIELD_ACCESSED_AT:
+		return service.AccessedAt, nil
 	default:
 		return -1, fmt.Errorf("failed to match any valid service sort fields for sort field: %v", sortField)
 	}
@@ -172,12 +330,16 @@ func serviceToPbSortField(
 		return pb.Cursor_SORT_FIELD_CREATED_AT, nil
 	case service.LastModifiedAt:
 		return pb.Cursor_SORT_FIELD_LAST_MODIFIED_AT, nil
+	case service.AccessedAt:
+		return pb.Cursor_SORT_FIELD_ACCESSED_AT, nil
 	default:
 		return -1, fmt.Errorf("failed to find a valid pb sort field for: %v", sortField)
 	}
 }
 
-func serviceToPbCursor(cursor service.Cursor) (*pb.Cursor, error) {
+// the kind is the list that hands out the cursor, parseServiceCursor rejects the cursor on the
+// other lists
+func serviceToPbCursor(cursor service.Cursor, kind pb.Cursor_Kind) (*pb.Cursor, error) {
 	sortField, err := serviceToPbSortField(cursor.SortField)
 	temp := cursor.LastSeenID.String()
 	if err != nil {
@@ -187,16 +349,50 @@ func serviceToPbCursor(cursor service.Cursor) (*pb.Cursor, error) {
 		SortField: sortField,
 		LastSeenTime: timestamppb.New(cursor.LastSeenTime),
 		LastSeenDocumentId: &temp,
+		Kind: kind,
 	}, nil
 }
 
+// cursors are built from the timestamps of documents and permissions, a cursor time before this
+// is not one that the service handed out, for example the zero time of a hand crafted cursor
+var minCursorTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// reject a cursor time that the service could not have handed out. A time in the future is
+// clamped to the current time, it reads the same page as a cursor from the beginning and can
+// come from a clock that is slightly ahead of this one
+func normalizeCursorTime(lastSeenTime *timestamppb.Timestamp) (time.Time, error) {
+	if err := lastSeenTime.CheckValid(); err != nil {
+		return time.Time{}, fmt.Errorf("invalid cursor time: %w", err)
+	}
+	cursorTime := lastSeenTime.AsTime()
+	if cursorTime.Before(minCursorTime) {
+		return time.Time{}, fmt.Errorf(
+			"cursor time: %s is before the earliest accepted time: %s",
+			cursorTime.Format(time.RFC3339Nano), minCursorTime.Format(time.RFC3339),
+		)
+	}
+	if now := time.Now(); cursorTime.After(now) {
+		return now, nil
+	}
+	return cursorTime, nil
+}
+
 type RequestWithCursor interface {
 	GetCursor() *pb.Cursor
 }
 
+// the kind is the list that the cursor is read by, a cursor handed out by another list or
+// without a kind is rejected with an invalid input error. Accepting a cursor without a kind
+// would let a client clear the kind to read a cursor on the wrong list
 func parseServiceCursor(
 	reqCursor *pb.Cursor,
+	kind pb.Cursor_Kind,
 ) (*service.Cursor, error) {
+	if cursorKind := reqCursor.GetKind(); cursorKind != kind {
+		return nil, service.InvalidInput(
+			fmt.Sprintf("a cursor of kind: %v can not be used by a list of kind: %v", cursorKind, kind), nil,
+		)
+	}
 	sortField, err := pbToServiceSortField(reqCursor.SortField)
 	if err != nil {
 		return nil, err
@@ -218,9 +414,13 @@ func parseServiceCursor(
 		} else {
 			docId = service.MaxDocumentID()
 		}
+		lastSeenTime, err := normalizeCursorTime(reqCursor.LastSeenTime)
+		if err != nil {
+			return nil, err
+		}
 		return &service.Cursor{
 			SortField: sortField,
-			LastSeenTime: reqCursor.LastSeenTime.AsTime(),
+			LastSeenTime: lastSeenTime,
 			LastSeenID: docId,
 		}, nil
 	}
@@ -248,7 +448,7 @@ func serviceToPbPermission(permission service.Permission) (*pb.Permission, error
 	if err != nil {
 		return nil, fmt.Errorf("error encountered when serializing permission: %w", err)
 	}
-	return &pb.Permission{
+	pbPermission := &pb.Permission{
 		Recipient: &pb.Principal{
 			PrincipalId: permission.RecipientID.String(),
 			PrincipalType: principalType,
@@ -258,6 +458,49 @@ func serviceToPbPermission(permission service.Permission) (*pb.Permission, error
 		CreatedBy: permission.CreatedBy.String(),
 		CreatedAt: timestamppb.New(permission.CreatedAt),
 		LastModifiedAt: timestamppb.New(permission.LastModifiedAt),
+		Label: permission.Label,
+		Pending: permission.Pending,
+	}
+	if permission.DowngradeTo != nil && permission.DowngradeAt != nil {
+		downgradeTo, err := serviceToPbPermissionLevel(*permission.DowngradeTo)
+		if err != nil {
+			return nil, fmt.Errorf("error encountered when serializing permission: %w", err)
+		}
+		pbPermission.DowngradeTo = &downgradeTo
+		pbPermission.DowngradeAt = timestamppb.New(*permission.DowngradeAt)
+	}
+	return pbPermission, nil
+}
+
+func serviceToPbPermissionEvent(event service.PermissionEvent) (*pb.PermissionEvent, error) {
+	var change pb.PermissionEvent_Change
+	switch event.Change {
+	case service.PermissionAdded:
+		change = pb.PermissionEvent_CHANGE_ADDED
+	case service.PermissionUpdated:
+		change = pb.PermissionEvent_CHANGE_UPDATED
+	case service.PermissionRemoved:
+		change = pb.PermissionEvent_CHANGE_REMOVED
+	default:
+		return nil, fmt.Errorf("failed to map service permission change to pb change: %v", event.Change)
+	}
+	principalType, err := serviceToPbRecipientType(event.RecipientType)
+	if err != nil {
+		return nil, fmt.Errorf("error encountered when serializing permission event: %w", err)
+	}
+	permissionLevel, err := serviceToPbPermissionLevel(event.PermissionLevel)
+	if err != nil {
+		return nil, fmt.Errorf("error encountered when serializing permission event: %w", err)
+	}
+	return &pb.PermissionEvent{
+		Change: change,
+		DocumentId: event.DocumentID.String(),
+		Recipient: &pb.Principal{
+			PrincipalId: event.RecipientID.String(),
+			PrincipalType: principalType,
+		},
+		PermissionLevel: permissionLevel,
+		Pending: event.Pending,
 	}, nil
 }
 
@@ -282,9 +525,17 @@ func (s *DocumentServiceServerImpl) CreateDocument(
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "unable to parse owner user Id as uuid")
 	}
+	var clientDocumentId *uuid.UUID
+	if createDocReq.ClientDocumentId != nil {
+		parsed, err := uuid.Parse(*createDocReq.ClientDocumentId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unable to parse client document Id as uuid")
+		}
+		clientDocumentId = &parsed
+	}
 	// call the service function with the validated inputs
 	documentId, err := s.documentService.CreateDocument(
-		ctx, userId, createDocReq.DocumentName, createDocReq.DocumentDescription,
+		ctx, userId, createDocReq.DocumentName, createDocReq.DocumentDescription, clientDocumentId,
 	)
 	// if necessary, translate the error to a grpc error
 	if err != nil {
@@ -305,21 +556,58 @@ func (s *DocumentServiceServerImpl) GetDocument(
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "unable to parse documentId as uuid")
 	}
+	// parse the id of the calling principal from the client context
+	callerId, err := uuid.Parse(getDocReq.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", getDocReq.GetClientContext().GetPrincipalId(),
+		)
+	}
+	if getDocReq.GetIncludeTombstone() {
+		document, tombstone, err := s.documentService.GetDocumentOrTombstone(
+			ctx, callerId, documentId, publicLinkVersion(getDocReq.GetClientContext()),
+		)
+		if err != nil {
+			return nil, serviceToGRPCError(err)
+		}
+		if tombstone != nil {
+			return &pb.GetDocumentReply{
+				Tombstone: &pb.DocumentTombstone{
+					DocumentId: tombstone.DocumentID.String(),
+					DeletedAt: timestamppb.New(tombstone.DeletedAt),
+				},
+			}, nil
+		}
+		return s.documentReply(ctx, *document, getDocReq.GetIncludeCollaboratorCount())
+	}
 	document, err := s.documentService.GetDocument(
-		ctx, documentId,
+		ctx, callerId, documentId, publicLinkVersion(getDocReq.GetClientContext()),
 	)
 	if err != nil {
 		return nil, serviceToGRPCError(err)
 	}
-	return &pb.GetDocumentReply{
-		Document: &pb.Document{
-			DocumentId: document.ID.String(),
-			DocumentName: document.Name,
-			Description: document.Description,
-			CreatedAt: timestamppb.New(document.CreatedAt),
-			LastModifiedAt: timestamppb.New(document.LastModifiedAt),
-		},
-	}, nil
+	return s.documentReply(ctx, *document, getDocReq.GetIncludeCollaboratorCount())
+}
+
+// the collaborators are only counted once the caller has been allowed to read the document
+func (s *DocumentServiceServerImpl) documentReply(
+	ctx context.Context,
+	document service.Document,
+	includeCollaboratorCount bool,
+) (*pb.GetDocumentReply, error) {
+	pbDocument, err := serviceToPbDocument(document)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	reply := &pb.GetDocumentReply{ Document: pbDocument }
+	if includeCollaboratorCount {
+		count, err := s.documentService.CountCollaborators(ctx, document.ID)
+		if err != nil {
+			return nil, serviceToGRPCError(err)
+		}
+		reply.CollaboratorCount = &count
+	}
+	return reply, nil
 }
 
 func (s *DocumentServiceServerImpl) UpdateDocument(
@@ -333,15 +621,19 @@ func (s *DocumentServiceServerImpl) UpdateDocument(
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid")
 	}
-	// translate the userId to a uuid
-	// userId, err := uuid.Parse(updateDocReq.UserId)
-	// if err != nil {
-	// 	return nil, status.Errorf(codes.InvalidArgument, "failed to parse userId as uuid")
-	// }
-	// TODO: use the userId to verify that this user has update permissions on this document
+	// translate the calling principal id to a uuid, the caller is recorded in the history of
+	// the document
+	callerId, err := uuid.Parse(updateDocReq.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", updateDocReq.GetClientContext().GetPrincipalId(),
+		)
+	}
+	// TODO: use the callerId to verify that this principal has update permissions on this document
 	// call the update document service function
 	err = s.documentService.UpdateDocument(
-		ctx, documentId, updateDocReq.Name, updateDocReq.Description,
+		ctx, documentId, callerId, updateDocReq.Name, updateDocReq.Description,
+		updateDocReq.ClearName, updateDocReq.ClearDescription,
 	)
 	// return any errors if necessary
 	if err != nil {
@@ -350,6 +642,51 @@ func (s *DocumentServiceServerImpl) UpdateDocument(
 	return &emptypb.Empty{}, nil
 }
 
+func (s *DocumentServiceServerImpl) GetDocumentHistory(
+	ctx context.Context,
+	req *pb.GetDocumentHistoryRequest,
+) (*pb.GetDocumentHistoryReply, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse documentId: %s as uuid", req.DocumentId,
+		)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	// the service starts from the beginning when there is no cursor from a previous page
+	var cursor *service.Cursor
+	if req.Cursor != nil && req.Cursor.LastSeenTime != nil {
+		cursor, err = parseServiceCursor(req.Cursor, pb.Cursor_KIND_DOCUMENT_HISTORY)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	pageSize := service.DefaultPageSize
+	if req.PageSize != nil {
+		pageSize = *req.PageSize
+	}
+	changes, responseCursor, hasMore, err := s.documentService.GetDocumentHistory(
+		ctx, documentId, callerId, cursor, pageSize,
+	)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	pbRespCursor, err := serviceToPbCursor(*responseCursor, pb.Cursor_KIND_DOCUMENT_HISTORY)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.GetDocumentHistoryReply{
+		Changes: serviceToPbDocumentChangeList(changes),
+		Cursor: pbRespCursor,
+		HasMore: hasMore,
+	}, nil
+}
+
 func (s *DocumentServiceServerImpl) DeleteDocument(
 	ctx context.Context,
 	deleteDocReq *pb.DeleteDocumentRequest,
@@ -370,6 +707,48 @@ func (s *DocumentServiceServerImpl) DeleteDocument(
 	return &emptypb.Empty{}, nil
 }
 
+func (s *DocumentServiceServerImpl) ArchiveDocument(
+	ctx context.Context,
+	req *pb.ArchiveDocumentRequest,
+) (*emptypb.Empty, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	err = s.documentService.ArchiveDocument(ctx, callerId, documentId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) RestoreDocument(
+	ctx context.Context,
+	req *pb.RestoreDocumentRequest,
+) (*emptypb.Empty, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	err = s.documentService.RestoreDocument(ctx, callerId, documentId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
 func (s *DocumentServiceServerImpl) DeleteDocuments(
 	ctx context.Context,
 	deleteDocsReq *pb.DeleteDocumentsRequest,
@@ -390,8 +769,8 @@ func (s *DocumentServiceServerImpl) DeleteDocuments(
 			codes.InvalidArgument, "failed to parse user id: %s", deleteDocsReq.ClientContext.PrincipalId,
 		)
 	}
-	// validate that the user has ownership permissions over each of the documents in the list 
-	// call the delete documents service method
+	// call the delete documents service method, this validates that the user has ownership
+	// permissions over each of the documents in the list
 	err = s.documentService.DeleteDocuments(ctx, parsedDocumentIds, parsedUserId)
 	if err != nil {
 		return nil, serviceToGRPCError(err)
@@ -399,6 +778,76 @@ func (s *DocumentServiceServerImpl) DeleteDocuments(
 	return &emptypb.Empty{}, nil
 }
 
+func (s *DocumentServiceServerImpl) ReassignOwnedDocuments(
+	ctx context.Context,
+	req *pb.ReassignOwnedDocumentsRequest,
+) (*pb.ReassignOwnedDocumentsReply, error) {
+	fromOwnerId, err := uuid.Parse(req.FromOwnerId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse from owner id as uuid: %v", req.FromOwnerId)
+	}
+	toOwnerId, err := uuid.Parse(req.ToOwnerId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse to owner id as uuid: %v", req.ToOwnerId)
+	}
+	moved, err := s.documentService.ReassignOwnedDocuments(ctx, fromOwnerId, toOwnerId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.ReassignOwnedDocumentsReply{ MovedCount: moved }, nil
+}
+
+func (s *DocumentServiceServerImpl) EnsureDocumentHasOwner(
+	ctx context.Context,
+	req *pb.EnsureDocumentHasOwnerRequest,
+) (*pb.EnsureDocumentHasOwnerReply, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	fallbackOwnerId, err := uuid.Parse(req.FallbackOwnerId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse fallback owner id as uuid: %v", req.FallbackOwnerId)
+	}
+	repaired, err := s.documentService.EnsureDocumentHasOwner(ctx, documentId, fallbackOwnerId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.EnsureDocumentHasOwnerReply{ Repaired: repaired }, nil
+}
+
+func (s *DocumentServiceServerImpl) SetPublicAccess(
+	ctx context.Context,
+	req *pb.SetPublicAccessRequest,
+) (*pb.SetPublicAccessReply, error) {
+	// parse the document id
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	// parse the id of the calling principal from the client context
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	// parse the public access if it is present, the public link is disabled otherwise
+	var publicAccess *service.PermissionLevel
+	if req.PublicAccess != nil {
+		parsedPublicAccess, err := pbToServicePermissionLevel(req.GetPublicAccess())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		publicAccess = &parsedPublicAccess
+	}
+	publicLinkVersion, err := s.documentService.SetPublicAccess(ctx, callerId, documentId, publicAccess)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.SetPublicAccessReply{ PublicLinkVersion: publicLinkVersion }, nil
+}
+
 func (s *DocumentServiceServerImpl) ListDocumentsByPrincipal(
 	ctx context.Context,
 	listDocReq *pb.ListDocumentByPrincipalRequest,
@@ -417,11 +866,14 @@ func (s *DocumentServiceServerImpl) ListDocumentsByPrincipal(
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-	// construct the cursor
-	// parse the sort field
-	cursor, err := parseServiceCursor(listDocReq.Cursor)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+	// construct the cursor, the service starts from the beginning when there is no cursor from a
+	// previous page. A cursor without a last seen time still picks the sort field
+	var cursor *service.Cursor
+	if listDocReq.Cursor != nil {
+		cursor, err = parseServiceCursor(listDocReq.Cursor, pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 	}
 	// parse the page size
 	var pageSize int32
@@ -430,9 +882,20 @@ func (s *DocumentServiceServerImpl) ListDocumentsByPrincipal(
 	} else {
 		pageSize = *listDocReq.PageSize
 	}
+	// parse the collection id if it is present, every document is listed otherwise
+	var collectionId *uuid.UUID
+	if listDocReq.CollectionId != nil {
+		parsedCollectionId, err := uuid.Parse(listDocReq.GetCollectionId())
+		if err != nil {
+			return nil, status.Errorf(
+				codes.InvalidArgument, "failed to parse collection id as uuid: %v", listDocReq.GetCollectionId(),
+			)
+		}
+		collectionId = &parsedCollectionId
+	}
 	// call the relevant helper function
-	documentPermissions, responseCursor, err := s.documentService.ListDocumentsByPrincipal(
-		ctx, principalId, permissionFilter, cursor, pageSize,
+	documentPermissions, responseCursor, hasMore, err := s.documentService.ListDocumentsByPrincipal(
+		ctx, principalId, permissionFilter, listDocReq.FavoritesOnly, collectionId, listDocReq.IncludeArchived, cursor, pageSize,
 	)
 	// return any errors if necessary
 	if err != nil {
@@ -444,58 +907,360 @@ func (s *DocumentServiceServerImpl) ListDocumentsByPrincipal(
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	// serialize the response cursor
-	pbRespCursor, err := serviceToPbCursor(*responseCursor)
+	pbRespCursor, err := serviceToPbCursor(*responseCursor, pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	return &pb.ListDocumentsByPrincipalReply{
 		DocumentPermissions: pbDocumentPermissions,
 		Cursor: pbRespCursor,
+		HasMore: hasMore,
 	}, nil
 }
 
-func (s *DocumentServiceServerImpl) GetPermissionsOfPrincipalOnDocument(
+func (s *DocumentServiceServerImpl) ListDocumentsModifiedSince(
 	ctx context.Context,
-	req *pb.GetPermissionsRequest,
-) (*pb.GetPermissionsReply, error) {
-	// parse the documentID as a uuid
-	documentId, err := uuid.Parse(req.DocumentId)
-	if err != nil {
-		return nil, status.Errorf(
-			codes.InvalidArgument, "unable to parse document ID as a uuid: %v", req.DocumentId,
-		)
-	}
-	// parse the principalID as a uuid
+	req *pb.ListDocumentsModifiedSinceRequest,
+) (*pb.ListDocumentsByPrincipalReply, error) {
+	// parse the principal id
 	principalId, err := uuid.Parse(req.PrincipalId)
 	if err != nil {
 		return nil, status.Errorf(
-			codes.InvalidArgument, "unable to parse principalId as a uuid: %v", req.PrincipalId,
+			codes.InvalidArgument, "unable to parse principalId: %s as uuid", req.PrincipalId,
 		)
 	}
-	permission, err := s.documentService.GetPermissionOfPrincipalOnDocument(ctx, documentId, principalId)
-	// return any error that I may have found
+	// the service starts from the since time when there is no cursor from a previous page
+	var cursor *service.Cursor
+	if req.Cursor != nil && req.Cursor.LastSeenTime != nil {
+		cursor, err = parseServiceCursor(req.Cursor, pb.Cursor_KIND_DOCUMENTS_MODIFIED_SINCE)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	// parse the page size
+	pageSize := service.DefaultPageSize
+	if req.PageSize != nil {
+		pageSize = *req.PageSize
+	}
+	documentPermissions, responseCursor, hasMore, err := s.documentService.ListDocumentsModifiedSince(
+		ctx, principalId, req.GetSince().AsTime(), cursor, pageSize,
+	)
 	if err != nil {
 		return nil, serviceToGRPCError(err)
 	}
-	// serialize the permission object and return it
-	pbPermission, err := serviceToPbPermission(permission)
+	// serialize list of documents and return cursor to a protobuf response
+	pbDocumentPermissions, err := serviceToPbDocumentPermissionList(documentPermissions)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	return &pb.GetPermissionsReply{
-		Permission: pbPermission,
+	pbRespCursor, err := serviceToPbCursor(*responseCursor, pb.Cursor_KIND_DOCUMENTS_MODIFIED_SINCE)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.ListDocumentsByPrincipalReply{
+		DocumentPermissions: pbDocumentPermissions,
+		Cursor: pbRespCursor,
+		HasMore: hasMore,
 	}, nil
 }
 
-func (s *DocumentServiceServerImpl) ListPermissionsOnDocument(
-	ctx context.Context, 
-	req *pb.ListPermissionsOnDocumentRequest,
-) (*pb.ListPermissionsOnDocumentReply, error) {
-	// parse the documentID
-	documentId, err := uuid.Parse(req.DocumentId)
+func (s *DocumentServiceServerImpl) ListRecentlyAccessed(
+	ctx context.Context,
+	req *pb.ListRecentlyAccessedRequest,
+) (*pb.ListRecentlyAccessedReply, error) {
+	principalId, err := uuid.Parse(req.PrincipalId)
 	if err != nil {
 		return nil, status.Errorf(
-			codes.InvalidArgument,
+			codes.InvalidArgument, "unable to parse principalId: %s as uuid", req.PrincipalId,
+		)
+	}
+	// the service starts from the beginning when there is no cursor from a previous page
+	var cursor *service.Cursor
+	if req.Cursor != nil && req.Cursor.LastSeenTime != nil {
+		cursor, err = parseServiceCursor(req.Cursor, pb.Cursor_KIND_RECENTLY_ACCESSED)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	pageSize := service.DefaultPageSize
+	if req.PageSize != nil {
+		pageSize = *req.PageSize
+	}
+	accessedDocuments, responseCursor, hasMore, err := s.documentService.ListRecentlyAccessed(
+		ctx, principalId, cursor, pageSize,
+	)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	pbAccessedDocuments, err := serviceToPbAccessedDocumentList(accessedDocuments)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	pbRespCursor, err := serviceToPbCursor(*responseCursor, pb.Cursor_KIND_RECENTLY_ACCESSED)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.ListRecentlyAccessedReply{
+		AccessedDocuments: pbAccessedDocuments,
+		Cursor: pbRespCursor,
+		HasMore: hasMore,
+	}, nil
+}
+
+func (s *DocumentServiceServerImpl) ListSharedDocumentsByOwner(
+	ctx context.Context,
+	req *pb.ListSharedDocumentsByOwnerRequest,
+) (*pb.ListSharedDocumentsByOwnerReply, error) {
+	// parse the owner id
+	ownerId, err := uuid.Parse(req.OwnerId)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "unable to parse ownerId: %s as uuid", req.OwnerId,
+		)
+	}
+	// the service starts from the beginning when there is no cursor from a previous page
+	var cursor *service.Cursor
+	if req.Cursor != nil && req.Cursor.LastSeenTime != nil {
+		cursor, err = parseServiceCursor(req.Cursor, pb.Cursor_KIND_SHARED_DOCUMENTS)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	// parse the page size
+	pageSize := service.DefaultPageSize
+	if req.PageSize != nil {
+		pageSize = *req.PageSize
+	}
+	sharedDocuments, responseCursor, hasMore, err := s.documentService.ListSharedDocumentsByOwner(
+		ctx, ownerId, cursor, pageSize,
+	)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	pbSharedDocuments, err := serviceToPbSharedDocumentList(sharedDocuments)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	pbRespCursor, err := serviceToPbCursor(*responseCursor, pb.Cursor_KIND_SHARED_DOCUMENTS)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.ListSharedDocumentsByOwnerReply{
+		SharedDocuments: pbSharedDocuments,
+		Cursor: pbRespCursor,
+		HasMore: hasMore,
+	}, nil
+}
+
+func (s *DocumentServiceServerImpl) ListAllDocuments(
+	ctx context.Context,
+	req *pb.ListAllDocumentsRequest,
+) (*pb.ListAllDocumentsReply, error) {
+	// the service starts from the beginning when there is no cursor from a previous page
+	var cursor *service.Cursor
+	var err error
+	if req.Cursor != nil && req.Cursor.LastSeenTime != nil {
+		cursor, err = parseServiceCursor(req.Cursor, pb.Cursor_KIND_ALL_DOCUMENTS)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	pageSize := service.DefaultPageSize
+	if req.PageSize != nil {
+		pageSize = *req.PageSize
+	}
+	// parse the filters, a filter that is not set matches every document
+	var filters service.DocumentFilters
+	if req.OwnerId != nil {
+		ownerId, err := uuid.Parse(*req.OwnerId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to parse owner id as uuid: %v", *req.OwnerId)
+		}
+		filters.OwnerID = &ownerId
+	}
+	if req.CreatedBefore != nil {
+		if err := req.CreatedBefore.CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid created before time: %v", err)
+		}
+		createdBefore := req.CreatedBefore.AsTime()
+		filters.CreatedBefore = &createdBefore
+	}
+	if req.CreatedAfter != nil {
+		if err := req.CreatedAfter.CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid created after time: %v", err)
+		}
+		createdAfter := req.CreatedAfter.AsTime()
+		filters.CreatedAfter = &createdAfter
+	}
+	documents, responseCursor, hasMore, err := s.documentService.ListAllDocuments(ctx, cursor, pageSize, filters)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	pbDocuments := make([]*pb.Document, len(documents))
+	for i, document := range documents {
+		pbDocuments[i], err = serviceToPbDocument(document)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	pbRespCursor, err := serviceToPbCursor(*responseCursor, pb.Cursor_KIND_ALL_DOCUMENTS)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.ListAllDocumentsReply{
+		Documents: pbDocuments,
+		Cursor: pbRespCursor,
+		HasMore: hasMore,
+	}, nil
+}
+
+func (s *DocumentServiceServerImpl) CountDocumentsByPrincipalGrouped(
+	ctx context.Context,
+	req *pb.CountDocumentsByPrincipalGroupedRequest,
+) (*pb.CountDocumentsByPrincipalGroupedReply, error) {
+	// parse the principal id
+	principalId, err := uuid.Parse(req.PrincipalId)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "unable to parse principalId: %s as uuid", req.PrincipalId,
+		)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	counts, err := s.documentService.CountDocumentsByPrincipalGrouped(ctx, callerId, principalId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.CountDocumentsByPrincipalGroupedReply{
+		OwnerCount: counts[service.Owner],
+		EditorCount: counts[service.Editor],
+		ViewerCount: counts[service.Viewer],
+	}, nil
+}
+
+func (s *DocumentServiceServerImpl) GetPermissionsOfPrincipalOnDocument(
+	ctx context.Context,
+	req *pb.GetPermissionsRequest,
+) (*pb.GetPermissionsReply, error) {
+	// parse the documentID as a uuid
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "unable to parse document ID as a uuid: %v", req.DocumentId,
+		)
+	}
+	// parse the principalID as a uuid
+	principalId, err := uuid.Parse(req.PrincipalId)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "unable to parse principalId as a uuid: %v", req.PrincipalId,
+		)
+	}
+	// parse the id of the calling principal from the client context
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	permission, err := s.documentService.GetPermissionOfPrincipalOnDocument(
+		ctx, callerId, documentId, principalId, publicLinkVersion(req.GetClientContext()),
+	)
+	// return any error that I may have found
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	// serialize the permission object and return it
+	pbPermission, err := serviceToPbPermission(permission)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.GetPermissionsReply{
+		Permission: pbPermission,
+	}, nil
+}
+
+func (s *DocumentServiceServerImpl) GetPermissionLevelsForPrincipalOnDocuments(
+	ctx context.Context,
+	req *pb.GetPermissionLevelsRequest,
+) (*pb.GetPermissionLevelsReply, error) {
+	// parse the principal id
+	principalId, err := uuid.Parse(req.PrincipalId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse principal id as uuid: %v", req.PrincipalId)
+	}
+	// parse the id of the calling principal from the client context
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	// parse the document ids
+	documentIds := make(uuid.UUIDs, len(req.DocumentIds))
+	for i, documentId := range req.DocumentIds {
+		documentIds[i], err = uuid.Parse(documentId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id: %s", documentId)
+		}
+	}
+	levels, err := s.documentService.GetPermissionLevelsForPrincipalOnDocuments(
+		ctx, callerId, principalId, documentIds,
+	)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	pbLevels := make(map[string]pb.PermissionLevel, len(levels))
+	for documentId, level := range levels {
+		pbLevels[documentId.String()], err = serviceToPbPermissionLevel(level)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return &pb.GetPermissionLevelsReply{ PermissionLevels: pbLevels }, nil
+}
+
+func (s *DocumentServiceServerImpl) CheckAccess(
+	ctx context.Context,
+	req *pb.CheckAccessRequest,
+) (*pb.CheckAccessReply, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	// the access of the calling principal is checked
+	principalId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	action, err := pbToServiceAction(req.Action)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	allowed, err := s.documentService.CheckAccess(
+		ctx, documentId, principalId, publicLinkVersion(req.GetClientContext()), action,
+	)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.CheckAccessReply{ Allowed: allowed }, nil
+}
+
+func (s *DocumentServiceServerImpl) ListPermissionsOnDocument(
+	ctx context.Context, 
+	req *pb.ListPermissionsOnDocumentRequest,
+) (*pb.ListPermissionsOnDocumentReply, error) {
+	// parse the documentID
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
 			"failed to parse documentID as a uuid: %v",
 			req.DocumentId,
 		)
@@ -505,11 +1270,14 @@ func (s *DocumentServiceServerImpl) ListPermissionsOnDocument(
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-	// construct the cursor
-	// parse the sort field
-	cursor, err := parseServiceCursor(req.Cursor)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+	// construct the cursor, the service starts from the beginning when there is no cursor from a
+	// previous page. A cursor without a last seen time still picks the sort field
+	var cursor *service.Cursor
+	if req.Cursor != nil {
+		cursor, err = parseServiceCursor(req.Cursor, pb.Cursor_KIND_PERMISSIONS_ON_DOCUMENT)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 	}
 	// optionally apply the default page size
 	var pageSize int32
@@ -518,12 +1286,26 @@ func (s *DocumentServiceServerImpl) ListPermissionsOnDocument(
 	} else {
 		pageSize = *req.PageSize
 	}
-	recipientPermissions, respCursor, err := s.documentService.ListPermissionsOnDocument(
+	// optionally parse the principal to leave out of the listing
+	var excludedPrincipalId *uuid.UUID
+	if req.ExcludedPrincipalId != nil {
+		parsed, err := uuid.Parse(*req.ExcludedPrincipalId)
+		if err != nil {
+			return nil, status.Errorf(
+				codes.InvalidArgument,
+				"failed to parse excludedPrincipalId as a uuid: %v",
+				*req.ExcludedPrincipalId,
+			)
+		}
+		excludedPrincipalId = &parsed
+	}
+	recipientPermissions, respCursor, hasMore, err := s.documentService.ListPermissionsOnDocument(
 		ctx,
 		documentId,
 		permissionFilter,
 		cursor,
 		pageSize,
+		excludedPrincipalId,
 	)
 	// conditionally return an error
 	if err != nil {
@@ -535,7 +1317,7 @@ func (s *DocumentServiceServerImpl) ListPermissionsOnDocument(
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	// serialize the response cursor to pb
-	pbRespCursor, err := serviceToPbCursor(*respCursor)
+	pbRespCursor, err := serviceToPbCursor(*respCursor, pb.Cursor_KIND_PERMISSIONS_ON_DOCUMENT)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -543,6 +1325,42 @@ func (s *DocumentServiceServerImpl) ListPermissionsOnDocument(
 	return &pb.ListPermissionsOnDocumentReply{
 		RecipientPermissions: pbRecipientPermissions,
 		Cursor: pbRespCursor,
+		HasMore: hasMore,
+	}, nil
+}
+
+func (s *DocumentServiceServerImpl) GetDocumentSharingSummary(
+	ctx context.Context,
+	req *pb.GetDocumentSharingSummaryRequest,
+) (*pb.GetDocumentSharingSummaryReply, error) {
+	// parse the document id
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	// parse the id of the calling principal from the client context
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	summary, err := s.documentService.GetDocumentSharingSummary(ctx, documentId, callerId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	pbOwner, err := serviceToPbPermission(summary.Owner)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	pbCollaborators, err := serviceToPbPermissionList(summary.Collaborators)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.GetDocumentSharingSummaryReply{
+		Owner: pbOwner,
+		Collaborators: pbCollaborators,
+		CollaboratorCount: summary.CollaboratorCount,
 	}, nil
 }
 
@@ -562,13 +1380,17 @@ func (s *DocumentServiceServerImpl) CreateGuest(
 			codes.InvalidArgument, "failed to parse user Id as uuid: %v", req.ClientContext.PrincipalId,
 		)
 	}
-	// parse the permission level
-	permissionLevel, err := pbToServicePermissionLevel(req.PermissionLevel)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+	// parse the permission level if it is present, the service decides the default otherwise
+	var permissionLevel *service.PermissionLevel
+	if req.PermissionLevel != nil {
+		parsedPermissionLevel, err := pbToServicePermissionLevel(req.GetPermissionLevel())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		permissionLevel = &parsedPermissionLevel
 	}
 	// call the relevant service function
-	guestId, err := s.documentService.CreateGuest(ctx, userId, documentId, permissionLevel)
+	guestId, err := s.documentService.CreateGuest(ctx, userId, documentId, permissionLevel, req.Label)
 	// return any error
 	if err != nil {
 		return nil, serviceToGRPCError(err)
@@ -579,10 +1401,46 @@ func (s *DocumentServiceServerImpl) CreateGuest(
 	}, nil
 }
 
+func (s *DocumentServiceServerImpl) CreateGuests(
+	ctx context.Context,
+	req *pb.CreateGuestsRequest,
+) (*pb.CreateGuestsReply, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling user id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	// parse the permission level if it is present, the service decides the default otherwise
+	var permissionLevel *service.PermissionLevel
+	if req.PermissionLevel != nil {
+		parsedPermissionLevel, err := pbToServicePermissionLevel(req.GetPermissionLevel())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		permissionLevel = &parsedPermissionLevel
+	}
+	guestIds, err := s.documentService.CreateGuests(ctx, callerId, documentId, req.Count, permissionLevel)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	pbGuestIds := make([]string, len(guestIds))
+	for i, guestId := range guestIds {
+		pbGuestIds[i] = guestId.String()
+	}
+	return &pb.CreateGuestsReply{
+		GuestIds: pbGuestIds,
+	}, nil
+}
+
 func (s *DocumentServiceServerImpl) UpsertPermissionUser(
 	ctx context.Context,
 	req *pb.UpsertPermissionUserRequest,
-) (*emptypb.Empty, error) {
+) (*pb.UpsertPermissionUserReply, error) {
 	// parse the user Id
 	userId, err := uuid.Parse(req.UserId)
 	if err != nil {
@@ -593,21 +1451,73 @@ func (s *DocumentServiceServerImpl) UpsertPermissionUser(
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
 	}
+	// parse the calling user id, the document service verifies that the caller holds at
+	// least the permission level that they are granting
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling user id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
 	// parse the permission level
 	permissionLevel, err := pbToServicePermissionLevel(req.PermissionLevel)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 	// call the relevant service function
-	err = s.documentService.UpsertPermissionUser(
-		ctx, userId, documentId, permissionLevel,
+	created, err := s.documentService.UpsertPermissionUser(
+		ctx, callerId, userId, documentId, permissionLevel,
 	)
 	// return any relevant errors
 	if err != nil {
 		return nil, serviceToGRPCError(err)
 	}
-	// return empty proto
-	return &emptypb.Empty{}, nil
+	// report whether the permission was created or an existing permission was updated
+	return &pb.UpsertPermissionUserReply{
+		Created: created,
+	}, nil
+}
+
+func (s *DocumentServiceServerImpl) UpsertPermissionsUser(
+	ctx context.Context,
+	req *pb.UpsertPermissionsUserRequest,
+) (*pb.UpsertPermissionsUserReply, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling user id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	shares := make([]service.UserShare, len(req.Shares))
+	for i, share := range req.Shares {
+		userId, err := uuid.Parse(share.GetUserId())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to parse user id as uuid: %v", share.GetUserId())
+		}
+		permissionLevel, err := pbToServicePermissionLevel(share.GetPermissionLevel())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		shares[i] = service.UserShare{ UserID: userId, PermissionLevel: permissionLevel }
+	}
+	results, err := s.documentService.UpsertPermissionsUser(ctx, callerId, documentId, shares)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	reply := &pb.UpsertPermissionsUserReply{
+		Results: make([]*pb.UpsertPermissionsUserReply_Result, len(results)),
+	}
+	for i, result := range results {
+		reply.Results[i] = &pb.UpsertPermissionsUserReply_Result{
+			UserId: result.UserID.String(),
+			Created: result.Created,
+		}
+	}
+	return reply, nil
 }
 
 func (s *DocumentServiceServerImpl) UpdatePermissionGuest(
@@ -634,6 +1544,162 @@ func (s *DocumentServiceServerImpl) UpdatePermissionGuest(
 	return &emptypb.Empty{}, nil
 }
 
+func (s *DocumentServiceServerImpl) SchedulePermissionDowngrade(
+	ctx context.Context,
+	req *pb.SchedulePermissionDowngradeRequest,
+) (*emptypb.Empty, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	principalId, err := uuid.Parse(req.PrincipalId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse principal id as uuid: %v", req.PrincipalId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	downgradeTo, err := pbToServicePermissionLevel(req.DowngradeTo)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.DowngradeAt == nil {
+		return nil, status.Error(codes.InvalidArgument, "the downgrade time is required")
+	}
+	err = s.documentService.SchedulePermissionDowngrade(
+		ctx, callerId, documentId, principalId, downgradeTo, req.DowngradeAt.AsTime(),
+	)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) UpdateGuestLabel(
+	ctx context.Context,
+	req *pb.UpdateGuestLabelRequest,
+) (*emptypb.Empty, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	guestId, err := uuid.Parse(req.GuestId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse guestId as uuid: %v", req.GuestId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling user id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	err = s.documentService.UpdateGuestLabel(ctx, callerId, documentId, guestId, req.Label)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) RotateGuestLink(
+	ctx context.Context,
+	req *pb.RotateGuestLinkRequest,
+) (*pb.RotateGuestLinkReply, error) {
+	guestId, err := uuid.Parse(req.GuestId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse guestId as uuid: %v", req.GuestId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling user id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	tokenVersion, err := s.documentService.RotateGuestLink(ctx, guestId, callerId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.RotateGuestLinkReply{ TokenVersion: tokenVersion }, nil
+}
+
+func (s *DocumentServiceServerImpl) GetGuestTokenVersion(
+	ctx context.Context,
+	req *pb.GetGuestTokenVersionRequest,
+) (*pb.GetGuestTokenVersionReply, error) {
+	guestId, err := uuid.Parse(req.GuestId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse guestId as uuid: %v", req.GuestId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	tokenVersion, err := s.documentService.GetGuestTokenVersion(ctx, callerId, guestId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.GetGuestTokenVersionReply{ TokenVersion: tokenVersion }, nil
+}
+
+func (s *DocumentServiceServerImpl) WatchDocumentPermissions(
+	req *pb.WatchDocumentPermissionsRequest,
+	stream pb.DocumentService_WatchDocumentPermissionsServer,
+) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	stopOnShutdown := context.AfterFunc(s.shutdown, cancel)
+	defer stopOnShutdown()
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to parse documentId as uuid: %v", req.DocumentId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return status.Errorf(
+			codes.InvalidArgument, "failed to parse calling user id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	watcher, err := s.documentService.WatchDocumentPermissions(ctx, documentId, callerId)
+	if err != nil {
+		return serviceToGRPCError(err)
+	}
+	defer watcher.Close()
+	// the headers tell the caller that the watcher is listening, so a change made after the
+	// headers are received is never missed
+	if err = stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		event, err := watcher.Next(ctx)
+		if err != nil {
+			// the caller can watch again once it reaches a server that is not shutting down
+			if s.shutdown.Err() != nil {
+				return status.Error(codes.Unavailable, "the server is shutting down")
+			}
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return serviceToGRPCError(err)
+		}
+		pbEvent, err := serviceToPbPermissionEvent(event)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to serialize permission event: %v", err)
+		}
+		if err = stream.Send(pbEvent); err != nil {
+			return err
+		}
+		// the owner permission was transferred or removed, for example because the document was
+		// deleted, so the caller is no longer allowed to watch the document
+		if event.RecipientID == callerId && (event.Change == service.PermissionRemoved || event.PermissionLevel != service.Owner) {
+			return nil
+		}
+	}
+}
+
 func (s *DocumentServiceServerImpl) DeletePermissionsPrincipal(
 	ctx context.Context,
 	req *pb.DeletePermissionsPrincipalRequest,
@@ -656,4 +1722,232 @@ func (s *DocumentServiceServerImpl) DeletePermissionsPrincipal(
 	}
 	// return an empty response
 	return &emptypb.Empty{}, nil
-}
\ No newline at end of file
+}
+
+func (s *DocumentServiceServerImpl) LeaveDocument(
+	ctx context.Context,
+	req *pb.LeaveDocumentRequest,
+) (*emptypb.Empty, error) {
+	// parse the document id
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	// parse the id of the calling principal from the client context
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	err = s.documentService.LeaveDocument(ctx, callerId, documentId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) InviteUser(
+	ctx context.Context,
+	req *pb.InviteUserRequest,
+) (*emptypb.Empty, error) {
+	userId, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse user Id as uuid: %v", req.UserId)
+	}
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	callerId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling user id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	permissionLevel, err := pbToServicePermissionLevel(req.PermissionLevel)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = s.documentService.InviteUser(ctx, callerId, userId, documentId, permissionLevel)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) ListPendingShares(
+	ctx context.Context,
+	req *pb.ListPendingSharesRequest,
+) (*pb.ListPendingSharesReply, error) {
+	principalId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	pendingShares, err := s.documentService.ListPendingShares(ctx, principalId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	pbPendingShares := make([]*pb.ListPendingSharesReply_PendingShare, len(pendingShares))
+	for i, pendingShare := range pendingShares {
+		document, err := serviceToPbDocument(pendingShare.Document)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		permissionLevel, err := serviceToPbPermissionLevel(pendingShare.Permission)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		pbPendingShares[i] = &pb.ListPendingSharesReply_PendingShare{
+			Document: document,
+			PermissionLevel: permissionLevel,
+			SharedBy: pendingShare.SharedBy.String(),
+			SharedAt: timestamppb.New(pendingShare.SharedAt),
+		}
+	}
+	return &pb.ListPendingSharesReply{ PendingShares: pbPendingShares }, nil
+}
+
+func (s *DocumentServiceServerImpl) AcceptPendingShare(
+	ctx context.Context,
+	req *pb.AcceptPendingShareRequest,
+) (*emptypb.Empty, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	principalId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	err = s.documentService.AcceptPendingShare(ctx, principalId, documentId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) StarDocument(
+	ctx context.Context,
+	req *pb.StarDocumentRequest,
+) (*emptypb.Empty, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	principalId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	err = s.documentService.StarDocument(ctx, principalId, documentId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) UnstarDocument(
+	ctx context.Context,
+	req *pb.UnstarDocumentRequest,
+) (*emptypb.Empty, error) {
+	documentId, err := uuid.Parse(req.DocumentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse document id as uuid: %v", req.DocumentId)
+	}
+	principalId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	err = s.documentService.UnstarDocument(ctx, principalId, documentId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) CreateCollection(
+	ctx context.Context,
+	req *pb.CreateCollectionRequest,
+) (*pb.Collection, error) {
+	principalId, err := uuid.Parse(req.GetClientContext().GetPrincipalId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", req.GetClientContext().GetPrincipalId(),
+		)
+	}
+	collection, err := s.documentService.CreateCollection(ctx, principalId, req.Name)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return serviceToPbCollection(*collection), nil
+}
+
+// parse the ids of the collection, the document and the calling principal of a request that
+// changes the documents in a collection
+func parseCollectionMembershipIds(
+	collectionIdString string,
+	documentIdString string,
+	clientContext *pb.ClientContext,
+) (collectionId uuid.UUID, documentId uuid.UUID, principalId uuid.UUID, err error) {
+	collectionId, err = uuid.Parse(collectionIdString)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse collection id as uuid: %v", collectionIdString,
+		)
+	}
+	documentId, err = uuid.Parse(documentIdString)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse document id as uuid: %v", documentIdString,
+		)
+	}
+	principalId, err = uuid.Parse(clientContext.GetPrincipalId())
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, status.Errorf(
+			codes.InvalidArgument, "failed to parse calling principal id as uuid: %v", clientContext.GetPrincipalId(),
+		)
+	}
+	return collectionId, documentId, principalId, nil
+}
+
+func (s *DocumentServiceServerImpl) AddToCollection(
+	ctx context.Context,
+	req *pb.AddToCollectionRequest,
+) (*emptypb.Empty, error) {
+	collectionId, documentId, principalId, err := parseCollectionMembershipIds(
+		req.CollectionId, req.DocumentId, req.GetClientContext(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = s.documentService.AddToCollection(ctx, principalId, collectionId, documentId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DocumentServiceServerImpl) RemoveFromCollection(
+	ctx context.Context,
+	req *pb.RemoveFromCollectionRequest,
+) (*emptypb.Empty, error) {
+	collectionId, documentId, principalId, err := parseCollectionMembershipIds(
+		req.CollectionId, req.DocumentId, req.GetClientContext(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = s.documentService.RemoveFromCollection(ctx, principalId, collectionId, documentId)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,462 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/townsag/reed/document_service/api/v1"
+	"github.com/townsag/reed/document_service/internal/service"
+	"github.com/townsag/reed/document_service/pkg/client"
+)
+
+func TestPbToServicePermissionLevel_Unspecified_Unit(t *testing.T) {
+	if _, err := pbToServicePermissionLevel(pb.PermissionLevel_PERMISSION_UNSPECIFIED); err == nil {
+		t.Error("want an error for an unspecified permission level")
+	}
+	if _, err := pbToServicePermissionLevelList(
+		[]pb.PermissionLevel{ pb.PermissionLevel_PERMISSION_VIEWER, pb.PermissionLevel_PERMISSION_UNSPECIFIED },
+	); err == nil {
+		t.Error("want an error for a permission filter with an unspecified permission level")
+	}
+}
+
+func TestServiceToGRPCError_Gone_Unit(t *testing.T) {
+	// only the failed precondition of an archived document carries the archived reason
+	err := serviceToGRPCError(service.Gone("the document is archived", nil))
+	if status.Code(err) != codes.FailedPrecondition || !client.IsDocumentArchived(err) {
+		t.Errorf("want a failed precondition error with the archived reason, got: %v", err)
+	}
+	if client.IsDocumentArchived(status.Error(codes.FailedPrecondition, "some other precondition")) {
+		t.Error("want a failed precondition error without the archived reason not to be reported as archived")
+	}
+}
+
+func TestServiceToGRPCError_QuotaExceeded_Unit(t *testing.T) {
+	// a quota carries its reason so that it is not mistaken for a rate limit
+	err := serviceToGRPCError(service.QuotaExceeded("the document has too many guests", nil))
+	if status.Code(err) != codes.ResourceExhausted || !client.IsQuotaExceeded(err) {
+		t.Errorf("want a resource exhausted error with the quota reason, got: %v", err)
+	}
+	if client.IsQuotaExceeded(status.Error(codes.ResourceExhausted, "rate limit exceeded")) {
+		t.Error("want a resource exhausted error without the quota reason not to be reported as a quota")
+	}
+}
+
+func TestPermissionLevel_WireNumbers_Unit(t *testing.T) {
+	// clients built before the unspecified value was added send these numbers
+	want := map[pb.PermissionLevel]int32{
+		pb.PermissionLevel_PERMISSION_VIEWER: 0,
+		pb.PermissionLevel_PERMISSION_EDITOR: 1,
+		pb.PermissionLevel_PERMISSION_OWNER: 2,
+	}
+	for level, number := range want {
+		if int32(level) != number {
+			t.Errorf("want permission level: %v at number: %d, got: %d", level, number, int32(level))
+		}
+	}
+}
+
+func TestServiceToPbPermissionLevel_Unknown_Unit(t *testing.T) {
+	level, err := serviceToPbPermissionLevel(service.PermissionLevel(-1))
+	if err == nil {
+		t.Errorf("want an error for an unknown permission level, got: %v", level)
+	}
+	// an unknown level is never sent as a value that is not in the proto enum
+	if level != pb.PermissionLevel_PERMISSION_UNSPECIFIED {
+		t.Errorf("want: %v for an unknown permission level, got: %v", pb.PermissionLevel_PERMISSION_UNSPECIFIED, level)
+	}
+}
+
+func TestPermissionLevel_RoundTrip_Unit(t *testing.T) {
+	for _, level := range service.AllPermissions {
+		pbLevel, err := serviceToPbPermissionLevel(level)
+		if err != nil {
+			t.Fatalf("failed to convert permission level: %v with error: %v", level, err)
+		}
+		if pbLevel == pb.PermissionLevel_PERMISSION_UNSPECIFIED {
+			t.Errorf("permission level: %v was converted to the unspecified value", level)
+		}
+		got, err := pbToServicePermissionLevel(pbLevel)
+		if err != nil || got != level {
+			t.Errorf("want permission level: %v after a round trip, got: %v with error: %v", level, got, err)
+		}
+	}
+}
+
+func TestParseServiceCursor_ZeroTime_Unit(t *testing.T) {
+	// both the zero time of go and the unix epoch are before the earliest accepted time
+	for _, lastSeenTime := range []time.Time{ {}, time.Unix(0, 0) } {
+		_, err := parseServiceCursor(&pb.Cursor{
+			LastSeenTime: timestamppb.New(lastSeenTime), Kind: pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL,
+		}, pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL)
+		if err == nil {
+			t.Errorf("want an error for a cursor with time: %v", lastSeenTime)
+		}
+	}
+}
+
+func TestParseServiceCursor_FarFuture_Unit(t *testing.T) {
+	before := time.Now()
+	cursor, err := parseServiceCursor(&pb.Cursor{
+		LastSeenTime: timestamppb.New(before.AddDate(100, 0, 0)),
+		Kind: pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL,
+	}, pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL)
+	if err != nil {
+		t.Fatalf("want a far future cursor time to be clamped, got error: %v", err)
+	}
+	if cursor.LastSeenTime.Before(before) || cursor.LastSeenTime.After(time.Now()) {
+		t.Errorf("want the cursor time clamped to the current time, got: %v", cursor.LastSeenTime)
+	}
+	// a time outside of the range of a timestamp is rejected
+	_, err = parseServiceCursor(&pb.Cursor{
+		LastSeenTime: &timestamppb.Timestamp{ Seconds: 1 << 62 }, Kind: pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL,
+	}, pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL)
+	if err == nil {
+		t.Error("want an error for a cursor time outside of the range of a timestamp")
+	}
+}
+
+func TestParseServiceCursor_Normal_Unit(t *testing.T) {
+	lastSeenTime := time.Now().Add(-time.Hour).UTC()
+	lastSeenId := uuid.New()
+	lastSeenIdString := lastSeenId.String()
+	cursor, err := parseServiceCursor(&pb.Cursor{
+		SortField: pb.Cursor_SORT_FIELD_LAST_MODIFIED_AT,
+		LastSeenTime: timestamppb.New(lastSeenTime),
+		LastSeenDocumentId: &lastSeenIdString,
+		Kind: pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL,
+	}, pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL)
+	if err != nil {
+		t.Fatalf("failed to parse cursor with error: %v", err)
+	}
+	if cursor.SortField != service.LastModifiedAt || !cursor.LastSeenTime.Equal(lastSeenTime) || cursor.LastSeenID != lastSeenId {
+		t.Errorf(
+			"want cursor: %v, %v, %s, got: %v, %v, %s",
+			service.LastModifiedAt, lastSeenTime, lastSeenId,
+			cursor.SortField, cursor.LastSeenTime, cursor.LastSeenID,
+		)
+	}
+}
+
+func TestParseServiceCursor_KindMismatch_Unit(t *testing.T) {
+	documentsCursor, err := serviceToPbCursor(
+		service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now().Add(-time.Hour), LastSeenID: uuid.New() },
+		pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL,
+	)
+	if err != nil {
+		t.Fatalf("failed to convert cursor with error: %v", err)
+	}
+	// the cursor is read by the list that handed it out
+	if _, err = parseServiceCursor(documentsCursor, pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL); err != nil {
+		t.Errorf("failed to parse a cursor on the list that handed it out with error: %v", err)
+	}
+	_, err = parseServiceCursor(documentsCursor, pb.Cursor_KIND_PERMISSIONS_ON_DOCUMENT)
+	var invalidError *service.InvalidInputError
+	if !errors.As(err, &invalidError) {
+		t.Errorf("want an invalid input error for a documents cursor on the permissions list, got: %v", err)
+	}
+	// clearing the kind does not get a cursor past the check, a cursor without a kind is
+	// rejected by every list
+	documentsCursor.Kind = pb.Cursor_KIND_UNSPECIFIED
+	for _, kind := range []pb.Cursor_Kind{ pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL, pb.Cursor_KIND_PERMISSIONS_ON_DOCUMENT } {
+		_, err = parseServiceCursor(documentsCursor, kind)
+		if !errors.As(err, &invalidError) {
+			t.Errorf("want an invalid input error for a cursor without a kind on list: %v, got: %v", kind, err)
+		}
+	}
+}
+
+func TestListPermissionsOnDocument_DocumentsCursor_Unit(t *testing.T) {
+	documentsCursor, err := serviceToPbCursor(
+		service.Cursor{ SortField: service.CreatedAt, LastSeenTime: time.Now().Add(-time.Hour), LastSeenID: uuid.New() },
+		pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL,
+	)
+	if err != nil {
+		t.Fatalf("failed to convert cursor with error: %v", err)
+	}
+	// the document service is nil so the test panics if the cursor reaches the service
+	server := &DocumentServiceServerImpl{}
+	_, err = server.ListPermissionsOnDocument(t.Context(), &pb.ListPermissionsOnDocumentRequest{
+		DocumentId: uuid.NewString(),
+		PermissionsFilter: []pb.PermissionLevel{ pb.PermissionLevel_PERMISSION_VIEWER },
+		Cursor: documentsCursor,
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("want code: %v for a documents cursor on the permissions list, got: %v", codes.InvalidArgument, err)
+	}
+}
+
+// a repository that pages through a fixed set of documents and permissions, newest first, the
+// way the postgres repository seeks past the cursor
+type pagingRepository struct {
+	service.DocumentRepository
+	documents []service.DocumentPermission
+	permissions []service.Permission
+}
+
+func newPagingRepository(count int) *pagingRepository {
+	repo := &pagingRepository{}
+	start := time.Now().Add(-time.Hour)
+	documentId := uuid.New()
+	for i := range count {
+		createdAt := start.Add(-time.Duration(i) * time.Minute)
+		repo.documents = append(repo.documents, service.DocumentPermission{
+			Document: service.Document{ ID: uuid.New(), CreatedAt: createdAt, LastModifiedAt: createdAt },
+			Permission: service.Viewer,
+			PermissionCreatedAt: createdAt,
+			PermissionLastModifiedAt: createdAt,
+		})
+		repo.permissions = append(repo.permissions, service.Permission{
+			RecipientID: uuid.New(),
+			RecipientType: service.User,
+			DocumentID: documentId,
+			PermissionLevel: service.Viewer,
+			CreatedBy: uuid.New(),
+			CreatedAt: createdAt,
+			LastModifiedAt: createdAt,
+		})
+	}
+	return repo
+}
+
+// the items are newest first, an item is after the cursor when it is older than the last seen
+// time, or as old and with a smaller id
+func afterCursor(cursor *service.Cursor, itemTime time.Time, itemId uuid.UUID) bool {
+	return itemTime.Before(cursor.LastSeenTime) ||
+		(itemTime.Equal(cursor.LastSeenTime) && bytes.Compare(itemId[:], cursor.LastSeenID[:]) < 0)
+}
+
+// the index of the first item after the cursor and the index after the last item of the page
+func pageBounds(cursor *service.Cursor, count int, pageSize int32, key func(i int) (time.Time, uuid.UUID)) (int, int) {
+	first := count
+	for i := range count {
+		if itemTime, itemId := key(i); afterCursor(cursor, itemTime, itemId) {
+			first = i
+			break
+		}
+	}
+	return first, min(first + int(pageSize), count)
+}
+
+func (r *pagingRepository) ListDocumentsByPrincipal(
+	ctx context.Context,
+	principalId uuid.UUID,
+	permissions []service.PermissionLevel,
+	favoritesOnly bool,
+	collectionId *uuid.UUID,
+	includeArchived bool,
+	cursor *service.Cursor,
+	pageSize int32,
+) ([]service.DocumentPermission, *service.Cursor, bool, error) {
+	first, end := pageBounds(cursor, len(r.documents), pageSize, func(i int) (time.Time, uuid.UUID) {
+		return r.documents[i].Document.CreatedAt, r.documents[i].Document.ID
+	})
+	page := r.documents[first:end]
+	respCursor := &service.Cursor{ SortField: cursor.SortField }
+	if len(page) > 0 {
+		respCursor.LastSeenTime = page[len(page) - 1].Document.CreatedAt
+		respCursor.LastSeenID = page[len(page) - 1].Document.ID
+	}
+	return page, respCursor, end < len(r.documents), nil
+}
+
+func (r *pagingRepository) ListPermissionsOnDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	permissions []service.PermissionLevel,
+	cursor *service.Cursor,
+	pageSize int32,
+	excludedRecipientId *uuid.UUID,
+) ([]service.Permission, *service.Cursor, bool, error) {
+	first, end := pageBounds(cursor, len(r.permissions), pageSize, func(i int) (time.Time, uuid.UUID) {
+		return r.permissions[i].CreatedAt, r.permissions[i].RecipientID
+	})
+	page := r.permissions[first:end]
+	respCursor := &service.Cursor{ SortField: cursor.SortField }
+	if len(page) > 0 {
+		respCursor.LastSeenTime = page[len(page) - 1].CreatedAt
+		respCursor.LastSeenID = page[len(page) - 1].RecipientID
+	}
+	return page, respCursor, end < len(r.permissions), nil
+}
+
+func TestListWithoutCursor_FirstPage_Unit(t *testing.T) {
+	// the gateway sends no cursor for the first page of every list
+	repo := newPagingRepository(3)
+	documentService := service.NewDocumentService(repo)
+	t.Cleanup(documentService.StopAccessWrites)
+	server := NewDocumentServiceImpl(documentService)
+	pageSize := int32(2)
+	documentsReply, err := server.ListDocumentsByPrincipal(t.Context(), &pb.ListDocumentByPrincipalRequest{
+		PrincipalId: uuid.NewString(),
+		PageSize: &pageSize,
+	})
+	if err != nil {
+		t.Fatalf("failed to list documents without a cursor with error: %v", err)
+	}
+	if len(documentsReply.DocumentPermissions) != 2 || !documentsReply.HasMore ||
+		documentsReply.DocumentPermissions[0].Document.DocumentId != repo.documents[0].Document.ID.String() {
+		t.Errorf("want the first page of 2 documents with more to come, got: %v", documentsReply)
+	}
+	if documentsReply.Cursor.GetKind() != pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL {
+		t.Errorf("want a cursor of kind: %v, got: %v", pb.Cursor_KIND_DOCUMENTS_BY_PRINCIPAL, documentsReply.Cursor.GetKind())
+	}
+	permissionsReply, err := server.ListPermissionsOnDocument(t.Context(), &pb.ListPermissionsOnDocumentRequest{
+		DocumentId: uuid.NewString(),
+		PageSize: &pageSize,
+	})
+	if err != nil {
+		t.Fatalf("failed to list permissions without a cursor with error: %v", err)
+	}
+	if len(permissionsReply.RecipientPermissions) != 2 || !permissionsReply.HasMore ||
+		permissionsReply.RecipientPermissions[0].Recipient.PrincipalId != repo.permissions[0].RecipientID.String() {
+		t.Errorf("want the first page of 2 permissions with more to come, got: %v", permissionsReply)
+	}
+	if permissionsReply.Cursor.GetKind() != pb.Cursor_KIND_PERMISSIONS_ON_DOCUMENT {
+		t.Errorf("want a cursor of kind: %v, got: %v", pb.Cursor_KIND_PERMISSIONS_ON_DOCUMENT, permissionsReply.Cursor.GetKind())
+	}
+}
+
+// serve the repository through the real server and connect a client to it, so that the cursors
+// the client sends back are parsed by the server
+func newPagingClient(t *testing.T, repo *pagingRepository) *client.DocumentServiceClient {
+	t.Helper()
+	documentService := service.NewDocumentService(repo)
+	t.Cleanup(documentService.StopAccessWrites)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen with error: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterDocumentServiceServer(grpcServer, NewDocumentServiceImpl(documentService))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	documentClient, err := client.NewDocumentServiceClient(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to create a client with error: %v", err)
+	}
+	t.Cleanup(func() { documentClient.Close() })
+	return documentClient
+}
+
+func TestIterateDocumentsByPrincipal_ThroughServer_Unit(t *testing.T) {
+	repo := newPagingRepository(7)
+	documentClient := newPagingClient(t, repo)
+	pageSize := int32(3)
+	principalId := uuid.New()
+	var got []string
+	for documentPermission, err := range documentClient.IterateDocumentsByPrincipal(
+		t.Context(), principalId, principalId, nil, nil, &pageSize,
+	) {
+		if err != nil {
+			t.Fatalf("failed to iterate documents with error: %v", err)
+		}
+		got = append(got, documentPermission.Document.DocumentId)
+	}
+	if len(got) != len(repo.documents) {
+		t.Fatalf("want %d documents, got: %d", len(repo.documents), len(got))
+	}
+	for i, documentPermission := range repo.documents {
+		if got[i] != documentPermission.Document.ID.String() {
+			t.Errorf("want document: %s at position %d, got: %s", documentPermission.Document.ID, i, got[i])
+		}
+	}
+}
+
+func TestIteratePermissionsOnDocument_ThroughServer_Unit(t *testing.T) {
+	repo := newPagingRepository(7)
+	documentClient := newPagingClient(t, repo)
+	pageSize := int32(3)
+	var got []string
+	for permission, err := range documentClient.IteratePermissionsOnDocument(
+		t.Context(), uuid.New(), uuid.New(), nil, nil, &pageSize,
+	) {
+		if err != nil {
+			t.Fatalf("failed to iterate permissions with error: %v", err)
+		}
+		got = append(got, permission.Recipient.PrincipalId)
+	}
+	if len(got) != len(repo.permissions) {
+		t.Fatalf("want %d permissions, got: %d", len(repo.permissions), len(got))
+	}
+	for i, permission := range repo.permissions {
+		if got[i] != permission.RecipientID.String() {
+			t.Errorf("want recipient: %s at position %d, got: %s", permission.RecipientID, i, got[i])
+		}
+	}
+}
+
+// a repository where every caller owns every document and the watchers wait until they are
+// stopped, no change is ever made
+type idleWatchRepository struct {
+	service.DocumentRepository
+}
+
+type idleWatcher struct{}
+
+func (w idleWatcher) Next(ctx context.Context) (service.PermissionEvent, error) {
+	<-ctx.Done()
+	return service.PermissionEvent{}, ctx.Err()
+}
+
+func (w idleWatcher) Close() {}
+
+func (r *idleWatchRepository) GetPermissionLevel(
+	ctx context.Context, documentId uuid.UUID, principalId uuid.UUID,
+) (service.PermissionLevel, bool, error) {
+	return service.Owner, true, nil
+}
+
+func (r *idleWatchRepository) WatchDocumentPermissions(
+	ctx context.Context, documentId uuid.UUID,
+) (service.PermissionWatcher, error) {
+	return idleWatcher{}, nil
+}
+
+func TestStopWatches_GracefulStopReturns_Unit(t *testing.T) {
+	documentService := service.NewDocumentService(&idleWatchRepository{})
+	t.Cleanup(documentService.StopAccessWrites)
+	documentServer := NewDocumentServiceImpl(documentService)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen with error: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterDocumentServiceServer(grpcServer, documentServer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	documentClient, err := client.NewDocumentServiceClient(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to create a client with error: %v", err)
+	}
+	t.Cleanup(func() { documentClient.Close() })
+	stream, err := documentClient.WatchDocumentPermissions(t.Context(), uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatalf("failed to watch the permissions of the document with error: %v", err)
+	}
+	// the watch is open and the caller never cancels it
+	documentServer.StopWatches()
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the graceful stop to return once the watches are stopped")
+	}
+	if _, err = stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("want: %v for a watch that was stopped by the shutdown, got: %v", codes.Unavailable, err)
+	}
+}
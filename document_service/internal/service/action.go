@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+)
+
+// the actions that a principal can take on a document, each action requires a minimum
+// permission level. This is the one place that maps permission levels to actions so that
+// clients can ask whether an action is allowed instead of mapping the levels themselves
+type Action int32
+const (
+	View Action = iota
+	Edit
+	Manage
+	Delete
+)
+
+var AllActions []Action = []Action{
+	View, Edit, Manage, Delete,
+}
+
+var actionNames = map[Action]string{
+	View: "view",
+	Edit: "edit",
+	Manage: "manage",
+	Delete: "delete",
+}
+
+// the lowest permission level that is allowed to take each action. Managing a document covers
+// sharing it and changing its public access
+var actionRequiredLevels = map[Action]PermissionLevel{
+	View: Viewer,
+	Edit: Editor,
+	Manage: Owner,
+	Delete: Owner,
+}
+
+// the actions that stay allowed once a document is archived. Editing and managing an archived
+// document are rejected with a gone error until it is restored, it can still be read and deleted
+var archivedActions = map[Action]struct{}{
+	View: {},
+	Delete: {},
+}
+
+func (a Action) String() string {
+	if name, ok := actionNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("Action(%d)", int32(a))
+}
+
+// reports whether a principal holding this permission level can take the action, an unknown
+// action is never permitted
+func (p PermissionLevel) Permits(action Action) bool {
+	required, ok := actionRequiredLevels[action]
+	if !ok {
+		return false
+	}
+	return p >= required
+}
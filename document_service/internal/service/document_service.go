@@ -2,8 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -34,6 +40,8 @@ type SortField int32
 const (
 	CreatedAt SortField = iota
 	LastModifiedAt
+	// the time the principal last read the document, only used by the recently accessed list
+	AccessedAt
 )
 
 type Document struct {
@@ -42,6 +50,26 @@ type Document struct {
 	Description *string
 	CreatedAt time.Time
 	LastModifiedAt time.Time
+	// nil while the document is active, set when the document has been archived
+	ArchivedAt *time.Time
+	// the permission level granted to holders of a public link, nil when the public link
+	// of the document is disabled
+	PublicAccess *PermissionLevel
+	// public link tokens are only honoured at the current version, the version is bumped each
+	// time the public link is disabled
+	PublicLinkVersion int32
+}
+
+// true when the public link of the document is enabled and a public link issued at the version
+// is still valid. A nil version means that the caller did not present a public link
+func (d *Document) honoursPublicLink(publicLinkVersion *int32) bool {
+	return publicLinkVersion != nil && d.PublicAccess != nil && *publicLinkVersion == d.PublicLinkVersion
+}
+
+// what is left of a document after it has been deleted
+type DocumentTombstone struct {
+	DocumentID uuid.UUID
+	DeletedAt time.Time
 }
 
 type Permission struct {
@@ -52,6 +80,14 @@ type Permission struct {
 	CreatedBy uuid.UUID
 	CreatedAt time.Time
 	LastModifiedAt time.Time
+	// the label of a guest, nil for users and for guests without a label
+	Label *string
+	// a pending share grants no access until the recipient accepts it
+	Pending bool
+	// the level that the permission is lowered to at downgrade at, both are nil when no
+	// downgrade is scheduled
+	DowngradeTo *PermissionLevel
+	DowngradeAt *time.Time
 }
 
 type Cursor struct {
@@ -60,12 +96,198 @@ type Cursor struct {
 	LastSeenID uuid.UUID
 }
 
+const DefaultGuestPermissionLevel PermissionLevel = Viewer
+
+// the longest label that a guest can have, in characters
+const MaxGuestLabelLength = 100
+
+// the longest name that a collection can have, in characters
+const MaxCollectionNameLength = 100
+
+// the most guests that can be created in one call to CreateGuests when the limit is not
+// configured
+const DefaultMaxGuestBatchSize int32 = 20
+
+// the most guests that a document can have at once when the limit is not configured
+const DefaultMaxGuestsPerDocument int32 = 100
+
+// the permission levels that a guest can be given when the policy is not configured, guests
+// can never be owners. This returns a new slice on every call so that a caller cannot change the
+// default policy of every other service by writing to it
+func DefaultGuestPermissions() []PermissionLevel {
+	return []PermissionLevel{ Viewer, Editor }
+}
+
+// the permission levels held by the principals that a document has been shared with
+var CollaboratorPermissions = []PermissionLevel{ Viewer, Editor }
+
+// the number of collaborators included in the preview of a sharing summary
+const SharingSummaryPreviewSize int32 = 5
+
+type SharingSummary struct {
+	Owner Permission
+	// the most recently modified collaborator permissions, at most SharingSummaryPreviewSize
+	Collaborators []Permission
+	// the number of principals that the document has been shared with, the owner is not
+	// counted as a collaborator
+	CollaboratorCount int64
+}
+
 const DefaultPageSize int32 = 10
 const MaxPageSize int32 = 100
 
+// the number of documents that are moved to a new owner in each transaction
+const ReassignBatchSize int32 = 100
+
+// the number of due downgrades that are applied in each transaction
+const DefaultDowngradeBatchSize int32 = 100
+
+// the reason recorded in the permission audit for a downgrade applied by the downgrade job
+const ScheduledDowngradeReason = "scheduled downgrade"
+
+// the most documents that the permission levels of a principal can be read on in one request,
+// and the most principals that the permission levels on a document can be read for
+const MaxPermissionLevelBatchSize = 100
+
+// the most users that a document can be shared with in one request
+const MaxShareBatchSize = 100
+
+// the most pending shares that are listed for a principal, newest first
+const MaxPendingShares int32 = 100
+
+// how long after a document is deleted that reading it returns a tombstone instead of not found
+const TombstoneRetention = 30 * 24 * time.Hour
+
+// how long recording that a principal read a document can take before it is abandoned
+const AccessWriteTimeout = 5 * time.Second
+
+// the most accesses that can wait to be recorded, an access that arrives when the queue is full is
+// dropped so that a slow database never backs up into the reads
+const AccessQueueSize = 1024
+
+// the longest window that the viewers of a document can be read for, and the most viewers that
+// are listed. Viewers are for showing who is looking at a document now, not an audit of reads
+const MaxDocumentViewersWindow = 24 * time.Hour
+const MaxDocumentViewers int32 = 100
+
 type DocumentPermission struct {
 	Document Document
 	Permission PermissionLevel
+	// when the permission was granted to the principal and when it was last changed
+	PermissionCreatedAt time.Time
+	PermissionLastModifiedAt time.Time
+}
+
+// a document that the principal has read and the last time they read it
+type AccessedDocument struct {
+	Document Document
+	Permission PermissionLevel
+	AccessedAt time.Time
+}
+
+// the level to share a document with a user at in a batch share. Created is set in the result of
+// the share and is true when the user did not have a permission on the document before
+type UserShare struct {
+	UserID uuid.UUID
+	PermissionLevel PermissionLevel
+	Created bool
+}
+
+// a principal that read the document recently and the last time they read it
+type DocumentViewer struct {
+	PrincipalID uuid.UUID
+	RecipientType RecipientType
+	Permission PermissionLevel
+	ViewedAt time.Time
+}
+
+// a permission on a document that has been offered to a principal who has not accepted it yet
+type PendingShare struct {
+	Document Document
+	Permission PermissionLevel
+	// the principal that offered the share and when
+	SharedBy uuid.UUID
+	SharedAt time.Time
+}
+
+// an update of the name or description of a document. Both fields are recorded from before
+// and after the update, a field that was not changed has the same old and new value
+type DocumentChange struct {
+	ID uuid.UUID
+	DocumentID uuid.UUID
+	// the principal that made the update
+	ActorID uuid.UUID
+	OldName *string
+	NewName *string
+	OldDescription *string
+	NewDescription *string
+	ChangedAt time.Time
+}
+
+// a change to a permission that was not made by a principal, for example a scheduled downgrade
+// applied by the downgrade job
+type PermissionAuditEntry struct {
+	ID uuid.UUID
+	DocumentID uuid.UUID
+	RecipientID uuid.UUID
+	OldPermissionLevel PermissionLevel
+	NewPermissionLevel PermissionLevel
+	Reason string
+	ChangedAt time.Time
+}
+
+type PermissionChange int32
+const (
+	PermissionAdded PermissionChange = iota
+	PermissionUpdated
+	PermissionRemoved
+)
+
+// a change to a permission on a document as it is streamed to the watchers of the document. The
+// permission level is the level after the change, or the level before the change for a removal
+type PermissionEvent struct {
+	Change PermissionChange
+	DocumentID uuid.UUID
+	RecipientID uuid.UUID
+	RecipientType RecipientType
+	PermissionLevel PermissionLevel
+	Pending bool
+}
+
+// a subscription to the changes to the permissions on one document. Changes that are committed
+// after the subscription is opened are returned by Next in the order they were committed. The
+// caller must call Close once it is done with the subscription
+type PermissionWatcher interface {
+	// block until the next change or until the context is done
+	Next(ctx context.Context) (event PermissionEvent, err error)
+	Close()
+}
+
+// a document owned by the principal that has been shared with at least one collaborator
+type SharedDocument struct {
+	Document Document
+	// the number of principals the document is shared with, the owner is not counted
+	CollaboratorCount int64
+}
+
+// a named group of documents that a principal organizes their documents with, like a folder. A
+// collection does not grant access to the documents in it, the documents of a collection are
+// listed through the permissions of its owner
+type Collection struct {
+	ID uuid.UUID
+	OwnerID uuid.UUID
+	Name string
+	CreatedAt time.Time
+}
+
+// the filters of the admin list of every document, a nil filter matches every document
+type DocumentFilters struct {
+	// only documents that this principal owns
+	OwnerID *uuid.UUID
+	// only documents created before this time
+	CreatedBefore *time.Time
+	// only documents created at or after this time
+	CreatedAfter *time.Time
 }
 
 func MaxDocumentID() uuid.UUID {
@@ -84,6 +306,16 @@ func NewBeginningCursor(sortField SortField) *Cursor {
 	}
 }
 
+// the max id excludes documents that were last modified exactly at the since time, because
+// documents modified since the cursor are read in ascending order of id
+func NewSinceCursor(since time.Time) *Cursor {
+	return &Cursor{
+		SortField: LastModifiedAt,
+		LastSeenTime: since,
+		LastSeenID: MaxDocumentID(),
+	}
+}
+
 /*
 Open questions:
 - should the calling code or the repository be in charge of generating
@@ -96,30 +328,184 @@ Open questions:
 */
 
 type DocumentRepository interface {
-	CreateDocument(ctx context.Context, userId uuid.UUID, documentName *string, documentDescription *string) (documentId uuid.UUID, err error)
+	CreateDocument(ctx context.Context, userId uuid.UUID, documentName *string, documentDescription *string, clientDocumentId *uuid.UUID) (documentId uuid.UUID, err error)
 	GetDocument(ctx context.Context, documentId uuid.UUID) (document *Document, err error)
-	UpdateDocument(ctx context.Context, documentId uuid.UUID, documentName *string, documentDescription *string) (err error)
+	// the tombstone of a document that was deleted after deletedAfter, not found otherwise
+	GetDocumentTombstone(ctx context.Context, documentId uuid.UUID, principalId uuid.UUID, deletedAfter time.Time) (tombstone *DocumentTombstone, err error)
+	// delete the tombstones of documents deleted at or before deletedBefore
+	PruneDocumentTombstones(ctx context.Context, deletedBefore time.Time) (pruned int64, err error)
+	// the update appends a change to the history of the document in the same transaction
+	UpdateDocument(ctx context.Context, documentId uuid.UUID, actorId uuid.UUID, documentName *string, documentDescription *string, clearName bool, clearDescription bool) (err error)
+	// list the changes to the name and description of the document, newest first
+	ListDocumentHistory(ctx context.Context, documentId uuid.UUID, cursor *Cursor, pageSize int32) (changes []DocumentChange, cursorResp *Cursor, hasMore bool, err error)
 	DeleteDocument(ctx context.Context, documentId uuid.UUID) (err error)
+	// archived documents are soft deleted, mutations on an archived document return a gone error
+	ArchiveDocument(ctx context.Context, documentId uuid.UUID) (err error)
+	RestoreDocument(ctx context.Context, documentId uuid.UUID) (err error)
+	// a nil public access disables the public link of the document and bumps its version, the
+	// version after the change is returned
+	SetPublicAccess(ctx context.Context, documentId uuid.UUID, publicAccess *PermissionLevel) (publicLinkVersion int32, err error)
+	TouchDocument(ctx context.Context, documentId uuid.UUID) (err error)
 	DeleteDocuments(ctx context.Context, documentIds uuid.UUIDs, userId uuid.UUID) (err error)
-	// list the documents that are associated with that user at those permission levels
-	ListDocumentsByPrincipal(ctx context.Context, principalId uuid.UUID, permissions []PermissionLevel, cursor *Cursor, pageSize int32) (documentPermissions []DocumentPermission, cursorResp *Cursor, err error)
+	// make the to owner the owner of every document owned by the from owner and remove the
+	// permissions of the from owner on those documents, batchSize documents per transaction
+	ReassignOwnedDocuments(ctx context.Context, fromOwnerId uuid.UUID, toOwnerId uuid.UUID, batchSize int32) (moved int64, err error)
+	// make the fallback owner the owner of the document when the document has no owner, a
+	// permission that the fallback owner already holds is raised to owner. repaired is false
+	// when the document already had an owner
+	EnsureDocumentHasOwner(ctx context.Context, documentId uuid.UUID, fallbackOwnerId uuid.UUID) (repaired bool, err error)
+	// list the documents that are associated with that user at those permission levels, only the
+	// documents that the user starred when favoritesOnly is set and only the documents in the
+	// collection when collectionId is set. Archived documents are only listed when includeArchived is set
+	ListDocumentsByPrincipal(ctx context.Context, principalId uuid.UUID, permissions []PermissionLevel, favoritesOnly bool, collectionId *uuid.UUID, includeArchived bool, cursor *Cursor, pageSize int32) (documentPermissions []DocumentPermission, cursorResp *Cursor, hasMore bool, err error)
+	// list the documents of the principal that were modified after the cursor, oldest modification first
+	ListDocumentsModifiedSince(ctx context.Context, principalId uuid.UUID, cursor *Cursor, pageSize int32) (documentPermissions []DocumentPermission, cursorResp *Cursor, hasMore bool, err error)
+	// record that the principal read the document now, replacing their previous access
+	RecordDocumentAccess(ctx context.Context, principalId uuid.UUID, documentId uuid.UUID) (err error)
+	// starring a starred document and unstarring a document that is not starred are no-ops
+	StarDocument(ctx context.Context, principalId uuid.UUID, documentId uuid.UUID) (err error)
+	UnstarDocument(ctx context.Context, principalId uuid.UUID, documentId uuid.UUID) (err error)
+	CreateCollection(ctx context.Context, ownerId uuid.UUID, name string) (collection *Collection, err error)
+	// not found when there is no collection with the id
+	GetCollection(ctx context.Context, collectionId uuid.UUID) (collection *Collection, err error)
+	// adding a document that is in the collection and removing a document that is not are no-ops
+	AddToCollection(ctx context.Context, collectionId uuid.UUID, documentId uuid.UUID) (err error)
+	RemoveFromCollection(ctx context.Context, collectionId uuid.UUID, documentId uuid.UUID) (err error)
+	// list the active documents that the principal has read and still has a permission on, most recently read first
+	ListRecentlyAccessed(ctx context.Context, principalId uuid.UUID, cursor *Cursor, pageSize int32) (accessedDocuments []AccessedDocument, cursorResp *Cursor, hasMore bool, err error)
+	// the principals with a permission on the document that read it since the given time, most
+	// recent read first and at most limit of them
+	GetDocumentViewers(ctx context.Context, documentId uuid.UUID, since time.Time, limit int32) (viewers []DocumentViewer, err error)
+	// list the documents owned by the principal that are shared with at least one collaborator, newest first
+	ListSharedDocumentsByOwner(ctx context.Context, ownerId uuid.UUID, cursor *Cursor, pageSize int32) (sharedDocuments []SharedDocument, cursorResp *Cursor, hasMore bool, err error)
+	// lists every document including archived documents, newest first
+	ListAllDocuments(ctx context.Context, cursor *Cursor, pageSize int32, filters DocumentFilters) (documents []Document, cursorResp *Cursor, hasMore bool, err error)
+	// the number of active documents the principal holds each permission level on, levels
+	// without documents may be missing from the map
+	CountDocumentsByPrincipalGrouped(ctx context.Context, principalId uuid.UUID) (counts map[PermissionLevel]int64, err error)
 	GetPermissionOfPrincipalOnDocument(ctx context.Context, documentId uuid.UUID, principalId uuid.UUID) (permission Permission, err error)
+	// a lighter read of the permission for authorization checks, found is false when the
+	// principal has no permission on the document
+	GetPermissionLevel(ctx context.Context, documentId uuid.UUID, principalId uuid.UUID) (level PermissionLevel, found bool, err error)
+	// the levels of the principal on each of the documents, documents that the principal has no
+	// permission on are missing from the map
+	GetPermissionLevelsForPrincipalOnDocuments(ctx context.Context, principalId uuid.UUID, documentIds uuid.UUIDs) (levels map[uuid.UUID]PermissionLevel, err error)
+	// the subset of the documents that the principal owns, in no particular order
+	GetOwnedDocumentIds(ctx context.Context, ownerId uuid.UUID, documentIds uuid.UUIDs) (owned uuid.UUIDs, err error)
+	// the levels of each of the principals on the document, principals that have no permission
+	// on the document are missing from the map
+	GetPermissionsForPrincipals(ctx context.Context, documentId uuid.UUID, principalIds uuid.UUIDs) (levels map[uuid.UUID]PermissionLevel, err error)
 	// consider if we also want to be able to filter on user type here
-	ListPermissionsOnDocument(ctx context.Context, documentId uuid.UUID, permissions []PermissionLevel, cursor *Cursor, pageSize int32) (recipientPermissions []Permission, cursorResp *Cursor, err error)
-	CreateGuest(ctx context.Context, creatorId uuid.UUID, documentId uuid.UUID, permission PermissionLevel) (guestId uuid.UUID, err error)
-	UpsertPermissionUser(ctx context.Context, userId uuid.UUID, documentId uuid.UUID, permission PermissionLevel) (err error)
+	ListPermissionsOnDocument(ctx context.Context, documentId uuid.UUID, permissions []PermissionLevel, cursor *Cursor, pageSize int32, excludedRecipientId *uuid.UUID) (recipientPermissions []Permission, cursorResp *Cursor, hasMore bool, err error)
+	CountPermissionsOnDocument(ctx context.Context, documentId uuid.UUID, permissions []PermissionLevel) (count int64, err error)
+	CreateGuest(ctx context.Context, creatorId uuid.UUID, documentId uuid.UUID, permission PermissionLevel, label *string, maxGuests int32) (guestId uuid.UUID, err error)
+	// either every guest is created or none are
+	CreateGuests(ctx context.Context, creatorId uuid.UUID, documentId uuid.UUID, count int32, permission PermissionLevel, maxGuests int32) (guestIds []uuid.UUID, err error)
+	// users that already have a permission on the target are skipped, each guest of the source is
+	// copied as a new guest of the target. copied is the number of permissions created
+	CopyPermissions(ctx context.Context, creatorId uuid.UUID, sourceDocumentId uuid.UUID, targetDocumentId uuid.UUID, maxGuests int32) (copied int, err error)
+	// a nil label clears the label of the guest
+	UpdateGuestLabel(ctx context.Context, documentId uuid.UUID, guestId uuid.UUID, label *string) (err error)
+	// the document of the guest and the current token version of the guest
+	GetGuest(ctx context.Context, guestId uuid.UUID) (documentId uuid.UUID, tokenVersion int32, err error)
+	// bump the token version of the guest and return the new version
+	RotateGuestTokenVersion(ctx context.Context, guestId uuid.UUID) (tokenVersion int32, err error)
+	// created is true when the principal did not have a permission on the document before the upsert
+	UpsertPermissionUser(ctx context.Context, userId uuid.UUID, documentId uuid.UUID, permission PermissionLevel) (created bool, err error)
+	// the users whose permission was created or changed are in the map, created is false for a
+	// user whose existing permission was updated
+	UpsertPermissionsUser(ctx context.Context, creatorId uuid.UUID, documentId uuid.UUID, shares []UserShare) (created map[uuid.UUID]bool, err error)
+	// the user must not already have a permission or a pending share on the document
+	InvitePermissionUser(ctx context.Context, creatorId uuid.UUID, userId uuid.UUID, documentId uuid.UUID, permission PermissionLevel) (err error)
+	// the pending shares of the principal on active documents, newest first
+	ListPendingShares(ctx context.Context, principalId uuid.UUID, limit int32) (pendingShares []PendingShare, err error)
+	// not found when the principal has no pending share on the document
+	AcceptPendingShare(ctx context.Context, principalId uuid.UUID, documentId uuid.UUID) (err error)
 	UpdatePermissionGuest(ctx context.Context, guestId uuid.UUID, permission PermissionLevel) (err error)
 	DeletePermissionsPrincipal(ctx context.Context, recipientId uuid.UUID, documentId uuid.UUID) (err error)
+	// not found when the recipient has no accepted permission above the downgrade level, a
+	// downgrade replaces any downgrade already scheduled on the permission
+	SchedulePermissionDowngrade(ctx context.Context, documentId uuid.UUID, recipientId uuid.UUID, downgradeTo PermissionLevel, downgradeAt time.Time) (err error)
+	// apply the downgrades that are due at now, batchSize downgrades per transaction. Each
+	// downgrade is recorded in the permission audit in the same transaction
+	ApplyDueDowngrades(ctx context.Context, now time.Time, batchSize int32) (applied int64, err error)
+	// the most recent audit entries of the document, newest first
+	ListPermissionAudit(ctx context.Context, documentId uuid.UUID, pageSize int32) (entries []PermissionAuditEntry, err error)
+	// subscribe to the changes to the permissions on the document, the subscription is
+	// listening once this returns
+	WatchDocumentPermissions(ctx context.Context, documentId uuid.UUID) (watcher PermissionWatcher, err error)
 }
 
 type DocumentService struct {
 	documentRepo DocumentRepository
+	// the most guests that can be created in one call to CreateGuests
+	maxGuestBatchSize int32
+	// the most guests that a document can have at once
+	maxGuestsPerDocument int32
+	// the permission levels that a guest can be created with or updated to
+	guestPermissions []PermissionLevel
+	// the accesses that are waiting to be recorded by the access writer
+	accessQueue chan accessRecord
+	// guards sending on the access queue against it being closed by StopAccessWrites
+	accessMu sync.RWMutex
+	accessStopped bool
+	// closed once the access writer has recorded every queued access and returned
+	accessWriterDone chan struct{}
+	// the clock that recorded reads and deletions are aged against, tests replace it to move
+	// time forward without waiting
+	Now func() time.Time
+}
+
+// a read of a document that is waiting to be recorded. A record with a flushed channel is not an
+// access, the writer closes the channel once every access queued before it has been recorded
+type accessRecord struct {
+	ctx context.Context
+	principalId uuid.UUID
+	documentId uuid.UUID
+	flushed chan struct{}
 }
 
 func NewDocumentService(documentRepo DocumentRepository) *DocumentService {
-	return &DocumentService{
+	return NewDocumentServiceWithMaxGuestBatchSize(documentRepo, DefaultMaxGuestBatchSize)
+}
+
+func NewDocumentServiceWithMaxGuestBatchSize(
+	documentRepo DocumentRepository,
+	maxGuestBatchSize int32,
+) *DocumentService {
+	return NewDocumentServiceWithGuestLimits(documentRepo, maxGuestBatchSize, DefaultMaxGuestsPerDocument)
+}
+
+func NewDocumentServiceWithGuestLimits(
+	documentRepo DocumentRepository,
+	maxGuestBatchSize int32,
+	maxGuestsPerDocument int32,
+) *DocumentService {
+	return NewDocumentServiceWithGuestPolicy(
+		documentRepo, maxGuestBatchSize, maxGuestsPerDocument, DefaultGuestPermissions(),
+	)
+}
+
+// guestPermissions restricts the permission levels that guests can hold, for example to only
+// allow read only share links. Owner is never allowed for a guest even when it is listed
+func NewDocumentServiceWithGuestPolicy(
+	documentRepo DocumentRepository,
+	maxGuestBatchSize int32,
+	maxGuestsPerDocument int32,
+	guestPermissions []PermissionLevel,
+) *DocumentService {
+	ds := &DocumentService{
 		documentRepo: documentRepo,
+		maxGuestBatchSize: maxGuestBatchSize,
+		maxGuestsPerDocument: maxGuestsPerDocument,
+		// copied so that the caller cannot change the policy after the service is created
+		guestPermissions: slices.Clone(guestPermissions),
+		accessQueue: make(chan accessRecord, AccessQueueSize),
+		accessWriterDone: make(chan struct{}),
+		Now: time.Now,
 	}
+	go ds.writeAccesses()
+	return ds
 }
 
 func (ds *DocumentService) CreateDocument(
@@ -127,12 +513,20 @@ func (ds *DocumentService) CreateDocument(
 	ownerUserId uuid.UUID,
 	documentName *string,
 	documentDescription *string,
+	clientDocumentId *uuid.UUID,
 ) (uuid.UUID, error) {
+	// offline-first clients generate the ids of their documents before syncing them, the
+	// server generates the id when the client does not supply one
+	if clientDocumentId != nil && *clientDocumentId == uuid.Nil {
+		return uuid.Nil, InvalidInput("the client supplied document id must not be the nil uuid", nil)
+	}
 	// we may need some permission logic here if we want to enforce quotas on documents that a user
 	// can create
 	// this is an internal api that will be called by the api gateway layer. We can expect that
 	// the owner userId is a valid Id without checking with the user service
-	documentId, err := ds.documentRepo.CreateDocument(ctx, ownerUserId, documentName, documentDescription)
+	documentId, err := ds.documentRepo.CreateDocument(
+		ctx, ownerUserId, documentName, documentDescription, clientDocumentId,
+	)
 	if err != nil {
 		// err.(DomainError) syntax does not check all the way down the error chain but instead 
 		// checks the type of the top error. We want to use this syntax because our goal is to wrap
@@ -144,12 +538,16 @@ func (ds *DocumentService) CreateDocument(
 	return documentId, err
 }
 
+// the calling principal must hold a permission on the document. Callers that present a
+// public link of the document can read it without a permission while the public link is enabled
+// and has not been disabled since the link was issued. publicLinkVersion is the version of the
+// presented public link, nil when the caller did not present one
 func (ds *DocumentService) GetDocument(
 	ctx context.Context,
+	callerId uuid.UUID,
 	documentId uuid.UUID,
+	publicLinkVersion *int32,
 ) (*Document, error) {
-	// TODO: add some permission logic here so that we can be sure the user has permission
-	// 		 to get this document
 	document, err := ds.documentRepo.GetDocument(ctx, documentId)
 	if err != nil {
 		// this is a runtime type assertion
@@ -160,220 +558,1598 @@ func (ds *DocumentService) GetDocument(
 		if _, ok := err.(DomainError); !ok {
 			err = RepoImpl("unexpected error encountered when getting document", err)
 		}
+		return nil, err
+	}
+	if document.honoursPublicLink(publicLinkVersion) {
+		return document, nil
+	}
+	if _, err = ds.readCallerPermission(ctx, callerId, documentId); err != nil {
+		return nil, err
 	}
-	return document, err
+	ds.recordAccess(ctx, callerId, documentId)
+	return document, nil
 }
 
-func (ds *DocumentService) UpdateDocument(
+// the number of principals that the document is shared with, the owner is not counted. This does
+// not check the caller, it is meant to be called after the caller has read the document
+func (ds *DocumentService) CountCollaborators(
 	ctx context.Context,
 	documentId uuid.UUID,
-	documentName *string,
-	documentDescription *string,
-) (err error) {
-	// TODO: add some permission logic here so that we can be sure that the user has
-	// 		 permission to update this document
-	if documentName == nil && documentDescription == nil {
-		return InvalidInput("at least one of documentName or documentDescription must be provided to update document", nil)
-	}
-	err = ds.documentRepo.UpdateDocument(ctx, documentId, documentName, documentDescription)
+) (count int64, err error) {
+	count, err = ds.documentRepo.CountPermissionsOnDocument(ctx, documentId, CollaboratorPermissions)
 	if err != nil {
 		if _, ok := err.(DomainError); !ok {
-			err = RepoImpl("unexpected error when updating document", err)
+			err = RepoImpl("unexpected error found when counting the collaborators of the document", err)
 		}
+		return 0, err
 	}
-	return err
+	return count, nil
 }
 
-func (ds *DocumentService) DeleteDocument(
+// the access is queued for the access writer so that a slow or failed write never delays or
+// fails the read. An access is dropped when the queue is full or the service is stopping, the
+// repository logs a failed write and the access is not retried
+func (ds *DocumentService) recordAccess(ctx context.Context, principalId uuid.UUID, documentId uuid.UUID) {
+	ds.accessMu.RLock()
+	defer ds.accessMu.RUnlock()
+	if ds.accessStopped {
+		return
+	}
+	// the write outlives the request, so it keeps the values of the request context but
+	// not its cancellation
+	record := accessRecord{
+		ctx: context.WithoutCancel(ctx),
+		principalId: principalId,
+		documentId: documentId,
+	}
+	select {
+	case ds.accessQueue <- record:
+	default:
+		slog.WarnContext(
+			ctx, "dropped a document access because the access queue is full",
+			"principalId", principalId.String(), "documentId", documentId.String(),
+		)
+	}
+}
+
+// records the queued accesses one at a time until the queue is closed by StopAccessWrites
+func (ds *DocumentService) writeAccesses() {
+	defer close(ds.accessWriterDone)
+	for record := range ds.accessQueue {
+		if record.flushed != nil {
+			close(record.flushed)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(record.ctx, AccessWriteTimeout)
+		_ = ds.documentRepo.RecordDocumentAccess(ctx, record.principalId, record.documentId)
+		cancel()
+	}
+}
+
+// block until the accesses that were queued before this call have been written, this lets a
+// caller that lists the recent accesses right after a read see that read
+func (ds *DocumentService) WaitForAccessWrites() {
+	flushed := make(chan struct{})
+	ds.accessMu.RLock()
+	if ds.accessStopped {
+		ds.accessMu.RUnlock()
+		<-ds.accessWriterDone
+		return
+	}
+	// unlike an access the flush waits for room in the queue instead of being dropped
+	ds.accessQueue <- accessRecord{ flushed: flushed }
+	ds.accessMu.RUnlock()
+	<-flushed
+}
+
+// stop accepting accesses and block until the queued accesses have been written. Reads after
+// this do not record their access, so it is called once the server has stopped serving
+func (ds *DocumentService) StopAccessWrites() {
+	ds.accessMu.Lock()
+	if !ds.accessStopped {
+		ds.accessStopped = true
+		close(ds.accessQueue)
+	}
+	ds.accessMu.Unlock()
+	<-ds.accessWriterDone
+}
+
+// like GetDocument, but a document that was deleted within the tombstone retention returns its
+// tombstone instead of a not found error so that syncing clients can tell it apart from a
+// document that never existed. The permissions of a deleted document are gone with it, so the
+// tombstone is only returned to a caller that held a permission when the document was deleted,
+// other callers get the same not found error as for a document that never existed
+func (ds *DocumentService) GetDocumentOrTombstone(
 	ctx context.Context,
+	callerId uuid.UUID,
 	documentId uuid.UUID,
-) (err error) {
-	// TODO: add some permission logic here so that we can be sure that the user has
-	//		 permissions to delete this document 
-	err = ds.documentRepo.DeleteDocument(ctx, documentId)
-	if err != nil {
-		if _, ok := err.(DomainError); !ok {
-			err = RepoImpl("unexpected error when deleting document", err)
+	publicLinkVersion *int32,
+) (document *Document, tombstone *DocumentTombstone, err error) {
+	document, err = ds.GetDocument(ctx, callerId, documentId, publicLinkVersion)
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		return document, nil, err
+	}
+	tombstone, tombstoneErr := ds.documentRepo.GetDocumentTombstone(
+		ctx, documentId, callerId, ds.Now().Add(-TombstoneRetention),
+	)
+	if tombstoneErr != nil {
+		if errors.As(tombstoneErr, &notFound) {
+			// the document never existed, was deleted before the retention, or the caller did
+			// not hold a permission on it
+			return nil, nil, err
 		}
+		if _, ok := tombstoneErr.(DomainError); !ok {
+			tombstoneErr = RepoImpl("unexpected error encountered when getting document tombstone", tombstoneErr)
+		}
+		return nil, nil, tombstoneErr
 	}
-	return err
+	return nil, tombstone, nil
 }
 
-func (ds *DocumentService) DeleteDocuments(
+// delete the tombstones that are past the retention at now, this is called by the tombstone
+// prune job. The time is passed in so that the job can be driven by a fake clock in tests
+func (ds *DocumentService) PruneDocumentTombstones(
 	ctx context.Context,
-	documentIds uuid.UUIDs,
-	userId uuid.UUID,
-) (err error) {
-	// TODO: add some permission logic here so that we can be sure that the user has 
-	// permission to delete these documents 
-	err = ds.documentRepo.DeleteDocuments(ctx, documentIds, userId)
-	if err != nil{
+	now time.Time,
+) (pruned int64, err error) {
+	pruned, err = ds.documentRepo.PruneDocumentTombstones(ctx, now.Add(-TombstoneRetention))
+	if err != nil {
 		if _, ok := err.(DomainError); !ok {
-			err = RepoImpl("unexpected error when deleting documents", err)
+			err = RepoImpl("unexpected error when pruning document tombstones", err)
 		}
 	}
-	return err
+	return pruned, err
 }
 
-func (ds *DocumentService) ListDocumentsByPrincipal(
+// a nil name or description keeps its old value, clearName and clearDescription set the name or
+// description back to null. A field cannot be both set and cleared in the same update. Only
+// editors and owners can update a document, the history records the actor as the editor
+func (ds *DocumentService) UpdateDocument(
 	ctx context.Context,
-	principalId uuid.UUID,
-	permissions []PermissionLevel, 
-	cursor *Cursor,
-	pageSize int32,
-) (documentPermissions []DocumentPermission, cursorResp *Cursor, err error) {
-	// validate the inputs and replace them with default values where necessary
-	// if the list of permissions is empty, replace it with the default value (all permissions)
-	if len(permissions) < 1 {
-		permissions = AllPermissions
+	documentId uuid.UUID,
+	actorId uuid.UUID,
+	documentName *string,
+	documentDescription *string,
+	clearName bool,
+	clearDescription bool,
+) (err error) {
+	if documentName == nil && documentDescription == nil && !clearName && !clearDescription {
+		return InvalidInput("at least one of documentName or documentDescription must be provided or cleared to update document", nil)
 	}
-	// if the cursor is empty, replace it with the default starting cursor
-	if cursor == nil {
-		cursor = NewBeginningCursor(CreatedAt)
+	if documentName != nil && clearName {
+		return InvalidInput("documentName cannot be both provided and cleared", nil)
 	}
-	// if the page size is -1, replace it with the default page size
-	// if the page size is too large, replace it with the default page size
-	if pageSize < 1 || pageSize > MaxPageSize {
-		pageSize = DefaultPageSize
+	if documentDescription != nil && clearDescription {
+		return InvalidInput("documentDescription cannot be both provided and cleared", nil)
 	}
-	// call the relevant document repo function
-	documentPermissions, cursorResp, err = ds.documentRepo.ListDocumentsByPrincipal(
-		ctx,
-		principalId,
-		permissions,
-		cursor,
-		pageSize,
-	)
+	// read the document first so that a missing document is not found rather than denied, like
+	// in GetDocument
+	if _, err = ds.documentRepo.GetDocument(ctx, documentId); err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when getting document", err)
+		}
+		return err
+	}
+	actorLevel, err := ds.readCallerPermission(ctx, actorId, documentId)
+	if err != nil {
+		return err
+	}
+	if actorLevel < Editor {
+		return PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s must be an editor or owner of document: %s to update it",
+				actorId.String(), documentId.String(),
+			),
+			nil,
+		)
+	}
+	err = ds.documentRepo.UpdateDocument(ctx, documentId, actorId, documentName, documentDescription, clearName, clearDescription)
 	if err != nil {
 		if _, ok := err.(DomainError); !ok {
-			err = RepoImpl("unexpected error found when listing documents by principal", err)
+			err = RepoImpl("unexpected error when updating document", err)
 		}
-		return nil, nil, err
 	}
-	return documentPermissions, cursorResp, nil
+	return err
 }
 
-func (ds *DocumentService) GetPermissionOfPrincipalOnDocument(
+// mark the document as modified now without changing its name or description, for example as a
+// heartbeat from an editor so that the document floats to the top of last modified listings.
+// Only editors and owners can touch a document
+func (ds *DocumentService) TouchDocument(
 	ctx context.Context,
 	documentId uuid.UUID,
-	principalId uuid.UUID,
-) (permission Permission, err error) {
-	permission, err = ds.documentRepo.GetPermissionOfPrincipalOnDocument(
-		ctx, documentId, principalId,
-	)
+	callerId uuid.UUID,
+) (err error) {
+	callerLevel, err := ds.readCallerPermission(ctx, callerId, documentId)
+	if err != nil {
+		return err
+	}
+	if callerLevel < Editor {
+		return PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s must be an editor or owner of document: %s to touch it",
+				callerId.String(), documentId.String(),
+			),
+			nil,
+		)
+	}
+	err = ds.documentRepo.TouchDocument(ctx, documentId)
 	if err != nil {
 		if _, ok := err.(DomainError); !ok {
-			err = RepoImpl("unexpected error found when getting permission", err)
+			err = RepoImpl("unexpected error when touching document", err)
 		}
 	}
-	return permission, err
+	return err
 }
 
-func (ds *DocumentService) ListPermissionsOnDocument(
+// any principal with a permission on the document can read its history, the cursor must be
+// sorted by created at, which orders the changes by when they were made
+func (ds *DocumentService) GetDocumentHistory(
 	ctx context.Context,
 	documentId uuid.UUID,
-	permissions []PermissionLevel,
+	callerId uuid.UUID,
 	cursor *Cursor,
 	pageSize int32,
-) (recipientPermissions []Permission, cursorResp *Cursor, err error) {
-	// TODO: add some permissions logic here. We don't want principals with view permission to be
-	//		 able to see the other principals that have other permissions on the document 
-	// if the list of permissions is empty, replace it with the permissive list of permissions
-	if len(permissions) < 1 {
-		permissions = AllPermissions
-	}
-	// if the cursor is a nil pointer, replace it with the default beginning cursor
+) (changes []DocumentChange, cursorResp *Cursor, hasMore bool, err error) {
 	if cursor == nil {
 		cursor = NewBeginningCursor(CreatedAt)
 	}
-	// if the pagesize is out of bounds, replace it with the default page size 
+	if cursor.SortField != CreatedAt {
+		return nil, nil, false, InvalidInput("the cursor of a document history must be sorted by created at", nil)
+	}
 	if pageSize < 1 || pageSize > MaxPageSize {
 		pageSize = DefaultPageSize
 	}
-	// call the relevant repo method
-	recipientPermissions, cursorResp, err = ds.documentRepo.ListPermissionsOnDocument(
-		ctx, documentId, permissions, cursor, pageSize,
-	)
-	// conditionally wrap the error
+	if _, err = ds.readCallerPermission(ctx, callerId, documentId); err != nil {
+		return nil, nil, false, err
+	}
+	changes, cursorResp, hasMore, err = ds.documentRepo.ListDocumentHistory(ctx, documentId, cursor, pageSize)
 	if err != nil {
 		if _, ok := err.(DomainError); !ok {
-			err = RepoImpl("unexpected error found when listing permissions on document", err)
+			err = RepoImpl("unexpected error found when listing the history of the document", err)
 		}
+		return nil, nil, false, err
 	}
-	return recipientPermissions, cursorResp, err
+	return changes, cursorResp, hasMore, nil
 }
 
-func (ds *DocumentService) CreateGuest(
+func (ds *DocumentService) DeleteDocument(
 	ctx context.Context,
-	creatorId uuid.UUID,
 	documentId uuid.UUID,
-	permissionLevel PermissionLevel,
-) (guestId uuid.UUID, err error) {
-	// TODO: add some permission logic here, we want to verify that the creator Id 
-	//		 has owner permissions on the document and is a userId
-	// verify that the permission level is one of the valid permission levels for a guest
-	if permissionLevel == Owner {
-		return uuid.Nil, InvalidInput(
-			fmt.Sprintf(
-				"failed to create guest because guests cannot have this permission level: %v",
-				permissionLevel,
-			), 
-			nil,
-		)
-	}
-	// call the correct repo function
-	guestId, err = ds.documentRepo.CreateGuest(
-		ctx, creatorId, documentId, permissionLevel,
-	)
+) (err error) {
+	// TODO: add some permission logic here so that we can be sure that the user has
+	//		 permissions to delete this document 
+	err = ds.documentRepo.DeleteDocument(ctx, documentId)
 	if err != nil {
 		if _, ok := err.(DomainError); !ok {
-			err = RepoImpl("failed to create guest with unknown error", err)
+			err = RepoImpl("unexpected error when deleting document", err)
 		}
 	}
-	return guestId, err
+	return err
 }
 
-func (ds *DocumentService) UpsertPermissionUser(
-	ctx context.Context, 
-	userId uuid.UUID,
+// only the owner of the document can archive it
+func (ds *DocumentService) ArchiveDocument(
+	ctx context.Context,
+	callerId uuid.UUID,
 	documentId uuid.UUID,
-	permissionLevel PermissionLevel,
 ) (err error) {
-	// TODO: add some permission logic here, we want to verify that the calling userId has the 
-	//		 owner level permissions on that document so that it can update other users permissions
-	// validate the permission level
-	if permissionLevel == Owner {
-		return InvalidInput("cannot grant owner permission to user other than by creating a document with that user", nil)
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return err
 	}
-	// call the relevant repo function
-	err = ds.documentRepo.UpsertPermissionUser(
-		ctx, userId, documentId, permissionLevel,
-	)
-	// conditionally wrap the error output 
+	if err = ds.checkOwner(ctx, callerId, documentId, "archive it"); err != nil {
+		return err
+	}
+	err = ds.documentRepo.ArchiveDocument(ctx, documentId)
 	if err != nil {
 		if _, ok := err.(DomainError); !ok {
-			err = RepoImpl("failed to upsert permission on user with unknown error", err)
+			err = RepoImpl("unexpected error when archiving document", err)
 		}
 	}
 	return err
 }
 
-func (ds *DocumentService) UpdatePermissionGuest(
+// only the owner of the document can restore it
+func (ds *DocumentService) RestoreDocument(
 	ctx context.Context,
-	guestId uuid.UUID,
-	permissionLevel PermissionLevel,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
 ) (err error) {
-	// TODO: add some permission logic here, we want to verify that the calling userId has the 
-	//		 correct permissions to update the permissions of guests on a document
-	// validate the permission level
-	if permissionLevel == Owner {
-		return InvalidInput("cannot grant owner permission to a guest", nil)
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return err
 	}
-	// call the relevant repo function
-	err = ds.documentRepo.UpdatePermissionGuest(
-		ctx, guestId, permissionLevel,
-	)
-	// conditionally wrap the error
+	if err = ds.checkOwner(ctx, callerId, documentId, "restore it"); err != nil {
+		return err
+	}
+	err = ds.documentRepo.RestoreDocument(ctx, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when restoring document", err)
+		}
+	}
+	return err
+}
+
+func (ds *DocumentService) DeleteDocuments(
+	ctx context.Context,
+	documentIds uuid.UUIDs,
+	userId uuid.UUID,
+) (err error) {
+	// the batch is deleted all or nothing, so check that the caller owns every document before
+	// the delete transaction starts. Documents that do not exist are reported as not owned
+	notOwned, err := ds.GetNotOwnedDocumentIds(ctx, userId, documentIds)
+	if err != nil {
+		return err
+	}
+	if len(notOwned) > 0 {
+		return PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s must be the owner of every document to delete them, not owner of: %s",
+				userId.String(), strings.Join(notOwned.Strings(), ", "),
+			),
+			nil,
+		)
+	}
+	err = ds.documentRepo.DeleteDocuments(ctx, documentIds, userId)
+	if err != nil{
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when deleting documents", err)
+		}
+	}
+	return err
+}
+
+// the subset of the documents that the owner owns, read in one round trip. Documents that do
+// not exist are not owned
+func (ds *DocumentService) GetOwnedDocumentIds(
+	ctx context.Context,
+	ownerId uuid.UUID,
+	documentIds uuid.UUIDs,
+) (owned uuid.UUIDs, err error) {
+	if len(documentIds) == 0 {
+		return nil, nil
+	}
+	owned, err = ds.documentRepo.GetOwnedDocumentIds(ctx, ownerId, documentIds)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when reading the owned documents", err)
+		}
+	}
+	return owned, err
+}
+
+// the requested documents that the owner does not own, in the order that they were requested
+func (ds *DocumentService) GetNotOwnedDocumentIds(
+	ctx context.Context,
+	ownerId uuid.UUID,
+	documentIds uuid.UUIDs,
+) (notOwned uuid.UUIDs, err error) {
+	owned, err := ds.GetOwnedDocumentIds(ctx, ownerId, documentIds)
+	if err != nil {
+		return nil, err
+	}
+	ownedSet := make(map[uuid.UUID]struct{}, len(owned))
+	for _, documentId := range owned {
+		ownedSet[documentId] = struct{}{}
+	}
+	for _, documentId := range documentIds {
+		if _, ok := ownedSet[documentId]; !ok {
+			notOwned = append(notOwned, documentId)
+		}
+	}
+	return notOwned, nil
+}
+
+// move every document owned by the from owner to the to owner, this is used to hand the
+// documents of a departing user to their successor. Authorization is left to the caller, the
+// gateway only exposes this to admins
+func (ds *DocumentService) ReassignOwnedDocuments(
+	ctx context.Context,
+	fromOwnerId uuid.UUID,
+	toOwnerId uuid.UUID,
+) (moved int64, err error) {
+	if fromOwnerId == toOwnerId {
+		return 0, InvalidInput(
+			fmt.Sprintf("cannot reassign the documents of principal: %s to themselves", fromOwnerId.String()),
+			nil,
+		)
+	}
+	moved, err = ds.documentRepo.ReassignOwnedDocuments(ctx, fromOwnerId, toOwnerId, ReassignBatchSize)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when reassigning owned documents", err)
+		}
+	}
+	return moved, err
+}
+
+// schedule the permission of the recipient to be lowered to downgradeTo once downgradeAt has
+// passed, only the owner can schedule a downgrade. A time in the past is applied the next time
+// the downgrade job runs
+func (ds *DocumentService) SchedulePermissionDowngrade(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+	recipientId uuid.UUID,
+	downgradeTo PermissionLevel,
+	downgradeAt time.Time,
+) (err error) {
+	err = ds.checkOwner(ctx, callerId, documentId, "schedule a permission downgrade")
+	if err != nil {
+		return err
+	}
+	recipientLevel, found, err := ds.documentRepo.GetPermissionLevel(ctx, documentId, recipientId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when reading the permission of the recipient", err)
+		}
+		return err
+	}
+	if !found {
+		return NotFound(
+			fmt.Sprintf("principal: %s has no permission on document: %s", recipientId.String(), documentId.String()),
+			nil,
+		)
+	}
+	if recipientLevel == Owner {
+		return InvalidInput("the permission of the owner cannot be downgraded", nil)
+	}
+	if downgradeTo >= recipientLevel {
+		return InvalidInput(
+			fmt.Sprintf("cannot downgrade a permission at level: %v to level: %v", recipientLevel, downgradeTo),
+			nil,
+		)
+	}
+	err = ds.documentRepo.SchedulePermissionDowngrade(ctx, documentId, recipientId, downgradeTo, downgradeAt)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when scheduling a permission downgrade", err)
+		}
+	}
+	return err
+}
+
+// apply every downgrade that is due at now, this is called by the downgrade job. The time is
+// passed in so that the job can be driven by a fake clock in tests
+func (ds *DocumentService) ApplyDueDowngrades(
+	ctx context.Context,
+	now time.Time,
+	batchSize int32,
+) (applied int64, err error) {
+	if batchSize < 1 {
+		return 0, InvalidInput(fmt.Sprintf("batch size must be at least 1, got: %d", batchSize), nil)
+	}
+	applied, err = ds.documentRepo.ApplyDueDowngrades(ctx, now, batchSize)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when applying due downgrades", err)
+		}
+	}
+	return applied, err
+}
+
+// only the owner can read the permission audit of the document
+func (ds *DocumentService) ListPermissionAudit(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callerId uuid.UUID,
+	pageSize int32,
+) (entries []PermissionAuditEntry, err error) {
+	err = ds.checkOwner(ctx, callerId, documentId, "read the permission audit")
+	if err != nil {
+		return nil, err
+	}
+	entries, err = ds.documentRepo.ListPermissionAudit(ctx, documentId, pageSize)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when listing the permission audit", err)
+		}
+	}
+	return entries, err
+}
+
+// subscribe to the changes to the permissions on the document so that collaboration clients can
+// react to sharing changes as they happen. Only the owner can watch the permissions of a
+// document, the caller must close the returned watcher
+func (ds *DocumentService) WatchDocumentPermissions(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callerId uuid.UUID,
+) (watcher PermissionWatcher, err error) {
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return nil, err
+	}
+	if err = ds.checkOwner(ctx, callerId, documentId, "watch its permissions"); err != nil {
+		return nil, err
+	}
+	watcher, err = ds.documentRepo.WatchDocumentPermissions(ctx, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when watching the permissions of the document", err)
+		}
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// a repair utility for documents that lost their owner, for example after a failed migration,
+// which nobody can share or delete. The document is left unchanged when it has an owner.
+// Authorization is left to the caller, the admin role is only known to the gateway which
+// checks it before calling this
+func (ds *DocumentService) EnsureDocumentHasOwner(
+	ctx context.Context,
+	documentId uuid.UUID,
+	fallbackOwnerId uuid.UUID,
+) (repaired bool, err error) {
+	repaired, err = ds.documentRepo.EnsureDocumentHasOwner(ctx, documentId, fallbackOwnerId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when ensuring that the document has an owner", err)
+		}
+	}
+	return repaired, err
+}
+
+func (ds *DocumentService) ListDocumentsByPrincipal(
+	ctx context.Context,
+	principalId uuid.UUID,
+	permissions []PermissionLevel, 
+	favoritesOnly bool,
+	collectionId *uuid.UUID,
+	includeArchived bool,
+	cursor *Cursor,
+	pageSize int32,
+) (documentPermissions []DocumentPermission, cursorResp *Cursor, hasMore bool, err error) {
+	// only the owner of a collection can list the documents in it
+	if collectionId != nil {
+		if err = ds.checkCollectionOwner(ctx, principalId, *collectionId); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	// validate the inputs and replace them with default values where necessary
+	// if the list of permissions is empty, replace it with the default value (all permissions)
+	if len(permissions) < 1 {
+		permissions = AllPermissions
+	}
+	// if the cursor is empty, replace it with the default starting cursor
+	if cursor == nil {
+		cursor = NewBeginningCursor(CreatedAt)
+	}
+	// if the page size is -1, replace it with the default page size
+	// if the page size is too large, replace it with the default page size
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+	// call the relevant document repo function
+	documentPermissions, cursorResp, hasMore, err = ds.documentRepo.ListDocumentsByPrincipal(
+		ctx,
+		principalId,
+		permissions,
+		favoritesOnly,
+		collectionId,
+		includeArchived,
+		cursor,
+		pageSize,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when listing documents by principal", err)
+		}
+		return nil, nil, false, err
+	}
+	return documentPermissions, cursorResp, hasMore, nil
+}
+
+// incremental sync for clients that cache documents. Documents are returned oldest modification
+// first, since is only used when there is no cursor from a previous page. Archived documents are
+// returned with ArchivedAt set so that clients can drop them, deleted documents are not returned
+func (ds *DocumentService) ListDocumentsModifiedSince(
+	ctx context.Context,
+	principalId uuid.UUID,
+	since time.Time,
+	cursor *Cursor,
+	pageSize int32,
+) (documentPermissions []DocumentPermission, cursorResp *Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		cursor = NewSinceCursor(since)
+	}
+	if cursor.SortField != LastModifiedAt {
+		return nil, nil, false, InvalidInput("the cursor of a sync must be sorted by last modified at", nil)
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+	documentPermissions, cursorResp, hasMore, err = ds.documentRepo.ListDocumentsModifiedSince(
+		ctx, principalId, cursor, pageSize,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when listing documents modified since", err)
+		}
+		return nil, nil, false, err
+	}
+	return documentPermissions, cursorResp, hasMore, nil
+}
+
+// lists the documents that the owner has shared and how many collaborators each one has,
+// documents that are only visible to the owner are skipped. Documents are returned in reverse
+// chronological order of creation
+func (ds *DocumentService) ListSharedDocumentsByOwner(
+	ctx context.Context,
+	ownerId uuid.UUID,
+	cursor *Cursor,
+	pageSize int32,
+) (sharedDocuments []SharedDocument, cursorResp *Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		cursor = NewBeginningCursor(CreatedAt)
+	}
+	if cursor.SortField != CreatedAt {
+		return nil, nil, false, InvalidInput("the cursor of shared documents must be sorted by created at", nil)
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+	sharedDocuments, cursorResp, hasMore, err = ds.documentRepo.ListSharedDocumentsByOwner(
+		ctx, ownerId, cursor, pageSize,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when listing shared documents by owner", err)
+		}
+		return nil, nil, false, err
+	}
+	return sharedDocuments, cursorResp, hasMore, nil
+}
+
+// lists every document in reverse chronological order of creation, including archived
+// documents and documents without an owner. This is not scoped to a principal, the caller is
+// responsible for only allowing admins to list every document
+func (ds *DocumentService) ListAllDocuments(
+	ctx context.Context,
+	cursor *Cursor,
+	pageSize int32,
+	filters DocumentFilters,
+) (documents []Document, cursorResp *Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		cursor = NewBeginningCursor(CreatedAt)
+	}
+	if cursor.SortField != CreatedAt {
+		return nil, nil, false, InvalidInput("the cursor of all documents must be sorted by created at", nil)
+	}
+	if filters.CreatedBefore != nil && filters.CreatedAfter != nil && !filters.CreatedAfter.Before(*filters.CreatedBefore) {
+		return nil, nil, false, InvalidInput("created after must be before created before", nil)
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+	documents, cursorResp, hasMore, err = ds.documentRepo.ListAllDocuments(ctx, cursor, pageSize, filters)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when listing all documents", err)
+		}
+		return nil, nil, false, err
+	}
+	return documents, cursorResp, hasMore, nil
+}
+
+// lists the principals that read the document within the duration, most recent read first, for
+// showing who else is looking at a document. Reads are recorded by GetDocument, so the caller
+// appears once they have read the document. Any collaborator on the document can list its viewers
+func (ds *DocumentService) GetDocumentViewers(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callerId uuid.UUID,
+	withinDuration time.Duration,
+) (viewers []DocumentViewer, err error) {
+	if withinDuration <= 0 || withinDuration > MaxDocumentViewersWindow {
+		return nil, InvalidInput(
+			fmt.Sprintf("the viewers window must be between 0 and %v, got: %v", MaxDocumentViewersWindow, withinDuration),
+			nil,
+		)
+	}
+	if _, err = ds.readCallerPermission(ctx, callerId, documentId); err != nil {
+		return nil, err
+	}
+	viewers, err = ds.documentRepo.GetDocumentViewers(
+		ctx, documentId, ds.Now().Add(-withinDuration), MaxDocumentViewers,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when listing the viewers of a document", err)
+		}
+		return nil, err
+	}
+	return viewers, nil
+}
+
+// lists the documents that the principal opened, most recently opened first. Only reads of the
+// document by a principal that holds a permission on it are recorded, documents that were
+// archived or that the principal lost access to are skipped
+func (ds *DocumentService) ListRecentlyAccessed(
+	ctx context.Context,
+	principalId uuid.UUID,
+	cursor *Cursor,
+	pageSize int32,
+) (accessedDocuments []AccessedDocument, cursorResp *Cursor, hasMore bool, err error) {
+	if cursor == nil {
+		cursor = NewBeginningCursor(AccessedAt)
+	}
+	if cursor.SortField != AccessedAt {
+		return nil, nil, false, InvalidInput("the cursor of recently accessed documents must be sorted by accessed at", nil)
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+	accessedDocuments, cursorResp, hasMore, err = ds.documentRepo.ListRecentlyAccessed(
+		ctx, principalId, cursor, pageSize,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when listing recently accessed documents", err)
+		}
+		return nil, nil, false, err
+	}
+	return accessedDocuments, cursorResp, hasMore, nil
+}
+
+// the counts are read with one grouped query instead of a count for each level. Every
+// permission level is present in the returned map, levels without documents count zero. The
+// counts reveal how many documents a principal can see, so principals can only count their own
+func (ds *DocumentService) CountDocumentsByPrincipalGrouped(
+	ctx context.Context,
+	callerId uuid.UUID,
+	principalId uuid.UUID,
+) (counts map[PermissionLevel]int64, err error) {
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return nil, err
+	}
+	if callerId != principalId {
+		return nil, PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s cannot count the documents of principal: %s",
+				callerId.String(), principalId.String(),
+			),
+			nil,
+		)
+	}
+	counts, err = ds.documentRepo.CountDocumentsByPrincipalGrouped(ctx, principalId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when counting documents by principal", err)
+		}
+		return nil, err
+	}
+	if counts == nil {
+		counts = make(map[PermissionLevel]int64, len(AllPermissions))
+	}
+	for _, level := range AllPermissions {
+		if _, ok := counts[level]; !ok {
+			counts[level] = 0
+		}
+	}
+	return counts, nil
+}
+
+// the calling principal can always read their own permission on a document, only the owner
+// of a document can read the permissions of other principals. A caller without a permission
+// that presents a public link of the document is granted the public access level of the document
+func (ds *DocumentService) GetPermissionOfPrincipalOnDocument(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+	publicLinkVersion *int32,
+) (permission Permission, err error) {
+	if callerId != principalId {
+		err = ds.checkOwner(ctx, callerId, documentId, "read the permissions of other principals")
+		if err != nil {
+			return Permission{}, err
+		}
+	}
+	permission, err = ds.documentRepo.GetPermissionOfPrincipalOnDocument(
+		ctx, documentId, principalId,
+	)
+	var notFound *NotFoundError
+	if publicLinkVersion != nil && callerId == principalId && errors.As(err, &notFound) {
+		return ds.publicLinkPermission(ctx, documentId, principalId, publicLinkVersion, err)
+	}
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when getting permission", err)
+		}
+	}
+	return permission, err
+}
+
+// read the permission levels of a principal on several documents at once, for example before a
+// bulk operation. The calling principal can always read their own levels, the levels of other
+// principals are only returned on documents that the caller owns. Documents that the principal
+// has no permission on are left out of the map instead of failing the request
+func (ds *DocumentService) GetPermissionLevelsForPrincipalOnDocuments(
+	ctx context.Context,
+	callerId uuid.UUID,
+	principalId uuid.UUID,
+	documentIds uuid.UUIDs,
+) (levels map[uuid.UUID]PermissionLevel, err error) {
+	if len(documentIds) > MaxPermissionLevelBatchSize {
+		return nil, InvalidInput(
+			fmt.Sprintf(
+				"can read permission levels on at most %d documents at once, got: %d",
+				MaxPermissionLevelBatchSize, len(documentIds),
+			),
+			nil,
+		)
+	}
+	if len(documentIds) == 0 {
+		return map[uuid.UUID]PermissionLevel{}, nil
+	}
+	levels, err = ds.documentRepo.GetPermissionLevelsForPrincipalOnDocuments(ctx, principalId, documentIds)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when getting permission levels", err)
+		}
+		return nil, err
+	}
+	if callerId == principalId {
+		return levels, nil
+	}
+	// drop the documents that the caller does not own, this is one more query instead of an
+	// ownership check for each document
+	callerLevels, err := ds.documentRepo.GetPermissionLevelsForPrincipalOnDocuments(ctx, callerId, documentIds)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when getting the permission levels of the caller", err)
+		}
+		return nil, err
+	}
+	for documentId := range levels {
+		if callerLevels[documentId] != Owner {
+			delete(levels, documentId)
+		}
+	}
+	return levels, nil
+}
+
+// read the permission levels of several principals on one document at once, for example to show
+// the current access of a set of users in a share dialog. Only the owner of the document can read
+// the levels, principals that have no permission on the document are left out of the map
+func (ds *DocumentService) GetPermissionsForPrincipals(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callerId uuid.UUID,
+	principalIds uuid.UUIDs,
+) (levels map[uuid.UUID]PermissionLevel, err error) {
+	if len(principalIds) > MaxPermissionLevelBatchSize {
+		return nil, InvalidInput(
+			fmt.Sprintf(
+				"can read permission levels of at most %d principals at once, got: %d",
+				MaxPermissionLevelBatchSize, len(principalIds),
+			),
+			nil,
+		)
+	}
+	err = ds.checkOwner(ctx, callerId, documentId, "read the permissions of other principals")
+	if err != nil {
+		return nil, err
+	}
+	if len(principalIds) == 0 {
+		return map[uuid.UUID]PermissionLevel{}, nil
+	}
+	levels, err = ds.documentRepo.GetPermissionsForPrincipals(ctx, documentId, principalIds)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when getting the permission levels of principals", err)
+		}
+		return nil, err
+	}
+	return levels, nil
+}
+
+// build the permission held by a principal that presents a public link of the document. The
+// not found error is returned unchanged when the public link of the document is disabled or the
+// presented public link was issued before it was last disabled
+func (ds *DocumentService) publicLinkPermission(
+	ctx context.Context,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+	publicLinkVersion *int32,
+	notFound error,
+) (Permission, error) {
+	document, err := ds.documentRepo.GetDocument(ctx, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when getting document", err)
+		}
+		return Permission{}, err
+	}
+	if !document.honoursPublicLink(publicLinkVersion) {
+		return Permission{}, notFound
+	}
+	return Permission{
+		RecipientID: principalId,
+		RecipientType: Guest,
+		DocumentID: documentId,
+		PermissionLevel: *document.PublicAccess,
+	}, nil
+}
+
+// read the permission level of the calling principal on the document, a caller without a
+// permission gets a permission denied error instead of a not found error
+func (ds *DocumentService) readCallerPermission(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+) (PermissionLevel, error) {
+	callerLevel, found, err := ds.documentRepo.GetPermissionLevel(ctx, documentId, callerId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when reading the permission of the caller", err)
+		}
+		return 0, err
+	}
+	if !found {
+		return 0, PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s has no permission on document: %s",
+				callerId.String(), documentId.String(),
+			),
+			nil,
+		)
+	}
+	return callerLevel, nil
+}
+
+// reports whether the principal can take the action on the document. A principal without a
+// permission on the document is not allowed to take any action, this is not an error. The rules
+// of GetDocument apply, a document that does not exist is not found, a presented public link
+// grants the access level of the link, and an archived document can only be viewed or deleted
+func (ds *DocumentService) CheckAccess(
+	ctx context.Context,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+	publicLinkVersion *int32,
+	action Action,
+) (allowed bool, err error) {
+	if _, ok := actionRequiredLevels[action]; !ok {
+		return false, InvalidInput(fmt.Sprintf("unknown action: %v", action), nil)
+	}
+	document, err := ds.documentRepo.GetDocument(ctx, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when getting document", err)
+		}
+		return false, err
+	}
+	if document.ArchivedAt != nil {
+		if _, ok := archivedActions[action]; !ok {
+			return false, nil
+		}
+	}
+	level, found, err := ds.documentRepo.GetPermissionLevel(ctx, documentId, principalId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when checking access", err)
+		}
+		return false, err
+	}
+	// the public link only ever adds to the access that the principal holds on its own
+	if document.honoursPublicLink(publicLinkVersion) && (!found || *document.PublicAccess > level) {
+		level, found = *document.PublicAccess, true
+	}
+	return found && level.Permits(action), nil
+}
+
+// returns a permission denied error unless the calling principal is the owner of the document,
+// action describes what the caller is attempting for the error message
+func (ds *DocumentService) checkOwner(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+	action string,
+) error {
+	callerLevel, err := ds.readCallerPermission(ctx, callerId, documentId)
+	if err != nil {
+		return err
+	}
+	if callerLevel != Owner {
+		return PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s must be the owner of document: %s to %s",
+				callerId.String(), documentId.String(), action,
+			),
+			nil,
+		)
+	}
+	return nil
+}
+
+// any principal with a permission on the document can read its sharing summary. The summary
+// is composed from the owner permission, the first page of collaborator permissions, and the
+// count of collaborator permissions so that callers do not have to make several requests
+func (ds *DocumentService) GetDocumentSharingSummary(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callerId uuid.UUID,
+) (summary SharingSummary, err error) {
+	if _, err = ds.readCallerPermission(ctx, callerId, documentId); err != nil {
+		return SharingSummary{}, err
+	}
+	owners, _, _, err := ds.documentRepo.ListPermissionsOnDocument(
+		ctx, documentId, []PermissionLevel{ Owner }, NewBeginningCursor(LastModifiedAt), 1, nil,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when reading the owner of the document", err)
+		}
+		return SharingSummary{}, err
+	}
+	if len(owners) < 1 {
+		// every document is created with an owner and ownership cannot be removed
+		return SharingSummary{}, RepoImpl(
+			fmt.Sprintf("no owner found for document: %s", documentId.String()), nil,
+		)
+	}
+	summary.Owner = owners[0]
+	summary.Collaborators, _, _, err = ds.documentRepo.ListPermissionsOnDocument(
+		ctx, documentId, CollaboratorPermissions, NewBeginningCursor(LastModifiedAt), SharingSummaryPreviewSize, nil,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when listing the collaborators of the document", err)
+		}
+		return SharingSummary{}, err
+	}
+	summary.CollaboratorCount, err = ds.documentRepo.CountPermissionsOnDocument(
+		ctx, documentId, CollaboratorPermissions,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when counting the collaborators of the document", err)
+		}
+		return SharingSummary{}, err
+	}
+	return summary, nil
+}
+
+// only the owner of a document can change its public access. A nil public access disables the
+// public link of the document and revokes the public links issued before, the public link cannot
+// grant the owner permission level. The version that new public links are issued at is returned
+func (ds *DocumentService) SetPublicAccess(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+	publicAccess *PermissionLevel,
+) (publicLinkVersion int32, err error) {
+	if publicAccess != nil && *publicAccess == Owner {
+		return 0, InvalidInput(
+			"the public link of a document cannot grant the owner permission level",
+			nil,
+		)
+	}
+	err = ds.checkOwner(ctx, callerId, documentId, "change its public access")
+	if err != nil {
+		return 0, err
+	}
+	publicLinkVersion, err = ds.documentRepo.SetPublicAccess(ctx, documentId, publicAccess)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error when setting the public access of document", err)
+		}
+		return 0, err
+	}
+	return publicLinkVersion, nil
+}
+
+// a non nil excludedRecipientId leaves the permission of that principal out of the listing, this
+// lets an owner list the principals they shared the document with without listing themselves
+// while still listing any other principal at the owner level
+func (ds *DocumentService) ListPermissionsOnDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	permissions []PermissionLevel,
+	cursor *Cursor,
+	pageSize int32,
+	excludedRecipientId *uuid.UUID,
+) (recipientPermissions []Permission, cursorResp *Cursor, hasMore bool, err error) {
+	// TODO: add some permissions logic here. We don't want principals with view permission to be
+	//		 able to see the other principals that have other permissions on the document 
+	// if the list of permissions is empty, replace it with the permissive list of permissions
+	if len(permissions) < 1 {
+		permissions = AllPermissions
+	}
+	// if the cursor is a nil pointer, replace it with the default beginning cursor
+	if cursor == nil {
+		cursor = NewBeginningCursor(CreatedAt)
+	}
+	// if the pagesize is out of bounds, replace it with the default page size 
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+	// call the relevant repo method
+	recipientPermissions, cursorResp, hasMore, err = ds.documentRepo.ListPermissionsOnDocument(
+		ctx, documentId, permissions, cursor, pageSize, excludedRecipientId,
+	)
+	// conditionally wrap the error
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when listing permissions on document", err)
+		}
+	}
+	return recipientPermissions, cursorResp, hasMore, err
+}
+
+// the nil uuid is never the id of a principal or a document, reject it before it can be
+// persisted. The name is the name of the id in the error message
+func checkIdNotNil(name string, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return InvalidInput(fmt.Sprintf("the %s must not be the nil uuid", name), nil)
+	}
+	return nil
+}
+
+func (ds *DocumentService) CreateGuest(
+	ctx context.Context,
+	creatorId uuid.UUID,
+	documentId uuid.UUID,
+	permissionLevel *PermissionLevel,
+	label *string,
+) (guestId uuid.UUID, err error) {
+	if err = checkIdNotNil("creator id", creatorId); err != nil {
+		return uuid.Nil, err
+	}
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return uuid.Nil, err
+	}
+	// TODO: add some permission logic here, we want to verify that the creator Id 
+	//		 has owner permissions on the document and is a userId
+	guestPermissionLevel, err := ds.resolveGuestPermissionLevel(permissionLevel)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err = checkGuestLabel(label); err != nil {
+		return uuid.Nil, err
+	}
+	// call the correct repo function
+	guestId, err = ds.documentRepo.CreateGuest(
+		ctx, creatorId, documentId, guestPermissionLevel, label, ds.maxGuestsPerDocument,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("failed to create guest with unknown error", err)
+		}
+	}
+	return guestId, err
+}
+
+// the calling principal cannot grant a permission level higher than the level they hold
+// on the document, and cannot modify the permission of a principal that holds a higher level
+// than they do. Owners can grant any level other than owner
+func (ds *DocumentService) checkPermissionHierarchy(
+	ctx context.Context,
+	callerId uuid.UUID,
+	targetId uuid.UUID,
+	documentId uuid.UUID,
+	permissionLevel PermissionLevel,
+) error {
+	callerLevel, err := ds.readCallerPermission(ctx, callerId, documentId)
+	if err != nil {
+		return err
+	}
+	if permissionLevel > callerLevel {
+		return PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s cannot grant permission level: %v which is higher than their own: %v",
+				callerId.String(), permissionLevel, callerLevel,
+			),
+			nil,
+		)
+	}
+	// the target principal may not have a permission on the document yet, in which case
+	// there is nothing to compare against
+	targetLevel, found, err := ds.documentRepo.GetPermissionLevel(ctx, documentId, targetId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when reading the permission of the target", err)
+		}
+		return err
+	}
+	if !found {
+		return nil
+	}
+	return checkTargetLevel(callerId, callerLevel, targetId, targetLevel, documentId)
+}
+
+// the caller can only change the permission of a target that holds at most the level of the
+// caller, and never the permission of the owner
+func checkTargetLevel(
+	callerId uuid.UUID,
+	callerLevel PermissionLevel,
+	targetId uuid.UUID,
+	targetLevel PermissionLevel,
+	documentId uuid.UUID,
+) error {
+	if targetLevel > callerLevel {
+		return PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s cannot modify the permission of principal: %s which holds a higher level",
+				callerId.String(), targetId.String(),
+			),
+			nil,
+		)
+	}
+	// the owner of a document keeps the owner permission for as long as the document exists
+	if targetLevel == Owner {
+		return InvalidInput(
+			fmt.Sprintf(
+				"cannot change the permission of principal: %s because they are the owner of document: %s",
+				targetId.String(), documentId.String(),
+			),
+			nil,
+		)
+	}
+	return nil
+}
+
+func (ds *DocumentService) UpsertPermissionUser(
+	ctx context.Context,
+	callerId uuid.UUID,
+	userId uuid.UUID,
+	documentId uuid.UUID,
+	permissionLevel PermissionLevel,
+) (created bool, err error) {
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return false, err
+	}
+	if err = checkIdNotNil("user id", userId); err != nil {
+		return false, err
+	}
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return false, err
+	}
+	// validate the permission level
+	if permissionLevel == Owner {
+		return false, InvalidInput("cannot grant owner permission to user other than by creating a document with that user", nil)
+	}
+	// a principal already holds their own permission, sharing with themselves would at best be
+	// redundant and at worst downgrade their own permission
+	if callerId == userId {
+		return false, InvalidInput(
+			fmt.Sprintf("principal: %s cannot share document: %s with themselves", callerId.String(), documentId.String()),
+			nil,
+		)
+	}
+	// verify that the calling user holds at least the permission level that they are granting
+	if err = ds.checkPermissionHierarchy(ctx, callerId, userId, documentId, permissionLevel); err != nil {
+		return false, err
+	}
+	// call the relevant repo function
+	created, err = ds.documentRepo.UpsertPermissionUser(
+		ctx, userId, documentId, permissionLevel,
+	)
+	// conditionally wrap the error output 
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("failed to upsert permission on user with unknown error", err)
+		}
+	}
+	return created, err
+}
+
+// share the document with a batch of users in one statement, for example when a share dialog
+// adds several people at once. The caller is held to the same rules as sharing with each user
+// directly and the batch is rejected as a whole if any share breaks them. The shares are
+// returned in the order they were given with created set for the users that did not have a
+// permission on the document before
+func (ds *DocumentService) UpsertPermissionsUser(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+	shares []UserShare,
+) (results []UserShare, err error) {
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return nil, err
+	}
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return nil, err
+	}
+	if len(shares) < 1 || len(shares) > MaxShareBatchSize {
+		return nil, InvalidInput(
+			fmt.Sprintf("can share with between 1 and %d users at once, got: %d", MaxShareBatchSize, len(shares)),
+			nil,
+		)
+	}
+	// a user that appears twice would be upserted twice by the same statement
+	userIds := make(uuid.UUIDs, 0, len(shares))
+	seen := make(map[uuid.UUID]struct{}, len(shares))
+	for _, share := range shares {
+		if err = checkIdNotNil("user id", share.UserID); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[share.UserID]; ok {
+			return nil, InvalidInput(fmt.Sprintf("user: %s is shared with more than once", share.UserID.String()), nil)
+		}
+		seen[share.UserID] = struct{}{}
+		if share.PermissionLevel == Owner {
+			return nil, InvalidInput("cannot grant owner permission to user other than by creating a document with that user", nil)
+		}
+		if share.UserID == callerId {
+			return nil, InvalidInput(
+				fmt.Sprintf("principal: %s cannot share document: %s with themselves", callerId.String(), documentId.String()),
+				nil,
+			)
+		}
+		userIds = append(userIds, share.UserID)
+	}
+	callerLevel, err := ds.readCallerPermission(ctx, callerId, documentId)
+	if err != nil {
+		return nil, err
+	}
+	targetLevels, err := ds.documentRepo.GetPermissionsForPrincipals(ctx, documentId, userIds)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when reading the permissions of the targets", err)
+		}
+		return nil, err
+	}
+	for _, share := range shares {
+		if share.PermissionLevel > callerLevel {
+			return nil, PermissionDenied(
+				fmt.Sprintf(
+					"principal: %s cannot grant permission level: %v which is higher than their own: %v",
+					callerId.String(), share.PermissionLevel, callerLevel,
+				),
+				nil,
+			)
+		}
+		if targetLevel, found := targetLevels[share.UserID]; found {
+			if err = checkTargetLevel(callerId, callerLevel, share.UserID, targetLevel, documentId); err != nil {
+				return nil, err
+			}
+		}
+	}
+	created, err := ds.documentRepo.UpsertPermissionsUser(ctx, callerId, documentId, shares)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("failed to upsert permissions on users with unknown error", err)
+		}
+		return nil, err
+	}
+	results = make([]UserShare, len(shares))
+	for i, share := range shares {
+		share.Created = created[share.UserID]
+		results[i] = share
+	}
+	return results, nil
+}
+
+// offer the user a permission on the document that grants no access until the user accepts it,
+// the caller is held to the same rules as sharing the document directly
+func (ds *DocumentService) InviteUser(
+	ctx context.Context,
+	callerId uuid.UUID,
+	userId uuid.UUID,
+	documentId uuid.UUID,
+	permissionLevel PermissionLevel,
+) (err error) {
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return err
+	}
+	if err = checkIdNotNil("user id", userId); err != nil {
+		return err
+	}
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return err
+	}
+	if permissionLevel == Owner {
+		return InvalidInput("cannot offer owner permission to a user", nil)
+	}
+	if callerId == userId {
+		return InvalidInput(
+			fmt.Sprintf("principal: %s cannot invite themselves to document: %s", callerId.String(), documentId.String()),
+			nil,
+		)
+	}
+	if err = ds.checkPermissionHierarchy(ctx, callerId, userId, documentId, permissionLevel); err != nil {
+		return err
+	}
+	err = ds.documentRepo.InvitePermissionUser(ctx, callerId, userId, documentId, permissionLevel)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when inviting user", err)
+		}
+	}
+	return err
+}
+
+// the shares offered to the principal that they have not accepted yet, at most MaxPendingShares
+func (ds *DocumentService) ListPendingShares(
+	ctx context.Context,
+	principalId uuid.UUID,
+) (pendingShares []PendingShare, err error) {
+	pendingShares, err = ds.documentRepo.ListPendingShares(ctx, principalId, MaxPendingShares)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when listing pending shares", err)
+		}
+		return nil, err
+	}
+	return pendingShares, nil
+}
+
+// the principal must have a permission on the document to star it, the favorite is kept when the
+// principal later loses their permission but the document is no longer listed
+func (ds *DocumentService) StarDocument(
+	ctx context.Context,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+) (err error) {
+	if _, err = ds.readCallerPermission(ctx, principalId, documentId); err != nil {
+		return err
+	}
+	err = ds.documentRepo.StarDocument(ctx, principalId, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when starring document", err)
+		}
+	}
+	return err
+}
+
+// a principal can always unstar a document, even after they lost their permission on it
+func (ds *DocumentService) UnstarDocument(
+	ctx context.Context,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+) (err error) {
+	err = ds.documentRepo.UnstarDocument(ctx, principalId, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when unstarring document", err)
+		}
+	}
+	return err
+}
+
+// a collection belongs to the principal that creates it, the name does not have to be unique
+func (ds *DocumentService) CreateCollection(
+	ctx context.Context,
+	ownerId uuid.UUID,
+	name string,
+) (collection *Collection, err error) {
+	if err = checkIdNotNil("owner id", ownerId); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, InvalidInput("the name of a collection must not be empty", nil)
+	}
+	if utf8.RuneCountInString(name) > MaxCollectionNameLength {
+		return nil, InvalidInput(
+			fmt.Sprintf("the name of a collection can be at most %d characters", MaxCollectionNameLength),
+			nil,
+		)
+	}
+	collection, err = ds.documentRepo.CreateCollection(ctx, ownerId, name)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when creating collection", err)
+		}
+		return nil, err
+	}
+	return collection, nil
+}
+
+// returns a permission denied error unless the calling principal owns the collection
+func (ds *DocumentService) checkCollectionOwner(
+	ctx context.Context,
+	callerId uuid.UUID,
+	collectionId uuid.UUID,
+) error {
+	if err := checkIdNotNil("collection id", collectionId); err != nil {
+		return err
+	}
+	collection, err := ds.documentRepo.GetCollection(ctx, collectionId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when reading collection", err)
+		}
+		return err
+	}
+	if collection.OwnerID != callerId {
+		return PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s is not the owner of collection: %s",
+				callerId.String(), collectionId.String(),
+			),
+			nil,
+		)
+	}
+	return nil
+}
+
+// the caller must own the collection and have a permission on the document, adding the document
+// to a collection does not change who can access it
+func (ds *DocumentService) AddToCollection(
+	ctx context.Context,
+	callerId uuid.UUID,
+	collectionId uuid.UUID,
+	documentId uuid.UUID,
+) (err error) {
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return err
+	}
+	if err = ds.checkCollectionOwner(ctx, callerId, collectionId); err != nil {
+		return err
+	}
+	if _, err = ds.readCallerPermission(ctx, callerId, documentId); err != nil {
+		return err
+	}
+	err = ds.documentRepo.AddToCollection(ctx, collectionId, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when adding document to collection", err)
+		}
+	}
+	return err
+}
+
+// the owner of a collection can always remove a document from it, even after they lost their
+// permission on the document
+func (ds *DocumentService) RemoveFromCollection(
+	ctx context.Context,
+	callerId uuid.UUID,
+	collectionId uuid.UUID,
+	documentId uuid.UUID,
+) (err error) {
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return err
+	}
+	if err = ds.checkCollectionOwner(ctx, callerId, collectionId); err != nil {
+		return err
+	}
+	err = ds.documentRepo.RemoveFromCollection(ctx, collectionId, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when removing document from collection", err)
+		}
+	}
+	return err
+}
+
+// turn the pending share of the principal on the document into a permission that grants access
+func (ds *DocumentService) AcceptPendingShare(
+	ctx context.Context,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+) (err error) {
+	err = ds.documentRepo.AcceptPendingShare(ctx, principalId, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when accepting pending share", err)
+		}
+	}
+	return err
+}
+
+func (ds *DocumentService) UpdatePermissionGuest(
+	ctx context.Context,
+	guestId uuid.UUID,
+	permissionLevel PermissionLevel,
+) (err error) {
+	// TODO: add some permission logic here, we want to verify that the calling userId has the 
+	//		 correct permissions to update the permissions of guests on a document
+	if err = checkIdNotNil("guest id", guestId); err != nil {
+		return err
+	}
+	// validate the permission level
+	if err = ds.checkGuestPermissionLevel(permissionLevel); err != nil {
+		return err
+	}
+	// call the relevant repo function
+	err = ds.documentRepo.UpdatePermissionGuest(
+		ctx, guestId, permissionLevel,
+	)
+	// conditionally wrap the error
 	if err != nil {
 		if _, ok := err.(DomainError); !ok {
 			err = RepoImpl("unknown error found when updating guest permission level", err)
@@ -382,6 +2158,257 @@ func (ds *DocumentService) UpdatePermissionGuest(
 	return err
 }
 
+// most guests are read only share links, so guests are viewers unless a permission level is
+// provided
+func (ds *DocumentService) resolveGuestPermissionLevel(permissionLevel *PermissionLevel) (PermissionLevel, error) {
+	guestPermissionLevel := DefaultGuestPermissionLevel
+	if permissionLevel != nil {
+		guestPermissionLevel = *permissionLevel
+	}
+	if err := ds.checkGuestPermissionLevel(guestPermissionLevel); err != nil {
+		return 0, err
+	}
+	return guestPermissionLevel, nil
+}
+
+// verify that the permission level is one of the levels that the policy allows for guests
+func (ds *DocumentService) checkGuestPermissionLevel(permissionLevel PermissionLevel) error {
+	if permissionLevel != Owner && slices.Contains(ds.guestPermissions, permissionLevel) {
+		return nil
+	}
+	return InvalidInput(
+		fmt.Sprintf("guests cannot have this permission level: %v", permissionLevel),
+		nil,
+	)
+}
+
+// create several independent guests on the document in one transaction, for example one share
+// link per reviewer. Only the owner of the document can create guests in bulk
+func (ds *DocumentService) CreateGuests(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+	count int32,
+	permissionLevel *PermissionLevel,
+) (guestIds []uuid.UUID, err error) {
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return nil, err
+	}
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return nil, err
+	}
+	if count < 1 || count > ds.maxGuestBatchSize {
+		return nil, InvalidInput(
+			fmt.Sprintf("count must be between 1 and %d, got: %d", ds.maxGuestBatchSize, count),
+			nil,
+		)
+	}
+	guestPermissionLevel, err := ds.resolveGuestPermissionLevel(permissionLevel)
+	if err != nil {
+		return nil, err
+	}
+	if err = ds.checkOwner(ctx, callerId, documentId, "create guests"); err != nil {
+		return nil, err
+	}
+	guestIds, err = ds.documentRepo.CreateGuests(
+		ctx, callerId, documentId, count, guestPermissionLevel, ds.maxGuestsPerDocument,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("failed to create guests with unknown error", err)
+		}
+	}
+	return guestIds, err
+}
+
+// replicate the sharing of the source document onto the target document, for example when
+// creating a series of related documents. The owner and pending shares of the source are not
+// copied, users that already have a permission on the target keep it, and copying again does not
+// add the guests that were already copied. Scheduled downgrades are copied so that a temporary
+// share stays temporary. The caller must own both documents
+func (ds *DocumentService) CopyPermissions(
+	ctx context.Context,
+	sourceDocumentId uuid.UUID,
+	targetDocumentId uuid.UUID,
+	callerId uuid.UUID,
+) (copied int, err error) {
+	if err = checkIdNotNil("source document id", sourceDocumentId); err != nil {
+		return 0, err
+	}
+	if err = checkIdNotNil("target document id", targetDocumentId); err != nil {
+		return 0, err
+	}
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return 0, err
+	}
+	if sourceDocumentId == targetDocumentId {
+		return 0, InvalidInput(
+			fmt.Sprintf("cannot copy the permissions of document: %s onto itself", sourceDocumentId.String()),
+			nil,
+		)
+	}
+	if err = ds.checkOwner(ctx, callerId, sourceDocumentId, "copy its permissions"); err != nil {
+		return 0, err
+	}
+	if err = ds.checkOwner(ctx, callerId, targetDocumentId, "copy permissions onto it"); err != nil {
+		return 0, err
+	}
+	copied, err = ds.documentRepo.CopyPermissions(
+		ctx, callerId, sourceDocumentId, targetDocumentId, ds.maxGuestsPerDocument,
+	)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when copying permissions", err)
+		}
+	}
+	return copied, err
+}
+
+func checkGuestLabel(label *string) error {
+	if label != nil && utf8.RuneCountInString(*label) > MaxGuestLabelLength {
+		return InvalidInput(
+			fmt.Sprintf("the label of a guest can be at most %d characters", MaxGuestLabelLength),
+			nil,
+		)
+	}
+	return nil
+}
+
+// only the owner of the document can label its guests, a nil label clears the label
+func (ds *DocumentService) UpdateGuestLabel(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+	guestId uuid.UUID,
+	label *string,
+) (err error) {
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return err
+	}
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return err
+	}
+	if err = checkIdNotNil("guest id", guestId); err != nil {
+		return err
+	}
+	if err = checkGuestLabel(label); err != nil {
+		return err
+	}
+	if err = ds.checkOwner(ctx, callerId, documentId, "label its guests"); err != nil {
+		return err
+	}
+	err = ds.documentRepo.UpdateGuestLabel(ctx, documentId, guestId, label)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unknown error found when updating the label of a guest", err)
+		}
+	}
+	return err
+}
+
+// invalidate the tokens that were issued to the guest, for example after its share link leaked.
+// The guest keeps its permission and the history of its access, tokens issued after the
+// rotation carry the returned token version. Only the owner of the document can rotate the
+// links of its guests
+func (ds *DocumentService) RotateGuestLink(
+	ctx context.Context,
+	guestId uuid.UUID,
+	callerId uuid.UUID,
+) (tokenVersion int32, err error) {
+	if err = checkIdNotNil("guest id", guestId); err != nil {
+		return 0, err
+	}
+	documentId, _, err := ds.documentRepo.GetGuest(ctx, guestId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unknown error found when reading the guest", err)
+		}
+		return 0, err
+	}
+	if err = ds.checkOwner(ctx, callerId, documentId, "rotate the links of its guests"); err != nil {
+		return 0, err
+	}
+	tokenVersion, err = ds.documentRepo.RotateGuestTokenVersion(ctx, guestId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unknown error found when rotating the link of the guest", err)
+		}
+	}
+	return tokenVersion, err
+}
+
+// the current token version of the guest, tokens issued with an older version were revoked by
+// rotating the link of the guest. Only the guest can read its token version, which the gateway
+// does for the guest of each guest token that it verifies
+func (ds *DocumentService) GetGuestTokenVersion(
+	ctx context.Context,
+	callerId uuid.UUID,
+	guestId uuid.UUID,
+) (tokenVersion int32, err error) {
+	if err = checkIdNotNil("caller id", callerId); err != nil {
+		return 0, err
+	}
+	if err = checkIdNotNil("guest id", guestId); err != nil {
+		return 0, err
+	}
+	if callerId != guestId {
+		return 0, PermissionDenied(
+			fmt.Sprintf(
+				"principal: %s can not read the token version of guest: %s", callerId.String(), guestId.String(),
+			),
+			nil,
+		)
+	}
+	_, tokenVersion, err = ds.documentRepo.GetGuest(ctx, guestId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unknown error found when reading the guest", err)
+		}
+	}
+	return tokenVersion, err
+}
+
+// a collaborator removes their own permission on a document without involving the owner. The
+// owner cannot leave because every document must keep an owner
+func (ds *DocumentService) LeaveDocument(
+	ctx context.Context,
+	callerId uuid.UUID,
+	documentId uuid.UUID,
+) (err error) {
+	level, found, err := ds.documentRepo.GetPermissionLevel(ctx, documentId, callerId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error found when reading the permission of the caller", err)
+		}
+		return err
+	}
+	if !found {
+		return NotFound(
+			fmt.Sprintf(
+				"principal: %s has no permission on document: %s to leave",
+				callerId.String(), documentId.String(),
+			),
+			nil,
+		)
+	}
+	if level == Owner {
+		return InvalidInput(
+			fmt.Sprintf(
+				"principal: %s owns document: %s, the owner cannot leave a document, delete it or have its ownership reassigned instead",
+				callerId.String(), documentId.String(),
+			),
+			nil,
+		)
+	}
+	err = ds.documentRepo.DeletePermissionsPrincipal(ctx, callerId, documentId)
+	if err != nil {
+		if _, ok := err.(DomainError); !ok {
+			err = RepoImpl("unexpected error encountered when leaving document", err)
+		}
+	}
+	return err
+}
+
 func (ds *DocumentService) DeletePermissionPrincipal(
 	ctx context.Context,
 	recipientId uuid.UUID,
@@ -390,6 +2417,12 @@ func (ds *DocumentService) DeletePermissionPrincipal(
 	// TODO: add some permission logic here, we want to make sure that the calling userId
 	// 		 has the owner permission on the document so that they can delete other principals
 	//		 permissions
+	if err = checkIdNotNil("recipient id", recipientId); err != nil {
+		return err
+	}
+	if err = checkIdNotNil("document id", documentId); err != nil {
+		return err
+	}
 	err = ds.documentRepo.DeletePermissionsPrincipal(
 		ctx, recipientId, documentId,
 	)
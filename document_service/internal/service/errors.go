@@ -48,6 +48,54 @@ func (e *UniqueConflictError) Error() string {
 func (e *UniqueConflictError) Unwrap() error { return e.Err }
 func (e *UniqueConflictError) isDomainError() {}
 
+type PermissionDeniedError struct {
+	Msg string
+	Err error
+}
+
+func (e *PermissionDeniedError) Error() string {
+	return fmt.Sprintf("permission denied, msg: %s, err: %v", e.Msg, e.Err)
+}
+func (e *PermissionDeniedError) Unwrap() error { return e.Err }
+func (e *PermissionDeniedError) isDomainError() {}
+
+// returned when a mutation targets a document that has been archived. This is distinct from
+// not found because the document still exists and can be restored
+type GoneError struct {
+	Msg string
+	Err error
+}
+
+func (e *GoneError) Error() string {
+	return fmt.Sprintf("this resource has been archived, msg: %s, err: %v", e.Msg, e.Err)
+}
+func (e *GoneError) Unwrap() error { return e.Err }
+func (e *GoneError) isDomainError() {}
+
+// returned when a mutation would take a document past one of its configured limits
+type QuotaExceededError struct {
+	Msg string
+	Err error
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded, msg: %s, err: %v", e.Msg, e.Err)
+}
+func (e *QuotaExceededError) Unwrap() error { return e.Err }
+func (e *QuotaExceededError) isDomainError() {}
+
+// returned when the service is at capacity, unlike a quota this clears once other callers are done
+type ResourceExhaustedError struct {
+	Msg string
+	Err error
+}
+
+func (e *ResourceExhaustedError) Error() string {
+	return fmt.Sprintf("resource exhausted, msg: %s, err: %v", e.Msg, e.Err)
+}
+func (e *ResourceExhaustedError) Unwrap() error { return e.Err }
+func (e *ResourceExhaustedError) isDomainError() {}
+
 func RepoImpl(msg string, err error) *RepoImplError {
 	return &RepoImplError{
 		Msg: msg,
@@ -76,4 +124,32 @@ func UniqueConflict(msg string, err error) *UniqueConflictError {
 	}
 }
 
+func PermissionDenied(msg string, err error) *PermissionDeniedError {
+	return &PermissionDeniedError{
+		Msg: msg,
+		Err: err,
+	}
+}
+
+func Gone(msg string, err error) *GoneError {
+	return &GoneError{
+		Msg: msg,
+		Err: err,
+	}
+}
+
+func QuotaExceeded(msg string, err error) *QuotaExceededError {
+	return &QuotaExceededError{
+		Msg: msg,
+		Err: err,
+	}
+}
+
+func ResourceExhausted(msg string, err error) *ResourceExhaustedError {
+	return &ResourceExhaustedError{
+		Msg: msg,
+		Err: err,
+	}
+}
+
 var ErrNilPointer error = fmt.Errorf("pointer must not be nil")
\ No newline at end of file
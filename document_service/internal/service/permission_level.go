@@ -0,0 +1,76 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// the names of the permission levels, these match the names used by the database and the
+// api gateway
+var permissionLevelNames = map[PermissionLevel]string{
+	Viewer: "viewer",
+	Editor: "editor",
+	Owner: "owner",
+}
+
+// print the name of the permission level so that logs and error messages are readable, an
+// unknown level prints its number
+func (p PermissionLevel) String() string {
+	if name, ok := permissionLevelNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("PermissionLevel(%d)", int32(p))
+}
+
+// parse the name of a permission level, case is ignored
+func ParsePermissionLevel(name string) (PermissionLevel, error) {
+	for _, level := range AllPermissions {
+		if strings.EqualFold(name, permissionLevelNames[level]) {
+			return level, nil
+		}
+	}
+	return 0, InvalidInput(
+		fmt.Sprintf("%q is not a valid permission level, want one of: viewer, editor, owner", name),
+		nil,
+	)
+}
+
+// parse the names of the permission levels that guests are allowed to hold, owner is rejected
+// because a guest can never own a document
+func ParseGuestPermissions(names []string) ([]PermissionLevel, error) {
+	levels := make([]PermissionLevel, 0, len(names))
+	for _, name := range names {
+		level, err := ParsePermissionLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		if level == Owner {
+			return nil, InvalidInput("guests cannot be allowed the owner permission level", nil)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// permission levels are marshaled as their name instead of their number
+func (p PermissionLevel) MarshalJSON() ([]byte, error) {
+	name, ok := permissionLevelNames[p]
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal unknown permission level: %d", int32(p))
+	}
+	return json.Marshal(name)
+}
+
+func (p *PermissionLevel) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("a permission level must be a string: %w", err)
+	}
+	level, err := ParsePermissionLevel(name)
+	if err != nil {
+		return err
+	}
+	*p = level
+	return nil
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// how often the downgrade worker looks for due downgrades when the interval is not configured
+const DefaultDowngradeInterval = time.Minute
+
+// applies the permission downgrades that are due at now, implemented by the document service
+type DowngradeApplier interface {
+	ApplyDueDowngrades(ctx context.Context, now time.Time, batchSize int32) (applied int64, err error)
+}
+
+// periodically lowers the permissions whose scheduled downgrade time has passed. A failed run
+// is logged and retried on the next tick instead of stopping the worker
+type DowngradeWorker struct {
+	applier DowngradeApplier
+	interval time.Duration
+	batchSize int32
+	// the clock that the downgrade times are compared against, tests replace it to move time
+	// forward without waiting
+	Now func() time.Time
+}
+
+func NewDowngradeWorker(applier DowngradeApplier, interval time.Duration, batchSize int32) *DowngradeWorker {
+	return &DowngradeWorker{
+		applier: applier,
+		interval: interval,
+		batchSize: batchSize,
+		Now: time.Now,
+	}
+}
+
+func (w *DowngradeWorker) Name() string {
+	return "permission downgrades"
+}
+
+// apply the due downgrades once, returns the number of permissions that were downgraded
+func (w *DowngradeWorker) RunOnce(ctx context.Context) (int64, error) {
+	applied, err := w.applier.ApplyDueDowngrades(ctx, w.Now(), w.batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to apply due permission downgrades", "applied", applied, "error", err)
+		return applied, err
+	}
+	if applied > 0 {
+		slog.InfoContext(ctx, "applied due permission downgrades", "applied", applied)
+	}
+	return applied, nil
+}
+
+func (w *DowngradeWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			_, _ = w.RunOnce(ctx)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/townsag/reed/document_service/internal/worker"
+)
+
+// records the time and batch size of every run, the first run fails. Later runs do not block
+// when the test has stopped reading from ran
+type fakeDowngradeApplier struct {
+	mu sync.Mutex
+	times []time.Time
+	batchSizes []int32
+	ran chan struct{}
+}
+
+func (f *fakeDowngradeApplier) ApplyDueDowngrades(ctx context.Context, now time.Time, batchSize int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.times = append(f.times, now)
+	f.batchSizes = append(f.batchSizes, batchSize)
+	select {
+	case f.ran <- struct{}{}:
+	default:
+	}
+	if len(f.times) == 1 {
+		return 0, errors.New("database unavailable")
+	}
+	return 1, nil
+}
+
+func TestDowngradeWorker_RunsOnEachTick_Unit(t *testing.T) {
+	applier := &fakeDowngradeApplier{ ran: make(chan struct{}, 2) }
+	downgradeWorker := worker.NewDowngradeWorker(applier, time.Millisecond, 25)
+	fakeNow := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	downgradeWorker.Now = func() time.Time { return fakeNow }
+	manager := worker.NewManager(time.Second)
+	manager.Register(downgradeWorker)
+	manager.Start(t.Context())
+	// the failed first run does not stop the worker
+	waitFor(t, applier.ran, "run")
+	waitFor(t, applier.ran, "run again")
+	if err := manager.Shutdown(); err != nil {
+		t.Fatalf("want the worker to stop before the timeout, got: %v", err)
+	}
+	applier.mu.Lock()
+	defer applier.mu.Unlock()
+	for i := range 2 {
+		if !applier.times[i].Equal(fakeNow) || applier.batchSizes[i] != 25 {
+			t.Errorf("want run %d at: %v with batch size: 25, got: %v with batch size: %d", i, fakeNow, applier.times[i], applier.batchSizes[i])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// how long Shutdown waits for the workers to return when the wait is not configured
+const DefaultShutdownTimeout = 10 * time.Second
+
+// a background task of the service, for example a dispatcher that polls a table. Start blocks
+// until the worker is done and must return soon after its context is cancelled
+type Worker interface {
+	Name() string
+	Start(ctx context.Context) error
+}
+
+// starts the registered workers together and stops them together when the service shuts down
+type Manager struct {
+	workers []Worker
+	shutdownTimeout time.Duration
+	cancel context.CancelFunc
+	// the names of the workers that have not returned yet
+	mu sync.Mutex
+	running map[string]struct{}
+	done sync.WaitGroup
+}
+
+func NewManager(shutdownTimeout time.Duration) *Manager {
+	return &Manager{
+		shutdownTimeout: shutdownTimeout,
+		running: make(map[string]struct{}),
+	}
+}
+
+// workers have to be registered before the manager is started
+func (m *Manager) Register(worker Worker) {
+	m.workers = append(m.workers, worker)
+}
+
+// run each registered worker in its own goroutine. The workers are cancelled when the parent
+// context is cancelled or when Shutdown is called, whichever comes first
+func (m *Manager) Start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+	for _, worker := range m.workers {
+		m.mu.Lock()
+		m.running[worker.Name()] = struct{}{}
+		m.mu.Unlock()
+		m.done.Add(1)
+		go m.run(ctx, worker)
+	}
+}
+
+func (m *Manager) run(ctx context.Context, worker Worker) {
+	defer m.done.Done()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, worker.Name())
+		m.mu.Unlock()
+	}()
+	slog.InfoContext(ctx, "starting background worker", "worker", worker.Name())
+	err := worker.Start(ctx)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.ErrorContext(ctx, "background worker stopped with an error", "worker", worker.Name(), "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "background worker stopped", "worker", worker.Name())
+}
+
+// cancel the workers and wait for them to return. Returns an error naming the workers that
+// were still running when the shutdown timeout passed, those workers are abandoned
+func (m *Manager) Shutdown() error {
+	if m.cancel == nil {
+		return nil
+	}
+	m.cancel()
+	stopped := make(chan struct{})
+	go func() {
+		m.done.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-time.After(m.shutdownTimeout):
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		names := make([]string, 0, len(m.running))
+		for name := range m.running {
+			names = append(names, name)
+		}
+		return fmt.Errorf(
+			"background workers did not stop within %v: %s", m.shutdownTimeout, strings.Join(names, ", "),
+		)
+	}
+}
@@ -0,0 +1,103 @@
+package worker_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/townsag/reed/document_service/internal/worker"
+)
+
+// a worker that blocks until it is cancelled and records that it saw the cancellation
+type recordingWorker struct {
+	name string
+	started chan struct{}
+	cancelled chan struct{}
+}
+
+func newRecordingWorker(name string) *recordingWorker {
+	return &recordingWorker{
+		name: name,
+		started: make(chan struct{}),
+		cancelled: make(chan struct{}),
+	}
+}
+
+func (w *recordingWorker) Name() string {
+	return w.name
+}
+
+func (w *recordingWorker) Start(ctx context.Context) error {
+	close(w.started)
+	<-ctx.Done()
+	close(w.cancelled)
+	return ctx.Err()
+}
+
+// a worker that ignores the cancellation of its context
+type stuckWorker struct {
+	release chan struct{}
+}
+
+func (w *stuckWorker) Name() string {
+	return "stuck"
+}
+
+func (w *stuckWorker) Start(ctx context.Context) error {
+	<-w.release
+	return nil
+}
+
+func waitFor(t *testing.T, signal chan struct{}, event string) {
+	select {
+	case <-signal:
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for the worker to be %s", event)
+	}
+}
+
+func TestManager_StartsAndCancelsWorkers_Unit(t *testing.T) {
+	manager := worker.NewManager(time.Second)
+	workers := []*recordingWorker{ newRecordingWorker("first"), newRecordingWorker("second") }
+	for _, w := range workers {
+		manager.Register(w)
+	}
+	manager.Start(t.Context())
+	for _, w := range workers {
+		waitFor(t, w.started, "started")
+	}
+	if err := manager.Shutdown(); err != nil {
+		t.Fatalf("want the workers to stop before the timeout, got: %v", err)
+	}
+	for _, w := range workers {
+		select {
+		case <-w.cancelled:
+		default:
+			t.Errorf("want worker: %s to see the cancellation before shutdown returns", w.name)
+		}
+	}
+}
+
+func TestManager_ParentContextCancelsWorkers_Unit(t *testing.T) {
+	manager := worker.NewManager(time.Second)
+	w := newRecordingWorker("worker")
+	manager.Register(w)
+	ctx, cancel := context.WithCancel(t.Context())
+	manager.Start(ctx)
+	waitFor(t, w.started, "started")
+	cancel()
+	waitFor(t, w.cancelled, "cancelled")
+}
+
+func TestManager_ShutdownTimeout_Unit(t *testing.T) {
+	manager := worker.NewManager(10 * time.Millisecond)
+	stuck := &stuckWorker{ release: make(chan struct{}) }
+	defer close(stuck.release)
+	manager.Register(stuck)
+	manager.Start(t.Context())
+	err := manager.Shutdown()
+	if err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Errorf("want an error naming the worker that did not stop, got: %v", err)
+	}
+}
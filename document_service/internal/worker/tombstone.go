@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// how often the tombstone worker prunes expired tombstones when the interval is not configured
+const DefaultTombstonePruneInterval = time.Hour
+
+// deletes the tombstones that are past their retention at now, implemented by the document service
+type TombstonePruner interface {
+	PruneDocumentTombstones(ctx context.Context, now time.Time) (pruned int64, err error)
+}
+
+// periodically deletes the tombstones of documents that were deleted longer ago than the
+// retention so that the table does not grow with every document that was ever deleted. A failed
+// run is logged and retried on the next tick instead of stopping the worker
+type TombstoneWorker struct {
+	pruner TombstonePruner
+	interval time.Duration
+	// the clock that the tombstone retention is measured from, tests replace it to move time
+	// forward without waiting
+	Now func() time.Time
+}
+
+func NewTombstoneWorker(pruner TombstonePruner, interval time.Duration) *TombstoneWorker {
+	return &TombstoneWorker{
+		pruner: pruner,
+		interval: interval,
+		Now: time.Now,
+	}
+}
+
+func (w *TombstoneWorker) Name() string {
+	return "document tombstone pruning"
+}
+
+// prune the expired tombstones once, returns the number of tombstones that were deleted
+func (w *TombstoneWorker) RunOnce(ctx context.Context) (int64, error) {
+	pruned, err := w.pruner.PruneDocumentTombstones(ctx, w.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to prune document tombstones", "error", err)
+		return 0, err
+	}
+	if pruned > 0 {
+		slog.InfoContext(ctx, "pruned document tombstones", "pruned", pruned)
+	}
+	return pruned, nil
+}
+
+func (w *TombstoneWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			_, _ = w.RunOnce(ctx)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/townsag/reed/document_service/internal/worker"
+)
+
+// records the time of every run, the first run fails. Later runs do not block when the test has
+// stopped reading from ran
+type fakeTombstonePruner struct {
+	mu sync.Mutex
+	times []time.Time
+	ran chan struct{}
+}
+
+func (f *fakeTombstonePruner) PruneDocumentTombstones(ctx context.Context, now time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.times = append(f.times, now)
+	select {
+	case f.ran <- struct{}{}:
+	default:
+	}
+	if len(f.times) == 1 {
+		return 0, errors.New("database unavailable")
+	}
+	return 1, nil
+}
+
+func TestTombstoneWorker_RunsOnEachTick_Unit(t *testing.T) {
+	pruner := &fakeTombstonePruner{ ran: make(chan struct{}, 2) }
+	tombstoneWorker := worker.NewTombstoneWorker(pruner, time.Millisecond)
+	fakeNow := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	tombstoneWorker.Now = func() time.Time { return fakeNow }
+	manager := worker.NewManager(time.Second)
+	manager.Register(tombstoneWorker)
+	manager.Start(t.Context())
+	// the failed first run does not stop the worker
+	waitFor(t, pruner.ran, "run")
+	waitFor(t, pruner.ran, "run again")
+	if err := manager.Shutdown(); err != nil {
+		t.Fatalf("want the worker to stop before the timeout, got: %v", err)
+	}
+	pruner.mu.Lock()
+	defer pruner.mu.Unlock()
+	for i := range 2 {
+		if !pruner.times[i].Equal(fakeNow) {
+			t.Errorf("want run %d at: %v, got: %v", i, fakeNow, pruner.times[i])
+		}
+	}
+}
@@ -2,12 +2,20 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"iter"
+	"time"
 
 	"github.com/google/uuid"
 	pb "github.com/townsag/reed/document_service/api/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type DocumentServiceClient struct {
@@ -16,7 +24,17 @@ type DocumentServiceClient struct {
 }
 
 func NewDocumentServiceClient(addr string) (*DocumentServiceClient, error) {
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return NewDocumentServiceClientWithTLS(addr, nil)
+}
+
+// connect over tls when tlsConfig is set, a nil config connects in plaintext. The config has
+// to match the server, see GetServerTLSConfig of the document service
+func NewDocumentServiceClientWithTLS(addr string, tlsConfig *tls.Config) (*DocumentServiceClient, error) {
+	transportCredentials := insecure.NewCredentials()
+	if tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(transportCredentials))
 	// TODO: this is where we should add an observability interceptor
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a connection: %s", err.Error())
@@ -34,31 +52,94 @@ func (c *DocumentServiceClient) Close() error {
 	return c.conn.Close()
 }
 
-/*
-TODO:
-- add some validations that the required pointers 
-  that are being passed in are not nil
-- add some validations that the arrays that are being passed in that we expect to have 
-  values in them actually have values in them
-*/
+// the reason of the error info detail that the document service attaches to the failed
+// precondition error of a mutation on an archived document
+const ReasonDocumentArchived = "DOCUMENT_ARCHIVED"
+
+// the reason of the error info detail that the document service attaches to the resource
+// exhausted error of a mutation that would take a document past one of its limits, like the
+// most guests a document can have
+const ReasonQuotaExceeded = "QUOTA_EXCEEDED"
+
+// reports whether the error is the error of a mutation on an archived document. Other failed
+// precondition errors do not carry the archived reason
+func IsDocumentArchived(err error) bool {
+	return hasReason(err, codes.FailedPrecondition, ReasonDocumentArchived)
+}
+
+// reports whether the error is the error of a mutation that would exceed a limit of the
+// document. Resource exhausted errors without the reason are rate limits and can be retried
+func IsQuotaExceeded(err error) bool {
+	return hasReason(err, codes.ResourceExhausted, ReasonQuotaExceeded)
+}
+
+func hasReason(err error, code codes.Code, reason string) bool {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != code {
+		return false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetReason() == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// a required id argument of a client method and the name it is reported with
+type requiredId struct {
+	name string
+	id uuid.UUID
+}
+
+// the required arguments are checked before the rpc is made so that a missing id is not sent
+// to the document service as the string of the nil uuid. The error has the invalid argument
+// code that the document service would have returned so callers handle both the same way
+func checkRequiredIds(ids ...requiredId) error {
+	for _, required := range ids {
+		if required.id == uuid.Nil {
+			return status.Errorf(codes.InvalidArgument, "%s must not be the nil uuid", required.name)
+		}
+	}
+	return nil
+}
+
+// check that a required list of ids has at least one id and none of its ids are the nil uuid
+func checkRequiredIdList(name string, ids uuid.UUIDs) error {
+	if len(ids) == 0 {
+		return status.Errorf(codes.InvalidArgument, "%s must have at least one id", name)
+	}
+	for _, id := range ids {
+		if id == uuid.Nil {
+			return status.Errorf(codes.InvalidArgument, "%s must not contain the nil uuid", name)
+		}
+	}
+	return nil
+}
 
 func (c *DocumentServiceClient) CreateDocument(
 	ctx context.Context,
 	ownerUserId uuid.UUID,
 	documentName *string,
 	documentDescription *string,
+	clientDocumentId *uuid.UUID,
 ) (uuid.UUID, error) {
-	reply, err := c.client.CreateDocument(
-		ctx,
-		&pb.CreateDocumentRequest{
-			OwnerUserId: ownerUserId.String(),
-			DocumentName: documentName,
-			DocumentDescription: documentDescription,
-			ClientContext: &pb.ClientContext{
-				PrincipalId: ownerUserId.String(),
-			},
+	if err := checkRequiredIds(requiredId{ "ownerUserId", ownerUserId }); err != nil {
+		return uuid.Nil, err
+	}
+	request := &pb.CreateDocumentRequest{
+		OwnerUserId: ownerUserId.String(),
+		DocumentName: documentName,
+		DocumentDescription: documentDescription,
+		ClientContext: &pb.ClientContext{
+			PrincipalId: ownerUserId.String(),
 		},
-	)
+	}
+	if clientDocumentId != nil {
+		id := clientDocumentId.String()
+		request.ClientDocumentId = &id
+	}
+	reply, err := c.client.CreateDocument(ctx, request)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -70,18 +151,29 @@ func (c *DocumentServiceClient) CreateDocument(
 	return documentId, nil
 }
 
+// publicLinkVersion is the version of the public link the principal presented, nil when they did not present one,
+// includeCollaboratorCount costs the document service an extra query
 func (c *DocumentServiceClient) GetDocument(
 	ctx context.Context,
 	documentId uuid.UUID,
 	principalId uuid.UUID,
+	publicLinkVersion *int32,
+	includeTombstone bool,
+	includeCollaboratorCount bool,
 ) (*pb.GetDocumentReply, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "principalId", principalId }); err != nil {
+		return nil, err
+	}
 	return c.client.GetDocument(
 		ctx,
 		&pb.GetDocumentRequest{
 			DocumentId: documentId.String(),
 			ClientContext: &pb.ClientContext{
 				PrincipalId: principalId.String(),
+				PublicLinkVersion: publicLinkVersion,
 			},
+			IncludeTombstone: includeTombstone,
+			IncludeCollaboratorCount: includeCollaboratorCount,
 		},
 	)
 }
@@ -92,13 +184,21 @@ func (c *DocumentServiceClient) UpdateDocument(
 	principalId uuid.UUID,
 	name *string,
 	description *string,
+	// set the name or description back to null, the name or description that is cleared must be nil
+	clearName bool,
+	clearDescription bool,
 ) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "principalId", principalId }); err != nil {
+		return err
+	}
 	_, err := c.client.UpdateDocument(
 		ctx,
 		&pb.UpdateDocumentRequest{
 			DocumentId: documentId.String(),
 			Name: name,
 			Description: description,
+			ClearName: clearName,
+			ClearDescription: clearDescription,
 			ClientContext: &pb.ClientContext{
 				PrincipalId: principalId.String(),
 			},
@@ -112,6 +212,9 @@ func (c *DocumentServiceClient) DeleteDocument(
 	documentId uuid.UUID,
 	userId uuid.UUID,
 ) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "userId", userId }); err != nil {
+		return err
+	}
 	_, err := c.client.DeleteDocument(
 		ctx,
 		&pb.DeleteDocumentRequest{
@@ -130,6 +233,12 @@ func (c *DocumentServiceClient) DeleteDocuments(
 	documentIds uuid.UUIDs,
 	userId uuid.UUID,
 ) error {
+	if err := checkRequiredIds(requiredId{ "userId", userId }); err != nil {
+		return err
+	}
+	if err := checkRequiredIdList("documentIds", documentIds); err != nil {
+		return err
+	}
 	_, err := c.client.DeleteDocuments(
 		ctx,
 		&pb.DeleteDocumentsRequest{
@@ -143,19 +252,217 @@ func (c *DocumentServiceClient) DeleteDocuments(
 	return err
 }
 
+// only the owner of the document can archive it, mutations on an archived document fail
+// until it is restored
+func (c *DocumentServiceClient) ArchiveDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return err
+	}
+	_, err := c.client.ArchiveDocument(
+		ctx,
+		&pb.ArchiveDocumentRequest{
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+	return err
+}
+
+func (c *DocumentServiceClient) RestoreDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return err
+	}
+	_, err := c.client.RestoreDocument(
+		ctx,
+		&pb.RestoreDocumentRequest{
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+	return err
+}
+
+// move every document owned by the from owner to the to owner and return the number of
+// documents that were moved. The calling principal is the admin that requested the move
+func (c *DocumentServiceClient) ReassignOwnedDocuments(
+	ctx context.Context,
+	fromOwnerId uuid.UUID,
+	toOwnerId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) (int64, error) {
+	if err := checkRequiredIds(requiredId{ "fromOwnerId", fromOwnerId }, requiredId{ "toOwnerId", toOwnerId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return 0, err
+	}
+	reply, err := c.client.ReassignOwnedDocuments(
+		ctx,
+		&pb.ReassignOwnedDocumentsRequest{
+			FromOwnerId: fromOwnerId.String(),
+			ToOwnerId: toOwnerId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return reply.MovedCount, nil
+}
+
+// make the fallback owner the owner of the document if the document has no owner, repaired is
+// true when an owner was assigned. The calling principal is the admin that requested the repair
+func (c *DocumentServiceClient) EnsureDocumentHasOwner(
+	ctx context.Context,
+	documentId uuid.UUID,
+	fallbackOwnerId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) (bool, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "fallbackOwnerId", fallbackOwnerId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return false, err
+	}
+	reply, err := c.client.EnsureDocumentHasOwner(
+		ctx,
+		&pb.EnsureDocumentHasOwnerRequest{
+			DocumentId: documentId.String(),
+			FallbackOwnerId: fallbackOwnerId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return reply.Repaired, nil
+}
+
+// a nil public access disables the public link of the document
+func (c *DocumentServiceClient) SetPublicAccess(
+	ctx context.Context,
+	documentId uuid.UUID,
+	userId uuid.UUID,
+	publicAccess *pb.PermissionLevel,
+) (publicLinkVersion int32, err error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "userId", userId }); err != nil {
+		return 0, err
+	}
+	reply, err := c.client.SetPublicAccess(
+		ctx,
+		&pb.SetPublicAccessRequest{
+			DocumentId: documentId.String(),
+			PublicAccess: publicAccess,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: userId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return reply.GetPublicLinkVersion(), nil
+}
+
 func (c *DocumentServiceClient) ListDocumentsByPrincipal(
 	ctx context.Context,
 	targetPrincipalId uuid.UUID,
 	callingPrincipalId uuid.UUID,
 	permissionFilter []pb.PermissionLevel,
+	favoritesOnly bool,
+	collectionId *uuid.UUID,
+	includeArchived bool,
+	cursor *pb.Cursor,
+	pageSize *int32,
+) (*pb.ListDocumentsByPrincipalReply, error) {
+	if err := checkRequiredIds(requiredId{ "targetPrincipalId", targetPrincipalId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	req := &pb.ListDocumentByPrincipalRequest{
+		PrincipalId: targetPrincipalId.String(),
+		PermissionsFilter: permissionFilter,
+		Cursor: cursor,
+		PageSize: pageSize,
+		FavoritesOnly: favoritesOnly,
+		IncludeArchived: includeArchived,
+		ClientContext: &pb.ClientContext{
+			PrincipalId: callingPrincipalId.String(),
+		},
+	}
+	if collectionId != nil {
+		collectionIdString := collectionId.String()
+		req.CollectionId = &collectionIdString
+	}
+	return c.client.ListDocumentsByPrincipal(ctx, req)
+}
+
+// yields every document that the target principal has permissions on, paging from the given
+// cursor until the service reports that there are no more documents. A nil cursor starts from
+// the beginning of the traversal. The first error is yielded and ends the iteration
+func (c *DocumentServiceClient) IterateDocumentsByPrincipal(
+	ctx context.Context,
+	targetPrincipalId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+	permissionFilter []pb.PermissionLevel,
+	cursor *pb.Cursor,
+	pageSize *int32,
+) iter.Seq2[*pb.ListDocumentsByPrincipalReply_DocumentPermission, error] {
+	return func(yield func(*pb.ListDocumentsByPrincipalReply_DocumentPermission, error) bool) {
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			reply, err := c.ListDocumentsByPrincipal(
+				ctx, targetPrincipalId, callingPrincipalId, permissionFilter, false, nil, false, cursor, pageSize,
+			)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, documentPermission := range reply.DocumentPermissions {
+				if !yield(documentPermission, nil) {
+					return
+				}
+			}
+			if !reply.HasMore {
+				return
+			}
+			cursor = reply.Cursor
+		}
+	}
+}
+
+// the cursor returned by the previous page takes precedence over since
+func (c *DocumentServiceClient) ListDocumentsModifiedSince(
+	ctx context.Context,
+	targetPrincipalId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+	since time.Time,
 	cursor *pb.Cursor,
 	pageSize *int32,
 ) (*pb.ListDocumentsByPrincipalReply, error) {
-	return c.client.ListDocumentsByPrincipal(
+	if err := checkRequiredIds(requiredId{ "targetPrincipalId", targetPrincipalId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.ListDocumentsModifiedSince(
 		ctx,
-		&pb.ListDocumentByPrincipalRequest{
+		&pb.ListDocumentsModifiedSinceRequest{
 			PrincipalId: targetPrincipalId.String(),
-			PermissionsFilter: permissionFilter,
+			Since: timestamppb.New(since),
 			Cursor: cursor,
 			PageSize: pageSize,
 			ClientContext: &pb.ClientContext{
@@ -165,127 +472,769 @@ func (c *DocumentServiceClient) ListDocumentsByPrincipal(
 	)
 }
 
-func (c *DocumentServiceClient) GetPermissionsOfPrincipalOnDocument(
+func (c *DocumentServiceClient) ListRecentlyAccessed(
 	ctx context.Context,
-	documentId uuid.UUID,
-	targetPrincipalId uuid.UUID,
-	callingPrincipalId uuid.UUID,
-) (*pb.GetPermissionsReply, error) {
-	return c.client.GetPermissionsOfPrincipalOnDocument(
+	principalId uuid.UUID,
+	cursor *pb.Cursor,
+	pageSize *int32,
+) (*pb.ListRecentlyAccessedReply, error) {
+	if err := checkRequiredIds(requiredId{ "principalId", principalId }); err != nil {
+		return nil, err
+	}
+	return c.client.ListRecentlyAccessed(
 		ctx,
-		&pb.GetPermissionsRequest{
-			DocumentId: documentId.String(),
-			PrincipalId: targetPrincipalId.String(),
+		&pb.ListRecentlyAccessedRequest{
+			PrincipalId: principalId.String(),
+			Cursor: cursor,
+			PageSize: pageSize,
 			ClientContext: &pb.ClientContext{
-				PrincipalId: callingPrincipalId.String(),
+				PrincipalId: principalId.String(),
 			},
 		},
 	)
 }
 
-/*
-Sending an empty list of permissions is treated as no permission filter on the 
-server side, therefore it is a valid input to this function
-*/
-func (c *DocumentServiceClient) ListPermissionsOnDocument(
+func (c *DocumentServiceClient) ListSharedDocumentsByOwner(
 	ctx context.Context,
-	documentId uuid.UUID,
-	principalId uuid.UUID,
-	permissionFilter []pb.PermissionLevel,
+	ownerId uuid.UUID,
+	callingPrincipalId uuid.UUID,
 	cursor *pb.Cursor,
 	pageSize *int32,
-) (*pb.ListPermissionsOnDocumentReply, error) {
-	return c.client.ListPermissionsOnDocument(
+) (*pb.ListSharedDocumentsByOwnerReply, error) {
+	if err := checkRequiredIds(requiredId{ "ownerId", ownerId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.ListSharedDocumentsByOwner(
 		ctx,
-		&pb.ListPermissionsOnDocumentRequest{
-			DocumentId: documentId.String(),
-			PermissionsFilter: permissionFilter,
+		&pb.ListSharedDocumentsByOwnerRequest{
+			OwnerId: ownerId.String(),
 			Cursor: cursor,
 			PageSize: pageSize,
 			ClientContext: &pb.ClientContext{
-				PrincipalId: principalId.String(),
+				PrincipalId: callingPrincipalId.String(),
 			},
 		},
 	)
 }
 
-func (c *DocumentServiceClient) CreateGuest(
+// list every document regardless of the principal, the caller must only call this on behalf of
+// an admin. A nil filter matches every document
+func (c *DocumentServiceClient) ListAllDocuments(
+	ctx context.Context,
+	callingPrincipalId uuid.UUID,
+	cursor *pb.Cursor,
+	pageSize *int32,
+	ownerId *uuid.UUID,
+	createdBefore *time.Time,
+	createdAfter *time.Time,
+) (*pb.ListAllDocumentsReply, error) {
+	if err := checkRequiredIds(requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	req := &pb.ListAllDocumentsRequest{
+		Cursor: cursor,
+		PageSize: pageSize,
+		ClientContext: &pb.ClientContext{
+			PrincipalId: callingPrincipalId.String(),
+		},
+	}
+	if ownerId != nil {
+		temp := ownerId.String()
+		req.OwnerId = &temp
+	}
+	if createdBefore != nil {
+		req.CreatedBefore = timestamppb.New(*createdBefore)
+	}
+	if createdAfter != nil {
+		req.CreatedAfter = timestamppb.New(*createdAfter)
+	}
+	return c.client.ListAllDocuments(ctx, req)
+}
+
+func (c *DocumentServiceClient) CountDocumentsByPrincipalGrouped(
+	ctx context.Context,
+	principalId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) (*pb.CountDocumentsByPrincipalGroupedReply, error) {
+	if err := checkRequiredIds(requiredId{ "principalId", principalId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.CountDocumentsByPrincipalGrouped(
+		ctx,
+		&pb.CountDocumentsByPrincipalGroupedRequest{
+			PrincipalId: principalId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+}
+
+func (c *DocumentServiceClient) GetDocumentHistory(
 	ctx context.Context,
 	documentId uuid.UUID,
-	userId uuid.UUID,
-	permissionLevel pb.PermissionLevel,
-) (*pb.CreateGuestReply, error) {
-	return c.client.CreateGuest(
+	callingPrincipalId uuid.UUID,
+	cursor *pb.Cursor,
+	pageSize *int32,
+) (*pb.GetDocumentHistoryReply, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.GetDocumentHistory(
 		ctx,
-		&pb.CreateGuestRequest{
+		&pb.GetDocumentHistoryRequest{
 			DocumentId: documentId.String(),
-			PermissionLevel: permissionLevel,
+			Cursor: cursor,
+			PageSize: pageSize,
 			ClientContext: &pb.ClientContext{
-				PrincipalId: userId.String(),
-				PrincipalType: pb.Principal_USER.Enum(),
+				PrincipalId: callingPrincipalId.String(),
 			},
 		},
 	)
 }
 
-func (c *DocumentServiceClient) UpsertPermissionUser(
+func (c *DocumentServiceClient) GetDocumentSharingSummary(
 	ctx context.Context,
-	targetUserId uuid.UUID,
-	callingUserId uuid.UUID,
 	documentId uuid.UUID,
-	permissionLevel pb.PermissionLevel,
-) error {
-	_, err := c.client.UpsertPermissionUser(
+	callingPrincipalId uuid.UUID,
+) (*pb.GetDocumentSharingSummaryReply, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.GetDocumentSharingSummary(
 		ctx,
-		&pb.UpsertPermissionUserRequest{
-			UserId: targetUserId.String(),
+		&pb.GetDocumentSharingSummaryRequest{
 			DocumentId: documentId.String(),
-			PermissionLevel: permissionLevel,
 			ClientContext: &pb.ClientContext{
-				PrincipalId: callingUserId.String(),
-				PrincipalType: pb.Principal_USER.Enum(),
+				PrincipalId: callingPrincipalId.String(),
 			},
 		},
 	)
-	return err
 }
 
-func (c *DocumentServiceClient) UpdatePermissionGuest(
+func (c *DocumentServiceClient) GetPermissionsOfPrincipalOnDocument(
 	ctx context.Context,
-	guestId uuid.UUID,
-	callingUserId uuid.UUID,
-	permissionLevel pb.PermissionLevel,
-) error {
-	_, err := c.client.UpdatePermissionGuest(
+	documentId uuid.UUID,
+	targetPrincipalId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+	publicLinkVersion *int32,
+) (*pb.GetPermissionsReply, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "targetPrincipalId", targetPrincipalId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.GetPermissionsOfPrincipalOnDocument(
 		ctx,
-		&pb.UpdatePermissionGuestRequest{
-			GuestId: guestId.String(),
-			PermissionLevel: permissionLevel,
+		&pb.GetPermissionsRequest{
+			DocumentId: documentId.String(),
+			PrincipalId: targetPrincipalId.String(),
 			ClientContext: &pb.ClientContext{
-				PrincipalId: callingUserId.String(),
-				PrincipalType: pb.Principal_USER.Enum(),
+				PrincipalId: callingPrincipalId.String(),
+				PublicLinkVersion: publicLinkVersion,
 			},
 		},
 	)
-	return err
 }
 
-func (c *DocumentServiceClient) DeletePermissionsPrincipal(
+// whether the calling principal can take the action on the document, publicLinkVersion is the
+// version of the public link that the caller presented or nil when it did not present one
+func (c *DocumentServiceClient) CheckAccess(
 	ctx context.Context,
-	principalId uuid.UUID,
 	documentId uuid.UUID,
-	callingUserId uuid.UUID,
-) error {
-	_, err := c.client.DeletePermissionsPrincipal(
+	callingPrincipalId uuid.UUID,
+	publicLinkVersion *int32,
+	action pb.Action,
+) (*pb.CheckAccessReply, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.CheckAccess(
 		ctx,
-		&pb.DeletePermissionsPrincipalRequest{
-			PrincipalId: principalId.String(),
+		&pb.CheckAccessRequest{
 			DocumentId: documentId.String(),
+			Action: action,
 			ClientContext: &pb.ClientContext{
-				PrincipalId: callingUserId.String(),
-				PrincipalType: pb.Principal_USER.Enum(),
+				PrincipalId: callingPrincipalId.String(),
+				PublicLinkVersion: publicLinkVersion,
 			},
 		},
 	)
-	return err
-}
\ No newline at end of file
+}
+
+// the permission levels of the target principal on each of the documents, keyed by document id.
+// Documents that the target principal has no permission on are left out
+func (c *DocumentServiceClient) GetPermissionLevelsForPrincipalOnDocuments(
+	ctx context.Context,
+	documentIds uuid.UUIDs,
+	targetPrincipalId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) (*pb.GetPermissionLevelsReply, error) {
+	if err := checkRequiredIds(requiredId{ "targetPrincipalId", targetPrincipalId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	if err := checkRequiredIdList("documentIds", documentIds); err != nil {
+		return nil, err
+	}
+	return c.client.GetPermissionLevelsForPrincipalOnDocuments(
+		ctx,
+		&pb.GetPermissionLevelsRequest{
+			PrincipalId: targetPrincipalId.String(),
+			DocumentIds: documentIds.Strings(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+}
+
+/*
+Sending an empty list of permissions is treated as no permission filter on the 
+server side, therefore it is a valid input to this function. A non nil excludedPrincipalId
+leaves the permission of that principal out of the listing
+*/
+func (c *DocumentServiceClient) ListPermissionsOnDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+	permissionFilter []pb.PermissionLevel,
+	cursor *pb.Cursor,
+	pageSize *int32,
+	excludedPrincipalId *uuid.UUID,
+) (*pb.ListPermissionsOnDocumentReply, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "principalId", principalId }); err != nil {
+		return nil, err
+	}
+	req := &pb.ListPermissionsOnDocumentRequest{
+		DocumentId: documentId.String(),
+		PermissionsFilter: permissionFilter,
+		Cursor: cursor,
+		PageSize: pageSize,
+		ClientContext: &pb.ClientContext{
+			PrincipalId: principalId.String(),
+		},
+	}
+	if excludedPrincipalId != nil {
+		excluded := excludedPrincipalId.String()
+		req.ExcludedPrincipalId = &excluded
+	}
+	return c.client.ListPermissionsOnDocument(ctx, req)
+}
+
+// yields every permission on the document, paging from the given cursor until the service
+// reports that there are no more permissions. A nil cursor starts from the beginning of the
+// traversal. The first error is yielded and ends the iteration
+func (c *DocumentServiceClient) IteratePermissionsOnDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+	permissionFilter []pb.PermissionLevel,
+	cursor *pb.Cursor,
+	pageSize *int32,
+) iter.Seq2[*pb.Permission, error] {
+	return func(yield func(*pb.Permission, error) bool) {
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			reply, err := c.ListPermissionsOnDocument(
+				ctx, documentId, principalId, permissionFilter, cursor, pageSize, nil,
+			)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, permission := range reply.RecipientPermissions {
+				if !yield(permission, nil) {
+					return
+				}
+			}
+			if !reply.HasMore {
+				return
+			}
+			cursor = reply.Cursor
+		}
+	}
+}
+
+func (c *DocumentServiceClient) CreateGuest(
+	ctx context.Context,
+	documentId uuid.UUID,
+	userId uuid.UUID,
+	// pass nil to use the default guest permission level of the document service
+	permissionLevel *pb.PermissionLevel,
+	// pass nil to create a guest without a label
+	label *string,
+) (*pb.CreateGuestReply, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "userId", userId }); err != nil {
+		return nil, err
+	}
+	return c.client.CreateGuest(
+		ctx,
+		&pb.CreateGuestRequest{
+			DocumentId: documentId.String(),
+			PermissionLevel: permissionLevel,
+			Label: label,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: userId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+}
+
+func (c *DocumentServiceClient) CreateGuests(
+	ctx context.Context,
+	documentId uuid.UUID,
+	userId uuid.UUID,
+	count int32,
+	// pass nil to use the default guest permission level of the document service
+	permissionLevel *pb.PermissionLevel,
+) ([]uuid.UUID, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "userId", userId }); err != nil {
+		return nil, err
+	}
+	if count < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "count must be at least one, got: %d", count)
+	}
+	reply, err := c.client.CreateGuests(
+		ctx,
+		&pb.CreateGuestsRequest{
+			DocumentId: documentId.String(),
+			Count: count,
+			PermissionLevel: permissionLevel,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: userId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	guestIds := make([]uuid.UUID, len(reply.GuestIds))
+	for i, guestId := range reply.GuestIds {
+		guestIds[i], err = uuid.Parse(guestId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse guest id: %s returned from the document service: %w", guestId, err)
+		}
+	}
+	return guestIds, nil
+}
+
+func (c *DocumentServiceClient) UpsertPermissionUser(
+	ctx context.Context,
+	targetUserId uuid.UUID,
+	callingUserId uuid.UUID,
+	documentId uuid.UUID,
+	permissionLevel pb.PermissionLevel,
+) (*pb.UpsertPermissionUserReply, error) {
+	if err := checkRequiredIds(requiredId{ "targetUserId", targetUserId }, requiredId{ "callingUserId", callingUserId }, requiredId{ "documentId", documentId }); err != nil {
+		return nil, err
+	}
+	return c.client.UpsertPermissionUser(
+		ctx,
+		&pb.UpsertPermissionUserRequest{
+			UserId: targetUserId.String(),
+			DocumentId: documentId.String(),
+			PermissionLevel: permissionLevel,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+}
+
+// share the document with a batch of users, the results are in the order of the shares
+func (c *DocumentServiceClient) UpsertPermissionsUser(
+	ctx context.Context,
+	callingUserId uuid.UUID,
+	documentId uuid.UUID,
+	shares []*pb.UserShare,
+) (*pb.UpsertPermissionsUserReply, error) {
+	if err := checkRequiredIds(requiredId{ "callingUserId", callingUserId }, requiredId{ "documentId", documentId }); err != nil {
+		return nil, err
+	}
+	return c.client.UpsertPermissionsUser(
+		ctx,
+		&pb.UpsertPermissionsUserRequest{
+			DocumentId: documentId.String(),
+			Shares: shares,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+}
+
+// a nil label clears the label of the guest
+func (c *DocumentServiceClient) UpdateGuestLabel(
+	ctx context.Context,
+	documentId uuid.UUID,
+	guestId uuid.UUID,
+	callingUserId uuid.UUID,
+	label *string,
+) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "guestId", guestId }, requiredId{ "callingUserId", callingUserId }); err != nil {
+		return err
+	}
+	_, err := c.client.UpdateGuestLabel(
+		ctx,
+		&pb.UpdateGuestLabelRequest{
+			DocumentId: documentId.String(),
+			GuestId: guestId.String(),
+			Label: label,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	return err
+}
+
+// invalidate the tokens that were issued to the guest, returns the token version that new
+// tokens of the guest must carry
+func (c *DocumentServiceClient) RotateGuestLink(
+	ctx context.Context,
+	guestId uuid.UUID,
+	callingUserId uuid.UUID,
+) (int32, error) {
+	if err := checkRequiredIds(requiredId{ "guestId", guestId }, requiredId{ "callingUserId", callingUserId }); err != nil {
+		return 0, err
+	}
+	reply, err := c.client.RotateGuestLink(
+		ctx,
+		&pb.RotateGuestLinkRequest{
+			GuestId: guestId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return reply.TokenVersion, nil
+}
+
+// the server is listening once this returns, changes made after that are received from the
+// returned stream. Cancel the context to stop watching
+func (c *DocumentServiceClient) WatchDocumentPermissions(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callingUserId uuid.UUID,
+) (pb.DocumentService_WatchDocumentPermissionsClient, error) {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingUserId", callingUserId }); err != nil {
+		return nil, err
+	}
+	stream, err := c.client.WatchDocumentPermissions(
+		ctx,
+		&pb.WatchDocumentPermissionsRequest{
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	// the server sends the headers once it is listening
+	if _, err = stream.Header(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (c *DocumentServiceClient) GetGuestTokenVersion(
+	ctx context.Context,
+	guestId uuid.UUID,
+) (int32, error) {
+	if err := checkRequiredIds(requiredId{ "guestId", guestId }); err != nil {
+		return 0, err
+	}
+	// the guest is the calling principal, the caller is responsible for verifying that the
+	// guest presented a token signed for it
+	reply, err := c.client.GetGuestTokenVersion(
+		ctx,
+		&pb.GetGuestTokenVersionRequest{
+			GuestId: guestId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: guestId.String(),
+				PrincipalType: pb.Principal_GUEST.Enum(),
+			},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return reply.TokenVersion, nil
+}
+
+func (c *DocumentServiceClient) UpdatePermissionGuest(
+	ctx context.Context,
+	guestId uuid.UUID,
+	callingUserId uuid.UUID,
+	permissionLevel pb.PermissionLevel,
+) error {
+	if err := checkRequiredIds(requiredId{ "guestId", guestId }, requiredId{ "callingUserId", callingUserId }); err != nil {
+		return err
+	}
+	_, err := c.client.UpdatePermissionGuest(
+		ctx,
+		&pb.UpdatePermissionGuestRequest{
+			GuestId: guestId.String(),
+			PermissionLevel: permissionLevel,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	return err
+}
+
+// lower the permission of the principal to downgradeTo once downgradeAt has passed
+func (c *DocumentServiceClient) SchedulePermissionDowngrade(
+	ctx context.Context,
+	documentId uuid.UUID,
+	principalId uuid.UUID,
+	callingUserId uuid.UUID,
+	downgradeTo pb.PermissionLevel,
+	downgradeAt time.Time,
+) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "principalId", principalId }, requiredId{ "callingUserId", callingUserId }); err != nil {
+		return err
+	}
+	if downgradeAt.IsZero() {
+		return status.Error(codes.InvalidArgument, "downgradeAt must be set")
+	}
+	_, err := c.client.SchedulePermissionDowngrade(
+		ctx,
+		&pb.SchedulePermissionDowngradeRequest{
+			DocumentId: documentId.String(),
+			PrincipalId: principalId.String(),
+			DowngradeTo: downgradeTo,
+			DowngradeAt: timestamppb.New(downgradeAt),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	return err
+}
+
+func (c *DocumentServiceClient) DeletePermissionsPrincipal(
+	ctx context.Context,
+	principalId uuid.UUID,
+	documentId uuid.UUID,
+	callingUserId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "principalId", principalId }, requiredId{ "documentId", documentId }, requiredId{ "callingUserId", callingUserId }); err != nil {
+		return err
+	}
+	_, err := c.client.DeletePermissionsPrincipal(
+		ctx,
+		&pb.DeletePermissionsPrincipalRequest{
+			PrincipalId: principalId.String(),
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	return err
+}
+
+// the calling principal removes their own permission on the document, owners cannot leave
+func (c *DocumentServiceClient) LeaveDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return err
+	}
+	_, err := c.client.LeaveDocument(
+		ctx,
+		&pb.LeaveDocumentRequest{
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+	return err
+}
+
+// offer the target user a permission on the document that grants no access until they accept it
+func (c *DocumentServiceClient) InviteUser(
+	ctx context.Context,
+	targetUserId uuid.UUID,
+	callingUserId uuid.UUID,
+	documentId uuid.UUID,
+	permissionLevel pb.PermissionLevel,
+) error {
+	if err := checkRequiredIds(requiredId{ "targetUserId", targetUserId }, requiredId{ "callingUserId", callingUserId }, requiredId{ "documentId", documentId }); err != nil {
+		return err
+	}
+	_, err := c.client.InviteUser(
+		ctx,
+		&pb.InviteUserRequest{
+			UserId: targetUserId.String(),
+			DocumentId: documentId.String(),
+			PermissionLevel: permissionLevel,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingUserId.String(),
+				PrincipalType: pb.Principal_USER.Enum(),
+			},
+		},
+	)
+	return err
+}
+
+func (c *DocumentServiceClient) ListPendingShares(
+	ctx context.Context,
+	callingPrincipalId uuid.UUID,
+) (*pb.ListPendingSharesReply, error) {
+	if err := checkRequiredIds(requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.ListPendingShares(
+		ctx,
+		&pb.ListPendingSharesRequest{
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+}
+
+func (c *DocumentServiceClient) AcceptPendingShare(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return err
+	}
+	_, err := c.client.AcceptPendingShare(
+		ctx,
+		&pb.AcceptPendingShareRequest{
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+	return err
+}
+
+func (c *DocumentServiceClient) StarDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return err
+	}
+	_, err := c.client.StarDocument(
+		ctx,
+		&pb.StarDocumentRequest{
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+	return err
+}
+
+func (c *DocumentServiceClient) UnstarDocument(
+	ctx context.Context,
+	documentId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return err
+	}
+	_, err := c.client.UnstarDocument(
+		ctx,
+		&pb.UnstarDocumentRequest{
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+	return err
+}
+
+func (c *DocumentServiceClient) CreateCollection(
+	ctx context.Context,
+	name string,
+	callingPrincipalId uuid.UUID,
+) (*pb.Collection, error) {
+	if err := checkRequiredIds(requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return nil, err
+	}
+	return c.client.CreateCollection(
+		ctx,
+		&pb.CreateCollectionRequest{
+			Name: name,
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+}
+
+func (c *DocumentServiceClient) AddToCollection(
+	ctx context.Context,
+	collectionId uuid.UUID,
+	documentId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "collectionId", collectionId }, requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return err
+	}
+	_, err := c.client.AddToCollection(
+		ctx,
+		&pb.AddToCollectionRequest{
+			CollectionId: collectionId.String(),
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+	return err
+}
+
+func (c *DocumentServiceClient) RemoveFromCollection(
+	ctx context.Context,
+	collectionId uuid.UUID,
+	documentId uuid.UUID,
+	callingPrincipalId uuid.UUID,
+) error {
+	if err := checkRequiredIds(requiredId{ "collectionId", collectionId }, requiredId{ "documentId", documentId }, requiredId{ "callingPrincipalId", callingPrincipalId }); err != nil {
+		return err
+	}
+	_, err := c.client.RemoveFromCollection(
+		ctx,
+		&pb.RemoveFromCollectionRequest{
+			CollectionId: collectionId.String(),
+			DocumentId: documentId.String(),
+			ClientContext: &pb.ClientContext{
+				PrincipalId: callingPrincipalId.String(),
+			},
+		},
+	)
+	return err
+}
@@ -0,0 +1,353 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/townsag/reed/document_service/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serves the items in pages of pageSize, the cursor of each page holds the index of the next
+// item so that a cursor that is not fed back in serves the same page again
+type fakePagingClient struct {
+	pb.DocumentServiceClient
+	items []string
+	pageSize int
+	calls int
+}
+
+func (f *fakePagingClient) page(cursor *pb.Cursor) ([]string, *pb.Cursor, bool) {
+	f.calls++
+	start := 0
+	if cursor != nil {
+		fmt.Sscan(cursor.GetLastSeenDocumentId(), &start)
+	}
+	end := min(start + f.pageSize, len(f.items))
+	next := fmt.Sprint(end)
+	return f.items[start:end], &pb.Cursor{ LastSeenDocumentId: &next }, end < len(f.items)
+}
+
+func (f *fakePagingClient) ListDocumentsByPrincipal(
+	ctx context.Context, in *pb.ListDocumentByPrincipalRequest, opts ...grpc.CallOption,
+) (*pb.ListDocumentsByPrincipalReply, error) {
+	items, cursor, hasMore := f.page(in.Cursor)
+	reply := &pb.ListDocumentsByPrincipalReply{ Cursor: cursor, HasMore: hasMore }
+	for _, item := range items {
+		reply.DocumentPermissions = append(
+			reply.DocumentPermissions,
+			&pb.ListDocumentsByPrincipalReply_DocumentPermission{
+				Document: &pb.Document{ DocumentId: item },
+			},
+		)
+	}
+	return reply, nil
+}
+
+func (f *fakePagingClient) ListPermissionsOnDocument(
+	ctx context.Context, in *pb.ListPermissionsOnDocumentRequest, opts ...grpc.CallOption,
+) (*pb.ListPermissionsOnDocumentReply, error) {
+	items, cursor, hasMore := f.page(in.Cursor)
+	reply := &pb.ListPermissionsOnDocumentReply{ Cursor: cursor, HasMore: hasMore }
+	for _, item := range items {
+		reply.RecipientPermissions = append(
+			reply.RecipientPermissions,
+			&pb.Permission{ Recipient: &pb.Principal{ PrincipalId: item } },
+		)
+	}
+	return reply, nil
+}
+
+func newFakePagingClient(itemCount int, pageSize int) *fakePagingClient {
+	items := make([]string, itemCount)
+	for i := range items {
+		items[i] = uuid.NewString()
+	}
+	return &fakePagingClient{ items: items, pageSize: pageSize }
+}
+
+// fails the test unless every item was yielded exactly once and in order
+func verifyYielded(t *testing.T, want []string, got []string) {
+	if len(got) != len(want) {
+		t.Fatalf("want %d items, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want item: %s at position %d, got: %s", want[i], i, got[i])
+		}
+	}
+}
+
+func TestIterateDocumentsByPrincipal_MultiplePages_Unit(t *testing.T) {
+	fake := newFakePagingClient(7, 3)
+	c := &DocumentServiceClient{ client: fake }
+	var got []string
+	for documentPermission, err := range c.IterateDocumentsByPrincipal(
+		t.Context(), uuid.New(), uuid.New(), nil, nil, nil,
+	) {
+		if err != nil {
+			t.Fatalf("failed to iterate documents with error: %v", err)
+		}
+		got = append(got, documentPermission.Document.DocumentId)
+	}
+	verifyYielded(t, fake.items, got)
+	if fake.calls != 3 {
+		t.Errorf("want 3 pages to be requested, got: %d", fake.calls)
+	}
+}
+
+func TestIteratePermissionsOnDocument_MultiplePages_Unit(t *testing.T) {
+	fake := newFakePagingClient(6, 2)
+	c := &DocumentServiceClient{ client: fake }
+	var got []string
+	for permission, err := range c.IteratePermissionsOnDocument(
+		t.Context(), uuid.New(), uuid.New(), nil, nil, nil,
+	) {
+		if err != nil {
+			t.Fatalf("failed to iterate permissions with error: %v", err)
+		}
+		got = append(got, permission.Recipient.PrincipalId)
+	}
+	verifyYielded(t, fake.items, got)
+	if fake.calls != 3 {
+		t.Errorf("want 3 pages to be requested, got: %d", fake.calls)
+	}
+}
+
+func TestIterateDocumentsByPrincipal_Break_Unit(t *testing.T) {
+	fake := newFakePagingClient(7, 3)
+	c := &DocumentServiceClient{ client: fake }
+	// breaking out of the loop stops the iterator from requesting more pages
+	for range c.IterateDocumentsByPrincipal(t.Context(), uuid.New(), uuid.New(), nil, nil, nil) {
+		break
+	}
+	if fake.calls != 1 {
+		t.Errorf("want 1 page to be requested, got: %d", fake.calls)
+	}
+}
+
+func TestIterateDocumentsByPrincipal_Cancelled_Unit(t *testing.T) {
+	fake := newFakePagingClient(7, 3)
+	c := &DocumentServiceClient{ client: fake }
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	var count int
+	var iterErr error
+	for _, err := range c.IterateDocumentsByPrincipal(ctx, uuid.New(), uuid.New(), nil, nil, nil) {
+		if err != nil {
+			iterErr = err
+			continue
+		}
+		count++
+		// cancel part way through the first page, the rest of the page is still yielded
+		if count == 1 {
+			cancel()
+		}
+	}
+	if !errors.Is(iterErr, context.Canceled) {
+		t.Errorf("want a context canceled error, got: %v", iterErr)
+	}
+	if count != 3 || fake.calls != 1 {
+		t.Errorf("want the first page of 3 documents from 1 request, got: %d documents from %d requests", count, fake.calls)
+	}
+}
+
+func TestClient_InvalidArguments_Unit(t *testing.T) {
+	// the embedded service client is nil so any rpc that is made panics, the invalid arguments
+	// have to be rejected before the rpc
+	c := &DocumentServiceClient{ client: struct{ pb.DocumentServiceClient }{} }
+	ctx := t.Context()
+	id := uuid.New()
+	calls := map[string]func() error{
+		"CreateDocument": func() error {
+			_, err := c.CreateDocument(ctx, uuid.Nil, nil, nil, nil)
+			return err
+		},
+		"GetDocument": func() error {
+			_, err := c.GetDocument(ctx, uuid.Nil, id, nil, false, false)
+			return err
+		},
+		"UpdateDocument": func() error {
+			return c.UpdateDocument(ctx, id, uuid.Nil, nil, nil, false, false)
+		},
+		"DeleteDocument": func() error {
+			return c.DeleteDocument(ctx, uuid.Nil, id)
+		},
+		"DeleteDocuments empty": func() error {
+			return c.DeleteDocuments(ctx, uuid.UUIDs{}, id)
+		},
+		"DeleteDocuments nil member": func() error {
+			return c.DeleteDocuments(ctx, uuid.UUIDs{ id, uuid.Nil }, id)
+		},
+		"DeleteDocuments nil caller": func() error {
+			return c.DeleteDocuments(ctx, uuid.UUIDs{ id }, uuid.Nil)
+		},
+		"ArchiveDocument": func() error {
+			return c.ArchiveDocument(ctx, id, uuid.Nil)
+		},
+		"RestoreDocument": func() error {
+			return c.RestoreDocument(ctx, uuid.Nil, id)
+		},
+		"ReassignOwnedDocuments": func() error {
+			_, err := c.ReassignOwnedDocuments(ctx, id, uuid.Nil, id)
+			return err
+		},
+		"EnsureDocumentHasOwner": func() error {
+			_, err := c.EnsureDocumentHasOwner(ctx, id, uuid.Nil, id)
+			return err
+		},
+		"SetPublicAccess": func() error {
+			_, err := c.SetPublicAccess(ctx, uuid.Nil, id, nil)
+			return err
+		},
+		"ListDocumentsByPrincipal": func() error {
+			_, err := c.ListDocumentsByPrincipal(ctx, id, uuid.Nil, nil, false, nil, false, nil, nil)
+			return err
+		},
+		"IterateDocumentsByPrincipal": func() error {
+			for _, err := range c.IterateDocumentsByPrincipal(ctx, uuid.Nil, id, nil, nil, nil) {
+				return err
+			}
+			return nil
+		},
+		"ListDocumentsModifiedSince": func() error {
+			_, err := c.ListDocumentsModifiedSince(ctx, uuid.Nil, id, time.Now(), nil, nil)
+			return err
+		},
+		"ListRecentlyAccessed": func() error {
+			_, err := c.ListRecentlyAccessed(ctx, uuid.Nil, nil, nil)
+			return err
+		},
+		"ListSharedDocumentsByOwner": func() error {
+			_, err := c.ListSharedDocumentsByOwner(ctx, uuid.Nil, id, nil, nil)
+			return err
+		},
+		"ListAllDocuments": func() error {
+			_, err := c.ListAllDocuments(ctx, uuid.Nil, nil, nil, nil, nil, nil)
+			return err
+		},
+		"CountDocumentsByPrincipalGrouped": func() error {
+			_, err := c.CountDocumentsByPrincipalGrouped(ctx, id, uuid.Nil)
+			return err
+		},
+		"GetDocumentHistory": func() error {
+			_, err := c.GetDocumentHistory(ctx, uuid.Nil, id, nil, nil)
+			return err
+		},
+		"GetDocumentSharingSummary": func() error {
+			_, err := c.GetDocumentSharingSummary(ctx, uuid.Nil, id)
+			return err
+		},
+		"GetPermissionsOfPrincipalOnDocument": func() error {
+			_, err := c.GetPermissionsOfPrincipalOnDocument(ctx, id, uuid.Nil, id, nil)
+			return err
+		},
+		"CheckAccess": func() error {
+			_, err := c.CheckAccess(ctx, id, uuid.Nil, nil, pb.Action_ACTION_VIEW)
+			return err
+		},
+		"GetPermissionLevelsForPrincipalOnDocuments empty": func() error {
+			_, err := c.GetPermissionLevelsForPrincipalOnDocuments(ctx, nil, id, id)
+			return err
+		},
+		"ListPermissionsOnDocument": func() error {
+			_, err := c.ListPermissionsOnDocument(ctx, uuid.Nil, id, nil, nil, nil, nil)
+			return err
+		},
+		"IteratePermissionsOnDocument": func() error {
+			for _, err := range c.IteratePermissionsOnDocument(ctx, id, uuid.Nil, nil, nil, nil) {
+				return err
+			}
+			return nil
+		},
+		"CreateGuest": func() error {
+			_, err := c.CreateGuest(ctx, uuid.Nil, id, nil, nil)
+			return err
+		},
+		"CreateGuests nil document": func() error {
+			_, err := c.CreateGuests(ctx, uuid.Nil, id, 1, nil)
+			return err
+		},
+		"CreateGuests zero count": func() error {
+			_, err := c.CreateGuests(ctx, id, id, 0, nil)
+			return err
+		},
+		"UpsertPermissionUser": func() error {
+			_, err := c.UpsertPermissionUser(ctx, uuid.Nil, id, id, pb.PermissionLevel_PERMISSION_VIEWER)
+			return err
+		},
+		"UpsertPermissionsUser": func() error {
+			_, err := c.UpsertPermissionsUser(ctx, uuid.Nil, id, nil)
+			return err
+		},
+		"UpdateGuestLabel": func() error {
+			return c.UpdateGuestLabel(ctx, id, uuid.Nil, id, nil)
+		},
+		"RotateGuestLink": func() error {
+			_, err := c.RotateGuestLink(ctx, uuid.Nil, id)
+			return err
+		},
+		"WatchDocumentPermissions": func() error {
+			_, err := c.WatchDocumentPermissions(ctx, uuid.Nil, id)
+			return err
+		},
+		"GetGuestTokenVersion": func() error {
+			_, err := c.GetGuestTokenVersion(ctx, uuid.Nil)
+			return err
+		},
+		"UpdatePermissionGuest": func() error {
+			return c.UpdatePermissionGuest(ctx, uuid.Nil, id, pb.PermissionLevel_PERMISSION_VIEWER)
+		},
+		"SchedulePermissionDowngrade nil principal": func() error {
+			return c.SchedulePermissionDowngrade(ctx, id, uuid.Nil, id, pb.PermissionLevel_PERMISSION_VIEWER, time.Now())
+		},
+		"SchedulePermissionDowngrade zero time": func() error {
+			return c.SchedulePermissionDowngrade(ctx, id, id, id, pb.PermissionLevel_PERMISSION_VIEWER, time.Time{})
+		},
+		"DeletePermissionsPrincipal": func() error {
+			return c.DeletePermissionsPrincipal(ctx, uuid.Nil, id, id)
+		},
+		"LeaveDocument": func() error {
+			return c.LeaveDocument(ctx, uuid.Nil, id)
+		},
+		"InviteUser": func() error {
+			return c.InviteUser(ctx, id, id, uuid.Nil, pb.PermissionLevel_PERMISSION_EDITOR)
+		},
+		"ListPendingShares": func() error {
+			_, err := c.ListPendingShares(ctx, uuid.Nil)
+			return err
+		},
+		"AcceptPendingShare": func() error {
+			return c.AcceptPendingShare(ctx, uuid.Nil, id)
+		},
+		"StarDocument": func() error {
+			return c.StarDocument(ctx, id, uuid.Nil)
+		},
+		"UnstarDocument": func() error {
+			return c.UnstarDocument(ctx, uuid.Nil, id)
+		},
+		"CreateCollection": func() error {
+			_, err := c.CreateCollection(ctx, "reading list", uuid.Nil)
+			return err
+		},
+		"AddToCollection": func() error {
+			return c.AddToCollection(ctx, uuid.Nil, id, id)
+		},
+		"RemoveFromCollection": func() error {
+			return c.RemoveFromCollection(ctx, id, uuid.Nil, id)
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if code := status.Code(call()); code != codes.InvalidArgument {
+				t.Errorf("want an invalid argument error, got code: %v", code)
+			}
+		})
+	}
+}
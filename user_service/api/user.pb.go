@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -23,11 +24,19 @@ const (
 )
 
 type User struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	UserName      string                 `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
-	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
-	MaxDocuments  int32                  `protobuf:"varint,4,opt,name=max_documents,json=maxDocuments,proto3" json:"max_documents,omitempty"`
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	UserId       string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	UserName     string                 `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	Email        string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	MaxDocuments int32                  `protobuf:"varint,4,opt,name=max_documents,json=maxDocuments,proto3" json:"max_documents,omitempty"`
+	// these are implicit field types
+	// I am taking that to mean semantically that they will always be set by the server
+	IsActive       bool                   `protobuf:"varint,5,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	LastModifiedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=last_modified_at,json=lastModifiedAt,proto3" json:"last_modified_at,omitempty"`
+	// bumped when the user is deactivated or changes their password, tokens that were issued
+	// with an older version are no longer valid
+	TokenVersion  int32 `protobuf:"varint,8,opt,name=token_version,json=tokenVersion,proto3" json:"token_version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,6 +99,34 @@ func (x *User) GetMaxDocuments() int32 {
 	return 0
 }
 
+func (x *User) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *User) GetLastModifiedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastModifiedAt
+	}
+	return nil
+}
+
+func (x *User) GetTokenVersion() int32 {
+	if x != nil {
+		return x.TokenVersion
+	}
+	return 0
+}
+
 type GetUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -134,6 +171,149 @@ func (x *GetUserRequest) GetUserId() string {
 	return ""
 }
 
+type GetUserByEmailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserEmail     string                 `protobuf:"bytes,1,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserByEmailRequest) Reset() {
+	*x = GetUserByEmailRequest{}
+	mi := &file_api_user_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserByEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserByEmailRequest) ProtoMessage() {}
+
+func (x *GetUserByEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserByEmailRequest.ProtoReflect.Descriptor instead.
+func (*GetUserByEmailRequest) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetUserByEmailRequest) GetUserEmail() string {
+	if x != nil {
+		return x.UserEmail
+	}
+	return ""
+}
+
+// exactly one identifier has to be set, a request without one is rejected
+type ResolveUserRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Identifier:
+	//
+	//	*ResolveUserRequest_UserId
+	//	*ResolveUserRequest_Email
+	//	*ResolveUserRequest_UserName
+	Identifier    isResolveUserRequest_Identifier `protobuf_oneof:"identifier"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveUserRequest) Reset() {
+	*x = ResolveUserRequest{}
+	mi := &file_api_user_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveUserRequest) ProtoMessage() {}
+
+func (x *ResolveUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveUserRequest.ProtoReflect.Descriptor instead.
+func (*ResolveUserRequest) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ResolveUserRequest) GetIdentifier() isResolveUserRequest_Identifier {
+	if x != nil {
+		return x.Identifier
+	}
+	return nil
+}
+
+func (x *ResolveUserRequest) GetUserId() string {
+	if x != nil {
+		if x, ok := x.Identifier.(*ResolveUserRequest_UserId); ok {
+			return x.UserId
+		}
+	}
+	return ""
+}
+
+func (x *ResolveUserRequest) GetEmail() string {
+	if x != nil {
+		if x, ok := x.Identifier.(*ResolveUserRequest_Email); ok {
+			return x.Email
+		}
+	}
+	return ""
+}
+
+func (x *ResolveUserRequest) GetUserName() string {
+	if x != nil {
+		if x, ok := x.Identifier.(*ResolveUserRequest_UserName); ok {
+			return x.UserName
+		}
+	}
+	return ""
+}
+
+type isResolveUserRequest_Identifier interface {
+	isResolveUserRequest_Identifier()
+}
+
+type ResolveUserRequest_UserId struct {
+	UserId string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3,oneof"`
+}
+
+type ResolveUserRequest_Email struct {
+	Email string `protobuf:"bytes,2,opt,name=email,proto3,oneof"`
+}
+
+type ResolveUserRequest_UserName struct {
+	UserName string `protobuf:"bytes,3,opt,name=user_name,json=userName,proto3,oneof"`
+}
+
+func (*ResolveUserRequest_UserId) isResolveUserRequest_Identifier() {}
+
+func (*ResolveUserRequest_Email) isResolveUserRequest_Identifier() {}
+
+func (*ResolveUserRequest_UserName) isResolveUserRequest_Identifier() {}
+
 type UserReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
@@ -143,7 +323,7 @@ type UserReply struct {
 
 func (x *UserReply) Reset() {
 	*x = UserReply{}
-	mi := &file_api_user_proto_msgTypes[2]
+	mi := &file_api_user_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -155,7 +335,7 @@ func (x *UserReply) String() string {
 func (*UserReply) ProtoMessage() {}
 
 func (x *UserReply) ProtoReflect() protoreflect.Message {
-	mi := &file_api_user_proto_msgTypes[2]
+	mi := &file_api_user_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -168,7 +348,7 @@ func (x *UserReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserReply.ProtoReflect.Descriptor instead.
 func (*UserReply) Descriptor() ([]byte, []int) {
-	return file_api_user_proto_rawDescGZIP(), []int{2}
+	return file_api_user_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UserReply) GetUser() *User {
@@ -179,18 +359,21 @@ func (x *UserReply) GetUser() *User {
 }
 
 type CreateUserRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserName      string                 `protobuf:"bytes,1,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
-	UserEmail     string                 `protobuf:"bytes,2,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
-	Password      string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
-	MaxDocuments  *int32                 `protobuf:"varint,4,opt,name=max_documents,json=maxDocuments,proto3,oneof" json:"max_documents,omitempty"`
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	UserName     string                 `protobuf:"bytes,1,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	UserEmail    string                 `protobuf:"bytes,2,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
+	Password     string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	MaxDocuments *int32                 `protobuf:"varint,4,opt,name=max_documents,json=maxDocuments,proto3,oneof" json:"max_documents,omitempty"`
+	// the plan of the user, max_documents defaults to the quota of the plan when it is not set.
+	// The free plan is used when this is not set
+	Plan          *string `protobuf:"bytes,5,opt,name=plan,proto3,oneof" json:"plan,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateUserRequest) Reset() {
 	*x = CreateUserRequest{}
-	mi := &file_api_user_proto_msgTypes[3]
+	mi := &file_api_user_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -202,7 +385,7 @@ func (x *CreateUserRequest) String() string {
 func (*CreateUserRequest) ProtoMessage() {}
 
 func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_user_proto_msgTypes[3]
+	mi := &file_api_user_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -215,7 +398,7 @@ func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateUserRequest.ProtoReflect.Descriptor instead.
 func (*CreateUserRequest) Descriptor() ([]byte, []int) {
-	return file_api_user_proto_rawDescGZIP(), []int{3}
+	return file_api_user_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *CreateUserRequest) GetUserName() string {
@@ -246,16 +429,25 @@ func (x *CreateUserRequest) GetMaxDocuments() int32 {
 	return 0
 }
 
+func (x *CreateUserRequest) GetPlan() string {
+	if x != nil && x.Plan != nil {
+		return *x.Plan
+	}
+	return ""
+}
+
 type CreateUserReply struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// the created user so that clients do not have to call GetUser after creating a user
+	User          *User `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateUserReply) Reset() {
 	*x = CreateUserReply{}
-	mi := &file_api_user_proto_msgTypes[4]
+	mi := &file_api_user_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -267,7 +459,7 @@ func (x *CreateUserReply) String() string {
 func (*CreateUserReply) ProtoMessage() {}
 
 func (x *CreateUserReply) ProtoReflect() protoreflect.Message {
-	mi := &file_api_user_proto_msgTypes[4]
+	mi := &file_api_user_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -280,7 +472,7 @@ func (x *CreateUserReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateUserReply.ProtoReflect.Descriptor instead.
 func (*CreateUserReply) Descriptor() ([]byte, []int) {
-	return file_api_user_proto_rawDescGZIP(), []int{4}
+	return file_api_user_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *CreateUserReply) GetUserId() string {
@@ -290,6 +482,118 @@ func (x *CreateUserReply) GetUserId() string {
 	return ""
 }
 
+func (x *CreateUserReply) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type CheckAvailabilityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserName      *string                `protobuf:"bytes,1,opt,name=user_name,json=userName,proto3,oneof" json:"user_name,omitempty"`
+	Email         *string                `protobuf:"bytes,2,opt,name=email,proto3,oneof" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckAvailabilityRequest) Reset() {
+	*x = CheckAvailabilityRequest{}
+	mi := &file_api_user_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckAvailabilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckAvailabilityRequest) ProtoMessage() {}
+
+func (x *CheckAvailabilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckAvailabilityRequest.ProtoReflect.Descriptor instead.
+func (*CheckAvailabilityRequest) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CheckAvailabilityRequest) GetUserName() string {
+	if x != nil && x.UserName != nil {
+		return *x.UserName
+	}
+	return ""
+}
+
+func (x *CheckAvailabilityRequest) GetEmail() string {
+	if x != nil && x.Email != nil {
+		return *x.Email
+	}
+	return ""
+}
+
+// only the fields that were set in the request are set in the reply
+type CheckAvailabilityReply struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	UserNameAvailable *bool                  `protobuf:"varint,1,opt,name=user_name_available,json=userNameAvailable,proto3,oneof" json:"user_name_available,omitempty"`
+	EmailAvailable    *bool                  `protobuf:"varint,2,opt,name=email_available,json=emailAvailable,proto3,oneof" json:"email_available,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CheckAvailabilityReply) Reset() {
+	*x = CheckAvailabilityReply{}
+	mi := &file_api_user_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckAvailabilityReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckAvailabilityReply) ProtoMessage() {}
+
+func (x *CheckAvailabilityReply) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckAvailabilityReply.ProtoReflect.Descriptor instead.
+func (*CheckAvailabilityReply) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CheckAvailabilityReply) GetUserNameAvailable() bool {
+	if x != nil && x.UserNameAvailable != nil {
+		return *x.UserNameAvailable
+	}
+	return false
+}
+
+func (x *CheckAvailabilityReply) GetEmailAvailable() bool {
+	if x != nil && x.EmailAvailable != nil {
+		return *x.EmailAvailable
+	}
+	return false
+}
+
 type DeactivateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -299,7 +603,7 @@ type DeactivateUserRequest struct {
 
 func (x *DeactivateUserRequest) Reset() {
 	*x = DeactivateUserRequest{}
-	mi := &file_api_user_proto_msgTypes[5]
+	mi := &file_api_user_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -311,7 +615,7 @@ func (x *DeactivateUserRequest) String() string {
 func (*DeactivateUserRequest) ProtoMessage() {}
 
 func (x *DeactivateUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_user_proto_msgTypes[5]
+	mi := &file_api_user_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -324,7 +628,7 @@ func (x *DeactivateUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeactivateUserRequest.ProtoReflect.Descriptor instead.
 func (*DeactivateUserRequest) Descriptor() ([]byte, []int) {
-	return file_api_user_proto_rawDescGZIP(), []int{5}
+	return file_api_user_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *DeactivateUserRequest) GetUserId() string {
@@ -345,7 +649,7 @@ type ChangeUserPasswordRequest struct {
 
 func (x *ChangeUserPasswordRequest) Reset() {
 	*x = ChangeUserPasswordRequest{}
-	mi := &file_api_user_proto_msgTypes[6]
+	mi := &file_api_user_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -357,7 +661,7 @@ func (x *ChangeUserPasswordRequest) String() string {
 func (*ChangeUserPasswordRequest) ProtoMessage() {}
 
 func (x *ChangeUserPasswordRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_user_proto_msgTypes[6]
+	mi := &file_api_user_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -370,7 +674,7 @@ func (x *ChangeUserPasswordRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChangeUserPasswordRequest.ProtoReflect.Descriptor instead.
 func (*ChangeUserPasswordRequest) Descriptor() ([]byte, []int) {
-	return file_api_user_proto_rawDescGZIP(), []int{6}
+	return file_api_user_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ChangeUserPasswordRequest) GetUserId() string {
@@ -404,7 +708,7 @@ type ValidatePasswordRequest struct {
 
 func (x *ValidatePasswordRequest) Reset() {
 	*x = ValidatePasswordRequest{}
-	mi := &file_api_user_proto_msgTypes[7]
+	mi := &file_api_user_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -416,7 +720,7 @@ func (x *ValidatePasswordRequest) String() string {
 func (*ValidatePasswordRequest) ProtoMessage() {}
 
 func (x *ValidatePasswordRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_user_proto_msgTypes[7]
+	mi := &file_api_user_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -429,7 +733,7 @@ func (x *ValidatePasswordRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ValidatePasswordRequest.ProtoReflect.Descriptor instead.
 func (*ValidatePasswordRequest) Descriptor() ([]byte, []int) {
-	return file_api_user_proto_rawDescGZIP(), []int{7}
+	return file_api_user_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ValidatePasswordRequest) GetUserName() string {
@@ -447,16 +751,19 @@ func (x *ValidatePasswordRequest) GetUserPassword() string {
 }
 
 type ValidatePasswordReply struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        *string                `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
-	IsValid       bool                   `protobuf:"varint,2,opt,name=is_valid,json=isValid,proto3" json:"is_valid,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the id of the user with the user name, this is the nil uuid when the password is invalid
+	UserId  *string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	IsValid bool    `protobuf:"varint,2,opt,name=is_valid,json=isValid,proto3" json:"is_valid,omitempty"`
+	// the token version of the user, tokens issued for this login should carry it
+	TokenVersion  int32 `protobuf:"varint,3,opt,name=token_version,json=tokenVersion,proto3" json:"token_version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ValidatePasswordReply) Reset() {
 	*x = ValidatePasswordReply{}
-	mi := &file_api_user_proto_msgTypes[8]
+	mi := &file_api_user_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -468,7 +775,7 @@ func (x *ValidatePasswordReply) String() string {
 func (*ValidatePasswordReply) ProtoMessage() {}
 
 func (x *ValidatePasswordReply) ProtoReflect() protoreflect.Message {
-	mi := &file_api_user_proto_msgTypes[8]
+	mi := &file_api_user_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -481,7 +788,7 @@ func (x *ValidatePasswordReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ValidatePasswordReply.ProtoReflect.Descriptor instead.
 func (*ValidatePasswordReply) Descriptor() ([]byte, []int) {
-	return file_api_user_proto_rawDescGZIP(), []int{8}
+	return file_api_user_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ValidatePasswordReply) GetUserId() string {
@@ -498,29 +805,64 @@ func (x *ValidatePasswordReply) GetIsValid() bool {
 	return false
 }
 
+func (x *ValidatePasswordReply) GetTokenVersion() int32 {
+	if x != nil {
+		return x.TokenVersion
+	}
+	return 0
+}
+
 var File_api_user_proto protoreflect.FileDescriptor
 
 const file_api_user_proto_rawDesc = "" +
 	"\n" +
-	"\x0eapi/user.proto\x12\x03api\x1a\x1bgoogle/protobuf/empty.proto\"w\n" +
+	"\x0eapi/user.proto\x12\x03api\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xba\x02\n" +
 	"\x04User\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
 	"\tuser_name\x18\x02 \x01(\tR\buserName\x12\x14\n" +
 	"\x05email\x18\x03 \x01(\tR\x05email\x12#\n" +
-	"\rmax_documents\x18\x04 \x01(\x05R\fmaxDocuments\")\n" +
+	"\rmax_documents\x18\x04 \x01(\x05R\fmaxDocuments\x12\x1b\n" +
+	"\tis_active\x18\x05 \x01(\bR\bisActive\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12D\n" +
+	"\x10last_modified_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x0elastModifiedAt\x12#\n" +
+	"\rtoken_version\x18\b \x01(\x05R\ftokenVersion\")\n" +
 	"\x0eGetUserRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\"*\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"6\n" +
+	"\x15GetUserByEmailRequest\x12\x1d\n" +
+	"\n" +
+	"user_email\x18\x01 \x01(\tR\tuserEmail\"t\n" +
+	"\x12ResolveUserRequest\x12\x19\n" +
+	"\auser_id\x18\x01 \x01(\tH\x00R\x06userId\x12\x16\n" +
+	"\x05email\x18\x02 \x01(\tH\x00R\x05email\x12\x1d\n" +
+	"\tuser_name\x18\x03 \x01(\tH\x00R\buserNameB\f\n" +
+	"\n" +
+	"identifier\"*\n" +
 	"\tUserReply\x12\x1d\n" +
-	"\x04user\x18\x01 \x01(\v2\t.api.UserR\x04user\"\xa7\x01\n" +
+	"\x04user\x18\x01 \x01(\v2\t.api.UserR\x04user\"\xc9\x01\n" +
 	"\x11CreateUserRequest\x12\x1b\n" +
 	"\tuser_name\x18\x01 \x01(\tR\buserName\x12\x1d\n" +
 	"\n" +
 	"user_email\x18\x02 \x01(\tR\tuserEmail\x12\x1a\n" +
 	"\bpassword\x18\x03 \x01(\tR\bpassword\x12(\n" +
-	"\rmax_documents\x18\x04 \x01(\x05H\x00R\fmaxDocuments\x88\x01\x01B\x10\n" +
-	"\x0e_max_documents\"*\n" +
+	"\rmax_documents\x18\x04 \x01(\x05H\x00R\fmaxDocuments\x88\x01\x01\x12\x17\n" +
+	"\x04plan\x18\x05 \x01(\tH\x01R\x04plan\x88\x01\x01B\x10\n" +
+	"\x0e_max_documentsB\a\n" +
+	"\x05_plan\"I\n" +
 	"\x0fCreateUserReply\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\"0\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\x04user\x18\x03 \x01(\v2\t.api.UserR\x04user\"o\n" +
+	"\x18CheckAvailabilityRequest\x12 \n" +
+	"\tuser_name\x18\x01 \x01(\tH\x00R\buserName\x88\x01\x01\x12\x19\n" +
+	"\x05email\x18\x02 \x01(\tH\x01R\x05email\x88\x01\x01B\f\n" +
+	"\n" +
+	"_user_nameB\b\n" +
+	"\x06_email\"\xa7\x01\n" +
+	"\x16CheckAvailabilityReply\x123\n" +
+	"\x13user_name_available\x18\x01 \x01(\bH\x00R\x11userNameAvailable\x88\x01\x01\x12,\n" +
+	"\x0femail_available\x18\x02 \x01(\bH\x01R\x0eemailAvailable\x88\x01\x01B\x16\n" +
+	"\x14_user_name_availableB\x12\n" +
+	"\x10_email_available\"0\n" +
 	"\x15DeactivateUserRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\"z\n" +
 	"\x19ChangeUserPasswordRequest\x12\x17\n" +
@@ -529,16 +871,20 @@ const file_api_user_proto_rawDesc = "" +
 	"\fnew_password\x18\x03 \x01(\tR\vnewPassword\"[\n" +
 	"\x17ValidatePasswordRequest\x12\x1b\n" +
 	"\tuser_name\x18\x01 \x01(\tR\buserName\x12#\n" +
-	"\ruser_password\x18\x02 \x01(\tR\fuserPassword\"\\\n" +
+	"\ruser_password\x18\x02 \x01(\tR\fuserPassword\"\x81\x01\n" +
 	"\x15ValidatePasswordReply\x12\x1c\n" +
 	"\auser_id\x18\x01 \x01(\tH\x00R\x06userId\x88\x01\x01\x12\x19\n" +
-	"\bis_valid\x18\x02 \x01(\bR\aisValidB\n" +
+	"\bis_valid\x18\x02 \x01(\bR\aisValid\x12#\n" +
+	"\rtoken_version\x18\x03 \x01(\x05R\ftokenVersionB\n" +
 	"\n" +
-	"\b_user_id2\xe5\x02\n" +
+	"\b_user_id2\xb2\x04\n" +
 	"\vUserService\x120\n" +
-	"\aGetUser\x12\x13.api.GetUserRequest\x1a\x0e.api.UserReply\"\x00\x12<\n" +
+	"\aGetUser\x12\x13.api.GetUserRequest\x1a\x0e.api.UserReply\"\x00\x12>\n" +
+	"\x0eGetUserByEmail\x12\x1a.api.GetUserByEmailRequest\x1a\x0e.api.UserReply\"\x00\x128\n" +
+	"\vResolveUser\x12\x17.api.ResolveUserRequest\x1a\x0e.api.UserReply\"\x00\x12<\n" +
 	"\n" +
-	"CreateUser\x12\x16.api.CreateUserRequest\x1a\x14.api.CreateUserReply\"\x00\x12F\n" +
+	"CreateUser\x12\x16.api.CreateUserRequest\x1a\x14.api.CreateUserReply\"\x00\x12Q\n" +
+	"\x11CheckAvailability\x12\x1d.api.CheckAvailabilityRequest\x1a\x1b.api.CheckAvailabilityReply\"\x00\x12F\n" +
 	"\x0eDeactivateUser\x12\x1a.api.DeactivateUserRequest\x1a\x16.google.protobuf.Empty\"\x00\x12N\n" +
 	"\x12ChangeUserPassword\x12\x1e.api.ChangeUserPasswordRequest\x1a\x16.google.protobuf.Empty\"\x00\x12N\n" +
 	"\x10ValidatePassword\x12\x1c.api.ValidatePasswordRequest\x1a\x1a.api.ValidatePasswordReply\"\x00B+Z)github.com/townsag/reed/users_service/apib\x06proto3"
@@ -555,36 +901,50 @@ func file_api_user_proto_rawDescGZIP() []byte {
 	return file_api_user_proto_rawDescData
 }
 
-var file_api_user_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_api_user_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_api_user_proto_goTypes = []any{
 	(*User)(nil),                      // 0: api.User
 	(*GetUserRequest)(nil),            // 1: api.GetUserRequest
-	(*UserReply)(nil),                 // 2: api.UserReply
-	(*CreateUserRequest)(nil),         // 3: api.CreateUserRequest
-	(*CreateUserReply)(nil),           // 4: api.CreateUserReply
-	(*DeactivateUserRequest)(nil),     // 5: api.DeactivateUserRequest
-	(*ChangeUserPasswordRequest)(nil), // 6: api.ChangeUserPasswordRequest
-	(*ValidatePasswordRequest)(nil),   // 7: api.ValidatePasswordRequest
-	(*ValidatePasswordReply)(nil),     // 8: api.ValidatePasswordReply
-	(*emptypb.Empty)(nil),             // 9: google.protobuf.Empty
+	(*GetUserByEmailRequest)(nil),     // 2: api.GetUserByEmailRequest
+	(*ResolveUserRequest)(nil),        // 3: api.ResolveUserRequest
+	(*UserReply)(nil),                 // 4: api.UserReply
+	(*CreateUserRequest)(nil),         // 5: api.CreateUserRequest
+	(*CreateUserReply)(nil),           // 6: api.CreateUserReply
+	(*CheckAvailabilityRequest)(nil),  // 7: api.CheckAvailabilityRequest
+	(*CheckAvailabilityReply)(nil),    // 8: api.CheckAvailabilityReply
+	(*DeactivateUserRequest)(nil),     // 9: api.DeactivateUserRequest
+	(*ChangeUserPasswordRequest)(nil), // 10: api.ChangeUserPasswordRequest
+	(*ValidatePasswordRequest)(nil),   // 11: api.ValidatePasswordRequest
+	(*ValidatePasswordReply)(nil),     // 12: api.ValidatePasswordReply
+	(*timestamppb.Timestamp)(nil),     // 13: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),             // 14: google.protobuf.Empty
 }
 var file_api_user_proto_depIdxs = []int32{
-	0, // 0: api.UserReply.user:type_name -> api.User
-	1, // 1: api.UserService.GetUser:input_type -> api.GetUserRequest
-	3, // 2: api.UserService.CreateUser:input_type -> api.CreateUserRequest
-	5, // 3: api.UserService.DeactivateUser:input_type -> api.DeactivateUserRequest
-	6, // 4: api.UserService.ChangeUserPassword:input_type -> api.ChangeUserPasswordRequest
-	7, // 5: api.UserService.ValidatePassword:input_type -> api.ValidatePasswordRequest
-	2, // 6: api.UserService.GetUser:output_type -> api.UserReply
-	4, // 7: api.UserService.CreateUser:output_type -> api.CreateUserReply
-	9, // 8: api.UserService.DeactivateUser:output_type -> google.protobuf.Empty
-	9, // 9: api.UserService.ChangeUserPassword:output_type -> google.protobuf.Empty
-	8, // 10: api.UserService.ValidatePassword:output_type -> api.ValidatePasswordReply
-	6, // [6:11] is the sub-list for method output_type
-	1, // [1:6] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	13, // 0: api.User.created_at:type_name -> google.protobuf.Timestamp
+	13, // 1: api.User.last_modified_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: api.UserReply.user:type_name -> api.User
+	0,  // 3: api.CreateUserReply.user:type_name -> api.User
+	1,  // 4: api.UserService.GetUser:input_type -> api.GetUserRequest
+	2,  // 5: api.UserService.GetUserByEmail:input_type -> api.GetUserByEmailRequest
+	3,  // 6: api.UserService.ResolveUser:input_type -> api.ResolveUserRequest
+	5,  // 7: api.UserService.CreateUser:input_type -> api.CreateUserRequest
+	7,  // 8: api.UserService.CheckAvailability:input_type -> api.CheckAvailabilityRequest
+	9,  // 9: api.UserService.DeactivateUser:input_type -> api.DeactivateUserRequest
+	10, // 10: api.UserService.ChangeUserPassword:input_type -> api.ChangeUserPasswordRequest
+	11, // 11: api.UserService.ValidatePassword:input_type -> api.ValidatePasswordRequest
+	4,  // 12: api.UserService.GetUser:output_type -> api.UserReply
+	4,  // 13: api.UserService.GetUserByEmail:output_type -> api.UserReply
+	4,  // 14: api.UserService.ResolveUser:output_type -> api.UserReply
+	6,  // 15: api.UserService.CreateUser:output_type -> api.CreateUserReply
+	8,  // 16: api.UserService.CheckAvailability:output_type -> api.CheckAvailabilityReply
+	14, // 17: api.UserService.DeactivateUser:output_type -> google.protobuf.Empty
+	14, // 18: api.UserService.ChangeUserPassword:output_type -> google.protobuf.Empty
+	12, // 19: api.UserService.ValidatePassword:output_type -> api.ValidatePasswordReply
+	12, // [12:20] is the sub-list for method output_type
+	4,  // [4:12] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_api_user_proto_init() }
@@ -592,15 +952,22 @@ func file_api_user_proto_init() {
 	if File_api_user_proto != nil {
 		return
 	}
-	file_api_user_proto_msgTypes[3].OneofWrappers = []any{}
+	file_api_user_proto_msgTypes[3].OneofWrappers = []any{
+		(*ResolveUserRequest_UserId)(nil),
+		(*ResolveUserRequest_Email)(nil),
+		(*ResolveUserRequest_UserName)(nil),
+	}
+	file_api_user_proto_msgTypes[5].OneofWrappers = []any{}
+	file_api_user_proto_msgTypes[7].OneofWrappers = []any{}
 	file_api_user_proto_msgTypes[8].OneofWrappers = []any{}
+	file_api_user_proto_msgTypes[12].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_user_proto_rawDesc), len(file_api_user_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
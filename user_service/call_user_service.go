@@ -23,6 +23,7 @@ func main() {
 		"test@example.com",
 		"password",
 		nil,
+		nil,
 	)
 	fmt.Println("reply: ", reply)
 	if err != nil {
@@ -9,7 +9,9 @@ import (
 	"os"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	pb "github.com/townsag/reed/user_service/api"
 	"github.com/townsag/reed/user_service/internal/config"
@@ -22,7 +24,11 @@ import (
 
 func main() {
 	// initialize the otel sdk
-	otelShutdown, err := config.SetupOTelSDK(context.Background())
+	telemetryCfg, err := middleware.GetTelemetryConfig("user-service")
+	if err != nil {
+		log.Fatalf("failed to get telemetry configuration: %v", err)
+	}
+	otelShutdown, err := middleware.SetupOTelSDK(context.Background(), telemetryCfg)
 	if err != nil {
 		log.Fatalf("failed to bootstrap OTEL SDK: %v", err)
 	}
@@ -39,10 +45,28 @@ func main() {
 		os.Exit(1)
 	}
 	defer pool.Close()
+	acquireTimeout, queryTimeout, err := config.GetRepositoryTimeouts()
+	if err != nil {
+		slog.Error("failed to get database timeout configuration", "error", err.Error())
+		os.Exit(1)
+	}
+	passwordHistorySize, err := config.GetPasswordHistorySize()
+	if err != nil {
+		slog.Error("failed to get password history configuration", "error", err.Error())
+		os.Exit(1)
+	}
 	// create a repo
-	userRepo := repository.NewUserRepository(pool)
+	userRepo := repository.NewUserRepositoryWithPasswordHistory(
+		pool, acquireTimeout, queryTimeout, passwordHistorySize,
+	)
+	// record the latency of each repository method
+	instrumentedRepo, err := repository.NewInstrumentedUserRepository(userRepo, otel.GetMeterProvider())
+	if err != nil {
+		slog.Error("failed to instrument the user repository", "error", err.Error())
+		os.Exit(1)
+	}
 	// create a service
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(instrumentedRepo)
 	// create a server
 	userServer := server.NewUserServiceImpl(userService)
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", 50051))
@@ -50,13 +74,44 @@ func main() {
 		slog.Error("failed to listen", "error", err)
 		os.Exit(1)
 	}
-	s := grpc.NewServer(
+	rateLimiter, err := config.GetRateLimiter()
+	if err != nil {
+		slog.Error("failed to get the rate limit configuration", "error", err)
+		os.Exit(1)
+	}
+	// recover from panics in the handlers and in the interceptors after this one
+	recoveryInterceptor, err := middleware.RecoveryInterceptor(otel.GetMeterProvider())
+	if err != nil {
+		slog.Error("failed to create the recovery interceptor", "error", err)
+		os.Exit(1)
+	}
+	maxHandlerTimeout, err := config.GetMaxHandlerTimeout()
+	if err != nil {
+		slog.Error("failed to get the max handler timeout", "error", err)
+		os.Exit(1)
+	}
+	serverOptions := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor,
+			grpc.UnaryServerInterceptor(middleware.PrincipalIdInterceptor()),
 			grpc.UnaryServerInterceptor(middleware.TraceIdInterceptor()),
+			grpc.UnaryServerInterceptor(rateLimiter.Interceptor()),
 			grpc.UnaryServerInterceptor(middleware.LoggingInterceptor()),
+			// innermost so that the logging interceptor sees the DeadlineExceeded of a handler that timed out
+			grpc.UnaryServerInterceptor(middleware.TimeoutInterceptor(maxHandlerTimeout)),
 		),
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
+	}
+	// serve over tls when a certificate is configured, plaintext otherwise
+	tlsConfig, err := config.GetServerTLSConfig()
+	if err != nil {
+		slog.Error("failed to get the tls configuration", "error", err.Error())
+		os.Exit(1)
+	}
+	if tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s := grpc.NewServer(serverOptions...)
 	pb.RegisterUserServiceServer(s, userServer)
 	slog.Warn(fmt.Sprintf("server listening at %v", lis.Addr()))
 	if err := s.Serve(lis); err != nil {
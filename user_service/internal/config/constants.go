@@ -4,4 +4,7 @@ const (
 	MinUsernameLength = 3
 	MinPasswordLength = 8
 	DefaultMaxDocuments int32 = 100
+	// the largest document quota that can be requested when creating a user, values above
+	// this are almost certainly a mistake by the caller
+	MaxMaxDocuments int32 = 10000
 )
\ No newline at end of file
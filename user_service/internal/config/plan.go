@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// the plan of a user when the create user request does not name one
+const FreePlan = "free"
+
+// the document quota that a new user gets on each plan when the create user request does not
+// set the quota itself
+var PlanMaxDocuments = map[string]int32{
+	FreePlan: DefaultMaxDocuments,
+	"pro": 1000,
+	"team": MaxMaxDocuments,
+}
+
+// the names of the plans in alphabetical order, for error messages
+func PlanNames() []string {
+	names := make([]string, 0, len(PlanMaxDocuments))
+	for name := range PlanMaxDocuments {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// resolve the default document quota of a plan, an empty plan name is the free plan
+func ResolvePlanMaxDocuments(plan string) (int32, error) {
+	if plan == "" {
+		plan = FreePlan
+	}
+	maxDocuments, ok := PlanMaxDocuments[plan]
+	if !ok {
+		return 0, fmt.Errorf("unknown plan: %q, must be one of: %s", plan, strings.Join(PlanNames(), ", "))
+	}
+	return maxDocuments, nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestResolvePlanMaxDocuments_Unit(t *testing.T) {
+	testCases := []struct{
+		plan string
+		want int32
+	}{
+		{ plan: "", want: DefaultMaxDocuments },
+		{ plan: FreePlan, want: DefaultMaxDocuments },
+		{ plan: "pro", want: 1000 },
+		{ plan: "team", want: MaxMaxDocuments },
+	}
+	for _, testCase := range testCases {
+		got, err := ResolvePlanMaxDocuments(testCase.plan)
+		if err != nil {
+			t.Fatalf("failed to resolve plan: %q with error: %v", testCase.plan, err)
+		}
+		if got != testCase.want {
+			t.Errorf("want max documents: %d for plan: %q, got: %d", testCase.want, testCase.plan, got)
+		}
+	}
+}
+
+func TestResolvePlanMaxDocuments_UnknownPlan_Unit(t *testing.T) {
+	for _, plan := range []string{ "enterprise", "Free", " free" } {
+		if _, err := ResolvePlanMaxDocuments(plan); err == nil {
+			t.Errorf("want an error for the unknown plan: %q", plan)
+		}
+	}
+}
@@ -35,6 +35,33 @@ func GetConfiguration() (*pgxpool.Config, error) {
 	return cfg, nil	
 }
 
+// read the amount of time to wait for a connection from the pool and the amount of time that
+// the queries of one repository method can take, values use the time.ParseDuration format
+func GetRepositoryTimeouts() (acquireTimeout time.Duration, queryTimeout time.Duration, err error) {
+	acquireTimeout, err = time.ParseDuration(util.GetEnvWithDefault("POOL_ACQUIRE_TIMEOUT", "5s"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse POOL_ACQUIRE_TIMEOUT: %w", err)
+	}
+	queryTimeout, err = time.ParseDuration(util.GetEnvWithDefault("QUERY_TIMEOUT", "10s"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse QUERY_TIMEOUT: %w", err)
+	}
+	return acquireTimeout, queryTimeout, nil
+}
+
+// read the number of most recent passwords, counting the current one, that a new password must
+// not match. Zero disables the password history check
+func GetPasswordHistorySize() (int32, error) {
+	size, err := strconv.ParseInt(util.GetEnvWithDefault("PASSWORD_HISTORY_SIZE", "0"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse PASSWORD_HISTORY_SIZE: %w", err)
+	}
+	if size < 0 {
+		return 0, fmt.Errorf("PASSWORD_HISTORY_SIZE must not be negative, got: %d", size)
+	}
+	return int32(size), nil
+}
+
 func CreateDBConnectionPool(ctx context.Context, config *pgxpool.Config) (*pgxpool.Pool, error) {
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/townsag/reed/user_service/pkg/middleware"
+	"github.com/townsag/reed/user_service/internal/util"
+)
+
+// read the rate limits of the gRPC server. RATE_LIMIT is the rate:burst that applies to each
+// method, RATE_LIMIT_METHODS overrides it for individual methods with a comma separated list of
+// method=rate:burst entries, and RATE_LIMIT_BY_PRINCIPAL gives each calling principal its own
+// budget for each method. A rate of zero disables the limit
+func GetRateLimiter() (*middleware.RateLimiter, error) {
+	defaultLimit, err := middleware.ParseRateLimit(util.GetEnvWithDefault("RATE_LIMIT", "500:1000"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RATE_LIMIT: %w", err)
+	}
+	methodLimits, err := middleware.ParseMethodRateLimits(util.GetEnvWithDefault("RATE_LIMIT_METHODS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RATE_LIMIT_METHODS: %w", err)
+	}
+	byPrincipal, err := strconv.ParseBool(util.GetEnvWithDefault("RATE_LIMIT_BY_PRINCIPAL", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RATE_LIMIT_BY_PRINCIPAL: %w", err)
+	}
+	return middleware.NewRateLimiter(defaultLimit, methodLimits, byPrincipal), nil
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/townsag/reed/user_service/internal/util"
+)
+
+// read the longest a gRPC handler can run from MAX_HANDLER_TIMEOUT, in the time.ParseDuration
+// format. Calls without a deadline or with a later one are cut off at this timeout, zero disables it
+func GetMaxHandlerTimeout() (time.Duration, error) {
+	maxTimeout, err := time.ParseDuration(util.GetEnvWithDefault("MAX_HANDLER_TIMEOUT", "30s"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse MAX_HANDLER_TIMEOUT: %w", err)
+	}
+	if maxTimeout < 0 {
+		return 0, fmt.Errorf("MAX_HANDLER_TIMEOUT must not be negative, got: %v", maxTimeout)
+	}
+	return maxTimeout, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/townsag/reed/user_service/internal/service"
+)
+
+const instrumentationName = "github.com/townsag/reed/user_service/internal/repository"
+
+// the name of the histogram that records the latency of each repository method, the method
+// name is recorded in the method attribute
+const MethodDurationMetric = "repository.method.duration"
+
+// InstrumentedUserRepository decorates a user repository and records the latency of each
+// method call in a histogram. It implements the same interface as the repository that it wraps
+// so the service layer does not know that it is there
+type InstrumentedUserRepository struct {
+	next service.UserRepository
+	duration metric.Float64Histogram
+}
+
+func NewInstrumentedUserRepository(
+	next service.UserRepository,
+	meterProvider metric.MeterProvider,
+) (*InstrumentedUserRepository, error) {
+	duration, err := meterProvider.Meter(instrumentationName).Float64Histogram(
+		MethodDurationMetric,
+		metric.WithDescription("the latency of user repository method calls"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository duration histogram: %w", err)
+	}
+	return &InstrumentedUserRepository{
+		next: next,
+		duration: duration,
+	}, nil
+}
+
+// called with defer at the start of each method so that the arguments are evaluated when the
+// method is entered and the measurement is recorded when it returns
+func (r *InstrumentedUserRepository) record(ctx context.Context, method string, start time.Time) {
+	r.duration.Record(
+		ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(attribute.String("method", method)),
+	)
+}
+
+func (r *InstrumentedUserRepository) CreateUser(
+	ctx context.Context, userName string, email string, maxDocuments int32, password string,
+) (*service.User, service.DomainError) {
+	defer r.record(ctx, "CreateUser", time.Now())
+	return r.next.CreateUser(ctx, userName, email, maxDocuments, password)
+}
+
+func (r *InstrumentedUserRepository) GetUserById(
+	ctx context.Context, userId uuid.UUID,
+) (*service.User, service.DomainError) {
+	defer r.record(ctx, "GetUserById", time.Now())
+	return r.next.GetUserById(ctx, userId)
+}
+
+func (r *InstrumentedUserRepository) GetUserByEmail(
+	ctx context.Context, userEmail string,
+) (*service.User, service.DomainError) {
+	defer r.record(ctx, "GetUserByEmail", time.Now())
+	return r.next.GetUserByEmail(ctx, userEmail)
+}
+
+func (r *InstrumentedUserRepository) GetUserByUserName(
+	ctx context.Context, userName string,
+) (*service.User, service.DomainError) {
+	defer r.record(ctx, "GetUserByUserName", time.Now())
+	return r.next.GetUserByUserName(ctx, userName)
+}
+
+func (r *InstrumentedUserRepository) UserNameExists(
+	ctx context.Context, userName string,
+) (bool, service.DomainError) {
+	defer r.record(ctx, "UserNameExists", time.Now())
+	return r.next.UserNameExists(ctx, userName)
+}
+
+func (r *InstrumentedUserRepository) EmailExists(
+	ctx context.Context, email string,
+) (bool, service.DomainError) {
+	defer r.record(ctx, "EmailExists", time.Now())
+	return r.next.EmailExists(ctx, email)
+}
+
+func (r *InstrumentedUserRepository) DeactivateUser(ctx context.Context, userId uuid.UUID) service.DomainError {
+	defer r.record(ctx, "DeactivateUser", time.Now())
+	return r.next.DeactivateUser(ctx, userId)
+}
+
+func (r *InstrumentedUserRepository) ModifyPassword(
+	ctx context.Context, userId uuid.UUID, oldPassword string, newPassword string,
+) service.DomainError {
+	defer r.record(ctx, "ModifyPassword", time.Now())
+	return r.next.ModifyPassword(ctx, userId, oldPassword, newPassword)
+}
+
+func (r *InstrumentedUserRepository) ValidatePassword(
+	ctx context.Context, userName string, password string,
+) (uuid.UUID, int32, bool, service.DomainError) {
+	defer r.record(ctx, "ValidatePassword", time.Now())
+	return r.next.ValidatePassword(ctx, userName, password)
+}
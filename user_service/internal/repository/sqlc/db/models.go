@@ -8,6 +8,13 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type PasswordHistory struct {
+	ID             int64
+	UserID         pgtype.UUID
+	HashedPassword string
+	CreatedAt      pgtype.Timestamp
+}
+
 type User struct {
 	ID             pgtype.UUID
 	UserName       string
@@ -17,4 +24,5 @@ type User struct {
 	IsActive       pgtype.Bool
 	CreatedAt      pgtype.Timestamp
 	LastModified   pgtype.Timestamp
+	TokenVersion   int32
 }
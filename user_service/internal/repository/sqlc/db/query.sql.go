@@ -13,7 +13,7 @@ import (
 
 const changeUserPassword = `-- name: ChangeUserPassword :one
 UPDATE users
-SET hashed_password = $1, last_modified = CURRENT_TIMESTAMP
+SET hashed_password = $1, token_version = token_version + 1, last_modified = CURRENT_TIMESTAMP
 WHERE id = $2
 RETURNING id
 `
@@ -30,9 +30,10 @@ func (q *Queries) ChangeUserPassword(ctx context.Context, arg ChangeUserPassword
 	return id, err
 }
 
-const createUser = `-- name: CreateUser :exec
+const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, user_name, email, max_documents, hashed_password)
 VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version
 `
 
 type CreateUserParams struct {
@@ -43,20 +44,32 @@ type CreateUserParams struct {
 	HashedPassword string
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
-	_, err := q.db.Exec(ctx, createUser,
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, createUser,
 		arg.ID,
 		arg.UserName,
 		arg.Email,
 		arg.MaxDocuments,
 		arg.HashedPassword,
 	)
-	return err
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserName,
+		&i.Email,
+		&i.MaxDocuments,
+		&i.HashedPassword,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.LastModified,
+		&i.TokenVersion,
+	)
+	return i, err
 }
 
 const deactivateUser = `-- name: DeactivateUser :one
 UPDATE users
-SET is_active = FALSE, last_modified = CURRENT_TIMESTAMP
+SET is_active = FALSE, token_version = token_version + 1, last_modified = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING id
 `
@@ -67,9 +80,22 @@ func (q *Queries) DeactivateUser(ctx context.Context, id pgtype.UUID) (pgtype.UU
 	return id, err
 }
 
+const emailExists = `-- name: EmailExists :one
+SELECT EXISTS (
+    SELECT 1 FROM users WHERE email = $1
+)
+`
+
+func (q *Queries) EmailExists(ctx context.Context, email string) (bool, error) {
+	row := q.db.QueryRow(ctx, emailExists, email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const getHashedPassword = `-- name: GetHashedPassword :one
 
-SELECT id, hashed_password
+SELECT id, hashed_password, is_active, token_version
 FROM users
 WHERE user_name = $1
 `
@@ -77,6 +103,8 @@ WHERE user_name = $1
 type GetHashedPasswordRow struct {
 	ID             pgtype.UUID
 	HashedPassword string
+	IsActive       pgtype.Bool
+	TokenVersion   int32
 }
 
 // this allows us to read and update the password with serializability guarantees
@@ -85,12 +113,17 @@ type GetHashedPasswordRow struct {
 func (q *Queries) GetHashedPassword(ctx context.Context, userName string) (GetHashedPasswordRow, error) {
 	row := q.db.QueryRow(ctx, getHashedPassword, userName)
 	var i GetHashedPasswordRow
-	err := row.Scan(&i.ID, &i.HashedPassword)
+	err := row.Scan(
+		&i.ID,
+		&i.HashedPassword,
+		&i.IsActive,
+		&i.TokenVersion,
+	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified
+SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version
 FROM users
 WHERE email = $1
 `
@@ -107,12 +140,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.LastModified,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified 
+SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version 
 FROM users 
 WHERE id = $1
 `
@@ -129,12 +163,36 @@ func (q *Queries) GetUserById(ctx context.Context, id pgtype.UUID) (User, error)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.LastModified,
+		&i.TokenVersion,
+	)
+	return i, err
+}
+
+const getUserByUserName = `-- name: GetUserByUserName :one
+SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version
+FROM users
+WHERE user_name = $1
+`
+
+func (q *Queries) GetUserByUserName(ctx context.Context, userName string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByUserName, userName)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserName,
+		&i.Email,
+		&i.MaxDocuments,
+		&i.HashedPassword,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.LastModified,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
 const getUserForUpdate = `-- name: GetUserForUpdate :one
-SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified 
+SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version 
 FROM users 
 WHERE id = $1
 FOR UPDATE
@@ -152,6 +210,93 @@ func (q *Queries) GetUserForUpdate(ctx context.Context, id pgtype.UUID) (User, e
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.LastModified,
+		&i.TokenVersion,
 	)
 	return i, err
 }
+
+const insertPasswordHistory = `-- name: InsertPasswordHistory :exec
+INSERT INTO password_history (user_id, hashed_password)
+VALUES ($1, $2)
+`
+
+type InsertPasswordHistoryParams struct {
+	UserID         pgtype.UUID
+	HashedPassword string
+}
+
+func (q *Queries) InsertPasswordHistory(ctx context.Context, arg InsertPasswordHistoryParams) error {
+	_, err := q.db.Exec(ctx, insertPasswordHistory, arg.UserID, arg.HashedPassword)
+	return err
+}
+
+const listPasswordHistory = `-- name: ListPasswordHistory :many
+SELECT hashed_password FROM password_history
+WHERE user_id = $1
+ORDER BY id DESC
+LIMIT $2
+`
+
+type ListPasswordHistoryParams struct {
+	UserID pgtype.UUID
+	Limit  int32
+}
+
+// the most recent previous passwords of the user, newest first
+func (q *Queries) ListPasswordHistory(ctx context.Context, arg ListPasswordHistoryParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listPasswordHistory, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var hashed_password string
+		if err := rows.Scan(&hashed_password); err != nil {
+			return nil, err
+		}
+		items = append(items, hashed_password)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const prunePasswordHistory = `-- name: PrunePasswordHistory :execrows
+DELETE FROM password_history
+WHERE user_id = $1
+AND id NOT IN (
+    SELECT id FROM password_history
+    WHERE user_id = $1
+    ORDER BY id DESC
+    LIMIT $2
+)
+`
+
+type PrunePasswordHistoryParams struct {
+	UserID pgtype.UUID
+	Limit  int32
+}
+
+// delete all but the newest previous passwords of the user
+func (q *Queries) PrunePasswordHistory(ctx context.Context, arg PrunePasswordHistoryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, prunePasswordHistory, arg.UserID, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const userNameExists = `-- name: UserNameExists :one
+SELECT EXISTS (
+    SELECT 1 FROM users WHERE user_name = $1
+)
+`
+
+func (q *Queries) UserNameExists(ctx context.Context, userName string) (bool, error) {
+	row := q.db.QueryRow(ctx, userNameExists, userName)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -1,14 +1,15 @@
--- name: CreateUser :exec
+-- name: CreateUser :one
 INSERT INTO users (id, user_name, email, max_documents, hashed_password)
-VALUES ($1, $2, $3, $4, $5);
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version;
 
 -- name: GetUserById :one
-SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified 
+SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version 
 FROM users 
 WHERE id = $1;
 
 -- name: GetUserForUpdate :one
-SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified 
+SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version 
 FROM users 
 WHERE id = $1
 FOR UPDATE;
@@ -17,23 +18,60 @@ FOR UPDATE;
 -- other operations cannot update, delete, or select for update on that row
 
 -- name: GetHashedPassword :one
-SELECT id, hashed_password
+SELECT id, hashed_password, is_active, token_version
 FROM users
 WHERE user_name = $1;
 
 -- name: GetUserByEmail :one
-SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified
+SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version
 FROM users
 WHERE email = $1;
 
+-- name: GetUserByUserName :one
+SELECT id, user_name, email, max_documents, hashed_password, is_active, created_at, last_modified, token_version
+FROM users
+WHERE user_name = $1;
+
+-- name: UserNameExists :one
+SELECT EXISTS (
+    SELECT 1 FROM users WHERE user_name = $1
+);
+
+-- name: EmailExists :one
+SELECT EXISTS (
+    SELECT 1 FROM users WHERE email = $1
+);
+
 -- name: DeactivateUser :one
 UPDATE users
-SET is_active = FALSE, last_modified = CURRENT_TIMESTAMP
+SET is_active = FALSE, token_version = token_version + 1, last_modified = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING id;
 
 -- name: ChangeUserPassword :one
 UPDATE users
-SET hashed_password = $1, last_modified = CURRENT_TIMESTAMP
+SET hashed_password = $1, token_version = token_version + 1, last_modified = CURRENT_TIMESTAMP
 WHERE id = $2
-RETURNING id;
\ No newline at end of file
+RETURNING id;
+
+-- name: InsertPasswordHistory :exec
+INSERT INTO password_history (user_id, hashed_password)
+VALUES ($1, $2);
+
+-- the most recent previous passwords of the user, newest first
+-- name: ListPasswordHistory :many
+SELECT hashed_password FROM password_history
+WHERE user_id = $1
+ORDER BY id DESC
+LIMIT $2;
+
+-- delete all but the newest previous passwords of the user
+-- name: PrunePasswordHistory :execrows
+DELETE FROM password_history
+WHERE user_id = $1
+AND id NOT IN (
+    SELECT id FROM password_history
+    WHERE user_id = $1
+    ORDER BY id DESC
+    LIMIT $2
+);
@@ -7,7 +7,21 @@ CREATE TABLE users (
     hashed_password VARCHAR(255) NOT NULL,
     is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    last_modified TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    last_modified TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- bumped whenever the existing sessions of the user must stop working, tokens carry the
+    -- version that they were issued with and are rejected once it is stale
+    token_version INTEGER NOT NULL DEFAULT 0
 );
 
-CREATE INDEX idx_users_username ON users(user_name DESC);
\ No newline at end of file
+CREATE INDEX idx_users_username ON users(user_name DESC);
+
+-- the previous hashed passwords of each user, newest first by id. The current password stays
+-- in the users table. Only as many rows as the password history policy checks are kept
+CREATE TABLE password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    hashed_password VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_history_user_id ON password_history(user_id, id DESC);
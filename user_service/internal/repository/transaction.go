@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/townsag/reed/user_service/internal/service"
+)
+
+// postgres error codes of failures that are caused by concurrent transactions, a transaction
+// that fails with one of these can succeed when it is run again from the start
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode = "40P01"
+)
+
+// the most times that a transaction is attempted before its transient failure is returned
+const maxTransactionAttempts = 3
+
+// the time waited before the second attempt of a transaction, this doubles for each attempt
+// after that
+const transactionRetryBackoff = 10 * time.Millisecond
+
+// the part of a pool connection that starts transactions
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+func isTransientError(err error) bool {
+	var pgError *pgconn.PgError
+	if !errors.As(err, &pgError) {
+		return false
+	}
+	return pgError.Code == serializationFailureCode || pgError.Code == deadlockDetectedCode
+}
+
+// run fn in a transaction and commit it when fn succeeds. The whole transaction is run again
+// when it fails with a transient error, fn must not have side effects outside of the
+// transaction. A transient failure of the last attempt is returned as a TransientError so that
+// callers can tell it apart from a permanent failure
+func runInTransaction(
+	ctx context.Context,
+	beginner txBeginner,
+	fn func(tx pgx.Tx) service.DomainError,
+) service.DomainError {
+	backoff := transactionRetryBackoff
+	var domainErr service.DomainError
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		domainErr = attemptTransaction(ctx, beginner, fn)
+		if domainErr == nil || !isTransientError(domainErr) {
+			return domainErr
+		}
+		if attempt == maxTransactionAttempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return service.RepoImpl("context ended while waiting to retry a transaction", ctx.Err())
+		}
+		backoff *= 2
+	}
+	return service.Transient(
+		fmt.Sprintf("transaction failed after %d attempts", maxTransactionAttempts), domainErr,
+	)
+}
+
+func attemptTransaction(
+	ctx context.Context,
+	beginner txBeginner,
+	fn func(tx pgx.Tx) service.DomainError,
+) service.DomainError {
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return service.RepoImpl("failed to begin a transaction", err)
+	}
+	defer tx.Rollback(ctx)
+	if domainErr := fn(tx); domainErr != nil {
+		return domainErr
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return service.RepoImpl("failed to commit the transaction", err)
+	}
+	return nil
+}
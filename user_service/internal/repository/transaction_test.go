@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+
+	sqlc "github.com/townsag/reed/user_service/internal/repository/sqlc/db"
+	"github.com/townsag/reed/user_service/internal/service"
+)
+
+// a row that either fails with err or calls scan to fill in the destinations
+type fakeRow struct {
+	err  error
+	scan func(dest ...any)
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.scan(dest...)
+	return nil
+}
+
+// embedding the interface satisfies it without a database, calling a method that is not
+// overridden panics
+type fakeTx struct {
+	pgx.Tx
+	queryRow func(sql string) pgx.Row
+}
+
+func (tx *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return tx.queryRow(sql)
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
+// hands out the transaction for each attempt in order, the last one is reused
+type fakeBeginner struct {
+	attempts []*fakeTx
+	begins   int
+}
+
+func (b *fakeBeginner) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx := b.attempts[min(b.begins, len(b.attempts)-1)]
+	b.begins++
+	return tx, nil
+}
+
+// a transaction where reading the user fails with err
+func failingTx(err error) *fakeTx {
+	return &fakeTx{
+		queryRow: func(sql string) pgx.Row {
+			return fakeRow{ err: err }
+		},
+	}
+}
+
+// a transaction where the user has the hashed password and the update succeeds
+func succeedingTx(userId uuid.UUID, hashedPassword string) *fakeTx {
+	return &fakeTx{
+		queryRow: func(sql string) pgx.Row {
+			if strings.Contains(sql, "name: GetUserForUpdate") {
+				return fakeRow{ scan: func(dest ...any) {
+					// the hashed password is the fifth column of the user
+					*dest[4].(*string) = hashedPassword
+				} }
+			}
+			return fakeRow{ scan: func(dest ...any) {} }
+		},
+	}
+}
+
+func TestModifyPassword_RetriesSerializationFailure_Unit(t *testing.T) {
+	userId := uuid.New()
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("oldPassword"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password with error: %v", err)
+	}
+	beginner := &fakeBeginner{ attempts: []*fakeTx{
+		failingTx(&pgconn.PgError{ Code: serializationFailureCode }),
+		succeedingTx(userId, string(hashedPassword)),
+	} }
+	repo := &UserRepository{ queries: sqlc.New(nil) }
+	domainErr := repo.modifyPassword(t.Context(), beginner, userId, "oldPassword", "newPassword")
+	if domainErr != nil {
+		t.Fatalf("want the retried transaction to succeed, got: %v", domainErr)
+	}
+	if beginner.begins != 2 {
+		t.Errorf("want 2 transaction attempts, got: %d", beginner.begins)
+	}
+}
+
+func TestModifyPassword_RetriesExhausted_Unit(t *testing.T) {
+	beginner := &fakeBeginner{ attempts: []*fakeTx{
+		failingTx(&pgconn.PgError{ Code: deadlockDetectedCode }),
+	} }
+	repo := &UserRepository{ queries: sqlc.New(nil) }
+	domainErr := repo.modifyPassword(t.Context(), beginner, uuid.New(), "oldPassword", "newPassword")
+	var transientError *service.TransientError
+	if !errors.As(domainErr, &transientError) {
+		t.Errorf("want: a service TransientError after the retries are exhausted, got: %v", domainErr)
+	}
+	if beginner.begins != maxTransactionAttempts {
+		t.Errorf("want %d transaction attempts, got: %d", maxTransactionAttempts, beginner.begins)
+	}
+}
+
+func TestModifyPassword_PermanentErrorNotRetried_Unit(t *testing.T) {
+	beginner := &fakeBeginner{ attempts: []*fakeTx{
+		failingTx(pgx.ErrNoRows),
+	} }
+	repo := &UserRepository{ queries: sqlc.New(nil) }
+	domainErr := repo.modifyPassword(t.Context(), beginner, uuid.New(), "oldPassword", "newPassword")
+	var notFoundError *service.NotFoundError
+	if !errors.As(domainErr, &notFoundError) {
+		t.Errorf("want: a service NotFoundError for a missing user, got: %v", domainErr)
+	}
+	if beginner.begins != 1 {
+		t.Errorf("want 1 transaction attempt, got: %d", beginner.begins)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -28,13 +29,74 @@ var _ service.UserRepository = (*UserRepository)(nil)
 type UserRepository struct {
 	queries *sqlc.Queries
 	pool *pgxpool.Pool
+	// the maximum amount of time to wait for a connection from the pool
+	acquireTimeout time.Duration
+	// the maximum amount of time that the queries of one repository method can take, this
+	// includes the time spent waiting for a connection
+	queryTimeout time.Duration
+	// the number of most recent passwords, counting the current one, that a new password must
+	// not match. Zero disables the password history check
+	passwordHistorySize int32
 }
 
+const DefaultAcquireTimeout time.Duration = 5 * time.Second
+const DefaultQueryTimeout time.Duration = 10 * time.Second
+// the password history check is disabled unless it is configured
+const DefaultPasswordHistorySize int32 = 0
+
 // pgxpool implements the DBTX interface defined by the generated sqlc code
 // func NewUserRepository(conn *pgxpool.Pool) *UserRepository {
 // ^removed as to follow golang best practice of accepting interfaces and returning structs
 func NewUserRepository(conn *pgxpool.Pool) *UserRepository {
-	return &UserRepository{ queries: sqlc.New(conn), pool: conn }
+	return NewUserRepositoryWithTimeouts(conn, DefaultAcquireTimeout, DefaultQueryTimeout)
+}
+
+func NewUserRepositoryWithTimeouts(
+	conn *pgxpool.Pool,
+	acquireTimeout time.Duration,
+	queryTimeout time.Duration,
+) *UserRepository {
+	return NewUserRepositoryWithPasswordHistory(conn, acquireTimeout, queryTimeout, DefaultPasswordHistorySize)
+}
+
+func NewUserRepositoryWithPasswordHistory(
+	conn *pgxpool.Pool,
+	acquireTimeout time.Duration,
+	queryTimeout time.Duration,
+	passwordHistorySize int32,
+) *UserRepository {
+	return &UserRepository{
+		queries: sqlc.New(conn),
+		pool: conn,
+		acquireTimeout: acquireTimeout,
+		queryTimeout: queryTimeout,
+		passwordHistorySize: passwordHistorySize,
+	}
+}
+
+// derive a context that is bounded by the query timeout and acquire a connection from the pool
+// waiting at most the acquire timeout, this prevents requests from blocking on an exhausted pool
+// indefinitely. The caller must call the returned release function once it is done with the
+// connection
+func (r *UserRepository) acquire(
+	ctx context.Context,
+) (context.Context, *pgxpool.Conn, func(), service.DomainError) {
+	queryCtx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	acquireCtx, cancelAcquire := context.WithTimeout(queryCtx, r.acquireTimeout)
+	defer cancelAcquire()
+	conn, err := r.pool.Acquire(acquireCtx)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, service.RepoImpl(
+			fmt.Sprintf("failed to acquire a database connection within %v", r.acquireTimeout),
+			err,
+		)
+	}
+	release := func() {
+		conn.Release()
+		cancel()
+	}
+	return queryCtx, conn, release, nil
 }
 
 // add the helper method for converting from the User struct defined by the generated
@@ -49,6 +111,7 @@ func serviceToRepository(user service.User) *sqlc.User {
 		IsActive: pgtype.Bool{ Bool:user.IsActive, Valid: true },
 		CreatedAt: pgtype.Timestamp{ Time: user.CreatedAt, Valid: true },
 		LastModified: pgtype.Timestamp{ Time: user.LastModified, Valid: true },
+		TokenVersion: user.TokenVersion,
 	}
 }
 
@@ -62,6 +125,27 @@ func repositoryToService(user sqlc.User) *service.User {
 		IsActive: user.IsActive.Bool,
 		CreatedAt: user.CreatedAt.Time,
 		LastModified: user.LastModified.Time,
+		TokenVersion: user.TokenVersion,
+	}
+}
+
+// the names that postgres gives to the inline unique constraints of the users table
+const (
+	userNameUniqueConstraint = "users_user_name_key"
+	emailUniqueConstraint = "users_email_key"
+)
+
+// map the unique constraint that a write violated to the field of the user that collided, the
+// field names match the field names of the service validation errors. An unknown constraint
+// maps to an empty field
+func conflictingUserField(constraintName string) string {
+	switch constraintName {
+	case userNameUniqueConstraint:
+		return "user_name"
+	case emailUniqueConstraint:
+		return "email"
+	default:
+		return ""
 	}
 }
 
@@ -71,10 +155,10 @@ func (r *UserRepository) CreateUser(
 	email string,
 	maxDocuments int32, 
 	password string,
-) (uuid.UUID, service.DomainError) {
+) (*service.User, service.DomainError) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return uuid.Nil, service.RepoImpl("error creating hash of users new password", err)
+		return nil, service.RepoImpl("error creating hash of users new password", err)
 	}
 	userId := uuid.New()
 	params := sqlc.CreateUserParams{
@@ -84,33 +168,46 @@ func (r *UserRepository) CreateUser(
 		MaxDocuments: pgtype.Int4{ Int32: maxDocuments, Valid: true },
 		HashedPassword: string(hashedPassword),
 	}
-	err = r.queries.CreateUser(ctx, params)
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return nil, acquireErr
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	user, err := queries.CreateUser(ctx, params)
 	if err != nil {
 		var pgError *pgconn.PgError
 		if errors.As(err, &pgError) {
 			// parse the error code here and determine a semantic error type
 			// unique conflict
 			if pgError.Code == "23505" {
-				return uuid.Nil, service.UniqueConflict(
+				return nil, service.UniqueConflictField(
 					fmt.Sprintf("constraint: %s, detail: %s", pgError.ConstraintName, pgError.Detail), 
+					conflictingUserField(pgError.ConstraintName),
 					err,
 				)
 			} else {
 				// db implementation error
-				return uuid.Nil, service.RepoImpl(pgError.Error(), pgError)
+				return nil, service.RepoImpl(pgError.Error(), pgError)
 			}
 		} else {
-			return uuid.Nil, service.RepoImpl("unknown error encountered when creating user", err)
+			return nil, service.RepoImpl("unknown error encountered when creating user", err)
 		}
 	}
-	return userId, nil
+	return repositoryToService(user), nil
 }
 
 func (r *UserRepository) GetUserById(
 	ctx context.Context,
 	userId uuid.UUID,
 ) (*service.User, service.DomainError) {
-	user, err := r.queries.GetUserById(ctx, pgtype.UUID{ Bytes: userId, Valid: true })
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return nil, acquireErr
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	user, err := queries.GetUserById(ctx, pgtype.UUID{ Bytes: userId, Valid: true })
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, service.NotFound(fmt.Sprintf("No user found for userId: %d", userId))
@@ -122,7 +219,13 @@ func (r *UserRepository) GetUserById(
 }
 
 func (r *UserRepository) GetUserByEmail(ctx context.Context, userEmail string) (*service.User, service.DomainError) {
-	user, err := r.queries.GetUserByEmail(ctx, userEmail)
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return nil, acquireErr
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	user, err := queries.GetUserByEmail(ctx, userEmail)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, service.NotFound(fmt.Sprintf("No user found with email: %s", userEmail))
@@ -133,8 +236,58 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, userEmail string) (
 	return repositoryToService(user), nil
 }
 
+func (r *UserRepository) GetUserByUserName(ctx context.Context, userName string) (*service.User, service.DomainError) {
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return nil, acquireErr
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	user, err := queries.GetUserByUserName(ctx, userName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.NotFound(fmt.Sprintf("No user found with user name: %s", userName))
+		} else {
+			return nil, service.RepoImpl(err.Error(), err)
+		}
+	}
+	return repositoryToService(user), nil
+}
+
+func (r *UserRepository) UserNameExists(ctx context.Context, userName string) (bool, service.DomainError) {
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return false, acquireErr
+	}
+	defer release()
+	exists, err := sqlc.New(conn).UserNameExists(ctx, userName)
+	if err != nil {
+		return false, service.RepoImpl(err.Error(), err)
+	}
+	return exists, nil
+}
+
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, service.DomainError) {
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return false, acquireErr
+	}
+	defer release()
+	exists, err := sqlc.New(conn).EmailExists(ctx, email)
+	if err != nil {
+		return false, service.RepoImpl(err.Error(), err)
+	}
+	return exists, nil
+}
+
 func (r *UserRepository) DeactivateUser (ctx context.Context, userId uuid.UUID) service.DomainError {
-	_, err := r.queries.DeactivateUser(ctx, pgtype.UUID{ Bytes: userId, Valid: true })
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return acquireErr
+	}
+	defer release()
+	queries := sqlc.New(conn)
+	_, err := queries.DeactivateUser(ctx, pgtype.UUID{ Bytes: userId, Valid: true })
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return service.NotFound(fmt.Sprintf("No user found with userId: %d to deactivate", userId))
@@ -151,46 +304,121 @@ func (r *UserRepository) ModifyPassword(
 	oldPassword string, 
 	newPassword string,
 ) service.DomainError {
-	// create a transaction
-	tx, err := r.pool.Begin(ctx)
-	if err != nil {
-		return service.RepoImpl(
-			"failed to create a transaction when modifying password",
-			err,
-		)
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return acquireErr
 	}
-	defer tx.Rollback(ctx)
-	txQueries := r.queries.WithTx(tx)
-	// read the password associated with this user
-	user, err := txQueries.GetUserForUpdate(ctx, pgtype.UUID{ Bytes: userId, Valid: true })
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return service.NotFound(fmt.Sprintf("No user found with userId: %d to update", userId))
-		} else {
-			return service.RepoImpl("unexpected error found when reading user", err)
+	defer release()
+	return r.modifyPassword(ctx, conn, userId, oldPassword, newPassword)
+}
+
+// the transaction of ModifyPassword, the connection is taken as a txBeginner so that the
+// transaction can be run against a fake database in tests
+func (r *UserRepository) modifyPassword(
+	ctx context.Context,
+	beginner txBeginner,
+	userId uuid.UUID,
+	oldPassword string,
+	newPassword string,
+) service.DomainError {
+	// hashing is slow on purpose, the new password is hashed at most once even when the
+	// transaction is retried
+	var newHashedPassword []byte
+	return runInTransaction(ctx, beginner, func(tx pgx.Tx) service.DomainError {
+		txQueries := r.queries.WithTx(tx)
+		// read the password associated with this user
+		user, err := txQueries.GetUserForUpdate(ctx, pgtype.UUID{ Bytes: userId, Valid: true })
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return service.NotFound(fmt.Sprintf("No user found with userId: %d to update", userId))
+			} else {
+				return service.RepoImpl("unexpected error found when reading user", err)
+			}
+		}
+		// validate that the old password matches the hashed password in the database
+		if err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(oldPassword)); err != nil {
+			return service.PasswordMismatch(err)
+		}
+		if domainErr := r.checkPasswordHistory(ctx, txQueries, user, newPassword); domainErr != nil {
+			return domainErr
+		}
+		// update the database to reflect the change in hashed password
+		if newHashedPassword == nil {
+			newHashedPassword, err = bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+			if err != nil {
+				return service.RepoImpl("error creating hash of users new password", err)
+			}
 		}
+		param := sqlc.ChangeUserPasswordParams{
+			HashedPassword: string(newHashedPassword),
+			ID: user.ID,
+		}
+		_, err = txQueries.ChangeUserPassword(ctx, param)
+		if err != nil {
+			return service.RepoImpl("error updating user record with new hashed password", err)
+		}
+		return r.rotatePasswordHistory(ctx, txQueries, user)
+	})
+}
+
+// reject a new password that matches the current password or one of the previous passwords
+// kept by the password history policy
+func (r *UserRepository) checkPasswordHistory(
+	ctx context.Context,
+	txQueries *sqlc.Queries,
+	user sqlc.User,
+	newPassword string,
+) service.DomainError {
+	if r.passwordHistorySize < 1 {
+		return nil
 	}
-	// validate that the old password matches the hashed password in the database
-	if err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(oldPassword)); err != nil {
-		return service.PasswordMismatch(err)
+	hashedPasswords := []string{ user.HashedPassword }
+	if r.passwordHistorySize > 1 {
+		previous, err := txQueries.ListPasswordHistory(ctx, sqlc.ListPasswordHistoryParams{
+			UserID: user.ID,
+			Limit: r.passwordHistorySize - 1,
+		})
+		if err != nil {
+			return service.RepoImpl("error reading the password history of the user", err)
+		}
+		hashedPasswords = append(hashedPasswords, previous...)
 	}
-	// update the database to reflect the change in hashed password
-	newHashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return service.RepoImpl("error creating hash of users new password", err)
+	for _, hashedPassword := range hashedPasswords {
+		if bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(newPassword)) == nil {
+			return service.Invalid(
+				fmt.Sprintf("the new password must not match any of the last %d passwords", r.passwordHistorySize),
+				nil,
+			)
+		}
 	}
-	param := sqlc.ChangeUserPasswordParams{
-		HashedPassword: string(newHashedPassword),
-		ID: user.ID,
+	return nil
+}
+
+// keep the replaced password in the history and delete the passwords that are older than the
+// policy checks
+func (r *UserRepository) rotatePasswordHistory(
+	ctx context.Context,
+	txQueries *sqlc.Queries,
+	user sqlc.User,
+) service.DomainError {
+	// the current password is read from the users table, so a history of one needs no rows
+	if r.passwordHistorySize < 2 {
+		return nil
 	}
-	_, err = txQueries.ChangeUserPassword(ctx, param)
+	err := txQueries.InsertPasswordHistory(ctx, sqlc.InsertPasswordHistoryParams{
+		UserID: user.ID,
+		HashedPassword: user.HashedPassword,
+	})
 	if err != nil {
-		return service.RepoImpl("error updating user record with new hashed password", err)
+		return service.RepoImpl("error adding the replaced password to the password history", err)
 	}
-	err = tx.Commit(ctx)
+	_, err = txQueries.PrunePasswordHistory(ctx, sqlc.PrunePasswordHistoryParams{
+		UserID: user.ID,
+		Limit: r.passwordHistorySize - 1,
+	})
 	if err != nil {
-		return service.RepoImpl("error committing the update password hash transaction", err)
-	} 
+		return service.RepoImpl("error pruning the password history of the user", err)
+	}
 	return nil
 }
 
@@ -198,19 +426,25 @@ func (r *UserRepository) ValidatePassword(
 	ctx context.Context,
 	userName string,
 	password string,
-) (uuid.UUID, bool, service.DomainError) {
+) (uuid.UUID, int32, bool, service.DomainError) {
+	ctx, conn, release, acquireErr := r.acquire(ctx)
+	if acquireErr != nil {
+		return uuid.Nil, 0, false, acquireErr
+	}
+	defer release()
+	queries := sqlc.New(conn)
 	// read the password associated with the user
-	row, err := r.queries.GetHashedPassword(
+	row, err := queries.GetHashedPassword(
 		ctx, userName,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return uuid.Nil, false, service.NotFound(fmt.Sprintf(
+			return uuid.Nil, 0, false, service.NotFound(fmt.Sprintf(
 				"no user found with user name: %s for checking password", 
 				userName,
 			))
 		} else {
-			return uuid.Nil, false, service.RepoImpl(
+			return uuid.Nil, 0, false, service.RepoImpl(
 				fmt.Sprintf("unexpected error found when reading user with username: %s", userName),
 				err,
 			)
@@ -218,9 +452,16 @@ func (r *UserRepository) ValidatePassword(
 	}
 	// hash the given users password and compare the hashed password to the stored hashed password
 	if err := bcrypt.CompareHashAndPassword([]byte(row.HashedPassword), []byte(password)); err != nil {
-		return uuid.Nil, false, nil
+		return uuid.Nil, 0, false, nil
+	}
+	// only reveal that the account is deactivated to a caller that knows the password, a
+	// deactivated user must not be able to obtain new tokens
+	if row.IsActive.Valid && !row.IsActive.Bool {
+		return uuid.Nil, 0, false, service.Deactivated(
+			fmt.Sprintf("the user with user name: %s has been deactivated", userName),
+		)
 	}
-	return uuid.UUID(row.ID.Bytes), true, nil
+	return uuid.UUID(row.ID.Bytes), row.TokenVersion, true, nil
 }
 
 // consider adding something like this
@@ -27,10 +27,11 @@ func TestCreateUserIntegration(t *testing.T) {
 	}
 	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
 	// pass some dummy data to the user repository create user function
-	userId, err := userRepo.CreateUser(t.Context(), "testUser", "test@example.com", 100, "asdfasdf")
+	createdUser, err := userRepo.CreateUser(t.Context(), "testUser", "test@example.com", 100, "asdfasdf")
 	if err != nil {
 		t.Fatalf("failed to create user with error: %v", err)
 	}
+	userId := createdUser.UserId
 	// call the get user by id function to validate that the create user function worked 
 	user, err := userRepo.GetUserById(t.Context(), userId)
 	if err != nil {
@@ -61,10 +62,11 @@ func TestGetUserEmailIntegration(t *testing.T) {
 		t.Fatalf("unable to connect to postgres test container: %v", err)
 	}
 	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
-	userId, err := userRepo.CreateUser(t.Context(), "testUser2", "test2@example.com", 100, "asdfasdf")
+	createdUser, err := userRepo.CreateUser(t.Context(), "testUser2", "test2@example.com", 100, "asdfasdf")
 	if err != nil {
 		t.Fatalf("failed to create test user: %v", err)
 	}
+	userId := createdUser.UserId
 	user, err := userRepo.GetUserByEmail(t.Context(), "test2@example.com")
 	if err != nil {
 		t.Fatalf("failed to retreive user by email: %v", err)
@@ -93,7 +95,10 @@ func TestCreateDuplicateUserEmailIntegration(t *testing.T) {
 	// to the type pointed at by target. Target had to be a pointer to a pointer because we are going to
 	// modify the pointer target itself instead of modifying the value pointed to by target
 	if !errors.As(err, &uniqueError) {
-		t.Errorf("when creating a duplicate user, want unique error, got: %v", err)
+		t.Fatalf("when creating a duplicate user, want unique error, got: %v", err)
+	}
+	if uniqueError.Field != "email" {
+		t.Errorf("when creating a user with a duplicate email, want conflicting field: email, got: %q", uniqueError.Field)
 	}
 }
 
@@ -116,7 +121,10 @@ func TestCreateDuplicateUserNameIntegration(t *testing.T) {
 	// to the type pointed at by target. Target had to be a pointer to a pointer because we are going to
 	// modify the pointer target itself instead of modifying the value pointed to by target
 	if !errors.As(err, &uniqueError) {
-		t.Errorf("when creating a duplicate user, want unique constraint error, got: %v", err)
+		t.Fatalf("when creating a duplicate user, want unique constraint error, got: %v", err)
+	}
+	if uniqueError.Field != "user_name" {
+		t.Errorf("when creating a user with a duplicate user name, want conflicting field: user_name, got: %q", uniqueError.Field)
 	}
 }
 
@@ -167,10 +175,11 @@ func TestDeactivateUserIntegration(t *testing.T) {
 	}
 	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
 	// create a user
-	userId, err := userRepo.CreateUser(t.Context(), "testUser5", "test5@example.com", 10, "asdf")
+	createdUser, err := userRepo.CreateUser(t.Context(), "testUser5", "test5@example.com", 10, "asdf")
 	if err != nil {
 		t.Fatalf("unable to create a new user: %v", err)
 	}
+	userId := createdUser.UserId
 	// deactivate that user
 	err = userRepo.DeactivateUser(t.Context(), userId)
 	if err != nil {
@@ -213,10 +222,11 @@ func TestModifyPasswordIntegration(t *testing.T) {
 		t.Fatalf("unable to connect to postgres container: %v", err)
 	}
 	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
-	userId, err := userRepo.CreateUser(t.Context(), "testUser6", "test6@example.com", 12, "asdf")
+	createdUser, err := userRepo.CreateUser(t.Context(), "testUser6", "test6@example.com", 12, "asdf")
 	if err != nil {
 		t.Fatalf("failed to create a user: %v", err)
 	}
+	userId := createdUser.UserId
 	// update the hashed password of the user
 	err = userRepo.ModifyPassword(t.Context(), userId, "asdf", "qwer")
 	if err != nil {
@@ -259,10 +269,11 @@ func TestModifyPasswordInvalidIntegration(t *testing.T) {
 		t.Fatalf("unable to connect to postgres container: %v", err)
 	}
 	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
-	userId, err := userRepo.CreateUser(t.Context(), "testUser7", "test7@example.com", 12, "asdf")
+	createdUser, err := userRepo.CreateUser(t.Context(), "testUser7", "test7@example.com", 12, "asdf")
 	if err != nil {
 		t.Fatalf("failed to create a user: %v", err)
 	}
+	userId := createdUser.UserId
 	// update the hashed password of the user
 	err = userRepo.ModifyPassword(t.Context(), userId, "qwer", "qwer")
 	var passwordError *service.PasswordMismatchError
@@ -280,15 +291,16 @@ func TestValidatePassword_Valid_Integration(t *testing.T) {
 	}
 	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
 	// create a dummy user
-	userId, err := userRepo.CreateUser(
+	createdUser, err := userRepo.CreateUser(
 		t.Context(), "testUser8", "test8@example.com", 12, "asdf",
 	)
 	if err != nil {
 		t.Fatalf("failed to create dummy user with error: %v", err)
 	}
+	userId := createdUser.UserId
 	// validate the users password against the password stored in the database for the dummy user
 	// it should be correct 
-	resultId, isValid, err := userRepo.ValidatePassword(t.Context(), "testUser8", "asdf")
+	resultId, _, isValid, err := userRepo.ValidatePassword(t.Context(), "testUser8", "asdf")
 	if err != nil {
 		t.Fatalf("failed to validate password with error: %v", err)
 	}
@@ -321,7 +333,7 @@ func TestValidatePassword_Invalid_Integration(t *testing.T) {
 		t.Fatalf("failed to create dummy user with error: %v", err)
 	}
 	// validate that a password other than the dummy users password is deemed as invalid
-	resultId, isValid, err := userRepo.ValidatePassword(t.Context(), "testUser9", "qwer")
+	resultId, _, isValid, err := userRepo.ValidatePassword(t.Context(), "testUser9", "qwer")
 	if err != nil {
 		t.Fatalf("failed to validate password with error: %v", err)
 	}
@@ -336,4 +348,386 @@ func TestValidatePassword_Invalid_Integration(t *testing.T) {
 			resultId,
 		)
 	}
-}
\ No newline at end of file
+}
+
+func TestValidatePassword_UnknownUserName_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
+	resultId, _, isValid, err := userRepo.ValidatePassword(t.Context(), "noSuchUser", "asdf")
+	var notFoundErr *service.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("want: NotFoundError for an unknown user name, got: %v", err)
+	}
+	if isValid || resultId != uuid.Nil {
+		t.Errorf("want: an invalid result with the nil uuid, got: %v and %v", isValid, resultId)
+	}
+}
+
+// a deactivated user must not be able to exchange their credentials for new tokens
+func TestValidatePassword_Deactivated_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
+	// create a dummy user and deactivate it
+	createdUser, err := userRepo.CreateUser(
+		t.Context(), "testUser10", "test10@example.com", 12, "asdf",
+	)
+	if err != nil {
+		t.Fatalf("failed to create dummy user with error: %v", err)
+	}
+	userId := createdUser.UserId
+	err = userRepo.DeactivateUser(t.Context(), userId)
+	if err != nil {
+		t.Fatalf("unable to deactivate user: %v", err)
+	}
+	// validate that the correct password is rejected for the deactivated user
+	resultId, _, isValid, err := userRepo.ValidatePassword(t.Context(), "testUser10", "asdf")
+	var deactivatedError *service.DeactivatedError
+	if !errors.As(err, &deactivatedError) {
+		t.Errorf("want: DeactivatedError for a deactivated user, got: %v", err)
+	}
+	if isValid {
+		t.Errorf(
+			"want: isValid to be false for a deactivated user, got: %v", isValid,
+		)
+	}
+	if uuid.Nil != resultId {
+		t.Errorf(
+			"want: validated users id to be nil uuid, got: %v",
+			resultId,
+		)
+	}
+}
+
+// verify that the user returned from create user matches the user that is returned by a
+// subsequent call to get user
+func TestCreateUser_ReturnsCreatedUser_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
+	createdUser, err := userRepo.CreateUser(
+		t.Context(), "testUser11", "test11@example.com", 12, "asdf",
+	)
+	if err != nil {
+		t.Fatalf("failed to create dummy user with error: %v", err)
+	}
+	user, err := userRepo.GetUserById(t.Context(), createdUser.UserId)
+	if err != nil {
+		t.Fatalf("failed to retrieve user from the database by id: %v", err)
+	}
+	if createdUser.UserName != user.UserName {
+		t.Errorf("want userName: %s, got userName: %s", user.UserName, createdUser.UserName)
+	}
+	if createdUser.Email != user.Email {
+		t.Errorf("want email: %s, got email: %s", user.Email, createdUser.Email)
+	}
+	if createdUser.MaxDocuments != user.MaxDocuments {
+		t.Errorf("want maxDocuments: %d, got maxDocuments: %d", user.MaxDocuments, createdUser.MaxDocuments)
+	}
+	if createdUser.IsActive != user.IsActive || !createdUser.IsActive {
+		t.Errorf("want a newly created user to be active, got isActive: %t", createdUser.IsActive)
+	}
+	if !createdUser.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("want createdAt: %v, got createdAt: %v", user.CreatedAt, createdUser.CreatedAt)
+	}
+	if !createdUser.LastModified.Equal(user.LastModified) {
+		t.Errorf("want lastModified: %v, got lastModified: %v", user.LastModified, createdUser.LastModified)
+	}
+}
+
+// verify that the user service resolves a user by email through the repository
+func TestGetUserByEmail_Service_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	userService := service.NewUserService(repository.NewUserRepository(conn))
+	createdUser, err := userService.CreateUser(
+		t.Context(), "testUser12", "test12@example.com", nil, nil, "asdfasdf",
+	)
+	if err != nil {
+		t.Fatalf("failed to create dummy user with error: %v", err)
+	}
+	user, err := userService.GetUserByEmail(t.Context(), "test12@example.com")
+	if err != nil {
+		t.Fatalf("failed to retrieve user by email: %v", err)
+	}
+	if user.UserId != createdUser.UserId {
+		t.Errorf("want userId: %v, got userId: %v", createdUser.UserId, user.UserId)
+	}
+	if user.UserName != "testUser12" {
+		t.Errorf("want userName: %s, got userName: %s", "testUser12", user.UserName)
+	}
+}
+
+// verify that the user service returns a not found error for an email with no user
+func TestGetUserByEmail_Service_NotFound_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	userService := service.NewUserService(repository.NewUserRepository(conn))
+	_, err = userService.GetUserByEmail(t.Context(), "missing12@example.com")
+	var notFoundError *service.NotFoundError
+	if !errors.As(err, &notFoundError) {
+		t.Errorf("when getting a user by an email that does not exist, expected not found error, got: %v", err)
+	}
+}
+
+// verify that the user service resolves a user by each kind of identifier
+func TestResolveUser_Service_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	userService := service.NewUserService(repository.NewUserRepository(conn))
+	createdUser, err := userService.CreateUser(
+		t.Context(), "testUser16", "test16@example.com", nil, nil, "asdfasdf",
+	)
+	if err != nil {
+		t.Fatalf("failed to create dummy user with error: %v", err)
+	}
+	email, userName := "test16@example.com", "testUser16"
+	testCases := []struct{
+		name string
+		identifier service.UserIdentifier
+	}{
+		{ name: "id", identifier: service.UserIdentifier{ UserId: &createdUser.UserId } },
+		{ name: "email", identifier: service.UserIdentifier{ Email: &email } },
+		{ name: "user name", identifier: service.UserIdentifier{ UserName: &userName } },
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			user, err := userService.ResolveUser(t.Context(), testCase.identifier)
+			if err != nil {
+				t.Fatalf("failed to resolve user with error: %v", err)
+			}
+			if user.UserId != createdUser.UserId {
+				t.Errorf("want userId: %v, got userId: %v", createdUser.UserId, user.UserId)
+			}
+		})
+	}
+}
+
+// verify that the user service returns a not found error for each kind of identifier with no user
+func TestResolveUser_Service_NotFound_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	userService := service.NewUserService(repository.NewUserRepository(conn))
+	userId := uuid.New()
+	email, userName := "missing16@example.com", "missingUser16"
+	for _, identifier := range []service.UserIdentifier{
+		{ UserId: &userId }, { Email: &email }, { UserName: &userName },
+	} {
+		_, err = userService.ResolveUser(t.Context(), identifier)
+		var notFoundError *service.NotFoundError
+		if !errors.As(err, &notFoundError) {
+			t.Errorf("when resolving a user that does not exist, expected not found error, got: %v", err)
+		}
+	}
+}
+
+// changing the password or deactivating the user bumps the token version so that the tokens
+// issued before then are rejected by the gateway
+func TestTokenVersion_BumpedOnPasswordChangeAndDeactivation_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to postgres container: %v", err)
+	}
+	var userRepo *repository.UserRepository = repository.NewUserRepository(conn)
+	createdUser, err := userRepo.CreateUser(t.Context(), "testUser13", "test13@example.com", 12, "asdf")
+	if err != nil {
+		t.Fatalf("failed to create a user: %v", err)
+	}
+	userId := createdUser.UserId
+	_, issuedVersion, _, err := userRepo.ValidatePassword(t.Context(), "testUser13", "asdf")
+	if err != nil {
+		t.Fatalf("failed to validate password with error: %v", err)
+	}
+	if issuedVersion != createdUser.TokenVersion {
+		t.Errorf("want token version: %d at login, got: %d", createdUser.TokenVersion, issuedVersion)
+	}
+	err = userRepo.ModifyPassword(t.Context(), userId, "asdf", "qwer")
+	if err != nil {
+		t.Fatalf("failed to modify the password: %v", err)
+	}
+	user, err := userRepo.GetUserById(t.Context(), userId)
+	if err != nil {
+		t.Fatalf("failed to get the modified user: %v", err)
+	}
+	if user.TokenVersion != issuedVersion + 1 {
+		t.Errorf("want token version: %d after changing the password, got: %d", issuedVersion + 1, user.TokenVersion)
+	}
+	// a token issued after the password change carries the new version
+	_, reissuedVersion, _, err := userRepo.ValidatePassword(t.Context(), "testUser13", "qwer")
+	if err != nil {
+		t.Fatalf("failed to validate password with error: %v", err)
+	}
+	if reissuedVersion != user.TokenVersion {
+		t.Errorf("want token version: %d at login, got: %d", user.TokenVersion, reissuedVersion)
+	}
+	err = userRepo.DeactivateUser(t.Context(), userId)
+	if err != nil {
+		t.Fatalf("unable to deactivate user: %v", err)
+	}
+	user, err = userRepo.GetUserById(t.Context(), userId)
+	if err != nil {
+		t.Fatalf("failed to get the deactivated user: %v", err)
+	}
+	if user.TokenVersion != reissuedVersion + 1 {
+		t.Errorf("want token version: %d after deactivating the user, got: %d", reissuedVersion + 1, user.TokenVersion)
+	}
+}
+
+func TestModifyPassword_SamePasswordRejected_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to postgres container: %v", err)
+	}
+	userRepo := repository.NewUserRepositoryWithPasswordHistory(
+		conn, repository.DefaultAcquireTimeout, repository.DefaultQueryTimeout, 3,
+	)
+	createdUser, err := userRepo.CreateUser(t.Context(), "testUser14", "test14@example.com", 12, "asdf")
+	if err != nil {
+		t.Fatalf("failed to create a user: %v", err)
+	}
+	err = userRepo.ModifyPassword(t.Context(), createdUser.UserId, "asdf", "asdf")
+	var invalidErr *service.InvalidError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("when reusing the current password, want InvalidError, got: %v", err)
+	}
+}
+
+func TestModifyPassword_HistoryRotates_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to postgres container: %v", err)
+	}
+	// the current password and the two before it are checked
+	userRepo := repository.NewUserRepositoryWithPasswordHistory(
+		conn, repository.DefaultAcquireTimeout, repository.DefaultQueryTimeout, 3,
+	)
+	createdUser, err := userRepo.CreateUser(t.Context(), "testUser15", "test15@example.com", 12, "first")
+	if err != nil {
+		t.Fatalf("failed to create a user: %v", err)
+	}
+	userId := createdUser.UserId
+	// genuinely new passwords succeed
+	for _, change := range [][2]string{ { "first", "second" }, { "second", "third" } } {
+		if err = userRepo.ModifyPassword(t.Context(), userId, change[0], change[1]); err != nil {
+			t.Fatalf("failed to change the password from: %s to: %s with error: %v", change[0], change[1], err)
+		}
+	}
+	// the first password is still one of the last three
+	err = userRepo.ModifyPassword(t.Context(), userId, "third", "first")
+	var invalidErr *service.InvalidError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("when reusing a recent password, want InvalidError, got: %v", err)
+	}
+	// after one more change the first password has rotated out of the history
+	if err = userRepo.ModifyPassword(t.Context(), userId, "third", "fourth"); err != nil {
+		t.Fatalf("failed to change the password with error: %v", err)
+	}
+	if err = userRepo.ModifyPassword(t.Context(), userId, "fourth", "first"); err != nil {
+		t.Errorf("want the oldest password to be usable once it leaves the history, got: %v", err)
+	}
+}
+
+// the availability of each field reflects whether a user already holds it, the fields that were
+// not checked are left nil
+func TestCheckAvailability_Service_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	userService := service.NewUserService(repository.NewUserRepository(conn))
+	_, err = userService.CreateUser(
+		t.Context(), "testUser17", "test17@example.com", nil, nil, "asdfasdf",
+	)
+	if err != nil {
+		t.Fatalf("failed to create dummy user with error: %v", err)
+	}
+	takenUserName, takenEmail := "testUser17", "test17@example.com"
+	freeUserName, freeEmail := "freeUser17", "free17@example.com"
+	testCases := []struct{
+		name string
+		userName *string
+		email *string
+		wantUserName *bool
+		wantEmail *bool
+	}{
+		{ name: "both taken", userName: &takenUserName, email: &takenEmail, wantUserName: ptr(false), wantEmail: ptr(false) },
+		{ name: "both available", userName: &freeUserName, email: &freeEmail, wantUserName: ptr(true), wantEmail: ptr(true) },
+		{ name: "user name taken", userName: &takenUserName, email: &freeEmail, wantUserName: ptr(false), wantEmail: ptr(true) },
+		{ name: "email taken", userName: &freeUserName, email: &takenEmail, wantUserName: ptr(true), wantEmail: ptr(false) },
+		{ name: "only user name", userName: &takenUserName, wantUserName: ptr(false) },
+		{ name: "only email", email: &freeEmail, wantEmail: ptr(true) },
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			availability, err := userService.CheckAvailability(t.Context(), testCase.userName, testCase.email)
+			if err != nil {
+				t.Fatalf("failed to check availability with error: %v", err)
+			}
+			if !equalOptionalBools(testCase.wantUserName, availability.UserNameAvailable) {
+				t.Errorf("want user name available: %v, got: %v", derefBool(testCase.wantUserName), derefBool(availability.UserNameAvailable))
+			}
+			if !equalOptionalBools(testCase.wantEmail, availability.EmailAvailable) {
+				t.Errorf("want email available: %v, got: %v", derefBool(testCase.wantEmail), derefBool(availability.EmailAvailable))
+			}
+		})
+	}
+}
+
+// a deactivated user keeps their user name and email, so they are not available
+func TestCheckAvailability_DeactivatedUser_Integration(t *testing.T) {
+	conn, err := setupPostgresContainer()
+	if err != nil {
+		t.Fatalf("unable to connect to the postgres container: %v", err)
+	}
+	userService := service.NewUserService(repository.NewUserRepository(conn))
+	createdUser, err := userService.CreateUser(
+		t.Context(), "testUser18", "test18@example.com", nil, nil, "asdfasdf",
+	)
+	if err != nil {
+		t.Fatalf("failed to create dummy user with error: %v", err)
+	}
+	if err = userService.DeactivateUser(t.Context(), createdUser.UserId); err != nil {
+		t.Fatalf("failed to deactivate user with error: %v", err)
+	}
+	userName, email := "testUser18", "test18@example.com"
+	availability, err := userService.CheckAvailability(t.Context(), &userName, &email)
+	if err != nil {
+		t.Fatalf("failed to check availability with error: %v", err)
+	}
+	if *availability.UserNameAvailable || *availability.EmailAvailable {
+		t.Errorf("want the user name and email of a deactivated user to be taken, got: %+v", availability)
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func equalOptionalBools(a *bool, b *bool) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func derefBool(b *bool) any {
+	if b == nil {
+		return nil
+	}
+	return *b
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sort"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/google/uuid"
 	pb "github.com/townsag/reed/user_service/api"
@@ -42,6 +45,8 @@ func serviceToGRPCError(err error) error {
 	var uniqueError *service.UniqueConflictError
 	var invalidError *service.InvalidError
 	var passwordError *service.PasswordMismatchError
+	var deactivatedError *service.DeactivatedError
+	var transientError *service.TransientError
 
 	switch {
 	case err == nil:
@@ -49,16 +54,61 @@ func serviceToGRPCError(err error) error {
 	case errors.As(err, &notFound):
 		return status.Error(codes.NotFound, err.Error())
 	case errors.As(err, &uniqueError):
-		return status.Error(codes.AlreadyExists, err.Error())
+		return uniqueToGRPCError(uniqueError)
 	case errors.As(err, &invalidError):
-		return status.Error(codes.InvalidArgument, err.Error())
+		return invalidToGRPCError(invalidError)
 	case errors.As(err, &passwordError):
 		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &deactivatedError):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &transientError):
+		// aborted tells the client that the request can be retried
+		return status.Error(codes.Aborted, "the request conflicted with a concurrent request, try again")
 	default:
 		return status.Error(codes.Internal, "internal server error encountered")
 	}
 }
 
+// attach the individual field violations of an invalid error to the status as a BadRequest
+// detail so that clients can report every invalid field at once
+func invalidToGRPCError(invalidError *service.InvalidError) error {
+	return withFieldViolations(status.New(codes.InvalidArgument, invalidError.Error()), invalidError.Fields)
+}
+
+// attach the field whose value is already taken to the status in the same way as the fields of
+// an invalid error, this lets clients tell a duplicate user name apart from a duplicate email
+func uniqueToGRPCError(uniqueError *service.UniqueConflictError) error {
+	st := status.New(codes.AlreadyExists, uniqueError.Error())
+	if uniqueError.Field == "" {
+		return st.Err()
+	}
+	return withFieldViolations(st, map[string]string{ uniqueError.Field: "is already in use" })
+}
+
+func withFieldViolations(st *status.Status, violations map[string]string) error {
+	if len(violations) == 0 {
+		return st.Err()
+	}
+	// sort the fields so that the order of the violations is deterministic
+	fields := make([]string, 0, len(violations))
+	for field := range violations {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	badRequest := &errdetails.BadRequest{}
+	for _, field := range fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field: field,
+			Description: violations[field],
+		})
+	}
+	detailed, err := st.WithDetails(badRequest)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
 func (s *UserServiceServerImpl) GetUser(
 	ctx context.Context,
 	getUserReq *pb.GetUserRequest,
@@ -76,15 +126,69 @@ func (s *UserServiceServerImpl) GetUser(
 		return nil, serviceToGRPCError(err)
 	}
 	return &pb.UserReply{
-		User: &pb.User{
-			UserId: user.UserId.String(),
-			UserName: user.UserName,
-			Email: user.Email,
-			MaxDocuments: user.MaxDocuments, 
-		},
+		User: serviceToPbUser(user),
+	}, nil
+}
+
+func (s *UserServiceServerImpl) GetUserByEmail(
+	ctx context.Context,
+	getUserByEmailReq *pb.GetUserByEmailRequest,
+) (*pb.UserReply, error) {
+	if getUserByEmailReq.UserEmail == "" {
+		slog.WarnContext(ctx, "the received user email is empty string")
+		return nil, status.Error(codes.InvalidArgument, "user_email is a required argument")
+	}
+	user, err := s.userService.GetUserByEmail(ctx, getUserByEmailReq.UserEmail)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.UserReply{
+		User: serviceToPbUser(user),
+	}, nil
+}
+
+func (s *UserServiceServerImpl) ResolveUser(
+	ctx context.Context,
+	resolveUserReq *pb.ResolveUserRequest,
+) (*pb.UserReply, error) {
+	// the oneof can only carry a single identifier on the wire, the service still checks that
+	// exactly one was set
+	var identifier service.UserIdentifier
+	switch id := resolveUserReq.Identifier.(type) {
+	case *pb.ResolveUserRequest_UserId:
+		userId, err := uuid.Parse(id.UserId)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to parse the uuid provided by the client", "error", err.Error())
+			return nil, status.Errorf(codes.InvalidArgument, "failed to parse user id as uuid: %v", id.UserId)
+		}
+		identifier.UserId = &userId
+	case *pb.ResolveUserRequest_Email:
+		identifier.Email = &id.Email
+	case *pb.ResolveUserRequest_UserName:
+		identifier.UserName = &id.UserName
+	}
+	user, err := s.userService.ResolveUser(ctx, identifier)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.UserReply{
+		User: serviceToPbUser(user),
 	}, nil
 }
 
+func serviceToPbUser(user *service.User) *pb.User {
+	return &pb.User{
+		UserId: user.UserId.String(),
+		UserName: user.UserName,
+		Email: user.Email,
+		MaxDocuments: user.MaxDocuments, 
+		IsActive: user.IsActive,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		LastModifiedAt: timestamppb.New(user.LastModified),
+		TokenVersion: user.TokenVersion,
+	}
+}
+
 func (s *UserServiceServerImpl) CreateUser(
 	ctx context.Context, 
 	createUserReq *pb.CreateUserRequest,
@@ -100,14 +204,31 @@ func (s *UserServiceServerImpl) CreateUser(
 		return nil, status.Errorf(codes.InvalidArgument, "password is required")
 	}
 	// create the user using the user service layer
-	userId, err := s.userService.CreateUser(ctx, createUserReq.UserName, createUserReq.UserEmail, createUserReq.MaxDocuments, createUserReq.Password)
+	user, err := s.userService.CreateUser(ctx, createUserReq.UserName, createUserReq.UserEmail, createUserReq.MaxDocuments, createUserReq.Plan, createUserReq.Password)
 	// try the different types of service errors that can be created, return the appropriate code
 	// conflict, internal service error, etc.
 	if err != nil {
 		return nil, serviceToGRPCError(err)
 	}
 	return &pb.CreateUserReply{
-		UserId: userId.String(),
+		UserId: user.UserId.String(),
+		User: serviceToPbUser(user),
+	}, nil
+}
+
+func (s *UserServiceServerImpl) CheckAvailability(
+	ctx context.Context,
+	checkAvailabilityReq *pb.CheckAvailabilityRequest,
+) (*pb.CheckAvailabilityReply, error) {
+	availability, err := s.userService.CheckAvailability(
+		ctx, checkAvailabilityReq.UserName, checkAvailabilityReq.Email,
+	)
+	if err != nil {
+		return nil, serviceToGRPCError(err)
+	}
+	return &pb.CheckAvailabilityReply{
+		UserNameAvailable: availability.UserNameAvailable,
+		EmailAvailable: availability.EmailAvailable,
 	}, nil
 }
 
@@ -129,9 +250,18 @@ func (s *UserServiceServerImpl) DeactivateUser(
 	return &emptypb.Empty{}, nil
 }
 
+// ChangePassword is the name this handler had before it was renamed to match the
+// ChangeUserPassword rpc, it is kept so that existing callers of the server keep compiling
 func (s *UserServiceServerImpl) ChangePassword(
 	ctx context.Context,
 	changePasswordRequest *pb.ChangeUserPasswordRequest,
+) (*emptypb.Empty, error) {
+	return s.ChangeUserPassword(ctx, changePasswordRequest)
+}
+
+func (s *UserServiceServerImpl) ChangeUserPassword(
+	ctx context.Context,
+	changePasswordRequest *pb.ChangeUserPasswordRequest,
 ) (*emptypb.Empty, error) {
 	if changePasswordRequest.OldPassword == "" {
 		return nil, status.Error(codes.InvalidArgument, "user old_password is a required argument")
@@ -169,7 +299,7 @@ func (s *UserServiceServerImpl) ValidatePassword(
 		return nil, status.Error(codes.InvalidArgument, "user_name cannot be empty string")
 	}
 	// call the validate password method on the user service object
-	userId, isValid, err := s.userService.ValidatePassword(ctx, req.UserName, req.UserPassword)
+	userId, tokenVersion, isValid, err := s.userService.ValidatePassword(ctx, req.UserName, req.UserPassword)
 	// return either an error indicating a failure to read information
 	if err != nil {
 		return nil, serviceToGRPCError(err)
@@ -179,5 +309,6 @@ func (s *UserServiceServerImpl) ValidatePassword(
 	return &pb.ValidatePasswordReply{
 		UserId: &userIdStr,
 		IsValid: isValid,
+		TokenVersion: tokenVersion,
 	}, nil
 }
\ No newline at end of file
@@ -37,9 +37,15 @@ func (e *RepoImplError) isDomainError() {}
 type UniqueConflictError struct {
 	Msg string
 	Err error
+	// the name of the field whose value is already taken, this is empty when the conflict
+	// could not be attributed to a field
+	Field string
 }
 
 func (e *UniqueConflictError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("unique conflict, msg: %s, field: %s, err: %v", e.Msg, e.Field, e.Err)
+	}
 	return fmt.Sprintf("unique conflict, msg: %s, err: %v", e.Msg, e.Err)
 }
 
@@ -52,9 +58,15 @@ func (e *UniqueConflictError) isDomainError() {}
 type InvalidError struct {
 	Msg string
 	Err error
+	// maps the name of each invalid field to the reason it failed validation, this is
+	// nil when the error does not describe specific fields
+	Fields map[string]string
 }
 
 func (e *InvalidError) Error() string {
+	if len(e.Fields) > 0 {
+		return fmt.Sprintf("invalid input, msg: %s, fields: %v, err: %v", e.Msg, e.Fields, e.Err)
+	}
 	return fmt.Sprintf("invalid input, msg: %s, err: %v", e.Msg, e.Err)
 }
 
@@ -74,6 +86,33 @@ func (e *PasswordMismatchError) Error() string {
 
 func (e *PasswordMismatchError) isDomainError() {}
 
+type DeactivatedError struct {
+	Msg string
+}
+
+func (e *DeactivatedError) Error() string {
+	return e.Msg
+}
+
+func (e *DeactivatedError) isDomainError() {}
+
+// a failure caused by concurrent transactions, like a serialization failure or a deadlock. The
+// same request can succeed when it is made again
+type TransientError struct {
+	Msg string
+	Err error
+}
+
+func (e *TransientError) Error() string {
+	return fmt.Sprintf("transient failure, msg: %s, err: %v", e.Msg, e.Err)
+}
+
+func (e *TransientError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TransientError) isDomainError() {}
+
 func NotFound(msg string) *NotFoundError {
 	return &NotFoundError{
 		Msg: msg,
@@ -94,6 +133,14 @@ func UniqueConflict(msg string, err error) *UniqueConflictError {
 	}
 }
 
+func UniqueConflictField(msg string, field string, err error) *UniqueConflictError {
+	return &UniqueConflictError{
+		Msg: msg,
+		Err: err,
+		Field: field,
+	}
+}
+
 func Invalid(msg string, err error) *InvalidError {
 	return &InvalidError{
 		Msg: msg,
@@ -101,8 +148,28 @@ func Invalid(msg string, err error) *InvalidError {
 	}
 }
 
+func InvalidFields(msg string, fields map[string]string) *InvalidError {
+	return &InvalidError{
+		Msg: msg,
+		Fields: fields,
+	}
+}
+
 func PasswordMismatch(err error) *PasswordMismatchError {
 	return &PasswordMismatchError{
 		Err: err,
 	}
-}
\ No newline at end of file
+}
+
+func Deactivated(msg string) *DeactivatedError {
+	return &DeactivatedError{
+		Msg: msg,
+	}
+}
+
+func Transient(msg string, err error) *TransientError {
+	return &TransientError{
+		Msg: msg,
+		Err: err,
+	}
+}
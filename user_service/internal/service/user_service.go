@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 	"log/slog"
 
@@ -19,22 +20,31 @@ type User struct {
 	IsActive bool
 	CreatedAt time.Time
 	LastModified time.Time
+	// bumped when the user is deactivated or changes their password so that tokens issued
+	// before then stop working
+	TokenVersion int32
 }
 
 // the consumer of the repository package defines the interface that
 // the repository object has to conform to. This allows multiple repos
 // to implement the UserRepository interface
 type UserRepository interface {
-	CreateUser(ctx context.Context, userName string, email string, maxDocuments int32, password string) (userId uuid.UUID, err DomainError)
+	CreateUser(ctx context.Context, userName string, email string, maxDocuments int32, password string) (*User, DomainError)
 	GetUserById(ctx context.Context, userId uuid.UUID) (*User, DomainError)
 	GetUserByEmail(ctx context.Context, userEmail string) (*User, DomainError)
+	GetUserByUserName(ctx context.Context, userName string) (*User, DomainError)
+	// whether a user already holds the user name or the email, deactivated users still hold theirs
+	UserNameExists(ctx context.Context, userName string) (bool, DomainError)
+	EmailExists(ctx context.Context, email string) (bool, DomainError)
 	DeactivateUser(ctx context.Context, userId uuid.UUID) (DomainError)
 	// push the responsibility for hashing passwords down to the repository layer, the user service
 	// just deals in plaintext passwords. This makes the interactions between the service and the 
 	// repository cleaner because the service does not have to hold an interactive transaction in 
 	// case another process changes the users password while the service is validating it
 	ModifyPassword(ctx context.Context, userId uuid.UUID, oldPassword string, newPassword string) (DomainError)
-	ValidatePassword(ctx context.Context, userName string, password string) (uuid.UUID, bool, DomainError)
+	// the token version is returned with the result so that a token can be issued with the
+	// version that was current when the password was checked
+	ValidatePassword(ctx context.Context, userName string, password string) (uuid.UUID, int32, bool, DomainError)
 }
 
 // in the case of repositories, we wanted to be able to swap out multiple different repository
@@ -63,37 +73,58 @@ func NewUserService(repo UserRepository) *UserService {
 // interfaces to pass between the server and the service layer and prevents the service layer from being
 // aware of gRPC specific structs
 
-func (us *UserService) CreateUser(ctx context.Context, userName string, email string, maxDocuments *int32, password string) (uuid.UUID, error) {
-	if len(userName) < config.MinUsernameLength {
-		slog.WarnContext(ctx, "failed to create user, username is too small", "userName", userName)
-		return uuid.Nil, Invalid(
-			fmt.Sprintf("username: <%s> did not match the min username length constraint: %d", userName, config.MinUsernameLength),
-			nil,
-		)
-	}
+// the document quota of the user is maxDocuments when it is set, otherwise it is the default
+// quota of the plan. A nil plan is the free plan
+func (us *UserService) CreateUser(ctx context.Context, userName string, email string, maxDocuments *int32, plan *string, password string) (*User, error) {
+	validator := newFieldValidator()
+	validator.check(
+		len(userName) >= config.MinUsernameLength,
+		"user_name",
+		fmt.Sprintf("must be at least %d characters long", config.MinUsernameLength),
+	)
 	// TODO: validate the email using regex, etc.
 	// TODO: create a sign-up flow that requires clicking a link in their inbox
-	if len(password) < config.MinPasswordLength {
-		slog.WarnContext(ctx, "failed to create user, password is too small", "password", password)
-		return uuid.Nil, Invalid(
-			fmt.Sprintf("password: <%s> did not match the min password length constraint: %d", password, config.MinPasswordLength),
-			nil,
+	validator.check(
+		len(password) >= config.MinPasswordLength,
+		"password",
+		fmt.Sprintf("must be at least %d characters long", config.MinPasswordLength),
+	)
+	if maxDocuments != nil {
+		validator.check(
+			*maxDocuments >= 0 && *maxDocuments <= config.MaxMaxDocuments,
+			"max_documents",
+			fmt.Sprintf("must be between 0 and %d", config.MaxMaxDocuments),
 		)
 	}
-	resolvedMaxDocuments := config.DefaultMaxDocuments
+	// the plan is validated even when the quota is set so that a typo in the plan is not missed
+	planMaxDocuments := config.DefaultMaxDocuments
+	if plan != nil {
+		resolved, err := config.ResolvePlanMaxDocuments(*plan)
+		validator.check(
+			err == nil,
+			"plan",
+			fmt.Sprintf("must be one of: %s", strings.Join(config.PlanNames(), ", ")),
+		)
+		planMaxDocuments = resolved
+	}
+	if err := validator.err("failed to validate create user request"); err != nil {
+		slog.WarnContext(ctx, "failed to create user, request is invalid", "userName", userName, "error", err.Error())
+		return nil, err
+	}
+	resolvedMaxDocuments := planMaxDocuments
 	if maxDocuments != nil {
 		resolvedMaxDocuments = *maxDocuments
 	}
-	userId, err := us.repo.CreateUser(ctx, userName, email, resolvedMaxDocuments, password)
+	user, err := us.repo.CreateUser(ctx, userName, email, resolvedMaxDocuments, password)
 	if err != nil {
 		slog.ErrorContext(
 			ctx,
 			"failed to create user because of repository error",
 			"error", err.Error(),
 		)
-		return uuid.Nil, err
+		return nil, err
 	} else {
-		return userId, nil
+		return user, nil
 	}
 }
 
@@ -111,6 +142,113 @@ func (us *UserService) GetUser(ctx context.Context, userId uuid.UUID) (*User, er
 	}
 }
 
+// look up a user by their email, this is used to resolve the recipient of a share when the
+// recipient is identified by email instead of by id
+func (us *UserService) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	user, err := us.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		slog.ErrorContext(
+			ctx,
+			"failed to get user by email because of repository error",
+			"error", err.Error(),
+		)
+		return nil, err
+	} else {
+		return user, nil
+	}
+}
+
+// identifies a single user by exactly one of its unique fields, the fields that are not used
+// are left nil
+type UserIdentifier struct {
+	UserId *uuid.UUID
+	Email *string
+	UserName *string
+}
+
+// look up a user by whichever identifier the caller has, this saves callers from choosing
+// between a lookup method per identifier. Exactly one identifier has to be set
+func (us *UserService) ResolveUser(ctx context.Context, identifier UserIdentifier) (*User, error) {
+	provided := 0
+	for _, isSet := range []bool{
+		identifier.UserId != nil, identifier.Email != nil, identifier.UserName != nil,
+	} {
+		if isSet {
+			provided++
+		}
+	}
+	if provided != 1 {
+		slog.WarnContext(ctx, "failed to resolve user, request is invalid", "identifiers", provided)
+		return nil, Invalid(
+			fmt.Sprintf("exactly one of user_id, email, or user_name is required, got: %d", provided), nil,
+		)
+	}
+	var user *User
+	var err DomainError
+	switch {
+	case identifier.UserId != nil:
+		user, err = us.repo.GetUserById(ctx, *identifier.UserId)
+	case identifier.Email != nil:
+		user, err = us.repo.GetUserByEmail(ctx, *identifier.Email)
+	default:
+		user, err = us.repo.GetUserByUserName(ctx, *identifier.UserName)
+	}
+	if err != nil {
+		slog.ErrorContext(
+			ctx,
+			"failed to resolve user because of repository error",
+			"error", err.Error(),
+		)
+		return nil, err
+	}
+	return user, nil
+}
+
+// the availability of the fields that were checked, a field that was not checked is nil
+type Availability struct {
+	UserNameAvailable *bool
+	EmailAvailable *bool
+}
+
+// check whether a user could be created with the user name and the email before signing up, at
+// least one of them has to be set. The values are compared exactly as CreateUser stores them, so
+// a value is available here when the unique constraints would accept it on creation. A value
+// that is available can still be taken by another signup before this one is submitted
+func (us *UserService) CheckAvailability(ctx context.Context, userName *string, email *string) (*Availability, error) {
+	if userName == nil && email == nil {
+		slog.WarnContext(ctx, "failed to check availability, request is invalid")
+		return nil, Invalid("at least one of user_name or email is required", nil)
+	}
+	availability := &Availability{}
+	if userName != nil {
+		exists, err := us.repo.UserNameExists(ctx, *userName)
+		if err != nil {
+			slog.ErrorContext(
+				ctx,
+				"failed to check the availability of a user name because of repository error",
+				"error", err.Error(),
+			)
+			return nil, err
+		}
+		available := !exists
+		availability.UserNameAvailable = &available
+	}
+	if email != nil {
+		exists, err := us.repo.EmailExists(ctx, *email)
+		if err != nil {
+			slog.ErrorContext(
+				ctx,
+				"failed to check the availability of an email because of repository error",
+				"error", err.Error(),
+			)
+			return nil, err
+		}
+		available := !exists
+		availability.EmailAvailable = &available
+	}
+	return availability, nil
+}
+
 // calls to deactivate a user are like an upsert, if the user has already been deactivated they have no effect
 func (us *UserService) DeactivateUser(ctx context.Context, userId uuid.UUID) error {
 	err := us.repo.DeactivateUser(ctx, userId)
@@ -141,8 +279,8 @@ func (us *UserService) ValidatePassword(
 	ctx context.Context,
 	userName string,
 	password string,
-) (uuid.UUID, bool, error) {
-	userId, isValid, err := us.repo.ValidatePassword(
+) (uuid.UUID, int32, bool, error) {
+	userId, tokenVersion, isValid, err := us.repo.ValidatePassword(
 		ctx, userName, password,
 	)
 	if err != nil {
@@ -151,9 +289,9 @@ func (us *UserService) ValidatePassword(
 			"failed to validate password because of a repository error",
 			"error", err.Error(),
 		)
-		return uuid.Nil, false, err
+		return uuid.Nil, 0, false, err
 	}
-	return userId, isValid, nil
+	return userId, tokenVersion, isValid, nil
 }
 
 // Questions:
@@ -0,0 +1,194 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/townsag/reed/user_service/internal/config"
+	"github.com/townsag/reed/user_service/internal/service"
+)
+
+// validation happens before the repository is called so these tests do not need a
+// repository implementation
+
+func TestCreateUser_MultipleViolations_Unit(t *testing.T) {
+	userService := service.NewUserService(nil)
+	// both the user name and the password are too short
+	_, err := userService.CreateUser(t.Context(), "ab", "test@example.com", nil, nil, "short")
+	var invalidError *service.InvalidError
+	if !errors.As(err, &invalidError) {
+		t.Fatalf("want: InvalidError for an invalid request, got: %v", err)
+	}
+	if len(invalidError.Fields) != 2 {
+		t.Errorf("want: 2 field violations, got: %d, fields: %v", len(invalidError.Fields), invalidError.Fields)
+	}
+	for _, field := range []string{"user_name", "password"} {
+		if _, ok := invalidError.Fields[field]; !ok {
+			t.Errorf("want: a violation for field %s, got fields: %v", field, invalidError.Fields)
+		}
+	}
+}
+
+func TestCreateUser_SingleViolation_Unit(t *testing.T) {
+	userService := service.NewUserService(nil)
+	// only the password is too short
+	_, err := userService.CreateUser(t.Context(), "testUser", "test@example.com", nil, nil, "short")
+	var invalidError *service.InvalidError
+	if !errors.As(err, &invalidError) {
+		t.Fatalf("want: InvalidError for an invalid request, got: %v", err)
+	}
+	if len(invalidError.Fields) != 1 {
+		t.Errorf("want: 1 field violation, got: %d, fields: %v", len(invalidError.Fields), invalidError.Fields)
+	}
+	if _, ok := invalidError.Fields["password"]; !ok {
+		t.Errorf("want: a violation for field password, got fields: %v", invalidError.Fields)
+	}
+}
+
+// records the document quota that the service passes to the repository, embedding the interface
+// satisfies it and calling a method that is not overridden panics
+type recordingUserRepository struct {
+	service.UserRepository
+	maxDocuments *int32
+}
+
+func (r *recordingUserRepository) CreateUser(
+	ctx context.Context, userName string, email string, maxDocuments int32, password string,
+) (*service.User, service.DomainError) {
+	r.maxDocuments = &maxDocuments
+	return &service.User{ UserName: userName, Email: email, MaxDocuments: maxDocuments }, nil
+}
+
+func TestCreateUser_NegativeMaxDocuments_Unit(t *testing.T) {
+	repo := &recordingUserRepository{}
+	userService := service.NewUserService(repo)
+	maxDocuments := int32(-1)
+	_, err := userService.CreateUser(t.Context(), "testUser", "test@example.com", &maxDocuments, nil, "password")
+	var invalidError *service.InvalidError
+	if !errors.As(err, &invalidError) {
+		t.Fatalf("want: InvalidError for a negative max documents, got: %v", err)
+	}
+	if _, ok := invalidError.Fields["max_documents"]; !ok {
+		t.Errorf("want: a violation for field max_documents, got fields: %v", invalidError.Fields)
+	}
+	if repo.maxDocuments != nil {
+		t.Errorf("expected the repository not to be called for an invalid request")
+	}
+}
+
+func TestCreateUser_TooLargeMaxDocuments_Unit(t *testing.T) {
+	userService := service.NewUserService(&recordingUserRepository{})
+	maxDocuments := config.MaxMaxDocuments + 1
+	_, err := userService.CreateUser(t.Context(), "testUser", "test@example.com", &maxDocuments, nil, "password")
+	var invalidError *service.InvalidError
+	if !errors.As(err, &invalidError) {
+		t.Fatalf("want: InvalidError for a max documents above the limit, got: %v", err)
+	}
+}
+
+func TestCreateUser_ValidMaxDocuments_Unit(t *testing.T) {
+	zero, normal, limit := int32(0), int32(25), config.MaxMaxDocuments
+	testCases := []struct{
+		name string
+		maxDocuments *int32
+		want int32
+	}{
+		{ name: "nil defaults", maxDocuments: nil, want: config.DefaultMaxDocuments },
+		{ name: "zero", maxDocuments: &zero, want: 0 },
+		{ name: "normal", maxDocuments: &normal, want: 25 },
+		{ name: "limit", maxDocuments: &limit, want: config.MaxMaxDocuments },
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &recordingUserRepository{}
+			userService := service.NewUserService(repo)
+			_, err := userService.CreateUser(t.Context(), "testUser", "test@example.com", testCase.maxDocuments, nil, "password")
+			if err != nil {
+				t.Fatalf("failed to create user with error: %v", err)
+			}
+			if repo.maxDocuments == nil || *repo.maxDocuments != testCase.want {
+				t.Errorf("want max documents: %d, got: %v", testCase.want, repo.maxDocuments)
+			}
+		})
+	}
+}
+
+func TestCreateUser_PlanMaxDocuments_Unit(t *testing.T) {
+	free, pro, explicit := config.FreePlan, "pro", int32(25)
+	testCases := []struct{
+		name string
+		plan *string
+		maxDocuments *int32
+		want int32
+	}{
+		{ name: "free plan", plan: &free, want: config.DefaultMaxDocuments },
+		{ name: "pro plan", plan: &pro, want: config.PlanMaxDocuments["pro"] },
+		{ name: "explicit quota overrides plan", plan: &pro, maxDocuments: &explicit, want: 25 },
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &recordingUserRepository{}
+			userService := service.NewUserService(repo)
+			_, err := userService.CreateUser(t.Context(), "testUser", "test@example.com", testCase.maxDocuments, testCase.plan, "password")
+			if err != nil {
+				t.Fatalf("failed to create user with error: %v", err)
+			}
+			if repo.maxDocuments == nil || *repo.maxDocuments != testCase.want {
+				t.Errorf("want max documents: %d, got: %v", testCase.want, repo.maxDocuments)
+			}
+		})
+	}
+}
+
+func TestCreateUser_UnknownPlan_Unit(t *testing.T) {
+	repo := &recordingUserRepository{}
+	userService := service.NewUserService(repo)
+	plan := "enterprise"
+	_, err := userService.CreateUser(t.Context(), "testUser", "test@example.com", nil, &plan, "password")
+	var invalidError *service.InvalidError
+	if !errors.As(err, &invalidError) {
+		t.Fatalf("want: InvalidError for an unknown plan, got: %v", err)
+	}
+	if _, ok := invalidError.Fields["plan"]; !ok {
+		t.Errorf("want: a violation for field plan, got fields: %v", invalidError.Fields)
+	}
+	if repo.maxDocuments != nil {
+		t.Errorf("expected the repository not to be called for an invalid request")
+	}
+}
+
+func TestResolveUser_IdentifierCount_Unit(t *testing.T) {
+	userId := uuid.New()
+	email, userName := "test@example.com", "testUser"
+	testCases := []struct{
+		name string
+		identifier service.UserIdentifier
+	}{
+		{ name: "none", identifier: service.UserIdentifier{} },
+		{ name: "id and email", identifier: service.UserIdentifier{ UserId: &userId, Email: &email } },
+		{ name: "all", identifier: service.UserIdentifier{ UserId: &userId, Email: &email, UserName: &userName } },
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// the repository is nil so the test panics if the service calls it
+			userService := service.NewUserService(nil)
+			_, err := userService.ResolveUser(t.Context(), testCase.identifier)
+			var invalidError *service.InvalidError
+			if !errors.As(err, &invalidError) {
+				t.Errorf("want: InvalidError for an identifier without exactly one field, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckAvailability_NoFields_Unit(t *testing.T) {
+	// the repository is nil so the test panics if the service calls it
+	userService := service.NewUserService(nil)
+	_, err := userService.CheckAvailability(t.Context(), nil, nil)
+	var invalidError *service.InvalidError
+	if !errors.As(err, &invalidError) {
+		t.Errorf("want: InvalidError when neither the user name nor the email is set, got: %v", err)
+	}
+}
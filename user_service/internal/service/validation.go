@@ -0,0 +1,34 @@
+package service
+
+// fieldValidator collects every field that fails validation instead of returning on the
+// first failure, this way a client can fix all of the problems with a request in one round
+// trip
+type fieldValidator struct {
+	fields map[string]string
+}
+
+func newFieldValidator() *fieldValidator {
+	return &fieldValidator{
+		fields: make(map[string]string),
+	}
+}
+
+// record the reason that a field is invalid when ok is false, only the first failing
+// check for a field is kept
+func (v *fieldValidator) check(ok bool, field string, reason string) {
+	if ok {
+		return
+	}
+	if _, exists := v.fields[field]; !exists {
+		v.fields[field] = reason
+	}
+}
+
+// returns an untyped nil when every check passed so that callers can compare the result
+// against nil, otherwise returns an InvalidError carrying all of the field violations
+func (v *fieldValidator) err(msg string) error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return InvalidFields(msg, v.fields)
+}
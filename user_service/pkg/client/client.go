@@ -2,10 +2,14 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	"github.com/google/uuid"
 	pb "github.com/townsag/reed/user_service/api"
@@ -17,9 +21,19 @@ type UserServiceClient struct {
 }
 
 func NewUserServiceClient(addr string) (*UserServiceClient, error) {
+	return NewUserServiceClientWithTLS(addr, nil)
+}
+
+// connect over tls when tlsConfig is set, a nil config connects in plaintext. The config has
+// to match the server, see GetServerTLSConfig of the user service
+func NewUserServiceClientWithTLS(addr string, tlsConfig *tls.Config) (*UserServiceClient, error) {
+	transportCredentials := insecure.NewCredentials()
+	if tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
 	// perform some validations on the address to ensure that it is of the correct shape
 	// create a connection to the grpc server
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(transportCredentials))
 	// TODO: this^ is where I would add an interceptor that did observability
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a connection: %w", err)
@@ -40,12 +54,49 @@ func (c *UserServiceClient) GetUser(ctx context.Context, userId uuid.UUID) (*pb.
 	return c.client.GetUser(ctx, &pb.GetUserRequest{ UserId: userId.String() })
 }
 
+func (c *UserServiceClient) GetUserByEmail(ctx context.Context, userEmail string) (*pb.UserReply, error) {
+	return c.client.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{ UserEmail: userEmail })
+}
+
+// identifies the user to resolve, exactly one of the fields has to be set
+type UserIdentifier struct {
+	UserId *uuid.UUID
+	Email *string
+	UserName *string
+}
+
+// look up a user by whichever one of its identifiers the caller has, an identifier with none
+// or more than one of its fields set is rejected before a call is made
+func (c *UserServiceClient) ResolveUser(ctx context.Context, identifier UserIdentifier) (*pb.UserReply, error) {
+	req := &pb.ResolveUserRequest{}
+	provided := 0
+	if identifier.UserId != nil {
+		req.Identifier = &pb.ResolveUserRequest_UserId{ UserId: identifier.UserId.String() }
+		provided++
+	}
+	if identifier.Email != nil {
+		req.Identifier = &pb.ResolveUserRequest_Email{ Email: *identifier.Email }
+		provided++
+	}
+	if identifier.UserName != nil {
+		req.Identifier = &pb.ResolveUserRequest_UserName{ UserName: *identifier.UserName }
+		provided++
+	}
+	if provided != 1 {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "exactly one of user id, email, or user name is required, got: %d", provided,
+		)
+	}
+	return c.client.ResolveUser(ctx, req)
+}
+
 func (c *UserServiceClient) CreateUser(
 	ctx context.Context,
 	userName string,
 	userEmail string,
 	password string,
 	maxDocuments *int32,
+	plan *string,
 ) (*pb.CreateUserReply, error) {
 	return c.client.CreateUser(
 		ctx,
@@ -54,6 +105,26 @@ func (c *UserServiceClient) CreateUser(
 			UserEmail: userEmail,
 			Password: password,
 			MaxDocuments: maxDocuments,
+			Plan: plan,
+		},
+	)
+}
+
+// at least one of the user name and the email has to be set, the reply only carries the
+// availability of the fields that were set
+func (c *UserServiceClient) CheckAvailability(
+	ctx context.Context,
+	userName *string,
+	email *string,
+) (*pb.CheckAvailabilityReply, error) {
+	if userName == nil && email == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one of user name or email is required")
+	}
+	return c.client.CheckAvailability(
+		ctx,
+		&pb.CheckAvailabilityRequest{
+			UserName: userName,
+			Email: email,
 		},
 	)
 }
@@ -84,7 +155,7 @@ func (c *UserServiceClient) ValidatePassword(
 	ctx context.Context,
 	userName string,
 	password string,
-) (uuid.UUID, bool, error) {
+) (uuid.UUID, int32, bool, error) {
 	reply, err := c.client.ValidatePassword(
 		ctx,
 		&pb.ValidatePasswordRequest{
@@ -93,13 +164,13 @@ func (c *UserServiceClient) ValidatePassword(
 		},
 	)
 	if err != nil {
-		return uuid.Nil, false, err
+		return uuid.Nil, 0, false, err
 	} else {
 		// parse the uuid from the reply
 		userId, err := uuid.Parse(*reply.UserId)
 		if err != nil {
-			return uuid.Nil, false, err
+			return uuid.Nil, 0, false, err
 		}
-		return userId, reply.IsValid, nil
+		return userId, reply.TokenVersion, reply.IsValid, nil
 	}
 }
\ No newline at end of file
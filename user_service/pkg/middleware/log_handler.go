@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// a slog handler that adds the trace id and span id of the span in the context to every record,
+// the otel bridge reads them from the context itself but other handlers like the json handler
+// need them as attributes. The trace id has the same format as the request id header
+type traceContextHandler struct {
+	next slog.Handler
+}
+
+func NewTraceContextHandler(next slog.Handler) slog.Handler {
+	return &traceContextHandler{ next: next }
+}
+
+func (h *traceContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *traceContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record = record.Clone()
+		record.AddAttrs(
+			slog.String("traceId", uuid.UUID(spanContext.TraceID()).String()),
+			slog.String("spanId", spanContext.SpanID().String()),
+		)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *traceContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceContextHandler{ next: h.next.WithAttrs(attrs) }
+}
+
+func (h *traceContextHandler) WithGroup(name string) slog.Handler {
+	return &traceContextHandler{ next: h.next.WithGroup(name) }
+}
+
+// a slog handler that passes every record to each of the handlers that are enabled for its
+// level, for example to send logs to otel and to stdout at once
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func NewTeeHandler(handlers ...slog.Handler) slog.Handler {
+	return &teeHandler{ handlers: handlers }
+}
+
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// every handler is called even when one fails, the errors are joined
+func (h *teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var err error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, record.Level) {
+			err = errors.Join(err, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return err
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &teeHandler{ handlers: handlers }
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &teeHandler{ handlers: handlers }
+}
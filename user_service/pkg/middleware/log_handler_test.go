@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// decode the next record, the same decoder is used for all the records of a buffer because the
+// decoder reads ahead
+func decodeRecord(t *testing.T, decoder *json.Decoder) map[string]any {
+	var record map[string]any
+	if err := decoder.Decode(&record); err != nil {
+		t.Fatalf("failed to decode log record with error: %v", err)
+	}
+	return record
+}
+
+func TestTraceContextHandler_AddsIds_Unit(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewTraceContextHandler(slog.NewJSONHandler(&buf, nil)))
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{ 1, 2, 3 },
+		SpanID: trace.SpanID{ 4, 5, 6 },
+	})
+	logger.InfoContext(trace.ContextWithSpanContext(context.Background(), spanContext), "traced")
+	decoder := json.NewDecoder(&buf)
+	record := decodeRecord(t, decoder)
+	if want := uuid.UUID(spanContext.TraceID()).String(); record["traceId"] != want {
+		t.Errorf("want traceId: %s, got: %v", want, record["traceId"])
+	}
+	if want := spanContext.SpanID().String(); record["spanId"] != want {
+		t.Errorf("want spanId: %s, got: %v", want, record["spanId"])
+	}
+	// a record logged without a span is passed through unchanged
+	logger.InfoContext(context.Background(), "untraced")
+	record = decodeRecord(t, decoder)
+	if _, ok := record["traceId"]; ok {
+		t.Errorf("want no traceId on a record without a span, got: %v", record["traceId"])
+	}
+}
+
+func TestTeeHandler_WritesToEachHandler_Unit(t *testing.T) {
+	var infoBuf, warnBuf bytes.Buffer
+	logger := slog.New(NewTeeHandler(
+		slog.NewJSONHandler(&infoBuf, nil),
+		slog.NewJSONHandler(&warnBuf, &slog.HandlerOptions{ Level: slog.LevelWarn }),
+	)).With("service", "test")
+	logger.Info("info record")
+	logger.Warn("warn record")
+	infoDecoder := json.NewDecoder(&infoBuf)
+	for _, want := range []string{ "info record", "warn record" } {
+		if record := decodeRecord(t, infoDecoder); record["msg"] != want || record["service"] != "test" {
+			t.Errorf("want record: %s with the service attribute, got: %v", want, record)
+		}
+	}
+	// the handler that is not enabled for info records only gets the warning
+	warnDecoder := json.NewDecoder(&warnBuf)
+	if record := decodeRecord(t, warnDecoder); record["msg"] != "warn record" {
+		t.Errorf("want only the warn record, got: %v", record)
+	}
+	if warnDecoder.More() {
+		t.Errorf("want no more records after the warn record")
+	}
+}
@@ -20,4 +20,17 @@ func LoggingInterceptor() grpc.UnaryServerInterceptor {
 		slog.DebugContext(ctx, fmt.Sprintf("received a call to method %s", info.FullMethod))
 		return handler(ctx, req)
 	}
+}
+
+// the streaming version of LoggingInterceptor
+func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		slog.DebugContext(stream.Context(), fmt.Sprintf("received a call to method %s", info.FullMethod))
+		return handler(srv, stream)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// the metadata key that carries the id of the principal that the gateway authenticated, the
+// services only use it to tag their logs and never to authorize a request
+const principalIdKey contextKey = "x-reed-principal-id"
+
+type principalIdContextKey struct{}
+
+// store the id of the principal that made the request in the context so that the logs written
+// with it are tagged with the principal, and send it in the metadata of the gRPC calls made with
+// the returned context so that the logs of the downstream services are tagged as well
+func WithPrincipalId(ctx context.Context, principalId string) context.Context {
+	ctx = context.WithValue(ctx, principalIdContextKey{}, principalId)
+	return metadata.AppendToOutgoingContext(ctx, string(principalIdKey), principalId)
+}
+
+// get the id of the principal that made the request, this is empty when the request did not
+// carry one
+func GetPrincipalId(ctx context.Context) string {
+	principalId, _ := ctx.Value(principalIdContextKey{}).(string)
+	return principalId
+}
+
+// read the principal id sent by the caller into the context of the call
+func PrincipalIdInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		return handler(withIncomingPrincipalId(ctx), req)
+	}
+}
+
+// the streaming version of PrincipalIdInterceptor
+func PrincipalIdStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &contextServerStream{ ServerStream: stream, ctx: withIncomingPrincipalId(stream.Context()) })
+	}
+}
+
+func withIncomingPrincipalId(ctx context.Context) context.Context {
+	if values := metadata.ValueFromIncomingContext(ctx, string(principalIdKey)); len(values) > 0 {
+		ctx = context.WithValue(ctx, principalIdContextKey{}, values[0])
+	}
+	return ctx
+}
+
+// a server stream whose handler sees a different context than the one the stream was opened
+// with, the stream interceptors use it to hand values to the handler like the unary ones do
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// a slog handler that adds the principal id of the request to every record that is logged with
+// a context carrying one, records logged without a context are passed through unchanged
+type principalIdHandler struct {
+	next slog.Handler
+}
+
+func NewPrincipalIdHandler(next slog.Handler) slog.Handler {
+	return &principalIdHandler{ next: next }
+}
+
+func (h *principalIdHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *principalIdHandler) Handle(ctx context.Context, record slog.Record) error {
+	if principalId := GetPrincipalId(ctx); principalId != "" {
+		record = record.Clone()
+		record.AddAttrs(slog.String("principalId", principalId))
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *principalIdHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &principalIdHandler{ next: h.next.WithAttrs(attrs) }
+}
+
+func (h *principalIdHandler) WithGroup(name string) slog.Handler {
+	return &principalIdHandler{ next: h.next.WithGroup(name) }
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// run a call with the given incoming metadata through the principal id interceptor, the handler
+// of the call logs one record. Returns the decoded record
+func logThroughInterceptor(t *testing.T, md metadata.MD) map[string]any {
+	var buf bytes.Buffer
+	logger := slog.New(NewPrincipalIdHandler(slog.NewJSONHandler(&buf, nil)))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	_, err := PrincipalIdInterceptor()(
+		ctx, nil, &grpc.UnaryServerInfo{ FullMethod: "/test.Service/Method" },
+		func(ctx context.Context, req any) (any, error) {
+			logger.InfoContext(ctx, "handled the call")
+			return nil, nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("want no error from the interceptor, got: %v", err)
+	}
+	var record map[string]any
+	if err := json.NewDecoder(&buf).Decode(&record); err != nil {
+		t.Fatalf("failed to decode log record with error: %v", err)
+	}
+	return record
+}
+
+func TestPrincipalIdInterceptor_TagsLogs_Unit(t *testing.T) {
+	principalId := uuid.NewString()
+	record := logThroughInterceptor(t, metadata.Pairs(string(principalIdKey), principalId))
+	if record["principalId"] != principalId {
+		t.Errorf("want the log record tagged with principal: %s, got: %v", principalId, record)
+	}
+}
+
+func TestPrincipalIdInterceptor_NoPrincipal_Unit(t *testing.T) {
+	record := logThroughInterceptor(t, metadata.MD{})
+	if _, ok := record["principalId"]; ok {
+		t.Errorf("want no principal id on the log record of a call without one, got: %v", record)
+	}
+}
+
+// a server stream that only carries a context
+type contextOnlyServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextOnlyServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestPrincipalIdStreamInterceptor_TagsContext_Unit(t *testing.T) {
+	principalId := uuid.NewString()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(string(principalIdKey), principalId))
+	var got string
+	err := PrincipalIdStreamInterceptor()(
+		nil, &contextOnlyServerStream{ ctx: ctx }, &grpc.StreamServerInfo{ FullMethod: "/test.Service/Watch" },
+		func(srv any, stream grpc.ServerStream) error {
+			got = GetPrincipalId(stream.Context())
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("want no error from the interceptor, got: %v", err)
+	}
+	if got != principalId {
+		t.Errorf("want principal: %s in the context of the stream, got: %q", principalId, got)
+	}
+}
+
+func TestWithPrincipalId_OutgoingMetadata_Unit(t *testing.T) {
+	principalId := uuid.NewString()
+	ctx := WithPrincipalId(context.Background(), principalId)
+	if got := GetPrincipalId(ctx); got != principalId {
+		t.Errorf("want principal: %s in the context, got: %q", principalId, got)
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok || len(md.Get(string(principalIdKey))) != 1 || md.Get(string(principalIdKey))[0] != principalId {
+		t.Errorf("want principal: %s in the outgoing metadata, got: %v", principalId, md)
+	}
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// once the limiter holds this many buckets the least recently used bucket is dropped for each new
+// one. The least recently used bucket has had the longest to refill, so dropping it and handing
+// its caller a full bucket later gives away the fewest calls
+const maxRateLimitBuckets = 10000
+
+// the sustained number of calls per second and the number of calls that can be made at once
+// before the sustained rate applies. A rate of zero disables the limit
+type RateLimit struct {
+	Rate float64
+	Burst int
+}
+
+type tokenBucket struct {
+	key string
+	tokens float64
+	updatedAt time.Time
+}
+
+// a token bucket rate limiter for the unary calls of a gRPC server. Each method has its own
+// buckets, when byPrincipal is set each principal also gets its own bucket for the method so
+// that one busy caller does not use up the budget of the others
+type RateLimiter struct {
+	defaultLimit RateLimit
+	// keyed by the name of the method without the service, for example "GetUser"
+	methodLimits map[string]RateLimit
+	byPrincipal bool
+	now func() time.Time
+	maxBuckets int
+	mu sync.Mutex
+	// the elements of the recently used list keyed by the key of their bucket
+	buckets map[string]*list.Element
+	// the buckets from the most recently used at the front to the least recently used at the back
+	recentlyUsed *list.List
+}
+
+func NewRateLimiter(defaultLimit RateLimit, methodLimits map[string]RateLimit, byPrincipal bool) *RateLimiter {
+	return &RateLimiter{
+		defaultLimit: defaultLimit,
+		methodLimits: methodLimits,
+		byPrincipal: byPrincipal,
+		now: time.Now,
+		maxBuckets: maxRateLimitBuckets,
+		buckets: make(map[string]*list.Element),
+		recentlyUsed: list.New(),
+	}
+}
+
+func (l *RateLimiter) limitFor(fullMethod string) RateLimit {
+	if limit, ok := l.methodLimits[path.Base(fullMethod)]; ok {
+		return limit
+	}
+	return l.defaultLimit
+}
+
+// take a token from the bucket of the key, returns false when the bucket is empty
+func (l *RateLimiter) allow(key string, limit RateLimit) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var bucket *tokenBucket
+	if element, ok := l.buckets[key]; ok {
+		l.recentlyUsed.MoveToFront(element)
+		bucket = element.Value.(*tokenBucket)
+	} else {
+		for len(l.buckets) >= l.maxBuckets {
+			l.dropLeastRecentlyUsed()
+		}
+		bucket = &tokenBucket{ key: key, tokens: float64(limit.Burst), updatedAt: now }
+		l.buckets[key] = l.recentlyUsed.PushFront(bucket)
+	}
+	bucket.tokens = min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*limit.Rate)
+	bucket.updatedAt = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// the caller has to hold the lock
+func (l *RateLimiter) dropLeastRecentlyUsed() {
+	element := l.recentlyUsed.Back()
+	l.recentlyUsed.Remove(element)
+	delete(l.buckets, element.Value.(*tokenBucket).key)
+}
+
+// reject calls over the limit of their method with a resource exhausted status. The principal
+// is read from the metadata instead of the context so that this interceptor does not depend on
+// running after the principal id interceptor
+func (l *RateLimiter) Interceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		if err = l.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// the streaming version of Interceptor, opening a stream takes one token from the bucket of its
+// method. The messages sent on an open stream are not limited
+func (l *RateLimiter) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := l.check(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// take a token for a call to the method, returns a resource exhausted status when the limit is
+// exceeded
+func (l *RateLimiter) check(ctx context.Context, fullMethod string) error {
+	limit := l.limitFor(fullMethod)
+	if limit.Rate <= 0 {
+		return nil
+	}
+	key := fullMethod
+	if l.byPrincipal {
+		if values := metadata.ValueFromIncomingContext(ctx, string(principalIdKey)); len(values) > 0 {
+			key += " " + values[0]
+		}
+	}
+	if !l.allow(key, limit) {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded for method %s", fullMethod)
+	}
+	return nil
+}
+
+// parse the limits of individual methods from a comma separated list of method=rate:burst
+// entries, for example "ValidatePassword=5:10,GetUser=100:200"
+func ParseMethodRateLimits(spec string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	if strings.TrimSpace(spec) == "" {
+		return limits, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		method, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("want a method rate limit in the form method=rate:burst, got: %q", entry)
+		}
+		limit, err := ParseRateLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the rate limit of method %s: %w", method, err)
+		}
+		limits[method] = limit
+	}
+	return limits, nil
+}
+
+// parse a rate limit in the form rate:burst, the burst must be at least one unless the rate is
+// zero because a bucket that can not hold a token rejects every call
+func ParseRateLimit(value string) (RateLimit, error) {
+	rateValue, burstValue, ok := strings.Cut(value, ":")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("want a rate limit in the form rate:burst, got: %q", value)
+	}
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || rate < 0 {
+		return RateLimit{}, fmt.Errorf("want a non negative rate, got: %q", rateValue)
+	}
+	burst, err := strconv.Atoi(burstValue)
+	if err != nil || (rate > 0 && burst < 1) {
+		return RateLimit{}, fmt.Errorf("want a burst of at least one, got: %q", burstValue)
+	}
+	return RateLimit{ Rate: rate, Burst: burst }, nil
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// a rate limiter whose clock only moves when the test advances it
+func newTestRateLimiter(defaultLimit RateLimit, methodLimits map[string]RateLimit, byPrincipal bool) (*RateLimiter, *time.Time) {
+	limiter := NewRateLimiter(defaultLimit, methodLimits, byPrincipal)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+// make a call to the method through the interceptor as the principal, returns the status code
+func callThroughLimiter(limiter *RateLimiter, method string, principalId string) codes.Code {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(string(principalIdKey), principalId))
+	_, err := limiter.Interceptor()(
+		ctx, nil, &grpc.UnaryServerInfo{ FullMethod: "/test.Service/" + method },
+		func(ctx context.Context, req any) (any, error) {
+			return nil, nil
+		},
+	)
+	return status.Code(err)
+}
+
+func TestRateLimiter_ExceedingLimit_Unit(t *testing.T) {
+	limiter, _ := newTestRateLimiter(RateLimit{ Rate: 1, Burst: 3 }, nil, false)
+	principalId := uuid.NewString()
+	for i := range 3 {
+		if code := callThroughLimiter(limiter, "GetUser", principalId); code != codes.OK {
+			t.Fatalf("want call %d within the burst to pass, got: %v", i, code)
+		}
+	}
+	if code := callThroughLimiter(limiter, "GetUser", principalId); code != codes.ResourceExhausted {
+		t.Errorf("want: %v once the burst is used up, got: %v", codes.ResourceExhausted, code)
+	}
+}
+
+func TestRateLimiter_LowerRatePasses_Unit(t *testing.T) {
+	limiter, now := newTestRateLimiter(RateLimit{ Rate: 10, Burst: 1 }, nil, false)
+	principalId := uuid.NewString()
+	// calls spaced further apart than the rate allows always find a token in the bucket
+	for i := range 20 {
+		if code := callThroughLimiter(limiter, "GetUser", principalId); code != codes.OK {
+			t.Fatalf("want call %d under the rate to pass, got: %v", i, code)
+		}
+		*now = now.Add(200 * time.Millisecond)
+	}
+}
+
+func TestRateLimiter_MethodLimits_Unit(t *testing.T) {
+	limiter, _ := newTestRateLimiter(
+		RateLimit{ Rate: 0 }, map[string]RateLimit{ "ValidatePassword": { Rate: 1, Burst: 1 } }, false,
+	)
+	principalId := uuid.NewString()
+	if code := callThroughLimiter(limiter, "ValidatePassword", principalId); code != codes.OK {
+		t.Fatalf("want the first call to pass, got: %v", code)
+	}
+	if code := callThroughLimiter(limiter, "ValidatePassword", principalId); code != codes.ResourceExhausted {
+		t.Errorf("want the limited method to be rejected, got: %v", code)
+	}
+	// a rate of zero disables the limit of the other methods
+	for range 5 {
+		if code := callThroughLimiter(limiter, "GetUser", principalId); code != codes.OK {
+			t.Fatalf("want calls to an unlimited method to pass, got: %v", code)
+		}
+	}
+}
+
+func TestRateLimiter_ByPrincipal_Unit(t *testing.T) {
+	limiter, _ := newTestRateLimiter(RateLimit{ Rate: 1, Burst: 1 }, nil, true)
+	first := uuid.NewString()
+	if code := callThroughLimiter(limiter, "GetUser", first); code != codes.OK {
+		t.Fatalf("want the first call to pass, got: %v", code)
+	}
+	if code := callThroughLimiter(limiter, "GetUser", first); code != codes.ResourceExhausted {
+		t.Errorf("want the second call of the same principal to be rejected, got: %v", code)
+	}
+	if code := callThroughLimiter(limiter, "GetUser", uuid.NewString()); code != codes.OK {
+		t.Errorf("want another principal to have its own budget, got: %v", code)
+	}
+}
+
+func TestRateLimiter_DropsLeastRecentlyUsed_Unit(t *testing.T) {
+	limiter, _ := newTestRateLimiter(RateLimit{ Rate: 1, Burst: 1 }, nil, true)
+	limiter.maxBuckets = 2
+	busyId, idleId := uuid.NewString(), uuid.NewString()
+	callThroughLimiter(limiter, "GetUser", idleId)
+	callThroughLimiter(limiter, "GetUser", busyId)
+	// every principal is over its limit, the cap still holds as new principals arrive
+	for range 5 {
+		callThroughLimiter(limiter, "GetUser", uuid.NewString())
+		if code := callThroughLimiter(limiter, "GetUser", busyId); code != codes.ResourceExhausted {
+			t.Fatalf("want the recently used bucket of the busy principal to be kept, got: %v", code)
+		}
+		if len(limiter.buckets) > 2 || limiter.recentlyUsed.Len() != len(limiter.buckets) {
+			t.Fatalf("want at most 2 buckets, got: %d", len(limiter.buckets))
+		}
+	}
+	// the idle principal was the least recently used, so its bucket was dropped
+	if code := callThroughLimiter(limiter, "GetUser", idleId); code != codes.OK {
+		t.Errorf("want the dropped bucket of the idle principal to start full, got: %v", code)
+	}
+}
+
+func TestRateLimiter_StreamInterceptor_Unit(t *testing.T) {
+	limiter, _ := newTestRateLimiter(RateLimit{ Rate: 1, Burst: 1 }, nil, false)
+	openStream := func() codes.Code {
+		err := limiter.StreamInterceptor()(
+			nil, &contextOnlyServerStream{ ctx: context.Background() }, &grpc.StreamServerInfo{ FullMethod: "/test.Service/Watch" },
+			func(srv any, stream grpc.ServerStream) error {
+				return nil
+			},
+		)
+		return status.Code(err)
+	}
+	if code := openStream(); code != codes.OK {
+		t.Fatalf("want the first stream within the burst to open, got: %v", code)
+	}
+	// opening a stream takes a token like a unary call does
+	if code := openStream(); code != codes.ResourceExhausted {
+		t.Errorf("want: %v for a stream over the limit, got: %v", codes.ResourceExhausted, code)
+	}
+}
+
+func TestParseMethodRateLimits_Unit(t *testing.T) {
+	limits, err := ParseMethodRateLimits("ValidatePassword=5:10, GetUser=0.5:1")
+	if err != nil {
+		t.Fatalf("failed to parse method rate limits with error: %v", err)
+	}
+	if limits["ValidatePassword"] != (RateLimit{ Rate: 5, Burst: 10 }) || limits["GetUser"] != (RateLimit{ Rate: 0.5, Burst: 1 }) {
+		t.Errorf("want the limits of both methods, got: %+v", limits)
+	}
+	for _, spec := range []string{ "GetUser", "GetUser=5", "GetUser=-1:1", "GetUser=5:0", "=5:10" } {
+		if _, err := ParseMethodRateLimits(spec); err == nil {
+			t.Errorf("want an error for the rate limits: %q", spec)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const instrumentationName = "github.com/townsag/reed/user_service/pkg/middleware"
+
+// the name of the counter of panics recovered from rpc handlers, the full method name is
+// recorded in the method attribute
+const RecoveredPanicsMetric = "rpc.server.recovered_panics"
+
+// recover from a panic in a later interceptor or in the handler so that the caller gets an
+// internal error instead of a broken stream. The stack is logged with the trace id of the call
+// so that the panic can be found from the trace. This should be the first interceptor in the
+// chain so that panics in the other interceptors are also recovered
+func RecoveryInterceptor(meterProvider metric.MeterProvider) (grpc.UnaryServerInterceptor, error) {
+	recoveredPanics, err := recoveredPanicsCounter(meterProvider)
+	if err != nil {
+		return nil, err
+	}
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				resp, err = nil, handlePanic(ctx, recoveredPanics, info.FullMethod, recovered)
+			}
+		}()
+		return handler(ctx, req)
+	}, nil
+}
+
+// the streaming version of RecoveryInterceptor, it should also be the first stream interceptor
+func RecoveryStreamInterceptor(meterProvider metric.MeterProvider) (grpc.StreamServerInterceptor, error) {
+	recoveredPanics, err := recoveredPanicsCounter(meterProvider)
+	if err != nil {
+		return nil, err
+	}
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = handlePanic(stream.Context(), recoveredPanics, info.FullMethod, recovered)
+			}
+		}()
+		return handler(srv, stream)
+	}, nil
+}
+
+func recoveredPanicsCounter(meterProvider metric.MeterProvider) (metric.Int64Counter, error) {
+	recoveredPanics, err := meterProvider.Meter(instrumentationName).Int64Counter(
+		RecoveredPanicsMetric,
+		metric.WithDescription("the number of panics recovered from rpc handlers"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recovered panics counter: %w", err)
+	}
+	return recoveredPanics, nil
+}
+
+// log and count a recovered panic, returns the status that the caller gets instead
+func handlePanic(
+	ctx context.Context,
+	recoveredPanics metric.Int64Counter,
+	fullMethod string,
+	recovered any,
+) error {
+	traceId := traceIdUnknown
+	if spanContext := trace.SpanFromContext(ctx).SpanContext(); spanContext.HasTraceID() {
+		traceId = uuid.UUID(spanContext.TraceID()).String()
+	}
+	slog.ErrorContext(
+		ctx, "recovered from a panic while handling a call",
+		"method", fullMethod, "traceId", traceId,
+		"panic", fmt.Sprint(recovered), "stack", string(debug.Stack()),
+	)
+	recoveredPanics.Add(ctx, 1, metric.WithAttributes(attribute.String("method", fullMethod)))
+	// the panic value is not sent to the caller because it can hold internal details
+	return status.Errorf(codes.Internal, "internal error while handling %s", fullMethod)
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// a health server that panics when it is asked about the "panics" service
+type panickingHealthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (s *panickingHealthServer) Check(
+	ctx context.Context, req *healthpb.HealthCheckRequest,
+) (*healthpb.HealthCheckResponse, error) {
+	if req.Service == "panics" {
+		var response *healthpb.HealthCheckResponse
+		response.Status = healthpb.HealthCheckResponse_SERVING
+	}
+	return &healthpb.HealthCheckResponse{ Status: healthpb.HealthCheckResponse_SERVING }, nil
+}
+
+func (s *panickingHealthServer) Watch(
+	req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse],
+) error {
+	if req.Service == "panics" {
+		panic("watching the panics service")
+	}
+	return stream.Send(&healthpb.HealthCheckResponse{ Status: healthpb.HealthCheckResponse_SERVING })
+}
+
+func TestRecoveryInterceptor_HandlerPanics_Unit(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	interceptor, err := RecoveryInterceptor(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatalf("failed to create the recovery interceptor with error: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen with error: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptor))
+	healthpb.RegisterHealthServer(grpcServer, &panickingHealthServer{})
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create a client with error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+	_, err = client.Check(t.Context(), &healthpb.HealthCheckRequest{ Service: "panics" })
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("want: %v from a handler that panics, got: %v", codes.Internal, err)
+	}
+	// the server keeps serving calls after the panic
+	if _, err = client.Check(t.Context(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("want the next call to succeed, got: %v", err)
+	}
+	var resourceMetrics metricdata.ResourceMetrics
+	if err = reader.Collect(t.Context(), &resourceMetrics); err != nil {
+		t.Fatalf("failed to collect metrics with error: %v", err)
+	}
+	for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			if m.Name != RecoveredPanicsMetric {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("want an int64 sum, got: %T", m.Data)
+			}
+			if len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1 {
+				t.Fatalf("want one recovered panic, got: %+v", sum.DataPoints)
+			}
+			method, _ := sum.DataPoints[0].Attributes.Value(attribute.Key("method"))
+			if method.AsString() != healthpb.Health_Check_FullMethodName {
+				t.Errorf("want method attribute: %s, got: %s", healthpb.Health_Check_FullMethodName, method.AsString())
+			}
+			return
+		}
+	}
+	t.Errorf("no %s metric was recorded", RecoveredPanicsMetric)
+}
+
+func TestRecoveryStreamInterceptor_HandlerPanics_Unit(t *testing.T) {
+	interceptor, err := RecoveryStreamInterceptor(sdkmetric.NewMeterProvider())
+	if err != nil {
+		t.Fatalf("failed to create the recovery interceptor with error: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen with error: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.ChainStreamInterceptor(interceptor))
+	healthpb.RegisterHealthServer(grpcServer, &panickingHealthServer{})
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create a client with error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+	stream, err := client.Watch(t.Context(), &healthpb.HealthCheckRequest{ Service: "panics" })
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("want: %v from a stream handler that panics, got: %v", codes.Internal, err)
+	}
+	// the server keeps serving streams after the panic
+	stream, err = client.Watch(t.Context(), &healthpb.HealthCheckRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if err != nil {
+		t.Errorf("want the next stream to succeed, got: %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/log"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const serviceVersion = "0.1.0"
+
+// the only otlp protocol that the exporters are built with
+const ProtocolGRPC = "grpc"
+
+// the formats of the default logger. Otel logs go through the otel bridge, json logs are written
+// to stdout and tee logs go to both. Json logs are meant for local development without a collector
+const (
+	LogFormatOTel = "otel"
+	LogFormatJSON = "json"
+	LogFormatTee = "tee"
+)
+
+// configures the otlp exporters, read it from the environment with GetTelemetryConfig
+type TelemetryConfig struct {
+	// when telemetry is disabled no exporters are created, so the service runs without a
+	// collector. The global providers are left as no-ops and logs go to the default logger
+	Enabled bool
+	ServiceName string
+	// the url of the collector, an http scheme sends telemetry without tls
+	Endpoint string
+	// headers that are sent with every export, for example to authenticate with the collector
+	Headers map[string]string
+	Protocol string
+	// one of the log formats, an empty format is the otel format. Json logs are written even
+	// when telemetry is disabled
+	LogFormat string
+	// where json logs are written, stdout when nil
+	LogWriter io.Writer
+}
+
+// read the telemetry configuration from the standard otel environment variables, the service
+// name is defaultServiceName unless OTEL_SERVICE_NAME is set
+func GetTelemetryConfig(defaultServiceName string) (TelemetryConfig, error) {
+	disabled, err := strconv.ParseBool(getEnvWithDefault("OTEL_SDK_DISABLED", "false"))
+	if err != nil {
+		return TelemetryConfig{}, fmt.Errorf("failed to parse OTEL_SDK_DISABLED: %w", err)
+	}
+	headers, err := parseHeaders(getEnvWithDefault("OTEL_EXPORTER_OTLP_HEADERS", ""))
+	if err != nil {
+		return TelemetryConfig{}, fmt.Errorf("failed to parse OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+	}
+	logFormat := getEnvWithDefault("LOG_FORMAT", LogFormatOTel)
+	if logFormat != LogFormatOTel && logFormat != LogFormatJSON && logFormat != LogFormatTee {
+		return TelemetryConfig{}, fmt.Errorf(
+			"unsupported LOG_FORMAT: %s, must be one of: %s, %s, %s",
+			logFormat, LogFormatOTel, LogFormatJSON, LogFormatTee,
+		)
+	}
+	return TelemetryConfig{
+		Enabled: !disabled,
+		ServiceName: getEnvWithDefault("OTEL_SERVICE_NAME", defaultServiceName),
+		Endpoint: getEnvWithDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4317"),
+		Headers: headers,
+		Protocol: getEnvWithDefault("OTEL_EXPORTER_OTLP_PROTOCOL", ProtocolGRPC),
+		LogFormat: logFormat,
+	}, nil
+}
+
+// an unset or empty variable is the default value
+func getEnvWithDefault(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// headers are a comma separated list of key=value pairs
+func parseHeaders(raw string) (map[string]string, error) {
+	headers := make(map[string]string)
+	if raw == "" {
+		return headers, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("expected a key=value pair, got: %q", pair)
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// setupOTelSDK bootstraps the OpenTelemetry pipeline.
+// If it does not return an error, make sure to call shutdown for proper cleanup.
+func SetupOTelSDK(ctx context.Context, cfg TelemetryConfig) (func(context.Context) error, error) {
+	var shutdownFuncs []func(context.Context) error
+	var err error
+
+	// shutdown calls cleanup functions registered via shutdownFuncs.
+	// The errors from the calls are joined.
+	// Each registered cleanup will be invoked once.
+	shutdown := func(ctx context.Context) error {
+		var err error
+		for _, fn := range shutdownFuncs {
+			err = errors.Join(err, fn(ctx))
+		}
+		shutdownFuncs = nil
+		return err
+	}
+
+	// handleErr calls shutdown for cleanup and makes sure that all errors are returned.
+	handleErr := func(inErr error) {
+		err = errors.Join(inErr, shutdown(ctx))
+	}
+
+	// Set up propagator. Trace context is still propagated when telemetry is disabled so
+	// that the traces of other services are not broken
+	prop := newPropagator()
+	otel.SetTextMapPropagator(prop)
+
+	if !cfg.Enabled {
+		// there is no otel handler to send logs to, json logs are still written
+		if cfg.LogFormat == LogFormatJSON || cfg.LogFormat == LogFormatTee {
+			slog.SetDefault(slog.New(newLogHandler(cfg, nil)))
+		}
+		return shutdown, nil
+	}
+	if cfg.Protocol != ProtocolGRPC {
+		return shutdown, fmt.Errorf(
+			"unsupported otlp protocol: %s, only %s is supported", cfg.Protocol, ProtocolGRPC,
+		)
+	}
+
+	// set up a resource
+	resource, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(serviceVersion),
+		),
+	)
+
+	if err != nil {
+		return shutdown, err
+	}
+
+	// Set up trace provider.
+	tracerProvider, err := newTracerProvider(ctx, cfg, resource)
+	if err != nil {
+		handleErr(err)
+		return shutdown, err
+	}
+	shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
+	otel.SetTracerProvider(tracerProvider)
+
+	//Set up meter provider.
+	meterProvider, err := newMeterProvider(ctx, cfg, resource)
+	if err != nil {
+		handleErr(err)
+		return shutdown, err
+	}
+	shutdownFuncs = append(shutdownFuncs, meterProvider.Shutdown)
+	otel.SetMeterProvider(meterProvider)
+
+	// Set up logger provider.
+	loggerProvider, err := newLoggerProvider(ctx, cfg, resource)
+	if err != nil {
+		handleErr(err)
+		return shutdown, err
+	}
+	shutdownFuncs = append(shutdownFuncs, loggerProvider.Shutdown)
+	global.SetLoggerProvider(loggerProvider)
+
+	// create a new slog logger that is backed by a handler that will send all logs that
+	// it receives to otel
+	defaultLogger := otelslog.NewLogger(
+		cfg.ServiceName,
+		otelslog.WithLoggerProvider(loggerProvider),
+	)
+	slog.SetDefault(slog.New(newLogHandler(cfg, defaultLogger.Handler())))
+
+	return shutdown, err
+}
+
+// build the handler of the default logger for the log format of the config, otelHandler is nil
+// when telemetry is disabled and then only json logs are written
+func newLogHandler(cfg TelemetryConfig, otelHandler slog.Handler) slog.Handler {
+	writer := cfg.LogWriter
+	if writer == nil {
+		writer = os.Stdout
+	}
+	// the otel bridge reads the trace context of a record from its context, the json handler
+	// needs the ids as attributes
+	jsonHandler := NewTraceContextHandler(slog.NewJSONHandler(writer, nil))
+	var handler slog.Handler
+	switch {
+	case otelHandler == nil || cfg.LogFormat == LogFormatJSON:
+		handler = jsonHandler
+	case cfg.LogFormat == LogFormatTee:
+		handler = NewTeeHandler(otelHandler, jsonHandler)
+	default:
+		handler = otelHandler
+	}
+	// tag the records of each request with the principal that the gateway authenticated
+	return NewPrincipalIdHandler(handler)
+}
+
+func newPropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+}
+
+func newTracerProvider(ctx context.Context, cfg TelemetryConfig, res *resource.Resource) (*trace.TracerProvider, error) {
+	traceExporter, err := otlptracegrpc.New(
+		ctx,
+		otlptracegrpc.WithEndpointURL(cfg.Endpoint),
+		otlptracegrpc.WithHeaders(cfg.Headers),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := trace.NewTracerProvider(
+		trace.WithResource(res),
+		trace.WithBatcher(traceExporter,
+			// Default is 5s. Set to 1s for demonstrative purposes.
+			trace.WithBatchTimeout(time.Second)),
+	)
+	return tracerProvider, nil
+}
+
+func newMeterProvider(ctx context.Context, cfg TelemetryConfig, res *resource.Resource) (*metric.MeterProvider, error) {
+	metricExporter, err := otlpmetricgrpc.New(
+		ctx,
+		otlpmetricgrpc.WithEndpointURL(cfg.Endpoint),
+		otlpmetricgrpc.WithHeaders(cfg.Headers),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	meterProvider := metric.NewMeterProvider(
+		metric.WithResource(res),
+		metric.WithReader(
+			metric.NewPeriodicReader(
+				metricExporter,
+				// Default is 1m. Set to 3s for demonstrative purposes.
+				metric.WithInterval(3*time.Second),
+			),
+		),
+	)
+	return meterProvider, nil
+}
+
+func newLoggerProvider(ctx context.Context, cfg TelemetryConfig, res *resource.Resource) (*log.LoggerProvider, error) {
+	// create a otlp grpc log exporter
+	logExporter, err := otlploggrpc.New(
+		ctx,
+		otlploggrpc.WithEndpointURL(cfg.Endpoint),
+		otlploggrpc.WithHeaders(cfg.Headers),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// create a stdout log exporter
+	stdOutLogExporter, err := stdoutlog.New()
+	if err != nil {
+		return nil, err
+	}
+
+	loggerProvider := log.NewLoggerProvider(
+		log.WithResource(res),
+		log.WithProcessor(log.NewBatchProcessor(logExporter)),
+		log.WithProcessor(log.NewBatchProcessor(stdOutLogExporter)),
+	)
+	return loggerProvider, nil
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	collectorlogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// a collector that records the metadata of each trace export and accepts every other export
+type fakeCollector struct {
+	collectortrace.UnimplementedTraceServiceServer
+	exports chan metadata.MD
+}
+
+func (c *fakeCollector) Export(
+	ctx context.Context, req *collectortrace.ExportTraceServiceRequest,
+) (*collectortrace.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.exports <- md
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+type fakeMetricsCollector struct {
+	collectormetrics.UnimplementedMetricsServiceServer
+}
+
+func (fakeMetricsCollector) Export(
+	context.Context, *collectormetrics.ExportMetricsServiceRequest,
+) (*collectormetrics.ExportMetricsServiceResponse, error) {
+	return &collectormetrics.ExportMetricsServiceResponse{}, nil
+}
+
+type fakeLogsCollector struct {
+	collectorlogs.UnimplementedLogsServiceServer
+}
+
+func (fakeLogsCollector) Export(
+	context.Context, *collectorlogs.ExportLogsServiceRequest,
+) (*collectorlogs.ExportLogsServiceResponse, error) {
+	return &collectorlogs.ExportLogsServiceResponse{}, nil
+}
+
+// SetupOTelSDK replaces the global providers and the default logger, restore them once the
+// test is done
+func restoreGlobals(t *testing.T) {
+	tracerProvider := otel.GetTracerProvider()
+	meterProvider := otel.GetMeterProvider()
+	logger := slog.Default()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetMeterProvider(meterProvider)
+		slog.SetDefault(logger)
+	})
+}
+
+func TestSetupOTelSDK_Disabled_Unit(t *testing.T) {
+	restoreGlobals(t)
+	// the endpoint is never dialed when telemetry is disabled
+	shutdown, err := SetupOTelSDK(t.Context(), TelemetryConfig{
+		Enabled: false,
+		ServiceName: "test-service",
+		Endpoint: "http://localhost:1",
+		Protocol: "unsupported",
+	})
+	if err != nil {
+		t.Fatalf("failed to set up disabled telemetry with error: %v", err)
+	}
+	if err := shutdown(t.Context()); err != nil {
+		t.Errorf("want a no-op shutdown when telemetry is disabled, got error: %v", err)
+	}
+}
+
+func TestSetupOTelSDK_UnsupportedProtocol_Unit(t *testing.T) {
+	restoreGlobals(t)
+	_, err := SetupOTelSDK(t.Context(), TelemetryConfig{
+		Enabled: true,
+		ServiceName: "test-service",
+		Endpoint: "http://localhost:1",
+		Protocol: "http/protobuf",
+	})
+	if err == nil {
+		t.Errorf("want an error for an unsupported protocol")
+	}
+}
+
+func TestSetupOTelSDK_ConfiguredEndpoint_Unit(t *testing.T) {
+	restoreGlobals(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen with error: %v", err)
+	}
+	collector := &fakeCollector{ exports: make(chan metadata.MD, 10) }
+	server := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(server, collector)
+	collectormetrics.RegisterMetricsServiceServer(server, fakeMetricsCollector{})
+	collectorlogs.RegisterLogsServiceServer(server, fakeLogsCollector{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	shutdown, err := SetupOTelSDK(t.Context(), TelemetryConfig{
+		Enabled: true,
+		ServiceName: "test-service",
+		Endpoint: "http://" + listener.Addr().String(),
+		Headers: map[string]string{ "x-test-header": "test-value" },
+		Protocol: ProtocolGRPC,
+	})
+	if err != nil {
+		t.Fatalf("failed to set up telemetry with error: %v", err)
+	}
+	_, span := otel.Tracer("test").Start(t.Context(), "test-span")
+	span.End()
+	// shutting down flushes the batched span to the collector
+	if err := shutdown(t.Context()); err != nil {
+		t.Fatalf("failed to shut down telemetry with error: %v", err)
+	}
+	select {
+	case md := <-collector.exports:
+		if values := md.Get("x-test-header"); len(values) != 1 || values[0] != "test-value" {
+			t.Errorf("want header x-test-header: test-value, got: %v", values)
+		}
+	default:
+		t.Errorf("want the span to be exported to the configured endpoint")
+	}
+}
+
+func TestParseHeaders_Unit(t *testing.T) {
+	headers, err := parseHeaders("api-key=secret, tenant = reed")
+	if err != nil {
+		t.Fatalf("failed to parse headers with error: %v", err)
+	}
+	if len(headers) != 2 || headers["api-key"] != "secret" || headers["tenant"] != "reed" {
+		t.Errorf("want headers api-key: secret and tenant: reed, got: %v", headers)
+	}
+	if _, err := parseHeaders("missing-value"); err == nil {
+		t.Errorf("want an error for a header without a value")
+	}
+}
+
+func TestSetupOTelSDK_JSONLogFormat_Unit(t *testing.T) {
+	restoreGlobals(t)
+	var buf bytes.Buffer
+	_, err := SetupOTelSDK(t.Context(), TelemetryConfig{
+		Enabled: false,
+		ServiceName: "test-service",
+		LogFormat: LogFormatJSON,
+		LogWriter: &buf,
+	})
+	if err != nil {
+		t.Fatalf("failed to set up disabled telemetry with error: %v", err)
+	}
+	spanContext := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID: oteltrace.TraceID{ 1, 2, 3 },
+		SpanID: oteltrace.SpanID{ 4, 5, 6 },
+	})
+	slog.InfoContext(oteltrace.ContextWithSpanContext(t.Context(), spanContext), "json record")
+	// the record is written to the log writer even though telemetry is disabled
+	var record map[string]any
+	if err := json.NewDecoder(&buf).Decode(&record); err != nil {
+		t.Fatalf("failed to decode the json log record with error: %v", err)
+	}
+	if record["msg"] != "json record" {
+		t.Errorf("want msg: json record, got: %v", record["msg"])
+	}
+	if record["traceId"] == nil || record["spanId"] != spanContext.SpanID().String() {
+		t.Errorf("want the trace id and span id of the span as attributes, got: %v", record)
+	}
+}
+
+func TestGetTelemetryConfig_UnknownLogFormat_Unit(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "xml")
+	if _, err := GetTelemetryConfig("test-service"); err == nil {
+		t.Errorf("want an error for an unknown log format")
+	}
+}
+
+func TestGetTelemetryConfig_ServiceName_Unit(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	cfg, err := GetTelemetryConfig("document-service")
+	if err != nil {
+		t.Fatalf("failed to read the telemetry config: %v", err)
+	}
+	if cfg.ServiceName != "document-service" {
+		t.Errorf("want the default service name of the caller, got: %s", cfg.ServiceName)
+	}
+	t.Setenv("OTEL_SERVICE_NAME", "renamed-service")
+	cfg, err = GetTelemetryConfig("document-service")
+	if err != nil {
+		t.Fatalf("failed to read the telemetry config: %v", err)
+	}
+	if cfg.ServiceName != "renamed-service" {
+		t.Errorf("want OTEL_SERVICE_NAME to override the default, got: %s", cfg.ServiceName)
+	}
+}
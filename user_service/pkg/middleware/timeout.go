@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// bound how long a handler can run when the caller sent no deadline or a deadline that is
+// further away than maxTimeout. Without this a caller that sends no deadline, like an internal
+// job or a buggy client, lets a slow query hold a database connection for as long as it takes.
+// A handler that fails after the imposed deadline has passed returns DeadlineExceeded, whatever
+// error the handler returned. A maxTimeout of zero disables the interceptor
+func TimeoutInterceptor(maxTimeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		if maxTimeout <= 0 {
+			return handler(ctx, req)
+		}
+		// the deadline of the caller is kept when it is sooner than the max
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= maxTimeout {
+			return handler(ctx, req)
+		}
+		ctx, cancel := context.WithTimeout(ctx, maxTimeout)
+		defer cancel()
+		resp, err = handler(ctx, req)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			slog.WarnContext(
+				ctx, "handler exceeded the max handler timeout",
+				"method", info.FullMethod, "maxTimeout", maxTimeout.String(), "error", err,
+			)
+			return nil, status.Errorf(
+				codes.DeadlineExceeded, "%s did not finish within %v", info.FullMethod, maxTimeout,
+			)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// a handler that sleeps for the given duration unless its context is done first, like a slow
+// query that is cancelled with its context
+func sleepingHandler(sleep time.Duration) grpc.UnaryHandler {
+	return func(ctx context.Context, req any) (any, error) {
+		select {
+		case <-time.After(sleep):
+			return "done", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func TestTimeoutInterceptor_NoDeadline_Unit(t *testing.T) {
+	interceptor := TimeoutInterceptor(50 * time.Millisecond)
+	info := &grpc.UnaryServerInfo{ FullMethod: "/test.Service/Slow" }
+	start := time.Now()
+	// the caller sends no deadline and the handler sleeps past the max
+	_, err := interceptor(context.Background(), nil, info, sleepingHandler(5 * time.Second))
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("want: %v from a handler that runs past the max, got: %v", codes.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("want the handler to be cancelled at the max timeout, it ran for: %v", elapsed)
+	}
+	// a handler that finishes within the max is unaffected
+	resp, err := interceptor(context.Background(), nil, info, sleepingHandler(time.Millisecond))
+	if err != nil || resp != "done" {
+		t.Errorf("want a fast handler to succeed, got: %v with error: %v", resp, err)
+	}
+}
+
+func TestTimeoutInterceptor_CallerDeadline_Unit(t *testing.T) {
+	interceptor := TimeoutInterceptor(50 * time.Millisecond)
+	info := &grpc.UnaryServerInfo{ FullMethod: "/test.Service/Slow" }
+	// a deadline further away than the max is lowered to the max
+	ctx, cancel := context.WithTimeout(t.Context(), time.Hour)
+	defer cancel()
+	_, err := interceptor(ctx, nil, info, sleepingHandler(5 * time.Second))
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("want: %v when the deadline of the caller is beyond the max, got: %v", codes.DeadlineExceeded, err)
+	}
+	// a sooner deadline of the caller is kept, the interceptor leaves the error of the handler alone
+	ctx, cancel = context.WithTimeout(t.Context(), 10 * time.Millisecond)
+	defer cancel()
+	_, err = interceptor(ctx, nil, info, sleepingHandler(5 * time.Second))
+	if err != context.DeadlineExceeded {
+		t.Errorf("want the handler to see the deadline of the caller, got: %v", err)
+	}
+}
+
+func TestTimeoutInterceptor_Disabled_Unit(t *testing.T) {
+	interceptor := TimeoutInterceptor(0)
+	info := &grpc.UnaryServerInfo{ FullMethod: "/test.Service/Slow" }
+	resp, err := interceptor(context.Background(), nil, info, sleepingHandler(100 * time.Millisecond))
+	if err != nil || resp != "done" {
+		t.Errorf("want a max of zero to leave the handler unbounded, got: %v with error: %v", resp, err)
+	}
+}
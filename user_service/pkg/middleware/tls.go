@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// the tls versions that the gRPC servers can be pinned to, older versions are never accepted
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// the cipher suites that are used for tls 1.2 connections when none are configured, only
+// forward secret AEAD suites. Tls 1.3 suites are not configurable and are all considered secure
+var DefaultCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// parse the minimum tls version, either 1.2 or 1.3
+func ParseTLSVersion(raw string) (uint16, error) {
+	version, ok := tlsVersions[strings.TrimSpace(raw)]
+	if !ok {
+		return 0, fmt.Errorf("expected a tls version of 1.2 or 1.3, got: %q", raw)
+	}
+	return version, nil
+}
+
+// parse a comma separated list of cipher suite names, for example
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. The suites that go considers insecure are rejected, an
+// empty list is the default cipher suites
+func ParseCipherSuites(raw string) ([]uint16, error) {
+	if strings.TrimSpace(raw) == "" {
+		return DefaultCipherSuites, nil
+	}
+	secure := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+	var suites []uint16
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		id, ok := secure[name]
+		if !ok {
+			return nil, fmt.Errorf("expected the name of a secure cipher suite, got: %q", name)
+		}
+		suites = append(suites, id)
+	}
+	return suites, nil
+}
+
+// load the certificate and key of the server and apply the version floor and the cipher policy.
+// The cipher suites only apply to tls 1.2 connections
+func LoadServerTLSConfig(
+	certFile string,
+	keyFile string,
+	minVersion uint16,
+	cipherSuites []uint16,
+) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the tls certificate and key: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{ certificate },
+		MinVersion: minVersion,
+		CipherSuites: cipherSuites,
+	}, nil
+}
+
+// the tls configuration of a client of the gRPC servers. The certificate of the server is
+// verified against the certificates in caFile, or against the system roots when caFile is empty.
+// serverName is the name that the certificate must be issued for, the host of the address that
+// is dialed is used when it is empty. The floor is tls 1.2 so that the client can reach a server
+// pinned to either version
+func LoadClientTLSConfig(caFile string, serverName string) (*tls.Config, error) {
+	clientConfig := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if caFile == "" {
+		return clientConfig, nil
+	}
+	caPem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the tls ca file: %w", err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caPem) {
+		return nil, fmt.Errorf("expected at least one pem encoded certificate in the tls ca file: %s", caFile)
+	}
+	clientConfig.RootCAs = rootCAs
+	return clientConfig, nil
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// write a self signed certificate for localhost and its key to the test directory, returns the
+// paths of the certificate and the key
+func writeTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key with error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{ CommonName: "localhost" },
+		DNSNames: []string{ "localhost" },
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter: time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate with error: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key with error: %v", err)
+	}
+	certFile := filepath.Join(t.TempDir(), "cert.pem")
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	certPem := pem.EncodeToMemory(&pem.Block{ Type: "CERTIFICATE", Bytes: der })
+	keyPem := pem.EncodeToMemory(&pem.Block{ Type: "EC PRIVATE KEY", Bytes: keyDer })
+	if err := os.WriteFile(certFile, certPem, 0600); err != nil {
+		t.Fatalf("failed to write certificate with error: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPem, 0600); err != nil {
+		t.Fatalf("failed to write key with error: %v", err)
+	}
+	return certFile, keyFile
+}
+
+// serve the health service over tls with the server config, returns the address of the server
+func serveTestTLSServer(t *testing.T, serverConfig *tls.Config) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen with error: %v", err)
+	}
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverConfig)))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+// make a gRPC call to the server with the transport credentials of the client
+func checkTestTLSServer(t *testing.T, addr string, clientCredentials credentials.TransportCredentials) error {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(clientCredentials))
+	if err != nil {
+		t.Fatalf("failed to create client with error: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestTLS_GRPCClientAndServer_Unit(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	minVersion, err := ParseTLSVersion("1.3")
+	if err != nil {
+		t.Fatalf("failed to parse tls version with error: %v", err)
+	}
+	serverConfig, err := LoadServerTLSConfig(certFile, keyFile, minVersion, DefaultCipherSuites)
+	if err != nil {
+		t.Fatalf("failed to load server tls config with error: %v", err)
+	}
+	addr := serveTestTLSServer(t, serverConfig)
+	clientConfig, err := LoadClientTLSConfig(certFile, "localhost")
+	if err != nil {
+		t.Fatalf("failed to load client tls config with error: %v", err)
+	}
+	if err = checkTestTLSServer(t, addr, credentials.NewTLS(clientConfig)); err != nil {
+		t.Fatalf("want a client that trusts the server certificate to connect, got error: %v", err)
+	}
+	// the self signed certificate is not in the system roots
+	systemRootsConfig, err := LoadClientTLSConfig("", "localhost")
+	if err != nil {
+		t.Fatalf("failed to load client tls config with error: %v", err)
+	}
+	wrongNameConfig, err := LoadClientTLSConfig(certFile, "user-service.example")
+	if err != nil {
+		t.Fatalf("failed to load client tls config with error: %v", err)
+	}
+	// the server is pinned to tls 1.3
+	oldVersionConfig := clientConfig.Clone()
+	oldVersionConfig.MaxVersion = tls.VersionTLS12
+	rejected := map[string]credentials.TransportCredentials{
+		"untrusted certificate": credentials.NewTLS(systemRootsConfig),
+		"wrong server name": credentials.NewTLS(wrongNameConfig),
+		"tls 1.2 client": credentials.NewTLS(oldVersionConfig),
+		"plaintext client": insecure.NewCredentials(),
+	}
+	for name, clientCredentials := range rejected {
+		if err = checkTestTLSServer(t, addr, clientCredentials); status.Code(err) != codes.Unavailable {
+			t.Errorf("want the %s to be rejected with: %v, got: %v", name, codes.Unavailable, err)
+		}
+	}
+}
+
+func TestLoadClientTLSConfig_InvalidCAFile_Unit(t *testing.T) {
+	if _, err := LoadClientTLSConfig(filepath.Join(t.TempDir(), "missing.pem"), ""); err == nil {
+		t.Errorf("want an error for a missing ca file")
+	}
+	notPem := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPem, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("failed to write ca file with error: %v", err)
+	}
+	if _, err := LoadClientTLSConfig(notPem, ""); err == nil {
+		t.Errorf("want an error for a ca file without a certificate")
+	}
+}
+
+func TestParseTLSVersion_Unit(t *testing.T) {
+	for _, raw := range []string{ "1.0", "1.1", "", "tls1.3" } {
+		if _, err := ParseTLSVersion(raw); err == nil {
+			t.Errorf("want an error for tls version: %q", raw)
+		}
+	}
+}
+
+func TestParseCipherSuites_Unit(t *testing.T) {
+	suites, err := ParseCipherSuites("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	if err != nil {
+		t.Fatalf("failed to parse cipher suites with error: %v", err)
+	}
+	if len(suites) != 2 || suites[0] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("want the two configured suites in order, got: %v", suites)
+	}
+	// weak suites are rejected even though go can negotiate them
+	if _, err := ParseCipherSuites("TLS_RSA_WITH_RC4_128_SHA"); err == nil {
+		t.Errorf("want an error for an insecure cipher suite")
+	}
+	if suites, err := ParseCipherSuites(""); err != nil || len(suites) != len(DefaultCipherSuites) {
+		t.Errorf("want the default cipher suites for an empty list, got: %v with error: %v", suites, err)
+	}
+}